@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/milosgajdos83/gosom/som"
+)
+
+const (
+	// parquetBatchRows is the number of rows of Arrow records read from Parquet files
+	parquetBatchRows = 4096
+	// tableRows is the number of prediction rows written in one Arrow record or Parquet row group
+	tableRows = 65536
+)
+
+// recordReader reads Arrow records one at a time. It is implemented by Arrow IPC stream readers
+// and Parquet record readers.
+type recordReader interface {
+	Next() bool
+	Record() arrow.Record
+	Err() error
+	Release()
+}
+
+// arrowRows reads rows of Arrow records as data rows. Values of numeric columns are converted
+// to floats and nulls to NaN, which predictions treat as missing values.
+type arrowRows struct {
+	rr  recordReader
+	rec arrow.Record
+	// i is the index of the next row of rec
+	i   int
+	row []float64
+}
+
+// Next returns the next record row converted to floats or io.EOF if there are no more records
+func (a *arrowRows) Next() ([]float64, error) {
+	for a.rec == nil || a.i >= int(a.rec.NumRows()) {
+		if !a.rr.Next() {
+			if err := a.rr.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		a.rec, a.i = a.rr.Record(), 0
+	}
+	cols := int(a.rec.NumCols())
+	if cap(a.row) < cols {
+		a.row = make([]float64, cols)
+	}
+	a.row = a.row[:cols]
+	for j := range a.row {
+		v, err := arrowValue(a.rec.Column(j), a.i)
+		if err != nil {
+			return nil, fmt.Errorf("invalid column %s: %s", a.rec.ColumnName(j), err)
+		}
+		a.row[j] = v
+	}
+	a.i++
+	return a.row, nil
+}
+
+// arrowValue returns value i of numeric Arrow array col as float or NaN if the value is null.
+// It returns error if col is not numeric.
+func arrowValue(col arrow.Array, i int) (float64, error) {
+	if col.IsNull(i) {
+		return math.NaN(), nil
+	}
+	switch c := col.(type) {
+	case *array.Float64:
+		return c.Value(i), nil
+	case *array.Float32:
+		return float64(c.Value(i)), nil
+	case *array.Int64:
+		return float64(c.Value(i)), nil
+	case *array.Int32:
+		return float64(c.Value(i)), nil
+	case *array.Int16:
+		return float64(c.Value(i)), nil
+	case *array.Int8:
+		return float64(c.Value(i)), nil
+	case *array.Uint64:
+		return float64(c.Value(i)), nil
+	case *array.Uint32:
+		return float64(c.Value(i)), nil
+	case *array.Uint16:
+		return float64(c.Value(i)), nil
+	case *array.Uint8:
+		return float64(c.Value(i)), nil
+	}
+	return 0, fmt.Errorf("unsupported type: %s", col.DataType())
+}
+
+// openRows opens data file in path as a source of data rows and returns the source and a function
+// which closes the file. Files with .arrow or .arrows extension are read as Arrow IPC streams and files
+// with .parquet extension as Parquet files; all their columns must be numeric. Other files are read as CSV.
+func openRows(path string) (som.RowSource, func() error, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".arrow", ".arrows":
+		in, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		rr, err := ipc.NewReader(in, ipc.WithAllocator(memory.DefaultAllocator))
+		if err != nil {
+			in.Close()
+			return nil, nil, fmt.Errorf("invalid arrow file %s: %s", path, err)
+		}
+		return &arrowRows{rr: rr}, func() error {
+			rr.Release()
+			return in.Close()
+		}, nil
+	case ".parquet":
+		pf, err := file.OpenParquetFile(path, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid parquet file %s: %s", path, err)
+		}
+		fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{BatchSize: parquetBatchRows}, memory.DefaultAllocator)
+		if err != nil {
+			pf.Close()
+			return nil, nil, fmt.Errorf("invalid parquet file %s: %s", path, err)
+		}
+		rr, err := fr.GetRecordReader(context.Background(), nil, nil)
+		if err != nil {
+			pf.Close()
+			return nil, nil, fmt.Errorf("invalid parquet file %s: %s", path, err)
+		}
+		return &arrowRows{rr: rr}, func() error {
+			rr.Release()
+			return pf.Close()
+		}, nil
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &csvRows{r: csv.NewReader(in)}, in.Close, nil
+}
+
+// predictionTable builds Arrow records of predictions: row index, BMU index and BMU distance of every
+// data row and, if the map units are clustered, the BMU cluster and, if the map has an anomaly threshold,
+// whether the row is an anomaly.
+type predictionTable struct {
+	// clusters holds cluster ids of map units or nil if the units are not clustered
+	clusters []int
+	// threshold is the map anomaly threshold or NaN if the map has none
+	threshold float64
+	b         *array.RecordBuilder
+}
+
+// newPredictionTable creates prediction table of map m
+func newPredictionTable(m *som.Map) *predictionTable {
+	t := &predictionTable{clusters: m.Clusters(), threshold: m.AnomalyThreshold()}
+	fields := []arrow.Field{
+		{Name: "row", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "unit", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "distance", Type: arrow.PrimitiveTypes.Float64},
+	}
+	if t.clusters != nil {
+		fields = append(fields, arrow.Field{Name: "cluster", Type: arrow.PrimitiveTypes.Int64})
+	}
+	if !math.IsNaN(t.threshold) {
+		fields = append(fields, arrow.Field{Name: "anomaly", Type: arrow.FixedWidthTypes.Boolean})
+	}
+	t.b = array.NewRecordBuilder(memory.DefaultAllocator, arrow.NewSchema(fields, nil))
+	return t
+}
+
+// schema returns the schema of prediction records
+func (t *predictionTable) schema() *arrow.Schema {
+	return t.b.Schema()
+}
+
+// append appends predictions of a batch of data rows which starts at row first
+func (t *predictionTable) append(first int, bmus []int, dists []float64) {
+	for j, bmu := range bmus {
+		t.b.Field(0).(*array.Int64Builder).Append(int64(first + j))
+		t.b.Field(1).(*array.Int64Builder).Append(int64(bmu))
+		t.b.Field(2).(*array.Float64Builder).Append(dists[j])
+		field := 3
+		if t.clusters != nil {
+			t.b.Field(field).(*array.Int64Builder).Append(int64(t.clusters[bmu]))
+			field++
+		}
+		if !math.IsNaN(t.threshold) {
+			t.b.Field(field).(*array.BooleanBuilder).Append(dists[j] > t.threshold)
+		}
+	}
+}
+
+// rows returns the number of appended predictions which have not been flushed
+func (t *predictionTable) rows() int {
+	return t.b.Field(0).Len()
+}
+
+// flush passes the appended predictions as a record to write unless there are none
+func (t *predictionTable) flush(write func(arrow.Record) error) error {
+	if t.rows() == 0 {
+		return nil
+	}
+	rec := t.b.NewRecord()
+	defer rec.Release()
+	return write(rec)
+}
+
+// predictTable finds BMUs of rows of src by map m using workers goroutines and writes the predictions
+// to w as Arrow IPC stream if format is arrow or as Parquet file if format is parquet
+func predictTable(m *som.Map, src som.RowSource, w io.Writer, format string, workers int) error {
+	t := newPredictionTable(m)
+	defer t.b.Release()
+	var write func(arrow.Record) error
+	var closeWriter func() error
+	switch format {
+	case "arrow":
+		iw := ipc.NewWriter(w, ipc.WithSchema(t.schema()), ipc.WithAllocator(memory.DefaultAllocator))
+		write, closeWriter = iw.Write, iw.Close
+	case "parquet":
+		// the file writer closes sinks which are io.Closer, but w is closed by the caller
+		sink := struct{ io.Writer }{w}
+		fw, err := pqarrow.NewFileWriter(t.schema(), sink, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
+		if err != nil {
+			return err
+		}
+		write, closeWriter = fw.Write, fw.Close
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+	err := m.PredictBatches(src, workers, func(first int, bmus []int, dists []float64) error {
+		t.append(first, bmus, dists)
+		if t.rows() < tableRows {
+			return nil
+		}
+		return t.flush(write)
+	})
+	if err == nil {
+		err = t.flush(write)
+	}
+	if closeErr := closeWriter(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/stretchr/testify/assert"
+)
+
+// csvRecord reads CSV file in path as Arrow record of float64 columns x0, x1 and so on
+func csvRecord(t *testing.T, path string) arrow.Record {
+	in, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	records, err := csv.NewReader(in).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := make([]arrow.Field, len(records[0]))
+	for j := range fields {
+		fields[j] = arrow.Field{Name: "x" + strconv.Itoa(j), Type: arrow.PrimitiveTypes.Float64}
+	}
+	b := array.NewRecordBuilder(memory.DefaultAllocator, arrow.NewSchema(fields, nil))
+	defer b.Release()
+	for _, record := range records {
+		for j, field := range record {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.Field(j).(*array.Float64Builder).Append(v)
+		}
+	}
+	return b.NewRecord()
+}
+
+// readUnits returns the unit column of prediction records read by rr
+func readUnits(t *testing.T, rr recordReader) []int {
+	defer rr.Release()
+	var units []int
+	for rr.Next() {
+		rec := rr.Record()
+		idx := rec.Schema().FieldIndices("unit")
+		col := rec.Column(idx[0]).(*array.Int64)
+		for i := 0; i < col.Len(); i++ {
+			units = append(units, int(col.Value(i)))
+		}
+	}
+	if err := rr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return units
+}
+
+func TestPredictArrowParquet(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "data.csv")
+	writeCSV(t, dataPath, 40, 3)
+	modelPath := filepath.Join(dir, "model.bin")
+	status, _, stderr := runCmd("train", "-data", dataPath, "-iters", "5", "-out", modelPath)
+	assert.Equal(0, status, stderr)
+	m, err := loadModel(modelPath)
+	assert.NoError(err)
+	src, closeSrc, err := openRows(dataPath)
+	assert.NoError(err)
+	var want []int
+	for {
+		row, err := src.Next()
+		if err != nil {
+			break
+		}
+		bmu, _, err := m.BMU(row)
+		assert.NoError(err)
+		want = append(want, bmu)
+	}
+	assert.NoError(closeSrc())
+
+	// write the data as Arrow IPC stream and Parquet file
+	rec := csvRecord(t, dataPath)
+	defer rec.Release()
+	arrowPath := filepath.Join(dir, "data.arrows")
+	out, err := os.Create(arrowPath)
+	assert.NoError(err)
+	iw := ipc.NewWriter(out, ipc.WithSchema(rec.Schema()))
+	assert.NoError(iw.Write(rec))
+	assert.NoError(iw.Close())
+	assert.NoError(out.Close())
+	parquetPath := filepath.Join(dir, "data.parquet")
+	out, err = os.Create(parquetPath)
+	assert.NoError(err)
+	fw, err := pqarrow.NewFileWriter(rec.Schema(), out, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
+	assert.NoError(err)
+	assert.NoError(fw.Write(rec))
+	assert.NoError(fw.Close())
+
+	for _, input := range []string{arrowPath, parquetPath} {
+		// Arrow output
+		outPath := filepath.Join(dir, "bmus.arrows")
+		status, _, stderr = runCmd("predict", "-model", modelPath, "-data", input, "-format", "arrow", "-out", outPath)
+		assert.Equal(0, status, stderr)
+		in, err := os.Open(outPath)
+		assert.NoError(err)
+		rr, err := ipc.NewReader(in)
+		assert.NoError(err)
+		assert.Equal([]string{"row", "unit", "distance"}, fieldNames(rr.Schema()))
+		assert.Equal(want, readUnits(t, rr), input)
+		in.Close()
+		// Parquet output
+		outPath = filepath.Join(dir, "bmus.parquet")
+		status, _, stderr = runCmd("predict", "-model", modelPath, "-data", input, "-format", "parquet", "-out", outPath)
+		assert.Equal(0, status, stderr)
+		pf, err := file.OpenParquetFile(outPath, false)
+		assert.NoError(err)
+		fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{BatchSize: 16}, memory.DefaultAllocator)
+		assert.NoError(err)
+		prr, err := fr.GetRecordReader(context.Background(), nil, nil)
+		assert.NoError(err)
+		assert.Equal(want, readUnits(t, prr), input)
+		pf.Close()
+	}
+
+	// clusters and anomalies of clustered maps with anomaly threshold are written
+	_, err = m.ClusterKMeans(2, 1, 10)
+	assert.NoError(err)
+	assert.NoError(m.SetAnomalyThreshold(1.0))
+	table := newPredictionTable(m)
+	defer table.b.Release()
+	assert.Equal([]string{"row", "unit", "distance", "cluster", "anomaly"}, fieldNames(table.schema()))
+	table.append(0, []int{want[0]}, []float64{2.0})
+	assert.Equal(1, table.rows())
+	assert.NoError(table.flush(func(rec arrow.Record) error {
+		assert.Equal(int64(m.Clusters()[want[0]]), rec.Column(3).(*array.Int64).Value(0))
+		assert.True(rec.Column(4).(*array.Boolean).Value(0))
+		return nil
+	}))
+	assert.Equal(0, table.rows())
+	// other formats are not written as tables
+	assert.Error(predictTable(m, src, out, "xml", 1))
+}
+
+// fieldNames returns names of schema fields
+func fieldNames(schema *arrow.Schema) []string {
+	names := make([]string, schema.NumFields())
+	for i := range names {
+		names[i] = schema.Field(i).Name
+	}
+	return names
+}
@@ -0,0 +1,116 @@
+// Command gosom trains Self-Organizing Maps, predicts Best Match Units of new data
+// and renders trained maps.
+//
+// Usage:
+//
+//	gosom train -data data.csv [-config cfg.json] [-iters n] [-umatrix umatrix.svg] -out model.bin
+//	gosom predict -model model.bin -data new.csv|new.arrows|new.parquet [-out bmus.csv] [-format csv|ndjson|arrow|parquet]
+//	gosom render -model model.bin [-umatrix umatrix.svg] [-qerror qerror.svg] [-data data.csv] [-planes planes/]
+//
+// Map and training flags of train, such as -dims, -grid, -algorithm, -radius and -lrate,
+// override the config file fields of the same names.
+//
+// Data of predict is read from CSV, Arrow IPC stream (.arrow, .arrows) or Parquet (.parquet) files.
+// Arrow and Parquet output holds the row, unit and distance of every data row and, if the model
+// units are clustered or the model has an anomaly threshold, the unit cluster and anomaly flag.
+//
+// Errors are reported on stderr as a single line prefixed with "gosom: ".
+// The command exits with status 1 if the command fails and with status 2 if it is used incorrectly.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	cliname = "gosom"
+	// exitFailure is returned when the command fails
+	exitFailure = 1
+	// exitUsage is returned when the command is used incorrectly
+	exitUsage = 2
+)
+
+// errUsage is returned when the command is used incorrectly
+var errUsage = errors.New("invalid usage")
+
+// commands maps subcommands to their implementations
+var commands = map[string]func(args []string, stdout, stderr io.Writer) error{
+	"train":   trainCmd,
+	"predict": predictCmd,
+	"render":  renderCmd,
+}
+
+const usage = `usage: gosom <command> [flags]
+
+commands:
+  train    train a new map and save the model
+  predict  write Best Match Units of data rows
+  render   render u-matrix and component planes of a model
+
+Run gosom <command> -h to see the command flags.
+`
+
+// run runs the subcommand in args and returns the exit status
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "%s: unknown command: %s\n", cliname, args[0])
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	if err := cmd(args[1:], stdout, stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(stderr, "%s: %s: %s\n", cliname, args[0], err)
+		if errors.Is(err, errUsage) {
+			return exitUsage
+		}
+		return exitFailure
+	}
+	return 0
+}
+
+// newFlagSet creates flag set of subcommand name which reports errors to stderr
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(cliname+" "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseFlags parses args using fs and returns errUsage if args are invalid
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %s", errUsage, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected arguments: %v", errUsage, fs.Args())
+	}
+	return nil
+}
+
+// required returns errUsage if the value of any of the flags is empty.
+// Flags are given as flag name and value pairs.
+func required(flags ...string) error {
+	for i := 0; i+1 < len(flags); i += 2 {
+		if flags[i+1] == "" {
+			return fmt.Errorf("%w: missing -%s flag", errUsage, flags[i])
+		}
+	}
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos83/gosom/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCSV writes rows x cols random data matrix to CSV file in path
+func writeCSV(t *testing.T, path string, rows, cols int) {
+	data, err := matrix.MakeRandom(rows, cols, -10.0, 10.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	for i := 0; i < rows; i++ {
+		record := make([]string, cols)
+		for j, v := range data.RawRowView(i) {
+			record[j] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runCmd runs gosom with args and returns exit status, stdout and stderr
+func runCmd(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestTrainPredictRender(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "data.csv")
+	writeCSV(t, dataPath, 50, 3)
+	cfgPath := filepath.Join(dir, "cfg.json")
+	cfg := `{"dims": [5, 4], "ushape": "rectangle", "algorithm": "batch", "neighb": "bubble", "iters": 10}`
+	assert.NoError(os.WriteFile(cfgPath, []byte(cfg), 0644))
+	modelPath := filepath.Join(dir, "model.bin")
+
+	status, stdout, stderr := runCmd("train", "-data", dataPath, "-config", cfgPath, "-out", modelPath)
+	assert.Equal(0, status, stderr)
+	assert.Empty(stderr)
+	assert.Contains(stdout, "dims: 5x4\nunit shape: rectangle\n")
+	assert.Contains(stdout, "training: batch, 10 iterations\n")
+	assert.Contains(stdout, "quantization error: ")
+	assert.Contains(stdout, "topographic error: ")
+	// radius is derived from map dimensions
+	assert.Contains(stdout, "initial radius: 2.5000\n")
+
+	bmusPath := filepath.Join(dir, "bmus.csv")
+	status, _, stderr = runCmd("predict", "-model", modelPath, "-data", dataPath, "-out", bmusPath)
+	assert.Equal(0, status, stderr)
+	out, err := os.ReadFile(bmusPath)
+	assert.NoError(err)
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	assert.Len(lines, 51)
+	assert.Equal("row,unit,distance", lines[0])
+	// predictions of the loaded model match the model
+	m, err := loadModel(modelPath)
+	assert.NoError(err)
+	units, dim := m.Codebook().Dims()
+	assert.Equal(20, units)
+	assert.Equal(3, dim)
+	status, stdout, _ = runCmd("predict", "-model", modelPath, "-data", dataPath, "-format", "ndjson")
+	assert.Equal(0, status)
+	assert.Len(strings.Split(strings.TrimSpace(stdout), "\n"), 50)
+	assert.True(strings.HasPrefix(stdout, `{"row":0,"unit":`))
+
+	umatrixPath := filepath.Join(dir, "umatrix.svg")
+	planesDir := filepath.Join(dir, "planes")
+	status, _, stderr = runCmd("render", "-model", modelPath, "-umatrix", umatrixPath, "-planes", planesDir)
+	assert.Equal(0, status, stderr)
+	svg, err := os.ReadFile(umatrixPath)
+	assert.NoError(err)
+	assert.Contains(string(svg), "<polygon")
+	pngPath := filepath.Join(dir, "umatrix.png")
+	status, _, stderr = runCmd("render", "-model", modelPath, "-umatrix", pngPath)
+	assert.Equal(0, status, stderr)
+	file, err := os.Open(pngPath)
+	assert.NoError(err)
+	_, err = png.Decode(file)
+	assert.NoError(err)
+	file.Close()
+	// hit markers of data units are drawn over the u-matrix
+	hitsPath := filepath.Join(dir, "hits.svg")
+	status, _, stderr = runCmd("render", "-model", modelPath, "-umatrix", hitsPath, "-data", dataPath)
+	assert.Equal(0, status, stderr)
+	svg, err = os.ReadFile(hitsPath)
+	assert.NoError(err)
+	assert.True(strings.Count(string(svg), "<polygon") > 20)
+	assert.Contains(string(svg), `fill="rgb(255,0,0)"`)
+	// quantization error map of data
+	qerrorPath := filepath.Join(dir, "qerror.svg")
+	status, _, stderr = runCmd("render", "-model", modelPath, "-qerror", qerrorPath, "-data", dataPath)
+	assert.Equal(0, status, stderr)
+	svg, err = os.ReadFile(qerrorPath)
+	assert.NoError(err)
+	assert.Equal(20, strings.Count(string(svg), "<polygon"))
+	for i := 0; i < 3; i++ {
+		plane, err := os.ReadFile(filepath.Join(planesDir, "plane-"+strconv.Itoa(i)+".svg"))
+		assert.NoError(err)
+		assert.Equal(20, strings.Count(string(plane), "<polygon"))
+	}
+}
+
+func TestTrainDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "data.csv")
+	writeCSV(t, dataPath, 30, 2)
+	modelPath := filepath.Join(dir, "model.bin")
+	status, stdout, stderr := runCmd("train", "-data", dataPath, "-out", modelPath, "-iters", "5")
+	assert.Equal(0, status, stderr)
+	assert.Contains(stdout, "training: batch, 5 iterations\n")
+	_, err := os.Stat(modelPath)
+	assert.NoError(err)
+	// warnings are reported on stderr
+	cfgPath := filepath.Join(dir, "cfg.json")
+	assert.NoError(os.WriteFile(cfgPath, []byte(`{"algorithm": "seq", "lrate": 2.0}`), 0644))
+	status, _, stderr = runCmd("train", "-data", dataPath, "-config", cfgPath, "-out", modelPath, "-iters", "5")
+	assert.Equal(0, status)
+	assert.Contains(stderr, "gosom: train: warning: learning rate 2.000000 is larger than 1\n")
+	assert.Contains(stderr, "gosom: train: warning: 5 sequential training iterations don't visit all 30 data samples\n")
+	// verbose training logs progress and warnings once
+	status, _, stderr = runCmd("train", "-data", dataPath, "-config", cfgPath, "-out", modelPath, "-iters", "5", "-v")
+	assert.Equal(0, status)
+	assert.Contains(stderr, "gosom: train: som: seq training started: 5 iterations")
+	assert.Contains(stderr, "gosom: train: som: seq training: iteration 5/5: radius 1.0000")
+	assert.Equal(1, strings.Count(stderr, "learning rate 2.000000 is larger than 1"))
+	// training phases replace the number of iterations
+	phases := `{"algorithm": "seq", "phases": [{"iters": 60, "radius": 2, "lrate": 0.5}, {"iters": 30, "radius": 1, "lrate": 0.05}]}`
+	assert.NoError(os.WriteFile(cfgPath, []byte(phases), 0644))
+	status, stdout, stderr = runCmd("train", "-data", dataPath, "-config", cfgPath, "-out", modelPath, "-iters", "5")
+	assert.Equal(0, status, stderr)
+	assert.Contains(stdout, "training: seq, 90 iterations\n")
+	assert.Contains(stdout, "initial radius: 2.0000\n")
+}
+
+func TestTrainFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "data.csv")
+	writeCSV(t, dataPath, 30, 2)
+	cfgPath := filepath.Join(dir, "cfg.json")
+	assert.NoError(os.WriteFile(cfgPath, []byte(`{"dims": [5, 4], "algorithm": "seq", "radius": 3}`), 0644))
+	modelPath := filepath.Join(dir, "model.bin")
+	umatrixPath := filepath.Join(dir, "umatrix.png")
+	// flags override the config file fields
+	status, stdout, stderr := runCmd("train", "-data", dataPath, "-config", cfgPath, "-dims", "3,2",
+		"-ushape", "rectangle", "-algorithm", "batch", "-iters", "5", "-umatrix", umatrixPath, "-out", modelPath)
+	assert.Equal(0, status, stderr)
+	assert.Contains(stdout, "dims: 3x2\nunit shape: rectangle\n")
+	assert.Contains(stdout, "training: batch, 5 iterations\n")
+	assert.Contains(stdout, "initial radius: 3.0000\n")
+	file, err := os.Open(umatrixPath)
+	assert.NoError(err)
+	_, err = png.Decode(file)
+	assert.NoError(err)
+	file.Close()
+	// seeded trainings produce the same model
+	models := make([][]byte, 2)
+	for i := range models {
+		status, _, stderr = runCmd("train", "-data", dataPath, "-algorithm", "seq", "-seed", "3", "-iters", "50", "-out", modelPath)
+		assert.Equal(0, status, stderr)
+		models[i], err = os.ReadFile(modelPath)
+		assert.NoError(err)
+	}
+	assert.Equal(models[0], models[1])
+	// scaled models scale new data
+	status, _, stderr = runCmd("train", "-data", dataPath, "-scale", "-iters", "5", "-out", modelPath)
+	assert.Equal(0, status, stderr)
+	m, err := loadModel(modelPath)
+	assert.NoError(err)
+	if assert.NotNil(m.Scaler()) {
+		assert.Equal("zscore", string(m.Scaler().Method))
+	}
+}
+
+func TestErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "data.csv")
+	writeCSV(t, dataPath, 20, 2)
+	badCfg := filepath.Join(dir, "bad.json")
+	assert.NoError(os.WriteFile(badCfg, []byte(`{"radiuss": 2}`), 0644))
+	invalidCfg := filepath.Join(dir, "invalid.json")
+	assert.NoError(os.WriteFile(invalidCfg, []byte(`{"ushape": "triangle"}`), 0644))
+	ruleCfg := filepath.Join(dir, "rule.json")
+	assert.NoError(os.WriteFile(ruleCfg, []byte(`{"updaterule": "mode"}`), 0644))
+	modelPath := filepath.Join(dir, "model.bin")
+	otherData := filepath.Join(dir, "other.csv")
+	writeCSV(t, otherData, 5, 3)
+
+	testCases := []struct {
+		args   []string
+		status int
+		stderr string
+	}{
+		{[]string{}, exitUsage, "usage: gosom <command> [flags]"},
+		{[]string{"foo"}, exitUsage, "gosom: unknown command: foo\n"},
+		{[]string{"train", "-out", modelPath}, exitUsage, "gosom: train: invalid usage: missing -data flag\n"},
+		{[]string{"train", "-data", dataPath}, exitUsage, "gosom: train: invalid usage: missing -out flag\n"},
+		{[]string{"train", "-foo"}, exitUsage, "gosom: train: invalid usage: flag provided but not defined: -foo\n"},
+		{[]string{"train", "-data", dataPath, "-out", modelPath, "extra"}, exitUsage, "unexpected arguments: [extra]\n"},
+		{[]string{"train", "-data", filepath.Join(dir, "missing.csv"), "-out", modelPath}, exitFailure, "gosom: train: "},
+		{[]string{"train", "-data", dataPath, "-config", badCfg, "-out", modelPath}, exitFailure, `unknown field "radiuss"`},
+		{[]string{"train", "-data", dataPath, "-config", invalidCfg, "-out", modelPath}, exitFailure,
+			"gosom: train: invalid config file " + invalidCfg + ": invalid field ushape: unsupported unit shape: triangle\n"},
+		{[]string{"train", "-data", dataPath, "-config", ruleCfg, "-out", modelPath}, exitFailure,
+			"gosom: train: invalid config file " + ruleCfg + ": invalid field updaterule: unsupported batch update rule: mode\n"},
+		{[]string{"predict", "-data", dataPath}, exitUsage, "gosom: predict: invalid usage: missing -model flag\n"},
+		{[]string{"predict", "-model", dataPath, "-data", dataPath}, exitFailure, "gosom: predict: invalid model file"},
+		{[]string{"render", "-model", modelPath}, exitUsage, "missing -umatrix, -qerror or -planes flag\n"},
+		{[]string{"render", "-model", modelPath, "-umatrix", "u.gif"}, exitUsage, "unsupported u-matrix image format: u.gif"},
+		{[]string{"train", "-data", "d.csv", "-out", modelPath, "-umatrix", "u.gif"}, exitUsage, "unsupported u-matrix image format: u.gif"},
+		{[]string{"train", "-data", "d.csv", "-out", modelPath, "-dims", "3,x"}, exitUsage, "invalid -dims flag"},
+		{[]string{"render", "-model", modelPath, "-planes", "planes", "-data", "data.csv"}, exitUsage, "-data flag requires -umatrix or -qerror flag"},
+		{[]string{"render", "-model", modelPath, "-qerror", "q.svg"}, exitUsage, "-qerror flag requires -data flag"},
+		{[]string{"render", "-model", modelPath, "-qerror", "q.gif", "-data", "data.csv"}, exitUsage,
+			"unsupported quantization error map image format: q.gif"},
+	}
+	for _, tc := range testCases {
+		status, _, stderr := runCmd(tc.args...)
+		assert.Equal(tc.status, status, "%v", tc.args)
+		assert.Contains(stderr, tc.stderr, "%v", tc.args)
+	}
+	// predict fails on data of different dimension
+	status, _, _ := runCmd("train", "-data", dataPath, "-out", modelPath, "-iters", "2")
+	assert.Equal(0, status)
+	status, _, stderr := runCmd("predict", "-model", modelPath, "-data", otherData)
+	assert.Equal(exitFailure, status)
+	assert.Equal("gosom: predict: dimension mismatch\n", stderr)
+	// help is not an error
+	status, _, _ = runCmd("predict", "-h")
+	assert.Equal(0, status)
+}
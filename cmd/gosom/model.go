@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/milosgajdos83/gosom/som"
+)
+
+// saveModel saves map m to a model file in path
+func saveModel(m *som.Map, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := m.Save(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// loadModel loads map from a model file in path
+func loadModel(path string) (*som.Map, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	m, err := som.LoadMap(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid model file %s: %s", path, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+)
+
+// csvRows reads CSV records as data rows
+type csvRows struct {
+	r *csv.Reader
+}
+
+// Next returns the next CSV record converted to floats or io.EOF if there are no more records
+func (c *csvRows) Next() ([]float64, error) {
+	record, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := make([]float64, len(record))
+	for i, field := range record {
+		if row[i], err = strconv.ParseFloat(field, 64); err != nil {
+			return nil, err
+		}
+	}
+	return row, nil
+}
+
+// predictCmd streams Best Match Units of data rows to output
+func predictCmd(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("predict", stderr)
+	modelPath := fs.String("model", "", "path to model file")
+	dataPath := fs.String("data", "", "path to data: CSV, Arrow IPC stream (.arrow, .arrows) or Parquet (.parquet)")
+	out := fs.String("out", "-", "path to output file; - writes to stdout")
+	format := fs.String("format", "csv", "output format: csv, ndjson, arrow, parquet")
+	workers := fs.Int("workers", 0, "number of prediction worker goroutines")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required("model", *modelPath, "data", *dataPath); err != nil {
+		return err
+	}
+	m, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	src, closeSrc, err := openRows(*dataPath)
+	if err != nil {
+		return err
+	}
+	defer closeSrc()
+	w := stdout
+	var file *os.File
+	if *out != "-" {
+		if file, err = os.Create(*out); err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if *format == "arrow" || *format == "parquet" {
+		err = predictTable(m, src, w, *format, *workers)
+	} else {
+		err = m.PredictStream(src, w, *format, *workers)
+	}
+	if err != nil {
+		return err
+	}
+	if file != nil {
+		return file.Close()
+	}
+	return nil
+}
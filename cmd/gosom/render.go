@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/dataset"
+	"github.com/milosgajdos83/gosom/pkg/viz"
+	"github.com/milosgajdos83/gosom/som"
+)
+
+// planePalette renders component plane values in shades of gray from black to white
+var planePalette = viz.NewPalette(color.Black, color.White)
+
+// renderCmd renders u-matrix, quantization error map and component planes of a model
+func renderCmd(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("render", stderr)
+	modelPath := fs.String("model", "", "path to model file")
+	umatrix := fs.String("umatrix", "", "path to output u-matrix image: svg, png")
+	planes := fs.String("planes", "", "path to output directory of component plane SVG images")
+	qerror := fs.String("qerror", "", "path to output quantization error map image of data: svg, png")
+	dataPath := fs.String("data", "", "path to data whose hit histogram is drawn over the u-matrix: csv, lrn")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required("model", *modelPath); err != nil {
+		return err
+	}
+	if *umatrix == "" && *qerror == "" && *planes == "" {
+		return fmt.Errorf("%w: missing -umatrix, -qerror or -planes flag", errUsage)
+	}
+	if *dataPath != "" && *umatrix == "" && *qerror == "" {
+		return fmt.Errorf("%w: -data flag requires -umatrix or -qerror flag", errUsage)
+	}
+	if *qerror != "" && *dataPath == "" {
+		return fmt.Errorf("%w: -qerror flag requires -data flag", errUsage)
+	}
+	umatrixFormat, err := imageFormat("u-matrix", *umatrix)
+	if err != nil {
+		return err
+	}
+	qerrorFormat, err := imageFormat("quantization error map", *qerror)
+	if err != nil {
+		return err
+	}
+	m, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	var ds *dataset.DataSet
+	if *dataPath != "" {
+		if ds, err = dataset.New(*dataPath, ""); err != nil {
+			return err
+		}
+	}
+	if *umatrix != "" {
+		var hits []int
+		if ds != nil {
+			if hits, err = m.HitMap(ds.Data); err != nil {
+				return err
+			}
+		}
+		if err := renderUMatrix(m, *umatrix, umatrixFormat, hits); err != nil {
+			return err
+		}
+	}
+	if *qerror != "" {
+		if err := renderQError(m, ds.Data, *qerror, qerrorFormat); err != nil {
+			return err
+		}
+	}
+	if *planes != "" {
+		if err := renderPlanes(m, *planes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// imageFormat returns format of image path of kind, such as u-matrix, derived from its extension.
+// It returns errUsage if path is not empty and the format is not svg or png.
+func imageFormat(kind, path string) (string, error) {
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if path != "" && format != "svg" && format != "png" {
+		return "", fmt.Errorf("%w: unsupported %s image format: %s, supported formats are svg and png", errUsage, kind, path)
+	}
+	return format, nil
+}
+
+// renderUMatrix writes u-matrix image of map m in format svg or png to path.
+// PNG images and images with unit hits are grayscale heatmaps rendered by package viz
+// with hit markers of units with hits.
+func renderUMatrix(m *som.Map, path, format string, hits []int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	render := func() error { return m.UMatrix(file, nil, nil, "svg", "U-Matrix") }
+	if format == "png" || hits != nil {
+		render = func() error { return viz.UMatrix(file, m, format, &viz.Options{Hits: hits}) }
+	}
+	if err := render(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// renderQError writes quantization error map image of data on map m in format svg or png to path.
+// Units are colored by the heat palette from blue for low errors to red for high errors;
+// units without hits are transparent.
+func renderQError(m *som.Map, data *mat64.Dense, path, format string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := viz.QErrorMap(file, m, data, format, &viz.Options{Palette: viz.Heat}); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// renderPlanes writes component plane SVG images of map m to directory dir:
+// plane-<i>.svg holds values of i-th codebook component in shades of gray
+func renderPlanes(m *som.Map, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	_, dim := m.CodebookRaw().Dims()
+	for i := 0; i < dim; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("plane-%d.svg", i))
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := viz.ComponentPlane(file, m, i, "svg", &viz.Options{Palette: planePalette}); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/milosgajdos83/gosom/pkg/dataset"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/milosgajdos83/gosom/som"
+)
+
+// defaultIters returns the default number of iterations of training algorithm on rows samples:
+// sequential, temporal, neural gas, growing neural gas and gsom training visit every sample 10 times, batch training runs 100 epochs
+func defaultIters(algorithm som.Method, rows int) int {
+	if algorithm == som.Seq || algorithm == som.NeuralGas || algorithm == som.GrowingNeuralGas || algorithm == som.Temporal || algorithm == som.GSOM {
+		return 10 * rows
+	}
+	return 100
+}
+
+// configFlags defines flags of fs which override the map and training config file fields of
+// the same names and returns function which sets the fields of flags given on the command line in c
+func configFlags(fs *flag.FlagSet) func(c *som.FileConfig) error {
+	dims := fs.String("dims", "", "comma-separated map grid dimensions")
+	grid := fs.String("grid", "", "map grid type: planar, toroidal, cylindrical, spherical")
+	uShape := fs.String("ushape", "", "map unit shape: hexagon, rectangle")
+	connectivity := fs.String("connectivity", "", "connectivity of rectangle units: axial, moore")
+	init := fs.String("init", "", "codebook initialization: rand, lin, sample")
+	precision := fs.String("precision", "", "codebook storage precision: float64, float32")
+	seed := fs.Int64("seed", 0, "seed of random codebook initialization and training")
+	algorithm := fs.String("algorithm", "", "training algorithm: seq, batch, neuralgas, gng, temporal, gsom")
+	radius := fs.Float64("radius", 0, "initial radius; zero radius is derived from map dimensions")
+	rDecay := fs.String("rdecay", "", "radius decay strategy: lin, exp, inv, pow")
+	neighb := fs.String("neighb", "", "neighbourhood function: gaussian, bubble, mexican, cutgauss, epanechnikov")
+	lRate := fs.Float64("lrate", 0, "initial learning rate")
+	lDecay := fs.String("ldecay", "", "learning rate decay strategy: lin, exp, inv, pow")
+	workers := fs.Int("workers", 0, "number of training worker goroutines")
+	return func(c *som.FileConfig) error {
+		var err error
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "dims":
+				if c.Dims, err = utils.ParseDims(*dims); err != nil {
+					err = fmt.Errorf("%w: invalid -dims flag: %s", errUsage, err)
+				}
+			case "grid":
+				c.Grid = *grid
+			case "ushape":
+				c.UShape = *uShape
+			case "connectivity":
+				c.Connectivity = *connectivity
+			case "init":
+				c.Init = *init
+			case "precision":
+				c.Precision = *precision
+			case "seed":
+				c.Seed = seed
+			case "algorithm":
+				c.Algorithm = *algorithm
+			case "radius":
+				c.Radius = *radius
+			case "rdecay":
+				c.RDecay = *rDecay
+			case "neighb":
+				c.Neighb = *neighb
+			case "lrate":
+				c.LRate = *lRate
+			case "ldecay":
+				c.LDecay = *lDecay
+			case "workers":
+				c.Workers = *workers
+			}
+		})
+		return err
+	}
+}
+
+// trainCmd trains new map on data and saves it to a model file
+func trainCmd(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("train", stderr)
+	dataPath := fs.String("data", "", "path to training data: csv, lrn")
+	cfgPath := fs.String("config", "", "path to JSON map and training config file")
+	out := fs.String("out", "", "path to output model file")
+	iters := fs.Int("iters", 0, "number of training iterations; overrides config")
+	umatrix := fs.String("umatrix", "", "path to output u-matrix image of the trained map: svg, png")
+	scale := fs.Bool("scale", false, "scale data features to zero mean and unit variance; predict scales new data in the same way")
+	verbose := fs.Bool("v", false, "log training progress to stderr")
+	setConfig := configFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required("data", *dataPath, "out", *out); err != nil {
+		return err
+	}
+	umatrixFormat, err := imageFormat("u-matrix", *umatrix)
+	if err != nil {
+		return err
+	}
+	c := new(som.FileConfig)
+	if *cfgPath != "" {
+		if c, err = som.LoadConfig(*cfgPath); err != nil {
+			return err
+		}
+	}
+	if err := setConfig(c); err != nil {
+		return err
+	}
+	ds, err := dataset.New(*dataPath, "")
+	if err != nil {
+		return err
+	}
+	data := ds.Data
+	var scaler *som.Scaler
+	if *scale {
+		if scaler, err = som.FitScaler(som.ZScore, data); err != nil {
+			return err
+		}
+		if data, err = scaler.Transform(data); err != nil {
+			return err
+		}
+	}
+	opts, err := c.MapOptions()
+	if err != nil {
+		return err
+	}
+	m, err := som.NewMapWithOptions(data, opts...)
+	if err != nil {
+		return err
+	}
+	if err := m.SetScaler(scaler); err != nil {
+		return err
+	}
+	tc, err := c.TrainConfig()
+	if err != nil {
+		return err
+	}
+	n := c.Iters
+	if *iters != 0 {
+		n = *iters
+	}
+	if n == 0 {
+		rows, _ := data.Dims()
+		n = defaultIters(tc.Algorithm, rows)
+	}
+	if *verbose {
+		tc.Logger = log.New(stderr, cliname+": train: ", 0)
+	}
+	res, err := m.TrainWithResult(tc, data, n)
+	if err != nil {
+		return err
+	}
+	// training logger has already logged the warnings
+	if !*verbose {
+		for _, warning := range res.Warnings {
+			fmt.Fprintf(stderr, "%s: train: warning: %s\n", cliname, warning)
+		}
+	}
+	if err := m.RecordQuality(data); err != nil {
+		return err
+	}
+	if err := saveModel(m, *out); err != nil {
+		return err
+	}
+	if *umatrix != "" {
+		if err := renderUMatrix(m, *umatrix, umatrixFormat, nil); err != nil {
+			return err
+		}
+	}
+	fmt.Fprint(stdout, m.Summary())
+	fmt.Fprintf(stdout, "initial radius: %.4f\n", res.Radius)
+	fmt.Fprintf(stdout, "initial learning rate: %.4f\n", res.LRate)
+
+	return nil
+}
@@ -201,8 +201,8 @@ func main() {
 	// SOM configuration
 	grid := &som.GridConfig{
 		Size:   mdims,
-		Type:   grid,
-		UShape: ushape,
+		Type:   som.GridType(grid),
+		UShape: som.UnitShape(ushape),
 	}
 	cb := &som.CbConfig{
 		Dim:      dim,
@@ -222,12 +222,12 @@ func main() {
 	}
 	// training configuration
 	trainCfg := &som.TrainConfig{
-		Algorithm: training,
+		Algorithm: som.Method(training),
 		Radius:    radius,
-		RDecay:    rdecay,
+		RDecay:    som.Decay(rdecay),
 		NeighbFn:  som.Gaussian,
 		LRate:     lrate,
-		LDecay:    ldecay,
+		LDecay:    som.Decay(ldecay),
 	}
 	// run SOM training
 	log.Printf("Starting SOM training. Algorithm: %s, iterations: %d", trainCfg.Algorithm, iters)
@@ -250,7 +250,7 @@ func main() {
 		}
 	}
 	// codebook vectors contains sorted colors
-	imgData := m.Codebook().(*mat64.Dense)
+	imgData := m.Codebook()
 	somImg := Data2Image(imgData, mdims[0], mdims[1])
 	// save imaee
 	if err := SaveImage(output, somImg); err != nil {
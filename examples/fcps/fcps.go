@@ -138,8 +138,8 @@ func main() {
 	// SOM configuration
 	grid := &som.GridConfig{
 		Size:   mdims,
-		Type:   grid,
-		UShape: ushape,
+		Type:   som.GridType(grid),
+		UShape: som.UnitShape(ushape),
 	}
 	cb := &som.CbConfig{
 		Dim:      dim,
@@ -159,12 +159,12 @@ func main() {
 	}
 	// training configuration
 	trainCfg := &som.TrainConfig{
-		Algorithm: training,
+		Algorithm: som.Method(training),
 		Radius:    radius,
-		RDecay:    rdecay,
+		RDecay:    som.Decay(rdecay),
 		NeighbFn:  som.Gaussian,
 		LRate:     lrate,
-		LDecay:    ldecay,
+		LDecay:    som.Decay(ldecay),
 	}
 	// run SOM training
 	log.Printf("Starting SOM training. Method: %s, iterations: %d", trainCfg.Algorithm, iters)
@@ -0,0 +1,151 @@
+package dataset
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// CSVOptions configures ReadCSV. Columns are referred to by their header names if the file
+// has a header or by their zero-based indices in decimal notation.
+type CSVOptions struct {
+	// Header is true if the first record holds column names
+	Header bool
+	// Comma is the field delimiter. The default is ','.
+	Comma rune
+	// Include holds data columns. If it is empty, all columns but Exclude and Label columns are data.
+	Include []string
+	// Exclude holds columns which are not data, such as identifiers
+	Exclude []string
+	// Label is the column of row labels. If it is empty, rows have no labels.
+	Label string
+}
+
+// ReadCSV reads data set from CSV records of r configured by opts: data columns are stored in
+// data matrix in the order of the file, the label column in Labels and header names of data
+// columns in Names. Empty fields and NA fields are missing values stored as NaN.
+// ReadCSV returns error if a column of opts does not exist, if a column is both included
+// and excluded, if the label column is data column, if the records have different number of fields,
+// if a data field is not a number or if r has no data columns or rows.
+func ReadCSV(r io.Reader, opts CSVOptions) (*DataSet, error) {
+	csvReader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		csvReader.Comma = opts.Comma
+	}
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("invalid csv data: no records")
+	}
+	var header []string
+	if opts.Header {
+		header, records = records[0], records[1:]
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("invalid csv data: no data rows")
+	}
+	fields := len(records[0])
+	if header == nil {
+		header = make([]string, fields)
+		for i := range header {
+			header[i] = strconv.Itoa(i)
+		}
+	}
+	cols, label, err := csvColumns(header, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("invalid csv data: no data columns")
+	}
+	data := mat64.NewDense(len(records), len(cols), nil)
+	var labels []string
+	if label >= 0 {
+		labels = make([]string, len(records))
+	}
+	for i, record := range records {
+		for j, col := range cols {
+			v, err := parseField(record[col])
+			if err != nil {
+				return nil, fmt.Errorf("invalid csv field of row %d, column %s: %s", i, header[col], err)
+			}
+			data.Set(i, j, v)
+		}
+		if label >= 0 {
+			labels[i] = record[label]
+		}
+	}
+	ds := &DataSet{Data: data, Classes: make(map[int]int), Labels: labels}
+	if opts.Header {
+		ds.Names = make([]string, len(cols))
+		for i, col := range cols {
+			ds.Names[i] = header[col]
+		}
+	}
+	return ds, nil
+}
+
+// csvColumns returns indices of data columns in header selected by opts and index of the label
+// column which is -1 if opts have none
+func csvColumns(header []string, opts CSVOptions) ([]int, int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[name] = i
+	}
+	lookup := func(names []string) (map[int]bool, error) {
+		cols := make(map[int]bool, len(names))
+		for _, name := range names {
+			i, ok := index[name]
+			if !ok {
+				return nil, fmt.Errorf("invalid csv column: %s", name)
+			}
+			cols[i] = true
+		}
+		return cols, nil
+	}
+	include, err := lookup(opts.Include)
+	if err != nil {
+		return nil, -1, err
+	}
+	exclude, err := lookup(opts.Exclude)
+	if err != nil {
+		return nil, -1, err
+	}
+	label := -1
+	if opts.Label != "" {
+		i, ok := index[opts.Label]
+		if !ok {
+			return nil, -1, fmt.Errorf("invalid csv label column: %s", opts.Label)
+		}
+		if include[i] {
+			return nil, -1, fmt.Errorf("invalid csv label column: %s, it is data column", opts.Label)
+		}
+		label, exclude[i] = i, true
+	}
+	var cols []int
+	for i, name := range header {
+		switch {
+		case include[i] && exclude[i]:
+			return nil, -1, fmt.Errorf("invalid csv column: %s, it is both included and excluded", name)
+		case len(include) > 0 && include[i], len(include) == 0 && !exclude[i]:
+			cols = append(cols, i)
+		}
+	}
+	return cols, label, nil
+}
+
+// parseField parses CSV data field. Empty and NA fields are missing values returned as NaN.
+func parseField(field string) (float64, error) {
+	field = strings.TrimSpace(field)
+	if field == "" || field == "NA" {
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(field, 64)
+}
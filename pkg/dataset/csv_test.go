@@ -0,0 +1,61 @@
+package dataset
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	content := "id,x,y,class,z\n1,2.0,3.5,a,1\n2,4.5,,b,2\n3,7.0,NA,a,3\n"
+	// label and excluded columns are not data
+	ds, err := ReadCSV(strings.NewReader(content), CSVOptions{Header: true, Exclude: []string{"id"}, Label: "class"})
+	assert.NoError(err)
+	assert.Equal([]string{"x", "y", "z"}, ds.Names)
+	assert.Equal([]string{"a", "b", "a"}, ds.Labels)
+	rows, cols := ds.Data.Dims()
+	assert.Equal(3, rows)
+	assert.Equal(3, cols)
+	assert.Equal([]float64{2.0, 3.5, 1}, ds.Data.RawRowView(0))
+	// empty and NA fields are missing values
+	assert.True(math.IsNaN(ds.Data.At(1, 1)))
+	assert.True(math.IsNaN(ds.Data.At(2, 1)))
+	// included columns
+	ds, err = ReadCSV(strings.NewReader(content), CSVOptions{Header: true, Include: []string{"z", "x"}})
+	assert.NoError(err)
+	assert.Equal([]string{"x", "z"}, ds.Names)
+	assert.Nil(ds.Labels)
+	assert.True(mat64.Equal(mat64.NewDense(3, 2, []float64{2.0, 1, 4.5, 2, 7.0, 3}), ds.Data))
+	// columns without header are referred to by their indices
+	ds, err = ReadCSV(strings.NewReader("1;a;2\n3;b;4\n"), CSVOptions{Comma: ';', Label: "1"})
+	assert.NoError(err)
+	assert.Nil(ds.Names)
+	assert.Equal([]string{"a", "b"}, ds.Labels)
+	assert.True(mat64.Equal(mat64.NewDense(2, 2, []float64{1, 2, 3, 4}), ds.Data))
+
+	for _, tc := range []struct {
+		content string
+		opts    CSVOptions
+		err     string
+	}{
+		{"", CSVOptions{}, "no records"},
+		{"x,y\n", CSVOptions{Header: true}, "no data rows"},
+		{content, CSVOptions{Header: true, Exclude: []string{"w"}}, "invalid csv column: w"},
+		{content, CSVOptions{Header: true, Include: []string{"x"}, Exclude: []string{"x"}}, "both included and excluded"},
+		{content, CSVOptions{Header: true, Include: []string{"x"}, Label: "x"}, "it is data column"},
+		{content, CSVOptions{Header: true, Label: "w"}, "invalid csv label column: w"},
+		{content, CSVOptions{Header: true}, "row 0, column class"},
+		{"1,2\n3\n", CSVOptions{}, "wrong number of fields"},
+		{"1,a\n", CSVOptions{Exclude: []string{"0", "1"}}, "no data columns"},
+	} {
+		_, err := ReadCSV(strings.NewReader(tc.content), tc.opts)
+		if assert.Error(err, tc.err) {
+			assert.Contains(err.Error(), tc.err)
+		}
+	}
+}
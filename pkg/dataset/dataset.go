@@ -30,8 +30,10 @@ const (
 
 // load data funcs
 var loadFuncs = map[string]func(io.Reader) (*mat64.Dense, error){
-	".csv": LoadCSV,
-	".lrn": LoadLRN,
+	".csv":    LoadCSV,
+	".lrn":    LoadLRN,
+	".libsvm": loadLIBSVMData,
+	".svm":    loadLIBSVMData,
 }
 
 // load classifications funcs
@@ -43,12 +45,16 @@ var loadClsFuncs = map[string]func(io.Reader) (map[int]int, error){
 type DataSet struct {
 	Data    *mat64.Dense
 	Classes map[int]int
+	// Names holds names of data columns if the data file has them
+	Names []string
+	// Labels holds labels of data rows if the data file has them
+	Labels []string
 }
 
 // New returns pointer to dataset or fails with error if either the file
 // in dataPath does not exist or if it is encoded in an unsupported format.
-// File format is inferred from the file extension. Currently only csv and lrn
-// data formats are supported.
+// File format is inferred from the file extension. Currently csv, lrn and libsvm
+// (libsvm or svm extension) data formats are supported.
 // If the dataset has classification information it can be provided as the second
 // parameter. If the file in clsPath doesn't exist New fails with error.
 func New(dataPath string, clsPath string) (*DataSet, error) {
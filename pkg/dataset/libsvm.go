@@ -0,0 +1,93 @@
+package dataset
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// ReadLIBSVM reads data set from LIBSVM sparse format lines of r: every line holds the row label
+// followed by index:value pairs of features with one-based increasing indices. Features missing
+// from a line are zero. Empty lines and text after # are ignored. The data matrix has dim columns;
+// if dim is zero, it has as many columns as the largest feature index. Labels holds row labels.
+// ReadLIBSVM returns error if dim is negative, if a line is malformed or has a feature index larger
+// than positive dim or if r has no data rows.
+func ReadLIBSVM(r io.Reader, dim int) (*DataSet, error) {
+	if dim < 0 {
+		return nil, fmt.Errorf("invalid libsvm data dimension: %d", dim)
+	}
+	type feature struct {
+		col int
+		val float64
+	}
+	var rows [][]feature
+	var labels []string
+	cols := dim
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		row := make([]feature, 0, len(fields)-1)
+		for _, field := range fields[1:] {
+			kv := strings.SplitN(field, ":", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid libsvm line %d: feature %s is not index:value pair", line, field)
+			}
+			idx, err := strconv.Atoi(kv[0])
+			if err != nil || idx < 1 {
+				return nil, fmt.Errorf("invalid libsvm line %d: feature index %s must be positive integer", line, kv[0])
+			}
+			if len(row) > 0 && idx-1 <= row[len(row)-1].col {
+				return nil, fmt.Errorf("invalid libsvm line %d: feature index %d does not increase", line, idx)
+			}
+			if dim > 0 && idx > dim {
+				return nil, fmt.Errorf("invalid libsvm line %d: feature index %d exceeds dimension %d", line, idx, dim)
+			}
+			val, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid libsvm line %d: feature %d: %s", line, idx, err)
+			}
+			row = append(row, feature{col: idx - 1, val: val})
+			if idx > cols {
+				cols = idx
+			}
+		}
+		rows = append(rows, row)
+		labels = append(labels, fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("invalid libsvm data: no data rows")
+	}
+	if cols == 0 {
+		return nil, fmt.Errorf("invalid libsvm data: no features")
+	}
+	data := mat64.NewDense(len(rows), cols, nil)
+	for i, row := range rows {
+		for _, f := range row {
+			data.Set(i, f.col, f.val)
+		}
+	}
+	return &DataSet{Data: data, Classes: make(map[int]int), Labels: labels}, nil
+}
+
+// loadLIBSVMData loads data matrix of LIBSVM sparse format lines of r
+func loadLIBSVMData(r io.Reader) (*mat64.Dense, error) {
+	ds, err := ReadLIBSVM(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	return ds.Data, nil
+}
@@ -0,0 +1,53 @@
+package dataset
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadLIBSVM(t *testing.T) {
+	assert := assert.New(t)
+
+	content := "# sparse data\n+1 1:0.5 3:2\n\n-1 2:1.5 # second row\n+1\n"
+	ds, err := ReadLIBSVM(strings.NewReader(content), 0)
+	assert.NoError(err)
+	assert.Equal([]string{"+1", "-1", "+1"}, ds.Labels)
+	assert.True(mat64.Equal(mat64.NewDense(3, 3, []float64{0.5, 0, 2, 0, 1.5, 0, 0, 0, 0}), ds.Data))
+	// fixed dimension
+	ds, err = ReadLIBSVM(strings.NewReader(content), 5)
+	assert.NoError(err)
+	_, cols := ds.Data.Dims()
+	assert.Equal(5, cols)
+
+	for _, tc := range []struct {
+		content string
+		dim     int
+		err     string
+	}{
+		{content, -1, "invalid libsvm data dimension"},
+		{content, 2, "line 2: feature index 3 exceeds dimension 2"},
+		{"1 1:2 x\n", 0, "not index:value pair"},
+		{"1 0:2\n", 0, "must be positive integer"},
+		{"1 2:2 1:3\n", 0, "feature index 1 does not increase"},
+		{"1 1:x\n", 0, "line 1: feature 1"},
+		{"# comment\n", 0, "no data rows"},
+		{"1\n2\n", 0, "no features"},
+	} {
+		_, err := ReadLIBSVM(strings.NewReader(tc.content), tc.dim)
+		if assert.Error(err, tc.err) {
+			assert.Contains(err.Error(), tc.err)
+		}
+	}
+
+	// data sets are loaded from libsvm files
+	path := filepath.Join(t.TempDir(), "data.libsvm")
+	assert.NoError(os.WriteFile(path, []byte(content), 0644))
+	ds, err = New(path, "")
+	assert.NoError(err)
+	assert.True(mat64.Equal(mat64.NewDense(3, 3, []float64{0.5, 0, 2, 0, 1.5, 0, 0, 0, 0}), ds.Data))
+}
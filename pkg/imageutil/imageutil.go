@@ -0,0 +1,202 @@
+package imageutil
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/som"
+)
+
+// ColorSpace is color space of pixel rows
+type ColorSpace string
+
+const (
+	// RGB holds red, green and blue pixel components in the range from 0 to 1
+	RGB ColorSpace = "rgb"
+	// Lab holds CIE L*a*b* pixel components of sRGB pixels under D65 illuminant.
+	// Euclidean distances in Lab approximate perceived color differences.
+	Lab ColorSpace = "lab"
+)
+
+// FromImage returns matrix of img pixels in color space space: every row holds three components
+// of one pixel and pixels are ordered row by row from the top left corner of img. Pixel colors are
+// not premultiplied by alpha; alpha channel is dropped. It returns error if img is nil or empty
+// or if space is not supported.
+func FromImage(img image.Image, space ColorSpace) (*mat64.Dense, error) {
+	if img == nil || img.Bounds().Empty() {
+		return nil, fmt.Errorf("invalid image supplied: %v", img)
+	}
+	if space != RGB && space != Lab {
+		return nil, fmt.Errorf("unsupported color space: %s", space)
+	}
+	b := img.Bounds()
+	data := mat64.NewDense(b.Dx()*b.Dy(), 3, nil)
+	row := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgb := []float64{float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255}
+			if space == Lab {
+				rgb = rgbToLab(rgb)
+			}
+			data.SetRow(row, rgb)
+			row++
+		}
+	}
+	return data, nil
+}
+
+// Quantize returns a copy of img whose every pixel has the color of its BMU codebook vector
+// in map m, which was trained on pixels in color space space. Codebook colors out of RGB range
+// are clipped. Alpha channel of img is preserved. It returns error if img is nil or empty,
+// if space is not supported or if the map codebook dimension is not 3.
+func Quantize(img image.Image, m *som.Map, space ColorSpace) (image.Image, error) {
+	data, err := FromImage(img, space)
+	if err != nil {
+		return nil, err
+	}
+	bmus, _, err := m.Predict(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	cb := m.CodebookRaw()
+	units, _ := cb.Dims()
+	colors := make([]color.NRGBA, units)
+	for i := range colors {
+		rgb := cb.RawRowView(i)
+		if space == Lab {
+			rgb = labToRGB(rgb)
+		}
+		colors[i] = color.NRGBA{R: toByte(rgb[0]), G: toByte(rgb[1]), B: toByte(rgb[2])}
+	}
+	b := img.Bounds()
+	out := image.NewNRGBA(b)
+	row := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := colors[bmus[row]]
+			c.A = color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA).A
+			out.SetNRGBA(x, y, c)
+			row++
+		}
+	}
+	return out, nil
+}
+
+// QuantizeColors reduces img to at most nColors colors: it trains new map of nColors units on img
+// pixels in color space space using training configuration tc and quantizes img by the trained map.
+// The map grid is the most square grid of nColors units and its codebook is initialized by
+// som.LinInit. Seq training runs 10 iterations per pixel, batch training runs 100 epochs.
+// QuantizeColors returns the quantized image and the trained map. It returns error if nColors
+// is lower than 2 or if the training or the quantization fails.
+func QuantizeColors(img image.Image, nColors int, space ColorSpace, tc *som.TrainConfig) (image.Image, *som.Map, error) {
+	if nColors < 2 {
+		return nil, nil, fmt.Errorf("invalid number of colors: %d", nColors)
+	}
+	data, err := FromImage(img, space)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows := 1
+	for i := 1; i*i <= nColors; i++ {
+		if nColors%i == 0 {
+			rows = i
+		}
+	}
+	mc := &som.MapConfig{
+		Grid: &som.GridConfig{
+			Size:   []int{rows, nColors / rows},
+			Type:   som.Planar,
+			UShape: som.Hexagon,
+		},
+		Cb: &som.CbConfig{
+			Dim:      3,
+			InitFunc: som.LinInit,
+		},
+	}
+	m, err := som.NewMap(mc, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	iters := 100
+	if tc != nil && tc.Algorithm == som.Seq {
+		pixels, _ := data.Dims()
+		iters = 10 * pixels
+	}
+	if err := m.Train(tc, data, iters); err != nil {
+		return nil, nil, err
+	}
+	out, err := Quantize(img, m, space)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, m, nil
+}
+
+// toByte converts color component in the range from 0 to 1 to byte; the component is clipped to the range
+func toByte(v float64) uint8 {
+	return uint8(math.Round(255 * math.Max(0, math.Min(1, v))))
+}
+
+// D65 reference white of Lab conversion
+const (
+	whiteX = 0.95047
+	whiteY = 1.0
+	whiteZ = 1.08883
+)
+
+// rgbToLab converts sRGB color to CIE L*a*b*
+func rgbToLab(rgb []float64) []float64 {
+	r, g, b := linear(rgb[0]), linear(rgb[1]), linear(rgb[2])
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / whiteX
+	y := (0.2126729*r + 0.7151522*g + 0.0721750*b) / whiteY
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / whiteZ
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return []float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+// labToRGB converts CIE L*a*b* color to sRGB; the result may be out of RGB range
+func labToRGB(lab []float64) []float64 {
+	fy := (lab[0] + 16) / 116
+	fx, fz := fy+lab[1]/500, fy-lab[2]/200
+	x, y, z := labFInv(fx)*whiteX, labFInv(fy)*whiteY, labFInv(fz)*whiteZ
+	r := 3.2404542*x - 1.5371385*y - 0.4985314*z
+	g := -0.9692660*x + 1.8760108*y + 0.0415560*z
+	b := 0.0556434*x - 0.2040259*y + 1.0572252*z
+	return []float64{gamma(r), gamma(g), gamma(b)}
+}
+
+// linear converts sRGB component to linear RGB
+func linear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// gamma converts linear RGB component to sRGB
+func gamma(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// labF is the nonlinearity of Lab conversion
+func labF(t float64) float64 {
+	if t > 216.0/24389 {
+		return math.Cbrt(t)
+	}
+	return (24389.0/27*t + 16) / 116
+}
+
+// labFInv is the inverse of labF
+func labFInv(t float64) float64 {
+	if t*t*t > 216.0/24389 {
+		return t * t * t
+	}
+	return (116*t - 16) * 27 / 24389
+}
@@ -0,0 +1,165 @@
+package imageutil
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/som"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeGradient returns w x h image whose red component grows from left to right
+// and green component grows from top to bottom
+func makeGradient(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(255 * x / (w - 1)),
+				G: uint8(255 * y / (h - 1)),
+				B: 128,
+				A: uint8(100 + x),
+			})
+		}
+	}
+	return img
+}
+
+// distinctColors returns the number of distinct opaque colors of img
+func distinctColors(img image.Image) int {
+	colors := make(map[color.NRGBA]bool)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			c.A = 255
+			colors[c] = true
+		}
+	}
+	return len(colors)
+}
+
+func TestFromImage(t *testing.T) {
+	assert := assert.New(t)
+
+	img := makeGradient(8, 4)
+	data, err := FromImage(img, RGB)
+	assert.NoError(err)
+	rows, cols := data.Dims()
+	assert.Equal(32, rows)
+	assert.Equal(3, cols)
+	// pixels are ordered row by row
+	assert.InDelta(1.0, data.At(7, 0), 1e-9)
+	assert.InDelta(0.0, data.At(7, 1), 1e-9)
+	assert.InDelta(1.0, data.At(31, 1), 1e-9)
+	assert.InDelta(128.0/255, data.At(0, 2), 1e-9)
+
+	// white and black in Lab
+	bw := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	bw.SetNRGBA(0, 0, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	bw.SetNRGBA(1, 0, color.NRGBA{A: 255})
+	data, err = FromImage(bw, Lab)
+	assert.NoError(err)
+	assert.InDelta(100.0, data.At(0, 0), 1e-3)
+	assert.InDelta(0.0, data.At(0, 1), 1e-3)
+	assert.InDelta(0.0, data.At(0, 2), 1e-3)
+	assert.InDelta(0.0, data.At(1, 0), 1e-9)
+
+	// invalid input
+	_, err = FromImage(nil, RGB)
+	assert.Error(err)
+	_, err = FromImage(image.NewNRGBA(image.Rect(0, 0, 0, 0)), RGB)
+	assert.Error(err)
+	_, err = FromImage(img, ColorSpace("hsv"))
+	assert.Error(err)
+}
+
+func TestLabRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, rgb := range [][]float64{{0, 0, 0}, {1, 1, 1}, {1, 0, 0}, {0.2, 0.6, 0.9}, {0.01, 0.02, 0.5}} {
+		back := labToRGB(rgbToLab(rgb))
+		for j := range rgb {
+			assert.InDelta(rgb[j], back[j], 1e-5)
+		}
+	}
+}
+
+func TestQuantizeColors(t *testing.T) {
+	assert := assert.New(t)
+
+	img := makeGradient(16, 16)
+	tc := &som.TrainConfig{
+		Algorithm: som.Batch,
+		Radius:    2.0,
+		RDecay:    som.ExpDecay,
+		NeighbFn:  som.Gaussian,
+		LRate:     0.5,
+		LDecay:    som.ExpDecay,
+	}
+	for _, space := range []ColorSpace{RGB, Lab} {
+		out, m, err := QuantizeColors(img, 6, space, tc)
+		assert.NoError(err)
+		assert.Equal([]int{2, 3}, m.Grid().Size())
+		assert.Equal(img.Bounds(), out.Bounds())
+
+		// every unit which is BMU of a pixel contributes one color
+		data, err := FromImage(img, space)
+		assert.NoError(err)
+		bmus, err := m.BMUs(data)
+		assert.NoError(err)
+		live := make(map[int]bool)
+		for _, bmu := range bmus {
+			live[bmu] = true
+		}
+		assert.Equal(len(live), distinctColors(out))
+		assert.True(len(live) > 1)
+
+		// alpha is preserved
+		b := img.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				assert.Equal(img.NRGBAAt(x, y).A, color.NRGBAModel.Convert(out.At(x, y)).(color.NRGBA).A)
+			}
+		}
+	}
+
+	// seq training
+	tc = &som.TrainConfig{
+		Algorithm: som.Seq,
+		Radius:    2.0,
+		RDecay:    som.ExpDecay,
+		NeighbFn:  som.Gaussian,
+		LRate:     0.5,
+		LDecay:    som.ExpDecay,
+	}
+	out, _, err := QuantizeColors(img, 4, RGB, tc)
+	assert.NoError(err)
+	assert.True(distinctColors(out) <= 4)
+
+	// invalid input
+	_, _, err = QuantizeColors(img, 1, RGB, tc)
+	assert.Error(err)
+	_, _, err = QuantizeColors(nil, 4, RGB, tc)
+	assert.Error(err)
+	_, _, err = QuantizeColors(img, 4, RGB, nil)
+	assert.Error(err)
+}
+
+func TestQuantizeErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	img := makeGradient(4, 4)
+	data, err := FromImage(img, RGB)
+	assert.NoError(err)
+	mc := &som.MapConfig{
+		Grid: &som.GridConfig{Size: []int{2, 2}, Type: som.Planar, UShape: som.Hexagon},
+		Cb:   &som.CbConfig{Dim: 2, InitFunc: som.LinInit},
+	}
+	m, err := som.NewMap(mc, mat64.DenseCopyOf(data.Slice(0, 16, 0, 2)))
+	assert.NoError(err)
+	_, err = Quantize(img, m, RGB)
+	assert.Error(err)
+}
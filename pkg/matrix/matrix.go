@@ -55,13 +55,13 @@ func RowsMin(rows int, m *mat64.Dense) ([]float64, error) {
 // MakeRandom fails if non-positive matrix dimensions are requested.
 func MakeRandom(rows, cols int, min, max float64) (*mat64.Dense, error) {
 	return withValidDims(rows, cols, func() (*mat64.Dense, error) {
-		// set random seed
-		rand.Seed(55)
+		// use local random source with fixed seed
+		r := rand.New(rand.NewSource(55))
 		// allocate data slice
 		randVals := make([]float64, rows*cols)
 		for i := range randVals {
 			// we need value between 0 and 1.0
-			randVals[i] = rand.Float64()*(max-min) + min
+			randVals[i] = r.Float64()*(max-min) + min
 		}
 		return mat64.NewDense(rows, cols, randVals), nil
 	})
@@ -152,6 +152,36 @@ func withValidDim(dim string, count int, m *mat64.Dense,
 	return dimFn(dim, count, m, fn), nil
 }
 
+// Windows slides a window of size rows over time series m, whose rows are samples ordered in time,
+// and returns a matrix of training vectors: every row holds size consecutive samples of m concatenated
+// in time order. Windows start every step rows at the first row, so consecutive windows overlap
+// if step is smaller than size. Trailing rows which don't fill a window are dropped.
+// Windows fails with error if m is nil, if size or step are non-positive or if m has fewer rows than size.
+func Windows(m *mat64.Dense, size, step int) (*mat64.Dense, error) {
+	if m == nil {
+		return nil, fmt.Errorf("invalid matrix supplied: %v", m)
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid window size: %d", size)
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("invalid window step: %d", step)
+	}
+	rows, cols := m.Dims()
+	if rows < size {
+		return nil, fmt.Errorf("invalid window size: %d, series has %d rows", size, rows)
+	}
+	n := (rows-size)/step + 1
+	out := mat64.NewDense(n, size*cols, nil)
+	for i := 0; i < n; i++ {
+		row := out.RawRowView(i)
+		for k := 0; k < size; k++ {
+			copy(row[k*cols:(k+1)*cols], m.RawRowView(i*step+k))
+		}
+	}
+	return out, nil
+}
+
 // withValidDims validates if the rows and cols are valid matrix dimensions
 // It returns error if either rows or cols are invalid i.e. non-positive integers
 func withValidDims(rows, cols int, fn func() (*mat64.Dense, error)) (*mat64.Dense, error) {
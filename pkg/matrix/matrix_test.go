@@ -208,3 +208,30 @@ func TestAddConstant(t *testing.T) {
 	assert.Nil(mx)
 	assert.Error(err)
 }
+
+func TestWindows(t *testing.T) {
+	assert := assert.New(t)
+
+	series := mat64.NewDense(5, 2, []float64{1, 10, 2, 20, 3, 30, 4, 40, 5, 50})
+	// overlapping windows
+	w, err := Windows(series, 3, 1)
+	assert.NoError(err)
+	assert.True(mat64.Equal(mat64.NewDense(3, 6, []float64{
+		1, 10, 2, 20, 3, 30,
+		2, 20, 3, 30, 4, 40,
+		3, 30, 4, 40, 5, 50,
+	}), w))
+	// trailing rows which don't fill a window are dropped
+	w, err = Windows(series, 2, 2)
+	assert.NoError(err)
+	assert.True(mat64.Equal(mat64.NewDense(2, 4, []float64{1, 10, 2, 20, 3, 30, 4, 40}), w))
+	// invalid parameters
+	_, err = Windows(nil, 2, 1)
+	assert.Error(err)
+	_, err = Windows(series, 0, 1)
+	assert.EqualError(err, "invalid window size: 0")
+	_, err = Windows(series, 2, 0)
+	assert.EqualError(err, "invalid window step: 0")
+	_, err = Windows(series, 6, 1)
+	assert.EqualError(err, "invalid window size: 6, series has 5 rows")
+}
@@ -0,0 +1,363 @@
+// Package viz renders maps trained by package som, such as their u-matrix, component planes
+// and quantization error maps, as heatmap images of unit values.
+package viz
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/som"
+)
+
+const (
+	// DefaultUnitSize is the default distance of neighbouring units in rendered images in pixels
+	DefaultUnitSize = 20
+	// maxHitScale is the size of hit marker of the unit with most hits relative to the unit size
+	maxHitScale = 0.8
+)
+
+// Palette returns color of value v scaled to the range from 0 to 1
+type Palette func(v float64) color.Color
+
+// NewPalette returns palette which linearly interpolates between colors stops spread evenly
+// over the range from 0 to 1: the first stop is the color of 0 and the last one the color of 1.
+// It panics if fewer than two stops are supplied.
+func NewPalette(stops ...color.Color) Palette {
+	if len(stops) < 2 {
+		panic(fmt.Sprintf("viz: palette needs at least 2 stops, got %d", len(stops)))
+	}
+	rgba := make([]color.RGBA, len(stops))
+	for i, c := range stops {
+		rgba[i] = color.RGBAModel.Convert(c).(color.RGBA)
+	}
+	return func(v float64) color.Color {
+		v = math.Max(0, math.Min(1, v)) * float64(len(rgba)-1)
+		i := int(v)
+		if i == len(rgba)-1 {
+			return rgba[i]
+		}
+		t := v - float64(i)
+		mix := func(a, b uint8) uint8 { return uint8(math.Round(float64(a) + t*(float64(b)-float64(a)))) }
+		a, b := rgba[i], rgba[i+1]
+		return color.RGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: mix(a.A, b.A)}
+	}
+}
+
+var (
+	// Gray renders low values white and high values black, so units far from their neighbours
+	// are dark in u-matrix images
+	Gray = NewPalette(color.White, color.Black)
+	// Heat renders values from blue through cyan, green and yellow to red
+	Heat = NewPalette(color.RGBA{0, 0, 255, 255}, color.RGBA{0, 255, 255, 255}, color.RGBA{0, 255, 0, 255},
+		color.RGBA{255, 255, 0, 255}, color.RGBA{255, 0, 0, 255})
+)
+
+// Options configure rendered images
+type Options struct {
+	// Palette colors unit values scaled to the range from 0 to 1. If it is nil, Gray is used.
+	Palette Palette
+	// UnitSize is the distance of neighbouring units in pixels. If it is zero, DefaultUnitSize is used.
+	UnitSize int
+	// Hits holds hit histogram of units such as the one returned by som.Map.HitMap. If it is not nil,
+	// every unit with hits is marked by a smaller copy of its polygon whose area is proportional
+	// to the unit hits, so dead units and sparse map regions stand out.
+	Hits []int
+	// HitColor colors hit markers. If it is nil, red is used.
+	HitColor color.Color
+	// MissingColor colors units whose values are NaN, such as units without hits in quantization
+	// error maps. If it is nil, the units are transparent.
+	MissingColor color.Color
+}
+
+// withDefaults returns a copy of options with defaults of unset fields
+func (o *Options) withDefaults() Options {
+	var c Options
+	if o != nil {
+		c = *o
+	}
+	if c.Palette == nil {
+		c.Palette = Gray
+	}
+	if c.UnitSize == 0 {
+		c.UnitSize = DefaultUnitSize
+	}
+	if c.HitColor == nil {
+		c.HitColor = color.RGBA{255, 0, 0, 255}
+	}
+	if c.MissingColor == nil {
+		c.MissingColor = color.Transparent
+	}
+	return c
+}
+
+// UMatrix renders u-matrix of map m as heatmap image in format png or svg and writes it to w.
+// It returns error if m is nil, if the map u-matrix can't be computed or if Heatmap fails.
+func UMatrix(w io.Writer, m *som.Map, format string, opts *Options) error {
+	if m == nil {
+		return fmt.Errorf("invalid map supplied: %v", m)
+	}
+	values, err := m.UMatrixValues()
+	if err != nil {
+		return err
+	}
+	return Heatmap(w, m.Grid(), values, format, opts)
+}
+
+// ComponentPlane renders component plane of codebook component dim of map m as heatmap image
+// in format png or svg and writes it to w. It returns error if m is nil, if dim is not a valid
+// codebook component or if Heatmap fails.
+func ComponentPlane(w io.Writer, m *som.Map, dim int, format string, opts *Options) error {
+	if m == nil {
+		return fmt.Errorf("invalid map supplied: %v", m)
+	}
+	values, err := m.ComponentPlane(dim)
+	if err != nil {
+		return err
+	}
+	return Heatmap(w, m.Grid(), values, format, opts)
+}
+
+// QErrorMap renders quantization error map of data on map m as heatmap image in format png or svg
+// and writes it to w: units are colored by the mean distance of their data rows from their codebook
+// vectors, so poorly represented map regions stand out. Units without hits are colored by opts
+// MissingColor. It returns error if m is nil, if the map quantization error map of data can't be
+// computed or if Heatmap fails.
+func QErrorMap(w io.Writer, m *som.Map, data mat64.Matrix, format string, opts *Options) error {
+	if m == nil {
+		return fmt.Errorf("invalid map supplied: %v", m)
+	}
+	values, err := m.QErrorMap(data)
+	if err != nil {
+		return err
+	}
+	return Heatmap(w, m.Grid(), values, format, opts)
+}
+
+// Heatmap renders values of grid units as image in format png or svg and writes it to w.
+// Every unit is drawn as hexagon or square of its grid unit shape centered at its grid coordinates
+// and colored by opts palette: values are scaled to the range from 0 to 1 between their minimum
+// and maximum; constant values are rendered as 0.5 and NaN values by opts MissingColor.
+// If opts is nil, defaults are used.
+// It returns error if grid is nil, if the number of values differs from the number of grid units,
+// if opts unit size or hits are negative, if the number of opts hits differs from the number of grid
+// units or if format is not supported, ErrInvalidDims if the grid is not 2D,
+// ErrUnsupportedGrid if it is spherical, or error if the write to w fails.
+func Heatmap(w io.Writer, grid *som.Grid, values []float64, format string, opts *Options) error {
+	if grid == nil {
+		return fmt.Errorf("invalid grid supplied: %v", grid)
+	}
+	if len(grid.Size()) != 2 {
+		return fmt.Errorf("%w: %v, heatmaps are 2D", som.ErrInvalidDims, grid.Size())
+	}
+	if grid.Type() == string(som.Spherical) {
+		return fmt.Errorf("%w: %s, heatmaps are planar", som.ErrUnsupportedGrid, grid.Type())
+	}
+	coords := grid.Coords()
+	if units, _ := coords.Dims(); len(values) != units {
+		return fmt.Errorf("invalid number of values: %d, grid has %d units", len(values), units)
+	}
+	o := opts.withDefaults()
+	if o.UnitSize < 0 {
+		return fmt.Errorf("invalid unit size: %d", o.UnitSize)
+	}
+	if o.Hits != nil && len(o.Hits) != len(values) {
+		return fmt.Errorf("invalid number of hits: %d, grid has %d units", len(o.Hits), len(values))
+	}
+	for i, hits := range o.Hits {
+		if hits < 0 {
+			return fmt.Errorf("invalid hits of unit %d: %d, must not be negative", i, hits)
+		}
+	}
+	h := newHeatmap(grid, values, o)
+	switch strings.ToLower(format) {
+	case "png":
+		return png.Encode(w, h.image())
+	case "svg":
+		return h.svg(w)
+	}
+	return fmt.Errorf("unsupported image format: %s", format)
+}
+
+// heatmap holds unit polygons and colors of rendered image
+type heatmap struct {
+	// polygons holds vertices of every unit polygon in pixels
+	polygons [][][2]float64
+	// colors holds colors of units
+	colors []color.RGBA
+	// markers holds vertices of hit marker polygons in pixels
+	markers [][][2]float64
+	// markerColor is color of hit markers
+	markerColor color.RGBA
+	// width and height are image dimensions in pixels
+	width, height int
+}
+
+// newHeatmap returns heatmap of values of grid units rendered with options o
+func newHeatmap(grid *som.Grid, values []float64, o Options) *heatmap {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+	}
+	maxHits := 0
+	for _, hits := range o.Hits {
+		if hits > maxHits {
+			maxHits = hits
+		}
+	}
+	shape := unitShape(grid.UShape())
+	coords := grid.Coords()
+	px := float64(o.UnitSize)
+	h := &heatmap{
+		polygons:    make([][][2]float64, len(values)),
+		colors:      make([]color.RGBA, len(values)),
+		markerColor: color.RGBAModel.Convert(o.HitColor).(color.RGBA),
+	}
+	// units are offset by unit size from the image edges
+	for i, v := range values {
+		x, y := (coords.At(i, 0)+1)*px, (coords.At(i, 1)+1)*px
+		h.polygons[i] = polygon(shape, x, y, px)
+		if maxHits > 0 && o.Hits[i] > 0 {
+			// marker area is proportional to unit hits
+			scale := maxHitScale * math.Sqrt(float64(o.Hits[i])/float64(maxHits))
+			h.markers = append(h.markers, polygon(shape, x, y, scale*px))
+		}
+		h.width = int(math.Max(float64(h.width), math.Ceil(x+px)))
+		h.height = int(math.Max(float64(h.height), math.Ceil(y+px)))
+		if math.IsNaN(v) {
+			h.colors[i] = color.RGBAModel.Convert(o.MissingColor).(color.RGBA)
+			continue
+		}
+		scaled := 0.5
+		if max > min {
+			scaled = (v - min) / (max - min)
+		}
+		h.colors[i] = color.RGBAModel.Convert(o.Palette(scaled)).(color.RGBA)
+	}
+	return h
+}
+
+// unitShape returns polygon vertices of unit of shape uShape centered at the origin
+// of grid whose neighbouring units are a unit apart
+func unitShape(uShape string) [][2]float64 {
+	if uShape == string(som.Hexagon) {
+		big := math.Tan(math.Pi / 6)
+		small := big / 2
+		return [][2]float64{{0.5, small}, {0, big}, {-0.5, small}, {-0.5, -small}, {0, -big}, {0.5, -small}}
+	}
+	return [][2]float64{{0.5, 0.5}, {-0.5, 0.5}, {-0.5, -0.5}, {0.5, -0.5}}
+}
+
+// polygon returns vertices of polygon shape scaled by size and centered at x, y
+func polygon(shape [][2]float64, x, y, size float64) [][2]float64 {
+	poly := make([][2]float64, len(shape))
+	for i, p := range shape {
+		poly[i] = [2]float64{x + p[0]*size, y + p[1]*size}
+	}
+	return poly
+}
+
+// image rasterizes heatmap: pixels whose centers lie inside a unit polygon have the unit color,
+// pixels inside a hit marker have the marker color and the remaining pixels are transparent
+func (h *heatmap) image() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, h.width, h.height))
+	for i, poly := range h.polygons {
+		fill(img, poly, h.colors[i])
+	}
+	for _, poly := range h.markers {
+		fill(img, poly, h.markerColor)
+	}
+	return img
+}
+
+// fill sets color of img pixels whose centers lie inside convex polygon poly to c
+func fill(img *image.RGBA, poly [][2]float64, c color.RGBA) {
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range poly {
+		minX, maxX = math.Min(minX, p[0]), math.Max(maxX, p[0])
+		minY, maxY = math.Min(minY, p[1]), math.Max(maxY, p[1])
+	}
+	for y := int(math.Floor(minY)); y <= int(maxY); y++ {
+		for x := int(math.Floor(minX)); x <= int(maxX); x++ {
+			if insideConvex(poly, float64(x)+0.5, float64(y)+0.5) {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// insideConvex returns true if point x, y lies inside or on the edge of convex polygon poly
+func insideConvex(poly [][2]float64, x, y float64) bool {
+	sign := 0.0
+	for i, a := range poly {
+		b := poly[(i+1)%len(poly)]
+		cross := (b[0]-a[0])*(y-a[1]) - (b[1]-a[1])*(x-a[0])
+		if cross == 0 {
+			continue
+		}
+		if sign == 0 {
+			sign = math.Copysign(1, cross)
+		} else if math.Copysign(1, cross) != sign {
+			return false
+		}
+	}
+	return true
+}
+
+// svgPolygon is SVG polygon element
+type svgPolygon struct {
+	XMLName xml.Name `xml:"polygon"`
+	Points  string   `xml:"points,attr"`
+	Fill    string   `xml:"fill,attr"`
+	Stroke  string   `xml:"stroke,attr"`
+}
+
+// svgImage is SVG image of unit polygons
+type svgImage struct {
+	XMLName  xml.Name `xml:"svg"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Width    int      `xml:"width,attr"`
+	Height   int      `xml:"height,attr"`
+	Polygons []svgPolygon
+}
+
+// newSVGPolygon returns SVG polygon of vertices poly filled with color c and outlined by stroke
+func newSVGPolygon(poly [][2]float64, c color.RGBA, stroke string) svgPolygon {
+	points := make([]string, len(poly))
+	for i, p := range poly {
+		points[i] = fmt.Sprintf("%.2f,%.2f", p[0], p[1])
+	}
+	fill := "none"
+	if c.A > 0 {
+		fill = fmt.Sprintf("rgb(%d,%d,%d)", c.R, c.G, c.B)
+	}
+	return svgPolygon{
+		Points: strings.Join(points, " "),
+		Fill:   fill,
+		Stroke: stroke,
+	}
+}
+
+// svg writes heatmap as SVG image to w
+func (h *heatmap) svg(w io.Writer) error {
+	img := svgImage{Xmlns: "http://www.w3.org/2000/svg", Width: h.width, Height: h.height}
+	for i, poly := range h.polygons {
+		img.Polygons = append(img.Polygons, newSVGPolygon(poly, h.colors[i], "black"))
+	}
+	for _, poly := range h.markers {
+		img.Polygons = append(img.Polygons, newSVGPolygon(poly, h.markerColor, "none"))
+	}
+	enc := xml.NewEncoder(w)
+	if err := enc.Encode(img); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
@@ -0,0 +1,196 @@
+package viz
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/som"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMap returns map of random data with grid of the given size, type and unit shape
+func newMap(t *testing.T, size []int, gridType som.GridType, uShape som.UnitShape) *som.Map {
+	data := mat64.NewDense(20, 2, nil)
+	for i := 0; i < 20; i++ {
+		data.SetRow(i, []float64{float64(i % 5), float64(i / 5)})
+	}
+	m, err := som.NewMap(&som.MapConfig{
+		Grid: &som.GridConfig{Size: size, Type: gridType, UShape: uShape},
+		Cb:   &som.CbConfig{Dim: 2, InitFunc: som.RandInit},
+	}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestNewPalette(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewPalette(color.Black, color.RGBA{200, 100, 0, 255}, color.White)
+	assert.Equal(color.RGBA{0, 0, 0, 255}, p(0))
+	assert.Equal(color.RGBA{100, 50, 0, 255}, p(0.25))
+	assert.Equal(color.RGBA{200, 100, 0, 255}, p(0.5))
+	assert.Equal(color.RGBA{255, 255, 255, 255}, p(1))
+	// values out of range are clamped
+	assert.Equal(p(0), p(-1))
+	assert.Equal(p(1), p(2))
+	assert.Equal(color.RGBA{255, 255, 255, 255}, Gray(0))
+	assert.Equal(color.RGBA{255, 0, 0, 255}, Heat(1))
+	assert.Panics(func() { NewPalette(color.Black) })
+}
+
+func TestHeatmapPNG(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, uShape := range []som.UnitShape{som.Hexagon, som.Rectangle} {
+		m := newMap(t, []int{3, 4}, som.Planar, uShape)
+		values := make([]float64, 12)
+		values[5] = 1.0
+		var b bytes.Buffer
+		assert.NoError(Heatmap(&b, m.Grid(), values, "png", &Options{Palette: Heat, UnitSize: 10}))
+		img, err := png.Decode(&b)
+		assert.NoError(err)
+		// unit 5 is at row 2, col 1
+		coords := m.Grid().Coords()
+		x, y := int((coords.At(5, 0)+1)*10), int((coords.At(5, 1)+1)*10)
+		assert.Equal(color.RGBAModel.Convert(Heat(1)), color.RGBAModel.Convert(img.At(x, y)))
+		x, y = int((coords.At(0, 0)+1)*10), int((coords.At(0, 1)+1)*10)
+		assert.Equal(color.RGBAModel.Convert(Heat(0)), color.RGBAModel.Convert(img.At(x, y)))
+		// image corners are outside of units
+		_, _, _, a := img.At(0, 0).RGBA()
+		assert.Equal(uint32(0), a)
+		assert.True(img.Bounds().Dx() >= 50 && img.Bounds().Dy() >= 30, "%s: %v", uShape, img.Bounds())
+	}
+}
+
+func TestHeatmapSVG(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newMap(t, []int{4, 3}, som.Toroidal, som.Hexagon)
+	values := make([]float64, 12)
+	var b bytes.Buffer
+	assert.NoError(Heatmap(&b, m.Grid(), values, "SVG", nil))
+	svg := b.String()
+	assert.Equal(12, strings.Count(svg, "<polygon"))
+	// constant values are rendered in the middle of the palette
+	assert.Equal(12, strings.Count(svg, `fill="rgb(128,128,128)"`))
+}
+
+func TestHeatmapHits(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newMap(t, []int{3, 4}, som.Planar, som.Rectangle)
+	values := make([]float64, 12)
+	hits := make([]int, 12)
+	hits[5], hits[7] = 8, 2
+	opts := &Options{Palette: Gray, UnitSize: 20, Hits: hits}
+	var b bytes.Buffer
+	assert.NoError(Heatmap(&b, m.Grid(), values, "svg", opts))
+	// units with hits are marked by red polygons
+	svg := b.String()
+	assert.Equal(14, strings.Count(svg, "<polygon"))
+	assert.Equal(2, strings.Count(svg, `fill="rgb(255,0,0)"`))
+	b.Reset()
+	assert.NoError(Heatmap(&b, m.Grid(), values, "png", opts))
+	img, err := png.Decode(&b)
+	assert.NoError(err)
+	red := color.RGBAModel.Convert(color.RGBA{255, 0, 0, 255})
+	coords := m.Grid().Coords()
+	marked := func(unit, dx int) bool {
+		x, y := int((coords.At(unit, 0)+1)*20), int((coords.At(unit, 1)+1)*20)
+		return color.RGBAModel.Convert(img.At(x+dx, y)) == red
+	}
+	assert.True(marked(5, 0))
+	assert.True(marked(7, 0))
+	assert.False(marked(0, 0))
+	// marker area is proportional to hits: unit 7 marker is half as wide as unit 5 marker
+	assert.True(marked(5, 6))
+	assert.False(marked(7, 6))
+	// invalid hits
+	assert.EqualError(Heatmap(&b, m.Grid(), values, "png", &Options{Hits: make([]int, 3)}), "invalid number of hits: 3, grid has 12 units")
+	hits[0] = -1
+	assert.EqualError(Heatmap(&b, m.Grid(), values, "png", opts), "invalid hits of unit 0: -1, must not be negative")
+}
+
+func TestUMatrix(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newMap(t, []int{4, 5}, som.Planar, som.Hexagon)
+	var b bytes.Buffer
+	assert.NoError(UMatrix(&b, m, "png", nil))
+	_, err := png.Decode(&b)
+	assert.NoError(err)
+	assert.Error(UMatrix(&b, nil, "png", nil))
+}
+
+func TestComponentPlane(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newMap(t, []int{3, 4}, som.Planar, som.Rectangle)
+	plane, err := m.ComponentPlane(1)
+	assert.NoError(err)
+	var b, exp bytes.Buffer
+	assert.NoError(ComponentPlane(&b, m, 1, "svg", nil))
+	assert.NoError(Heatmap(&exp, m.Grid(), plane, "svg", nil))
+	assert.Equal(exp.String(), b.String())
+	assert.Error(ComponentPlane(&b, m, 2, "svg", nil))
+	assert.Error(ComponentPlane(&b, nil, 0, "svg", nil))
+}
+
+func TestQErrorMap(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newMap(t, []int{3, 4}, som.Planar, som.Rectangle)
+	data := mat64.NewDense(2, 2, []float64{0, 0, 4, 3})
+	errs, err := m.QErrorMap(data)
+	assert.NoError(err)
+	var b, exp bytes.Buffer
+	assert.NoError(QErrorMap(&b, m, data, "svg", nil))
+	assert.NoError(Heatmap(&exp, m.Grid(), errs, "svg", nil))
+	assert.Equal(exp.String(), b.String())
+	assert.Error(QErrorMap(&b, m, nil, "svg", nil))
+	assert.Error(QErrorMap(&b, nil, data, "svg", nil))
+}
+
+func TestHeatmapMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newMap(t, []int{3, 4}, som.Planar, som.Rectangle)
+	values := make([]float64, 12)
+	values[1], values[2] = 1.0, math.NaN()
+	var b bytes.Buffer
+	// units with NaN values are transparent
+	assert.NoError(Heatmap(&b, m.Grid(), values, "svg", nil))
+	assert.Equal(1, strings.Count(b.String(), `fill="none"`))
+	assert.Equal(1, strings.Count(b.String(), `fill="rgb(0,0,0)"`))
+	b.Reset()
+	opts := &Options{MissingColor: color.RGBA{0, 0, 255, 255}}
+	assert.NoError(Heatmap(&b, m.Grid(), values, "png", opts))
+	img, err := png.Decode(&b)
+	assert.NoError(err)
+	coords := m.Grid().Coords()
+	x, y := int((coords.At(2, 0)+1)*DefaultUnitSize), int((coords.At(2, 1)+1)*DefaultUnitSize)
+	assert.Equal(color.RGBAModel.Convert(color.RGBA{0, 0, 255, 255}), color.RGBAModel.Convert(img.At(x, y)))
+}
+
+func TestHeatmapErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newMap(t, []int{3, 4}, som.Planar, som.Hexagon)
+	var b bytes.Buffer
+	assert.Error(Heatmap(&b, nil, nil, "png", nil))
+	assert.EqualError(Heatmap(&b, m.Grid(), make([]float64, 3), "png", nil), "invalid number of values: 3, grid has 12 units")
+	assert.EqualError(Heatmap(&b, m.Grid(), make([]float64, 12), "gif", nil), "unsupported image format: gif")
+	assert.EqualError(Heatmap(&b, m.Grid(), make([]float64, 12), "png", &Options{UnitSize: -1}), "invalid unit size: -1")
+	cube := newMap(t, []int{2, 2, 2}, som.Planar, som.Rectangle)
+	assert.True(errors.Is(Heatmap(&b, cube.Grid(), make([]float64, 8), "png", nil), som.ErrInvalidDims))
+	sphere := newMap(t, som.SphericalSize(1), som.Spherical, som.Hexagon)
+	assert.True(errors.Is(Heatmap(&b, sphere.Grid(), make([]float64, 12), "png", nil), som.ErrUnsupportedGrid))
+}
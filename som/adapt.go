@@ -0,0 +1,173 @@
+package som
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	// DefaultAdaptRadius is the default initial radius of map adaptation
+	DefaultAdaptRadius = 1.0
+	// DefaultAdaptLRate is the default initial learning rate of map adaptation
+	DefaultAdaptLRate = 0.05
+)
+
+// AdaptConfig holds configuration of adaptation of trained map to new data.
+// Fields which are not set use the documented defaults.
+type AdaptConfig struct {
+	// Algorithm is adaptation method: seq or batch. Seq adaptation runs sequential training,
+	// batch adaptation moves every codebook vector by LRate of the way to its batch training update
+	// in every epoch. The default is seq.
+	Algorithm Method
+	// Radius is initial units radius; it decays exponentially. The default is DefaultAdaptRadius.
+	Radius float64
+	// LRate is initial learning rate: it must not exceed 1. Seq adaptation learning rate decays
+	// linearly, batch adaptation uses the same rate in every epoch. The default is DefaultAdaptLRate.
+	LRate float64
+	// Epochs is the number of passes through new data. The default is 1.
+	Epochs int
+	// MaxChange bounds the change of every codebook vector component to prevent the map from
+	// forgetting data it was trained on. The default zero does not bound the change.
+	MaxChange float64
+	// Seed is the seed of sample selection of seq adaptation
+	Seed int64
+}
+
+// AdaptResult describes how far adaptation moved the map codebook
+type AdaptResult struct {
+	// MeanShift is the mean euclidean distance between codebook vectors before and after adaptation
+	MeanShift float64
+	// MaxShift is the largest euclidean distance between codebook vectors before and after adaptation
+	MaxShift float64
+}
+
+// withDefaults returns a copy of the configuration with defaults of unset fields
+func (c AdaptConfig) withDefaults() AdaptConfig {
+	if c.Algorithm == "" {
+		c.Algorithm = Seq
+	}
+	if c.Radius == 0 {
+		c.Radius = DefaultAdaptRadius
+	}
+	if c.LRate == 0 {
+		c.LRate = DefaultAdaptLRate
+	}
+	if c.Epochs == 0 {
+		c.Epochs = 1
+	}
+	return c
+}
+
+// adapt validates map adaptation configuration with applied defaults
+func (v *validator) adapt(c AdaptConfig) {
+	if c.Algorithm != Seq && c.Algorithm != Batch {
+		if v.param("AdaptConfig.Algorithm", c.Algorithm, fmt.Errorf("%w: %s, adaptation supports seq and batch", ErrUnsupportedMethod, c.Algorithm)) {
+			return
+		}
+	}
+	if !(c.Radius > 0) || math.IsInf(c.Radius, 1) {
+		if v.param("AdaptConfig.Radius", c.Radius, fmt.Errorf("%w: %f, must be positive and finite", ErrInvalidRadius, c.Radius)) {
+			return
+		}
+	}
+	if !(c.LRate > 0 && c.LRate <= 1) {
+		if v.param("AdaptConfig.LRate", c.LRate, fmt.Errorf("%w: %f, must be positive and at most 1", ErrInvalidLRate, c.LRate)) {
+			return
+		}
+	}
+	if c.Epochs < 0 {
+		if v.param("AdaptConfig.Epochs", c.Epochs, fmt.Errorf("invalid number of epochs: %d", c.Epochs)) {
+			return
+		}
+	}
+	if !(c.MaxChange >= 0) || math.IsInf(c.MaxChange, 1) {
+		v.param("AdaptConfig.MaxChange", c.MaxChange, fmt.Errorf("invalid maximum change: %f, must be non-negative and finite", c.MaxChange))
+	}
+}
+
+// Adapt shifts codebook vectors of trained map towards newData by a short training on newData only,
+// whose small radius preserves the map topology. If cfg MaxChange is set, every codebook vector
+// component changes by at most MaxChange. Adapt records how far the codebook moved; see LastAdapt.
+// It returns error if newData is nil, ErrDimMismatch if newData dimension is different from the
+// codebook dimension or error which joins all problems of cfg.
+func (m *Map) Adapt(newData *mat64.Dense, cfg AdaptConfig) error {
+	// data can't be nil
+	if newData == nil {
+		return fmt.Errorf("invalid data supplied: %v", newData)
+	}
+	rows, cols := newData.Dims()
+	if _, dim := m.cbDims(); cols != dim {
+		return ErrDimMismatch
+	}
+	c := cfg.withDefaults()
+	v := &validator{}
+	v.adapt(c)
+	if err := v.err(); err != nil {
+		return err
+	}
+	tc := &TrainConfig{
+		Algorithm: c.Algorithm,
+		Radius:    c.Radius,
+		RDecay:    ExpDecay,
+		NeighbFn:  Gaussian,
+		LRate:     c.LRate,
+		LDecay:    LinDecay,
+	}
+	before := mat64.DenseCopyOf(m.cb())
+	if c.Algorithm == Seq {
+		if _, err := m.train(tc, newData, c.Epochs*rows, rand.New(rand.NewSource(c.Seed))); err != nil {
+			return err
+		}
+	} else {
+		for epoch := 0; epoch < c.Epochs; epoch++ {
+			// batch update target is computed on a copy of the codebook
+			target := &Map{codebook: mat64.DenseCopyOf(m.cb()), grid: m.grid, relevance: m.relevance, metric: m.metric}
+			if err := target.batchTrain(context.Background(), tc, newData, 1); err != nil {
+				return err
+			}
+			units, _ := m.cbDims()
+			cb := m.cb()
+			for u := 0; u < units; u++ {
+				vec := cb.RawRowView(u)
+				for j, t := range target.codebook.RawRowView(u) {
+					vec[j] += c.LRate * (t - vec[j])
+				}
+				m.setUnit(u, vec)
+			}
+		}
+	}
+	// bound the change and measure the codebook shift
+	units, _ := m.cbDims()
+	cb := m.cb()
+	res := &AdaptResult{}
+	for u := 0; u < units; u++ {
+		vec, old := cb.RawRowView(u), before.RawRowView(u)
+		if c.MaxChange > 0 {
+			for j := range vec {
+				vec[j] = math.Max(old[j]-c.MaxChange, math.Min(old[j]+c.MaxChange, vec[j]))
+			}
+			m.setUnit(u, vec)
+		}
+		shift := euclideanVec(vec, old)
+		res.MeanShift += shift / float64(units)
+		res.MaxShift = math.Max(res.MaxShift, shift)
+	}
+	m.tree = nil
+	m.norms = m.cbNorms()
+	m.quality = nil
+	m.adapt = res
+	return nil
+}
+
+// LastAdapt returns the codebook shift of the last Adapt call or nil if the map was not adapted
+func (m Map) LastAdapt() *AdaptResult {
+	if m.adapt == nil {
+		return nil
+	}
+	res := *m.adapt
+	return &res
+}
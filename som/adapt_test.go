@@ -0,0 +1,116 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeDrift generates n samples of two clusters; the second cluster is shifted by drift
+func makeDrift(n int, drift float64, seed int64) *mat64.Dense {
+	r := rand.New(rand.NewSource(seed))
+	data := mat64.NewDense(n, 2, nil)
+	for i := 0; i < n; i++ {
+		c := 0.0
+		if i%2 == 1 {
+			c = 5.0 + drift
+		}
+		data.Set(i, 0, c+r.NormFloat64()*0.5)
+		data.Set(i, 1, c+r.NormFloat64()*0.5)
+	}
+	return data
+}
+
+func TestAdapt(t *testing.T) {
+	assert := assert.New(t)
+
+	old := makeDrift(400, 0.0, 1)
+	oldTest := makeDrift(400, 0.0, 2)
+	drifted := makeDrift(400, 1.0, 3)
+	driftedTest := makeDrift(400, 1.0, 4)
+	mc, tc := mergeConfigs([]int{5, 5})
+	for _, alg := range []Method{Seq, Batch} {
+		m, err := NewMap(mc, old)
+		assert.NoError(err)
+		_, err = m.train(tc, old, 4000, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		assert.Nil(m.LastAdapt())
+		oldQE, err := m.QuantError(oldTest)
+		assert.NoError(err)
+		driftQE, err := m.QuantError(driftedTest)
+		assert.NoError(err)
+		cfg := AdaptConfig{Algorithm: alg, Epochs: 5, Seed: 1}
+		if alg == Batch {
+			cfg.LRate = 0.2
+		}
+		assert.NoError(m.Adapt(drifted, cfg))
+		adaptedOldQE, err := m.QuantError(oldTest)
+		assert.NoError(err)
+		adaptedDriftQE, err := m.QuantError(driftedTest)
+		assert.NoError(err)
+		assert.True(adaptedDriftQE < 0.8*driftQE, "%s: drifted data error after adaptation: %f, before: %f", alg, adaptedDriftQE, driftQE)
+		assert.True(adaptedOldQE < 1.35*oldQE, "%s: old data error after adaptation: %f, before: %f", alg, adaptedOldQE, oldQE)
+		res := m.LastAdapt()
+		assert.True(res.MeanShift > 0 && res.MaxShift >= res.MeanShift)
+	}
+}
+
+func TestAdaptMaxChange(t *testing.T) {
+	assert := assert.New(t)
+
+	old := makeDrift(200, 0.0, 1)
+	drifted := makeDrift(200, 2.0, 2)
+	mc, tc := mergeConfigs([]int{4, 4})
+	m, err := NewMap(mc, old)
+	assert.NoError(err)
+	_, err = m.train(tc, old, 2000, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	before := mat64.DenseCopyOf(m.CodebookRaw())
+	assert.NoError(m.Adapt(drifted, AdaptConfig{LRate: 0.5, Epochs: 3, MaxChange: 0.1}))
+	shifts := make([]float64, 16)
+	for i := 0; i < 16; i++ {
+		for j := 0; j < 2; j++ {
+			assert.True(math.Abs(m.CodebookRaw().At(i, j)-before.At(i, j)) <= 0.1+1e-12)
+		}
+		shifts[i] = euclideanVec(m.CodebookRaw().RawRowView(i), before.RawRowView(i))
+	}
+	res := m.LastAdapt()
+	assert.True(res.MaxShift <= 0.1*math.Sqrt2+1e-12)
+	mean, max := 0.0, 0.0
+	for _, s := range shifts {
+		mean += s / 16
+		max = math.Max(max, s)
+	}
+	assert.InDelta(mean, res.MeanShift, 1e-12)
+	assert.Equal(max, res.MaxShift)
+	// returned result is a copy
+	res.MaxShift = 10.0
+	assert.NotEqual(10.0, m.LastAdapt().MaxShift)
+}
+
+func TestAdaptErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeDrift(20, 0.0, 1)
+	mc, _ := mergeConfigs([]int{2, 2})
+	m, err := NewMap(mc, data)
+	assert.NoError(err)
+	assert.EqualError(m.Adapt(nil, AdaptConfig{}), fmt.Sprintf("invalid data supplied: %v", nil))
+	assert.Equal(ErrDimMismatch, m.Adapt(mat64.NewDense(2, 3, nil), AdaptConfig{}))
+	err = m.Adapt(data, AdaptConfig{Algorithm: NeuralGas, Radius: -1, LRate: 2, Epochs: -1, MaxChange: -1})
+	assert.Error(err)
+	for _, msg := range []string{
+		"neuralgas, adaptation supports seq and batch",
+		"invalid radius: -1.000000, must be positive and finite",
+		"invalid learning rate: 2.000000, must be positive and at most 1",
+		"invalid number of epochs: -1",
+		"invalid maximum change: -1.000000, must be non-negative and finite",
+	} {
+		assert.Contains(err.Error(), msg)
+	}
+	assert.Nil(m.LastAdapt())
+}
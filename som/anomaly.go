@@ -0,0 +1,94 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+)
+
+// FitAnomalyThreshold computes the quantile of BMU distances of data samples and stores it
+// in the map as the anomaly threshold. Samples whose BMU distance exceeds the threshold
+// are considered to be anomalies. quantile is the expected fraction of data samples
+// which are not anomalies, so 1-quantile is the expected false positive rate.
+// It returns error if data is nil, if quantile is not in (0, 1] interval or ErrDimMismatch
+// if data and codebook dimensions differ.
+func (m *Map) FitAnomalyThreshold(data *mat64.Dense, quantile float64) error {
+	if quantile <= 0.0 || quantile > 1.0 {
+		return fmt.Errorf("invalid quantile: %f", quantile)
+	}
+	scores, err := m.AnomalyScores(data)
+	if err != nil {
+		return err
+	}
+	sort.Float64s(scores)
+	m.threshold = stat.Quantile(quantile, stat.Empirical, scores, nil)
+	m.hasThreshold = true
+
+	return nil
+}
+
+// SetAnomalyThreshold sets the anomaly threshold of the map, such as a threshold computed by
+// FitAnomalyThreshold of another map or tuned in a monitoring pipeline.
+// It returns error if threshold is negative or not a number.
+func (m *Map) SetAnomalyThreshold(threshold float64) error {
+	if !(threshold >= 0) {
+		return fmt.Errorf("invalid anomaly threshold: %f", threshold)
+	}
+	m.threshold, m.hasThreshold = threshold, true
+	return nil
+}
+
+// AnomalyThreshold returns the anomaly threshold computed by FitAnomalyThreshold or set by SetAnomalyThreshold.
+// It returns NaN if the threshold has not been computed.
+func (m Map) AnomalyThreshold() float64 {
+	if !m.hasThreshold {
+		return math.NaN()
+	}
+	return m.threshold
+}
+
+// AnomalyScores returns anomaly scores of all data rows.
+// Anomaly score of a sample is the distance between the sample and its BMU.
+// Like Predict, AnomalyScores scales data rows by the map scaler if the map has one.
+// It returns error if data is nil or ErrDimMismatch if data and codebook dimensions differ.
+func (m Map) AnomalyScores(data *mat64.Dense) ([]float64, error) {
+	_, scores, err := m.Predict(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// Score returns anomaly score of sample x: the distance between x and its BMU. If the map has
+// a scaler set by SetScaler, x is scaled by it first in the same way as by AnomalyScores, so scores
+// of single samples of a monitored stream can be compared with the map anomaly threshold.
+// It returns error if x has no observed values or ErrDimMismatch if the dimension of x is different
+// from the map codebook dimension; the score is NaN when the error is returned.
+func (m Map) Score(x []float64) (float64, error) {
+	x, err := m.ScaleSample(x)
+	if err != nil {
+		return math.NaN(), err
+	}
+	_, score, err := m.BMU(x)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return score, nil
+}
+
+// IsAnomaly returns true if the anomaly score of x computed by Score exceeds the map anomaly threshold.
+// It also returns the anomaly score of x.
+// It returns error if the anomaly threshold has not been computed or if Score fails.
+func (m Map) IsAnomaly(x []float64) (bool, float64, error) {
+	if !m.hasThreshold {
+		return false, math.NaN(), fmt.Errorf("anomaly threshold not fitted")
+	}
+	score, err := m.Score(x)
+	if err != nil {
+		return false, math.NaN(), err
+	}
+	return score > m.threshold, score, nil
+}
@@ -0,0 +1,153 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeGaussian generates n samples from standard normal distribution of dimension dim
+// shifted by offset in every dimension
+func makeGaussian(n, dim int, offset float64, seed int64) *mat64.Dense {
+	r := rand.New(rand.NewSource(seed))
+	data := mat64.NewDense(n, dim, nil)
+	for i := 0; i < n; i++ {
+		for j := 0; j < dim; j++ {
+			data.Set(i, j, r.NormFloat64()+offset)
+		}
+	}
+	return data
+}
+
+func TestAnomaly(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeGaussian(1000, 2, 0.0, 1)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{6, 6},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      2,
+			InitFunc: RandInit,
+		},
+	}
+	tCfg := &TrainConfig{
+		Algorithm: "seq",
+		Radius:    3.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	err = m.Train(tCfg, data, 10000)
+	assert.NoError(err)
+	// threshold not fitted
+	assert.True(math.IsNaN(m.AnomalyThreshold()))
+	_, _, err = m.IsAnomaly(data.RawRowView(0))
+	assert.EqualError(err, "anomaly threshold not fitted")
+	// invalid parameters
+	errString := "invalid quantile: %f"
+	for _, q := range []float64{0.0, -0.5, 1.5} {
+		err = m.FitAnomalyThreshold(data, q)
+		assert.EqualError(err, fmt.Sprintf(errString, q))
+	}
+	err = m.FitAnomalyThreshold(nil, 0.95)
+	assert.Error(err)
+	err = m.FitAnomalyThreshold(mat64.NewDense(2, 3, nil), 0.95)
+	assert.Equal(ErrDimMismatch, err)
+	// fit the threshold
+	err = m.FitAnomalyThreshold(data, 0.95)
+	assert.NoError(err)
+	assert.True(m.AnomalyThreshold() > 0.0)
+	// in-distribution samples are flagged at roughly the false positive rate
+	test := makeGaussian(2000, 2, 0.0, 2)
+	rows, _ := test.Dims()
+	flagged := 0
+	for i := 0; i < rows; i++ {
+		anomaly, score, err := m.IsAnomaly(test.RawRowView(i))
+		assert.NoError(err)
+		assert.True(score >= 0.0)
+		if anomaly {
+			flagged++
+		}
+	}
+	fpr := float64(flagged) / float64(rows)
+	assert.InDelta(0.05, fpr, 0.03, "false positive rate: %f", fpr)
+	// samples drawn far away are flagged
+	far := makeGaussian(100, 2, 10.0, 3)
+	scores, err := m.AnomalyScores(far)
+	assert.NoError(err)
+	assert.Len(scores, 100)
+	for i, score := range scores {
+		anomaly, s, err := m.IsAnomaly(far.RawRowView(i))
+		assert.NoError(err)
+		assert.True(anomaly)
+		assert.Equal(score, s)
+	}
+	// samples in original units are scaled by the map scaler
+	scaler := &Scaler{Method: ZScore, Center: []float64{1, -1}, Scale: []float64{2, 2}}
+	orig, err := scaler.Inverse(far)
+	assert.NoError(err)
+	assert.NoError(m.SetScaler(scaler))
+	origScores, err := m.AnomalyScores(orig)
+	assert.NoError(err)
+	assert.InDeltaSlice(scores, origScores, 1e-9)
+	for i, score := range scores {
+		_, s, err := m.IsAnomaly(orig.RawRowView(i))
+		assert.NoError(err)
+		assert.InDelta(score, s, 1e-9)
+	}
+	// dimension mismatch
+	_, _, err = m.IsAnomaly([]float64{1.0})
+	assert.Equal(ErrDimMismatch, err)
+}
+
+func TestAnomalyScore(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeGaussian(200, 2, 5.0, 1)
+	scaler, err := FitScaler(ZScore, data)
+	assert.NoError(err)
+	scaled, err := scaler.Transform(data)
+	assert.NoError(err)
+	m, err := NewMap(makePrecisionMapCfg([]int{4, 3}, 2, ""), scaled)
+	assert.NoError(err)
+	_, err = m.train(gsomTrainConfig(), scaled, 1000, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	assert.NoError(m.SetScaler(scaler))
+	// single sample scores match scores of data in original units
+	scores, err := m.AnomalyScores(data)
+	assert.NoError(err)
+	for i := 0; i < 10; i++ {
+		score, err := m.Score(data.RawRowView(i))
+		assert.NoError(err)
+		assert.InDelta(scores[i], score, 1e-12)
+	}
+	_, err = m.Score([]float64{1.0})
+	assert.Equal(ErrDimMismatch, err)
+	_, err = m.Score([]float64{math.NaN(), math.NaN()})
+	assert.Error(err)
+	// threshold set by the monitoring pipeline
+	assert.NoError(m.SetAnomalyThreshold(0.5))
+	assert.Equal(0.5, m.AnomalyThreshold())
+	anomaly, score, err := m.IsAnomaly([]float64{50.0, 50.0})
+	assert.NoError(err)
+	assert.True(anomaly)
+	assert.True(score > 0.5)
+	anomaly, _, err = m.IsAnomaly(data.RawRowView(0))
+	assert.NoError(err)
+	assert.Equal(scores[0] > 0.5, anomaly)
+	for _, th := range []float64{-1.0, math.NaN()} {
+		assert.Error(m.SetAnomalyThreshold(th))
+	}
+	assert.Equal(0.5, m.AnomalyThreshold())
+}
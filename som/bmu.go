@@ -0,0 +1,89 @@
+package som
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// bmuBlockRows is the number of data rows whose BMUs are found using a single matrix multiplication
+const bmuBlockRows = 256
+
+// bmuTolerance is the relative tolerance of the BMU search scores: all units whose score is
+// within the tolerance of the best score are rescored using the exact euclidean distance
+const bmuTolerance = 1e-9
+
+// sqNorms returns a slice of squared euclidean norms of mx rows
+func sqNorms(mx *mat64.Dense) []float64 {
+	rows, _ := mx.Dims()
+	norms := make([]float64, rows)
+	for i := range norms {
+		for _, v := range mx.RawRowView(i) {
+			norms[i] += v * v
+		}
+	}
+	return norms
+}
+
+// matMul returns mul or function which computes matrix products by mat64.Dense.Mul if mul is nil
+func matMul(mul MatMulFunc) MatMulFunc {
+	if mul != nil {
+		return mul
+	}
+	return func(dst *mat64.Dense, a, b mat64.Matrix) {
+		dst.Mul(a, b)
+	}
+}
+
+// blockBMUs finds BMUs of data rows in range from to to-1 and stores their indices in bmus
+// and their distances in dists if dists is not nil. norms must contain squared norms of cb rows.
+// Squared euclidean distance between vector x and codebook vector c is |c|^2 - 2*x.c + |x|^2,
+// so BMU minimizes |c|^2 - 2*x.c; the dot products of a block of data rows with all codebook
+// vectors are computed by a single matrix multiplication. Units which score within rounding
+// tolerance of the best one are rescored exactly, so the results are the same as linear scan.
+// The products are computed by mul or by mat64.Dense.Mul if mul is nil.
+// Data and codebook dimensions must be the same.
+func blockBMUs(data, cb *mat64.Dense, norms []float64, from, to int, bmus []int, dists []float64, mul MatMulFunc) {
+	units, cols := cb.Dims()
+	maxNorm := 0.0
+	for _, n := range norms {
+		maxNorm = math.Max(maxNorm, n)
+	}
+	buf := make([]float64, bmuBlockRows*units)
+	for b := from; b < to; b += bmuBlockRows {
+		n := bmuBlockRows
+		if b+n > to {
+			n = to - b
+		}
+		prod := mat64.NewDense(n, units, buf[:n*units])
+		matMul(mul)(prod, data.View(b, 0, n, cols), cb.T())
+		for i := 0; i < n; i++ {
+			x := data.RawRowView(b + i)
+			dots := prod.RawRowView(i)
+			best := math.MaxFloat64
+			for j, dot := range dots {
+				if s := norms[j] - 2*dot; s < best {
+					best = s
+				}
+			}
+			xx := 0.0
+			for _, v := range x {
+				xx += v * v
+			}
+			limit := best + bmuTolerance*(xx+maxNorm)
+			bmu, dist := -1, math.MaxFloat64
+			for j, dot := range dots {
+				if norms[j]-2*dot > limit {
+					continue
+				}
+				if d := euclideanVec(x, cb.RawRowView(j)); d < dist {
+					bmu, dist = j, d
+				}
+			}
+			bmus[b-from+i] = bmu
+			if dists != nil {
+				dists[b-from+i] = dist
+			}
+		}
+	}
+}
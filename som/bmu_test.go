@@ -0,0 +1,76 @@
+package som
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSqNorms(t *testing.T) {
+	assert := assert.New(t)
+
+	mx := mat64.NewDense(3, 2, []float64{0.0, 0.0, 3.0, 4.0, -1.0, 2.0})
+	assert.Equal([]float64{0.0, 25.0, 5.0}, sqNorms(mx))
+}
+
+func TestBlockBMUs(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		dim      int
+		max, min float64
+	}{
+		{1, 10.0, -10.0},
+		{4, 10.0, -10.0},
+		{50, 10.0, -10.0},
+		// far from the origin: norms are large compared to distances
+		{8, 10010.0, 9990.0},
+	}
+	for _, tc := range testCases {
+		data := utils.GenerateClusters(600, tc.dim, 5, tc.max, tc.min, 2.0, 10)
+		cb := utils.GenerateClusters(120, tc.dim, 5, tc.max, tc.min, 2.0, 20)
+		// duplicate codebook vectors: ties must be broken by the lowest index
+		for i := 0; i < 10; i++ {
+			cb.SetRow(60+i, cb.RawRowView(i))
+			data.SetRow(300+i, cb.RawRowView(i))
+		}
+		m := &Map{codebook: cb}
+		norms := sqNorms(cb)
+		rows, _ := data.Dims()
+		bmus := make([]int, rows)
+		dists := make([]float64, rows)
+		blockBMUs(data, cb, norms, 0, rows, bmus, dists, nil)
+		for i := 0; i < rows; i++ {
+			bmu, dist, err := m.BMU(data.RawRowView(i))
+			assert.NoError(err)
+			assert.Equal(bmu, bmus[i], "dim: %d, row: %d", tc.dim, i)
+			assert.InDelta(dist, dists[i], 1e-9, "dim: %d, row: %d", tc.dim, i)
+		}
+		// partial range without distances
+		part := make([]int, 257)
+		blockBMUs(data, cb, norms, 100, 357, part, nil, nil)
+		assert.Equal(bmus[100:357], part)
+	}
+}
+
+func BenchmarkBlockBMUs(b *testing.B) {
+	data := utils.GenerateClusters(1000, 50, 10, 10.0, -10.0, 2.0, 10)
+	cb := utils.GenerateClusters(2500, 50, 10, 10.0, -10.0, 2.0, 20)
+	m := &Map{codebook: cb}
+	rows, _ := data.Dims()
+	b.Run("naive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < rows; j++ {
+				m.BMU(data.RawRowView(j))
+			}
+		}
+	})
+	b.Run("norms", func(b *testing.B) {
+		bmus := make([]int, rows)
+		for i := 0; i < b.N; i++ {
+			blockBMUs(data, cb, sqNorms(cb), 0, rows, bmus, nil, nil)
+		}
+	})
+}
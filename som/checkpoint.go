@@ -0,0 +1,125 @@
+package som
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Checkpoint holds state of seq or batch training which is written every TrainConfig.CheckpointEvery
+// iterations, so training which did not finish, for example because the process crashed,
+// can be resumed by ResumeTraining.
+type Checkpoint struct {
+	// Algorithm is the training algorithm
+	Algorithm Method
+	// Iter is the number of completed training iterations
+	Iter int
+	// Iters is the number of iterations of the whole training
+	Iters int
+	// Radius and LRate are initial radius and learning rate of the training. Radius derived
+	// by AutoRadius is stored, so the resumed training continues the same schedule.
+	Radius float64
+	LRate  float64
+	// Codebook holds codebook vectors after Iter iterations
+	Codebook *mat64.Dense
+}
+
+// CheckpointFunc writes training checkpoint cp
+type CheckpointFunc func(cp *Checkpoint) error
+
+// Save writes the checkpoint to w so it can be restored by LoadCheckpoint.
+// It returns error if the write to w fails.
+func (cp *Checkpoint) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(cp)
+}
+
+// LoadCheckpoint reads checkpoint saved by Save from r.
+// It returns error if the read from r fails or if the checkpoint is corrupted.
+func LoadCheckpoint(r io.Reader) (*Checkpoint, error) {
+	cp := new(Checkpoint)
+	if err := gob.NewDecoder(r).Decode(cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint: %s", err)
+	}
+	if cp.Codebook == nil || cp.Iter < 0 || cp.Iter > cp.Iters {
+		return nil, fmt.Errorf("invalid checkpoint: corrupted training state")
+	}
+	return cp, nil
+}
+
+// CheckpointFile returns CheckpointFunc which saves checkpoints to file in path. Every checkpoint
+// is written to a temporary file which then replaces the file in path, so a crash while the checkpoint
+// is written leaves the previous checkpoint intact.
+func CheckpointFile(path string) CheckpointFunc {
+	return func(cp *Checkpoint) error {
+		tmp := path + ".tmp"
+		file, err := os.Create(tmp)
+		if err != nil {
+			return err
+		}
+		if err := cp.Save(file); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		return os.Rename(tmp, path)
+	}
+}
+
+// checkpoint writes checkpoint of training by c after iter out of iters iterations
+// if checkpoints are enabled and one is due
+func (m *Map) checkpoint(c *TrainConfig, iter, iters int) error {
+	if c.CheckpointEvery <= 0 || iter <= c.startIter || iter%c.CheckpointEvery != 0 {
+		return nil
+	}
+	cp := &Checkpoint{
+		Algorithm: c.Algorithm,
+		Iter:      iter,
+		Iters:     iters,
+		Radius:    c.Radius,
+		LRate:     c.LRate,
+		Codebook:  m.Codebook(),
+	}
+	if err := c.Checkpoint(cp); err != nil {
+		return fmt.Errorf("checkpoint after %d iterations failed: %w", iter, err)
+	}
+	return nil
+}
+
+// ResumeTraining resumes training from checkpoint cp: it sets the map codebook to the checkpoint
+// codebook and runs the remaining iterations of the checkpointed training with the same radius and
+// learning rate schedule. The map must have the grid of the checkpointed map and tc the configuration
+// of the checkpointed training, which may write further checkpoints. Resumed seq training picks
+// different random samples than the interrupted training would have picked.
+// ResumeTraining returns error if cp is nil, if cp codebook dimensions are different from the map
+// codebook dimensions, if tc algorithm is different from cp algorithm, if cp training has finished
+// or in the same way as TrainContext; the map is not changed if tc is invalid.
+func (m *Map) ResumeTraining(ctx context.Context, tc *TrainConfig, data mat64.Matrix, cp *Checkpoint) (*TrainResult, error) {
+	if cp == nil || cp.Codebook == nil {
+		return nil, fmt.Errorf("invalid checkpoint: %v", cp)
+	}
+	units, dim := m.cbDims()
+	if rows, cols := cp.Codebook.Dims(); rows != units || cols != dim {
+		return nil, fmt.Errorf("invalid checkpoint codebook dimensions: %d x %d, map codebook has %d x %d", rows, cols, units, dim)
+	}
+	if err := validateTrainConfig(tc); err != nil {
+		return nil, err
+	}
+	if tc.Algorithm != cp.Algorithm {
+		return nil, fmt.Errorf("invalid checkpoint algorithm: %s, training algorithm is %s", cp.Algorithm, tc.Algorithm)
+	}
+	if cp.Iter >= cp.Iters {
+		return nil, fmt.Errorf("invalid checkpoint: training finished after %d iterations", cp.Iters)
+	}
+	c := *tc
+	c.Radius, c.LRate, c.AutoRadius, c.startIter = cp.Radius, cp.LRate, false, cp.Iter
+	for i := 0; i < units; i++ {
+		m.setUnit(i, cp.Codebook.RawRowView(i))
+	}
+	return m.trainSeqs(ctx, &c, data, nil, cp.Iters, trainRand(tc))
+}
@@ -0,0 +1,118 @@
+package som
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	mCfg := makePrecisionMapCfg([]int{4, 3}, 2, "")
+	// seq training writes checkpoints every CheckpointEvery iterations
+	var cps []*Checkpoint
+	tc := gsomTrainConfig()
+	tc.CheckpointEvery = 100
+	tc.Checkpoint = func(cp *Checkpoint) error {
+		cps = append(cps, cp)
+		return nil
+	}
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	assert.NoError(m.Train(tc, data, 450))
+	if assert.Len(cps, 4) {
+		for i, cp := range cps {
+			assert.Equal(Seq, cp.Algorithm)
+			assert.Equal(100*(i+1), cp.Iter)
+			assert.Equal(450, cp.Iters)
+			assert.Equal(2.0, cp.Radius)
+			assert.Equal(0.3, cp.LRate)
+		}
+		assert.False(mat64.Equal(cps[0].Codebook, m.Codebook()))
+	}
+
+	// interrupted batch training resumes from its last checkpoint
+	tc = &TrainConfig{Algorithm: Batch, Radius: 2.0, RDecay: ExpDecay, NeighbFn: Gaussian, LRate: 0.1, LDecay: ExpDecay}
+	ref, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	assert.NoError(ref.Train(tc, data, 10))
+	var last *Checkpoint
+	crash := errors.New("crash")
+	tc.CheckpointEvery = 3
+	tc.Checkpoint = func(cp *Checkpoint) error {
+		if cp.Iter == 9 {
+			return crash
+		}
+		last = cp
+		return nil
+	}
+	m, err = NewMap(mCfg, data)
+	assert.NoError(err)
+	err = m.Train(tc, data, 10)
+	assert.True(errors.Is(err, crash))
+	assert.Contains(err.Error(), "checkpoint after 9 iterations failed")
+	assert.Equal(6, last.Iter)
+	// the checkpoint is restored from a file
+	path := filepath.Join(t.TempDir(), "train.ckpt")
+	assert.NoError(CheckpointFile(path)(last))
+	_, err = os.Stat(path + ".tmp")
+	assert.True(os.IsNotExist(err))
+	file, err := os.Open(path)
+	assert.NoError(err)
+	cp, err := LoadCheckpoint(file)
+	file.Close()
+	assert.NoError(err)
+	assert.Equal(last.Iter, cp.Iter)
+	m, err = NewMap(mCfg, data)
+	assert.NoError(err)
+	tc.CheckpointEvery = 0
+	res, err := m.ResumeTraining(context.Background(), tc, data, cp)
+	assert.NoError(err)
+	assert.Equal(10, res.Iters)
+	assert.True(mat64.EqualApprox(ref.Codebook(), m.Codebook(), 1e-12))
+
+	// invalid checkpoints
+	_, err = m.ResumeTraining(context.Background(), tc, data, nil)
+	assert.Error(err)
+	small, err := NewMap(makePrecisionMapCfg([]int{2, 2}, 2, ""), data)
+	assert.NoError(err)
+	_, err = small.ResumeTraining(context.Background(), tc, data, cp)
+	assert.EqualError(err, "invalid checkpoint codebook dimensions: 12 x 2, map codebook has 4 x 2")
+	seq := *tc
+	seq.Algorithm, seq.LRate = Seq, 0.3
+	_, err = m.ResumeTraining(context.Background(), &seq, data, cp)
+	assert.EqualError(err, "invalid checkpoint algorithm: batch, training algorithm is seq")
+	done := *cp
+	done.Iter = done.Iters
+	_, err = m.ResumeTraining(context.Background(), tc, data, &done)
+	assert.Error(err)
+	_, err = LoadCheckpoint(bytes.NewReader([]byte("garbage")))
+	assert.Error(err)
+
+	// invalid checkpoint configuration
+	for _, c := range []struct {
+		every int
+		fn    CheckpointFunc
+		alg   Method
+		err   string
+	}{
+		{-1, nil, Seq, "invalid checkpoint interval: -1, must not be negative"},
+		{10, nil, Seq, "checkpoint function is not set"},
+		{10, CheckpointFile(path), NeuralGas, "checkpoints support seq and batch training"},
+	} {
+		tc := gsomTrainConfig()
+		tc.Algorithm, tc.CheckpointEvery, tc.Checkpoint = c.alg, c.every, c.fn
+		_, err := m.TrainWithResult(tc, data, 10)
+		if assert.Error(err) {
+			assert.Contains(err.Error(), c.err)
+		}
+	}
+}
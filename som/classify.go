@@ -0,0 +1,228 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Calibration holds unit labels assigned by Calibrate
+type Calibration struct {
+	// Labels holds the majority label of every unit; units which are not BMU of any sample have empty label
+	Labels []string
+	// Purity holds the fraction of unit samples with the unit label
+	Purity []float64
+	// Hist holds label histograms of units: counts of unit samples with every label.
+	// Units which are not BMU of any sample have nil histogram.
+	Hist []map[string]int
+}
+
+// LabelUnits assigns labels to map units by majority vote of labels of the data samples
+// which have the unit as their BMU in the same way as Calibrate.
+// It returns error in the same way as Calibrate.
+func (m *Map) LabelUnits(data *mat64.Dense, labels []string) error {
+	_, err := m.Calibrate(data, labels)
+	return err
+}
+
+// Calibrate assigns labels to map units by majority vote of labels of the data samples
+// which have the unit as their BMU and returns the unit labels with their label histograms,
+// which can be drawn on the map grid. Vote ties are broken in favour of lexicographically
+// smaller label. Units which are not BMU of any sample remain unlabeled.
+// It also stores label purity of each unit: the fraction of unit samples with the majority label.
+// The labels are kept by the map, so Classify can classify new samples by their BMU labels.
+// It returns error if data is nil, if the number of labels is different from the number
+// of data rows or ErrDimMismatch if data and codebook dimensions differ.
+func (m *Map) Calibrate(data *mat64.Dense, labels []string) (*Calibration, error) {
+	// data can't be nil
+	if data == nil {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, _ := data.Dims()
+	if len(labels) != rows {
+		return nil, fmt.Errorf("invalid number of labels: %d", len(labels))
+	}
+	bmus, err := m.BMUs(data)
+	if err != nil {
+		return nil, err
+	}
+	units, _ := m.cbDims()
+	votes := make([]map[string]int, units)
+	for i, bmu := range bmus {
+		if votes[bmu] == nil {
+			votes[bmu] = make(map[string]int)
+		}
+		votes[bmu][labels[i]]++
+	}
+	m.labels = make([]string, units)
+	m.purity = make([]float64, units)
+	for i, v := range votes {
+		if v == nil {
+			continue
+		}
+		total, best := 0, 0
+		for label, count := range v {
+			total += count
+			if count > best || (count == best && label < m.labels[i]) {
+				m.labels[i], best = label, count
+			}
+		}
+		m.purity[i] = float64(best) / float64(total)
+	}
+
+	return &Calibration{Labels: m.labels, Purity: m.purity, Hist: votes}, nil
+}
+
+// UnitLabels returns labels of all map units assigned by LabelUnits.
+// Unlabeled units have empty label. It returns nil if the map units have not been labeled.
+func (m Map) UnitLabels() []string {
+	return m.labels
+}
+
+// Classify returns the label of BMU of vector x and its label purity as a confidence.
+// If the BMU is not labeled, the label of the closest labeled unit on the map grid is returned.
+// If the map has a scaler, x is scaled by it first.
+// It returns error if the map units have not been labeled or ErrDimMismatch if the dimension
+// of x is different from the map codebook dimension.
+func (m Map) Classify(x []float64) (string, float64, error) {
+	if err := m.checkLabels(); err != nil {
+		return "", 0.0, err
+	}
+	x, err := m.ScaleSample(x)
+	if err != nil {
+		return "", 0.0, err
+	}
+	bmu, _, err := m.BMU(x)
+	if err != nil {
+		return "", 0.0, err
+	}
+	unit := m.labeledUnit(bmu)
+	return m.labels[unit], m.purity[unit], nil
+}
+
+// ClassifyVote classifies vector x by a weighted vote of its k best matching units.
+// Each unit votes for its label with the weight of its label purity divided by its distance to x.
+// Unlabeled units vote for the label of the closest labeled unit on the map grid.
+// It returns the winning label and the fraction of total vote weight it received.
+// Like Classify, ClassifyVote scales x by the map scaler if the map has one.
+// ClassifyVote fails in the same way as Classify or if k is not a positive integer.
+func (m Map) ClassifyVote(x []float64, k int) (string, float64, error) {
+	if err := m.checkLabels(); err != nil {
+		return "", 0.0, err
+	}
+	x, err := m.ScaleSample(x)
+	if err != nil {
+		return "", 0.0, err
+	}
+	units, dists, err := m.KBMU(x, k)
+	if err != nil {
+		return "", 0.0, err
+	}
+	votes := make(map[string]float64)
+	total := 0.0
+	for i, u := range units {
+		// exact match wins the vote outright
+		if dists[i] == 0.0 {
+			unit := m.labeledUnit(u)
+			return m.labels[unit], 1.0, nil
+		}
+		unit := m.labeledUnit(u)
+		w := m.purity[unit] / dists[i]
+		votes[m.labels[unit]] += w
+		total += w
+	}
+	label, best := "", math.Inf(-1)
+	for l, w := range votes {
+		if w > best || (w == best && l < label) {
+			label, best = l, w
+		}
+	}
+	if total == 0.0 {
+		return label, 0.0, nil
+	}
+	return label, best / total, nil
+}
+
+// ClassifyAll classifies every data row using Classify and returns slices of labels and confidences.
+// It returns error if data is nil or if any of the rows can not be classified.
+func (m Map) ClassifyAll(data *mat64.Dense) ([]string, []float64, error) {
+	// data can't be nil
+	if data == nil {
+		return nil, nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, _ := data.Dims()
+	labels := make([]string, rows)
+	conf := make([]float64, rows)
+	for i := 0; i < rows; i++ {
+		label, c, err := m.Classify(data.RawRowView(i))
+		if err != nil {
+			return nil, nil, err
+		}
+		labels[i], conf[i] = label, c
+	}
+
+	return labels, conf, nil
+}
+
+// checkLabels returns error if the map has no labeled units
+func (m Map) checkLabels() error {
+	for _, label := range m.labels {
+		if label != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("map units are not labeled")
+}
+
+// labeledUnit returns unit if it is labeled, otherwise it returns the closest
+// labeled unit on the map grid. Grid distance ties are broken by unit index.
+func (m Map) labeledUnit(unit int) int {
+	if m.labels[unit] != "" {
+		return unit
+	}
+	closest, dist := -1, math.MaxFloat64
+	coords := m.grid.coords.RawRowView(unit)
+	for i, label := range m.labels {
+		if label == "" {
+			continue
+		}
+		if d := euclideanVec(coords, m.grid.coords.RawRowView(i)); d < dist {
+			closest, dist = i, d
+		}
+	}
+	return closest
+}
+
+// ConfusionMatrix computes confusion matrix of predicted labels pred against true labels truth.
+// It returns the matrix and a sorted slice of all labels found in both pred and truth.
+// Rows of the matrix correspond to true labels, columns to predicted labels: element x_ij
+// contains the number of samples with i-th true label which were predicted as j-th label.
+// It returns error if pred and truth are empty or have different lengths.
+func ConfusionMatrix(pred, truth []string) (*mat64.Dense, []string, error) {
+	if len(pred) == 0 || len(pred) != len(truth) {
+		return nil, nil, fmt.Errorf("invalid number of labels. pred: %d, truth: %d", len(pred), len(truth))
+	}
+	index := make(map[string]int)
+	for _, labels := range [][]string{pred, truth} {
+		for _, label := range labels {
+			index[label] = 0
+		}
+	}
+	labels := make([]string, 0, len(index))
+	for label := range index {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for i, label := range labels {
+		index[label] = i
+	}
+	cm := mat64.NewDense(len(labels), len(labels), nil)
+	for i := range pred {
+		r, c := index[truth[i]], index[pred[i]]
+		cm.Set(r, c, cm.At(r, c)+1)
+	}
+
+	return cm, labels, nil
+}
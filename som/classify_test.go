@@ -0,0 +1,218 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeMoons generates two interleaving half circles data set with labels "upper" and "lower"
+func makeMoons(n int, noise float64, seed int64) (*mat64.Dense, []string) {
+	r := rand.New(rand.NewSource(seed))
+	data := mat64.NewDense(n, 2, nil)
+	labels := make([]string, n)
+	for i := 0; i < n; i++ {
+		t := r.Float64() * math.Pi
+		x, y := math.Cos(t), math.Sin(t)
+		labels[i] = "upper"
+		if i%2 == 1 {
+			x, y = 1-x, 0.5-y
+			labels[i] = "lower"
+		}
+		data.Set(i, 0, x+r.NormFloat64()*noise)
+		data.Set(i, 1, y+r.NormFloat64()*noise)
+	}
+	return data, labels
+}
+
+func TestLabelUnits(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NoError(err)
+	labels := []string{"a", "b", "a", "b", "c"}
+	// invalid parameters
+	errString := "invalid data supplied: %v"
+	err = m.LabelUnits(nil, labels)
+	assert.EqualError(err, fmt.Sprintf(errString, nil))
+	errString = "invalid number of labels: %d"
+	err = m.LabelUnits(dataMx, labels[:2])
+	assert.EqualError(err, fmt.Sprintf(errString, 2))
+	// unlabeled map can't classify
+	label, conf, err := m.Classify(dataMx.RawRowView(0))
+	assert.EqualError(err, "map units are not labeled")
+	assert.Equal("", label)
+	assert.Equal(0.0, conf)
+	// make every sample its own unit and merge two samples into one unit
+	m.codebook = mat64.NewDense(6, 4, nil)
+	for i := 0; i < 5; i++ {
+		m.codebook.SetRow(i, dataMx.RawRowView(i))
+	}
+	m.codebook.SetRow(5, []float64{100.0, 100.0, 100.0, 100.0})
+	data := mat64.NewDense(6, 4, append(append([]float64{}, dataMx.RawMatrix().Data...), dataMx.RawRowView(0)...))
+	err = m.LabelUnits(data, []string{"b", "b", "a", "b", "c", "a"})
+	assert.NoError(err)
+	assert.Equal([]string{"a", "b", "a", "b", "c", ""}, m.UnitLabels())
+	assert.Equal([]float64{0.5, 1.0, 1.0, 1.0, 1.0, 0.0}, m.purity)
+}
+
+func TestCalibrate(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NoError(err)
+	_, err = m.Calibrate(nil, nil)
+	assert.Error(err)
+	_, err = m.Calibrate(dataMx, []string{"a"})
+	assert.EqualError(err, "invalid number of labels: 1")
+	// make every sample its own unit and merge three samples into one unit
+	m.codebook = mat64.NewDense(6, 4, nil)
+	for i := 0; i < 5; i++ {
+		m.codebook.SetRow(i, dataMx.RawRowView(i))
+	}
+	m.codebook.SetRow(5, []float64{100.0, 100.0, 100.0, 100.0})
+	data := mat64.NewDense(7, 4, nil)
+	for i := 0; i < 7; i++ {
+		data.SetRow(i, dataMx.RawRowView(i%5))
+	}
+	cal, err := m.Calibrate(data, []string{"b", "a", "a", "b", "c", "c", "a"})
+	assert.NoError(err)
+	assert.Equal([]string{"b", "a", "a", "b", "c", ""}, cal.Labels)
+	assert.InDeltaSlice([]float64{0.5, 1.0, 1.0, 1.0, 1.0, 0.0}, cal.Purity, 1e-12)
+	assert.Equal([]map[string]int{{"b": 1, "c": 1}, {"a": 2}, {"a": 1}, {"b": 1}, {"c": 1}, nil}, cal.Hist)
+	// the map classifies samples by calibrated labels
+	assert.Equal(cal.Labels, m.UnitLabels())
+	label, conf, err := m.Classify(dataMx.RawRowView(1))
+	assert.NoError(err)
+	assert.Equal("a", label)
+	assert.Equal(1.0, conf)
+}
+
+func TestClassify(t *testing.T) {
+	assert := assert.New(t)
+
+	data, labels := makeMoons(400, 0.05, 7)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{8, 8},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      2,
+			InitFunc: RandInit,
+		},
+	}
+	tCfg := &TrainConfig{
+		Algorithm: "seq",
+		Radius:    4.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	err = m.Train(tCfg, data, 10000)
+	assert.NoError(err)
+	err = m.LabelUnits(data, labels)
+	assert.NoError(err)
+	// classify fresh samples drawn from the same distribution
+	test, truth := makeMoons(200, 0.05, 8)
+	pred, conf, err := m.ClassifyAll(test)
+	assert.NoError(err)
+	assert.Len(pred, 200)
+	for _, c := range conf {
+		assert.True(c > 0.0 && c <= 1.0)
+	}
+	cm, cmLabels, err := ConfusionMatrix(pred, truth)
+	assert.NoError(err)
+	assert.Equal([]string{"lower", "upper"}, cmLabels)
+	accuracy := (cm.At(0, 0) + cm.At(1, 1)) / 200.0
+	assert.True(accuracy > 0.9, "accuracy: %f", accuracy)
+	// weighted k-BMU vote
+	correct := 0
+	rows, _ := test.Dims()
+	for i := 0; i < rows; i++ {
+		label, conf, err := m.ClassifyVote(test.RawRowView(i), 3)
+		assert.NoError(err)
+		assert.True(conf > 0.0 && conf <= 1.0)
+		if label == truth[i] {
+			correct++
+		}
+	}
+	assert.True(float64(correct)/float64(rows) > 0.9)
+	_, _, err = m.ClassifyVote(test.RawRowView(0), 0)
+	assert.Error(err)
+	// samples in original units are scaled by the map scaler
+	x := test.RawRowView(0)
+	label, c, err := m.Classify(x)
+	assert.NoError(err)
+	voted, vc, err := m.ClassifyVote(x, 3)
+	assert.NoError(err)
+	assert.NoError(m.SetScaler(&Scaler{Method: ZScore, Center: []float64{1, -1}, Scale: []float64{2, 2}}))
+	orig := []float64{2*x[0] + 1, 2*x[1] - 1}
+	sLabel, sc, err := m.Classify(orig)
+	assert.NoError(err)
+	assert.Equal(label, sLabel)
+	assert.InDelta(c, sc, 1e-9)
+	sVoted, svc, err := m.ClassifyVote(orig, 3)
+	assert.NoError(err)
+	assert.Equal(voted, sVoted)
+	assert.InDelta(vc, svc, 1e-9)
+}
+
+func TestClassifyEmptyUnit(t *testing.T) {
+	assert := assert.New(t)
+
+	// 3x1 rectangle grid: units 0, 1, 2 lie on a line
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{3, 1}, Type: "planar", UShape: "rectangle"},
+		Cb:   &CbConfig{Dim: 1, InitFunc: RandInit},
+	}, mat64.NewDense(2, 1, []float64{0.0, 1.0}))
+	assert.NoError(err)
+	m.codebook = mat64.NewDense(3, 1, []float64{0.0, 10.0, 20.0})
+	err = m.LabelUnits(mat64.NewDense(2, 1, []float64{0.0, 20.0}), []string{"left", "right"})
+	assert.NoError(err)
+	assert.Equal([]string{"left", "", "right"}, m.UnitLabels())
+	// unit 1 is empty: both neighbours are equally far, the one with lower index wins
+	label, conf, err := m.Classify([]float64{10.0})
+	assert.NoError(err)
+	assert.Equal("left", label)
+	assert.Equal(1.0, conf)
+	label, _, err = m.Classify([]float64{19.0})
+	assert.NoError(err)
+	assert.Equal("right", label)
+	// dimension mismatch
+	_, _, err = m.Classify([]float64{1.0, 2.0})
+	assert.Equal(ErrDimMismatch, err)
+}
+
+func TestConfusionMatrix(t *testing.T) {
+	assert := assert.New(t)
+
+	errString := "invalid number of labels. pred: %d, truth: %d"
+	cm, labels, err := ConfusionMatrix(nil, nil)
+	assert.Nil(cm)
+	assert.Nil(labels)
+	assert.EqualError(err, fmt.Sprintf(errString, 0, 0))
+	cm, labels, err = ConfusionMatrix([]string{"a"}, []string{"a", "b"})
+	assert.EqualError(err, fmt.Sprintf(errString, 1, 2))
+
+	pred := []string{"a", "b", "b", "c", "a"}
+	truth := []string{"a", "a", "b", "b", "d"}
+	cm, labels, err = ConfusionMatrix(pred, truth)
+	assert.NoError(err)
+	assert.Equal([]string{"a", "b", "c", "d"}, labels)
+	expected := mat64.NewDense(4, 4, []float64{
+		1, 1, 0, 0,
+		0, 1, 1, 0,
+		0, 0, 0, 0,
+		1, 0, 0, 0,
+	})
+	assert.True(mat64.Equal(expected, cm))
+}
@@ -0,0 +1,335 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// ClusterKMeans clusters map codebook vectors into k clusters using k-means algorithm
+// with k-means++ initialization and returns cluster id of every map unit.
+// The algorithm runs until the cluster assignment stops changing or for at most maxIter
+// iterations. If any cluster becomes empty, it is reseeded with the codebook vector which
+// is farthest from its cluster centre. The results are deterministic for a fixed seed.
+// The cluster assignment is stored in the map and can be retrieved by calling Clusters.
+// It returns error if k is not a positive integer or if it exceeds the number of map units
+// or if maxIter is not a positive integer.
+func (m *Map) ClusterKMeans(k int, seed int64, maxIter int) ([]int, error) {
+	units, _ := m.cbDims()
+	if k <= 0 || k > units {
+		return nil, fmt.Errorf("invalid number of clusters: %d", k)
+	}
+	if maxIter <= 0 {
+		return nil, fmt.Errorf("invalid number of iterations: %d", maxIter)
+	}
+	r := rand.New(rand.NewSource(seed))
+	cb := m.cb()
+	centers := kMeansPlusPlus(cb, k, r)
+	m.clusters = kMeans(cb, centers, maxIter)
+
+	return m.clusters, nil
+}
+
+// Clusters returns cluster id of every map unit computed by the last clustering.
+// It returns nil if the map units have not been clustered.
+func (m Map) Clusters() []int {
+	return m.clusters
+}
+
+// SampleClusters returns cluster id of the BMU of every data row computed by the last clustering.
+// It returns error if the map units have not been clustered or if BMUs of data rows can't be found.
+func (m Map) SampleClusters(data mat64.Matrix) ([]int, error) {
+	if m.clusters == nil {
+		return nil, fmt.Errorf("invalid map: units have not been clustered")
+	}
+	bmus, err := m.BMUs(data)
+	if err != nil {
+		return nil, err
+	}
+	for i, bmu := range bmus {
+		bmus[i] = m.clusters[bmu]
+	}
+	return bmus, nil
+}
+
+// Merge is a single step of agglomerative clustering which merges clusters A and B.
+// Clusters 0 to N-1 are the map units, the cluster created by i-th merge has id N+i.
+type Merge struct {
+	// A is the id of the first merged cluster
+	A int
+	// B is the id of the second merged cluster
+	B int
+	// Dist is the linkage distance between the merged clusters
+	Dist float64
+	// Size is the number of map units in the merged cluster
+	Size int
+}
+
+// Dendrogram records the full sequence of agglomerative clustering merges
+type Dendrogram struct {
+	// Merges contains cluster merges in the order they were performed
+	Merges []Merge
+	// units is the number of clustered map units
+	units int
+}
+
+// Cut returns cluster id of every map unit when the dendrogram is cut at k clusters.
+// Cluster ids are numbered from 0 in the order of the lowest index unit of each cluster.
+// It returns error if k is not a positive integer or if it exceeds the number of map units.
+func (d *Dendrogram) Cut(k int) ([]int, error) {
+	if k <= 0 || k > d.units {
+		return nil, fmt.Errorf("invalid number of clusters: %d", k)
+	}
+	// parent of every cluster created by the applied merges
+	parent := make([]int, d.units+len(d.Merges))
+	for i := range parent {
+		parent[i] = i
+	}
+	for i, merge := range d.Merges[:d.units-k] {
+		parent[merge.A] = d.units + i
+		parent[merge.B] = d.units + i
+	}
+	root := func(c int) int {
+		for parent[c] != c {
+			c = parent[c]
+		}
+		return c
+	}
+	ids := make(map[int]int)
+	clusters := make([]int, d.units)
+	for i := range clusters {
+		r := root(i)
+		if _, ok := ids[r]; !ok {
+			ids[r] = len(ids)
+		}
+		clusters[i] = ids[r]
+	}
+	return clusters, nil
+}
+
+// Dendrogram performs agglomerative hierarchical clustering of map codebook vectors and
+// returns the full merge dendrogram. Only clusters which contain units adjacent on the map
+// grid can be merged, so every cluster forms a contiguous region of the map.
+// Supported linkages are "ward" and "average": ward linkage merges clusters which lead
+// to the minimum increase of within-cluster variance, average linkage merges clusters with
+// the minimum mean distance between their codebook vectors.
+// It returns error if unsupported linkage is requested.
+func (m Map) Dendrogram(linkage string) (*Dendrogram, error) {
+	if linkage != "ward" && linkage != "average" {
+		return nil, fmt.Errorf("unsupported linkage: %s", linkage)
+	}
+	units, _ := m.cbDims()
+	// unit distance matrix -- no need to check for error
+	uDistMx, _ := m.UnitDist()
+	cb := m.cb()
+	dist := make([][]float64, units)
+	adj := make([][]bool, units)
+	for i := 0; i < units; i++ {
+		dist[i] = make([]float64, units)
+		adj[i] = make([]bool, units)
+		for j := 0; j < units; j++ {
+			d := euclideanVec(cb.RawRowView(i), cb.RawRowView(j))
+			if linkage == "ward" {
+				d = d * d / 2.0
+			}
+			dist[i][j] = d
+			// see TopoError for the neighbourhood threshold
+			adj[i][j] = i != j && uDistMx.At(i, j) < 1.01*math.Sqrt(2)
+		}
+	}
+	// active clusters are stored in slots indexed by their lowest unit index
+	active := make([]bool, units)
+	ids := make([]int, units)
+	sizes := make([]int, units)
+	for i := range active {
+		active[i], ids[i], sizes[i] = true, i, 1
+	}
+	d := &Dendrogram{Merges: make([]Merge, 0, units-1), units: units}
+	for len(d.Merges) < units-1 {
+		a, b, min := -1, -1, math.MaxFloat64
+		for i := 0; i < units; i++ {
+			if !active[i] {
+				continue
+			}
+			for j := i + 1; j < units; j++ {
+				if active[j] && adj[i][j] && dist[i][j] < min {
+					a, b, min = i, j, dist[i][j]
+				}
+			}
+		}
+		// disconnected map grid
+		if a < 0 {
+			return nil, fmt.Errorf("map grid is not connected")
+		}
+		na, nb := float64(sizes[a]), float64(sizes[b])
+		// Lance-Williams distance update: cluster b is merged into slot a
+		for k := 0; k < units; k++ {
+			if !active[k] || k == a || k == b {
+				continue
+			}
+			var dk float64
+			switch linkage {
+			case "ward":
+				nk := float64(sizes[k])
+				dk = ((nk+na)*dist[k][a] + (nk+nb)*dist[k][b] - nk*min) / (nk + na + nb)
+			case "average":
+				dk = (na*dist[k][a] + nb*dist[k][b]) / (na + nb)
+			}
+			dist[a][k], dist[k][a] = dk, dk
+			adj[a][k] = adj[a][k] || adj[b][k]
+			adj[k][a] = adj[a][k]
+		}
+		sizes[a] += sizes[b]
+		d.Merges = append(d.Merges, Merge{A: ids[a], B: ids[b], Dist: min, Size: sizes[a]})
+		ids[a] = units + len(d.Merges) - 1
+		active[b] = false
+	}
+	return d, nil
+}
+
+// ClusterHierarchical clusters map units into k contiguous regions of the map using
+// agglomerative hierarchical clustering with the requested linkage and returns cluster
+// id of every map unit. See Dendrogram for the supported linkages.
+// The cluster assignment is stored in the map and can be retrieved by calling Clusters.
+// It returns error if k is not a positive integer or if it exceeds the number of map units
+// or if unsupported linkage is requested.
+func (m *Map) ClusterHierarchical(k int, linkage string) ([]int, error) {
+	units, _ := m.cbDims()
+	if k <= 0 || k > units {
+		return nil, fmt.Errorf("invalid number of clusters: %d", k)
+	}
+	d, err := m.Dendrogram(linkage)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := d.Cut(k)
+	if err != nil {
+		return nil, err
+	}
+	m.clusters = clusters
+
+	return m.clusters, nil
+}
+
+// kMeansPlusPlus picks k initial cluster centres from data rows using k-means++ algorithm
+func kMeansPlusPlus(data *mat64.Dense, k int, r *rand.Rand) *mat64.Dense {
+	rows, cols := data.Dims()
+	centers := mat64.NewDense(k, cols, nil)
+	centers.SetRow(0, data.RawRowView(r.Intn(rows)))
+	// squared distance of every row to its closest centre
+	dists := make([]float64, rows)
+	for i := range dists {
+		d := euclideanVec(data.RawRowView(i), centers.RawRowView(0))
+		dists[i] = d * d
+	}
+	for c := 1; c < k; c++ {
+		total := 0.0
+		for _, d := range dists {
+			total += d
+		}
+		// pick next centre with probability proportional to squared distance
+		next := 0
+		target := r.Float64() * total
+		for i, d := range dists {
+			target -= d
+			if target < 0 {
+				next = i
+				break
+			}
+		}
+		// all rows coincide with existing centres
+		if total == 0 {
+			next = r.Intn(rows)
+		}
+		centers.SetRow(c, data.RawRowView(next))
+		for i := range dists {
+			d := euclideanVec(data.RawRowView(i), centers.RawRowView(c))
+			if d*d < dists[i] {
+				dists[i] = d * d
+			}
+		}
+	}
+	return centers
+}
+
+// kMeans runs k-means clustering of data rows starting from the supplied cluster centres
+// and returns cluster id of every data row. centers are modified in place.
+// Empty clusters are reseeded with the data row farthest from its cluster centre.
+func kMeans(data, centers *mat64.Dense, maxIter int) []int {
+	rows, cols := data.Dims()
+	k, _ := centers.Dims()
+	assign := make([]int, rows)
+	for i := range assign {
+		assign[i] = -1
+	}
+	counts := make([]int, k)
+	sums := mat64.NewDense(k, cols, nil)
+	for iter := 0; iter < maxIter; iter++ {
+		changed := false
+		// assign every row to the closest centre
+		for i := 0; i < rows; i++ {
+			// no need to check for error: dimensions match
+			c, _ := ClosestVec("euclidean", data.RawRowView(i), centers)
+			if c != assign[i] {
+				assign[i] = c
+				changed = true
+			}
+		}
+		// reseed empty clusters
+		for c := range counts {
+			counts[c] = 0
+		}
+		for _, c := range assign {
+			counts[c]++
+		}
+		for c := 0; c < k; c++ {
+			if counts[c] > 0 {
+				continue
+			}
+			farthest, dist := -1, -1.0
+			for i := 0; i < rows; i++ {
+				// don't empty another cluster
+				if counts[assign[i]] < 2 {
+					continue
+				}
+				d := euclideanVec(data.RawRowView(i), centers.RawRowView(assign[i]))
+				if d > dist {
+					farthest, dist = i, d
+				}
+			}
+			if farthest < 0 {
+				continue
+			}
+			counts[assign[farthest]]--
+			assign[farthest] = c
+			counts[c]++
+			changed = true
+		}
+		if !changed {
+			break
+		}
+		// recompute cluster centres
+		raw := sums.RawMatrix().Data
+		for i := range raw {
+			raw[i] = 0.0
+		}
+		for i := 0; i < rows; i++ {
+			sum := sums.RawRowView(assign[i])
+			for j, v := range data.RawRowView(i) {
+				sum[j] += v
+			}
+		}
+		for c := 0; c < k; c++ {
+			if counts[c] == 0 {
+				continue
+			}
+			center := centers.RawRowView(c)
+			for j, v := range sums.RawRowView(c) {
+				center[j] = v / float64(counts[c])
+			}
+		}
+	}
+	return assign
+}
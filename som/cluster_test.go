@@ -0,0 +1,242 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterKMeans(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{6, 1}, Type: "planar", UShape: "rectangle"},
+		Cb:   &CbConfig{Dim: 1, InitFunc: RandInit},
+	}, mat64.NewDense(2, 1, []float64{0.0, 1.0}))
+	assert.NoError(err)
+	m.codebook = mat64.NewDense(6, 1, []float64{0.0, 10.0, 0.1, 10.1, 0.2, 10.2})
+	assert.Nil(m.Clusters())
+	// invalid parameters
+	errString := "invalid number of clusters: %d"
+	clusters, err := m.ClusterKMeans(0, 1, 10)
+	assert.Nil(clusters)
+	assert.EqualError(err, fmt.Sprintf(errString, 0))
+	clusters, err = m.ClusterKMeans(7, 1, 10)
+	assert.Nil(clusters)
+	assert.EqualError(err, fmt.Sprintf(errString, 7))
+	errString = "invalid number of iterations: %d"
+	clusters, err = m.ClusterKMeans(2, 1, 0)
+	assert.Nil(clusters)
+	assert.EqualError(err, fmt.Sprintf(errString, 0))
+	// two well separated groups of units
+	for seed := int64(0); seed < 10; seed++ {
+		clusters, err = m.ClusterKMeans(2, seed, 100)
+		assert.NoError(err)
+		assert.Len(clusters, 6)
+		assert.Equal(clusters[0], clusters[2])
+		assert.Equal(clusters[0], clusters[4])
+		assert.Equal(clusters[1], clusters[3])
+		assert.Equal(clusters[1], clusters[5])
+		assert.NotEqual(clusters[0], clusters[1])
+		assert.Equal(clusters, m.Clusters())
+	}
+	// fixed seed gives the same results
+	clusters, err = m.ClusterKMeans(3, 42, 100)
+	assert.NoError(err)
+	for i := 0; i < 5; i++ {
+		c, err := m.ClusterKMeans(3, 42, 100)
+		assert.NoError(err)
+		assert.Equal(clusters, c)
+	}
+	// every unit in its own cluster
+	clusters, err = m.ClusterKMeans(6, 3, 100)
+	assert.NoError(err)
+	seen := make(map[int]bool)
+	for _, c := range clusters {
+		seen[c] = true
+	}
+	assert.Len(seen, 6)
+}
+
+func TestSampleClusters(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{6, 1}, Type: "planar", UShape: "rectangle"},
+		Cb:   &CbConfig{Dim: 1, InitFunc: RandInit},
+	}, mat64.NewDense(2, 1, []float64{0.0, 1.0}))
+	assert.NoError(err)
+	m.codebook = mat64.NewDense(6, 1, []float64{0.0, 10.0, 0.1, 10.1, 0.2, 10.2})
+	data := mat64.NewDense(4, 1, []float64{9.5, 0.3, -1.0, 20.0})
+	_, err = m.SampleClusters(data)
+	assert.EqualError(err, "invalid map: units have not been clustered")
+	clusters, err := m.ClusterKMeans(2, 1, 100)
+	assert.NoError(err)
+	samples, err := m.SampleClusters(data)
+	assert.NoError(err)
+	assert.Equal([]int{clusters[1], clusters[0], clusters[0], clusters[1]}, samples)
+	_, err = m.SampleClusters(mat64.NewDense(1, 2, nil))
+	assert.Equal(ErrDimMismatch, err)
+}
+
+func TestKMeansEmptyCluster(t *testing.T) {
+	assert := assert.New(t)
+
+	data := mat64.NewDense(4, 1, []float64{0.0, 1.0, 2.0, 3.0})
+	// second centre is too far away to attract any data row
+	centers := mat64.NewDense(2, 1, []float64{0.0, 100.0})
+	assign := kMeans(data, centers, 100)
+	// the farthest row from its centre reseeds the empty cluster
+	assert.Equal([]int{0, 0, 0, 1}, assign)
+	assert.Equal([]float64{1.0, 3.0}, centers.RawMatrix().Data)
+	// more empty clusters than rows which can be moved
+	data = mat64.NewDense(2, 1, []float64{0.0, 1.0})
+	centers = mat64.NewDense(3, 1, []float64{0.0, 100.0, 200.0})
+	assign = kMeans(data, centers, 100)
+	assert.Equal([]int{0, 1}, assign)
+}
+
+func TestDendrogram(t *testing.T) {
+	assert := assert.New(t)
+
+	// 4x1 rectangle grid: units 0, 1, 2, 3 lie on a line
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{4, 1}, Type: "planar", UShape: "rectangle"},
+		Cb:   &CbConfig{Dim: 1, InitFunc: RandInit},
+	}, mat64.NewDense(2, 1, []float64{0.0, 1.0}))
+	assert.NoError(err)
+	m.codebook = mat64.NewDense(4, 1, []float64{0.0, 1.0, 10.0, 11.0})
+	// unsupported linkage
+	d, err := m.Dendrogram("foobar")
+	assert.Nil(d)
+	assert.EqualError(err, "unsupported linkage: foobar")
+	// average linkage
+	d, err = m.Dendrogram("average")
+	assert.NoError(err)
+	assert.Equal([]Merge{
+		{A: 0, B: 1, Dist: 1.0, Size: 2},
+		{A: 2, B: 3, Dist: 1.0, Size: 2},
+		{A: 4, B: 5, Dist: 10.0, Size: 4},
+	}, d.Merges)
+	// ward linkage
+	d, err = m.Dendrogram("ward")
+	assert.NoError(err)
+	assert.Len(d.Merges, 3)
+	assert.InDelta(0.5, d.Merges[0].Dist, 1e-9)
+	assert.InDelta(0.5, d.Merges[1].Dist, 1e-9)
+	assert.InDelta(100.0, d.Merges[2].Dist, 1e-9)
+	// cuts
+	errString := "invalid number of clusters: %d"
+	clusters, err := d.Cut(0)
+	assert.Nil(clusters)
+	assert.EqualError(err, fmt.Sprintf(errString, 0))
+	clusters, err = d.Cut(5)
+	assert.Nil(clusters)
+	assert.EqualError(err, fmt.Sprintf(errString, 5))
+	testCases := []struct {
+		k        int
+		clusters []int
+	}{
+		{1, []int{0, 0, 0, 0}},
+		{2, []int{0, 0, 1, 1}},
+		{3, []int{0, 0, 1, 2}},
+		{4, []int{0, 1, 2, 3}},
+	}
+	for _, tc := range testCases {
+		clusters, err = d.Cut(tc.k)
+		assert.NoError(err)
+		assert.Equal(tc.clusters, clusters)
+	}
+	// codebook vectors of units 0 and 2 are close, but they are not adjacent on the grid
+	m, err = NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{3, 1}, Type: "planar", UShape: "rectangle"},
+		Cb:   &CbConfig{Dim: 1, InitFunc: RandInit},
+	}, mat64.NewDense(2, 1, []float64{0.0, 1.0}))
+	assert.NoError(err)
+	m.codebook = mat64.NewDense(3, 1, []float64{0.0, 10.0, 0.5})
+	clusters, err = m.ClusterHierarchical(2, "average")
+	assert.NoError(err)
+	assert.Equal([]int{0, 1, 1}, clusters)
+	assert.Equal(clusters, m.Clusters())
+}
+
+func TestClusterHierarchical(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(300, 2, 3, 10.0, -10.0, 1.0, 5)
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{8, 8}, Type: "planar", UShape: "hexagon"},
+		Cb:   &CbConfig{Dim: 2, InitFunc: LinInit},
+	}, data)
+	assert.NoError(err)
+	err = m.Train(&TrainConfig{
+		Algorithm: "batch",
+		Radius:    4.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}, data, 50)
+	assert.NoError(err)
+	// invalid parameters
+	errString := "invalid number of clusters: %d"
+	clusters, err := m.ClusterHierarchical(0, "ward")
+	assert.Nil(clusters)
+	assert.EqualError(err, fmt.Sprintf(errString, 0))
+	clusters, err = m.ClusterHierarchical(65, "ward")
+	assert.Nil(clusters)
+	assert.EqualError(err, fmt.Sprintf(errString, 65))
+	clusters, err = m.ClusterHierarchical(3, "foobar")
+	assert.Nil(clusters)
+	assert.EqualError(err, "unsupported linkage: foobar")
+	// every cluster must be a contiguous region of the map
+	uDistMx, err := m.UnitDist()
+	assert.NoError(err)
+	for _, linkage := range []string{"ward", "average"} {
+		clusters, err = m.ClusterHierarchical(3, linkage)
+		assert.NoError(err)
+		assert.Len(clusters, 64)
+		ids := make(map[int]bool)
+		for _, c := range clusters {
+			ids[c] = true
+		}
+		assert.Len(ids, 3)
+		for c := range ids {
+			assert.True(isContiguous(clusters, c, uDistMx), "linkage %s, cluster %d", linkage, c)
+		}
+	}
+}
+
+// isContiguous checks if units of cluster c form a connected region of the map grid
+func isContiguous(clusters []int, c int, uDistMx *mat64.Dense) bool {
+	visited := make([]bool, len(clusters))
+	queue := []int{}
+	count := 0
+	for i, cl := range clusters {
+		if cl != c {
+			continue
+		}
+		count++
+		if len(queue) == 0 && !visited[i] {
+			queue = append(queue, i)
+			visited[i] = true
+		}
+	}
+	reached := 0
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		reached++
+		for v, cl := range clusters {
+			if cl == c && !visited[v] && uDistMx.At(u, v) < 1.01*math.Sqrt(2) {
+				visited[v] = true
+				queue = append(queue, v)
+			}
+		}
+	}
+	return reached == count
+}
@@ -0,0 +1,147 @@
+package som
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// codebook32 holds codebook vectors in a float32 backing array
+type codebook32 struct {
+	// rows is the number of codebook vectors
+	rows int
+	// cols is the dimension of codebook vectors
+	cols int
+	// data stores codebook vectors in row major order
+	data []float32
+}
+
+// newCodebook32 creates float32 copy of codebook cb
+func newCodebook32(cb *mat64.Dense) *codebook32 {
+	rows, cols := cb.Dims()
+	c := &codebook32{rows: rows, cols: cols, data: make([]float32, rows*cols)}
+	for i := 0; i < rows; i++ {
+		c.setRow(i, cb.RawRowView(i))
+	}
+	return c
+}
+
+// rowView returns i-th codebook vector which shares the backing array with the codebook
+func (c *codebook32) rowView(i int) []float32 {
+	return c.data[i*c.cols : (i+1)*c.cols]
+}
+
+// setRow sets i-th codebook vector to v
+func (c *codebook32) setRow(i int, v []float64) {
+	row := c.rowView(i)
+	for j := range row {
+		row[j] = float32(v[j])
+	}
+}
+
+// dense returns float64 copy of the codebook
+func (c *codebook32) dense() *mat64.Dense {
+	data := make([]float64, len(c.data))
+	for i, v := range c.data {
+		data[i] = float64(v)
+	}
+	return mat64.NewDense(c.rows, c.cols, data)
+}
+
+// euclidean32 computes euclidean distance between vectors a and b.
+// Element differences are computed in float32, their squares are accumulated in float64.
+func euclidean32(a []float64, b []float32) float64 {
+	d := 0.0
+	for i := range b {
+		diff := float32(a[i]) - b[i]
+		d += float64(diff * diff)
+	}
+
+	return math.Sqrt(d)
+}
+
+// closest returns the index of codebook vector closest to x and the distance between them.
+// If several codebook vectors of the same distance are found, the index of the first one is returned.
+func (c *codebook32) closest(x []float64) (int, float64) {
+	bmu, dist := 0, math.MaxFloat64
+	for i := 0; i < c.rows; i++ {
+		if d := euclidean32(x, c.rowView(i)); d < dist {
+			bmu, dist = i, d
+		}
+	}
+	return bmu, dist
+}
+
+// closestN returns indices of n codebook vectors closest to x and their distances to x
+// sorted by distance in ascending order. n must not exceed the number of codebook vectors.
+func (c *codebook32) closestN(n int, x []float64) ([]int, []float64) {
+	// no need to check for error
+	h, _ := newFloat64Heap(n)
+	for i := 0; i < c.rows; i++ {
+		heap.Push(h, &float64Item{val: euclidean32(x, c.rowView(i)), index: i})
+	}
+	// heap pops the farthest vectors first
+	closest := make([]int, n)
+	dists := make([]float64, n)
+	for j := n - 1; j >= 0; j-- {
+		item := heap.Pop(h).(*float64Item)
+		closest[j], dists[j] = item.index, item.val
+	}
+	return closest, dists
+}
+
+// cbDims returns the number of map codebook vectors and their dimension
+func (m Map) cbDims() (int, int) {
+	if m.cb32 != nil {
+		return m.cb32.rows, m.cb32.cols
+	}
+	return m.codebook.Dims()
+}
+
+// cb returns map codebook as float64 matrix.
+// The matrix is a copy of the codebook if the codebook is stored in float32 precision.
+func (m Map) cb() *mat64.Dense {
+	if m.cb32 != nil {
+		return m.cb32.dense()
+	}
+	return m.codebook
+}
+
+// cbNorms returns squared euclidean norms of map codebook vectors
+func (m Map) cbNorms() []float64 {
+	if m.cb32 == nil {
+		return sqNorms(m.codebook)
+	}
+	norms := make([]float64, m.cb32.rows)
+	for i := range norms {
+		for _, v := range m.cb32.rowView(i) {
+			norms[i] += float64(v) * float64(v)
+		}
+	}
+	return norms
+}
+
+// unitDistance returns euclidean distance between vector x and codebook vector of unit i
+// weighted by feature relevances if the map has learned them or the map metric distance
+func (m Map) unitDistance(x []float64, i int) float64 {
+	if m.relevance != nil {
+		return m.relevanceDistance(x, i)
+	}
+	if m.metric != nil {
+		return m.metric(x, m.codebook.RawRowView(i))
+	}
+	if m.cb32 != nil {
+		return euclidean32(x, m.cb32.rowView(i))
+	}
+	return euclideanVec(x, m.codebook.RawRowView(i))
+}
+
+// setUnit sets codebook vector of unit i to v
+func (m *Map) setUnit(i int, v []float64) {
+	if m.cb32 != nil {
+		m.cb32.setRow(i, v)
+		return
+	}
+	m.codebook.SetRow(i, v)
+}
@@ -0,0 +1,170 @@
+package som
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func makePrecisionMapCfg(size []int, dim int, precision string) *MapConfig {
+	return &MapConfig{
+		Grid: &GridConfig{Size: size, Type: "planar", UShape: "hexagon"},
+		Cb:   &CbConfig{Dim: dim, InitFunc: RandInit, Precision: precision},
+	}
+}
+
+func TestCodebook32(t *testing.T) {
+	assert := assert.New(t)
+
+	cb := mat64.NewDense(3, 2, []float64{0.1, 0.2, 1.0, 2.0, -3.5, 4.25})
+	c := newCodebook32(cb)
+	assert.Equal(3, c.rows)
+	assert.Equal(2, c.cols)
+	assert.Equal([]float32{1.0, 2.0}, c.rowView(1))
+	assert.True(mat64.EqualApprox(cb, c.dense(), 1e-7))
+	c.setRow(0, []float64{5.0, 6.0})
+	assert.Equal([]float32{5.0, 6.0}, c.rowView(0))
+	// distances
+	assert.InDelta(5.0, euclidean32([]float64{-2.0, -2.0}, c.rowView(1)), 1e-7)
+	bmu, dist := c.closest([]float64{1.1, 2.1})
+	assert.Equal(1, bmu)
+	assert.InDelta(0.1414213, dist, 1e-6)
+	closest, dists := c.closestN(2, []float64{1.1, 2.1})
+	assert.Equal([]int{1, 2}, closest)
+	assert.Equal(dist, dists[0])
+}
+
+func TestNewMapFloat32(t *testing.T) {
+	assert := assert.New(t)
+
+	m64, err := NewMap(makePrecisionMapCfg([]int{2, 3}, 4, ""), dataMx)
+	assert.NoError(err)
+	assert.Nil(m64.cb32)
+	assert.Equal("float64", m64.Precision())
+	m32, err := NewMap(makePrecisionMapCfg([]int{2, 3}, 4, "float32"), dataMx)
+	assert.NoError(err)
+	assert.NotNil(m32.cb32)
+	assert.Nil(m32.codebook)
+	assert.Equal("float32", m32.Precision())
+	// public API keeps returning float64 codebook
+	cb := m32.Codebook()
+	assert.True(mat64.EqualApprox(m64.codebook, cb, 1e-6))
+	assert.True(mat64.EqualApprox(m64.codebook, m32.CodebookRaw(), 1e-6))
+	rows, cols := m32.cbDims()
+	assert.Equal(6, rows)
+	assert.Equal(4, cols)
+	// BMU search
+	for i := 0; i < 5; i++ {
+		bmu64, dist64, err := m64.BMU(dataMx.RawRowView(i))
+		assert.NoError(err)
+		bmu32, dist32, err := m32.BMU(dataMx.RawRowView(i))
+		assert.NoError(err)
+		assert.Equal(bmu64, bmu32)
+		assert.InDelta(dist64, dist32, 1e-5)
+		closest, _, err := m32.KBMU(dataMx.RawRowView(i), 2)
+		assert.NoError(err)
+		assert.Equal(bmu32, closest[0])
+	}
+	_, _, err = m32.BMU([]float64{1.0})
+	assert.Equal(ErrDimMismatch, err)
+	// quality measures are computed on float64 copy of the codebook
+	qe64, err := m64.QuantError(dataMx)
+	assert.NoError(err)
+	qe32, err := m32.QuantError(dataMx)
+	assert.NoError(err)
+	assert.InDelta(qe64, qe32, 1e-5)
+	// float32 maps are not frozen
+	assert.False(m32.Freeze())
+	// invalid precision
+	m, err := NewMap(makePrecisionMapCfg([]int{2, 3}, 4, "float16"), dataMx)
+	assert.Nil(m)
+	assert.EqualError(err, "unsupported codebook precision: float16, supported: float32, float64")
+}
+
+func TestTrainFloat32(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(2000, 8, 5, 10.0, -10.0, 2.0, 10)
+	rows, _ := data.Dims()
+	tc := &TrainConfig{
+		Algorithm: "seq",
+		Radius:    5.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+	maps := make([]*Map, 2)
+	for i, precision := range []string{"float64", "float32"} {
+		m, err := NewMap(makePrecisionMapCfg([]int{10, 10}, 8, precision), data)
+		assert.NoError(err)
+		_, err = m.train(tc, data, 5000, rand.New(rand.NewSource(10)))
+		assert.NoError(err)
+		maps[i] = m
+	}
+	bmus64, err := maps[0].BMUs(data)
+	assert.NoError(err)
+	bmus32, err := maps[1].BMUs(data)
+	assert.NoError(err)
+	agree := 0
+	for i := range bmus64 {
+		if bmus64[i] == bmus32[i] {
+			agree++
+		}
+	}
+	assert.True(float64(agree)/float64(rows) > 0.99, "agreement: %d/%d", agree, rows)
+	// batch training can assign samples to different but nearly identical units, so only
+	// the quality of trained maps is compared
+	tc.Algorithm = "batch"
+	qErrs := make([]float64, 2)
+	for i, precision := range []string{"float64", "float32"} {
+		m, err := NewMap(makePrecisionMapCfg([]int{10, 10}, 8, precision), data)
+		assert.NoError(err)
+		_, err = m.train(tc, data, 100, rand.New(rand.NewSource(10)))
+		assert.NoError(err)
+		qErrs[i], err = m.QuantError(data)
+		assert.NoError(err)
+	}
+	assert.InEpsilon(qErrs[0], qErrs[1], 0.05)
+}
+
+func BenchmarkPrecision(b *testing.B) {
+	data := utils.GenerateClusters(1000, 128, 10, 10.0, -10.0, 2.0, 10)
+	rows, _ := data.Dims()
+	tc := &TrainConfig{
+		Algorithm: "seq",
+		Radius:    5.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+	for _, precision := range []string{"float64", "float32"} {
+		m, err := NewMap(makePrecisionMapCfg([]int{50, 50}, 128, precision), data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		// codebook memory footprint
+		cbBytes := 8 * len(m.cb().RawMatrix().Data)
+		if m.cb32 != nil {
+			cbBytes = 4 * len(m.cb32.data)
+		}
+		b.Run("bmu/"+precision, func(b *testing.B) {
+			b.ReportMetric(float64(cbBytes), "cb-bytes")
+			for i := 0; i < b.N; i++ {
+				m.BMU(data.RawRowView(i % rows))
+			}
+		})
+		b.Run("train/"+precision, func(b *testing.B) {
+			b.ReportMetric(float64(cbBytes), "cb-bytes")
+			for i := 0; i < b.N; i++ {
+				if _, err := m.train(tc, data, 10, rand.New(rand.NewSource(10))); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
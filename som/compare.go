@@ -0,0 +1,316 @@
+package som
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix"
+	"github.com/gonum/matrix/mat64"
+)
+
+// MapDiff describes the difference between two maps after alignment of their units
+type MapDiff struct {
+	// Matching holds the unit of map b matched to every unit of map a or -1 if the unit is not matched
+	Matching []int
+	// CodebookDist is the mean euclidean distance between codebook vectors of matched units
+	CodebookDist float64
+	// MaxDist is the largest euclidean distance between codebook vectors of matched units
+	MaxDist float64
+	// BMUAgreement is the fraction of data samples whose BMU in map b is matched to their BMU in map a
+	BMUAgreement float64
+	// FeatureShift holds the mean difference of every feature between codebook vectors
+	// of matched units: map b vectors minus map a vectors
+	FeatureShift []float64
+}
+
+// CompareMaps aligns units of maps a and b and returns their difference. Units are aligned by the
+// optimal assignment, which minimises the sum of euclidean distances between codebook vectors of
+// matched units, so maps which differ only in the order of their units have zero difference.
+// If the maps have different numbers of units, every unit of the smaller map is matched to a distinct
+// unit of the larger map and the remaining units are not matched. Grids of the maps are ignored.
+// All maps use euclidean distance. CompareMaps returns error if a, b or data are nil, if codebook
+// dimensions of the maps differ or ErrDimMismatch if data dimension is different from the codebook dimension.
+func CompareMaps(a, b *Map, data *mat64.Dense) (MapDiff, error) {
+	if err := checkCompared(a, b, false); err != nil {
+		return MapDiff{}, err
+	}
+	aUnits, aDim := a.cbDims()
+	bUnits, _ := b.cbDims()
+	// data can't be nil
+	if data == nil {
+		return MapDiff{}, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, cols := data.Dims()
+	if cols != aDim {
+		return MapDiff{}, ErrDimMismatch
+	}
+	aCb, bCb := a.cb(), b.cb()
+	// the cost matrix has at most as many rows as columns
+	n := aUnits
+	cost := codebookDistances(aCb, bCb)
+	if aUnits > bUnits {
+		n = bUnits
+		cost = mat64.DenseCopyOf(cost.T())
+	}
+	diff := MapDiff{Matching: make([]int, aUnits), FeatureShift: make([]float64, aDim)}
+	for i := range diff.Matching {
+		diff.Matching[i] = -1
+	}
+	for i, j := range assignment(cost) {
+		if aUnits <= bUnits {
+			diff.Matching[i] = j
+		} else {
+			diff.Matching[j] = i
+		}
+	}
+	for i, j := range diff.Matching {
+		if j < 0 {
+			continue
+		}
+		aVec, bVec := aCb.RawRowView(i), bCb.RawRowView(j)
+		d := euclideanVec(aVec, bVec)
+		diff.CodebookDist += d / float64(n)
+		diff.MaxDist = math.Max(diff.MaxDist, d)
+		for f := range diff.FeatureShift {
+			diff.FeatureShift[f] += (bVec[f] - aVec[f]) / float64(n)
+		}
+	}
+	aBMUs, err := a.BMUs(data)
+	if err != nil {
+		return MapDiff{}, err
+	}
+	bBMUs, err := b.BMUs(data)
+	if err != nil {
+		return MapDiff{}, err
+	}
+	agree := 0
+	for i, bmu := range aBMUs {
+		if diff.Matching[bmu] == bBMUs[i] {
+			agree++
+		}
+	}
+	if rows > 0 {
+		diff.BMUAgreement = float64(agree) / float64(rows)
+	}
+	return diff, nil
+}
+
+// CodebookDistances returns the matrix of euclidean distances between codebook vectors of every unit
+// of map a, in rows, and every unit of map b, in columns. For maps of the same dimensions its diagonal
+// holds the distance between codebook vectors of units at the same grid position, so it shows which
+// map regions drifted between two versions of a map. It returns error if a or b are nil or ErrDimMismatch
+// if codebook dimensions of the maps differ.
+func CodebookDistances(a, b *Map) (*mat64.Dense, error) {
+	if err := checkCompared(a, b, false); err != nil {
+		return nil, err
+	}
+	return codebookDistances(a.cb(), b.cb()), nil
+}
+
+// codebookDistances returns euclidean distances between rows of codebooks a and b
+func codebookDistances(a, b *mat64.Dense) *mat64.Dense {
+	aUnits, _ := a.Dims()
+	bUnits, _ := b.Dims()
+	dist := mat64.NewDense(aUnits, bUnits, nil)
+	for i := 0; i < aUnits; i++ {
+		row := dist.RawRowView(i)
+		for j := range row {
+			row[j] = euclideanVec(a.RawRowView(i), b.RawRowView(j))
+		}
+	}
+	return dist
+}
+
+// checkCompared returns error if a or b are nil or ErrDimMismatch if codebook dimensions of the maps differ
+// or, if sameUnits is true, if the maps have different numbers of units
+func checkCompared(a, b *Map, sameUnits bool) error {
+	if a == nil || b == nil {
+		return fmt.Errorf("invalid maps supplied: %v, %v", a, b)
+	}
+	aUnits, aDim := a.cbDims()
+	bUnits, bDim := b.cbDims()
+	if aDim != bDim {
+		return fmt.Errorf("invalid maps supplied: codebook dimensions %d and %d: %w", aDim, bDim, ErrDimMismatch)
+	}
+	if sameUnits && aUnits != bUnits {
+		return fmt.Errorf("invalid maps supplied: %d and %d units: %w", aUnits, bUnits, ErrDimMismatch)
+	}
+	return nil
+}
+
+// ProcrustesScore returns the Procrustes disparity of codebooks of maps a and b with the same number
+// of units: unit i of map a is paired with unit i of map b, which for maps of the same dimensions is
+// the unit at the same grid position. Both codebooks are centered and scaled to unit Frobenius norm and
+// the codebook of map b is optimally rotated onto the codebook of map a; the disparity is the remaining
+// sum of squared distances between paired codebook vectors. It is 0 if the codebooks differ only by
+// translation, scaling and rotation of the feature space and at most 1, so it measures whether two versions
+// of a map preserve the same arrangement of units even if the data moved as a whole. ProcrustesScore
+// returns error if a or b are nil, ErrDimMismatch if the maps have different numbers of units or codebook
+// dimensions or error if all codebook vectors of a map are equal.
+func ProcrustesScore(a, b *Map) (float64, error) {
+	if err := checkCompared(a, b, true); err != nil {
+		return -1.0, err
+	}
+	aCb, err := procrustesNormalized(a.cb())
+	if err != nil {
+		return -1.0, err
+	}
+	bCb, err := procrustesNormalized(b.cb())
+	if err != nil {
+		return -1.0, err
+	}
+	// the optimal rotation leaves disparity 1 - (sum of singular values of aCb^T * bCb)^2
+	var cross mat64.Dense
+	cross.Mul(aCb.T(), bCb)
+	var svd mat64.SVD
+	if !svd.Factorize(&cross, matrix.SVDNone) {
+		return -1.0, fmt.Errorf("failed to factorize codebook cross product")
+	}
+	trace := 0.0
+	for _, s := range svd.Values(nil) {
+		trace += s
+	}
+	return math.Min(math.Max(1-trace*trace, 0.0), 1.0), nil
+}
+
+// procrustesNormalized returns copy of codebook cb with centered columns scaled to unit Frobenius norm
+// or error if all codebook vectors are equal
+func procrustesNormalized(cb *mat64.Dense) (*mat64.Dense, error) {
+	units, dim := cb.Dims()
+	norm := mat64.DenseCopyOf(cb)
+	for j := 0; j < dim; j++ {
+		mean := 0.0
+		for i := 0; i < units; i++ {
+			mean += norm.At(i, j) / float64(units)
+		}
+		for i := 0; i < units; i++ {
+			norm.Set(i, j, norm.At(i, j)-mean)
+		}
+	}
+	frobenius := mat64.Norm(norm, 2)
+	if frobenius == 0 {
+		return nil, fmt.Errorf("invalid maps supplied: all codebook vectors are equal")
+	}
+	norm.Scale(1/frobenius, norm)
+	return norm, nil
+}
+
+// Agreement holds agreement of BMUs which two maps with the same number of units assign to the same data samples
+type Agreement struct {
+	// SameUnit is the fraction of samples whose BMUs have the same index in both maps; for maps
+	// of the same dimensions the BMUs are at the same grid position
+	SameUnit float64
+	// GridDist is the mean distance on the grid of map a between the BMU of a sample in map a and the unit
+	// with the index of its BMU in map b. It is NaN if map a has no topology.
+	GridDist float64
+	// AdjustedRand is the adjusted Rand index of groupings of the samples by their BMUs in the two maps:
+	// 1 if both maps group the samples in the same way, regardless of unit indices, and close to 0
+	// if the groupings are unrelated
+	AdjustedRand float64
+}
+
+// AssignmentAgreement returns agreement of BMUs which maps a and b with the same number of units assign
+// to data samples, such as a shared test set scored by two versions of a retrained map. AssignmentAgreement
+// returns error if a, b or data are nil, ErrDimMismatch if the maps have different numbers of units
+// or codebook dimensions or if data dimension is different from the codebook dimension.
+func AssignmentAgreement(a, b *Map, data mat64.Matrix) (Agreement, error) {
+	if err := checkCompared(a, b, true); err != nil {
+		return Agreement{}, err
+	}
+	// data can't be nil
+	if isNilMatrix(data) {
+		return Agreement{}, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, cols := data.Dims()
+	units, dim := a.cbDims()
+	if cols != dim {
+		return Agreement{}, ErrDimMismatch
+	}
+	aBMUs, err := a.BMUs(data)
+	if err != nil {
+		return Agreement{}, err
+	}
+	bBMUs, err := b.BMUs(data)
+	if err != nil {
+		return Agreement{}, err
+	}
+	agreement := Agreement{GridDist: math.NaN()}
+	if rows == 0 {
+		return agreement, nil
+	}
+	var unitDist *mat64.Dense
+	if !a.noTopology {
+		if unitDist, err = a.UnitDist(); err != nil {
+			return Agreement{}, err
+		}
+		agreement.GridDist = 0.0
+	}
+	for i, bmu := range aBMUs {
+		if bmu == bBMUs[i] {
+			agreement.SameUnit++
+		}
+		if unitDist != nil {
+			agreement.GridDist += unitDist.At(bmu, bBMUs[i]) / float64(rows)
+		}
+	}
+	agreement.SameUnit /= float64(rows)
+	agreement.AdjustedRand = adjustedRand(aBMUs, bBMUs, units, units)
+	return agreement, nil
+}
+
+// assignment returns the column assigned to every row of cost matrix cost, which must not have
+// more rows than columns, so that every row is assigned a distinct column and the sum of costs of
+// assigned columns is minimal. It implements the Hungarian algorithm in O(rows^2*cols) time.
+func assignment(cost *mat64.Dense) []int {
+	rows, cols := cost.Dims()
+	// potentials of rows and columns and the row assigned to every column indexed from 1;
+	// column 0 is a sentinel which holds the row being assigned
+	u, v := make([]float64, rows+1), make([]float64, cols+1)
+	assigned, way := make([]int, cols+1), make([]int, cols+1)
+	minv, used := make([]float64, cols+1), make([]bool, cols+1)
+	for i := 1; i <= rows; i++ {
+		assigned[0] = i
+		for j := range minv {
+			minv[j], used[j] = math.Inf(1), false
+		}
+		j0 := 0
+		// find the shortest augmenting path from row i
+		for assigned[j0] != 0 {
+			used[j0] = true
+			i0, delta, j1 := assigned[j0], math.Inf(1), 0
+			for j := 1; j <= cols; j++ {
+				if used[j] {
+					continue
+				}
+				if c := cost.At(i0-1, j-1) - u[i0] - v[j]; c < minv[j] {
+					minv[j], way[j] = c, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= cols; j++ {
+				if used[j] {
+					u[assigned[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+		// flip the augmenting path
+		for j0 != 0 {
+			j1 := way[j0]
+			assigned[j0] = assigned[j1]
+			j0 = j1
+		}
+	}
+	res := make([]int, rows)
+	for j := 1; j <= cols; j++ {
+		if assigned[j] != 0 {
+			res[assigned[j]-1] = j - 1
+		}
+	}
+	return res
+}
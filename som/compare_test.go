@@ -0,0 +1,233 @@
+package som
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// minAssignment returns the minimal total cost of assignment of distinct columns to rows of cost
+// by trying all assignments
+func minAssignment(cost *mat64.Dense, row int, used []bool) float64 {
+	rows, cols := cost.Dims()
+	if row == rows {
+		return 0.0
+	}
+	best := math.Inf(1)
+	for j := 0; j < cols; j++ {
+		if used[j] {
+			continue
+		}
+		used[j] = true
+		best = math.Min(best, cost.At(row, j)+minAssignment(cost, row+1, used))
+		used[j] = false
+	}
+	return best
+}
+
+func TestAssignment(t *testing.T) {
+	assert := assert.New(t)
+
+	r := rand.New(rand.NewSource(1))
+	for _, dims := range [][2]int{{1, 1}, {3, 3}, {4, 6}, {5, 5}} {
+		for trial := 0; trial < 20; trial++ {
+			cost := mat64.NewDense(dims[0], dims[1], nil)
+			for i := 0; i < dims[0]; i++ {
+				for j := 0; j < dims[1]; j++ {
+					cost.Set(i, j, float64(r.Intn(10)))
+				}
+			}
+			res := assignment(cost)
+			assert.Len(res, dims[0])
+			seen := make(map[int]bool)
+			total := 0.0
+			for i, j := range res {
+				assert.False(seen[j])
+				seen[j] = true
+				total += cost.At(i, j)
+			}
+			assert.InDelta(minAssignment(cost, 0, make([]bool, dims[1])), total, 1e-9)
+		}
+	}
+}
+
+func TestCompareMaps(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(400, 4, 1.0, 1)
+	a := trainMergeMap(t, data, []int{4, 4})
+
+	// b holds codebook vectors of a in reversed order
+	units, _ := a.cbDims()
+	perm := mat64.NewDense(units, 2, nil)
+	for i := 0; i < units; i++ {
+		perm.SetRow(i, a.codebook.RawRowView(units-1-i))
+	}
+	b := &Map{codebook: perm, grid: a.grid, norms: sqNorms(perm)}
+	diff, err := CompareMaps(a, b, data)
+	assert.NoError(err)
+	for i, j := range diff.Matching {
+		assert.Equal(units-1-i, j)
+	}
+	assert.InDelta(0.0, diff.CodebookDist, 1e-9)
+	assert.InDelta(0.0, diff.MaxDist, 1e-9)
+	assert.InDelta(1.0, diff.BMUAgreement, 1e-9)
+	assert.InDeltaSlice([]float64{0, 0}, diff.FeatureShift, 1e-9)
+
+	// b is trained on shifted data
+	shifted := mat64.DenseCopyOf(data)
+	rows, _ := shifted.Dims()
+	for i := 0; i < rows; i++ {
+		shifted.Set(i, 0, shifted.At(i, 0)+3.0)
+	}
+	b = trainMergeMap(t, shifted, []int{4, 4})
+	diff, err = CompareMaps(a, b, data)
+	assert.NoError(err)
+	assert.True(diff.CodebookDist > 1.0)
+	assert.True(diff.MaxDist >= diff.CodebookDist)
+	assert.True(diff.BMUAgreement < 1.0)
+	assert.InDelta(3.0, diff.FeatureShift[0], 1.0)
+	assert.InDelta(0.0, diff.FeatureShift[1], 1.0)
+
+	// maps of different sizes match units of the smaller map
+	c := trainMergeMap(t, data, []int{3, 3})
+	diff, err = CompareMaps(a, c, data)
+	assert.NoError(err)
+	unmatched := 0
+	for _, j := range diff.Matching {
+		if j < 0 {
+			unmatched++
+		}
+	}
+	assert.Equal(units-9, unmatched)
+	diff, err = CompareMaps(c, a, data)
+	assert.NoError(err)
+	assert.Len(diff.Matching, 9)
+	for _, j := range diff.Matching {
+		assert.True(j >= 0 && j < units)
+	}
+}
+
+func TestCompareMapsErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(100, 4, 1.0, 1)
+	a := trainMergeMap(t, data, []int{3, 3})
+
+	_, err := CompareMaps(nil, a, data)
+	assert.Error(err)
+	_, err = CompareMaps(a, nil, data)
+	assert.Error(err)
+	_, err = CompareMaps(a, a, nil)
+	assert.Error(err)
+	_, err = CompareMaps(a, a, mat64.NewDense(2, 3, nil))
+	assert.True(errors.Is(err, ErrDimMismatch))
+	cb := mat64.NewDense(9, 3, nil)
+	b := &Map{codebook: cb, grid: a.grid, norms: sqNorms(cb)}
+	_, err = CompareMaps(a, b, data)
+	assert.True(errors.Is(err, ErrDimMismatch))
+}
+
+func TestCodebookDistances(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(200, 4, 1.0, 1)
+	a := trainMergeMap(t, data, []int{3, 3})
+	b := trainMergeMap(t, data, []int{2, 2})
+	dist, err := CodebookDistances(a, b)
+	assert.NoError(err)
+	rows, cols := dist.Dims()
+	assert.Equal(9, rows)
+	assert.Equal(4, cols)
+	assert.InDelta(euclideanVec(a.codebook.RawRowView(2), b.codebook.RawRowView(3)), dist.At(2, 3), 1e-9)
+
+	// units of the same map have zero distance
+	dist, err = CodebookDistances(a, a)
+	assert.NoError(err)
+	for i := 0; i < 9; i++ {
+		assert.InDelta(0.0, dist.At(i, i), 1e-9)
+	}
+
+	_, err = CodebookDistances(nil, a)
+	assert.Error(err)
+	cb := mat64.NewDense(9, 3, nil)
+	_, err = CodebookDistances(a, &Map{codebook: cb, grid: a.grid, norms: sqNorms(cb)})
+	assert.True(errors.Is(err, ErrDimMismatch))
+}
+
+func TestProcrustesScore(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(200, 4, 1.0, 1)
+	a := trainMergeMap(t, data, []int{3, 3})
+
+	// b codebook is a rotated, scaled and shifted codebook of a
+	units, _ := a.cbDims()
+	cb := mat64.NewDense(units, 2, nil)
+	sin, cos := math.Sincos(0.7)
+	for i := 0; i < units; i++ {
+		x, y := a.codebook.At(i, 0), a.codebook.At(i, 1)
+		cb.SetRow(i, []float64{2*(cos*x-sin*y) + 5, 2*(sin*x+cos*y) - 1})
+	}
+	b := &Map{codebook: cb, grid: a.grid, norms: sqNorms(cb)}
+	score, err := ProcrustesScore(a, b)
+	assert.NoError(err)
+	assert.InDelta(0.0, score, 1e-9)
+
+	// codebook vectors of units on a square lattice with two opposite corners swapped
+	square, swapped := mat64.NewDense(units, 2, nil), mat64.NewDense(units, 2, nil)
+	for i := 0; i < units; i++ {
+		square.SetRow(i, []float64{float64(i / 3), float64(i % 3)})
+		j := i
+		if i == 0 || i == units-1 {
+			j = units - 1 - i
+		}
+		swapped.SetRow(i, []float64{float64(j / 3), float64(j % 3)})
+	}
+	score, err = ProcrustesScore(&Map{codebook: square, grid: a.grid, norms: sqNorms(square)},
+		&Map{codebook: swapped, grid: a.grid, norms: sqNorms(swapped)})
+	assert.NoError(err)
+	assert.InDelta(5.0/9.0, score, 1e-9)
+
+	_, err = ProcrustesScore(a, trainMergeMap(t, data, []int{2, 2}))
+	assert.True(errors.Is(err, ErrDimMismatch))
+	equal := mat64.NewDense(units, 2, nil)
+	_, err = ProcrustesScore(a, &Map{codebook: equal, grid: a.grid, norms: sqNorms(equal)})
+	assert.Error(err)
+}
+
+func TestAssignmentAgreement(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(200, 4, 1.0, 1)
+	a := trainMergeMap(t, data, []int{3, 3})
+	agreement, err := AssignmentAgreement(a, a, data)
+	assert.NoError(err)
+	assert.InDelta(1.0, agreement.SameUnit, 1e-9)
+	assert.InDelta(0.0, agreement.GridDist, 1e-9)
+	assert.InDelta(1.0, agreement.AdjustedRand, 1e-9)
+
+	// b holds codebook vectors of a in reversed order: samples are grouped in the same way by other units
+	units, _ := a.cbDims()
+	cb := mat64.NewDense(units, 2, nil)
+	for i := 0; i < units; i++ {
+		cb.SetRow(i, a.codebook.RawRowView(units-1-i))
+	}
+	b := &Map{codebook: cb, grid: a.grid, norms: sqNorms(cb)}
+	agreement, err = AssignmentAgreement(a, b, data)
+	assert.NoError(err)
+	assert.True(agreement.SameUnit < 1.0)
+	assert.True(agreement.GridDist > 0.0)
+	assert.InDelta(1.0, agreement.AdjustedRand, 1e-9)
+
+	_, err = AssignmentAgreement(a, b, nil)
+	assert.Error(err)
+	_, err = AssignmentAgreement(a, b, mat64.NewDense(2, 3, nil))
+	assert.True(errors.Is(err, ErrDimMismatch))
+	_, err = AssignmentAgreement(a, trainMergeMap(t, data, []int{2, 2}), data)
+	assert.True(errors.Is(err, ErrDimMismatch))
+}
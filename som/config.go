@@ -1,52 +1,94 @@
 package som
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
 
 	"github.com/gonum/matrix/mat64"
 )
 
-// uShapes maps supported SOM unit shapes
-var uShapes = map[string]bool{
-	"hexagon":   true,
-	"rectangle": true,
+var (
+	// ErrInvalidDims is returned when SOM grid dimensions are invalid
+	ErrInvalidDims = errors.New("invalid grid dimensions")
+	// ErrUnsupportedGrid is returned when SOM grid type is not supported
+	ErrUnsupportedGrid = errors.New("unsupported grid type")
+	// ErrUnsupportedUShape is returned when SOM unit shape is not supported
+	ErrUnsupportedUShape = errors.New("unsupported unit shape")
+	// ErrUnsupportedNeighbFn is returned when neighbourhood function is not supported
+	ErrUnsupportedNeighbFn = errors.New("unsupported neighbourhood function")
+	// ErrUnsupportedMetric is returned when distance metric is not supported
+	ErrUnsupportedMetric = errors.New("unsupported metric")
+	// ErrUnsupportedDecay is returned when radius or learning rate decay strategy is not supported
+	ErrUnsupportedDecay = errors.New("unsupported decay strategy")
+	// ErrInvalidRadius is returned when SOM unit radius is invalid
+	ErrInvalidRadius = errors.New("invalid radius")
+	// ErrInvalidLRate is returned when SOM learning rate is invalid
+	ErrInvalidLRate = errors.New("invalid learning rate")
+	// ErrUnsupportedMethod is returned when SOM training algorithm is not supported
+	ErrUnsupportedMethod = errors.New("invalid SOM training algorithm")
+	// ErrInvalidConfig matches every ConfigError returned by configuration validation
+	ErrInvalidConfig = errors.New("invalid configuration")
+)
+
+// ConfigError is the error of an invalid configuration parameter returned by configuration
+// validation. It can be extracted from validation errors using errors.As to find out which parameter
+// is invalid. Its message is the message of Err, which wraps the sentinel error of the problem,
+// such as ErrInvalidDims, if there is one.
+type ConfigError struct {
+	// Field is the invalid parameter, such as GridConfig.Size or TrainConfig.Phases[1].Iters
+	Field string
+	// Value is the invalid parameter value
+	Value interface{}
+	// Err describes the problem
+	Err error
 }
 
-// gridTypes maps supported grid types
-var coordsInitFns = map[string]coordsInitFunc{
-	"planar": GridCoords,
+// Error returns the problem description
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
 }
 
-// decays maps supported decay strategies
-var decays = map[string]bool{
-	"lin": true,
-	"exp": true,
-	"inv": true,
+// Unwrap returns the problem
+func (e *ConfigError) Unwrap() error {
+	return e.Err
 }
 
-// trainings maps supported training algorithms
-var trainingAlgs = map[string]bool{
-	"seq":   true,
-	"batch": true,
+// Is returns true if target is ErrInvalidConfig
+func (e *ConfigError) Is(target error) bool {
+	return target == ErrInvalidConfig
 }
 
-// coordsInitFunc defines SOM grid coordinates initialization function
-type coordsInitFunc func(string, []int) (*mat64.Dense, error)
+// CoordsInitFunc defines SOM grid coordinates initialization function.
+// It returns coordinates of grid units with the given unit shape and grid dimensions.
+type CoordsInitFunc func(string, []int) (*mat64.Dense, error)
 
 // NeighbFunc defines SOM neighbourhood function
 type NeighbFunc func(float64, float64) float64
 
+// MatMulFunc stores matrix product a*b in dst. dst has the dimensions of the product
+// and does not alias a or b.
+type MatMulFunc func(dst *mat64.Dense, a, b mat64.Matrix)
+
 // CbInitFunc defines SOM codebook initialization function
 type CbInitFunc func(*mat64.Dense, []int) (*mat64.Dense, error)
 
 // GridConfig holds SOM grid configuration
 type GridConfig struct {
-	// Size specifies SOM grid dimensions
+	// Size specifies SOM grid dimensions: the number of grid rows, columns and, for 3D grids
+	// of rectangle units, layers. Units are ordered by row first, then column, then layer.
+	// If it is nil, NewMap estimates the dimensions of planar, toroidal and cylindrical grids
+	// from data by GridSize.
 	Size []int
-	// Type specifies the type of SOM grid: planar
-	Type string
+	// Type specifies the type of SOM grid: planar, toroidal, cylindrical, spherical or a type registered by RegisterGridType
+	Type GridType
 	// UShape specifies SOM unit shape: hexagon, rectangle
-	UShape string
+	UShape UnitShape
+	// Connectivity specifies connectivity of rectangle units: axial or moore. It defines grid distances
+	// which are passed to the neighbourhood function and used by Grid.Neighbors. If it is empty,
+	// rectangle units are axial. Hexagon units don't support it.
+	Connectivity Connectivity `json:",omitempty"`
 }
 
 // CbConfig holds SOM codebook configuration
@@ -55,6 +97,20 @@ type CbConfig struct {
 	Dim int
 	// InitFunc specifies codebook initialization function
 	InitFunc CbInitFunc
+	// Precision specifies codebook storage precision: float64, float32.
+	// If it is empty, float64 is used.
+	Precision string
+	// Metric specifies distance of data samples from codebook vectors used by BMU search and training,
+	// such as Cosine or a function looked up by DistanceFuncByName. If it is nil, euclidean distance is
+	// used by optimized BMU searches. Only float64 codebooks support metrics.
+	Metric DistanceFunc
+	// FeatureWeights holds weights of codebook features, one per codebook dimension, which scale
+	// squared feature differences of euclidean distance of BMU search, training and prediction.
+	// Features of zero weight are passive: they are trained with the other features but don't
+	// influence the map. Weights must not be negative and at least one must be positive.
+	// They are stored as map feature relevances, which TrainRelevance and TrainLVQ adapt.
+	// If it is nil, features are not weighted. Feature weights can't be combined with Metric.
+	FeatureWeights []float64
 }
 
 // MapConfig holds SOM configuration
@@ -67,92 +123,749 @@ type MapConfig struct {
 
 // TrainConfig holds SOM training configuration
 type TrainConfig struct {
-	// Algorithm specifies training method: seq or batch
-	Algorithm string
-	// Radius specifies initial SOM units radius
+	// Algorithm specifies training method: seq, batch, neuralgas, gng, temporal or gsom
+	Algorithm Method
+	// Radius specifies initial SOM units radius. It must be a positive number unless AutoRadius is set.
+	// Neural gas training uses it as the initial neighbourhood range measured in unit ranks.
 	Radius float64
-	// RDecay specifies radius decay strategy: lin, exp
-	RDecay string
-	// NeighbFn specifies SOM neighbourhood function: gaussian, bubble, mexican
+	// AutoRadius derives initial SOM units radius from map dimensions at training time
+	// as half of the largest grid dimension. If it is set, Radius must be zero.
+	AutoRadius bool
+	// RDecay specifies radius decay strategy: lin, exp, inv, pow or a strategy registered by RegisterDecay
+	RDecay Decay
+	// RDecayFn is radius decay function, such as one returned by StepDecay.
+	// If it is set, it is used instead of RDecay strategy.
+	RDecayFn DecayFunc
+	// RSchedule is radius schedule, such as ExpSchedule, which defines the radius at every training
+	// iteration. If it is set, Radius, AutoRadius, RDecay and RDecayFn are ignored; neural gas training
+	// uses it as its neighbourhood range.
+	RSchedule Schedule
+	// NeighbFn specifies SOM neighbourhood function: gaussian, bubble, mexican, cutgauss, epanechnikov.
+	// Registered functions can be looked up using NeighbFuncByName.
 	NeighbFn NeighbFunc
-	// LRate specifies initial SOM learning rate
+	// LRate specifies initial SOM learning rate. It must be a positive number.
 	LRate float64
-	// LDecay specifies learning rate decay strategy: lin, exp
-	LDecay string
+	// LDecay specifies learning rate decay strategy: lin, exp, inv, pow or a strategy registered by RegisterDecay
+	LDecay Decay
+	// LDecayFn is learning rate decay function. If it is set, it is used instead of LDecay strategy.
+	LDecayFn DecayFunc
+	// LSchedule is learning rate schedule which defines the learning rate at every training iteration.
+	// If it is set, LRate, LDecay and LDecayFn are ignored.
+	LSchedule Schedule
+	// Workers specifies the number of worker goroutines used by the training.
+	// Batch training shards data samples across the workers which sum samples of every unit;
+	// the sums are merged at the end of every epoch.
+	// If it is not a positive integer, the number of CPUs is used.
+	Workers int
+	// ParBMUThreshold specifies the number of codebook elements (units x dim) above which
+	// BMU search in sequential training is split across Workers goroutines.
+	// If it is zero, DefaultParBMUThreshold is used; negative value disables parallel search.
+	ParBMUThreshold int
+	// NghbTableMaxBytes specifies the memory limit of the neighbourhood weight table used by
+	// batch training. If the table exceeds the limit, neighbourhood weights are computed on the fly.
+	// If it is zero, DefaultNghbTableMaxBytes is used; negative value disables the table.
+	NghbTableMaxBytes int
+	// NghbRadiusTolerance specifies the relative radius change up to which batch training reuses
+	// the neighbourhood weight table of an earlier epoch instead of rebuilding it, so the radius decays
+	// in steps of about NghbRadiusTolerance of the current radius. Building the table evaluates
+	// the neighbourhood function for every unit pair, which dominates epochs of large maps.
+	// It must be at least 0 and less than 1; zero rebuilds the table whenever the radius changes.
+	NghbRadiusTolerance float64
+	// MatMul computes the matrix products which take most of batch training time: dot products of
+	// data samples and codebook vectors of BMU search and neighbourhood weighted sums of dense
+	// neighbourhood tables. It lets the products run on an accelerated backend, such as a GPU BLAS
+	// library. If it is nil, mat64.Dense.Mul computes them using gonum BLAS.
+	MatMul MatMulFunc
+	// UpdateRule specifies batch training codebook update: mean, median, trimmed.
+	// Mean sets codebook vectors to neighbourhood weighted means of data samples, median and trimmed
+	// use neighbourhood weighted medians or trimmed means of every component, which are robust to outliers.
+	// If it is empty, mean is used. Sequential and neural gas training ignore it.
+	UpdateRule string
+	// TrimFraction specifies the fraction of neighbourhood weight of sample components which
+	// is trimmed from both ends by the trimmed update rule. It must be at least 0 and less than 0.5.
+	TrimFraction float64
+	// Leak specifies activation leak coefficient of temporal training. It must be between 0 and 1
+	// when the temporal training is used.
+	Leak float64
+	// GrowThreshold specifies the mean unit quantization error above which gsom training grows
+	// the map grid. It must be positive when the gsom training is used.
+	GrowThreshold float64
+	// ReviveDeadUnits re-initialises codebook vectors of dead units every ReviveInterval epochs of seq
+	// or batch training using ReviveStrategy. Revivals are logged and counted by TrainResult.
+	ReviveDeadUnits bool
+	// ReviveStrategy specifies how dead units are revived: split, sample. Split moves every dead unit
+	// into the region of a unit with the highest quantization error and splits it, sample re-seeds every
+	// dead unit with one of the data samples farthest from their BMUs. If it is empty, split is used.
+	ReviveStrategy string
+	// ReviveInterval specifies the number of epochs between dead unit revivals; seq training epoch
+	// has as many iterations as there are data samples. If it is zero, DefaultReviveInterval is used.
+	ReviveInterval int
+	// ReviveMinHits specifies the number of data samples with the unit as their BMU below which
+	// the unit is dead. If it is zero, units which are not BMU of any sample are dead.
+	ReviveMinHits int
+	// Logger logs training start and end, periodic progress and configuration warnings.
+	// If it is nil, nothing is logged.
+	Logger Logger
+	// TrackErrors records the mean distance of training samples to their BMUs in every epoch
+	// in TrainResult.QuantErrors. Seq, neural gas and gsom training epoch has as many iterations as
+	// there are data samples; every batch iteration is an epoch. Temporal training and batch training
+	// with median or trimmed update rule don't record the errors.
+	TrackErrors bool
+	// Patience stops the training early when the epoch quantization error, which is measured in the same
+	// way as by TrackErrors, has not improved on its lowest value by more than MinDelta for Patience epochs.
+	// Temporal training and batch training with median or trimmed update rule ignore it.
+	// If it is zero, the training runs all iterations.
+	Patience int
+	// MinDelta specifies the decrease of the epoch quantization error below which Patience epochs
+	// don't count as improvement. It must not be negative.
+	MinDelta float64
+	// OnEpoch is called at the end of every epoch of seq, batch, neural gas, temporal or gsom training
+	// with the epoch progress; epochs are counted in the same way as by TrackErrors. If it returns
+	// false, the training stops early. If it is nil, no function is called.
+	OnEpoch EpochFunc
+	// Validation holds held-out data samples which are not trained on. If it is set, the quantization
+	// error of the map on the validation data is measured at the end of every epoch of seq, batch, neural gas,
+	// temporal or gsom training, passed to OnEpoch and reported in TrainResult.Validation together with
+	// the training errors, so that overfitting can be detected. Every measurement searches BMUs of all
+	// validation samples. Its dimension must be the same as the codebook dimension. If it is nil,
+	// no validation error is measured.
+	Validation mat64.Matrix
+	// Metrics receives training metrics: completed iterations, current radius and learning rate,
+	// and, except for temporal training, quantization error and the number of dead units of
+	// recent samples. Training duration is set when seq, batch, neural gas, temporal or gsom training
+	// finishes. If it is nil, no metrics are updated.
+	Metrics Metrics
+	// Profile measures wall time which training spends searching BMUs, updating codebook vectors and
+	// decaying radius and learning rate. The timings are returned in TrainResult.Timings and logged when
+	// the training finishes. Profiling adds a few clock reads to every training iteration.
+	Profile bool
+	// Phases splits training into phases which run in turn, such as rough ordering with large radius
+	// and learning rate followed by fine tuning with small ones. Every phase has its own iterations,
+	// radius and learning rate; the other fields apply to all phases. If Phases is set, training runs
+	// the phase iterations instead of the number of iterations passed to the training method.
+	Phases []PhaseConfig
+	// Rand is the random number generator which picks random samples in seq, temporal, neural gas
+	// and gsom training, so that trainings with generators of the same seed are reproducible.
+	// If it is nil, every training uses a new generator seeded by the current time.
+	// Rand is not safe for concurrent use: concurrent trainings need their own generators.
+	Rand *rand.Rand
+	// CheckpointEvery specifies the number of iterations of seq or batch training after which
+	// Checkpoint is called with the training state, so the training can be resumed by ResumeTraining.
+	// If it is zero, no checkpoints are written.
+	CheckpointEvery int
+	// Checkpoint writes training checkpoints, for example to a file by CheckpointFile. It must be set
+	// if CheckpointEvery is positive. An error it returns stops the training.
+	Checkpoint CheckpointFunc
+	// KDTreeEvery enables approximate BMU search of seq and batch training of large maps: BMUs of dense
+	// samples are searched in KD-tree over codebook vectors which is rebuilt every KDTreeEvery epochs.
+	// Seq training epoch has as many iterations as there are data samples. Between rebuilds the tree
+	// partitions the codebook as it was when the tree was built, so the search may miss the BMU of
+	// a sample. Maps whose codebook is stored in float32 precision or which have learned feature
+	// relevances or a codebook metric use exact search. If it is zero, exact search is used.
+	KDTreeEvery int
+	// Weights holds weights of data samples of seq and batch training with mean update rule, such as
+	// counts of pre-aggregated samples, one per data row. Training with weights is equivalent to training
+	// with every sample repeated in proportion to its weight: seq training picks samples with probability
+	// proportional to their weights and batch training weights samples in unit averages.
+	// Weights must not be negative and at least one must be positive. If it is nil, samples are not weighted.
+	Weights []float64
+	// startIter is the iteration which training resumed by ResumeTraining starts at
+	startIter int
+}
+
+// validator collects configuration validation errors
+type validator struct {
+	// errs holds the collected errors
+	errs []error
+	// failFast stops validation at the first error
+	failFast bool
+}
+
+// add collects error err if it is not nil.
+// It returns true if validation should stop.
+func (v *validator) add(err error) bool {
+	if err != nil {
+		v.errs = append(v.errs, err)
+	}
+	return v.done()
+}
+
+// param collects error err of configuration parameter field with value if it is not nil.
+// It returns true if validation should stop.
+func (v *validator) param(field string, value interface{}, err error) bool {
+	if err == nil {
+		return v.done()
+	}
+	return v.add(&ConfigError{Field: field, Value: value, Err: err})
+}
+
+// done returns true if validation should stop: an error was collected in fail-fast mode
+func (v *validator) done() bool {
+	return v.failFast && len(v.errs) > 0
+}
+
+// err returns the collected errors joined by errors.Join or nil if there are none
+func (v *validator) err() error {
+	return errors.Join(v.errs...)
 }
 
 // validateGridConfig validates SOM grid configuration
-// It returns error if any of the config parameters are invalid
+// It returns error which joins all problems of the config parameters
 func validateGridConfig(c *GridConfig) error {
-	// SOM must have 2 dimensions
-	// TODO: figure out 3D maps
-	if len(c.Size) != 2 {
-		return fmt.Errorf("unsupported number of SOM grid dimensions supplied: %d", len(c.Size))
+	v := &validator{}
+	v.grid(c)
+	return v.err()
+}
+
+// grid validates SOM grid configuration
+func (v *validator) grid(c *GridConfig) {
+	// SOM must have 2 or 3 dimensions
+	if v.param("GridConfig.Size", c.Size, gridDimsError(c.Size)) {
+		return
+	}
+	// check if the supplied grid type is supported
+	if gridTypeFunc(string(c.Type)) == nil {
+		if v.param("GridConfig.Type", c.Type, fmt.Errorf("%w: %s, supported: %s", ErrUnsupportedGrid, c.Type, coordsInitFns.supported())) {
+			return
+		}
+	}
+	// check if the supplied unit shape type is supported
+	if !uShapes.Has(string(c.UShape)) {
+		if v.param("GridConfig.UShape", c.UShape, fmt.Errorf("%w: %s, supported: %s", ErrUnsupportedUShape, c.UShape, uShapes.supported())) {
+			return
+		}
 	}
-	// check if the supplied dimensions are negative integers or if they are single node
+	// hexagons only tile the plane
+	if c.UShape == Hexagon && len(c.Size) == 3 {
+		v.param("GridConfig.Size", c.Size, fmt.Errorf("%w: %v, hexagon units support 2 dimensions", ErrInvalidDims, c.Size))
+		return
+	}
+	// every other hexagon row is offset, so rows only wrap around evenly if their number is even
+	if c.Type == Toroidal && c.UShape == Hexagon && c.Size[0]%2 != 0 {
+		v.param("GridConfig.Size", c.Size, fmt.Errorf("%w: %v, toroidal hexagon grids must have an even number of rows", ErrInvalidDims, c.Size))
+		return
+	}
+	if c.Connectivity != "" {
+		if _, err := ParseConnectivity(string(c.Connectivity)); err != nil {
+			if v.param("GridConfig.Connectivity", c.Connectivity, err) {
+				return
+			}
+		} else if c.Connectivity == Moore && c.UShape != Rectangle {
+			if v.param("GridConfig.Connectivity", c.Connectivity, fmt.Errorf("%w: %s, moore connectivity needs rectangle units", ErrUnsupportedUShape, c.UShape)) {
+				return
+			}
+		}
+	}
+	if c.Type == Spherical {
+		if c.UShape != Hexagon {
+			if v.param("GridConfig.UShape", c.UShape, fmt.Errorf("%w: %s, spherical grids have hexagon units", ErrUnsupportedUShape, c.UShape)) {
+				return
+			}
+		}
+		_, err := sphereFreq(c.Size)
+		v.param("GridConfig.Size", c.Size, err)
+	}
+}
+
+// gridDimsError returns error if SOM grid dimensions size are invalid
+func gridDimsError(size []int) error {
+	if len(size) != 2 && len(size) != 3 {
+		return fmt.Errorf("%w: unsupported number of dimensions: %d, must be 2 or 3", ErrInvalidDims, len(size))
+	}
+	// check if the supplied dimensions are non-positive integers or if they are single node
 	product := 1
-	for _, dim := range c.Size {
+	for _, dim := range size {
 		if dim <= 0 {
-			return fmt.Errorf("incorrect SOM grid dimensions supplied: %v", c.Size)
+			return fmt.Errorf("%w: %v, must be at least 1", ErrInvalidDims, size)
 		}
 		product *= dim
 	}
 	// 1D dimensions supplied: [1,1,1...]
 	if product == 1 {
-		return fmt.Errorf("incorrect SOM grid dimensions supplied: %v", c.Size)
-	}
-	// check if the supplied grid type is supported
-	if _, ok := coordsInitFns[c.Type]; !ok {
-		return fmt.Errorf("unsupported SOM grid type: %s", c.Type)
+		return fmt.Errorf("%w: %v, must have at least 2 units", ErrInvalidDims, size)
 	}
-	// check if the supplied unit shape type is supported
-	if _, ok := uShapes[c.UShape]; !ok {
-		return fmt.Errorf("unsupported SOM unit shape: %s", c.UShape)
-	}
-
 	return nil
 }
 
 // validateCbConfig validates SOM configuration.
-// It returns error if any of the config parameters are invalid
+// It returns error which joins all problems of the config parameters
 func validateCbConfig(c *CbConfig) error {
+	v := &validator{}
+	v.cb(c)
+	return v.err()
+}
+
+// cb validates SOM codebook configuration
+func (v *validator) cb(c *CbConfig) {
 	// codebook vectors must have non-zero dimensions
 	if c.Dim <= 0 {
-		return fmt.Errorf("incorrect SOM codebook dimension supplied: %v", c.Dim)
+		if v.param("CbConfig.Dim", c.Dim, fmt.Errorf("incorrect SOM codebook dimension supplied: %v", c.Dim)) {
+			return
+		}
 	}
 	// check if the codebook init func is not nil
 	if c.InitFunc == nil {
-		return fmt.Errorf("invalid InitFunc: %v", c.InitFunc)
+		if v.param("CbConfig.InitFunc", c.InitFunc, fmt.Errorf("invalid InitFunc: %v", c.InitFunc)) {
+			return
+		}
+	}
+	// check codebook precision
+	if c.Precision != "" && !precisions.Has(c.Precision) {
+		if v.param("CbConfig.Precision", c.Precision, fmt.Errorf("unsupported codebook precision: %s, supported: %s", c.Precision, precisions.supported())) {
+			return
+		}
+	}
+	// float32 codebooks are searched by euclidean distance
+	if c.Metric != nil && c.Precision == "float32" {
+		if v.param("CbConfig.Metric", c.Metric, fmt.Errorf("%w: float32 codebooks use euclidean distance", ErrUnsupportedMetric)) {
+			return
+		}
+	}
+	if c.FeatureWeights != nil {
+		v.featureWeights(c)
+	}
+}
+
+// featureWeights validates feature weights of SOM codebook configuration
+func (v *validator) featureWeights(c *CbConfig) {
+	if len(c.FeatureWeights) != c.Dim {
+		if v.param("CbConfig.FeatureWeights", c.FeatureWeights, fmt.Errorf("invalid number of feature weights: %d, codebook dimension is %d", len(c.FeatureWeights), c.Dim)) {
+			return
+		}
+	}
+	if c.Metric != nil {
+		if v.param("CbConfig.Metric", c.Metric, fmt.Errorf("%w: feature weights weight euclidean distance", ErrUnsupportedMetric)) {
+			return
+		}
+	}
+	total := 0.0
+	for i, w := range c.FeatureWeights {
+		if !(w >= 0) || math.IsInf(w, 1) {
+			if v.param(fmt.Sprintf("CbConfig.FeatureWeights[%d]", i), w, fmt.Errorf("invalid feature weight %d: %f", i, w)) {
+				return
+			}
+			continue
+		}
+		total += w
+	}
+	if total == 0 {
+		v.param("CbConfig.FeatureWeights", c.FeatureWeights, fmt.Errorf("invalid feature weights: no positive weight"))
 	}
-	return nil
+}
+
+// validateMapConfig validates SOM grid and codebook configuration
+// It returns error which joins all problems of the config parameters
+func validateMapConfig(c *MapConfig) error {
+	v := &validator{}
+	v.mapConfig(c)
+	return v.err()
+}
+
+// mapConfig validates SOM grid and codebook configuration
+func (v *validator) mapConfig(c *MapConfig) {
+	if c == nil || c.Grid == nil || c.Cb == nil {
+		v.add(fmt.Errorf("invalid map configuration: %v", c))
+		return
+	}
+	if v.grid(c.Grid); v.done() {
+		return
+	}
+	v.cb(c.Cb)
 }
 
 // validateTrainConfig validtes SOM training configuration
-// It returns error if any of the training config parameters are invalid
+// It returns error which joins all problems of the training config parameters
 func validateTrainConfig(c *TrainConfig) error {
+	v := &validator{}
+	v.train(c)
+	return v.err()
+}
+
+// train validates SOM training configuration
+func (v *validator) train(c *TrainConfig) {
+	if c == nil {
+		v.add(fmt.Errorf("invalid training configuration: %v", c))
+		return
+	}
 	// training method must be supported
-	if _, ok := trainingAlgs[c.Algorithm]; !ok {
-		return fmt.Errorf("invalid SOM training algorithm: %s", c.Algorithm)
+	if !trainingAlgs.Has(string(c.Algorithm)) {
+		if v.param("TrainConfig.Algorithm", c.Algorithm, fmt.Errorf("%w: %s, supported: %s", ErrUnsupportedMethod, c.Algorithm, trainingAlgs.supported())) {
+			return
+		}
 	}
-	// initial SOM unit radius must be greater than zero
-	if c.Radius < 0 {
-		return fmt.Errorf("invalid SOM unit radius: %f", c.Radius)
+	// radius schedule replaces initial radius and its decay
+	if c.RSchedule != nil {
+		if err := checkSchedule(c.RSchedule); err != nil {
+			if v.param("TrainConfig.RSchedule", c.RSchedule, fmt.Errorf("%w: %v", ErrInvalidRadius, err)) {
+				return
+			}
+		}
+	} else if c.AutoRadius {
+		if c.Radius != 0 {
+			if v.param("TrainConfig.Radius", c.Radius, fmt.Errorf("%w: %f, must be zero when AutoRadius is set", ErrInvalidRadius, c.Radius)) {
+				return
+			}
+		}
+	} else if !(c.Radius > 0) || math.IsInf(c.Radius, 1) {
+		// initial SOM unit radius must be a positive finite number:
+		// zero radius breaks radius decay and NaN fails the comparison
+		if v.param("TrainConfig.Radius", c.Radius, fmt.Errorf("%w: %f, must be positive and finite", ErrInvalidRadius, c.Radius)) {
+			return
+		}
 	}
 	// check Radius decay strategy
-	if _, ok := decays[c.RDecay]; !ok {
-		return fmt.Errorf("unsupported Radius decay strategy: %s", c.RDecay)
+	if c.RSchedule == nil && c.RDecayFn == nil && decayFunc(string(c.RDecay)) == nil {
+		if v.param("TrainConfig.RDecay", c.RDecay, fmt.Errorf("%w for radius: %s, supported: %s", ErrUnsupportedDecay, c.RDecay, decayFuncs.supported())) {
+			return
+		}
 	}
 	// check the supplied is not nil
 	if c.NeighbFn == nil {
-		return fmt.Errorf("invalid Neighbourhood function: %v", c.NeighbFn)
+		if v.param("TrainConfig.NeighbFn", c.NeighbFn, fmt.Errorf("%w: %v", ErrUnsupportedNeighbFn, c.NeighbFn)) {
+			return
+		}
 	}
-	// initial SOM learning rate must be greater than zero
-	if c.LRate < 0 {
-		return fmt.Errorf("invalid SOM learning rate: %f", c.LRate)
+	// learning rate schedule replaces initial learning rate and its decay
+	if c.LSchedule != nil {
+		if err := checkSchedule(c.LSchedule); err != nil {
+			if v.param("TrainConfig.LSchedule", c.LSchedule, fmt.Errorf("%w: %v", ErrInvalidLRate, err)) {
+				return
+			}
+		}
+	} else if !(c.LRate > 0) || math.IsInf(c.LRate, 1) {
+		// initial SOM learning rate must be a positive finite number
+		if v.param("TrainConfig.LRate", c.LRate, fmt.Errorf("%w: %f, must be positive and finite", ErrInvalidLRate, c.LRate)) {
+			return
+		}
 	}
 	// check Learning rate decay strategy
-	if _, ok := decays[c.LDecay]; !ok {
-		return fmt.Errorf("unsupported Learning rate decay strategy: %s", c.LDecay)
+	if c.LSchedule == nil && c.LDecayFn == nil && decayFunc(string(c.LDecay)) == nil {
+		if v.param("TrainConfig.LDecay", c.LDecay, fmt.Errorf("%w for learning rate: %s, supported: %s", ErrUnsupportedDecay, c.LDecay, decayFuncs.supported())) {
+			return
+		}
+	}
+	// temporal training activations must decay
+	if c.Algorithm == Temporal && !(c.Leak > 0 && c.Leak < 1) {
+		if v.param("TrainConfig.Leak", c.Leak, fmt.Errorf("invalid leak coefficient: %f, must be between 0 and 1", c.Leak)) {
+			return
+		}
+	}
+	// growing grid needs a threshold of unit quantization error
+	if c.Algorithm == GSOM && !(c.GrowThreshold > 0) {
+		if v.param("TrainConfig.GrowThreshold", c.GrowThreshold, fmt.Errorf("invalid grow threshold: %f, must be positive", c.GrowThreshold)) {
+			return
+		}
+	}
+	// check batch update rule
+	if c.UpdateRule != "" && !updateRules.Has(c.UpdateRule) {
+		if v.param("TrainConfig.UpdateRule", c.UpdateRule, fmt.Errorf("unsupported batch update rule: %s, supported: %s", c.UpdateRule, updateRules.supported())) {
+			return
+		}
+	}
+	if !(c.TrimFraction >= 0 && c.TrimFraction < 0.5) {
+		if v.param("TrainConfig.TrimFraction", c.TrimFraction, fmt.Errorf("invalid trim fraction: %f, must be at least 0 and less than 0.5", c.TrimFraction)) {
+			return
+		}
+	}
+	// convergence check
+	if c.Patience < 0 {
+		if v.param("TrainConfig.Patience", c.Patience, fmt.Errorf("invalid patience: %d, must not be negative", c.Patience)) {
+			return
+		}
+	}
+	if !(c.MinDelta >= 0) {
+		if v.param("TrainConfig.MinDelta", c.MinDelta, fmt.Errorf("invalid minimum delta: %f, must not be negative", c.MinDelta)) {
+			return
+		}
+	}
+	// dead units are revived by seq and batch training only
+	if c.ReviveDeadUnits && c.Algorithm != Seq && c.Algorithm != Batch {
+		if v.param("TrainConfig.ReviveDeadUnits", c.ReviveDeadUnits, fmt.Errorf("%w: %s, dead unit revival supports seq and batch", ErrUnsupportedMethod, c.Algorithm)) {
+			return
+		}
+	}
+	if c.ReviveStrategy != "" && !reviveStrategies.Has(c.ReviveStrategy) {
+		if v.param("TrainConfig.ReviveStrategy", c.ReviveStrategy, fmt.Errorf("unsupported dead unit revival strategy: %s, supported: %s", c.ReviveStrategy, reviveStrategies.supported())) {
+			return
+		}
+	}
+	if c.ReviveInterval < 0 {
+		if v.param("TrainConfig.ReviveInterval", c.ReviveInterval, fmt.Errorf("invalid revive interval: %d", c.ReviveInterval)) {
+			return
+		}
+	}
+	if c.ReviveMinHits < 0 {
+		if v.param("TrainConfig.ReviveMinHits", c.ReviveMinHits, fmt.Errorf("invalid revive minimum hits: %d", c.ReviveMinHits)) {
+			return
+		}
+	}
+	// checkpoints are written by seq and batch training
+	if c.CheckpointEvery < 0 {
+		if v.param("TrainConfig.CheckpointEvery", c.CheckpointEvery, fmt.Errorf("invalid checkpoint interval: %d, must not be negative", c.CheckpointEvery)) {
+			return
+		}
+	}
+	if c.CheckpointEvery > 0 && c.Checkpoint == nil {
+		if v.param("TrainConfig.Checkpoint", c.Checkpoint, fmt.Errorf("invalid checkpoint interval: %d, checkpoint function is not set", c.CheckpointEvery)) {
+			return
+		}
+	}
+	if c.CheckpointEvery > 0 && (c.Algorithm != Seq && c.Algorithm != Batch || len(c.Phases) > 0) {
+		if v.param("TrainConfig.CheckpointEvery", c.CheckpointEvery, fmt.Errorf("%w: %s, checkpoints support seq and batch training without phases", ErrUnsupportedMethod, c.Algorithm)) {
+			return
+		}
+	}
+	if !(c.NghbRadiusTolerance >= 0 && c.NghbRadiusTolerance < 1) {
+		if v.param("TrainConfig.NghbRadiusTolerance", c.NghbRadiusTolerance, fmt.Errorf("invalid neighbourhood radius tolerance: %f, must be at least 0 and less than 1", c.NghbRadiusTolerance)) {
+			return
+		}
+	}
+	if c.KDTreeEvery < 0 {
+		if v.param("TrainConfig.KDTreeEvery", c.KDTreeEvery, fmt.Errorf("invalid KD-tree rebuild interval: %d, must not be negative", c.KDTreeEvery)) {
+			return
+		}
+	}
+	if c.KDTreeEvery > 0 && c.Algorithm != Seq && c.Algorithm != Batch {
+		if v.param("TrainConfig.KDTreeEvery", c.KDTreeEvery, fmt.Errorf("%w: %s, KD-tree BMU search supports seq and batch training", ErrUnsupportedMethod, c.Algorithm)) {
+			return
+		}
+	}
+	if c.Weights != nil && v.weights(c) {
+		return
+	}
+	for i, p := range c.Phases {
+		if v.phase(i, p) {
+			return
+		}
+	}
+}
+
+// weights adds errors of invalid sample weights of training configuration c.
+// It returns true if the validation should stop.
+func (v *validator) weights(c *TrainConfig) bool {
+	if c.Algorithm != Seq && (c.Algorithm != Batch || c.UpdateRule != "" && c.UpdateRule != "mean") {
+		method := string(c.Algorithm)
+		if c.Algorithm == Batch {
+			method += " " + c.UpdateRule
+		}
+		return v.param("TrainConfig.Weights", c.Weights, fmt.Errorf("%w: %s, sample weights support seq and batch mean training", ErrUnsupportedMethod, method))
+	}
+	total := 0.0
+	for i, w := range c.Weights {
+		if !(w >= 0) || math.IsInf(w, 1) {
+			return v.param(fmt.Sprintf("TrainConfig.Weights[%d]", i), w, fmt.Errorf("invalid sample weight %d: %f", i, w))
+		}
+		total += w
+	}
+	if total == 0 {
+		return v.param("TrainConfig.Weights", c.Weights, fmt.Errorf("invalid sample weights: no positive weight"))
+	}
+	return false
+}
+
+// ValidateConfig validates map and training configurations. If failFast is false, it returns
+// error which joins all problems of both configurations, otherwise it returns the first problem.
+// Every problem is a ConfigError which names the invalid parameter and wraps the matching sentinel
+// error, such as ErrInvalidDims, so it can be checked using errors.Is and errors.As.
+// Either configuration can be nil, in which case it is not validated.
+func ValidateConfig(mc *MapConfig, tc *TrainConfig, failFast bool) error {
+	v := &validator{failFast: failFast}
+	if mc != nil {
+		if v.mapConfig(mc); v.done() {
+			return v.errs[0]
+		}
+	}
+	if tc != nil {
+		v.train(tc)
+	}
+	if failFast && len(v.errs) > 0 {
+		return v.errs[0]
+	}
+	return v.err()
+}
+
+// ValidateAll validates map and training configurations together with the data set of dataRows
+// samples of dimension dataDim which are trained for iters iterations. Besides validating every
+// configuration field, it checks combinations of fields which are legal on their own.
+// It returns error which joins all invalid configuration fields, or error if data set is empty or if data
+// dimension does not match the codebook dimension, in which case ErrDimMismatch is wrapped.
+// It returns warnings for dubious combinations: radius larger than the map diagonal,
+// learning rate larger than 1, fewer sequential training iterations than data samples,
+// batch update rule of other training algorithms and hexagon units used in a single row or column map.
+func ValidateAll(mc *MapConfig, tc *TrainConfig, dataRows, dataDim, iters int) ([]string, error) {
+	// collect problems of both configurations
+	v := &validator{}
+	v.mapConfig(mc)
+	v.train(tc)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if dataRows <= 0 {
+		return nil, fmt.Errorf("invalid number of data samples: %d", dataRows)
+	}
+	if dataDim != mc.Cb.Dim {
+		return nil, fmt.Errorf("data dimension %d, codebook dimension %d: %w", dataDim, mc.Cb.Dim, ErrDimMismatch)
+	}
+	if iters <= 0 {
+		return nil, fmt.Errorf("invalid number of iterations: %d", iters)
+	}
+	coords, err := GridCoords(string(mc.Grid.UShape), mc.Grid.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	return crossCheck(coords, mc.Grid.Size, mc.Grid.UShape, tc, dataRows, iters), nil
+}
+
+// crossCheck returns warnings for dubious combinations of valid map grid and training parameters
+func crossCheck(coords mat64.Matrix, size []int, uShape UnitShape, tc *TrainConfig, dataRows, iters int) []string {
+	var warnings []string
+	radius, lRate := tc.Radius, tc.LRate
+	if tc.AutoRadius {
+		radius = autoRadius(size)
+	}
+	if tc.RSchedule != nil {
+		radius = tc.RSchedule.At(0, iters)
+	}
+	if tc.LSchedule != nil {
+		lRate = tc.LSchedule.At(0, iters)
+	}
+	// neural gas range is measured in unit ranks rather than grid distance and growing neural gas has no radius
+	if diag := gridDiagonal(coords); tc.Algorithm != NeuralGas && tc.Algorithm != GrowingNeuralGas && radius > diag {
+		warnings = append(warnings, fmt.Sprintf("radius %f is larger than map diagonal %f", radius, diag))
+	}
+	if lRate > 1.0 {
+		warnings = append(warnings, fmt.Sprintf("learning rate %f is larger than 1", lRate))
+	}
+	if (tc.Algorithm == Seq || tc.Algorithm == NeuralGas || tc.Algorithm == GrowingNeuralGas || tc.Algorithm == Temporal || tc.Algorithm == GSOM) && iters < dataRows {
+		warnings = append(warnings, fmt.Sprintf("%d sequential training iterations don't visit all %d data samples", iters, dataRows))
+	}
+	if tc.Algorithm != Batch && tc.UpdateRule != "" && tc.UpdateRule != "mean" {
+		warnings = append(warnings, fmt.Sprintf("update rule %s only applies to batch training", tc.UpdateRule))
+	}
+	for _, dim := range size {
+		if uShape == "hexagon" && dim == 1 {
+			warnings = append(warnings, fmt.Sprintf("hexagon units used in single row map: %v", size))
+			break
+		}
+	}
+
+	return warnings
+}
+
+// gridDiagonal returns the diagonal of bounding box of grid unit coordinates
+func gridDiagonal(coords mat64.Matrix) float64 {
+	rows, cols := coords.Dims()
+	diag := 0.0
+	for j := 0; j < cols; j++ {
+		min, max := math.Inf(1), math.Inf(-1)
+		for i := 0; i < rows; i++ {
+			min, max = math.Min(min, coords.At(i, j)), math.Max(max, coords.At(i, j))
+		}
+		diag += (max - min) * (max - min)
+	}
+
+	return math.Sqrt(diag)
+}
+
+// DefaultMapConfig returns SOM configuration for data with dataRows samples of dimension dataDim.
+// Grid dimensions use the same heuristic as GridSize: the map has 5*sqrt(dataRows) units which are
+// laid out in a nearly square planar grid of hexagon units, or in a single row for 1D data.
+// Codebook is initialized using RandInit.
+func DefaultMapConfig(dataRows, dataDim int) *MapConfig {
+	return &MapConfig{
+		Grid: &GridConfig{
+			Size:   gridDims(int(math.Ceil(5*math.Sqrt(float64(dataRows)))), dataDim),
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      dataDim,
+			InitFunc: RandInit,
+		},
+	}
+}
+
+// gridDims returns dimensions of a nearly square grid with at least units units, which is
+// at least 2, or dimensions of a single row grid for 1D data of dimension dataDim
+func gridDims(units, dataDim int) []int {
+	// map must have at least 2 units
+	if units < 2 {
+		units = 2
+	}
+	if dataDim == 1 {
+		return []int{1, units}
+	}
+	x := int(math.Ceil(math.Sqrt(float64(units))))
+	return []int{x, (units + x - 1) / x}
+}
+
+// DefaultTrainConfig returns SOM training configuration for map with the given number of units.
+// It uses batch training with gaussian neighbourhood, exponential radius and learning rate decays
+// and 0.5 initial learning rate. Initial radius is half of the side of square grid with units units.
+func DefaultTrainConfig(units int) *TrainConfig {
+	return &TrainConfig{
+		Algorithm: "batch",
+		Radius:    math.Max(math.Ceil(math.Sqrt(float64(units)))/2.0, 1.0),
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+}
+
+// ApplyDefaults sets empty grid and codebook configuration fields to their default values.
+// See GridConfig.ApplyDefaults and CbConfig.ApplyDefaults.
+func (c *MapConfig) ApplyDefaults() {
+	if c.Grid != nil {
+		c.Grid.ApplyDefaults()
+	}
+	if c.Cb != nil {
+		c.Cb.ApplyDefaults()
+	}
+}
+
+// ApplyDefaults sets empty grid type to planar and empty unit shape to hexagon.
+// Fields which are set are not modified, so invalid values still fail validation.
+func (c *GridConfig) ApplyDefaults() {
+	if c.Type == "" {
+		c.Type = "planar"
+	}
+	if c.UShape == "" {
+		c.UShape = "hexagon"
+	}
+}
+
+// ApplyDefaults sets nil codebook initialization function to RandInit and empty
+// precision to float64. Fields which are set are not modified.
+func (c *CbConfig) ApplyDefaults() {
+	if c.InitFunc == nil {
+		c.InitFunc = RandInit
+	}
+	if c.Precision == "" {
+		c.Precision = "float64"
+	}
+}
+
+// ApplyDefaults sets empty training configuration fields to the values used by DefaultTrainConfig:
+// batch algorithm, exponential radius and learning rate decays and gaussian neighbourhood function.
+// Zero learning rate is set to 0.5. Radius is not modified as its default depends on the map size,
+// so zero radius still fails validation unless AutoRadius is set.
+// Fields which are set are not modified, so invalid values still fail validation.
+func (c *TrainConfig) ApplyDefaults() {
+	if c.Algorithm == "" {
+		c.Algorithm = "batch"
+	}
+	if c.RDecay == "" {
+		c.RDecay = "exp"
+	}
+	if c.NeighbFn == nil {
+		c.NeighbFn = Gaussian
+	}
+	if c.LRate == 0 {
+		c.LRate = 0.5
+	}
+	if c.LDecay == "" {
+		c.LDecay = "exp"
 	}
-	return nil
 }
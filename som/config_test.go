@@ -1,9 +1,13 @@
 package som
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"testing"
 
+	"github.com/milosgajdos83/gosom/pkg/matrix"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -40,10 +44,10 @@ func TestValidateGridSize(t *testing.T) {
 	assert := assert.New(t)
 
 	mc := makeDefaultMapCfg()
-	errDimLen := "unsupported number of SOM grid dimensions supplied: %d"
-	errDimVal := "incorrect SOM grid dimensions supplied: %v"
-	wrongDims := []int{-1, 2}
-	singDims := []int{1, 1}
+	errDimLen := "invalid grid dimensions: unsupported number of dimensions: %d, must be 2 or 3"
+	errDimHex := "invalid grid dimensions: %v, hexagon units support 2 dimensions"
+	errDimVal := "invalid grid dimensions: %v, must be at least 1"
+	errDimSing := "invalid grid dimensions: %v, must have at least 2 units"
 	testCases := []struct {
 		size   []int
 		expErr bool
@@ -51,9 +55,16 @@ func TestValidateGridSize(t *testing.T) {
 	}{
 		{[]int{1}, true, fmt.Sprintf(errDimLen, 1)},
 		{[]int{}, true, fmt.Sprintf(errDimLen, 0)},
+		{[]int{2, 2, 2}, true, fmt.Sprintf(errDimHex, []int{2, 2, 2})},
+		{[]int{2, 2, 2, 2}, true, fmt.Sprintf(errDimLen, 4)},
 		{[]int{1, 2}, false, ""},
-		{singDims, true, fmt.Sprintf(errDimVal, singDims)},
-		{wrongDims, true, fmt.Sprintf(errDimVal, wrongDims)},
+		{[]int{2, 1}, false, ""},
+		{[]int{1, 1}, true, fmt.Sprintf(errDimSing, []int{1, 1})},
+		{[]int{-1, 2}, true, fmt.Sprintf(errDimVal, []int{-1, 2})},
+		{[]int{0, 10}, true, fmt.Sprintf(errDimVal, []int{0, 10})},
+		{[]int{10, 0}, true, fmt.Sprintf(errDimVal, []int{10, 0})},
+		{[]int{0, 0}, true, fmt.Sprintf(errDimVal, []int{0, 0})},
+		{[]int{0, 1}, true, fmt.Sprintf(errDimVal, []int{0, 1})},
 	}
 
 	size := mc.Grid.Size
@@ -62,10 +73,20 @@ func TestValidateGridSize(t *testing.T) {
 		err := validateGridConfig(mc.Grid)
 		if tc.expErr {
 			assert.EqualError(err, tc.errStr)
+			assert.True(errors.Is(err, ErrInvalidDims))
 		} else {
 			assert.NoError(err)
 		}
 	}
+	// 3D grids of rectangle units
+	mc.Grid.UShape = Rectangle
+	for _, size := range [][]int{{2, 2, 2}, {1, 1, 2}, {3, 4, 5}} {
+		mc.Grid.Size = size
+		assert.NoError(validateGridConfig(mc.Grid))
+	}
+	mc.Grid.Size = []int{1, 1, 1}
+	assert.True(errors.Is(validateGridConfig(mc.Grid), ErrInvalidDims))
+	mc.Grid.UShape = Hexagon
 	mc.Grid.Size = size
 }
 
@@ -73,12 +94,13 @@ func TestValidateGridType(t *testing.T) {
 	assert := assert.New(t)
 
 	mc := makeDefaultMapCfg()
-	errString := "unsupported SOM grid type: %s"
+	errString := "unsupported grid type: %s, supported: %s"
 	testCases := []struct {
-		grid   string
+		grid   GridType
 		expErr bool
 	}{
 		{"planar", false},
+		{"toroidal", false},
 		{"foobar", true},
 	}
 
@@ -87,21 +109,28 @@ func TestValidateGridType(t *testing.T) {
 		mc.Grid.Type = tc.grid
 		err := validateGridConfig(mc.Grid)
 		if tc.expErr {
-			assert.EqualError(err, fmt.Sprintf(errString, mc.Grid.Type))
+			assert.EqualError(err, fmt.Sprintf(errString, mc.Grid.Type, strings.Join(SupportedGridTypes(), ", ")))
+			assert.True(errors.Is(err, ErrUnsupportedGrid))
 		} else {
 			assert.NoError(err)
 		}
 	}
 	mc.Grid.Type = grid
+
+	// toroidal hexagon grids need an even number of rows
+	err := validateGridConfig(&GridConfig{Size: []int{3, 4}, Type: Toroidal, UShape: Hexagon})
+	assert.True(errors.Is(err, ErrInvalidDims))
+	assert.NoError(validateGridConfig(&GridConfig{Size: []int{4, 3}, Type: Toroidal, UShape: Hexagon}))
+	assert.NoError(validateGridConfig(&GridConfig{Size: []int{3, 3, 3}, Type: Toroidal, UShape: Rectangle}))
 }
 
 func TestValidateGridUshape(t *testing.T) {
 	assert := assert.New(t)
 
 	mc := makeDefaultMapCfg()
-	errString := "unsupported SOM unit shape: %s"
+	errString := "unsupported unit shape: %s, supported: hexagon, rectangle"
 	testCases := []struct {
-		ushape string
+		ushape UnitShape
 		expErr bool
 	}{
 		{"hexagon", false},
@@ -115,6 +144,7 @@ func TestValidateGridUshape(t *testing.T) {
 		err := validateGridConfig(mc.Grid)
 		if tc.expErr {
 			assert.EqualError(err, fmt.Sprintf(errString, mc.Grid.UShape))
+			assert.True(errors.Is(err, ErrUnsupportedUShape))
 		} else {
 			assert.NoError(err)
 		}
@@ -149,13 +179,70 @@ func TestValidateCbInitFunc(t *testing.T) {
 	mc.Cb.InitFunc = initFunc
 }
 
+func TestValidateCbPrecision(t *testing.T) {
+	assert := assert.New(t)
+
+	mc := makeDefaultMapCfg()
+	errString := "unsupported codebook precision: %s, supported: float32, float64"
+	testCases := []struct {
+		precision string
+		expErr    bool
+	}{
+		{"", false},
+		{"float64", false},
+		{"float32", false},
+		{"float16", true},
+	}
+
+	precision := mc.Cb.Precision
+	for _, tc := range testCases {
+		mc.Cb.Precision = tc.precision
+		err := validateCbConfig(mc.Cb)
+		if tc.expErr {
+			assert.EqualError(err, fmt.Sprintf(errString, mc.Cb.Precision))
+		} else {
+			assert.NoError(err)
+		}
+	}
+	mc.Cb.Precision = precision
+}
+
+func TestValidateCbFeatureWeights(t *testing.T) {
+	assert := assert.New(t)
+
+	mc := makeDefaultMapCfg()
+	dim := mc.Cb.Dim
+	testCases := []struct {
+		weights []float64
+		metric  DistanceFunc
+		err     string
+	}{
+		{nil, nil, ""},
+		{append(make([]float64, dim-1), 1.0), nil, ""},
+		{[]float64{1.0}, nil, fmt.Sprintf("invalid number of feature weights: 1, codebook dimension is %d", dim)},
+		{append([]float64{1.0}, append(make([]float64, dim-2), -1.0)...), nil, fmt.Sprintf("invalid feature weight %d: -1.000000", dim-1)},
+		{make([]float64, dim), nil, "invalid feature weights: no positive weight"},
+		{append(make([]float64, dim-1), 1.0), Manhattan, "unsupported metric: feature weights weight euclidean distance"},
+	}
+	for _, tc := range testCases {
+		mc.Cb.FeatureWeights, mc.Cb.Metric = tc.weights, tc.metric
+		err := validateCbConfig(mc.Cb)
+		if tc.err != "" {
+			assert.EqualError(err, tc.err)
+		} else {
+			assert.NoError(err)
+		}
+	}
+	mc.Cb.FeatureWeights, mc.Cb.Metric = nil, nil
+}
+
 func TestValidateAlgorithm(t *testing.T) {
 	assert := assert.New(t)
 
 	tr := makeDefaultTrainConfig()
-	errString := "invalid SOM training algorithm: %s"
+	errString := "invalid SOM training algorithm: %s, supported: batch, gng, gsom, neuralgas, seq, temporal"
 	testCases := []struct {
-		method string
+		method Method
 		expErr bool
 	}{
 		{"seq", false},
@@ -180,14 +267,21 @@ func TestValidateRadius(t *testing.T) {
 	assert := assert.New(t)
 
 	tr := makeDefaultTrainConfig()
-	errString := "invalid SOM unit radius: %f"
+	errString := "invalid radius: %f, must be positive and finite"
 	testCases := []struct {
 		radius float64
 		expErr bool
 	}{
 		{1.0, false},
 		{-10.0, true},
-		{0.0, false},
+		{0.0, true},
+		{math.Copysign(0, -1), true},
+		{math.SmallestNonzeroFloat64, false},
+		{-math.SmallestNonzeroFloat64, true},
+		{math.MaxFloat64, false},
+		{math.Inf(1), true},
+		{math.Inf(-1), true},
+		{math.NaN(), true},
 	}
 
 	origRadius := tr.Radius
@@ -196,10 +290,20 @@ func TestValidateRadius(t *testing.T) {
 		err := validateTrainConfig(tr)
 		if tc.expErr {
 			assert.EqualError(err, fmt.Sprintf(errString, tr.Radius))
+			assert.True(errors.Is(err, ErrInvalidRadius))
 		} else {
 			assert.NoError(err)
 		}
 	}
+	// zero radius is accepted when it is derived from map dimensions
+	tr.AutoRadius = true
+	tr.Radius = 0.0
+	assert.NoError(validateTrainConfig(tr))
+	tr.Radius = 1.0
+	err := validateTrainConfig(tr)
+	assert.EqualError(err, "invalid radius: 1.000000, must be zero when AutoRadius is set")
+	assert.True(errors.Is(err, ErrInvalidRadius))
+	tr.AutoRadius = false
 	tr.Radius = origRadius
 }
 
@@ -207,9 +311,9 @@ func TestValidateRDecay(t *testing.T) {
 	assert := assert.New(t)
 
 	tr := makeDefaultTrainConfig()
-	errString := "unsupported Radius decay strategy: %s"
+	errString := "unsupported decay strategy for radius: %s, supported: %s"
 	testCases := []struct {
-		decay  string
+		decay  Decay
 		expErr bool
 	}{
 		{"lin", false},
@@ -223,7 +327,8 @@ func TestValidateRDecay(t *testing.T) {
 		tr.RDecay = tc.decay
 		err := validateTrainConfig(tr)
 		if tc.expErr {
-			assert.EqualError(err, fmt.Sprintf(errString, tr.RDecay))
+			assert.EqualError(err, fmt.Sprintf(errString, tr.RDecay, strings.Join(SupportedDecays(), ", ")))
+			assert.True(errors.Is(err, ErrUnsupportedDecay))
 		} else {
 			assert.NoError(err)
 		}
@@ -235,7 +340,7 @@ func TestValidateNeighbFn(t *testing.T) {
 	assert := assert.New(t)
 
 	tr := makeDefaultTrainConfig()
-	errString := "invalid Neighbourhood function: %v"
+	errString := "unsupported neighbourhood function: %v"
 	testCases := []struct {
 		neighbFn NeighbFunc
 		expErr   bool
@@ -252,6 +357,7 @@ func TestValidateNeighbFn(t *testing.T) {
 		err := validateTrainConfig(tr)
 		if tc.expErr {
 			assert.EqualError(err, fmt.Sprintf(errString, tr.NeighbFn))
+			assert.True(errors.Is(err, ErrUnsupportedNeighbFn))
 		} else {
 			assert.NoError(err)
 		}
@@ -263,14 +369,21 @@ func TestValidateLRate(t *testing.T) {
 	assert := assert.New(t)
 
 	tr := makeDefaultTrainConfig()
-	errString := "invalid SOM learning rate: %f"
+	errString := "invalid learning rate: %f, must be positive and finite"
 	testCases := []struct {
 		lrate  float64
 		expErr bool
 	}{
 		{1.0, false},
 		{-10.0, true},
-		{0.0, false},
+		{0.0, true},
+		{math.Copysign(0, -1), true},
+		{math.SmallestNonzeroFloat64, false},
+		{-math.SmallestNonzeroFloat64, true},
+		{math.MaxFloat64, false},
+		{math.Inf(1), true},
+		{math.Inf(-1), true},
+		{math.NaN(), true},
 	}
 
 	origLRate := tr.LRate
@@ -279,6 +392,7 @@ func TestValidateLRate(t *testing.T) {
 		err := validateTrainConfig(tr)
 		if tc.expErr {
 			assert.EqualError(err, fmt.Sprintf(errString, tr.LRate))
+			assert.True(errors.Is(err, ErrInvalidLRate))
 		} else {
 			assert.NoError(err)
 		}
@@ -290,9 +404,9 @@ func TestValidateLDecay(t *testing.T) {
 	assert := assert.New(t)
 
 	tr := makeDefaultTrainConfig()
-	errString := "unsupported Learning rate decay strategy: %s"
+	errString := "unsupported decay strategy for learning rate: %s, supported: %s"
 	testCases := []struct {
-		decay  string
+		decay  Decay
 		expErr bool
 	}{
 		{"lin", false},
@@ -306,10 +420,292 @@ func TestValidateLDecay(t *testing.T) {
 		tr.LDecay = tc.decay
 		err := validateTrainConfig(tr)
 		if tc.expErr {
-			assert.EqualError(err, fmt.Sprintf(errString, tr.LDecay))
+			assert.EqualError(err, fmt.Sprintf(errString, tr.LDecay, strings.Join(SupportedDecays(), ", ")))
+			assert.True(errors.Is(err, ErrUnsupportedDecay))
 		} else {
 			assert.NoError(err)
 		}
 	}
 	tr.LDecay = origLDecay
 }
+
+func TestDefaultConfigs(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		rows int
+		dim  int
+		size []int
+	}{
+		{100, 3, []int{8, 7}},
+		{100, 1, []int{1, 50}},
+		{1, 2, []int{3, 2}},
+		{0, 2, []int{2, 1}},
+	}
+	for _, tc := range testCases {
+		mc := DefaultMapConfig(tc.rows, tc.dim)
+		assert.Equal(tc.size, mc.Grid.Size)
+		assert.Equal(tc.dim, mc.Cb.Dim)
+		assert.NoError(validateGridConfig(mc.Grid))
+		assert.NoError(validateCbConfig(mc.Cb))
+	}
+	// train using nothing but defaults
+	data, _ := matrix.MakeRandom(50, 3, -10.0, 10.0)
+	rows, dim := data.Dims()
+	m, err := NewMap(DefaultMapConfig(rows, dim), data)
+	assert.NoError(err)
+	units, _ := m.Codebook().Dims()
+	tc := DefaultTrainConfig(units)
+	assert.Equal(3.0, tc.Radius)
+	assert.NoError(validateTrainConfig(tc))
+	assert.NoError(m.Train(tc, data, 20))
+	for _, v := range m.codebook.RawMatrix().Data {
+		assert.False(math.IsNaN(v) || math.IsInf(v, 0))
+	}
+	qe, err := m.QuantError(data)
+	assert.NoError(err)
+	assert.True(qe > 0 && qe < 10.0)
+	// small maps use unit radius
+	assert.Equal(1.0, DefaultTrainConfig(2).Radius)
+}
+
+func TestApplyDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	// zero value configurations fail strict validation
+	tc := &TrainConfig{Radius: 2.0}
+	assert.Error(validateTrainConfig(tc))
+	mc := &MapConfig{
+		Grid: &GridConfig{Size: []int{3, 2}},
+		Cb:   &CbConfig{Dim: 2},
+	}
+	assert.Error(validateGridConfig(mc.Grid))
+	assert.Error(validateCbConfig(mc.Cb))
+
+	tc.ApplyDefaults()
+	assert.NoError(validateTrainConfig(tc))
+	assert.Equal(Batch, tc.Algorithm)
+	assert.Equal(ExpDecay, tc.RDecay)
+	assert.Equal(ExpDecay, tc.LDecay)
+	assert.Equal(0.5, tc.LRate)
+	assert.Equal(2.0, tc.Radius)
+	assert.NotNil(tc.NeighbFn)
+	mc.ApplyDefaults()
+	assert.NoError(validateGridConfig(mc.Grid))
+	assert.NoError(validateCbConfig(mc.Cb))
+	assert.Equal(Planar, mc.Grid.Type)
+	assert.Equal(Hexagon, mc.Grid.UShape)
+	assert.Equal("float64", mc.Cb.Precision)
+	assert.NotNil(mc.Cb.InitFunc)
+	data, _ := matrix.MakeRandom(10, 2, -10.0, 10.0)
+	m, err := NewMap(mc, data)
+	assert.NoError(err)
+	assert.NoError(m.Train(tc, data, 10))
+
+	// values which are set are kept
+	tc = &TrainConfig{Algorithm: "foobar", Radius: 1.0, RDecay: "lin", LRate: 0.1}
+	tc.ApplyDefaults()
+	assert.Equal(LinDecay, tc.RDecay)
+	assert.Equal(0.1, tc.LRate)
+	assert.EqualError(validateTrainConfig(tc), "invalid SOM training algorithm: foobar, supported: batch, gng, gsom, neuralgas, seq, temporal")
+	gc := &GridConfig{Size: []int{3, 2}, UShape: "foobar"}
+	gc.ApplyDefaults()
+	assert.EqualError(validateGridConfig(gc), "unsupported unit shape: foobar, supported: hexagon, rectangle")
+	// missing sub-configurations are left nil
+	mc = &MapConfig{}
+	mc.ApplyDefaults()
+	assert.Nil(mc.Grid)
+	assert.Nil(mc.Cb)
+}
+
+func TestValidateAll(t *testing.T) {
+	assert := assert.New(t)
+
+	makeCfgs := func() (*MapConfig, *TrainConfig) {
+		mc := makeDefaultMapCfg()
+		mc.Grid.Size = []int{4, 3}
+		mc.Grid.UShape = "rectangle"
+		tc := makeDefaultTrainConfig()
+		tc.Radius = 2.0
+		return mc, tc
+	}
+	// sensible configuration has no warnings
+	mc, tc := makeCfgs()
+	warnings, err := ValidateAll(mc, tc, 100, 5, 1000)
+	assert.NoError(err)
+	assert.Empty(warnings)
+	// rectangle 4x3 grid diagonal is sqrt(3^2 + 2^2)
+	diag := math.Sqrt(13.0)
+	testCases := []struct {
+		modify  func(*MapConfig, *TrainConfig)
+		rows    int
+		iters   int
+		warning string
+	}{
+		{func(mc *MapConfig, tc *TrainConfig) { tc.Radius = 3.61 }, 100, 1000,
+			fmt.Sprintf("radius %f is larger than map diagonal %f", 3.61, diag)},
+		{func(mc *MapConfig, tc *TrainConfig) { tc.Radius = diag }, 100, 1000, ""},
+		{func(mc *MapConfig, tc *TrainConfig) { tc.LRate = 1.5 }, 100, 1000,
+			fmt.Sprintf("learning rate %f is larger than 1", 1.5)},
+		{func(mc *MapConfig, tc *TrainConfig) { tc.LRate = 1.0 }, 100, 1000, ""},
+		{func(mc *MapConfig, tc *TrainConfig) {}, 100, 99,
+			"99 sequential training iterations don't visit all 100 data samples"},
+		{func(mc *MapConfig, tc *TrainConfig) {}, 100, 100, ""},
+		{func(mc *MapConfig, tc *TrainConfig) { tc.Algorithm = "batch" }, 100, 10, ""},
+		{func(mc *MapConfig, tc *TrainConfig) { mc.Grid.UShape, mc.Grid.Size = "hexagon", []int{1, 10} }, 100, 1000,
+			"hexagon units used in single row map: [1 10]"},
+		{func(mc *MapConfig, tc *TrainConfig) { mc.Grid.UShape, mc.Grid.Size = "hexagon", []int{10, 1} }, 100, 1000,
+			"hexagon units used in single row map: [10 1]"},
+		{func(mc *MapConfig, tc *TrainConfig) { mc.Grid.Size = []int{1, 10} }, 100, 1000, ""},
+		// automatic radius is derived from map dimensions
+		{func(mc *MapConfig, tc *TrainConfig) { tc.Radius, tc.AutoRadius = 0.0, true }, 100, 1000, ""},
+	}
+	for _, c := range testCases {
+		mc, tc := makeCfgs()
+		c.modify(mc, tc)
+		warnings, err := ValidateAll(mc, tc, c.rows, 5, c.iters)
+		assert.NoError(err)
+		if c.warning == "" {
+			assert.Empty(warnings)
+		} else {
+			assert.Equal([]string{c.warning}, warnings)
+		}
+	}
+	// all warnings are reported
+	mc, tc = makeCfgs()
+	tc.Radius, tc.LRate = 10.0, 2.0
+	warnings, err = ValidateAll(mc, tc, 100, 5, 10)
+	assert.NoError(err)
+	assert.Len(warnings, 3)
+
+	// impossible configurations return errors
+	mc, tc = makeCfgs()
+	_, err = ValidateAll(nil, tc, 100, 5, 10)
+	assert.EqualError(err, "invalid map configuration: <nil>")
+	_, err = ValidateAll(mc, nil, 100, 5, 10)
+	assert.EqualError(err, "invalid training configuration: <nil>")
+	_, err = ValidateAll(mc, tc, 0, 5, 10)
+	assert.EqualError(err, "invalid number of data samples: 0")
+	_, err = ValidateAll(mc, tc, 100, 5, 0)
+	assert.EqualError(err, "invalid number of iterations: 0")
+	_, err = ValidateAll(mc, tc, 100, 3, 10)
+	assert.True(errors.Is(err, ErrDimMismatch))
+	mc.Grid.Size = []int{0, 3}
+	_, err = ValidateAll(mc, tc, 100, 5, 10)
+	assert.True(errors.Is(err, ErrInvalidDims))
+	mc, tc = makeCfgs()
+	mc.Cb.InitFunc = nil
+	_, err = ValidateAll(mc, tc, 100, 5, 10)
+	assert.Error(err)
+	mc, tc = makeCfgs()
+	tc.LDecay = "foobar"
+	warnings, err = ValidateAll(mc, tc, 100, 5, 10)
+	assert.Nil(warnings)
+	assert.True(errors.Is(err, ErrUnsupportedDecay))
+}
+
+func TestConfigError(t *testing.T) {
+	assert := assert.New(t)
+
+	mc := &MapConfig{
+		Grid: &GridConfig{Size: []int{0, 2}, Type: "planar", UShape: "foobar"},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}
+	tc := &TrainConfig{
+		Algorithm: "batch",
+		Radius:    2.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+		Phases:    []PhaseConfig{{Iters: 10}, {Iters: 0}},
+	}
+	err := ValidateConfig(mc, tc, false)
+	assert.True(errors.Is(err, ErrInvalidConfig))
+	// errors.As extracts the first invalid parameter
+	var cerr *ConfigError
+	assert.True(errors.As(err, &cerr))
+	assert.Equal("GridConfig.Size", cerr.Field)
+	assert.Equal([]int{0, 2}, cerr.Value)
+	assert.True(errors.Is(cerr, ErrInvalidDims))
+	assert.EqualError(cerr, "invalid grid dimensions: [0 2], must be at least 1")
+	// every problem names its parameter
+	var fields []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		assert.True(errors.As(e, &cerr))
+		fields = append(fields, cerr.Field)
+	}
+	assert.Equal([]string{"GridConfig.Size", "GridConfig.UShape", "TrainConfig.Phases[1].Iters"}, fields)
+	// fail fast error is ConfigError itself
+	err = ValidateConfig(nil, &TrainConfig{Algorithm: "batch", AutoRadius: true, Radius: 1.0}, true)
+	assert.True(errors.As(err, &cerr))
+	assert.Equal("TrainConfig.Radius", cerr.Field)
+	assert.Equal(1.0, cerr.Value)
+	// errors which are not caused by a parameter are not ConfigErrors
+	err = ValidateConfig(&MapConfig{}, nil, false)
+	assert.Error(err)
+	assert.False(errors.Is(err, ErrInvalidConfig))
+}
+
+func TestValidateConfigAggregate(t *testing.T) {
+	assert := assert.New(t)
+
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{0, 2},
+			Type:   "planar",
+			UShape: "foobar",
+		},
+		Cb: &CbConfig{
+			Dim:      2,
+			InitFunc: RandInit,
+		},
+	}
+	tc := &TrainConfig{
+		Algorithm: "batch",
+		Radius:    -1.0,
+		RDecay:    "foobar",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+	sentinels := []error{ErrInvalidDims, ErrUnsupportedUShape, ErrInvalidRadius, ErrUnsupportedDecay}
+
+	// all four problems are reported
+	err := ValidateConfig(mc, tc, false)
+	assert.Error(err)
+	for _, sentinel := range sentinels {
+		assert.True(errors.Is(err, sentinel), sentinel.Error())
+	}
+	assert.Len(strings.Split(err.Error(), "\n"), 4)
+	_, err = ValidateAll(mc, tc, 10, 2, 10)
+	for _, sentinel := range sentinels {
+		assert.True(errors.Is(err, sentinel), sentinel.Error())
+	}
+	// map and training problems are reported separately
+	err = validateMapConfig(mc)
+	assert.True(errors.Is(err, ErrInvalidDims))
+	assert.True(errors.Is(err, ErrUnsupportedUShape))
+	assert.False(errors.Is(err, ErrInvalidRadius))
+	err = validateTrainConfig(tc)
+	assert.True(errors.Is(err, ErrInvalidRadius))
+	assert.True(errors.Is(err, ErrUnsupportedDecay))
+	assert.False(errors.Is(err, ErrInvalidDims))
+	data, _ := matrix.MakeRandom(10, 2, -10.0, 10.0)
+	_, err = NewMap(mc, data)
+	assert.True(errors.Is(err, ErrInvalidDims))
+	assert.True(errors.Is(err, ErrUnsupportedUShape))
+
+	// fail-fast returns the first problem only
+	err = ValidateConfig(mc, tc, true)
+	assert.EqualError(err, "invalid grid dimensions: [0 2], must be at least 1")
+	err = ValidateConfig(nil, tc, true)
+	assert.EqualError(err, "invalid radius: -1.000000, must be positive and finite")
+
+	// valid configurations
+	mc.Grid.Size, mc.Grid.UShape = []int{2, 2}, "hexagon"
+	tc.Radius, tc.RDecay = 1.0, "exp"
+	assert.NoError(ValidateConfig(mc, tc, false))
+	assert.NoError(ValidateConfig(mc, tc, true))
+	assert.NoError(ValidateConfig(nil, nil, false))
+}
@@ -0,0 +1,247 @@
+package som
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileConfig is map and training configuration stored in a config file read by LoadConfig.
+// Fields which are not set use library defaults: map dimensions are estimated from data by GridSize
+// and the training uses NewTrainConfig defaults with the initial radius derived from map dimensions.
+type FileConfig struct {
+	// Dims holds map grid dimensions
+	Dims []int `json:"dims"`
+	// Grid is map grid type
+	Grid string `json:"grid"`
+	// UShape is map unit shape
+	UShape string `json:"ushape"`
+	// Connectivity is connectivity of rectangle units: axial, moore
+	Connectivity string `json:"connectivity"`
+	// Init is codebook initialization: rand, lin, sample
+	Init string `json:"init"`
+	// Precision is codebook storage precision: float64, float32
+	Precision string `json:"precision"`
+	// Seed is the seed of random codebook initialization and training sample picking
+	Seed *int64 `json:"seed"`
+	// Algorithm is training algorithm: seq, batch, neuralgas, gng, temporal, gsom
+	Algorithm string `json:"algorithm"`
+	// Radius is initial radius; zero radius is derived from map dimensions
+	Radius float64 `json:"radius"`
+	// RDecay is radius decay strategy
+	RDecay string `json:"rdecay"`
+	// Neighb is neighbourhood function name
+	Neighb string `json:"neighb"`
+	// LRate is initial learning rate
+	LRate float64 `json:"lrate"`
+	// LDecay is learning rate decay strategy
+	LDecay string `json:"ldecay"`
+	// Iters is the number of training iterations
+	Iters int `json:"iters"`
+	// Workers is the number of training worker goroutines
+	Workers int `json:"workers"`
+	// UpdateRule is batch training codebook update rule: mean, median, trimmed
+	UpdateRule string `json:"updaterule"`
+	// TrimFraction is the fraction of sample weight trimmed by trimmed update rule
+	TrimFraction float64 `json:"trim"`
+	// Leak is temporal training activation leak coefficient
+	Leak float64 `json:"leak"`
+	// GrowThreshold is gsom training unit quantization error above which the grid grows
+	GrowThreshold float64 `json:"growthreshold"`
+	// Patience is the number of epochs without quantization error improvement which stop the training
+	Patience int `json:"patience"`
+	// MinDelta is the quantization error decrease which counts as improvement
+	MinDelta float64 `json:"mindelta"`
+	// Revive re-initialises dead units during training
+	Revive bool `json:"revive"`
+	// ReviveInterval is the number of epochs between dead unit revivals
+	ReviveInterval int `json:"reviveinterval"`
+	// ReviveStrategy is dead unit revival strategy: split, sample
+	ReviveStrategy string `json:"revivestrategy"`
+	// Phases holds training phases with their own iters, radius and lrate which replace
+	// the number of training iterations
+	Phases []PhaseConfig `json:"phases"`
+}
+
+// LoadConfig reads config file in path and validates it. JSON files, which have .json extension, are
+// decoded directly; files of other formats, such as YAML or TOML, are converted to JSON by the converter
+// registered for their extension by RegisterConfigFormat. Unknown fields are rejected.
+// It returns error if the file can't be read, if its format is not supported or if it is invalid;
+// validation errors name the offending fields.
+func LoadConfig(path string) (*FileConfig, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	toJSON, err := configFormat(ext)
+	if err != nil {
+		return nil, err
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if toJSON != nil {
+		if body, err = toJSON(body); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %s", path, err)
+		}
+	}
+	c := new(FileConfig)
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %s", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Validate checks the configuration fields and the training configuration they populate.
+// It returns error which joins the problems of all invalid fields, each prefixed by the field name.
+// Field problems are ConfigError values whose Field is the config file field name.
+func (c *FileConfig) Validate() error {
+	v := &validator{}
+	field := func(name string, value interface{}, err error) {
+		if err != nil {
+			v.param(name, value, fmt.Errorf("invalid field %s: %w", name, err))
+		}
+	}
+	if c.Dims != nil {
+		if len(c.Dims) != 2 {
+			field("dims", c.Dims, fmt.Errorf("%w: %v, must have 2 dimensions", ErrInvalidDims, c.Dims))
+		} else {
+			field("dims", c.Dims, gridDimsError(c.Dims))
+		}
+	}
+	if c.Grid != "" {
+		_, err := ParseGridType(c.Grid)
+		field("grid", c.Grid, err)
+	}
+	if c.UShape != "" {
+		_, err := ParseUnitShape(c.UShape)
+		field("ushape", c.UShape, err)
+	}
+	if c.Connectivity != "" {
+		_, err := ParseConnectivity(c.Connectivity)
+		field("connectivity", c.Connectivity, err)
+	}
+	if c.Init != "" && cbInitFunc(c.Init) == nil {
+		field("init", c.Init, fmt.Errorf("unsupported codebook initialization: %s", c.Init))
+	}
+	if c.Precision != "" && !precisions.Has(c.Precision) {
+		field("precision", c.Precision, fmt.Errorf("unsupported codebook precision: %s", c.Precision))
+	}
+	if c.Algorithm != "" {
+		_, err := ParseMethod(c.Algorithm)
+		field("algorithm", c.Algorithm, err)
+	}
+	if c.Radius < 0 {
+		field("radius", c.Radius, fmt.Errorf("%w: %f, must not be negative", ErrInvalidRadius, c.Radius))
+	}
+	if c.RDecay != "" {
+		_, err := ParseDecay(c.RDecay)
+		field("rdecay", c.RDecay, err)
+	}
+	if c.Neighb != "" {
+		_, err := NeighbFuncByName(c.Neighb)
+		field("neighb", c.Neighb, err)
+	}
+	if c.LRate < 0 {
+		field("lrate", c.LRate, fmt.Errorf("%w: %f, must not be negative", ErrInvalidLRate, c.LRate))
+	}
+	if c.LDecay != "" {
+		_, err := ParseDecay(c.LDecay)
+		field("ldecay", c.LDecay, err)
+	}
+	if c.Iters < 0 {
+		field("iters", c.Iters, fmt.Errorf("invalid number of iterations: %d", c.Iters))
+	}
+	if c.Workers < 0 {
+		field("workers", c.Workers, fmt.Errorf("invalid number of workers: %d", c.Workers))
+	}
+	if c.UpdateRule != "" && !updateRules.Has(c.UpdateRule) {
+		field("updaterule", c.UpdateRule, fmt.Errorf("unsupported batch update rule: %s", c.UpdateRule))
+	}
+	if c.ReviveStrategy != "" && !reviveStrategies.Has(c.ReviveStrategy) {
+		field("revivestrategy", c.ReviveStrategy, fmt.Errorf("unsupported dead unit revival strategy: %s", c.ReviveStrategy))
+	}
+	if err := v.err(); err != nil {
+		return err
+	}
+	// field combinations are checked by training configuration validation
+	tc, err := c.TrainConfig()
+	if err != nil {
+		return err
+	}
+	return validateTrainConfig(tc)
+}
+
+// MapOptions returns map options set in the configuration.
+// It returns error if the map dimensions are set and they are not 2 dimensions.
+func (c *FileConfig) MapOptions() ([]Option, error) {
+	var opts []Option
+	if c.Dims != nil {
+		if len(c.Dims) != 2 {
+			return nil, fmt.Errorf("invalid map dimensions: %v", c.Dims)
+		}
+		opts = append(opts, WithDims(c.Dims[0], c.Dims[1]))
+	}
+	if c.Grid != "" {
+		opts = append(opts, WithGrid(c.Grid))
+	}
+	if c.UShape != "" {
+		opts = append(opts, WithUShape(c.UShape))
+	}
+	if c.Connectivity != "" {
+		opts = append(opts, WithConnectivity(c.Connectivity))
+	}
+	if c.Init != "" {
+		opts = append(opts, WithInit(c.Init))
+	}
+	if c.Precision != "" {
+		opts = append(opts, WithPrecision(c.Precision))
+	}
+	if c.Seed != nil {
+		opts = append(opts, WithSeed(*c.Seed))
+	}
+	return opts, nil
+}
+
+// TrainConfig returns training configuration set in the configuration. Training sample picking
+// is seeded by Seed if it is set. It returns error if the neighbourhood function is not registered.
+func (c *FileConfig) TrainConfig() (*TrainConfig, error) {
+	tc, _ := NewTrainConfig()
+	if c.Algorithm != "" {
+		tc.Algorithm = Method(c.Algorithm)
+	}
+	tc.Radius, tc.AutoRadius = c.Radius, c.Radius == 0
+	if c.RDecay != "" {
+		tc.RDecay = Decay(c.RDecay)
+	}
+	if c.Neighb != "" {
+		nFn, err := NeighbFuncByName(c.Neighb)
+		if err != nil {
+			return nil, err
+		}
+		tc.NeighbFn = nFn
+	}
+	if c.LRate != 0 {
+		tc.LRate = c.LRate
+	}
+	if c.LDecay != "" {
+		tc.LDecay = Decay(c.LDecay)
+	}
+	tc.Workers = c.Workers
+	tc.UpdateRule, tc.TrimFraction = c.UpdateRule, c.TrimFraction
+	tc.Leak, tc.GrowThreshold = c.Leak, c.GrowThreshold
+	tc.ReviveDeadUnits, tc.ReviveInterval, tc.ReviveStrategy = c.Revive, c.ReviveInterval, c.ReviveStrategy
+	tc.Patience, tc.MinDelta = c.Patience, c.MinDelta
+	tc.Phases = c.Phases
+	if c.Seed != nil {
+		tc.Rand = rand.New(rand.NewSource(*c.Seed))
+	}
+	return tc, nil
+}
@@ -0,0 +1,109 @@
+package som
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeConfig writes config file content to file name in a temporary directory and returns its path
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeConfig(t, "cfg.json", `{"dims": [5, 4], "ushape": "rectangle", "algorithm": "seq", "radius": 2,
+		"neighb": "bubble", "seed": 3, "precision": "float32", "phases": [{"iters": 10, "radius": 1}]}`)
+	c, err := LoadConfig(path)
+	assert.NoError(err)
+	assert.Equal([]int{5, 4}, c.Dims)
+	assert.Equal("rectangle", c.UShape)
+	assert.Equal(int64(3), *c.Seed)
+	assert.Equal([]PhaseConfig{{Iters: 10, Radius: 1.0}}, c.Phases)
+	opts, err := c.MapOptions()
+	assert.NoError(err)
+	assert.Len(opts, 4)
+	tc, err := c.TrainConfig()
+	assert.NoError(err)
+	assert.Equal(Seq, tc.Algorithm)
+	assert.Equal(2.0, tc.Radius)
+	assert.False(tc.AutoRadius)
+	assert.Equal(Bubble(1.0, 2.0), tc.NeighbFn(1.0, 2.0))
+	assert.Equal(0.5, tc.LRate)
+	assert.NotNil(tc.Rand)
+	// defaults
+	c, err = LoadConfig(writeConfig(t, "empty.json", `{}`))
+	assert.NoError(err)
+	tc, err = c.TrainConfig()
+	assert.NoError(err)
+	assert.Equal(Batch, tc.Algorithm)
+	assert.True(tc.AutoRadius)
+
+	// validation errors name the fields
+	_, err = LoadConfig(writeConfig(t, "invalid.json", `{"ushape": "triangle", "precision": "float16", "radius": -1, "rdecay": "fast"}`))
+	assert.True(errors.Is(err, ErrInvalidRadius))
+	assert.True(errors.Is(err, ErrUnsupportedDecay))
+	assert.Contains(err.Error(), "invalid field ushape: unsupported unit shape: triangle")
+	assert.Contains(err.Error(), "invalid field precision: unsupported codebook precision: float16")
+	assert.Contains(err.Error(), "invalid field radius: invalid radius: -1.000000, must not be negative")
+	assert.Contains(err.Error(), "invalid field rdecay: unsupported decay strategy: fast")
+	// field combinations are checked by training configuration validation
+	_, err = LoadConfig(writeConfig(t, "trim.json", `{"updaterule": "trimmed", "trim": 0.7}`))
+	assert.Error(err)
+	_, err = LoadConfig(writeConfig(t, "unknown.json", `{"radiuss": 2}`))
+	assert.Contains(err.Error(), `unknown field "radiuss"`)
+	_, err = LoadConfig(writeConfig(t, "cfg.yaml", "radius: 2\n"))
+	assert.EqualError(err, `unsupported config file format: ".yaml"`)
+	_, err = LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(err)
+}
+
+func TestRegisterConfigFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	// converts key=value lines to JSON object of numbers
+	kv := func(b []byte) ([]byte, error) {
+		var out bytes.Buffer
+		out.WriteString("{")
+		for i, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+			kv := strings.SplitN(line, "=", 2)
+			if len(kv) != 2 {
+				return nil, errors.New("invalid line: " + line)
+			}
+			if i > 0 {
+				out.WriteString(",")
+			}
+			out.WriteString(`"` + strings.TrimSpace(kv[0]) + `":` + strings.TrimSpace(kv[1]))
+		}
+		out.WriteString("}")
+		return out.Bytes(), nil
+	}
+	assert.NoError(RegisterConfigFormat(".KV", kv))
+	defer func() {
+		configFormats.mu.Lock()
+		delete(configFormats.entries, ".kv")
+		configFormats.mu.Unlock()
+	}()
+	c, err := LoadConfig(writeConfig(t, "cfg.kv", "radius = 2\nlrate = 0.1\n"))
+	assert.NoError(err)
+	assert.Equal(2.0, c.Radius)
+	assert.Equal(0.1, c.LRate)
+	_, err = LoadConfig(writeConfig(t, "bad.kv", "radius\n"))
+	assert.Contains(err.Error(), "invalid line: radius")
+
+	assert.Error(RegisterConfigFormat(".kv", kv))
+	assert.Error(RegisterConfigFormat(".json", kv))
+	assert.Error(RegisterConfigFormat("", kv))
+	assert.Error(RegisterConfigFormat(".ini", nil))
+}
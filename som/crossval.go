@@ -0,0 +1,173 @@
+package som
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+)
+
+// ParamSet bundles SOM configuration parameters evaluated by CrossValidate
+type ParamSet struct {
+	// Map holds SOM configuration
+	Map *MapConfig
+	// Train holds SOM training configuration
+	Train *TrainConfig
+	// Iters is a number of training iterations
+	Iters int
+}
+
+// CVResult holds cross-validation results of a particular ParamSet
+type CVResult struct {
+	// Params is the evaluated parameter set
+	Params ParamSet
+	// QuantErrMean is the mean held-out quantization error across all folds
+	QuantErrMean float64
+	// QuantErrStd is the standard deviation of held-out quantization error across all folds
+	QuantErrStd float64
+	// TopoErrMean is the mean held-out topographic error across all folds
+	TopoErrMean float64
+	// TopoErrStd is the standard deviation of held-out topographic error across all folds
+	TopoErrStd float64
+	// Err is set if any of the ParamSet runs failed
+	Err error
+}
+
+// cvJob is a single cross-validation run: one ParamSet trained on one fold
+type cvJob struct {
+	param int
+	fold  int
+	seed  int64
+}
+
+// CrossValidate evaluates SOM parameter sets using k-fold cross-validation.
+// Data rows are shuffled and split into folds number of folds. For each ParamSet and fold
+// a new map is trained on the remaining folds and its quantization and topographic errors
+// are computed on the held-out fold. Runs are executed concurrently by at most workers
+// goroutines; if workers is not a positive integer, the number of CPUs is used.
+// The results are deterministic for a fixed seed and are returned in the same order as params.
+// Failed runs are reported in the Err field of particular CVResult.
+// CrossValidate returns error if data is nil, params is empty or folds is invalid.
+func CrossValidate(data *mat64.Dense, params []ParamSet, folds int, seed int64, workers int) ([]CVResult, error) {
+	// data can't be nil
+	if data == nil {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	// need at least one parameter set
+	if len(params) == 0 {
+		return nil, fmt.Errorf("invalid number of parameter sets: %d", len(params))
+	}
+	rows, _ := data.Dims()
+	// every fold must contain at least one sample
+	if folds < 2 || folds > rows {
+		return nil, fmt.Errorf("invalid number of folds: %d", folds)
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	// shuffle the data rows and split them into folds
+	r := rand.New(rand.NewSource(seed))
+	perm := r.Perm(rows)
+	foldIdx := make([][]int, folds)
+	for i, row := range perm {
+		foldIdx[i%folds] = append(foldIdx[i%folds], row)
+	}
+	// results of all runs: params x folds
+	qErrs := make([][]float64, len(params))
+	tErrs := make([][]float64, len(params))
+	errs := make([][]error, len(params))
+	for i := range params {
+		qErrs[i] = make([]float64, folds)
+		tErrs[i] = make([]float64, folds)
+		errs[i] = make([]error, folds)
+	}
+	jobs := make(chan cvJob)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				train, test := cvSplit(data, foldIdx, job.fold)
+				p := params[job.param]
+				qe, te, err := cvRun(p, train, test, job.seed)
+				qErrs[job.param][job.fold] = qe
+				tErrs[job.param][job.fold] = te
+				errs[job.param][job.fold] = err
+			}
+		}()
+	}
+	for i := range params {
+		for j := 0; j < folds; j++ {
+			// every run gets its own seed derived from the cross-validation seed
+			jobs <- cvJob{param: i, fold: j, seed: seed + int64(i*folds+j) + 1}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	// aggregate the results
+	results := make([]CVResult, len(params))
+	for i, p := range params {
+		results[i].Params = p
+		for j := 0; j < folds; j++ {
+			if errs[i][j] != nil {
+				results[i].Err = fmt.Errorf("fold %d: %v", j, errs[i][j])
+				break
+			}
+		}
+		if results[i].Err != nil {
+			continue
+		}
+		results[i].QuantErrMean, results[i].QuantErrStd = stat.MeanStdDev(qErrs[i], nil)
+		results[i].TopoErrMean, results[i].TopoErrStd = stat.MeanStdDev(tErrs[i], nil)
+	}
+
+	return results, nil
+}
+
+// cvSplit splits data into training set and a test set which consists of fold-th fold rows
+func cvSplit(data *mat64.Dense, foldIdx [][]int, fold int) (*mat64.Dense, *mat64.Dense) {
+	rows, cols := data.Dims()
+	testRows := len(foldIdx[fold])
+	train := mat64.NewDense(rows-testRows, cols, nil)
+	test := mat64.NewDense(testRows, cols, nil)
+	trainRow := 0
+	for i, idx := range foldIdx {
+		for j, row := range idx {
+			if i == fold {
+				test.SetRow(j, data.RawRowView(row))
+				continue
+			}
+			train.SetRow(trainRow, data.RawRowView(row))
+			trainRow++
+		}
+	}
+	return train, test
+}
+
+// cvRun trains a new SOM using the supplied parameters on train data and evaluates it on test data.
+// It returns quantization and topographic error of the trained map.
+func cvRun(p ParamSet, train, test *mat64.Dense, seed int64) (float64, float64, error) {
+	if p.Map == nil || p.Map.Grid == nil || p.Map.Cb == nil || p.Train == nil {
+		return -1.0, -1.0, fmt.Errorf("invalid parameter set: %v", p)
+	}
+	m, err := NewMap(p.Map, train)
+	if err != nil {
+		return -1.0, -1.0, err
+	}
+	if _, err := m.train(p.Train, train, p.Iters, rand.New(rand.NewSource(seed))); err != nil {
+		return -1.0, -1.0, err
+	}
+	qe, err := m.QuantError(test)
+	if err != nil {
+		return -1.0, -1.0, err
+	}
+	te, err := m.TopoError(test)
+	if err != nil {
+		return -1.0, -1.0, err
+	}
+	return qe, te, nil
+}
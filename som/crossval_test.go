@@ -0,0 +1,84 @@
+package som
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeCVParamSet(size []int) ParamSet {
+	return ParamSet{
+		Map: &MapConfig{
+			Grid: &GridConfig{
+				Size:   size,
+				Type:   "planar",
+				UShape: "hexagon",
+			},
+			Cb: &CbConfig{
+				Dim:      2,
+				InitFunc: RandInit,
+			},
+		},
+		Train: &TrainConfig{
+			Algorithm: "seq",
+			Radius:    5.0,
+			RDecay:    "exp",
+			NeighbFn:  Gaussian,
+			LRate:     0.5,
+			LDecay:    "exp",
+		},
+		Iters: 2000,
+	}
+}
+
+func TestCrossValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(100, 2, 4, 10.0, -10.0, 2.0, 10)
+	params := []ParamSet{
+		makeCVParamSet([]int{2, 1}),
+		makeCVParamSet([]int{8, 8}),
+	}
+	// nil data
+	errString := "invalid data supplied: %v"
+	res, err := CrossValidate(nil, params, 3, 1, 2)
+	assert.Nil(res)
+	assert.EqualError(err, fmt.Sprintf(errString, nil))
+	// no parameter sets
+	errString = "invalid number of parameter sets: %d"
+	res, err = CrossValidate(data, nil, 3, 1, 2)
+	assert.Nil(res)
+	assert.EqualError(err, fmt.Sprintf(errString, 0))
+	// invalid folds
+	errString = "invalid number of folds: %d"
+	for _, folds := range []int{-1, 1, 101} {
+		res, err = CrossValidate(data, params, folds, 1, 2)
+		assert.Nil(res)
+		assert.EqualError(err, fmt.Sprintf(errString, folds))
+	}
+	// bigger map must have smaller held-out quantization error
+	res, err = CrossValidate(data, params, 3, 1, 2)
+	assert.NoError(err)
+	assert.Len(res, len(params))
+	for _, r := range res {
+		assert.NoError(r.Err)
+		assert.True(r.QuantErrMean > 0.0)
+		assert.True(r.QuantErrStd >= 0.0)
+	}
+	assert.True(res[1].QuantErrMean < res[0].QuantErrMean)
+	// same seed gives the same results regardless of worker count
+	again, err := CrossValidate(data, params, 3, 1, 1)
+	assert.NoError(err)
+	assert.Equal(res, again)
+	// invalid parameter set is reported but doesn't abort the sweep
+	invalid := makeCVParamSet([]int{2, 2})
+	invalid.Train = &TrainConfig{Algorithm: "foobar"}
+	res, err = CrossValidate(data, append(params, invalid), 3, 1, 2)
+	assert.NoError(err)
+	assert.Len(res, len(params)+1)
+	assert.NoError(res[0].Err)
+	assert.NoError(res[1].Err)
+	assert.Error(res[2].Err)
+}
@@ -0,0 +1,121 @@
+package som
+
+import (
+	"fmt"
+	"math"
+)
+
+// DecayFunc returns value of a parameter, such as units radius or learning rate, at iteration
+// of totalIterations iterations. The value decays from init at the first iteration to min
+// at the last iteration totalIterations-1; totalIterations is at least 2.
+type DecayFunc func(iteration, totalIterations int, init, min float64) float64
+
+// decay returns decay function of strategy; strategies which are not supported decay exponentially
+func decay(strategy Decay) DecayFunc {
+	if fn := decayFunc(string(strategy)); fn != nil {
+		return fn
+	}
+	return expDecay
+}
+
+// decayed returns value of parameter decayed by fn from init to min at iteration of totalIterations
+// iterations; single iteration training has no decay
+func decayed(fn DecayFunc, iteration, totalIterations int, init, min float64) float64 {
+	if totalIterations < 2 {
+		return init
+	}
+	return fn(iteration, totalIterations, init, min)
+}
+
+// rDecay returns radius decay function of the configuration: RDecayFn if it is set or RDecay strategy
+func (c *TrainConfig) rDecay() DecayFunc {
+	if c.RDecayFn != nil {
+		return c.RDecayFn
+	}
+	return decay(c.RDecay)
+}
+
+// lDecay returns learning rate decay function of the configuration: LDecayFn if it is set or LDecay strategy
+func (c *TrainConfig) lDecay() DecayFunc {
+	if c.LDecayFn != nil {
+		return c.LDecayFn
+	}
+	return decay(c.LDecay)
+}
+
+// radius returns units radius at iter-th out of iters training iterations
+func (c *TrainConfig) radius(iter, iters int) float64 {
+	if c.RSchedule != nil {
+		return c.RSchedule.At(iter, iters)
+	}
+	return decayed(c.rDecay(), iter, iters, c.Radius, MinRadius)
+}
+
+// initial returns initial radius and learning rate of iters training iterations:
+// the configured values or the first values of the configured schedules
+func (c *TrainConfig) initial(iters int) (float64, float64) {
+	radius, lRate := c.Radius, c.LRate
+	if c.RSchedule != nil {
+		radius = c.RSchedule.At(0, iters)
+	}
+	if c.LSchedule != nil {
+		lRate = c.LSchedule.At(0, iters)
+	}
+	return radius, lRate
+}
+
+// lRate returns learning rate at iter-th out of iters training iterations
+func (c *TrainConfig) lRate(iter, iters int) float64 {
+	if c.LSchedule != nil {
+		return c.LSchedule.At(iter, iters)
+	}
+	return decayed(c.lDecay(), iter, iters, c.LRate, MinLRate)
+}
+
+// StepDecay returns decay function which keeps the value constant between milestones and drops it
+// at every milestone, so the value decays from init to min in len(milestones) steps of equal ratio.
+// Milestones are fractions of training iterations in increasing order: 0.5 drops the value
+// halfway through training. The last step is taken at the last iteration at the latest.
+// It returns error if no milestones are supplied or if they are not increasing
+// or not greater than 0 and at most 1.
+func StepDecay(milestones ...float64) (DecayFunc, error) {
+	if len(milestones) == 0 {
+		return nil, fmt.Errorf("invalid step decay: no milestones")
+	}
+	for i, ms := range milestones {
+		if !(ms > 0 && ms <= 1) || (i > 0 && ms <= milestones[i-1]) {
+			return nil, fmt.Errorf("invalid step decay milestone: %f, milestones must increase from 0 to 1", ms)
+		}
+	}
+	ms := append([]float64(nil), milestones...)
+	return func(iteration, totalIterations int, init, min float64) float64 {
+		t := float64(iteration) / float64(totalIterations-1)
+		steps := 0
+		for steps < len(ms) && ms[steps] <= t {
+			steps++
+		}
+		return init * math.Pow(min/init, float64(steps)/float64(len(ms)))
+	}, nil
+}
+
+// linDecay decays linearly
+func linDecay(iteration, totalIterations int, init, min float64) float64 {
+	return init - float64(iteration)/float64(totalIterations-1)*(init-min)
+}
+
+// expDecay decays exponentially
+func expDecay(iteration, totalIterations int, init, min float64) float64 {
+	lambda := float64(totalIterations-1) / math.Log(init/min)
+	return init * math.Exp(-float64(iteration)/lambda)
+}
+
+// powDecay decays by power series init * (min/init)^(t/(totalIterations-1))
+func powDecay(iteration, totalIterations int, init, min float64) float64 {
+	return init * math.Pow(min/init, float64(iteration)/float64(totalIterations-1))
+}
+
+// invDecay decays inversely proportionally to the iteration
+func invDecay(iteration, totalIterations int, init, min float64) float64 {
+	a := (init/min - 1) / float64(totalIterations-1)
+	return init / (1 + a*float64(iteration))
+}
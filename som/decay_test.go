@@ -0,0 +1,77 @@
+package som
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPowDecay(t *testing.T) {
+	assert := assert.New(t)
+
+	testRadius(t, "pow")
+	testLR(t, "pow")
+	for _, iter := range []int{0, 10, 50, 99} {
+		assert.InDelta(expDecay(iter, 100, 10.0, 1.0), powDecay(iter, 100, 10.0, 1.0), 1e-9)
+	}
+}
+
+func TestStepDecay(t *testing.T) {
+	assert := assert.New(t)
+
+	fn, err := StepDecay(0.25, 0.5)
+	assert.NoError(err)
+	// iterations 0 to 10 of 11 iterations are fractions 0 to 1 of training
+	for iter, exp := range map[int]float64{0: 8.0, 2: 8.0, 3: 4.0, 4: 4.0, 5: 2.0, 10: 2.0} {
+		assert.InDelta(exp, fn(iter, 11, 8.0, 2.0), 1e-12, "iteration %d", iter)
+	}
+	// the last milestone is reached at the last iteration
+	fn, err = StepDecay(1.0)
+	assert.NoError(err)
+	assert.Equal(8.0, fn(9, 11, 8.0, 2.0))
+	assert.Equal(2.0, fn(10, 11, 8.0, 2.0))
+
+	_, err = StepDecay()
+	assert.EqualError(err, "invalid step decay: no milestones")
+	for _, milestones := range [][]float64{{0.0}, {1.5}, {0.5, 0.5}, {0.6, 0.3}, {math.NaN()}} {
+		_, err = StepDecay(milestones...)
+		assert.Error(err, "%v", milestones)
+	}
+}
+
+func TestTrainDecayFn(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(20, 1)
+	rows, _ := data.Dims()
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	rFn, err := StepDecay(0.5)
+	assert.NoError(err)
+	lFn := func(iteration, totalIterations int, init, min float64) float64 { return init / 2 }
+	var radii, lRates []float64
+	tc := &TrainConfig{
+		Algorithm: Seq,
+		Radius:    3.0,
+		RDecay:    "foobar",
+		RDecayFn:  rFn,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecayFn:  lFn,
+		OnEpoch: func(e Epoch) bool {
+			radii, lRates = append(radii, e.Radius), append(lRates, e.LRate)
+			return true
+		},
+	}
+	// decay functions override decay strategies
+	assert.NoError(validateTrainConfig(tc))
+	_, err = m.TrainWithResult(tc, mat64.DenseCopyOf(data), 4*rows)
+	assert.NoError(err)
+	assert.Equal([]float64{3.0, 3.0, MinRadius, MinRadius}, radii)
+	assert.Equal([]float64{0.25, 0.25, 0.25, 0.25}, lRates)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"sort"
 
 	"github.com/gonum/matrix/mat64"
 )
@@ -50,39 +51,33 @@ var colors = [][]int{{255, 0, 0}, {0, 255, 0}, {0, 0, 255}, {255, 255, 0}, {255,
 // writer   - the io.Writter to write the output SVG to.
 // classes  - if the classes are known (i.e. these are test data) they can be displayed providing the information in this map.
 // The map is: codebook vector row -> class number. When classes are not known (i.e. running with real data), just provide an empty map
+// UMatrixSVG returns ErrInvalidDims if dims are not 2D: u-matrix of 3D grids is available from UMatrixValues.
 func UMatrixSVG(codebook *mat64.Dense, dims []int, uShape, title string, writer io.Writer, classes map[int]int) error {
-	xmlEncoder := xml.NewEncoder(writer)
-	// array to hold the xml elements
-	elems := []interface{}{h1{Title: title}}
-
-	rows, _ := codebook.Dims()
-	distMat, err := DistanceMx("euclidean", codebook)
-	if err != nil {
-		return err
+	if len(dims) != 2 {
+		return fmt.Errorf("%w: %v, u-matrix SVG supports 2 dimensions", ErrInvalidDims, dims)
 	}
 	coords, err := GridCoords(uShape, dims)
 	if err != nil {
 		return err
 	}
-	coordsDistMat, err := DistanceMx("euclidean", coords)
+	umatrix, err := umatrixValues(codebook, &Grid{coords: coords})
 	if err != nil {
 		return err
 	}
+	return umatrixSVG(umatrix, coords, dims, uShape, title, writer, classes)
+}
 
-	umatrix := make([]float64, rows)
+// umatrixSVG writes an SVG representation of u-matrix values of units with the given grid coordinates
+// to writer. The remaining parameters are the same as the parameters of UMatrixSVG.
+func umatrixSVG(umatrix []float64, coords *mat64.Dense, dims []int, uShape, title string, writer io.Writer, classes map[int]int) error {
+	xmlEncoder := xml.NewEncoder(writer)
+	// array to hold the xml elements
+	elems := []interface{}{h1{Title: title}}
+
+	rows := len(umatrix)
 	maxDistance := -math.MaxFloat64
 	minDistance := math.MaxFloat64
-	for row := 0; row < rows; row++ {
-		avgDistance := 0.0
-		// this is a rough approximation of the notion of neighbor grid coords
-		allRowsInRadius := allRowsInRadius(row, math.Sqrt2*1.01, coordsDistMat)
-		for _, rwd := range allRowsInRadius {
-			if rwd.Dist > 0.0 {
-				avgDistance += distMat.At(row, rwd.Row)
-			}
-		}
-		avgDistance /= float64(len(allRowsInRadius) - 1)
-		umatrix[row] = avgDistance
+	for _, avgDistance := range umatrix {
 		if avgDistance > maxDistance {
 			maxDistance = avgDistance
 		}
@@ -170,6 +165,167 @@ func UMatrixSVG(codebook *mat64.Dense, dims []int, uShape, title string, writer
 	return nil
 }
 
+// UMatrixValues returns u-matrix of the map: the average codebook distance of every unit
+// from its neighbouring grid units. These are the values rendered by UMatrix.
+// It returns error if the distances could not be computed or ErrNoTopology if the map has no topology.
+func (m Map) UMatrixValues() ([]float64, error) {
+	if m.noTopology {
+		return nil, ErrNoTopology
+	}
+	return umatrixValues(m.cb(), m.grid)
+}
+
+// UMatrixOptions configures u-matrix computed by UMatrixValuesWith
+type UMatrixOptions struct {
+	// Aggregation combines codebook distances of every unit from its neighbouring grid units:
+	// mean, median or min. Median and min are less sensitive to a single distant neighbour,
+	// which makes u-matrices of maps trained on small data sets less noisy. The default is mean.
+	Aggregation string
+	// Sigma is the standard deviation of gaussian smoothing of the u-matrix over the map grid
+	// in grid distance units. The default zero does not smooth the u-matrix.
+	Sigma float64
+}
+
+// UMatrixValuesWith returns u-matrix of the map configured by opts: the codebook distances of every unit
+// from its neighbouring grid units are aggregated by opts.Aggregation and, if opts.Sigma is positive,
+// every value is replaced by the gaussian weighted average of values of all units by their grid distance.
+// UMatrixValuesWith with zero options returns the same values as UMatrixValues. It returns error if
+// the aggregation is not supported, if sigma is negative or not finite or if the distances could not
+// be computed, or ErrNoTopology if the map has no topology.
+func (m Map) UMatrixValuesWith(opts UMatrixOptions) ([]float64, error) {
+	if m.noTopology {
+		return nil, ErrNoTopology
+	}
+	agg := opts.Aggregation
+	if agg == "" {
+		agg = "mean"
+	}
+	if agg != "mean" && agg != "median" && agg != "min" {
+		return nil, fmt.Errorf("unsupported u-matrix aggregation: %s", agg)
+	}
+	if !(opts.Sigma >= 0) || math.IsInf(opts.Sigma, 1) {
+		return nil, fmt.Errorf("invalid u-matrix smoothing sigma: %f, must be non-negative and finite", opts.Sigma)
+	}
+	distMat, err := DistanceMx("euclidean", m.cb())
+	if err != nil {
+		return nil, err
+	}
+	umatrix, err := umatrixAgg(distMat, m.grid, agg)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Sigma == 0 {
+		return umatrix, nil
+	}
+	unitDist, err := m.UnitDist()
+	if err != nil {
+		return nil, err
+	}
+	smoothed := make([]float64, len(umatrix))
+	for i := range smoothed {
+		sum, total := 0.0, 0.0
+		for k, d := range unitDist.RawRowView(i) {
+			// units without neighbours have no u-matrix value
+			if math.IsNaN(umatrix[k]) {
+				continue
+			}
+			w := math.Exp(-d * d / (2 * opts.Sigma * opts.Sigma))
+			sum += w * umatrix[k]
+			total += w
+		}
+		smoothed[i] = sum / total
+	}
+	return smoothed, nil
+}
+
+// ComponentPlane returns component plane of codebook component dim: the value of the component
+// of every map unit codebook vector. Component planes show how the feature varies across the map.
+// It returns error if dim is not a valid codebook component index.
+func (m Map) ComponentPlane(dim int) ([]float64, error) {
+	units, cols := m.cbDims()
+	if dim < 0 || dim >= cols {
+		return nil, fmt.Errorf("invalid codebook component: %d, codebook dimension is %d", dim, cols)
+	}
+	plane := make([]float64, units)
+	if m.cb32 != nil {
+		for i := range plane {
+			plane[i] = float64(m.cb32.rowView(i)[dim])
+		}
+		return plane, nil
+	}
+	return mat64.Col(plane, dim, m.codebook), nil
+}
+
+// umatrixValues computes the average distance of every codebook vector from codebook vectors
+// of neighbouring units; see umatrixDist
+func umatrixValues(codebook *mat64.Dense, g *Grid) ([]float64, error) {
+	distMat, err := DistanceMx("euclidean", codebook)
+	if err != nil {
+		return nil, err
+	}
+	return umatrixDist(distMat, g)
+}
+
+// umatrixDist computes the average distance of every unit from its neighbouring units of grid g
+// using the matrix of distances between unit prototypes; see Grid.neighbRadius
+func umatrixDist(distMat *mat64.Dense, g *Grid) ([]float64, error) {
+	return umatrixAgg(distMat, g, "mean")
+}
+
+// umatrixAgg aggregates distances of every unit from its neighbouring units of grid g by aggregation
+// agg using the matrix of distances between unit prototypes; see umatrixDist
+func umatrixAgg(distMat *mat64.Dense, g *Grid, agg string) ([]float64, error) {
+	rows, _ := distMat.Dims()
+	if g.coords == nil {
+		return nil, fmt.Errorf("invalid grid coordinates: %v", g.coords)
+	}
+	coordsDistMat := g.unitDist()
+	radius := g.neighbRadius()
+
+	umatrix := make([]float64, rows)
+	dists := make([]float64, 0, rows)
+	for row := 0; row < rows; row++ {
+		dists = dists[:0]
+		// this is a rough approximation of the notion of neighbor grid coords
+		for _, rwd := range allRowsInRadius(row, radius*1.01, coordsDistMat) {
+			if rwd.Dist > 0.0 {
+				dists = append(dists, distMat.At(row, rwd.Row))
+			}
+		}
+		umatrix[row] = aggregate(dists, agg)
+	}
+
+	return umatrix, nil
+}
+
+// aggregate returns the mean, median or min of values x. It returns NaN if x is empty.
+// Median sorts x in place.
+func aggregate(x []float64, agg string) float64 {
+	if len(x) == 0 {
+		return math.NaN()
+	}
+	switch agg {
+	case "median":
+		sort.Float64s(x)
+		mid := len(x) / 2
+		if len(x)%2 == 0 {
+			return (x[mid-1] + x[mid]) / 2
+		}
+		return x[mid]
+	case "min":
+		lowest := x[0]
+		for _, v := range x[1:] {
+			lowest = math.Min(lowest, v)
+		}
+		return lowest
+	}
+	sum := 0.0
+	for _, v := range x {
+		sum += v
+	}
+	return sum / float64(len(x))
+}
+
 func allRowsInRadius(selectedRow int, radius float64, distMatrix *mat64.Dense) []rowWithDist {
 	rowsInRadius := []rowWithDist{}
 	for i, dist := range distMatrix.RowView(selectedRow).RawVector().Data {
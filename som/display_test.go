@@ -2,6 +2,9 @@ package som
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"math"
 	"strings"
 	"testing"
 
@@ -58,3 +61,148 @@ func TestUMatrixSVGWithClusters(t *testing.T) {
 	// make sure there is at least one text element
 	assert.True(strings.Contains(svg, "<text "))
 }
+
+func TestUMatrixValues(t *testing.T) {
+	assert := assert.New(t)
+
+	cb := mat64.NewDense(4, 1, []float64{0.0, 1.0, 2.0, 3.0})
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{2, 2},
+			Type:   "planar",
+			UShape: "rectangle",
+		},
+		Cb: &CbConfig{
+			Dim: 1,
+			InitFunc: func(*mat64.Dense, []int) (*mat64.Dense, error) {
+				return cb, nil
+			},
+		},
+	}
+	m, err := NewMap(mCfg, cb)
+	assert.NoError(err)
+	umatrix, err := m.UMatrixValues()
+	assert.NoError(err)
+	// all units of 2x2 map are neighbours of each other
+	exp := []float64{2.0, 4.0 / 3.0, 4.0 / 3.0, 2.0}
+	for i := range exp {
+		assert.InDelta(exp[i], umatrix[i], 1e-12)
+	}
+}
+
+func TestUMatrixValues3D(t *testing.T) {
+	assert := assert.New(t)
+
+	cb := mat64.NewDense(8, 1, []float64{0, 1, 2, 3, 4, 5, 6, 7})
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{2, 2, 2},
+			Type:   "planar",
+			UShape: "rectangle",
+		},
+		Cb: &CbConfig{
+			Dim: 1,
+			InitFunc: func(*mat64.Dense, []int) (*mat64.Dense, error) {
+				return cb, nil
+			},
+		},
+	}
+	m, err := NewMap(mCfg, cb)
+	assert.NoError(err)
+	umatrix, err := m.UMatrixValues()
+	assert.NoError(err)
+	// all units of 2x2x2 map are neighbours of each other
+	for i := range umatrix {
+		sum := 0.0
+		for j := 0; j < 8; j++ {
+			sum += math.Abs(float64(i - j))
+		}
+		assert.InDelta(sum/7, umatrix[i], 1e-12)
+	}
+	// SVG rendering is 2D
+	var out bytes.Buffer
+	err = m.UMatrix(&out, cb, nil, "svg", "3D")
+	assert.True(errors.Is(err, ErrInvalidDims))
+}
+
+func TestUMatrixValuesWith(t *testing.T) {
+	assert := assert.New(t)
+
+	cb := mat64.NewDense(4, 1, []float64{0.0, 1.0, 2.0, 3.0})
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{2, 2}, Type: Planar, UShape: Rectangle},
+		Cb: &CbConfig{
+			Dim: 1,
+			InitFunc: func(*mat64.Dense, []int) (*mat64.Dense, error) {
+				return cb, nil
+			},
+		},
+	}, cb)
+	assert.NoError(err)
+	mean, err := m.UMatrixValues()
+	assert.NoError(err)
+	umatrix, err := m.UMatrixValuesWith(UMatrixOptions{})
+	assert.NoError(err)
+	assert.Equal(mean, umatrix)
+	// unit neighbour distances: {1, 2, 3}, {1, 1, 2}, {2, 1, 1}, {3, 2, 1}
+	umatrix, err = m.UMatrixValuesWith(UMatrixOptions{Aggregation: "median"})
+	assert.NoError(err)
+	assert.Equal([]float64{2.0, 1.0, 1.0, 2.0}, umatrix)
+	umatrix, err = m.UMatrixValuesWith(UMatrixOptions{Aggregation: "min"})
+	assert.NoError(err)
+	assert.Equal([]float64{1.0, 1.0, 1.0, 1.0}, umatrix)
+	// narrow smoothing keeps the values, wide smoothing averages them
+	umatrix, err = m.UMatrixValuesWith(UMatrixOptions{Sigma: 0.01})
+	assert.NoError(err)
+	assert.InDeltaSlice(mean, umatrix, 1e-9)
+	umatrix, err = m.UMatrixValuesWith(UMatrixOptions{Sigma: 1e6})
+	assert.NoError(err)
+	for _, v := range umatrix {
+		assert.InDelta(5.0/3.0, v, 1e-9)
+	}
+	umatrix, err = m.UMatrixValuesWith(UMatrixOptions{Sigma: 1.0})
+	assert.NoError(err)
+	assert.True(umatrix[0] < mean[0] && umatrix[1] > mean[1])
+
+	_, err = m.UMatrixValuesWith(UMatrixOptions{Aggregation: "max"})
+	assert.EqualError(err, "unsupported u-matrix aggregation: max")
+	_, err = m.UMatrixValuesWith(UMatrixOptions{Sigma: -1})
+	assert.EqualError(err, "invalid u-matrix smoothing sigma: -1.000000, must be non-negative and finite")
+	m.noTopology = true
+	_, err = m.UMatrixValuesWith(UMatrixOptions{})
+	assert.Equal(ErrNoTopology, err)
+}
+
+func TestComponentPlane(t *testing.T) {
+	assert := assert.New(t)
+
+	cb := mat64.NewDense(4, 2, []float64{
+		0.0, 1.0,
+		1.0, 0.5,
+		2.0, 0.25,
+		3.0, 0.125,
+	})
+	for _, precision := range []string{"float64", "float32"} {
+		m, err := NewMap(&MapConfig{
+			Grid: &GridConfig{Size: []int{2, 2}, Type: Planar, UShape: Rectangle},
+			Cb: &CbConfig{
+				Dim: 2,
+				InitFunc: func(*mat64.Dense, []int) (*mat64.Dense, error) {
+					return mat64.DenseCopyOf(cb), nil
+				},
+				Precision: precision,
+			},
+		}, cb)
+		assert.NoError(err)
+		plane, err := m.ComponentPlane(0)
+		assert.NoError(err)
+		assert.Equal([]float64{0.0, 1.0, 2.0, 3.0}, plane)
+		plane, err = m.ComponentPlane(1)
+		assert.NoError(err)
+		assert.Equal([]float64{1.0, 0.5, 0.25, 0.125}, plane)
+		for _, dim := range []int{-1, 2} {
+			_, err = m.ComponentPlane(dim)
+			assert.EqualError(err, fmt.Sprintf("invalid codebook component: %d, codebook dimension is 2", dim))
+		}
+	}
+}
@@ -2,13 +2,82 @@ package som
 
 import (
 	"container/heap"
+	"errors"
 	"fmt"
 	"math"
 
 	"github.com/gonum/matrix/mat64"
 )
 
-// Distance calculates metric distance between vectors a and b.
+// ErrDimMismatch is returned when vector dimension does not match the codebook dimension
+var ErrDimMismatch = errors.New("dimension mismatch")
+
+// DistanceFunc computes distance between vectors a and b of the same dimension
+type DistanceFunc func(a, b []float64) float64
+
+// Euclidean computes euclidean distance between vectors a and b
+func Euclidean(a, b []float64) float64 {
+	return euclideanVec(a, b)
+}
+
+// Manhattan computes the sum of absolute differences of vectors a and b
+func Manhattan(a, b []float64) float64 {
+	d := 0.0
+	for i := range a {
+		d += math.Abs(a[i] - b[i])
+	}
+	return d
+}
+
+// Chebyshev computes the largest absolute difference of vectors a and b
+func Chebyshev(a, b []float64) float64 {
+	d := 0.0
+	for i := range a {
+		d = math.Max(d, math.Abs(a[i]-b[i]))
+	}
+	return d
+}
+
+// Cosine computes cosine distance of vectors a and b: 1 minus the cosine of their angle.
+// The distance ranges from 0 to 2; it is 1 if either vector is zero.
+func Cosine(a, b []float64) float64 {
+	dot, aa, bb := 0.0, 0.0, 0.0
+	for i := range a {
+		dot += a[i] * b[i]
+		aa += a[i] * a[i]
+		bb += b[i] * b[i]
+	}
+	if aa == 0 || bb == 0 {
+		return 1.0
+	}
+	return 1 - dot/math.Sqrt(aa*bb)
+}
+
+// Correlation computes correlation distance of vectors a and b: 1 minus the Pearson correlation
+// of their elements. The distance ranges from 0 to 2; it is 1 if either vector is constant.
+func Correlation(a, b []float64) float64 {
+	meanA, meanB := 0.0, 0.0
+	for i := range a {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= float64(len(a))
+	meanB /= float64(len(b))
+	cov, varA, varB := 0.0, 0.0, 0.0
+	for i := range a {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 1.0
+	}
+	return 1 - cov/math.Sqrt(varA*varB)
+}
+
+// Distance calculates metric distance between vectors a and b. The metric is euclidean, manhattan,
+// chebyshev, cosine, correlation or a metric registered by RegisterDistanceFunc.
 // If unsupported metric is requested Distance returns euclidean distance.
 // It returns error if the supplied vectors are either nil or have different dimensions
 func Distance(metric string, a, b []float64) (float64, error) {
@@ -16,13 +85,16 @@ func Distance(metric string, a, b []float64) (float64, error) {
 		return 0.0, fmt.Errorf("invalid vectors supplied. a: %v, b: %v", a, b)
 	}
 	if len(a) != len(b) {
-		return 0.0, fmt.Errorf("Incorrect vector dims. a: %d, b: %d", len(a), len(b))
+		return 0.0, fmt.Errorf("%w: a: %d, b: %d", ErrDimMismatch, len(a), len(b))
 	}
 
 	switch metric {
 	case "euclidean":
 		return euclideanVec(a, b), nil
 	default:
+		if fn := distanceFunc(metric); fn != nil {
+			return fn(a, b), nil
+		}
 		return euclideanVec(a, b), nil
 	}
 }
@@ -37,12 +109,20 @@ func DistanceMx(metric string, m *mat64.Dense) (*mat64.Dense, error) {
 		return nil, fmt.Errorf("invalid matrix supplied: %v", m)
 	}
 
-	switch metric {
-	case "euclidean":
-		return euclideanMx(m), nil
-	default:
+	fn := distanceFunc(metric)
+	if metric == "euclidean" || fn == nil {
 		return euclideanMx(m), nil
 	}
+	rows, _ := m.Dims()
+	out := mat64.NewDense(rows, rows, nil)
+	for i := 0; i < rows-1; i++ {
+		for j := i + 1; j < rows; j++ {
+			d := fn(m.RawRowView(i), m.RawRowView(j))
+			out.Set(i, j, d)
+			out.Set(j, i, d)
+		}
+	}
+	return out, nil
 }
 
 // ClosestVec finds the closest vector to v in the list of vectors stored in m rows
@@ -80,9 +160,10 @@ func ClosestVec(metric string, v []float64, m *mat64.Dense) (int, error) {
 
 // ClosestNVec finds the N closest vectors to v in the list of vectors stored in m rows
 // using the supplied distance metric. It returns a slice which contains indices to the m
-// rows. The length of the slice is the same as number of requested closest vectors - n.
-// ClosestNVec fails in the same way as ClosestVec. If n is higher than the number of
-// rows in m, or if it is not a positive integer, it fails with error too.
+// rows sorted by their distance to v in ascending order; vectors of the same distance are
+// sorted by their index. The length of the slice is the same as number of requested closest
+// vectors - n. ClosestNVec fails in the same way as ClosestVec. If n is higher than the number
+// of rows in m, or if it is not a positive integer, it fails with error too.
 func ClosestNVec(metric string, n int, v []float64, m *mat64.Dense) ([]int, error) {
 	// vector can't be nil
 	if v == nil || len(v) == 0 {
@@ -97,35 +178,38 @@ func ClosestNVec(metric string, n int, v []float64, m *mat64.Dense) ([]int, erro
 	if n <= 0 || n > rows {
 		return nil, fmt.Errorf("invalid number of closest vectors requested: %d", n)
 	}
-	// we return slice of indices
-	closest := make([]int, n)
+	closest, _, err := closestN(metric, n, v, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return closest, nil
+}
 
-	switch {
-	case n == 1:
-		idx, err := ClosestVec(metric, v, m)
+// closestN finds the n closest vectors to v in m rows using a bounded max-heap.
+// It returns indices of the closest rows and their distances to v sorted in ascending order.
+// Rows of the same distance are sorted by their index.
+// n must be a positive integer not higher than the number of rows in m.
+func closestN(metric string, n int, v []float64, m *mat64.Dense) ([]int, []float64, error) {
+	// no need to check for error
+	h, _ := newFloat64Heap(n)
+	rows, _ := m.Dims()
+	for i := 0; i < rows; i++ {
+		d, err := Distance(metric, v, m.RawRowView(i))
 		if err != nil {
-			return nil, err
-		}
-		closest[0] = idx
-	default:
-		// no need to check for error
-		h, _ := newFloat64Heap(n)
-		rows, _ := m.Dims()
-		for i := 0; i < rows; i++ {
-			d, err := Distance(metric, v, m.RawRowView(i))
-			if err != nil {
-				return nil, err
-			}
-			f := &float64Item{val: d, index: i}
-			heap.Push(h, f)
-		}
-		// add max vals to closest slide
-		for j := 0; j < n; j++ {
-			closest[j] = (heap.Pop(h).(*float64Item)).index
+			return nil, nil, err
 		}
+		heap.Push(h, &float64Item{val: d, index: i})
+	}
+	// heap pops the farthest vectors first
+	closest := make([]int, n)
+	dists := make([]float64, n)
+	for j := n - 1; j >= 0; j-- {
+		item := heap.Pop(h).(*float64Item)
+		closest[j], dists[j] = item.index, item.val
 	}
 
-	return closest, nil
+	return closest, dists, nil
 }
 
 // BMUs returns a slice which contains indices of Best Match Unit (BMU) codebook vectors for each
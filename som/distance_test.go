@@ -2,6 +2,7 @@ package som
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"testing"
 
@@ -48,6 +49,39 @@ func TestDistance(t *testing.T) {
 	assert.Equal(0.0, d)
 }
 
+func TestDistanceFuncs(t *testing.T) {
+	assert := assert.New(t)
+
+	a := []float64{1.0, 2.0, 3.0}
+	b := []float64{2.0, 4.0, 6.0}
+	c := []float64{3.0, 2.0, 1.0}
+	testCases := []struct {
+		metric string
+		fn     DistanceFunc
+		ab, ac float64
+	}{
+		{"euclidean", Euclidean, math.Sqrt(14), math.Sqrt(8)},
+		{"manhattan", Manhattan, 6.0, 4.0},
+		{"chebyshev", Chebyshev, 3.0, 2.0},
+		{"cosine", Cosine, 0.0, 1 - 10.0/14.0},
+		{"correlation", Correlation, 0.0, 2.0},
+	}
+	for _, tc := range testCases {
+		assert.InDelta(tc.ab, tc.fn(a, b), 1e-12, tc.metric)
+		assert.InDelta(tc.ac, tc.fn(a, c), 1e-12, tc.metric)
+		d, err := Distance(tc.metric, a, c)
+		assert.NoError(err)
+		assert.InDelta(tc.ac, d, 1e-12, tc.metric)
+		mx, err := DistanceMx(tc.metric, mat64.NewDense(3, 3, append(append(append([]float64(nil), a...), b...), c...)))
+		assert.NoError(err)
+		assert.InDelta(tc.ac, mx.At(2, 0), 1e-12, tc.metric)
+		assert.Equal(0.0, mx.At(1, 1))
+	}
+	// zero and constant vectors
+	assert.Equal(1.0, Cosine([]float64{0, 0}, []float64{1, 2}))
+	assert.Equal(1.0, Correlation([]float64{1, 1}, []float64{1, 2}))
+}
+
 func TestDistanceMx(t *testing.T) {
 	assert := assert.New(t)
 
@@ -203,6 +237,18 @@ func TestClosestNVec(t *testing.T) {
 	assert.NoError(err)
 	sort.Ints(closest)
 	assert.EqualValues([]int{1, 3}, closest)
+	// closest vectors are sorted by distance, ties by index
+	n = 4
+	mData = []float64{
+		0.0, 1.0,
+		0.0, 0.1,
+		0.0, 0.2,
+		0.1, 0.0,
+		0.2, 0.0}
+	m = mat64.NewDense(5, len(v), mData)
+	closest, err = ClosestNVec(metric, n, v, m)
+	assert.NoError(err)
+	assert.EqualValues([]int{1, 3, 2, 4}, closest)
 }
 
 func TestBMUs(t *testing.T) {
@@ -0,0 +1,296 @@
+package som
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+)
+
+const (
+	// DefaultEnsembleClusters is the default number of clusters of ensemble member codebooks
+	DefaultEnsembleClusters = 3
+	// ensembleKMeansIters is the maximum number of k-means iterations of member codebook clustering
+	ensembleKMeansIters = 100
+)
+
+// Ensemble is a bagging ensemble of SOMs: every member map is trained on a bootstrap resample
+// of the training data and its codebook is clustered. Member clusterings are aligned with the
+// clustering of the first member, so their cluster ids can be combined by voting.
+type Ensemble struct {
+	// Clusters is the number of clusters of every member codebook.
+	// If it is zero, DefaultEnsembleClusters is used.
+	Clusters int
+	// members are the trained member maps
+	members []*Map
+	// align maps cluster ids of every member to cluster ids of the first member
+	align [][]int
+}
+
+// Train trains b member maps configured by mc and tc on bootstrap resamples of data, which are drawn
+// with replacement and have the same number of rows as data. The members run the same number of
+// iterations as TrainRelational. Every member codebook is clustered by ClusterKMeans and member
+// clusters are aligned with the first member clusters by matching clusters which share most of data
+// samples. Members are trained concurrently by at most workers goroutines; if workers is not a positive
+// integer, the number of CPUs is used. The results are deterministic for a fixed seed.
+// Train replaces members of previous training. It returns error if data is nil, if b is not a positive
+// integer or if training or clustering of any member fails.
+func (e *Ensemble) Train(data *mat64.Dense, b int, mc *MapConfig, tc *TrainConfig, seed int64, workers int) error {
+	// data can't be nil
+	if data == nil {
+		return fmt.Errorf("invalid data supplied: %v", data)
+	}
+	if b <= 0 {
+		return fmt.Errorf("invalid number of ensemble members: %d", b)
+	}
+	if e.Clusters < 0 {
+		return fmt.Errorf("invalid number of clusters: %d", e.Clusters)
+	}
+	k := e.Clusters
+	if k == 0 {
+		k = DefaultEnsembleClusters
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	members := make([]*Map, b)
+	errs := make([]error, b)
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// every member gets its own seed derived from the ensemble seed
+				members[i], errs[i] = trainMember(data, k, mc, tc, seed+int64(i)+1)
+			}
+		}()
+	}
+	for i := 0; i < b; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("member %d: %w", i, err)
+		}
+	}
+	// align member clusterings with the first member clustering
+	clusters := make([][]int, b)
+	for i, m := range members {
+		c, err := m.SampleClusters(data)
+		if err != nil {
+			return err
+		}
+		clusters[i] = c
+	}
+	e.align = make([][]int, b)
+	for i := range members {
+		e.align[i] = alignClusters(clusters[i], clusters[0], k)
+	}
+	e.members = members
+	return nil
+}
+
+// trainMember trains and clusters new map on a bootstrap resample of data drawn using seed
+func trainMember(data *mat64.Dense, k int, mc *MapConfig, tc *TrainConfig, seed int64) (*Map, error) {
+	m, err := trainBootstrap(data, mc, tc, seed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.ClusterKMeans(k, seed, ensembleKMeansIters); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// trainBootstrap trains new map on a bootstrap resample of data drawn using seed.
+// The map runs the same number of iterations as TrainRelational.
+func trainBootstrap(data *mat64.Dense, mc *MapConfig, tc *TrainConfig, seed int64) (*Map, error) {
+	r := rand.New(rand.NewSource(seed))
+	rows, cols := data.Dims()
+	boot := mat64.NewDense(rows, cols, nil)
+	for i := 0; i < rows; i++ {
+		boot.SetRow(i, data.RawRowView(r.Intn(rows)))
+	}
+	m, err := NewMap(mc, boot)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.train(tc, boot, relIters(tc, rows), r); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// alignClusters maps k cluster ids of samples in clusters to cluster ids of the same samples
+// in ref. Cluster pairs which share most samples are matched greedily.
+func alignClusters(clusters, ref []int, k int) []int {
+	shared := make([][]int, k)
+	for i := range shared {
+		shared[i] = make([]int, k)
+	}
+	for i, c := range clusters {
+		shared[c][ref[i]]++
+	}
+	align := make([]int, k)
+	for i := range align {
+		align[i] = -1
+	}
+	used := make([]bool, k)
+	for n := 0; n < k; n++ {
+		best, from, to := -1, -1, -1
+		for i := 0; i < k; i++ {
+			if align[i] >= 0 {
+				continue
+			}
+			for j := 0; j < k; j++ {
+				if !used[j] && shared[i][j] > best {
+					best, from, to = shared[i][j], i, j
+				}
+			}
+		}
+		align[from], used[to] = to, true
+	}
+	return align
+}
+
+// Members returns the trained member maps. It returns nil if the ensemble has not been trained.
+func (e Ensemble) Members() []*Map {
+	return e.members
+}
+
+// checkMembers returns error if the ensemble has not been trained
+func (e Ensemble) checkMembers() error {
+	if len(e.members) == 0 {
+		return fmt.Errorf("ensemble has no members")
+	}
+	return nil
+}
+
+// QuantError returns fused quantization error of data: the mean of quantization errors of all members.
+// It returns error if the ensemble has not been trained or if the quantization error of any member fails.
+func (e Ensemble) QuantError(data mat64.Matrix) (float64, error) {
+	if err := e.checkMembers(); err != nil {
+		return -1.0, err
+	}
+	sum := 0.0
+	for _, m := range e.members {
+		qe, err := m.QuantError(data)
+		if err != nil {
+			return -1.0, err
+		}
+		sum += qe
+	}
+	return sum / float64(len(e.members)), nil
+}
+
+// BMUDistance returns the mean and the variance of distances of vector x to its BMUs in all members.
+// The variance estimates uncertainty of the ensemble representation of x.
+// It returns error if the ensemble has not been trained or ErrDimMismatch if the dimension of x
+// is different from the member codebook dimension.
+func (e Ensemble) BMUDistance(x []float64) (float64, float64, error) {
+	if err := e.checkMembers(); err != nil {
+		return -1.0, -1.0, err
+	}
+	dists := make([]float64, len(e.members))
+	for i, m := range e.members {
+		_, d, err := m.BMU(x)
+		if err != nil {
+			return -1.0, -1.0, err
+		}
+		dists[i] = d
+	}
+	if len(dists) == 1 {
+		return dists[0], 0.0, nil
+	}
+	mean, variance := stat.MeanVariance(dists, nil)
+	return mean, variance, nil
+}
+
+// PredictConsensus returns the consensus cluster of vector x and the fraction of members which voted
+// for it. Every member votes for the cluster of the BMU of x aligned with the first member clusters.
+// Vote ties are broken in favour of the smaller cluster id. PredictConsensus fails in the same way
+// as BMUDistance.
+func (e Ensemble) PredictConsensus(x []float64) (int, float64, error) {
+	if err := e.checkMembers(); err != nil {
+		return -1, 0.0, err
+	}
+	votes := make([]int, len(e.align[0]))
+	for i, m := range e.members {
+		bmu, _, err := m.BMU(x)
+		if err != nil {
+			return -1, 0.0, err
+		}
+		votes[e.align[i][m.clusters[bmu]]]++
+	}
+	best := 0
+	for c, v := range votes {
+		if v > votes[best] {
+			best = c
+		}
+	}
+	return best, float64(votes[best]) / float64(len(e.members)), nil
+}
+
+// CoAssignment returns the matrix of co-assignment frequencies of data rows: the fraction of members
+// which assign both rows to the same cluster. It returns error if the ensemble has not been trained,
+// if data is nil or ErrDimMismatch if data dimension is different from the member codebook dimension.
+func (e Ensemble) CoAssignment(data mat64.Matrix) (*mat64.Dense, error) {
+	if err := e.checkMembers(); err != nil {
+		return nil, err
+	}
+	if isNilMatrix(data) {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, _ := data.Dims()
+	co := mat64.NewDense(rows, rows, nil)
+	inc := 1.0 / float64(len(e.members))
+	for _, m := range e.members {
+		clusters, err := m.SampleClusters(data)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < rows; i++ {
+			row := co.RawRowView(i)
+			for j, c := range clusters {
+				if c == clusters[i] {
+					row[j] += inc
+				}
+			}
+		}
+	}
+	return co, nil
+}
+
+// Stability returns how consistently pairs of data rows co-locate across members: for every pair of rows
+// it computes the fraction of members which agree with the majority on whether the rows share a cluster
+// and returns the mean of the fractions over all pairs. Stability is 1 if all members agree on all pairs
+// and approaches 0.5 for random clusterings. It fails in the same way as CoAssignment or if data has
+// fewer than two rows.
+func (e Ensemble) Stability(data mat64.Matrix) (float64, error) {
+	co, err := e.CoAssignment(data)
+	if err != nil {
+		return -1.0, err
+	}
+	rows, _ := co.Dims()
+	if rows < 2 {
+		return -1.0, fmt.Errorf("invalid number of data samples: %d", rows)
+	}
+	sum := 0.0
+	for i := 0; i < rows; i++ {
+		for j := i + 1; j < rows; j++ {
+			f := co.At(i, j)
+			if f < 0.5 {
+				f = 1 - f
+			}
+			sum += f
+		}
+	}
+	return sum / float64(rows*(rows-1)/2), nil
+}
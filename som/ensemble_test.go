@@ -0,0 +1,170 @@
+package som
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// ensembleConfigs returns map and training configuration of ensemble tests
+func ensembleConfigs() (*MapConfig, *TrainConfig) {
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{4, 4},
+			Type:   Planar,
+			UShape: Hexagon,
+		},
+		Cb: &CbConfig{
+			Dim:      2,
+			InitFunc: RandInit,
+		},
+	}
+	tc := &TrainConfig{
+		Algorithm: Seq,
+		Radius:    2.0,
+		RDecay:    ExpDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    ExpDecay,
+	}
+	return mc, tc
+}
+
+func TestEnsembleTrain(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(150, 3, 0.5, 1)
+	mc, tc := ensembleConfigs()
+	e := &Ensemble{}
+	assert.NoError(e.Train(data, 5, mc, tc, 7, 3))
+	assert.Len(e.Members(), 5)
+	// training is deterministic regardless of the number of workers
+	other := &Ensemble{}
+	assert.NoError(other.Train(data, 5, mc, tc, 7, 1))
+	for i, m := range e.Members() {
+		assert.True(mat64.Equal(m.CodebookRaw(), other.Members()[i].CodebookRaw()), "member %d", i)
+		assert.Len(m.Clusters(), 16)
+	}
+	assert.Equal(e.align, other.align)
+	// members are trained on different resamples
+	assert.False(mat64.Equal(e.Members()[0].CodebookRaw(), e.Members()[1].CodebookRaw()))
+
+	// fused quantization error is the mean member error
+	qe, err := e.QuantError(data)
+	assert.NoError(err)
+	sum := 0.0
+	for _, m := range e.Members() {
+		mqe, err := m.QuantError(data)
+		assert.NoError(err)
+		sum += mqe
+	}
+	assert.InDelta(sum/5, qe, 1e-12)
+
+	// every blob centre gets its own consensus cluster which all members agree on
+	seen := make(map[int]bool)
+	for _, centre := range [][]float64{{10.0, 0.0}, {-5.0, 8.660254}, {-5.0, -8.660254}} {
+		c, agreement, err := e.PredictConsensus(centre)
+		assert.NoError(err)
+		assert.Equal(1.0, agreement)
+		seen[c] = true
+		mean, variance, err := e.BMUDistance(centre)
+		assert.NoError(err)
+		assert.True(mean >= 0 && variance >= 0)
+	}
+	assert.Len(seen, 3)
+	// far away samples have larger and more variable distances
+	mean, variance, err := e.BMUDistance([]float64{30.0, 30.0})
+	assert.NoError(err)
+	near, nearVar, err := e.BMUDistance([]float64{10.0, 0.0})
+	assert.NoError(err)
+	assert.True(mean > near)
+	assert.True(variance > nearVar)
+}
+
+func TestEnsembleStability(t *testing.T) {
+	assert := assert.New(t)
+
+	mc, tc := ensembleConfigs()
+	blobs := makeBlobs(150, 3, 0.5, 1)
+	e := &Ensemble{}
+	assert.NoError(e.Train(blobs, 5, mc, tc, 7, 0))
+	co, err := e.CoAssignment(blobs)
+	assert.NoError(err)
+	rows, cols := co.Dims()
+	assert.Equal(150, rows)
+	assert.Equal(150, cols)
+	for i := 0; i < rows; i++ {
+		assert.InDelta(1.0, co.At(i, i), 1e-12)
+	}
+	// samples of the same blob always share a cluster, samples of different blobs never do
+	assert.InDelta(1.0, co.At(0, 3), 1e-12)
+	assert.InDelta(0.0, co.At(0, 1), 1e-12)
+	blobStability, err := e.Stability(blobs)
+	assert.NoError(err)
+	assert.InDelta(1.0, blobStability, 1e-12)
+
+	// uniform data has no cluster structure
+	r := rand.New(rand.NewSource(1))
+	uniform := mat64.NewDense(150, 2, nil)
+	for i := 0; i < 150; i++ {
+		uniform.SetRow(i, []float64{r.Float64(), r.Float64()})
+	}
+	e = &Ensemble{Clusters: 6}
+	assert.NoError(e.Train(uniform, 5, mc, tc, 7, 0))
+	uniformStability, err := e.Stability(uniform)
+	assert.NoError(err)
+	assert.True(uniformStability < blobStability, "uniform: %f, blobs: %f", uniformStability, blobStability)
+}
+
+func TestEnsembleErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(30, 3, 0.5, 1)
+	mc, tc := ensembleConfigs()
+	e := &Ensemble{}
+	// untrained ensemble
+	_, err := e.QuantError(data)
+	assert.EqualError(err, "ensemble has no members")
+	_, _, err = e.PredictConsensus([]float64{0.0, 0.0})
+	assert.EqualError(err, "ensemble has no members")
+	_, _, err = e.BMUDistance([]float64{0.0, 0.0})
+	assert.EqualError(err, "ensemble has no members")
+	_, err = e.Stability(data)
+	assert.EqualError(err, "ensemble has no members")
+	// invalid parameters
+	assert.EqualError(e.Train(nil, 3, mc, tc, 1, 0), fmt.Sprintf("invalid data supplied: %v", nil))
+	assert.EqualError(e.Train(data, 0, mc, tc, 1, 0), "invalid number of ensemble members: 0")
+	e.Clusters = 20
+	assert.EqualError(e.Train(data, 2, mc, tc, 1, 0), "member 0: invalid number of clusters: 20")
+	e.Clusters = -1
+	assert.EqualError(e.Train(data, 2, mc, tc, 1, 0), "invalid number of clusters: -1")
+	e.Clusters = 0
+	bad := *tc
+	bad.LRate = -1.0
+	err = e.Train(data, 2, mc, &bad, 1, 0)
+	assert.True(errors.Is(err, ErrInvalidLRate))
+	assert.Nil(e.Members())
+	// dimension mismatch
+	assert.NoError(e.Train(data, 2, mc, tc, 1, 0))
+	_, _, err = e.PredictConsensus([]float64{0.0})
+	assert.Equal(ErrDimMismatch, err)
+	_, err = e.CoAssignment(mat64.NewDense(2, 3, nil))
+	assert.Equal(ErrDimMismatch, err)
+	_, err = e.CoAssignment(nil)
+	assert.EqualError(err, fmt.Sprintf("invalid data supplied: %v", nil))
+	_, err = e.Stability(mat64.NewDense(1, 2, nil))
+	assert.EqualError(err, "invalid number of data samples: 1")
+}
+
+func TestAlignClusters(t *testing.T) {
+	assert := assert.New(t)
+
+	ref := []int{0, 0, 1, 1, 2, 2}
+	assert.Equal([]int{2, 0, 1}, alignClusters([]int{1, 1, 2, 2, 0, 0}, ref, 3))
+	// every cluster is matched even if it shares no samples
+	assert.Equal([]int{0, 1, 2}, alignClusters([]int{0, 0, 0, 0, 0, 0}, ref, 3))
+}
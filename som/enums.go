@@ -0,0 +1,192 @@
+package som
+
+import "fmt"
+
+// GridType is SOM grid type: planar, toroidal, cylindrical, spherical or a type registered by RegisterGridType
+type GridType string
+
+const (
+	// Planar is a flat grid
+	Planar GridType = "planar"
+	// Toroidal is a grid whose unit distances wrap around the grid edges, so units
+	// on opposite edges are neighbours and the map has no border
+	Toroidal GridType = "toroidal"
+	// Cylindrical is a grid whose unit distances wrap around its left and right edges only, so units
+	// of the first and last columns are neighbours. It suits periodic data such as hour of day
+	// or compass headings which a planar grid cuts apart and toroidal grid wraps around twice.
+	Cylindrical GridType = "cylindrical"
+	// Spherical is a geodesic grid on a sphere whose unit distances are measured along
+	// the sphere surface; see SphereCoords
+	Spherical GridType = "spherical"
+)
+
+// ParseGridType returns grid type s.
+// It returns ErrUnsupportedGrid if s is neither a built-in nor a registered grid type.
+func ParseGridType(s string) (GridType, error) {
+	if gridTypeFunc(s) == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedGrid, s)
+	}
+	return GridType(s), nil
+}
+
+// MarshalText returns grid type name
+func (t GridType) MarshalText() ([]byte, error) {
+	return []byte(t), nil
+}
+
+// UnmarshalText parses grid type name using ParseGridType
+func (t *GridType) UnmarshalText(text []byte) error {
+	v, err := ParseGridType(string(text))
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+// UnitShape is SOM unit shape
+type UnitShape string
+
+const (
+	// Hexagon units have six neighbours
+	Hexagon UnitShape = "hexagon"
+	// Rectangle units have four neighbours
+	Rectangle UnitShape = "rectangle"
+)
+
+// ParseUnitShape returns unit shape s.
+// It returns ErrUnsupportedUShape if s is not a supported unit shape.
+func ParseUnitShape(s string) (UnitShape, error) {
+	if !uShapes.Has(s) {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedUShape, s)
+	}
+	return UnitShape(s), nil
+}
+
+// MarshalText returns unit shape name
+func (u UnitShape) MarshalText() ([]byte, error) {
+	return []byte(u), nil
+}
+
+// UnmarshalText parses unit shape name using ParseUnitShape
+func (u *UnitShape) UnmarshalText(text []byte) error {
+	v, err := ParseUnitShape(string(text))
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
+
+// Connectivity is connectivity of rectangle grid units which defines their grid distances
+type Connectivity string
+
+const (
+	// Axial connectivity connects rectangle units to their 4 neighbours along the grid axes at distance 1;
+	// grid distances are euclidean, so diagonal neighbours are sqrt(2) apart
+	Axial Connectivity = "axial"
+	// Moore connectivity connects rectangle units to their 8 neighbours, including diagonal ones,
+	// at distance 1; grid distances are Chebyshev distances, the largest coordinate difference
+	Moore Connectivity = "moore"
+)
+
+// ParseConnectivity returns connectivity s.
+// It returns error if s is not a supported connectivity.
+func ParseConnectivity(s string) (Connectivity, error) {
+	if s != string(Axial) && s != string(Moore) {
+		return "", fmt.Errorf("unsupported connectivity: %s, supported: %s, %s", s, Axial, Moore)
+	}
+	return Connectivity(s), nil
+}
+
+// MarshalText returns connectivity name
+func (c Connectivity) MarshalText() ([]byte, error) {
+	return []byte(c), nil
+}
+
+// UnmarshalText parses connectivity name using ParseConnectivity.
+// Empty text is the default axial connectivity, so it is kept empty.
+func (c *Connectivity) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = ""
+		return nil
+	}
+	v, err := ParseConnectivity(string(text))
+	if err != nil {
+		return err
+	}
+	*c = v
+	return nil
+}
+
+// Method is SOM training algorithm
+type Method string
+
+const (
+	// Seq is sequential training which updates the codebook after every sample
+	Seq Method = "seq"
+	// Batch is batch training which updates the codebook after every pass through data
+	Batch Method = "batch"
+)
+
+// ParseMethod returns training algorithm s.
+// It returns ErrUnsupportedMethod if s is not a supported training algorithm.
+func ParseMethod(s string) (Method, error) {
+	if !trainingAlgs.Has(s) {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedMethod, s)
+	}
+	return Method(s), nil
+}
+
+// MarshalText returns training algorithm name
+func (m Method) MarshalText() ([]byte, error) {
+	return []byte(m), nil
+}
+
+// UnmarshalText parses training algorithm name using ParseMethod
+func (m *Method) UnmarshalText(text []byte) error {
+	v, err := ParseMethod(string(text))
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Decay is radius and learning rate decay strategy
+type Decay string
+
+const (
+	// LinDecay decays linearly
+	LinDecay Decay = "lin"
+	// ExpDecay decays exponentially
+	ExpDecay Decay = "exp"
+	// InvDecay decays inversely proportionally to the iteration
+	InvDecay Decay = "inv"
+	// PowDecay decays by power series of the ratio of the final and the initial value
+	PowDecay Decay = "pow"
+)
+
+// ParseDecay returns decay strategy s.
+// It returns ErrUnsupportedDecay if s is not a supported decay strategy.
+func ParseDecay(s string) (Decay, error) {
+	if decayFunc(s) == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedDecay, s)
+	}
+	return Decay(s), nil
+}
+
+// MarshalText returns decay strategy name
+func (d Decay) MarshalText() ([]byte, error) {
+	return []byte(d), nil
+}
+
+// UnmarshalText parses decay strategy name using ParseDecay
+func (d *Decay) UnmarshalText(text []byte) error {
+	v, err := ParseDecay(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
@@ -0,0 +1,125 @@
+package som
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// textEnum is implemented by pointers to all enum types
+type textEnum interface {
+	MarshalText() ([]byte, error)
+	UnmarshalText([]byte) error
+}
+
+func TestParseEnums(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, name := range SupportedGridTypes() {
+		v, err := ParseGridType(name)
+		assert.NoError(err)
+		assert.Equal(GridType(name), v)
+	}
+	for _, name := range SupportedUShapes() {
+		v, err := ParseUnitShape(name)
+		assert.NoError(err)
+		assert.Equal(UnitShape(name), v)
+	}
+	for _, name := range SupportedAlgorithms() {
+		v, err := ParseMethod(name)
+		assert.NoError(err)
+		assert.Equal(Method(name), v)
+	}
+	for _, name := range SupportedDecays() {
+		v, err := ParseDecay(name)
+		assert.NoError(err)
+		assert.Equal(Decay(name), v)
+	}
+	// constants are supported
+	assert.Contains(SupportedGridTypes(), string(Planar))
+	for _, u := range []UnitShape{Hexagon, Rectangle} {
+		assert.Contains(SupportedUShapes(), string(u))
+	}
+	for _, m := range []Method{Seq, Batch} {
+		assert.Contains(SupportedAlgorithms(), string(m))
+	}
+	for _, d := range []Decay{LinDecay, ExpDecay, InvDecay} {
+		assert.Contains(SupportedDecays(), string(d))
+	}
+
+	// unknown values
+	_, err := ParseGridType("foobar")
+	assert.True(errors.Is(err, ErrUnsupportedGrid))
+	_, err = ParseUnitShape("foobar")
+	assert.True(errors.Is(err, ErrUnsupportedUShape))
+	_, err = ParseMethod("foobar")
+	assert.True(errors.Is(err, ErrUnsupportedMethod))
+	_, err = ParseDecay("foobar")
+	assert.True(errors.Is(err, ErrUnsupportedDecay))
+	_, err = ParseDecay("")
+	assert.True(errors.Is(err, ErrUnsupportedDecay))
+}
+
+func TestEnumsText(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		value    textEnum
+		empty    textEnum
+		text     string
+		sentinel error
+	}{
+		{&[]GridType{Planar}[0], new(GridType), "planar", ErrUnsupportedGrid},
+		{&[]UnitShape{Hexagon}[0], new(UnitShape), "hexagon", ErrUnsupportedUShape},
+		{&[]UnitShape{Rectangle}[0], new(UnitShape), "rectangle", ErrUnsupportedUShape},
+		{&[]Method{Seq}[0], new(Method), "seq", ErrUnsupportedMethod},
+		{&[]Method{Batch}[0], new(Method), "batch", ErrUnsupportedMethod},
+		{&[]Decay{LinDecay}[0], new(Decay), "lin", ErrUnsupportedDecay},
+		{&[]Decay{ExpDecay}[0], new(Decay), "exp", ErrUnsupportedDecay},
+		{&[]Decay{InvDecay}[0], new(Decay), "inv", ErrUnsupportedDecay},
+	}
+
+	for _, tc := range testCases {
+		text, err := tc.value.MarshalText()
+		assert.NoError(err)
+		assert.Equal(tc.text, string(text))
+		// round trip
+		assert.NoError(tc.empty.UnmarshalText(text))
+		assert.Equal(tc.value, tc.empty)
+		// unknown value fails and keeps the original value
+		err = tc.empty.UnmarshalText([]byte("foobar"))
+		assert.True(errors.Is(err, tc.sentinel), tc.text)
+		assert.Equal(tc.value, tc.empty)
+	}
+}
+
+func TestEnumsJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	var out TrainConfig
+	err := json.Unmarshal([]byte(`{"Algorithm": "seq", "RDecay": "lin", "LDecay": "inv"}`), &out)
+	assert.NoError(err)
+	assert.Equal(Seq, out.Algorithm)
+	assert.Equal(LinDecay, out.RDecay)
+	assert.Equal(InvDecay, out.LDecay)
+
+	gc := &GridConfig{Size: []int{2, 3}, Type: Planar, UShape: Rectangle}
+	data, err := json.Marshal(gc)
+	assert.NoError(err)
+	assert.Equal(`{"Size":[2,3],"Type":"planar","UShape":"rectangle"}`, string(data))
+	var outGrid GridConfig
+	assert.NoError(json.Unmarshal(data, &outGrid))
+	assert.Equal(*gc, outGrid)
+
+	// unknown values fail early
+	err = json.Unmarshal([]byte(`{"Algorithm": "sequential"}`), &out)
+	assert.True(errors.Is(err, ErrUnsupportedMethod))
+	err = json.Unmarshal([]byte(`{"RDecay": "linear"}`), &out)
+	assert.True(errors.Is(err, ErrUnsupportedDecay))
+	err = json.Unmarshal([]byte(`{"UShape": "triangle"}`), &outGrid)
+	assert.True(errors.Is(err, ErrUnsupportedUShape))
+	err = json.Unmarshal([]byte(`{"Type": "toroid"}`), &outGrid)
+	assert.True(errors.Is(err, ErrUnsupportedGrid))
+}
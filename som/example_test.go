@@ -0,0 +1,43 @@
+package som_test
+
+import (
+	"fmt"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/som"
+)
+
+func ExampleNewMapWithOptions() {
+	data := mat64.NewDense(6, 2, []float64{
+		0.0, 0.0,
+		0.1, 0.9,
+		0.5, 0.4,
+		0.9, 0.1,
+		1.0, 1.0,
+		0.4, 0.6,
+	})
+	m, err := som.NewMapWithOptions(data,
+		som.WithDims(4, 3),
+		som.WithUShape("rectangle"),
+		som.WithInit("rand"),
+		som.WithSeed(42),
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	units, dim := m.Codebook().Dims()
+	fmt.Println(m.Grid().Size(), m.Grid().UShape(), units, dim)
+	// Output: [4 3] rectangle 12 2
+}
+
+func ExampleNewMapWithOptions_invalidOptions() {
+	data := mat64.NewDense(2, 2, []float64{0.0, 0.0, 1.0, 1.0})
+	_, err := som.NewMapWithOptions(data,
+		som.WithDims(0, 3),
+		som.WithUShape("triangle"),
+		som.WithMetric("euclidean"),
+	)
+	fmt.Println(err)
+	// Output: invalid map options: invalid grid dimensions: [0 3]; unsupported unit shape: triangle
+}
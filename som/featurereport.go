@@ -0,0 +1,149 @@
+package som
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+)
+
+// RedundancyThreshold is the absolute correlation of two component planes above which
+// the features are flagged as redundant by FeatureReport
+const RedundancyThreshold = 0.9
+
+// FeatureStat holds component plane statistics of a single feature
+type FeatureStat struct {
+	// Feature is the feature index
+	Feature int
+	// Rank is the feature rank by Variance: the feature of the highest Variance has rank 1
+	Rank int
+	// Variance is the variance of the feature component plane normalised by the feature data variance.
+	// Features which structure the map keep most of their variance; noise is averaged out.
+	Variance float64
+	// UMatrixCorr is the correlation of the feature u-matrix, which only measures the feature
+	// distances of neighbouring units, with the map u-matrix. It is high if the feature
+	// forms the cluster borders of the map.
+	UMatrixCorr float64
+	// MaxCorr is the correlation of the component plane with the most correlated plane of another feature
+	MaxCorr float64
+	// Correlated is the index of the feature of the most correlated component plane or -1 if there is none
+	Correlated int
+	// Redundant is true if the absolute value of MaxCorr exceeds RedundancyThreshold
+	Redundant bool
+}
+
+// FeatureReport returns component plane statistics of every map feature ordered by feature index.
+// Correlations of constant planes are zero. It returns error if data is nil or has fewer than two rows,
+// ErrDimMismatch if data dimension is different from the codebook dimension or ErrNoTopology
+// if the map has no topology.
+func (m Map) FeatureReport(data *mat64.Dense) ([]FeatureStat, error) {
+	// data can't be nil
+	if data == nil {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, cols := data.Dims()
+	units, dim := m.cbDims()
+	if cols != dim {
+		return nil, ErrDimMismatch
+	}
+	if rows < 2 {
+		return nil, fmt.Errorf("invalid number of data samples: %d", rows)
+	}
+	umatrix, err := m.UMatrixValues()
+	if err != nil {
+		return nil, err
+	}
+	cb := m.cb()
+	planes := make([][]float64, dim)
+	stats := make([]FeatureStat, dim)
+	for f := range planes {
+		planes[f] = mat64.Col(nil, f, cb)
+		stats[f] = FeatureStat{Feature: f, Correlated: -1}
+		if v := stat.Variance(mat64.Col(nil, f, data), nil); v > 0 {
+			stats[f].Variance = stat.Variance(planes[f], nil) / v
+		}
+		// feature distances of all unit pairs
+		dist := mat64.NewDense(units, units, nil)
+		for i := 0; i < units; i++ {
+			for k := 0; k < units; k++ {
+				dist.Set(i, k, math.Abs(planes[f][i]-planes[f][k]))
+			}
+		}
+		fUmatrix, err := umatrixDist(dist, m.grid)
+		if err != nil {
+			return nil, err
+		}
+		stats[f].UMatrixCorr = correlation(fUmatrix, umatrix)
+	}
+	for f := range stats {
+		for g := range stats {
+			if g == f {
+				continue
+			}
+			if c := correlation(planes[f], planes[g]); stats[f].Correlated < 0 || math.Abs(c) > math.Abs(stats[f].MaxCorr) {
+				stats[f].MaxCorr, stats[f].Correlated = c, g
+			}
+		}
+		stats[f].Redundant = math.Abs(stats[f].MaxCorr) > RedundancyThreshold
+	}
+	order := make([]int, dim)
+	for f := range order {
+		order[f] = f
+	}
+	sort.SliceStable(order, func(a, b int) bool { return stats[order[a]].Variance > stats[order[b]].Variance })
+	for r, f := range order {
+		stats[f].Rank = r + 1
+	}
+	return stats, nil
+}
+
+// correlation returns Pearson correlation of x and y or zero if either of them is constant
+func correlation(x, y []float64) float64 {
+	if stat.Variance(x, nil) == 0 || stat.Variance(y, nil) == 0 {
+		return 0.0
+	}
+	return stat.Correlation(x, y, nil)
+}
+
+// WriteFeatureReportCSV writes feature statistics to w in CSV format with a header row.
+// Features are named by names indexed by feature index; features without a name are named
+// by their index. It returns error if the write to w fails.
+func WriteFeatureReportCSV(w io.Writer, stats []FeatureStat, names []string) error {
+	csvWriter := csv.NewWriter(w)
+	name := func(f int) string {
+		if f >= 0 && f < len(names) && names[f] != "" {
+			return names[f]
+		}
+		return strconv.Itoa(f)
+	}
+	header := []string{"feature", "rank", "variance", "umatrix_corr", "max_corr", "correlated", "redundant"}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+	for _, s := range stats {
+		correlated := ""
+		if s.Correlated >= 0 {
+			correlated = name(s.Correlated)
+		}
+		record := []string{
+			name(s.Feature),
+			strconv.Itoa(s.Rank),
+			strconv.FormatFloat(s.Variance, 'f', -1, 64),
+			strconv.FormatFloat(s.UMatrixCorr, 'f', -1, 64),
+			strconv.FormatFloat(s.MaxCorr, 'f', -1, 64),
+			correlated,
+			strconv.FormatBool(s.Redundant),
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+
+	return csvWriter.Error()
+}
@@ -0,0 +1,111 @@
+package som
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeFeatureData generates n samples of four features: the first two features form four blobs,
+// the third one is uniformly distributed noise and the fourth one is the negated first feature
+// with a little noise
+func makeFeatureData(n int, seed int64) *mat64.Dense {
+	r := rand.New(rand.NewSource(seed))
+	data := mat64.NewDense(n, 4, nil)
+	for i := 0; i < n; i++ {
+		x := float64(4*(i%2)-2) + r.NormFloat64()*0.3
+		data.Set(i, 0, x)
+		data.Set(i, 1, float64(4*(i/2%2)-2)+r.NormFloat64()*0.3)
+		data.Set(i, 2, 4*r.Float64()-2)
+		data.Set(i, 3, -x+r.NormFloat64()*0.05)
+	}
+	return data
+}
+
+// featureMap returns map of dim features trained on data
+func featureMap(t *testing.T, data *mat64.Dense) *Map {
+	_, dim := data.Dims()
+	mc, tc := mergeConfigs([]int{6, 6})
+	mc.Cb.Dim = dim
+	m, err := NewMap(mc, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.train(tc, data, 4000, rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestFeatureReport(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeFeatureData(400, 1)
+	m := featureMap(t, data)
+	stats, err := m.FeatureReport(data)
+	assert.NoError(err)
+	assert.Len(stats, 4)
+	// noise ranks last
+	assert.Equal(4, stats[2].Rank)
+	for f, s := range stats {
+		assert.Equal(f, s.Feature)
+		if f != 2 {
+			assert.True(s.Variance > stats[2].Variance, "feature %d variance: %f, noise variance: %f", f, s.Variance, stats[2].Variance)
+		}
+	}
+	// the negated feature is redundant
+	assert.Equal(3, stats[0].Correlated)
+	assert.Equal(0, stats[3].Correlated)
+	assert.True(stats[0].Redundant)
+	assert.True(stats[0].MaxCorr < -RedundancyThreshold)
+	assert.False(stats[2].Redundant)
+	assert.True(stats[0].UMatrixCorr > stats[2].UMatrixCorr)
+
+	var b bytes.Buffer
+	assert.NoError(WriteFeatureReportCSV(&b, stats, []string{"x", "y"}))
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	assert.Len(lines, 5)
+	assert.Equal("feature,rank,variance,umatrix_corr,max_corr,correlated,redundant", lines[0])
+	assert.True(strings.HasPrefix(lines[1], "x,"))
+	assert.True(strings.HasSuffix(lines[1], ",3,true"))
+	assert.True(strings.HasPrefix(lines[3], "2,4,"))
+	assert.True(strings.HasSuffix(lines[4], ",x,true"))
+}
+
+func TestFeatureReportConstant(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(200, 2, 0.5, 1)
+	rows, _ := data.Dims()
+	constant := mat64.NewDense(rows, 3, nil)
+	for i := 0; i < rows; i++ {
+		constant.Set(i, 0, data.At(i, 0))
+		constant.Set(i, 1, data.At(i, 1))
+		constant.Set(i, 2, 1.0)
+	}
+	m := featureMap(t, constant)
+	stats, err := m.FeatureReport(constant)
+	assert.NoError(err)
+	assert.Equal(0.0, stats[2].Variance)
+	assert.Equal(0.0, stats[2].UMatrixCorr)
+	assert.Equal(0.0, stats[2].MaxCorr)
+	assert.Equal(3, stats[2].Rank)
+	for _, s := range stats {
+		assert.False(math.IsNaN(s.UMatrixCorr) || math.IsNaN(s.MaxCorr) || math.IsNaN(s.Variance))
+	}
+
+	_, err = m.FeatureReport(nil)
+	assert.Error(err)
+	_, err = m.FeatureReport(mat64.NewDense(2, 2, nil))
+	assert.Equal(ErrDimMismatch, err)
+	_, err = m.FeatureReport(mat64.NewDense(1, 3, nil))
+	assert.EqualError(err, "invalid number of data samples: 1")
+	m.noTopology = true
+	_, err = m.FeatureReport(constant)
+	assert.Equal(ErrNoTopology, err)
+}
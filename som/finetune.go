@@ -0,0 +1,117 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	// DefaultFineTuneRadius is the default initial radius of fine-tuning
+	DefaultFineTuneRadius = 1.0
+	// DefaultFineTuneLRate is the default initial learning rate of seq fine-tuning
+	DefaultFineTuneLRate = 0.05
+	// DefaultFineTuneEpochs is the default number of fine-tuning epochs
+	DefaultFineTuneEpochs = 5
+)
+
+// FineTuneConfig holds configuration of fine-tuning of trained maps by FineTune.
+// Fields which are not set use the documented defaults.
+type FineTuneConfig struct {
+	// Algorithm is fine-tuning method: seq or batch. The default is batch.
+	Algorithm Method
+	// Radius is initial units radius; it decays linearly. The default is DefaultFineTuneRadius.
+	Radius float64
+	// LRate is initial learning rate of seq fine-tuning; it decays linearly.
+	// The default is DefaultFineTuneLRate.
+	LRate float64
+	// NeighbFn is neighbourhood function. The default is Gaussian.
+	NeighbFn NeighbFunc
+	// Epochs is the number of passes through data. The default is DefaultFineTuneEpochs.
+	Epochs int
+	// Workers specifies the number of worker goroutines in the same way as TrainConfig.Workers
+	Workers int
+	// Seed is the seed of sample selection of seq fine-tuning
+	Seed int64
+}
+
+// withDefaults returns a copy of the configuration with defaults of unset fields
+func (c FineTuneConfig) withDefaults() FineTuneConfig {
+	if c.Algorithm == "" {
+		c.Algorithm = Batch
+	}
+	if c.Radius == 0 {
+		c.Radius = DefaultFineTuneRadius
+	}
+	if c.LRate == 0 {
+		c.LRate = DefaultFineTuneLRate
+	}
+	if c.NeighbFn == nil {
+		c.NeighbFn = Gaussian
+	}
+	if c.Epochs == 0 {
+		c.Epochs = DefaultFineTuneEpochs
+	}
+	return c
+}
+
+// fineTune validates fine-tuning configuration with applied defaults
+func (v *validator) fineTune(c FineTuneConfig) {
+	if c.Algorithm != Seq && c.Algorithm != Batch {
+		if v.param("FineTuneConfig.Algorithm", c.Algorithm, fmt.Errorf("%w: %s, fine-tuning supports seq and batch", ErrUnsupportedMethod, c.Algorithm)) {
+			return
+		}
+	}
+	if !(c.Radius > 0) || math.IsInf(c.Radius, 1) {
+		if v.param("FineTuneConfig.Radius", c.Radius, fmt.Errorf("%w: %f, must be positive and finite", ErrInvalidRadius, c.Radius)) {
+			return
+		}
+	}
+	if !(c.LRate > 0) || math.IsInf(c.LRate, 1) {
+		if v.param("FineTuneConfig.LRate", c.LRate, fmt.Errorf("%w: %f, must be positive and finite", ErrInvalidLRate, c.LRate)) {
+			return
+		}
+	}
+	if c.Epochs < 0 {
+		v.param("FineTuneConfig.Epochs", c.Epochs, fmt.Errorf("invalid number of epochs: %d", c.Epochs))
+	}
+}
+
+// FineTune polishes a trained map, such as a map loaded from a checkpoint or imported from another
+// tool, by a few training epochs on data with small radius which start from the existing codebook,
+// so codebook vectors move closer to the data while the map keeps its ordering. Unlike Adapt, which
+// shifts the map towards new data, fine-tuning is the final phase of training on the training data.
+// Seq fine-tuning runs as many iterations per epoch as there are data rows. FineTune returns the
+// training result of the fine-tuning. It returns error if data is nil, error which joins all problems
+// of cfg or error returned by the training, such as ErrDimMismatch if data dimension is different
+// from the codebook dimension.
+func (m *Map) FineTune(data mat64.Matrix, cfg FineTuneConfig) (*TrainResult, error) {
+	// data can't be nil
+	if isNilMatrix(data) {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	c := cfg.withDefaults()
+	v := &validator{}
+	v.fineTune(c)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	tc := &TrainConfig{
+		Algorithm: c.Algorithm,
+		Radius:    c.Radius,
+		RDecay:    LinDecay,
+		NeighbFn:  c.NeighbFn,
+		LRate:     c.LRate,
+		LDecay:    LinDecay,
+		Workers:   c.Workers,
+		Rand:      rand.New(rand.NewSource(c.Seed)),
+	}
+	iters := c.Epochs
+	if c.Algorithm == Seq {
+		rows, _ := data.Dims()
+		iters *= rows
+	}
+	return m.TrainWithResult(tc, data, iters)
+}
@@ -0,0 +1,63 @@
+package som
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFineTune(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeDrift(300, 0.0, 1)
+	rows, _ := data.Dims()
+	mc, tc := mergeConfigs([]int{5, 5})
+	for _, alg := range []Method{Batch, Seq} {
+		m, err := NewMap(mc, data)
+		assert.NoError(err)
+		// rough training with large radius
+		rough := *tc
+		rough.RSchedule = ConstantSchedule{Value: 3.0}
+		_, err = m.train(&rough, data, 1000, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		before, err := m.QuantError(data)
+		assert.NoError(err)
+		ordered := mat64.DenseCopyOf(m.Codebook())
+		res, err := m.FineTune(data, FineTuneConfig{Algorithm: alg, Epochs: 3, Seed: 1})
+		assert.NoError(err)
+		assert.Equal(alg, res.Algorithm)
+		assert.Equal(DefaultFineTuneRadius, res.Radius)
+		if alg == Batch {
+			assert.Equal(3, res.Iters)
+		} else {
+			assert.Equal(3*rows, res.Iters)
+		}
+		after, err := m.QuantError(data)
+		assert.NoError(err)
+		assert.True(after < before, "%s: error after fine-tuning: %f, before: %f", alg, after, before)
+		// fine-tuning starts from the existing codebook
+		assert.False(mat64.Equal(ordered, m.Codebook()))
+		assert.True(mat64.EqualApprox(ordered, m.Codebook(), 2.0))
+	}
+}
+
+func TestFineTuneErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeDrift(50, 0.0, 1)
+	mc, _ := mergeConfigs([]int{3, 3})
+	m, err := NewMap(mc, data)
+	assert.NoError(err)
+	_, err = m.FineTune(nil, FineTuneConfig{})
+	assert.Error(err)
+	_, err = m.FineTune(data, FineTuneConfig{Algorithm: NeuralGas, Radius: -1.0, LRate: -0.1, Epochs: -1})
+	assert.True(errors.Is(err, ErrUnsupportedMethod))
+	assert.True(errors.Is(err, ErrInvalidRadius))
+	assert.True(errors.Is(err, ErrInvalidLRate))
+	assert.Contains(err.Error(), "invalid number of epochs: -1")
+	_, err = m.FineTune(mat64.NewDense(2, 3, nil), FineTuneConfig{})
+	assert.True(errors.Is(err, ErrDimMismatch))
+}
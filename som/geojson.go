@@ -0,0 +1,131 @@
+package som
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+)
+
+// geoJSONCollection is GeoJSON FeatureCollection written by WriteGeoJSON
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// geoJSONFeature is GeoJSON Feature of a unit or a cluster boundary
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// geoJSONGeometry is GeoJSON Polygon or MultiLineString geometry
+type geoJSONGeometry struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+// WriteGeoJSON writes the map grid as GeoJSON FeatureCollection to w, so that the map can be displayed
+// by mapping front ends, such as Leaflet with its simple, non-geographic coordinate system. Coordinates
+// are map space coordinates of the unit polygons returned by Grid.Polygons. The collection holds:
+//
+//	one Polygon feature per unit, ordered in the same way as map units, with properties kind "unit",
+//	unit id, umatrix value, which is null for units without neighbours, and, if the units are labeled
+//	or clustered, the unit label and cluster id
+//	if the units are clustered, one MultiLineString feature per pair of adjacent clusters with properties
+//	kind "boundary" and clusters, the ids of the two clusters; its lines are the polygon edges shared
+//	by units of the two clusters
+//
+// Boundaries follow edges of adjacent polygons, so units which are neighbours only across the edges
+// of toroidal or cylindrical grids have no boundary. WriteGeoJSON returns error if the grid is not two
+// dimensional, ErrNoTopology if the map has no topology or error if the write to w fails.
+func (m Map) WriteGeoJSON(w io.Writer) error {
+	polygons, err := m.grid.Polygons()
+	if err != nil {
+		return err
+	}
+	umatrix, err := m.UMatrixValues()
+	if err != nil {
+		return err
+	}
+	collection := geoJSONCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	for i, p := range polygons {
+		props := map[string]interface{}{"kind": "unit", "unit": i, "umatrix": nil}
+		if !math.IsNaN(umatrix[i]) {
+			props["umatrix"] = umatrix[i]
+		}
+		if m.labels != nil {
+			props["label"] = m.labels[i]
+		}
+		if m.clusters != nil {
+			props["cluster"] = m.clusters[i]
+		}
+		// GeoJSON rings are closed by repeating the first vertex
+		ring := append(append([][2]float64(nil), p...), p[0])
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geoJSONGeometry{Type: "Polygon", Coordinates: [][][2]float64{ring}},
+			Properties: props,
+		})
+	}
+	if m.clusters != nil {
+		collection.Features = append(collection.Features, m.clusterBoundaries(polygons)...)
+	}
+	return json.NewEncoder(w).Encode(&collection)
+}
+
+// clusterBoundaries returns MultiLineString features of the edges shared by polygons of units of different
+// clusters, one feature per pair of adjacent clusters ordered by the cluster ids
+func (m Map) clusterBoundaries(polygons [][][2]float64) []geoJSONFeature {
+	// vertices of adjacent polygons are equal up to rounding errors of unit coordinates
+	const eps = 1e-6
+	same := func(a, b [2]float64) bool {
+		return math.Abs(a[0]-b[0]) < eps && math.Abs(a[1]-b[1]) < eps
+	}
+	edges := make(map[[2]int][][][2]float64)
+	var pairs [][2]int
+	for i := range polygons {
+		for k := i + 1; k < len(polygons); k++ {
+			ci, ck := m.clusters[i], m.clusters[k]
+			if ci == ck {
+				continue
+			}
+			// adjacent polygons share an edge: two vertices
+			var shared [][2]float64
+			for _, a := range polygons[i] {
+				for _, b := range polygons[k] {
+					if same(a, b) {
+						shared = append(shared, a)
+					}
+				}
+			}
+			if len(shared) != 2 {
+				continue
+			}
+			pair := [2]int{ci, ck}
+			if ci > ck {
+				pair = [2]int{ck, ci}
+			}
+			if _, ok := edges[pair]; !ok {
+				pairs = append(pairs, pair)
+			}
+			edges[pair] = append(edges[pair], shared)
+		}
+	}
+	sort.Slice(pairs, func(a, b int) bool {
+		if pairs[a][0] != pairs[b][0] {
+			return pairs[a][0] < pairs[b][0]
+		}
+		return pairs[a][1] < pairs[b][1]
+	})
+	features := make([]geoJSONFeature, len(pairs))
+	for i, pair := range pairs {
+		features[i] = geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geoJSONGeometry{Type: "MultiLineString", Coordinates: edges[pair]},
+			Properties: map[string]interface{}{"kind": "boundary", "clusters": []int{pair[0], pair[1]}},
+		}
+	}
+	return features
+}
@@ -0,0 +1,77 @@
+package som
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteGeoJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(20, 1)
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{2, 3}, Type: Planar, UShape: Rectangle},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	m.clusters = []int{0, 0, 0, 0, 1, 1}
+	var buf bytes.Buffer
+	assert.NoError(m.WriteGeoJSON(&buf))
+	var dec struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Type     string `json:"type"`
+			Geometry struct {
+				Type        string         `json:"type"`
+				Coordinates [][][2]float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	assert.NoError(json.Unmarshal(buf.Bytes(), &dec))
+	assert.Equal("FeatureCollection", dec.Type)
+	// six units and one boundary
+	if !assert.Len(dec.Features, 7) {
+		return
+	}
+	umatrix, err := m.UMatrixValues()
+	assert.NoError(err)
+	polygons, err := m.Grid().Polygons()
+	assert.NoError(err)
+	for i := 0; i < 6; i++ {
+		f := dec.Features[i]
+		assert.Equal("Feature", f.Type)
+		assert.Equal("Polygon", f.Geometry.Type)
+		assert.Equal("unit", f.Properties["kind"])
+		assert.Equal(float64(i), f.Properties["unit"])
+		assert.Equal(float64(m.clusters[i]), f.Properties["cluster"])
+		assert.InDelta(umatrix[i], f.Properties["umatrix"], 1e-12)
+		assert.NotContains(f.Properties, "label")
+		// rings are closed
+		ring := f.Geometry.Coordinates[0]
+		assert.Equal(append(polygons[i], polygons[i][0]), ring)
+	}
+	boundary := dec.Features[6]
+	assert.Equal("MultiLineString", boundary.Geometry.Type)
+	assert.Equal("boundary", boundary.Properties["kind"])
+	assert.Equal([]interface{}{0.0, 1.0}, boundary.Properties["clusters"])
+	assert.Equal([][][2]float64{{{1.5, 0.5}, {1.5, -0.5}}, {{1.5, 1.5}, {1.5, 0.5}}}, boundary.Geometry.Coordinates)
+
+	// maps without clusters have no boundaries
+	m.clusters = nil
+	buf.Reset()
+	assert.NoError(m.WriteGeoJSON(&buf))
+	assert.NoError(json.Unmarshal(buf.Bytes(), &dec))
+	assert.Len(dec.Features, 6)
+
+	// spherical grids have no polygons
+	m, err = NewMap(&MapConfig{
+		Grid: &GridConfig{Size: SphericalSize(1), Type: Spherical, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	assert.Error(m.WriteGeoJSON(&buf))
+}
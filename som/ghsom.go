@@ -0,0 +1,354 @@
+package som
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	// DefaultGHSOMTau is the default fraction of data quantization error above which GHSOM units spawn child maps
+	DefaultGHSOMTau = 0.1
+	// DefaultGHSOMMaxDepth is the default number of GHSOM hierarchy levels including the root map
+	DefaultGHSOMMaxDepth = 3
+	// DefaultGHSOMMinSamples is the default number of data samples below which GHSOM units don't spawn child maps
+	DefaultGHSOMMinSamples = 10
+	// ghsomFormatVersion is the version of the format of saved GHSOMs
+	ghsomFormatVersion = 1
+)
+
+// GHSOM is Growing Hierarchical SOM: a tree of maps in which every unit whose quantization error is too
+// high spawns a child map trained on the data samples mapped to the unit. The hierarchy adapts to the data:
+// dense heterogeneous regions are represented by deep subtrees while homogeneous regions stay shallow.
+// Child maps grow horizontally too if they are trained by gsom training.
+type GHSOM struct {
+	// Tau is the fraction of quantization error of the whole data set, the mean distance of data samples
+	// from their mean, above which the mean quantization error of a unit makes it spawn a child map.
+	// Lower values build deeper hierarchies. If it is zero, DefaultGHSOMTau is used.
+	Tau float64
+	// Dims holds grid dimensions of child maps. If it is nil, child maps have 2x2 grid.
+	Dims []int
+	// MaxDepth is the number of hierarchy levels including the root map.
+	// If it is zero, DefaultGHSOMMaxDepth is used.
+	MaxDepth int
+	// MinSamples is the number of data samples of a unit below which the unit does not spawn a child map.
+	// If it is zero, DefaultGHSOMMinSamples is used.
+	MinSamples int
+	// root is the root of the trained hierarchy
+	root *ghsomNode
+}
+
+// ghsomNode is a map of GHSOM hierarchy
+type ghsomNode struct {
+	// m is the node map
+	m *Map
+	// children holds child maps of the map units indexed by unit; leaf units have nil children
+	children []*ghsomNode
+}
+
+// withDefaults returns a copy of the GHSOM parameters with defaults of unset fields
+func (g GHSOM) withDefaults() GHSOM {
+	if g.Tau == 0 {
+		g.Tau = DefaultGHSOMTau
+	}
+	if g.Dims == nil {
+		g.Dims = []int{2, 2}
+	}
+	if g.MaxDepth == 0 {
+		g.MaxDepth = DefaultGHSOMMaxDepth
+	}
+	if g.MinSamples == 0 {
+		g.MinSamples = DefaultGHSOMMinSamples
+	}
+	return g
+}
+
+// Train trains the hierarchy on data. The root map is configured by mc and every map is trained by tc
+// running the same number of iterations as TrainRelational. Child maps have Dims grid of the mc grid
+// type and unit shape; their codebooks are initialized by mc codebook initialization from the samples
+// of their parent unit. A unit spawns a child map if the hierarchy has fewer than MaxDepth levels below
+// the unit, if it is BMU of at least MinSamples data samples and if their mean distance from the unit
+// exceeds Tau times the mean distance of all data samples from their mean. Maps use euclidean distance.
+// Train replaces the previous hierarchy. It returns error if data is nil, if the GHSOM parameters are
+// invalid or if any map can't be created or trained.
+func (g *GHSOM) Train(data *mat64.Dense, mc *MapConfig, tc *TrainConfig) error {
+	return g.train(data, mc, tc, trainRand(tc))
+}
+
+// train trains the hierarchy using r as the source of randomness of training
+func (g *GHSOM) train(data *mat64.Dense, mc *MapConfig, tc *TrainConfig, r *rand.Rand) error {
+	// data can't be nil
+	if data == nil {
+		return fmt.Errorf("invalid data supplied: %v", data)
+	}
+	p := g.withDefaults()
+	if !(p.Tau > 0) || math.IsInf(p.Tau, 1) {
+		return fmt.Errorf("invalid GHSOM tau: %f, must be positive and finite", p.Tau)
+	}
+	if p.MaxDepth < 0 {
+		return fmt.Errorf("invalid GHSOM maximum depth: %d", p.MaxDepth)
+	}
+	if p.MinSamples < 0 {
+		return fmt.Errorf("invalid GHSOM minimum samples: %d", p.MinSamples)
+	}
+	if mc == nil || mc.Grid == nil || mc.Cb == nil {
+		return fmt.Errorf("invalid map configuration: %v", mc)
+	}
+	// child map grids are validated before any training
+	childMC := &MapConfig{
+		Grid: &GridConfig{Size: p.Dims, Type: mc.Grid.Type, UShape: mc.Grid.UShape, Connectivity: mc.Grid.Connectivity},
+		Cb:   &CbConfig{Dim: mc.Cb.Dim, InitFunc: mc.Cb.InitFunc},
+	}
+	if err := validateMapConfig(childMC); err != nil {
+		return err
+	}
+	rows, cols := data.Dims()
+	mean := make([]float64, cols)
+	for i := 0; i < rows; i++ {
+		for j, v := range data.RawRowView(i) {
+			mean[j] += v / float64(rows)
+		}
+	}
+	qe0 := 0.0
+	for i := 0; i < rows; i++ {
+		qe0 += euclideanVec(data.RawRowView(i), mean) / float64(rows)
+	}
+	root, err := p.grow(data, mc, childMC, tc, p.Tau*qe0, 1, r)
+	if err != nil {
+		return err
+	}
+	g.root = root
+	return nil
+}
+
+// grow trains new map configured by mc on data at hierarchy level depth and spawns child maps configured
+// by childMC for its units whose mean quantization error exceeds threshold
+func (g GHSOM) grow(data *mat64.Dense, mc, childMC *MapConfig, tc *TrainConfig, threshold float64, depth int, r *rand.Rand) (*ghsomNode, error) {
+	m, err := NewMap(mc, data)
+	if err != nil {
+		return nil, err
+	}
+	rows, cols := data.Dims()
+	if _, err := m.train(tc, data, relIters(tc, rows), r); err != nil {
+		return nil, err
+	}
+	units, _ := m.cbDims()
+	node := &ghsomNode{m: m, children: make([]*ghsomNode, units)}
+	if depth >= g.MaxDepth {
+		return node, nil
+	}
+	bmus, dists, err := m.Predict(data, 1)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([][]int, units)
+	qe := make([]float64, units)
+	for i, bmu := range bmus {
+		samples[bmu] = append(samples[bmu], i)
+		qe[bmu] += dists[i]
+	}
+	for u, rowIdx := range samples {
+		if len(rowIdx) < g.MinSamples || qe[u]/float64(len(rowIdx)) <= threshold {
+			continue
+		}
+		subset := mat64.NewDense(len(rowIdx), cols, nil)
+		for i, row := range rowIdx {
+			subset.SetRow(i, data.RawRowView(row))
+		}
+		if node.children[u], err = g.grow(subset, childMC, childMC, tc, threshold, depth+1, r); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// checkTrained returns error if the hierarchy has not been trained
+func (g GHSOM) checkTrained() error {
+	if g.root == nil {
+		return fmt.Errorf("GHSOM has not been trained")
+	}
+	return nil
+}
+
+// Root returns the root map of the hierarchy. It returns nil if the hierarchy has not been trained.
+func (g GHSOM) Root() *Map {
+	if g.root == nil {
+		return nil
+	}
+	return g.root.m
+}
+
+// Map returns the map reached from the root map by following child maps of units in path: path[0]
+// is a unit of the root map, path[1] a unit of its child map and so on. Empty path returns the root map.
+// It returns nil if the hierarchy has not been trained or if path does not lead to a map.
+func (g GHSOM) Map(path ...int) *Map {
+	node := g.root
+	for _, u := range path {
+		if node == nil || u < 0 || u >= len(node.children) {
+			return nil
+		}
+		node = node.children[u]
+	}
+	if node == nil {
+		return nil
+	}
+	return node.m
+}
+
+// Depth returns the number of levels of the trained hierarchy or zero if it has not been trained
+func (g GHSOM) Depth() int {
+	return g.root.depth()
+}
+
+// depth returns the number of levels of the subtree of node n
+func (n *ghsomNode) depth() int {
+	if n == nil {
+		return 0
+	}
+	d := 0
+	for _, c := range n.children {
+		if cd := c.depth(); cd > d {
+			d = cd
+		}
+	}
+	return d + 1
+}
+
+// Predict returns the path of vector x through the hierarchy: the BMU of x in the root map, its BMU in
+// the child map of the root BMU and so on until a unit without a child map is reached. It also returns
+// the distance of x from the last BMU of the path. It returns error if the hierarchy has not been
+// trained or ErrDimMismatch if the dimension of x is different from the codebook dimension.
+func (g GHSOM) Predict(x []float64) ([]int, float64, error) {
+	if err := g.checkTrained(); err != nil {
+		return nil, -1.0, err
+	}
+	var path []int
+	dist := -1.0
+	for node := g.root; node != nil; {
+		bmu, d, err := node.m.BMU(x)
+		if err != nil {
+			return nil, -1.0, err
+		}
+		path, dist = append(path, bmu), d
+		node = node.children[bmu]
+	}
+	return path, dist, nil
+}
+
+// savedGHSOM is GHSOM state stored by Save
+type savedGHSOM struct {
+	// Version is the format version
+	Version int
+	// Tau, Dims, MaxDepth and MinSamples hold the GHSOM parameters
+	Tau        float64
+	Dims       []int
+	MaxDepth   int
+	MinSamples int
+	// Root is the root of the hierarchy
+	Root *savedGHSOMNode
+}
+
+// savedGHSOMNode is a map of the hierarchy stored by Save
+type savedGHSOMNode struct {
+	// Map holds the map encoded by MarshalBinary
+	Map []byte
+	// Units holds the units of the map which have child maps stored in Children
+	Units    []int
+	Children []*savedGHSOMNode
+}
+
+// Save writes the trained hierarchy and its parameters to w so it can be restored by LoadGHSOM.
+// Every map of the hierarchy is saved in the same way as by Map.Save.
+// It returns error if the hierarchy has not been trained, if any map can't be saved or if the write to w fails.
+func (g GHSOM) Save(w io.Writer) error {
+	if err := g.checkTrained(); err != nil {
+		return err
+	}
+	root, err := g.root.save()
+	if err != nil {
+		return err
+	}
+	return gob.NewEncoder(w).Encode(&savedGHSOM{
+		Version:    ghsomFormatVersion,
+		Tau:        g.Tau,
+		Dims:       g.Dims,
+		MaxDepth:   g.MaxDepth,
+		MinSamples: g.MinSamples,
+		Root:       root,
+	})
+}
+
+// save returns saved subtree of node n
+func (n *ghsomNode) save() (*savedGHSOMNode, error) {
+	b, err := n.m.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	s := &savedGHSOMNode{Map: b}
+	for u, c := range n.children {
+		if c == nil {
+			continue
+		}
+		child, err := c.save()
+		if err != nil {
+			return nil, err
+		}
+		s.Units, s.Children = append(s.Units, u), append(s.Children, child)
+	}
+	return s, nil
+}
+
+// LoadGHSOM reads GHSOM saved by Save from r.
+// It returns error if the read from r fails, if the saved hierarchy or any of its maps is corrupted
+// or if its format version is not supported.
+func LoadGHSOM(r io.Reader) (*GHSOM, error) {
+	s := new(savedGHSOM)
+	if err := gob.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("invalid saved GHSOM: %s", err)
+	}
+	if s.Version != ghsomFormatVersion {
+		return nil, fmt.Errorf("unsupported saved GHSOM version: %d", s.Version)
+	}
+	if s.Root == nil {
+		return nil, fmt.Errorf("invalid saved GHSOM: no root map")
+	}
+	root, err := s.Root.load()
+	if err != nil {
+		return nil, err
+	}
+	return &GHSOM{
+		Tau:        s.Tau,
+		Dims:       s.Dims,
+		MaxDepth:   s.MaxDepth,
+		MinSamples: s.MinSamples,
+		root:       root,
+	}, nil
+}
+
+// load returns the subtree of saved node s
+func (s *savedGHSOMNode) load() (*ghsomNode, error) {
+	m := new(Map)
+	if err := m.UnmarshalBinary(s.Map); err != nil {
+		return nil, err
+	}
+	units, _ := m.cbDims()
+	if len(s.Units) != len(s.Children) {
+		return nil, fmt.Errorf("invalid saved GHSOM: corrupted child maps")
+	}
+	n := &ghsomNode{m: m, children: make([]*ghsomNode, units)}
+	for i, u := range s.Units {
+		if u < 0 || u >= units || n.children[u] != nil || s.Children[i] == nil {
+			return nil, fmt.Errorf("invalid saved GHSOM: corrupted child maps")
+		}
+		child, err := s.Children[i].load()
+		if err != nil {
+			return nil, err
+		}
+		n.children[u] = child
+	}
+	return n, nil
+}
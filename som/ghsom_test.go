@@ -0,0 +1,118 @@
+package som
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// ghsomConfigs returns root map configuration and training configuration of GHSOM tests
+func ghsomConfigs() (*MapConfig, *TrainConfig) {
+	mc := &MapConfig{
+		Grid: &GridConfig{Size: []int{2, 2}, Type: Planar, UShape: Rectangle},
+		Cb:   &CbConfig{Dim: 2, InitFunc: LinInit},
+	}
+	tc := &TrainConfig{
+		Algorithm: Batch,
+		Radius:    1.0,
+		RDecay:    ExpDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    ExpDecay,
+	}
+	return mc, tc
+}
+
+func TestGHSOM(t *testing.T) {
+	assert := assert.New(t)
+
+	// 8 blobs are more than the 4 units of the root map can represent
+	data := makeBlobs(800, 8, 0.5, 1)
+	mc, tc := ghsomConfigs()
+	g := &GHSOM{Tau: 0.05, MaxDepth: 3}
+	assert.Nil(g.Root())
+	assert.Equal(0, g.Depth())
+	assert.NoError(g.train(data, mc, tc, rand.New(rand.NewSource(1))))
+	assert.True(g.Depth() > 1)
+	assert.Equal(g.Root(), g.Map())
+	assert.True(g.Depth() <= 3)
+
+	rootQE, err := g.Root().QuantError(data)
+	assert.NoError(err)
+	leafQE := 0.0
+	rows, _ := data.Dims()
+	for i := 0; i < rows; i++ {
+		path, dist, err := g.Predict(data.RawRowView(i))
+		assert.NoError(err)
+		assert.True(len(path) >= 1 && len(path) <= g.Depth())
+		// the path leads through existing maps to a leaf unit
+		assert.NotNil(g.Map(path[:len(path)-1]...))
+		assert.Nil(g.Map(path...))
+		leafQE += dist / float64(rows)
+	}
+	assert.True(leafQE < rootQE, "leaf: %f, root: %f", leafQE, rootQE)
+	_, _, err = g.Predict([]float64{1.0})
+	assert.Equal(ErrDimMismatch, err)
+	assert.Nil(g.Map(-1))
+	assert.Nil(g.Map(4))
+
+	// high tau keeps the root map only
+	g = &GHSOM{Tau: 10}
+	assert.NoError(g.train(data, mc, tc, rand.New(rand.NewSource(1))))
+	assert.Equal(1, g.Depth())
+	path, _, err := g.Predict(data.RawRowView(0))
+	assert.NoError(err)
+	assert.Len(path, 1)
+}
+
+func TestGHSOMSave(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(400, 8, 0.5, 2)
+	mc, tc := ghsomConfigs()
+	g := &GHSOM{Tau: 0.05}
+	var buf bytes.Buffer
+	assert.EqualError(g.Save(&buf), "GHSOM has not been trained")
+	assert.NoError(g.train(data, mc, tc, rand.New(rand.NewSource(1))))
+	assert.NoError(g.Save(&buf))
+	loaded, err := LoadGHSOM(&buf)
+	assert.NoError(err)
+	assert.Equal(g.Tau, loaded.Tau)
+	assert.Equal(g.Depth(), loaded.Depth())
+	assert.True(mat64.Equal(g.Root().Codebook(), loaded.Root().Codebook()))
+	rows, _ := data.Dims()
+	for i := 0; i < rows; i++ {
+		path, dist, err := g.Predict(data.RawRowView(i))
+		assert.NoError(err)
+		loadedPath, loadedDist, err := loaded.Predict(data.RawRowView(i))
+		assert.NoError(err)
+		assert.Equal(path, loadedPath)
+		assert.Equal(dist, loadedDist)
+	}
+	_, err = LoadGHSOM(bytes.NewReader([]byte("garbage")))
+	assert.Error(err)
+}
+
+func TestGHSOMErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(40, 2, 0.5, 1)
+	mc, tc := ghsomConfigs()
+	g := &GHSOM{}
+	_, _, err := g.Predict([]float64{0, 0})
+	assert.EqualError(err, "GHSOM has not been trained")
+	assert.EqualError(g.Train(nil, mc, tc), "invalid data supplied: <nil>")
+	assert.EqualError(g.Train(data, nil, tc), "invalid map configuration: <nil>")
+	g.Tau = -1
+	assert.EqualError(g.Train(data, mc, tc), "invalid GHSOM tau: -1.000000, must be positive and finite")
+	g.Tau, g.MaxDepth = 0, -1
+	assert.EqualError(g.Train(data, mc, tc), "invalid GHSOM maximum depth: -1")
+	g.MaxDepth, g.MinSamples = 0, -1
+	assert.EqualError(g.Train(data, mc, tc), "invalid GHSOM minimum samples: -1")
+	g.MinSamples, g.Dims = 0, []int{0, 2}
+	assert.Error(g.Train(data, mc, tc))
+	assert.Nil(g.Root())
+}
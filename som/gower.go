@@ -0,0 +1,134 @@
+package som
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// ColumnType is the type of data column compared by Gower distance
+type ColumnType string
+
+const (
+	// Numeric columns hold numbers: their distance is the absolute difference divided by the column range
+	Numeric ColumnType = "numeric"
+	// Categorical columns hold integer category codes: their distance is 0 if the codes match and 1 otherwise
+	Categorical ColumnType = "categorical"
+)
+
+// GowerConfig describes columns of mixed numeric and categorical data compared by Gower distance
+type GowerConfig struct {
+	// Types holds the type of every column
+	Types []ColumnType
+	// Ranges holds the range of every column, such as the ranges computed by ColumnRanges.
+	// Ranges of categorical columns are ignored. If it is nil, numeric columns are not scaled.
+	Ranges []float64
+	// Weights holds non-negative weights of column distances. If it is nil, all columns have the same weight.
+	Weights []float64
+}
+
+// Gower returns Gower distance of mixed data configured by c: the weighted mean of distances of columns
+// of the compared vectors, which depend on column types. Every column distance is between 0 and 1 if
+// numeric columns are scaled by their ranges, so no column dominates the distance and categorical
+// columns don't need to be one-hot encoded. Training moves categorical codebook components between
+// category codes like numeric ones: a component which is not a category code contributes its absolute
+// difference from the compared code, up to 1. The returned function can be used as CbConfig.Metric.
+// Gower returns error if c has no column types, if any column type is not supported, if the number of
+// ranges or weights is different from the number of columns, if any numeric column range is negative
+// or not finite or if weights are negative, not finite or all zero.
+func Gower(c GowerConfig) (DistanceFunc, error) {
+	cols := len(c.Types)
+	if cols == 0 {
+		return nil, fmt.Errorf("invalid column types: %v", c.Types)
+	}
+	for j, t := range c.Types {
+		if t != Numeric && t != Categorical {
+			return nil, fmt.Errorf("unsupported column %d type: %s", j, t)
+		}
+	}
+	scale := make([]float64, cols)
+	for j := range scale {
+		scale[j] = 1.0
+	}
+	if c.Ranges != nil {
+		if len(c.Ranges) != cols {
+			return nil, fmt.Errorf("invalid number of column ranges: %d, expected %d", len(c.Ranges), cols)
+		}
+		for j, r := range c.Ranges {
+			if c.Types[j] != Numeric {
+				continue
+			}
+			if !(r >= 0) || math.IsInf(r, 1) {
+				return nil, fmt.Errorf("invalid column %d range: %f, must be non-negative and finite", j, r)
+			}
+			// constant columns don't contribute to the distance
+			scale[j] = 0.0
+			if r > 0 {
+				scale[j] = 1 / r
+			}
+		}
+	}
+	weights := make([]float64, cols)
+	for j := range weights {
+		weights[j] = 1.0
+	}
+	if c.Weights != nil {
+		if len(c.Weights) != cols {
+			return nil, fmt.Errorf("invalid number of column weights: %d, expected %d", len(c.Weights), cols)
+		}
+		copy(weights, c.Weights)
+	}
+	total := 0.0
+	for _, w := range weights {
+		if !(w >= 0) || math.IsInf(w, 1) {
+			return nil, fmt.Errorf("invalid column weights: %v, must be non-negative and finite", weights)
+		}
+		total += w
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("invalid column weights: %v, must not all be zero", weights)
+	}
+	categorical := make([]bool, cols)
+	for j, t := range c.Types {
+		categorical[j] = t == Categorical
+		weights[j] /= total
+	}
+	return func(a, b []float64) float64 {
+		d := 0.0
+		for j, w := range weights {
+			diff := math.Abs(a[j] - b[j])
+			if categorical[j] {
+				diff = math.Min(diff, 1.0)
+			} else {
+				diff *= scale[j]
+			}
+			d += w * diff
+		}
+		return d
+	}, nil
+}
+
+// ColumnRanges returns the difference between the largest and the smallest value of every data column.
+// It returns error if data is nil.
+func ColumnRanges(data *mat64.Dense) ([]float64, error) {
+	if data == nil {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, cols := data.Dims()
+	ranges := make([]float64, cols)
+	if rows == 0 {
+		return ranges, nil
+	}
+	lo := append([]float64(nil), data.RawRowView(0)...)
+	hi := append([]float64(nil), lo...)
+	for i := 1; i < rows; i++ {
+		for j, v := range data.RawRowView(i) {
+			lo[j], hi[j] = math.Min(lo[j], v), math.Max(hi[j], v)
+		}
+	}
+	for j := range ranges {
+		ranges[j] = hi[j] - lo[j]
+	}
+	return ranges, nil
+}
@@ -0,0 +1,104 @@
+package som
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGower(t *testing.T) {
+	assert := assert.New(t)
+
+	fn, err := Gower(GowerConfig{
+		Types:  []ColumnType{Numeric, Categorical, Categorical},
+		Ranges: []float64{10.0, 0.0, 0.0},
+	})
+	assert.NoError(err)
+	assert.InDelta(0.0, fn([]float64{1, 2, 3}, []float64{1, 2, 3}), 1e-12)
+	// numeric difference is scaled by the range, mismatched categories count 1
+	assert.InDelta((0.5+0+1)/3, fn([]float64{1, 2, 3}, []float64{6, 2, 7}), 1e-12)
+	// codebook components between category codes
+	assert.InDelta((0+0.25+0)/3, fn([]float64{1, 2, 3}, []float64{1, 2.25, 3}), 1e-12)
+
+	// weighted columns and constant numeric columns
+	fn, err = Gower(GowerConfig{
+		Types:   []ColumnType{Numeric, Numeric, Categorical},
+		Ranges:  []float64{0.0, 2.0, 5.0},
+		Weights: []float64{1, 1, 2},
+	})
+	assert.NoError(err)
+	assert.InDelta((0+0.5+2*1)/4, fn([]float64{1, 1, 0}, []float64{5, 2, 4}), 1e-12)
+
+	// unscaled numeric columns
+	fn, err = Gower(GowerConfig{Types: []ColumnType{Numeric}})
+	assert.NoError(err)
+	assert.InDelta(3.0, fn([]float64{1}, []float64{4}), 1e-12)
+}
+
+func TestGowerErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		c   GowerConfig
+		err string
+	}{
+		{GowerConfig{}, "invalid column types: []"},
+		{GowerConfig{Types: []ColumnType{Numeric, "ordinal"}}, "unsupported column 1 type: ordinal"},
+		{GowerConfig{Types: []ColumnType{Numeric}, Ranges: []float64{1, 2}}, "invalid number of column ranges: 2, expected 1"},
+		{GowerConfig{Types: []ColumnType{Numeric}, Ranges: []float64{-1}}, "invalid column 0 range: -1.000000, must be non-negative and finite"},
+		{GowerConfig{Types: []ColumnType{Numeric}, Weights: []float64{1, 2}}, "invalid number of column weights: 2, expected 1"},
+		{GowerConfig{Types: []ColumnType{Numeric}, Weights: []float64{math.NaN()}}, "invalid column weights: [NaN], must be non-negative and finite"},
+		{GowerConfig{Types: []ColumnType{Numeric, Categorical}, Weights: []float64{0, 0}}, "invalid column weights: [0 0], must not all be zero"},
+	}
+	for _, tc := range testCases {
+		_, err := Gower(tc.c)
+		assert.EqualError(err, tc.err)
+	}
+	// categorical column ranges are not checked
+	_, err := Gower(GowerConfig{Types: []ColumnType{Categorical}, Ranges: []float64{math.NaN()}})
+	assert.NoError(err)
+}
+
+func TestColumnRanges(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := ColumnRanges(nil)
+	assert.Error(err)
+	ranges, err := ColumnRanges(mat64.NewDense(3, 2, []float64{1, 5, -2, 5, 4, 5}))
+	assert.NoError(err)
+	assert.Equal([]float64{6, 0}, ranges)
+}
+
+func TestGowerTraining(t *testing.T) {
+	assert := assert.New(t)
+
+	// numeric column of two groups and categorical column which separates them
+	data := mat64.NewDense(40, 2, nil)
+	for i := 0; i < 40; i++ {
+		data.Set(i, 0, float64(i%2)*0.1+float64(i%4)*0.01)
+		data.Set(i, 1, float64(i%2)*3)
+	}
+	ranges, err := ColumnRanges(data)
+	assert.NoError(err)
+	fn, err := Gower(GowerConfig{Types: []ColumnType{Numeric, Categorical}, Ranges: ranges})
+	assert.NoError(err)
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{2, 2}, Type: Planar, UShape: Rectangle},
+		Cb:   &CbConfig{Dim: 2, InitFunc: SampleInit, Metric: fn},
+	}, data)
+	assert.NoError(err)
+	tc := &TrainConfig{Algorithm: Seq, Radius: 1.0, RDecay: LinDecay, NeighbFn: Gaussian, LRate: 0.5, LDecay: LinDecay}
+	assert.NoError(m.Train(tc, data, 400))
+	bmus, err := m.BMUs(data)
+	assert.NoError(err)
+	// samples of different categories never share a BMU
+	for i := range bmus {
+		for j := range bmus {
+			if bmus[i] == bmus[j] {
+				assert.Equal(data.At(i, 1), data.At(j, 1))
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package som
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"strings"
 
 	"github.com/gonum/floats"
@@ -18,8 +19,20 @@ type Grid struct {
 	size []int
 	// ushape holds grid unit shape
 	ushape string
+	// gtype holds grid type
+	gtype string
 	// coords holds grid point coordinates
 	coords *mat64.Dense
+	// cells holds the index of the bounding grid cell of every unit of masked grids
+	// whose cells don't all hold a unit; it is nil if every cell holds a unit
+	cells []int
+	// periods holds the period of every coordinate of toroidal and cylindrical grids: coordinates
+	// whose period is zero don't wrap around. It is nil for grids whose unit distances don't wrap around.
+	periods []float64
+	// radius holds the sphere radius of spherical grids; it is zero for other grids
+	radius float64
+	// connectivity holds connectivity of rectangle units; it is empty for axial units
+	connectivity string
 }
 
 // NewGrid creates new grid and returns it
@@ -31,16 +44,120 @@ func NewGrid(c *GridConfig) (*Grid, error) {
 	}
 
 	// grid coordinates matrix
-	coords, err := GridCoords(c.UShape, c.Size)
+	coords, err := gridTypeFunc(string(c.Type))(string(c.UShape), c.Size)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Grid{
+	g := &Grid{
 		size:   c.Size,
-		ushape: c.UShape,
+		ushape: string(c.UShape),
+		gtype:  string(c.Type),
 		coords: coords,
-	}, nil
+	}
+	if c.Connectivity == Moore {
+		g.connectivity = string(Moore)
+	}
+	switch c.Type {
+	case Toroidal:
+		g.periods = gridPeriods(string(c.UShape), c.Size)
+	case Cylindrical:
+		// only x coordinates wrap around columns
+		g.periods = make([]float64, len(c.Size))
+		g.periods[0] = float64(c.Size[1])
+	case Spherical:
+		g.radius = floats.Norm(coords.RawRowView(0), 2)
+	}
+	return g, nil
+}
+
+// gridPeriods returns periods of unit coordinates of toroidal grid of the given unit shape
+// and size: x coordinates wrap around columns, y coordinates around rows and z coordinates
+// around layers. Rows of hexagon grids are sqrt(0.75) apart.
+func gridPeriods(uShape string, size []int) []float64 {
+	periods := []float64{float64(size[1]), float64(size[0])}
+	if uShape == string(Hexagon) {
+		periods[1] *= math.Sqrt(0.75)
+	}
+	if len(size) == 3 {
+		periods = append(periods, float64(size[2]))
+	}
+	return periods
+}
+
+// unitDist returns a matrix which contains distances between grid units.
+// Distances of toroidal and cylindrical grid units are measured along the shortest way around the grid,
+// distances of spherical grid units along the sphere surface and distances of moore units are Chebyshev distances.
+func (g *Grid) unitDist() *mat64.Dense {
+	if g.radius == 0 && g.periods == nil && !g.moore() {
+		return euclideanMx(g.coords)
+	}
+	units, _ := g.coords.Dims()
+	out := mat64.NewDense(units, units, nil)
+	for i := 0; i < units-1; i++ {
+		a := g.coords.RawRowView(i)
+		for j := i + 1; j < units; j++ {
+			dist := g.dist(a, g.coords.RawRowView(j))
+			out.Set(i, j, dist)
+			out.Set(j, i, dist)
+		}
+	}
+	return out
+}
+
+// dist returns the distance between grid units with coordinates a and b measured in the same way as unitDist
+func (g *Grid) dist(a, b []float64) float64 {
+	if g.radius > 0 {
+		return g.radius * sphereAngle(a, b)
+	}
+	moore := g.moore()
+	if g.periods == nil && !moore {
+		return euclideanVec(a, b)
+	}
+	dist := 0.0
+	for k := range a {
+		d := math.Abs(a[k] - b[k])
+		if g.periods != nil && g.periods[k] > 0 {
+			d = math.Min(d, g.periods[k]-d)
+		}
+		if moore {
+			dist = math.Max(dist, d)
+		} else {
+			dist += d * d
+		}
+	}
+	if moore {
+		return dist
+	}
+	return math.Sqrt(dist)
+}
+
+// moore returns true if grid units have moore connectivity
+func (g *Grid) moore() bool {
+	return g.connectivity == string(Moore)
+}
+
+// neighbRadius returns the distance within which grid units are considered neighbours,
+// so that the neighbours of planar, toroidal and cylindrical grid units include diagonal units,
+// which moore units have at distance 1. Neighbours of spherical grid units are at most about 1.18 apart
+// while the other units are more than 1.4 apart.
+func (g *Grid) neighbRadius() float64 {
+	if g.radius > 0 {
+		return 1.25
+	}
+	if g.moore() {
+		return 1.0
+	}
+	return neighbDist(g.coords)
+}
+
+// unitIndex returns unit index of grid units whose distances are stored in unitDist.
+// Neighbourhoods of toroidal and cylindrical grid units wrap around the grid edges, so their index scans all units.
+func (g *Grid) unitIndex(unitDist *mat64.Dense) *unitIndex {
+	if g.periods != nil {
+		return newScanIndex(unitDist)
+	}
+	return newUnitIndex(g.coords, unitDist)
 }
 
 // Size returns a slice that contains Grid dimensions
@@ -53,11 +170,100 @@ func (g *Grid) UShape() string {
 	return g.ushape
 }
 
+// Type returns grid type
+func (g *Grid) Type() string {
+	return g.gtype
+}
+
+// Connectivity returns connectivity of grid units: moore for rectangle units with moore connectivity
+// and axial for the other units
+func (g *Grid) Connectivity() string {
+	if g.moore() {
+		return string(Moore)
+	}
+	return string(Axial)
+}
+
 // Coords returns a matrix that contains grid coordinates
 func (g *Grid) Coords() mat64.Matrix {
 	return g.coords
 }
 
+// Polygons returns the vertices of the polygon of every grid unit in map space, the space of unit
+// coordinates returned by Coords, so that plots and web front ends can draw the grid without deriving
+// its geometry. Hexagon units are regular hexagons of width 1 with vertices pointing along the y axis,
+// which tile the grid because hexagon grid rows are sqrt(0.75) apart and every other row is shifted
+// by 0.5. Rectangle units are squares of side 1. Vertices of every polygon are ordered counterclockwise
+// starting from the vertex right of and above the unit centre and the first vertex is not repeated.
+// Polygons are ordered in the same way as map units. It returns error if the grid is not two dimensional,
+// such as spherical grids.
+func (g *Grid) Polygons() ([][][2]float64, error) {
+	units, dim := g.coords.Dims()
+	if dim != 2 {
+		return nil, fmt.Errorf("unsupported grid polygons: %d dimensional %s grid", dim, g.gtype)
+	}
+	// vertex offsets of the unit shape from the unit centre
+	var offsets [][2]float64
+	if strings.EqualFold(g.ushape, "hexagon") {
+		big := math.Tan(math.Pi / 6.0)
+		small := big / 2.0
+		offsets = [][2]float64{{0.5, small}, {0, big}, {-0.5, small}, {-0.5, -small}, {0, -big}, {0.5, -small}}
+	} else {
+		offsets = [][2]float64{{0.5, 0.5}, {-0.5, 0.5}, {-0.5, -0.5}, {0.5, -0.5}}
+	}
+	polygons := make([][][2]float64, units)
+	for i := range polygons {
+		c := g.coords.RawRowView(i)
+		polygons[i] = make([][2]float64, len(offsets))
+		for j, o := range offsets {
+			polygons[i][j] = [2]float64{c[0] + o[0], c[1] + o[1]}
+		}
+	}
+	return polygons, nil
+}
+
+// Neighbors returns sorted indices of grid units other than the unit with index idx whose grid
+// distance from the unit is at most radius. Distances are measured in the same way as the training
+// neighbourhood: hexagon grid units have 6 neighbours at distance 1 and axial rectangle grid units 4, with
+// 4 more diagonal neighbours at distance sqrt(2), while moore rectangle grid units have all 8 neighbours
+// at distance 1. Neighbourhoods of toroidal and cylindrical grid units
+// wrap around the grid edges and distances of spherical grid units are measured along the sphere surface.
+// It returns error if idx is not a valid unit index or if radius is negative.
+func (g *Grid) Neighbors(idx int, radius float64) ([]int, error) {
+	units, _ := g.coords.Dims()
+	if idx < 0 || idx >= units {
+		return nil, fmt.Errorf("invalid unit index: %d", idx)
+	}
+	if !(radius >= 0) {
+		return nil, fmt.Errorf("invalid neighbourhood radius: %f", radius)
+	}
+	// allow for rounding errors of hexagon grid coordinates
+	radius += 1e-9
+	a := g.coords.RawRowView(idx)
+	var near []int
+	for i := 0; i < units; i++ {
+		if i != idx && g.dist(a, g.coords.RawRowView(i)) <= radius {
+			near = append(near, i)
+		}
+	}
+	return near, nil
+}
+
+// Mask returns a slice which holds true for every cell of the grid which holds a unit.
+// Cells are ordered column by column in the same way as map units. Masked grids, such as
+// grids of maps created by TrainGSOM, have units only in some cells of their bounding grid
+// whose dimensions are returned by Size. Mask returns nil if every cell holds a unit.
+func (g *Grid) Mask() []bool {
+	if g.cells == nil {
+		return nil
+	}
+	mask := make([]bool, utils.IntProduct(g.size))
+	for _, cell := range g.cells {
+		mask[cell] = true
+	}
+	return mask
+}
+
 // GridSize tries to estimate the best dimensions of map from data matrix and given unit shape.
 // It determines the grid size from eigenvectors of input data: the grid dimensions are
 // calculated from the ratio of two highest input eigenvalues.
@@ -113,42 +319,56 @@ func GridSize(data *mat64.Dense, uShape string) ([]int, error) {
 
 // RandInit returns a matrix initialized to uniformly distributed random values
 // in each column in range between [max, min] where max and min are maximum and minmum values
-// in particular matrix column. The returned matrix has product(dims) number of rows and
+// in particular matrix column; missing values, which are stored as NaN, are ignored.
+// The returned matrix has product(dims) number of rows and
 // as many columns as the matrix passed in as a parameter.
 // It fails with error if the new matrix could not be initialized or if data is nil.
 func RandInit(data *mat64.Dense, dims []int) (*mat64.Dense, error) {
+	// use the same fixed seed as matrix.MakeRandom
+	return randInit(data, dims, rand.New(rand.NewSource(55)))
+}
+
+// RandInitSeed returns CbInitFunc which works like RandInit,
+// but draws the random values from a random source with the given seed
+func RandInitSeed(seed int64) CbInitFunc {
+	return func(data *mat64.Dense, dims []int) (*mat64.Dense, error) {
+		return randInit(data, dims, rand.New(rand.NewSource(seed)))
+	}
+}
+
+// randInit implements RandInit using random source r
+func randInit(data *mat64.Dense, dims []int, r *rand.Rand) (*mat64.Dense, error) {
 	// if nil matrix is passed in, return error
 	if data == nil {
 		return nil, fmt.Errorf("invalid input matrix: %v", data)
 	}
 	// dims can't be nil
 	if dims == nil {
-		return nil, fmt.Errorf("invalid dimensions: %v", dims)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDims, dims)
 	}
 	// dims can't be nil or negative
 	for _, dim := range dims {
 		if dim <= 0 {
-			return nil, fmt.Errorf("Non-Positive dimensions supplied: %v", dims)
+			return nil, fmt.Errorf("%w: non-positive dimensions: %v", ErrInvalidDims, dims)
 		}
 	}
 	// input matrix dimensions
-	_, cols := data.Dims()
-	// get max of each column
-	max, err := matrix.ColsMax(cols, data)
-	if err != nil {
-		return nil, err
+	rows, cols := data.Dims()
+	if rows == 0 || cols == 0 {
+		return nil, fmt.Errorf("invalid input matrix dimensions: %d x %d", rows, cols)
 	}
-	// get min of each column
-	min, err := matrix.ColsMin(cols, data)
-	if err != nil {
-		return nil, err
+	// get min and max of observed values of each column
+	min, max := make([]float64, cols), make([]float64, cols)
+	for i := range min {
+		min[i], max[i] = observedRange(data, i)
 	}
 	mUnits := utils.IntProduct(dims)
 	// initialize matrix to rand values between 0.0 and 1.0
-	codebook, err := matrix.MakeRandom(mUnits, cols, 0.0, 1.0)
-	if err != nil {
-		return nil, err
+	randVals := make([]float64, mUnits*cols)
+	for i := range randVals {
+		randVals[i] = r.Float64()
 	}
+	codebook := mat64.NewDense(mUnits, cols, randVals)
 	for i := 0; i < cols; i++ {
 		col := codebook.ColView(i)
 		for j := 0; j < mUnits; j++ {
@@ -159,27 +379,75 @@ func RandInit(data *mat64.Dense, dims []int) (*mat64.Dense, error) {
 	return codebook, nil
 }
 
+// SampleInit returns a matrix initialized to randomly chosen rows of data.
+// Every data row is used at most once unless the matrix has more rows than data.
+// The returned matrix has product(dims) number of rows and as many columns as data.
+// It fails with error if data is nil or empty or if dims are invalid.
+func SampleInit(data *mat64.Dense, dims []int) (*mat64.Dense, error) {
+	// use the same fixed seed as RandInit
+	return sampleInit(data, dims, rand.New(rand.NewSource(55)))
+}
+
+// SampleInitSeed returns CbInitFunc which works like SampleInit,
+// but chooses the data rows using a random source with the given seed
+func SampleInitSeed(seed int64) CbInitFunc {
+	return func(data *mat64.Dense, dims []int) (*mat64.Dense, error) {
+		return sampleInit(data, dims, rand.New(rand.NewSource(seed)))
+	}
+}
+
+// sampleInit implements SampleInit using random source r
+func sampleInit(data *mat64.Dense, dims []int, r *rand.Rand) (*mat64.Dense, error) {
+	// if nil matrix is passed in, return error
+	if data == nil {
+		return nil, fmt.Errorf("invalid input matrix: %v", data)
+	}
+	if dims == nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDims, dims)
+	}
+	for _, dim := range dims {
+		if dim <= 0 {
+			return nil, fmt.Errorf("%w: non-positive dimensions: %v", ErrInvalidDims, dims)
+		}
+	}
+	rows, cols := data.Dims()
+	mUnits := utils.IntProduct(dims)
+	codebook := mat64.NewDense(mUnits, cols, nil)
+	var perm []int
+	for i := 0; i < mUnits; i++ {
+		// draw the next permutation of data rows once all rows have been used
+		if i%rows == 0 {
+			perm = r.Perm(rows)
+		}
+		codebook.SetRow(i, data.RawRowView(perm[i%rows]))
+	}
+	return codebook, nil
+}
+
 // LinInit returns a matrix initialized to values lying in a linear space
 // spanned by principal components of data stored in the data matrix passed in as parameter.
+// Codebook vectors of 2D maps are centered at the data mean and span the plane of the first two
+// principal components scaled by their standard deviations. Unlike RandInit it is deterministic.
 // It fails with error if the new matrix could not be initialized or if data is nil.
 func LinInit(data *mat64.Dense, dims []int) (*mat64.Dense, error) {
 	if err := validateLinInit(data, dims); err != nil {
 		return nil, err
 	}
-	// Adjust map dimensions size to account for 1D cases
-	mapDim := len(dims)
-	for _, dim := range dims {
-		if dim == 1 {
-			mapDim--
+	// map axes which have more than one unit span the linear space; 1D data spans a single axis
+	_, dataDim := data.Dims()
+	var axes []int
+	for i, dim := range dims {
+		if dim > 1 && len(axes) < dataDim {
+			axes = append(axes, i)
 		}
 	}
+	mapDim := len(axes)
 	// calculate linear space basis
 	mapVecs, err := getBaseVecs(data, mapDim)
 	if err != nil {
 		return nil, err
 	}
 
-	_, dataDim := data.Dims()
 	mUnits := utils.IntProduct(dims)
 	// initialize codebook matrix
 	codebook := mat64.NewDense(mUnits, dataDim, nil)
@@ -194,7 +462,7 @@ func LinInit(data *mat64.Dense, dims []int) (*mat64.Dense, error) {
 			codebook.SetRow(i, colsMean)
 		}
 		// calculate normalized coordinates
-		coords, err := getLinMapCoords(mapDim, dims)
+		coords, err := getLinMapCoords(dims)
 		if err != nil {
 			return nil, err
 		}
@@ -206,7 +474,7 @@ func LinInit(data *mat64.Dense, dims []int) (*mat64.Dense, error) {
 			for j := 0; j < mapDim; j++ {
 				// grab 1st map eigenvector i.e. base vector
 				mat64.Col(mapCol, j, mapVecs)
-				floats.Scale(coords.At(i, j), mapCol)
+				floats.Scale(coords.At(i, axes[j]), mapCol)
 				// grab first codebook row
 				mat64.Row(cbRow, i, codebook)
 				floats.Add(cbRow, mapCol)
@@ -235,12 +503,12 @@ func validateLinInit(data *mat64.Dense, dims []int) error {
 	}
 	// can't pass in nil dimensions
 	if dims == nil {
-		return fmt.Errorf("Incorrect dimensions: %v", dims)
+		return fmt.Errorf("%w: %v", ErrInvalidDims, dims)
 	}
 	// Check if any of the supplied dimensions are non-negative
 	for _, dim := range dims {
 		if dim <= 0 {
-			return fmt.Errorf("Non-Positive dimensions supplied: %v", dims)
+			return fmt.Errorf("%w: non-positive dimensions: %v", ErrInvalidDims, dims)
 		}
 	}
 	// Linear initialization requires at least 2 samples
@@ -290,9 +558,9 @@ func getBaseVecs(data *mat64.Dense, mapDim int) (*mat64.Dense, error) {
 	return baseVecs, nil
 }
 
-// getLinMapCoords calculates map coordinates and normalizes them to unit values
+// getLinMapCoords calculates map coordinates of every map axis and normalizes them to unit values
 // It returns error if it can't calculate coordinates
-func getLinMapCoords(mapDim int, dims []int) (*mat64.Dense, error) {
+func getLinMapCoords(dims []int) (*mat64.Dense, error) {
 	// calculate unit coordinates
 	coords, err := GridCoords("rectangle", dims)
 	if err != nil {
@@ -308,7 +576,7 @@ func getLinMapCoords(mapDim int, dims []int) (*mat64.Dense, error) {
 	coords.SetCol(1, x)
 	// normalize coordinates to unit values
 	c := make([]float64, mUnits)
-	for i := 0; i < mapDim; i++ {
+	for i := range dims {
 		c = mat64.Col(c, i, coords)
 		max := floats.Max(c)
 		min := floats.Min(c)
@@ -393,28 +661,28 @@ func GridCoords(uShape string, dims []int) (*mat64.Dense, error) {
 // given the provided parameters. It returns error if the validation fails
 func validateGridCoords(uShape string, dims []int) error {
 	// unsupported SOM unit shape
-	if _, ok := uShapes[uShape]; !ok {
-		return fmt.Errorf("unsupported unit shape: %s", uShape)
+	if !uShapes.Has(uShape) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedUShape, uShape)
 	}
 	// map dims can't be nil
 	if dims == nil {
-		return fmt.Errorf("invalid dimensions supplied: %v", dims)
+		return fmt.Errorf("%w: %v", ErrInvalidDims, dims)
 	}
 	// check if the dimensions are postive numbers
 	for _, dim := range dims {
 		if dim <= 0 {
-			return fmt.Errorf("Non-Positive dimensions supplied: %v", dims)
+			return fmt.Errorf("%w: non-positive dimensions: %v", ErrInvalidDims, dims)
 		}
 	}
 	// map dims can't be longer than 3
 	mDims := len(dims)
 	if mDims > 3 {
-		return fmt.Errorf("unsupported dimensions requested: %d", mDims)
+		return fmt.Errorf("%w: unsupported number of dimensions: %d", ErrInvalidDims, mDims)
 	}
 	// can't use hexagon with dims > 2
 	if strings.EqualFold(uShape, "hexagon") {
 		if mDims > 2 {
-			return fmt.Errorf("Exceeded allowed hexagon dims: %d", mDims)
+			return fmt.Errorf("%w: unsupported number of hexagon dimensions: %d", ErrInvalidDims, mDims)
 		}
 	}
 	return nil
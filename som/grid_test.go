@@ -1,9 +1,16 @@
 package som
 
 import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"strings"
 	"testing"
 
+	"github.com/gonum/floats"
 	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,12 +31,14 @@ func TestNewGrid(t *testing.T) {
 	assert.EqualValues(gCfg.Size, gSize)
 	// test UShape
 	uShape := g.UShape()
-	assert.Equal(gCfg.UShape, uShape)
+	assert.EqualValues(gCfg.UShape, uShape)
 	// test coords
 	coords := g.Coords()
 	rows, cols := coords.Dims()
 	assert.Equal(cols, len(gCfg.Size))
 	assert.Equal(rows, gCfg.Size[0]*gCfg.Size[1])
+	// every cell holds a unit
+	assert.Nil(g.Mask())
 	// test error cases
 	origDims := gCfg.Size
 	gCfg.Size = []int{1}
@@ -129,6 +138,50 @@ func TestRandInit(t *testing.T) {
 	assert.Error(err)
 }
 
+func TestSampleInit(t *testing.T) {
+	assert := assert.New(t)
+
+	data := mat64.NewDense(3, 2, []float64{1, 2, 3, 4, 5, 6})
+	// codebook rows are data rows, each used once
+	cb, err := SampleInit(data, []int{1, 3})
+	assert.NoError(err)
+	seen := make(map[float64]bool)
+	for i := 0; i < 3; i++ {
+		row := cb.RawRowView(i)
+		assert.Equal(row[0]+1, row[1])
+		seen[row[0]] = true
+	}
+	assert.Len(seen, 3)
+	// more units than data rows
+	cb, err = SampleInit(data, []int{2, 4})
+	assert.NoError(err)
+	rows, cols := cb.Dims()
+	assert.Equal(8, rows)
+	assert.Equal(2, cols)
+	for i := 0; i < rows; i++ {
+		row := cb.RawRowView(i)
+		assert.Equal(row[0]+1, row[1])
+	}
+	// deterministic initialization
+	cb2, err := SampleInit(data, []int{2, 4})
+	assert.NoError(err)
+	assert.True(mat64.Equal(cb, cb2))
+	// seeded initialization
+	cb2, err = SampleInitSeed(55)(data, []int{2, 4})
+	assert.NoError(err)
+	assert.True(mat64.Equal(cb, cb2))
+	cb2, err = SampleInitSeed(7)(data, []int{2, 4})
+	assert.NoError(err)
+	assert.False(mat64.Equal(cb, cb2))
+	// invalid input
+	_, err = SampleInit(nil, []int{2, 2})
+	assert.Error(err)
+	_, err = SampleInit(data, nil)
+	assert.True(errors.Is(err, ErrInvalidDims))
+	_, err = SampleInit(data, []int{0, 2})
+	assert.True(errors.Is(err, ErrInvalidDims))
+}
+
 func TestLinInit(t *testing.T) {
 	assert := assert.New(t)
 
@@ -161,7 +214,28 @@ func TestLinInit(t *testing.T) {
 	// non positive dimensions supplied
 	linMx, err = LinInit(inMx, []int{-1, 2})
 	assert.Nil(linMx)
-	assert.Error(err)
+	assert.True(errors.Is(err, ErrInvalidDims))
+	// codebook of noisy planar data lies in the plane of the data and is reproducible
+	r := rand.New(rand.NewSource(1))
+	plane := mat64.NewDense(200, 3, nil)
+	for i := 0; i < 200; i++ {
+		x, y := r.NormFloat64()*3, r.NormFloat64()
+		plane.SetRow(i, []float64{x + y + 1, x - y + 2, 3 + r.NormFloat64()*0.01})
+	}
+	linMx, err = LinInit(plane, []int{4, 6})
+	assert.NoError(err)
+	again, err := LinInit(plane, []int{4, 6})
+	assert.NoError(err)
+	assert.True(mat64.Equal(linMx, again))
+	mean := make([]float64, 3)
+	for i := 0; i < 24; i++ {
+		vec := linMx.RawRowView(i)
+		assert.InDelta(3.0, vec[2], 0.05)
+		floats.Add(mean, vec)
+	}
+	floats.Scale(1.0/24, mean)
+	assert.InDelta(stat.Mean(mat64.Col(nil, 0, plane), nil), mean[0], 1e-9)
+	assert.InDelta(stat.Mean(mat64.Col(nil, 1, plane), nil), mean[1], 1e-9)
 	// insufficient number of samples
 	inMx = mat64.NewDense(1, 2, []float64{1, 1})
 	linMx, err = LinInit(inMx, []int{5, 2})
@@ -207,7 +281,7 @@ func TestGridCoords(t *testing.T) {
 	// incorrect units shape
 	coords, err = GridCoords("fooshape", []int{2, 2})
 	assert.Nil(coords)
-	assert.Error(err)
+	assert.True(errors.Is(err, ErrUnsupportedUShape))
 	// nil dimensions
 	coords, err = GridCoords("hexagon", nil)
 	assert.Nil(coords)
@@ -219,5 +293,274 @@ func TestGridCoords(t *testing.T) {
 	// negative plane dimensions
 	coords, err = GridCoords("hexagon", []int{-1, 2})
 	assert.Nil(coords)
+	assert.True(errors.Is(err, ErrInvalidDims))
+}
+
+func TestGrid3D(t *testing.T) {
+	assert := assert.New(t)
+
+	// units are ordered by row first, then column, then layer
+	coords, err := GridCoords("rectangle", []int{2, 3, 4})
+	assert.NoError(err)
+	units, dims := coords.Dims()
+	assert.Equal(24, units)
+	assert.Equal(3, dims)
+	assert.Equal([]float64{0, 1, 0}, coords.RawRowView(1))
+	assert.Equal([]float64{1, 0, 0}, coords.RawRowView(2))
+	assert.Equal([]float64{0, 0, 1}, coords.RawRowView(6))
+
+	g, err := NewGrid(&GridConfig{Size: []int{2, 3, 4}, Type: Planar, UShape: Rectangle})
+	assert.NoError(err)
+	assert.Nil(g.Mask())
+	_, err = NewGrid(&GridConfig{Size: []int{2, 3, 4}, Type: Planar, UShape: Hexagon})
+	assert.True(errors.Is(err, ErrInvalidDims))
+
+	// linear initialization spans three principal components of 3D data
+	data := mat64.NewDense(27, 3, nil)
+	for i := 0; i < 27; i++ {
+		data.SetRow(i, []float64{float64(i % 3), float64(i / 3 % 3), float64(i / 9)})
+	}
+	cb, err := LinInit(data, []int{3, 3, 3})
+	assert.NoError(err)
+	for j := 0; j < 3; j++ {
+		col := mat64.Col(nil, j, cb)
+		assert.True(floats.Max(col)-floats.Min(col) > 0.5)
+	}
+	// more map axes than data dimensions
+	cb, err = LinInit(mat64.DenseCopyOf(data.Slice(0, 27, 0, 2)), []int{3, 3, 3})
+	assert.NoError(err)
+	assert.Equal(27, cb.RawMatrix().Rows)
+}
+
+func TestToroidalGrid(t *testing.T) {
+	assert := assert.New(t)
+
+	// every unit has the same number of nearest neighbours
+	for _, tc := range []struct {
+		c      *GridConfig
+		neighb int
+		// near is the number of units closer than 1.5 including the unit
+		near int
+	}{
+		{&GridConfig{Size: []int{4, 5}, Type: Toroidal, UShape: Rectangle}, 4, 9},
+		{&GridConfig{Size: []int{4, 5}, Type: Toroidal, UShape: Hexagon}, 6, 7},
+		{&GridConfig{Size: []int{3, 4, 5}, Type: Toroidal, UShape: Rectangle}, 6, 19},
+	} {
+		g, err := NewGrid(tc.c)
+		assert.NoError(err)
+		dist := g.unitDist()
+		units, _ := dist.Dims()
+		for i := 0; i < units; i++ {
+			neighb := 0
+			for j := 0; j < units; j++ {
+				assert.InDelta(dist.At(i, j), dist.At(j, i), 1e-12)
+				if i != j && dist.At(i, j) < 1.01 {
+					assert.InDelta(1.0, dist.At(i, j), 1e-12)
+					neighb++
+				}
+			}
+			assert.Equal(tc.neighb, neighb, "%v unit %d", tc.c, i)
+		}
+		// toroidal grids are scanned by unit index
+		assert.Len(g.unitIndex(dist).within(nil, 0, 1.5), tc.near)
+	}
+
+	// units on the opposite edges are neighbours
+	g, err := NewGrid(&GridConfig{Size: []int{4, 5}, Type: Toroidal, UShape: Rectangle})
+	assert.NoError(err)
+	dist := g.unitDist()
+	assert.Equal(1.0, dist.At(0, 3))
+	assert.Equal(1.0, dist.At(0, 16))
+	assert.InDelta(math.Sqrt2, dist.At(0, 19), 1e-12)
+	assert.Equal(2.0, dist.At(0, 8))
+	planar, err := NewGrid(&GridConfig{Size: []int{4, 5}, Type: Planar, UShape: Rectangle})
+	assert.NoError(err)
+	assert.Equal(3.0, planar.unitDist().At(0, 3))
+	assert.Equal(Toroidal, GridType(g.Type()))
+}
+
+func TestCylindricalGrid(t *testing.T) {
+	assert := assert.New(t)
+
+	for uShape, near := range map[UnitShape]int{Rectangle: 4, Hexagon: 5} {
+		// odd number of rows is allowed: rows don't wrap around
+		g, err := NewGrid(&GridConfig{Size: []int{3, 5}, Type: Cylindrical, UShape: uShape})
+		assert.NoError(err)
+		assert.Equal(Cylindrical, GridType(g.Type()))
+		dist := g.unitDist()
+		// units of the first and last columns are neighbours
+		assert.InDelta(1.0, dist.At(0, 12), 1e-12, "%s", uShape)
+		assert.InDelta(2.0, dist.At(0, 9), 1e-12, "%s", uShape)
+		// units of the first and last rows are not
+		planar, err := NewGrid(&GridConfig{Size: []int{3, 5}, Type: Planar, UShape: uShape})
+		assert.NoError(err)
+		assert.InDelta(planar.unitDist().At(0, 2), dist.At(0, 2), 1e-12, "%s", uShape)
+		// cylindrical grids are scanned by unit index
+		assert.Len(g.unitIndex(dist).within(nil, 0, 1.01), near, "%s", uShape)
+	}
+	g, err := NewGrid(&GridConfig{Size: []int{3, 4, 5}, Type: Cylindrical, UShape: Rectangle})
+	assert.NoError(err)
+	dist := g.unitDist()
+	// unit 9 is at row 0, column 3 and layer 0, unit 12 at row 0, column 0 and layer 1
+	assert.InDelta(1.0, dist.At(0, 9), 1e-12)
+	assert.InDelta(1.0, dist.At(0, 12), 1e-12)
+	assert.InDelta(4.0, dist.At(0, 48), 1e-12)
+}
+
+func TestGridNeighbors(t *testing.T) {
+	assert := assert.New(t)
+
+	g, err := NewGrid(&GridConfig{Size: []int{4, 5}, Type: Planar, UShape: Rectangle})
+	assert.NoError(err)
+	for _, tc := range []struct {
+		idx    int
+		radius float64
+		near   []int
+	}{
+		{5, 1.0, []int{1, 4, 6, 9}},
+		{5, math.Sqrt2, []int{0, 1, 2, 4, 6, 8, 9, 10}},
+		{0, 1.0, []int{1, 4}},
+		{0, 0.0, nil},
+	} {
+		near, err := g.Neighbors(tc.idx, tc.radius)
+		assert.NoError(err)
+		assert.Equal(tc.near, near)
+	}
+	// hexagon grid units have 6 neighbours
+	g, err = NewGrid(&GridConfig{Size: []int{4, 5}, Type: Planar, UShape: Hexagon})
+	assert.NoError(err)
+	near, err := g.Neighbors(5, 1.0)
+	assert.NoError(err)
+	assert.Len(near, 6)
+	// neighbourhoods of toroidal grids wrap around the grid edges
+	g, err = NewGrid(&GridConfig{Size: []int{4, 5}, Type: Toroidal, UShape: Rectangle})
+	assert.NoError(err)
+	near, err = g.Neighbors(0, 1.0)
+	assert.NoError(err)
+	assert.Equal([]int{1, 3, 4, 16}, near)
+	g, err = NewGrid(&GridConfig{Size: []int{4, 6}, Type: Toroidal, UShape: Hexagon})
+	assert.NoError(err)
+	for i := 0; i < 24; i++ {
+		near, err = g.Neighbors(i, 1.0)
+		assert.NoError(err)
+		assert.Len(near, 6, "unit %d", i)
+	}
+	// invalid parameters
+	_, err = g.Neighbors(24, 1.0)
+	assert.EqualError(err, "invalid unit index: 24")
+	_, err = g.Neighbors(0, -1.0)
+	assert.Error(err)
+}
+
+func TestMooreConnectivity(t *testing.T) {
+	assert := assert.New(t)
+
+	// moore units have 8 neighbours at distance 1
+	g, err := NewGrid(&GridConfig{Size: []int{4, 5}, Type: Planar, UShape: Rectangle, Connectivity: Moore})
+	assert.NoError(err)
+	assert.Equal(string(Moore), g.Connectivity())
+	near, err := g.Neighbors(5, 1.0)
+	assert.NoError(err)
+	assert.Equal([]int{0, 1, 2, 4, 6, 8, 9, 10}, near)
+	near, err = g.Neighbors(0, 1.0)
+	assert.NoError(err)
+	assert.Equal([]int{1, 4, 5}, near)
+	// grid distances are Chebyshev distances
+	unitDist := g.unitDist()
+	assert.Equal(1.0, unitDist.At(0, 5))
+	assert.Equal(2.0, unitDist.At(0, 10))
+	assert.Equal(4.0, unitDist.At(0, 19))
+	assert.Equal(1.0, g.neighbRadius())
+
+	// neighbourhoods of toroidal grids wrap around the grid edges
+	g, err = NewGrid(&GridConfig{Size: []int{4, 5}, Type: Toroidal, UShape: Rectangle, Connectivity: Moore})
+	assert.NoError(err)
+	near, err = g.Neighbors(0, 1.0)
+	assert.NoError(err)
+	assert.Equal([]int{1, 3, 4, 5, 7, 16, 17, 19}, near)
+
+	// 3D moore units have 26 neighbours
+	g, err = NewGrid(&GridConfig{Size: []int{3, 3, 3}, Type: Planar, UShape: Rectangle, Connectivity: Moore})
+	assert.NoError(err)
+	near, err = g.Neighbors(13, 1.0)
+	assert.NoError(err)
+	assert.Len(near, 26)
+
+	// rectangle units are axial by default
+	g, err = NewGrid(&GridConfig{Size: []int{4, 5}, Type: Planar, UShape: Rectangle})
+	assert.NoError(err)
+	assert.Equal(string(Axial), g.Connectivity())
+	assert.InDelta(math.Sqrt2, g.unitDist().At(0, 5), 1e-9)
+
+	// hexagon units don't support moore connectivity
+	_, err = NewGrid(&GridConfig{Size: []int{4, 5}, Type: Planar, UShape: Hexagon, Connectivity: Moore})
+	assert.True(errors.Is(err, ErrUnsupportedUShape))
+	_, err = NewGrid(&GridConfig{Size: []int{4, 5}, Type: Planar, UShape: Rectangle, Connectivity: "von"})
+	assert.Error(err)
+	_, err = ParseConnectivity("von")
+	assert.EqualError(err, "unsupported connectivity: von, supported: axial, moore")
+
+	// connectivity survives JSON round trips and empty connectivity is omitted
+	for _, gc := range []GridConfig{
+		{Size: []int{4, 5}, Type: Planar, UShape: Rectangle, Connectivity: Moore},
+		{Size: []int{4, 5}, Type: Planar, UShape: Rectangle},
+	} {
+		data, err := json.Marshal(gc)
+		assert.NoError(err)
+		assert.Equal(gc.Connectivity != "", strings.Contains(string(data), "Connectivity"))
+		var out GridConfig
+		assert.NoError(json.Unmarshal(data, &out))
+		assert.Equal(gc, out)
+	}
+	var out GridConfig
+	assert.NoError(json.Unmarshal([]byte(`{"Connectivity": ""}`), &out))
+	assert.Empty(out.Connectivity)
+}
+
+func TestGridPolygons(t *testing.T) {
+	assert := assert.New(t)
+
+	g, err := NewGrid(&GridConfig{Size: []int{2, 3}, Type: Planar, UShape: Rectangle})
+	assert.NoError(err)
+	polygons, err := g.Polygons()
+	assert.NoError(err)
+	assert.Len(polygons, 6)
+	assert.Equal([][2]float64{{0.5, 0.5}, {-0.5, 0.5}, {-0.5, -0.5}, {0.5, -0.5}}, polygons[0])
+	assert.Equal([][2]float64{{2.5, 1.5}, {1.5, 1.5}, {1.5, 0.5}, {2.5, 0.5}}, polygons[5])
+
+	// neighbouring hexagons share an edge
+	g, err = NewGrid(&GridConfig{Size: []int{4, 5}, Type: Planar, UShape: Hexagon})
+	assert.NoError(err)
+	polygons, err = g.Polygons()
+	assert.NoError(err)
+	shared := func(a, b [][2]float64) int {
+		n := 0
+		for _, u := range a {
+			for _, v := range b {
+				if math.Abs(u[0]-v[0]) < 1e-9 && math.Abs(u[1]-v[1]) < 1e-9 {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	for i, p := range polygons {
+		assert.Len(p, 6)
+		near, err := g.Neighbors(i, 1.0)
+		assert.NoError(err)
+		for _, j := range near {
+			assert.Equal(2, shared(p, polygons[j]), "units %d and %d", i, j)
+		}
+		// hexagon vertices are tan(pi/6) away from the unit centre
+		c := g.coords.RawRowView(i)
+		for _, v := range p {
+			assert.InDelta(math.Tan(math.Pi/6), math.Hypot(v[0]-c[0], v[1]-c[1]), 1e-9)
+		}
+	}
+
+	// spherical grids are not two dimensional
+	g, err = NewGrid(&GridConfig{Size: SphericalSize(1), Type: Spherical, UShape: Hexagon})
+	assert.NoError(err)
+	_, err = g.Polygons()
 	assert.Error(err)
 }
@@ -0,0 +1,263 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// SearchSpace enumerates SOM parameters evaluated by GridSearch and RandomSearch. Every combination of the listed
+// values configures one run; empty lists keep the value of the base configuration.
+type SearchSpace struct {
+	// Map is the base map configuration: every run replaces its grid size
+	Map *MapConfig
+	// Train is the base training configuration
+	Train *TrainConfig
+	// Dims lists grid dimensions
+	Dims [][]int
+	// Radius lists initial units radii
+	Radius []float64
+	// LRate lists initial learning rates
+	LRate []float64
+	// NeighbFn lists neighbourhood functions by name: gaussian, bubble, mexican, cutgauss, epanechnikov or functions
+	// registered by RegisterNeighbFunc
+	NeighbFn []string
+	// Decay lists decay strategies which are used for both radius and learning rate
+	Decay []Decay
+	// Iters lists numbers of training iterations. If it is empty, runs use the same
+	// number of iterations as TrainRelational.
+	Iters []int
+}
+
+// ScoreWeights holds weights of the combined error which ranks GridSearch runs:
+// QuantError * quantization error + TopoError * topographic error
+type ScoreWeights struct {
+	// QuantError is the weight of quantization error
+	QuantError float64
+	// TopoError is the weight of topographic error
+	TopoError float64
+}
+
+// SearchResult holds the result of a single GridSearch run
+type SearchResult struct {
+	// Rank is the run rank by Score starting at 1; it is zero for failed runs
+	Rank int
+	// Params is the evaluated parameter set
+	Params ParamSet
+	// NeighbFn is the name of the neighbourhood function of the run or empty if the base
+	// training configuration function was used
+	NeighbFn string
+	// QuantError is the quantization error of training data
+	QuantError float64
+	// TopoError is the topographic error of training data
+	TopoError float64
+	// Score is the combined error
+	Score float64
+	// Duration is the duration of the run training and evaluation
+	Duration time.Duration
+	// Err is set if the run parameters are invalid or the run failed
+	Err error
+}
+
+// GridSearch trains one map on data for every combination of parameters of space and ranks the runs
+// by the combined error score of their quantization and topographic errors on data. Runs are executed
+// concurrently by at most workers goroutines; if workers is not a positive integer, the number of CPUs
+// is used. Results of successful runs are ordered by their rank, ties are ordered by the enumeration
+// order of the combinations; failed runs follow and are reported in the Err field of their result.
+// The results, except for durations, are deterministic for a fixed seed. GridSearch returns error
+// if data is nil, if space has no base configurations or if score weights are negative, not finite
+// or both zero.
+func GridSearch(data *mat64.Dense, space SearchSpace, score ScoreWeights, seed int64, workers int) ([]SearchResult, error) {
+	if err := validateSearch(data, space, score); err != nil {
+		return nil, err
+	}
+	rows, _ := data.Dims()
+	results := searchRuns(space, rows)
+	idx := make([]int, len(results))
+	for i := range idx {
+		idx[i] = i
+	}
+	return runSearch(data, results, idx, score, seed, workers), nil
+}
+
+// RandomSearch trains maps of runs combinations of parameters of space which are drawn at random without
+// replacement and ranks them in the same way as GridSearch. If space has at most runs combinations, all
+// of them are evaluated. Random search finds good parameters of large search spaces in a fraction of
+// the GridSearch time. A run of a combination has the same result as the GridSearch run of the combination
+// with the same seed, so the drawn combinations, the results and their order, except for durations,
+// are deterministic for a fixed seed. RandomSearch returns error if runs is not a positive integer
+// or in the same way as GridSearch.
+func RandomSearch(data *mat64.Dense, space SearchSpace, score ScoreWeights, runs int, seed int64, workers int) ([]SearchResult, error) {
+	if runs <= 0 {
+		return nil, fmt.Errorf("invalid number of runs: %d", runs)
+	}
+	if err := validateSearch(data, space, score); err != nil {
+		return nil, err
+	}
+	rows, _ := data.Dims()
+	all := searchRuns(space, rows)
+	idx := rand.New(rand.NewSource(seed)).Perm(len(all))
+	if runs < len(idx) {
+		idx = idx[:runs]
+	}
+	// ties are ordered by the enumeration order of the combinations
+	sort.Ints(idx)
+	results := make([]SearchResult, len(idx))
+	for i, j := range idx {
+		results[i] = all[j]
+	}
+	return runSearch(data, results, idx, score, seed, workers), nil
+}
+
+// validateSearch returns error if data is nil, if space has no base configurations or if score weights
+// are negative, not finite or both zero
+func validateSearch(data *mat64.Dense, space SearchSpace, score ScoreWeights) error {
+	// data can't be nil
+	if data == nil {
+		return fmt.Errorf("invalid data supplied: %v", data)
+	}
+	if space.Map == nil || space.Map.Grid == nil || space.Map.Cb == nil || space.Train == nil {
+		return fmt.Errorf("invalid search space: missing base configuration")
+	}
+	for _, w := range []float64{score.QuantError, score.TopoError} {
+		if !(w >= 0) || math.IsInf(w, 1) {
+			return fmt.Errorf("invalid score weights: %v, must be non-negative and finite", score)
+		}
+	}
+	if score.QuantError == 0 && score.TopoError == 0 {
+		return fmt.Errorf("invalid score weights: %v, must not both be zero", score)
+	}
+	return nil
+}
+
+// runSearch evaluates search runs results using at most workers goroutines and returns them ranked.
+// idx holds the enumeration index of every run, from which the run seed is derived.
+func runSearch(data *mat64.Dense, results []SearchResult, idx []int, score ScoreWeights, seed int64, workers int) []SearchResult {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				res := &results[i]
+				if res.Err != nil {
+					continue
+				}
+				start := time.Now()
+				// every run gets its own seed derived from the search seed
+				res.QuantError, res.TopoError, res.Err = searchRun(res.Params, data, seed+int64(idx[i])+1)
+				res.Duration = time.Since(start)
+				if res.Err == nil {
+					res.Score = score.QuantError*res.QuantError + score.TopoError*res.TopoError
+				}
+			}
+		}()
+	}
+	for i := range results {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	sort.SliceStable(results, func(a, b int) bool {
+		if (results[a].Err == nil) != (results[b].Err == nil) {
+			return results[a].Err == nil
+		}
+		return results[a].Err == nil && results[a].Score < results[b].Score
+	})
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Rank = i + 1
+		}
+	}
+	return results
+}
+
+// searchRuns returns results of all runs of space on rows data samples with their parameter sets
+// in enumeration order: grid dimensions vary slowest, iterations fastest. Runs whose neighbourhood
+// function is not registered have their Err set.
+func searchRuns(space SearchSpace, rows int) []SearchResult {
+	// an empty list is enumerated as a single base value
+	count := func(n int) int {
+		if n == 0 {
+			return 1
+		}
+		return n
+	}
+	sizes := []int{
+		count(len(space.Dims)), count(len(space.Radius)), count(len(space.LRate)),
+		count(len(space.NeighbFn)), count(len(space.Decay)), count(len(space.Iters)),
+	}
+	total := 1
+	for _, n := range sizes {
+		total *= n
+	}
+	results := make([]SearchResult, total)
+	idx := make([]int, len(sizes))
+	for i := range results {
+		// decode the combination indices of run i
+		rest := i
+		for j := len(sizes) - 1; j >= 0; j-- {
+			idx[j], rest = rest%sizes[j], rest/sizes[j]
+		}
+		grid := *space.Map.Grid
+		tc := *space.Train
+		res := &results[i]
+		if len(space.Dims) > 0 {
+			grid.Size = space.Dims[idx[0]]
+		}
+		if len(space.Radius) > 0 {
+			tc.Radius = space.Radius[idx[1]]
+		}
+		if len(space.LRate) > 0 {
+			tc.LRate = space.LRate[idx[2]]
+		}
+		if len(space.NeighbFn) > 0 {
+			res.NeighbFn = space.NeighbFn[idx[3]]
+			fn, err := NeighbFuncByName(res.NeighbFn)
+			if err != nil {
+				res.Err = err
+			}
+			tc.NeighbFn = fn
+		}
+		if len(space.Decay) > 0 {
+			tc.RDecay, tc.LDecay = space.Decay[idx[4]], space.Decay[idx[4]]
+		}
+		res.Params = ParamSet{Map: &MapConfig{Grid: &grid, Cb: space.Map.Cb}, Train: &tc}
+		res.Params.Iters = relIters(&tc, rows)
+		if len(space.Iters) > 0 {
+			res.Params.Iters = space.Iters[idx[5]]
+		}
+	}
+	return results
+}
+
+// searchRun trains a new SOM using the supplied parameters on data and returns its quantization
+// and topographic error of data
+func searchRun(p ParamSet, data *mat64.Dense, seed int64) (float64, float64, error) {
+	m, err := NewMap(p.Map, data)
+	if err != nil {
+		return -1.0, -1.0, err
+	}
+	if _, err := m.train(p.Train, data, p.Iters, rand.New(rand.NewSource(seed))); err != nil {
+		return -1.0, -1.0, err
+	}
+	qe, err := m.QuantError(data)
+	if err != nil {
+		return -1.0, -1.0, err
+	}
+	te, err := m.TopoError(data)
+	if err != nil {
+		return -1.0, -1.0, err
+	}
+	return qe, te, nil
+}
@@ -0,0 +1,143 @@
+package som
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGridSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(200, 4, 1.0, 1)
+	mc, tc := mergeConfigs([]int{4, 4})
+	space := SearchSpace{
+		Map:      mc,
+		Train:    tc,
+		Dims:     [][]int{{1, 2}, {4, 4}},
+		Radius:   []float64{-1.0, 2.0},
+		NeighbFn: []string{"gaussian", "unknown"},
+		Iters:    []int{500},
+	}
+	results, err := GridSearch(data, space, ScoreWeights{QuantError: 1.0, TopoError: 1.0}, 1, 2)
+	assert.NoError(err)
+	assert.Len(results, 8)
+	// only gaussian runs with positive radius are valid
+	for i, res := range results {
+		if i < 2 {
+			assert.NoError(res.Err)
+			assert.Equal(i+1, res.Rank)
+			assert.Equal(2.0, res.Params.Train.Radius)
+			assert.Equal("gaussian", res.NeighbFn)
+			assert.Equal(500, res.Params.Iters)
+			assert.InDelta(res.QuantError+res.TopoError, res.Score, 1e-12)
+			assert.True(res.Duration > 0)
+			continue
+		}
+		assert.Error(res.Err)
+		assert.Equal(0, res.Rank)
+	}
+	// undersized map is ranked below the reasonable one
+	assert.Equal([]int{4, 4}, results[0].Params.Map.Grid.Size)
+	assert.Equal([]int{1, 2}, results[1].Params.Map.Grid.Size)
+	assert.True(results[0].Score < results[1].Score)
+	// base configurations are not modified
+	assert.Equal([]int{4, 4}, mc.Grid.Size)
+	assert.Equal(3.0, tc.Radius)
+
+	// results are deterministic regardless of the number of workers
+	other, err := GridSearch(data, space, ScoreWeights{QuantError: 1.0, TopoError: 1.0}, 1, 1)
+	assert.NoError(err)
+	for i, res := range results {
+		assert.Equal(res.Params.Map.Grid.Size, other[i].Params.Map.Grid.Size)
+		assert.Equal(res.QuantError, other[i].QuantError)
+		assert.Equal(res.TopoError, other[i].TopoError)
+	}
+
+	// empty lists keep the base values
+	results, err = GridSearch(data, SearchSpace{Map: mc, Train: tc}, ScoreWeights{TopoError: 1.0}, 1, 0)
+	assert.NoError(err)
+	assert.Len(results, 1)
+	assert.NoError(results[0].Err)
+	assert.Equal([]int{4, 4}, results[0].Params.Map.Grid.Size)
+	assert.Equal(2000, results[0].Params.Iters)
+	assert.Equal(results[0].TopoError, results[0].Score)
+}
+
+func TestGridSearchErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(20, 2, 1.0, 1)
+	mc, tc := mergeConfigs([]int{2, 2})
+	space := SearchSpace{Map: mc, Train: tc}
+	weights := ScoreWeights{QuantError: 1.0}
+
+	_, err := GridSearch(nil, space, weights, 1, 0)
+	assert.Error(err)
+	_, err = GridSearch(data, SearchSpace{Map: mc}, weights, 1, 0)
+	assert.Error(err)
+	_, err = GridSearch(data, SearchSpace{Train: tc}, weights, 1, 0)
+	assert.Error(err)
+	_, err = GridSearch(data, space, ScoreWeights{}, 1, 0)
+	assert.Error(err)
+	_, err = GridSearch(data, space, ScoreWeights{QuantError: -1.0}, 1, 0)
+	assert.Error(err)
+}
+
+func TestRandomSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(200, 4, 1.0, 1)
+	mc, tc := mergeConfigs([]int{4, 4})
+	space := SearchSpace{
+		Map:    mc,
+		Train:  tc,
+		Dims:   [][]int{{1, 2}, {3, 3}, {4, 4}},
+		Radius: []float64{1.0, 2.0},
+		LRate:  []float64{0.1, 0.5},
+		Iters:  []int{300},
+	}
+	weights := ScoreWeights{QuantError: 1.0, TopoError: 1.0}
+	results, err := RandomSearch(data, space, weights, 5, 1, 2)
+	assert.NoError(err)
+	assert.Len(results, 5)
+	for i, res := range results {
+		assert.NoError(res.Err)
+		assert.Equal(i+1, res.Rank)
+		if i > 0 {
+			assert.True(results[i-1].Score <= res.Score)
+		}
+	}
+	// runs have the same results as grid search runs of the same combinations
+	all, err := GridSearch(data, space, weights, 1, 2)
+	assert.NoError(err)
+	assert.Len(all, 12)
+	for _, res := range results {
+		found := false
+		for _, other := range all {
+			p, o := res.Params, other.Params
+			if p.Map.Grid.Size[0] == o.Map.Grid.Size[0] && p.Map.Grid.Size[1] == o.Map.Grid.Size[1] &&
+				p.Train.Radius == o.Train.Radius && p.Train.LRate == o.Train.LRate {
+				assert.Equal(other.Score, res.Score)
+				found = true
+			}
+		}
+		assert.True(found)
+	}
+	// results are deterministic for a fixed seed
+	other, err := RandomSearch(data, space, weights, 5, 1, 1)
+	assert.NoError(err)
+	for i, res := range results {
+		assert.Equal(res.Params.Map.Grid.Size, other[i].Params.Map.Grid.Size)
+		assert.Equal(res.Score, other[i].Score)
+	}
+	// small spaces are searched exhaustively
+	results, err = RandomSearch(data, space, weights, 100, 1, 0)
+	assert.NoError(err)
+	assert.Len(results, 12)
+
+	_, err = RandomSearch(data, space, weights, 0, 1, 0)
+	assert.EqualError(err, "invalid number of runs: 0")
+	_, err = RandomSearch(nil, space, weights, 5, 1, 0)
+	assert.Error(err)
+}
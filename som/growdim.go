@@ -0,0 +1,86 @@
+package som
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// GrowDim appends n features to the map codebook after training has started, such as new words
+// of a growing hashing trick vocabulary of streamed text, so the map can be trained on samples of
+// the new dimension without retraining from scratch. New codebook columns of all units are set
+// to init, one value per new feature, such as means of the new features in recent samples;
+// if init is nil, they are set to zero, which suits sparse features missing from older samples.
+// New features get the mean relevance of the other features if the map has feature relevances,
+// which are then normalised to sum to 1 again, and scalers of ZScore and MinMax scaling leave them unscaled. Feature names are removed because
+// the new features have none; set them again by SetFeatureNames. The map must not be used while
+// it grows: readers of snapshots published by SharedMap see the new dimension in the next snapshot.
+// GrowDim returns error if n is not a positive integer or if init does not hold n finite values.
+func (m *Map) GrowDim(n int, init []float64) error {
+	if n <= 0 {
+		return fmt.Errorf("invalid number of new features: %d", n)
+	}
+	if init != nil && len(init) != n {
+		return fmt.Errorf("invalid number of new feature values: %d, expected %d", len(init), n)
+	}
+	for j, v := range init {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("invalid new feature value %d: %f", j, v)
+		}
+	}
+	units, dim := m.cbDims()
+	old := m.cb()
+	grown := mat64.NewDense(units, dim+n, nil)
+	for i := 0; i < units; i++ {
+		row := grown.RawRowView(i)
+		copy(row, old.RawRowView(i))
+		copy(row[dim:], init)
+	}
+	if m.cb32 != nil {
+		m.cb32 = newCodebook32(grown)
+	} else {
+		m.codebook = grown
+	}
+	if m.relevance != nil {
+		mean := 0.0
+		for _, r := range m.relevance {
+			mean += r / float64(dim)
+		}
+		for j := 0; j < n; j++ {
+			m.relevance = append(m.relevance, mean)
+		}
+		normaliseRelevance(m.relevance)
+	}
+	// scalers may be shared with other maps
+	if s := m.scaler; s != nil && s.Method != UnitLength {
+		grownScaler := &Scaler{
+			Method: s.Method,
+			Center: append(append([]float64(nil), s.Center...), make([]float64, n)...),
+			Scale:  append([]float64(nil), s.Scale...),
+		}
+		for j := 0; j < n; j++ {
+			grownScaler.Scale = append(grownScaler.Scale, 1.0)
+		}
+		m.scaler = grownScaler
+	}
+	m.features = nil
+	// the codebook has changed: discard KD-tree and quality measures and recompute cached norms
+	m.tree, m.quality = nil, nil
+	if m.norms != nil {
+		m.norms = m.cbNorms()
+	}
+	return nil
+}
+
+// GrowDim appends n features to the trained map codebook in the same way as Map.GrowDim, so the next
+// updates accept samples of the new dimension. If OnlineConfig.Shared is set, the grown map is published.
+func (o *OnlineTrainer) GrowDim(n int, init []float64) error {
+	if err := o.m.GrowDim(n, init); err != nil {
+		return err
+	}
+	if o.c.Shared != nil {
+		o.c.Shared.Publish(o.m)
+	}
+	return nil
+}
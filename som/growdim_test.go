@@ -0,0 +1,88 @@
+package som
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrowDim(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(50, 1)
+	for _, precision := range []string{"", "float32"} {
+		m, err := NewMap(makePrecisionMapCfg([]int{3, 2}, 2, precision), data)
+		assert.NoError(err)
+		before := m.Codebook()
+		assert.NoError(m.GrowDim(2, nil))
+		cb := m.Codebook()
+		units, dim := cb.Dims()
+		assert.Equal(6, units)
+		assert.Equal(4, dim)
+		for i := 0; i < units; i++ {
+			assert.Equal(mat64.Row(nil, i, before), cb.RawRowView(i)[:2])
+			assert.Equal([]float64{0, 0}, cb.RawRowView(i)[2:])
+		}
+		assert.NoError(m.GrowDim(1, []float64{0.5}))
+		cb = m.Codebook()
+		for i := 0; i < units; i++ {
+			assert.Equal(0.5, cb.At(i, 4))
+		}
+		// the grown map maps samples of the new dimension
+		_, _, err = m.BMU([]float64{0, 0, 0, 0, 0})
+		assert.NoError(err)
+		_, _, err = m.BMU([]float64{0, 0})
+		assert.Equal(ErrDimMismatch, err)
+	}
+
+	// relevances, scalers and feature names
+	m, err := NewMapWithOptions(data, WithDims(3, 2), WithFeatureWeights(1.0, 0.5))
+	assert.NoError(err)
+	scaler, err := FitScaler(ZScore, data)
+	assert.NoError(err)
+	assert.NoError(m.SetScaler(scaler))
+	assert.NoError(m.SetFeatureNames([]string{"x", "y"}))
+	assert.NoError(m.GrowDim(2, nil))
+	assert.InDeltaSlice([]float64{1.0 / 3, 1.0 / 6, 0.25, 0.25}, m.FeatureRelevance(), 1e-12)
+	assert.InDelta(1.0, floats.Sum(m.FeatureRelevance()), 1e-12)
+	assert.Nil(m.FeatureNames())
+	assert.Len(scaler.Center, 2)
+	scaled, err := m.scaler.Transform(mat64.NewDense(1, 4, []float64{scaler.Center[0], scaler.Center[1], 3.0, -1.0}))
+	assert.NoError(err)
+	assert.Equal([]float64{0, 0, 3.0, -1.0}, mat64.Row(nil, 0, scaled))
+	bmus, _, err := m.Predict(mat64.NewDense(1, 4, nil), 1)
+	assert.NoError(err)
+	assert.Len(bmus, 1)
+
+	assert.EqualError(m.GrowDim(0, nil), "invalid number of new features: 0")
+	assert.EqualError(m.GrowDim(2, []float64{1.0}), "invalid number of new feature values: 1, expected 2")
+	assert.Error(m.GrowDim(1, []float64{math.NaN()}))
+	_, dim := m.cbDims()
+	assert.Equal(4, dim)
+}
+
+func TestOnlineTrainerGrowDim(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(50, 1)
+	m, err := NewMap(makePrecisionMapCfg([]int{3, 2}, 2, ""), data)
+	assert.NoError(err)
+	shared, err := NewSharedMap(m)
+	assert.NoError(err)
+	o, err := m.NewOnlineTrainer(OnlineConfig{Shared: shared})
+	assert.NoError(err)
+	defer o.Close()
+	assert.NoError(o.Update([]float64{0.5, 0.5}))
+	assert.Equal(ErrDimMismatch, o.Update([]float64{0.5, 0.5, 1.0}))
+	assert.NoError(o.GrowDim(1, nil))
+	assert.NoError(o.Update([]float64{0.5, 0.5, 1.0}))
+	assert.Equal(ErrDimMismatch, o.Update([]float64{0.5, 0.5}))
+	// the grown map is published
+	snapshot, _ := shared.Load()
+	_, dim := snapshot.Codebook().Dims()
+	assert.Equal(3, dim)
+	assert.Error(o.GrowDim(-1, nil))
+}
@@ -0,0 +1,353 @@
+package som
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	// GSOMGrowEpochs is the number of passes through data of GSOM growing phase
+	GSOMGrowEpochs = 10
+	// GSOMDistribution is the fraction of their own error which is added to neighbours
+	// of high error units which can't grow
+	GSOMDistribution = 0.5
+	// GSOMSmoothRadius is initial radius of GSOM smoothing phase
+	GSOMSmoothRadius = 2.0
+)
+
+// gsomUnit is a unit of growing map
+type gsomUnit struct {
+	// row and col are unit lattice position; they can be negative
+	row, col int
+	// vec is the unit codebook vector
+	vec []float64
+	// err is accumulated quantization error of the unit
+	err float64
+}
+
+// TrainGSOM trains Growing SOM on data with growth controlled by spreadFactor: the higher the factor,
+// the more units the map grows. The map starts with 2x2 units initialized by random samples.
+// During GSOMGrowEpochs passes through data every BMU accumulates squared distances of its samples and
+// when its error exceeds the growth threshold -dim*ln(spreadFactor), where dim is data dimension,
+// the BMU grows new units into all free cells which neighbour it in its row or column. New units
+// extrapolate codebook vectors of the BMU and its neighbour on the opposite side if there is one.
+// High error units which have no free neighbour cell add GSOMDistribution of their errors to
+// their neighbours instead. The growth stops when the map has as many units as data samples.
+// Units are updated with tc learning rate, radius and neighbourhood function in the same way as in sequential training.
+// The smoothing phase finally trains the grown map by tc algorithm with half of the learning rate and
+// GSOMSmoothRadius initial radius, running the same number of iterations as TrainRelational.
+// Data features should be scaled to the unit range so the threshold is meaningful.
+// The returned map has rectangle units in a masked grid: Grid().Mask() marks the cells of its
+// bounding grid which hold units. TrainGSOM returns error if data is nil, if spreadFactor is not
+// between 0 and 1, if tc is invalid or its algorithm is not seq or batch or if the training fails.
+func TrainGSOM(data *mat64.Dense, spreadFactor float64, tc *TrainConfig) (*Map, error) {
+	return trainGSOM(data, spreadFactor, tc, trainRand(tc))
+}
+
+// trainGSOM trains Growing SOM using r as the source of randomness of training
+func trainGSOM(data *mat64.Dense, spreadFactor float64, tc *TrainConfig, r *rand.Rand) (*Map, error) {
+	// data can't be nil
+	if data == nil {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	if !(spreadFactor > 0 && spreadFactor < 1) {
+		return nil, fmt.Errorf("invalid spread factor: %f, must be between 0 and 1", spreadFactor)
+	}
+	if err := validateTrainConfig(tc); err != nil {
+		return nil, err
+	}
+	if tc.Algorithm != Seq && tc.Algorithm != Batch {
+		return nil, fmt.Errorf("%w: %s, gsom training supports seq and batch", ErrUnsupportedMethod, tc.Algorithm)
+	}
+	rows, cols := data.Dims()
+	threshold := -float64(cols) * math.Log(spreadFactor)
+	units := make([]gsomUnit, 0, 4)
+	for _, p := range [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		vec := append([]float64(nil), data.RawRowView(r.Intn(rows))...)
+		units = append(units, gsomUnit{row: p[0], col: p[1], vec: vec})
+	}
+	// growing phase radius of automatic radius configuration is derived from the initial map
+	gc := *tc
+	if gc.AutoRadius {
+		gc.Radius, gc.AutoRadius = autoRadius([]int{2, 2}), false
+	}
+	m, cells := gsomMap(units)
+	t, err := newSeqTrainer(m, &gc)
+	if err != nil {
+		return nil, err
+	}
+	iters := GSOMGrowEpochs * rows
+	for epoch := 0; epoch < GSOMGrowEpochs; epoch++ {
+		for k, s := range r.Perm(rows) {
+			sample := data.RawRowView(s)
+			// no need to check for error: sample and codebook have the same dimension
+			bmu, d, _ := m.BMU(sample)
+			t.update(epoch*rows+k, iters, bmu, sample)
+			units[bmu].err += d * d
+			if units[bmu].err <= threshold {
+				continue
+			}
+			grown := gsomGrow(units, cells, bmu, threshold, rows, m)
+			if grown == nil {
+				continue
+			}
+			t.close()
+			units = grown
+			m, cells = gsomMap(units)
+			if t, err = newSeqTrainer(m, &gc); err != nil {
+				return nil, err
+			}
+		}
+	}
+	t.close()
+	// smoothing phase fine tunes the grown map
+	sc := *tc
+	sc.Radius, sc.AutoRadius, sc.RSchedule, sc.Phases = GSOMSmoothRadius, false, nil, nil
+	_, lRate := tc.initial(1)
+	sc.LRate, sc.LSchedule = lRate/2, nil
+	if _, err := m.train(&sc, data, relIters(&sc, rows), r); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// gsomGrow handles error of unit bmu which exceeds threshold. If the unit has free neighbour cells
+// and the map has fewer than maxUnits units, it returns units with new units grown into the free cells;
+// codebook vectors of the existing units are synced from map m. Otherwise the unit error is distributed
+// to its neighbours and gsomGrow returns nil. Error of unit bmu is halved threshold in both cases.
+func gsomGrow(units []gsomUnit, cells map[[2]int]int, bmu int, threshold float64, maxUnits int, m *Map) []gsomUnit {
+	u := units[bmu]
+	units[bmu].err = threshold / 2
+	var free, taken [][2]int
+	for _, off := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		p := [2]int{u.row + off[0], u.col + off[1]}
+		if _, ok := cells[p]; ok {
+			taken = append(taken, p)
+		} else {
+			free = append(free, p)
+		}
+	}
+	if len(free) == 0 || len(units) >= maxUnits {
+		for _, p := range taken {
+			units[cells[p]].err *= 1 + GSOMDistribution
+		}
+		return nil
+	}
+	cb := m.cb()
+	grown := make([]gsomUnit, len(units), len(units)+len(free))
+	for i := range units {
+		grown[i] = units[i]
+		grown[i].vec = append([]float64(nil), cb.RawRowView(i)...)
+	}
+	bmuVec := grown[bmu].vec
+	for _, p := range free {
+		vec := append([]float64(nil), bmuVec...)
+		// extrapolate from the neighbour on the opposite side
+		if opp, ok := cells[[2]int{2*u.row - p[0], 2*u.col - p[1]}]; ok {
+			for j := range vec {
+				vec[j] = 2*bmuVec[j] - grown[opp].vec[j]
+			}
+		}
+		grown = append(grown, gsomUnit{row: p[0], col: p[1], vec: vec})
+	}
+	return grown
+}
+
+// gsomMap sorts units column by column and returns map of the units in masked grid
+// of their bounding box and lattice cells of the units
+func gsomMap(units []gsomUnit) (*Map, map[[2]int]int) {
+	sort.Slice(units, func(i, j int) bool {
+		if units[i].col != units[j].col {
+			return units[i].col < units[j].col
+		}
+		return units[i].row < units[j].row
+	})
+	minRow, maxRow, minCol, maxCol := units[0].row, units[0].row, units[0].col, units[len(units)-1].col
+	for _, u := range units {
+		if u.row < minRow {
+			minRow = u.row
+		}
+		if u.row > maxRow {
+			maxRow = u.row
+		}
+	}
+	gridRows := maxRow - minRow + 1
+	cb := mat64.NewDense(len(units), len(units[0].vec), nil)
+	coords := mat64.NewDense(len(units), 2, nil)
+	gridCells := make([]int, len(units))
+	cells := make(map[[2]int]int, len(units))
+	for i, u := range units {
+		cb.SetRow(i, u.vec)
+		coords.Set(i, 0, float64(u.col-minCol))
+		coords.Set(i, 1, float64(u.row-minRow))
+		gridCells[i] = (u.col-minCol)*gridRows + u.row - minRow
+		cells[[2]int{u.row, u.col}] = i
+	}
+	// grids whose cells all hold a unit are not masked
+	if len(units) == gridRows*(maxCol-minCol+1) {
+		gridCells = nil
+	}
+	grid := &Grid{
+		size:   []int{gridRows, maxCol - minCol + 1},
+		ushape: string(Rectangle),
+		gtype:  string(Planar),
+		coords: coords,
+		cells:  gridCells,
+	}
+	return &Map{codebook: cb, grid: grid, norms: sqNorms(cb)}, cells
+}
+
+// GSOM is growing grid training: sequential training of a small initial planar grid which grows
+// during the first half of training iterations. At the end of every epoch of the growing phase,
+// which has as many iterations as there are data samples, the unit with the highest mean quantization
+// error of the epoch samples is found and if the error exceeds TrainConfig.GrowThreshold, a grid row or
+// column is inserted between the unit and its direct grid neighbour with the farthest codebook vector.
+// Codebook vectors of the inserted units are means of their neighbours in the row or column insertion
+// direction. The grid stops growing when it would have more units than data samples.
+const GSOM Method = "gsom"
+
+// gsomTrain runs growing grid training on a given data set
+func (m *Map) gsomTrain(ctx context.Context, tc *TrainConfig, data mat64.Matrix, iters int, r *rand.Rand) error {
+	if m.grid.gtype != string(Planar) || len(m.grid.size) != 2 || m.grid.cells != nil {
+		return fmt.Errorf("%w: %s %v, gsom training grows 2D planar grids without mask", ErrUnsupportedGrid, m.grid.gtype, m.grid.size)
+	}
+	rows, _ := data.Dims()
+	t, err := newSeqTrainer(m, tc)
+	if err != nil {
+		return err
+	}
+	defer func() { t.close() }()
+	log := trainLogger(tc)
+	rd := newRowReader(data)
+	units, _ := m.cbDims()
+	t.metrics = newTrainMetrics(m, tc, units, iters, rows)
+	// errs and hits accumulate BMU distances and samples of units in the current epoch
+	errs, hits := make([]float64, units), make([]int, units)
+	for i := 0; i < iters; i++ {
+		if err := canceled(ctx, i); err != nil {
+			return err
+		}
+		logProgress(log, tc, i, iters)
+		sample := rd.row(r.Intn(rows))
+		m.profile.start()
+		bmu, dist := t.bmu(sample)
+		t.metrics.sample(bmu, dist)
+		m.profile.lap(profileBMU)
+		t.update(i, iters, bmu, sample)
+		errs[bmu] += dist
+		hits[bmu]++
+		if !t.metrics.update(i) {
+			break
+		}
+		// the grid grows at the end of epochs of the first half of training
+		if (i+1)%rows != 0 || i >= iters/2 {
+			continue
+		}
+		worst, worstErr := -1, tc.GrowThreshold
+		for u := range errs {
+			if hits[u] > 0 && errs[u]/float64(hits[u]) > worstErr {
+				worst, worstErr = u, errs[u]/float64(hits[u])
+			}
+		}
+		if worst >= 0 && units+max(m.grid.size[0], m.grid.size[1]) <= rows {
+			t.close()
+			if err := m.growGrid(worst); err != nil {
+				return err
+			}
+			metrics := t.metrics
+			if t, err = newSeqTrainer(m, tc); err != nil {
+				return err
+			}
+			units, _ = m.cbDims()
+			t.metrics = metrics
+			t.metrics.resize(units)
+			log.Printf("som: %s training: iteration %d/%d: grid grown to %v", tc.Algorithm, i+1, iters, m.grid.size)
+		}
+		errs, hits = make([]float64, units), make([]int, units)
+	}
+	m.epochs = t.metrics
+
+	return nil
+}
+
+// growGrid inserts a grid row or column between unit u and its direct grid neighbour whose codebook
+// vector is the farthest from the unit codebook vector. The map grid is rebuilt and unit state
+// assigned to units of the previous grid, such as labels and clusters, is discarded.
+func (m *Map) growGrid(u int) error {
+	rows, cols := m.grid.size[0], m.grid.size[1]
+	row, col := u%rows, u/rows
+	cb := m.cb()
+	// atRow or atCol is the index of the inserted row or column; the other one is -1
+	atRow, atCol, farthest := -1, -1, -1.0
+	for _, off := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		r, c := row+off[0], col+off[1]
+		if r < 0 || r >= rows || c < 0 || c >= cols {
+			continue
+		}
+		if d := m.unitDistance(cb.RawRowView(u), c*rows+r); d > farthest {
+			atRow, atCol, farthest = -1, -1, d
+			if off[0] != 0 {
+				atRow = max(row, r)
+			} else {
+				atCol = max(col, c)
+			}
+		}
+	}
+	newRows, newCols := rows, cols
+	if atRow >= 0 {
+		newRows++
+	} else {
+		newCols++
+	}
+	grid, err := NewGrid(&GridConfig{
+		Size:         []int{newRows, newCols},
+		Type:         Planar,
+		UShape:       UnitShape(m.grid.ushape),
+		Connectivity: Connectivity(m.grid.connectivity),
+	})
+	if err != nil {
+		return err
+	}
+	_, dim := cb.Dims()
+	grown := mat64.NewDense(newRows*newCols, dim, nil)
+	for c := 0; c < newCols; c++ {
+		c1, c2 := growSrc(c, atCol)
+		for r := 0; r < newRows; r++ {
+			r1, r2 := growSrc(r, atRow)
+			vec := grown.RawRowView(c*newRows + r)
+			a, b := cb.RawRowView(c1*rows+r1), cb.RawRowView(c2*rows+r2)
+			for j := range vec {
+				vec[j] = (a[j] + b[j]) / 2
+			}
+		}
+	}
+	if m.cb32 != nil {
+		m.cb32 = newCodebook32(grown)
+	} else {
+		m.codebook = grown
+	}
+	m.grid = grid
+	m.norms = m.cbNorms()
+	m.labels, m.purity, m.targets = nil, nil, nil
+	m.classes, m.classDist, m.clusters = nil, nil, nil
+	return nil
+}
+
+// growSrc returns indices of the previous grid rows or columns whose units are averaged into
+// units of i-th row or column of grown grid with a row or column inserted at index at.
+// If at is negative, no row or column was inserted.
+func growSrc(i, at int) (int, int) {
+	switch {
+	case at < 0 || i < at:
+		return i, i
+	case i == at:
+		return at - 1, at
+	default:
+		return i - 1, i - 1
+	}
+}
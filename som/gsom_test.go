@@ -0,0 +1,234 @@
+package som
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// gsomTrainConfig returns training configuration of GSOM tests
+func gsomTrainConfig() *TrainConfig {
+	return &TrainConfig{
+		Algorithm: Seq,
+		Radius:    2.0,
+		RDecay:    ExpDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.3,
+		LDecay:    ExpDecay,
+	}
+}
+
+// unitBlobs returns blobs scaled to the unit square
+func unitBlobs(n int, seed int64) *mat64.Dense {
+	data := makeBlobs(n, 5, 1.0, seed)
+	rows, _ := data.Dims()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < 2; j++ {
+			data.Set(i, j, (data.At(i, j)+13)/26)
+		}
+	}
+	return data
+}
+
+func TestTrainGSOMSpread(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(300, 1)
+	prev := 0
+	for _, sf := range []float64{0.1, 0.5, 0.9, 0.99} {
+		m, err := trainGSOM(data, sf, gsomTrainConfig(), rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		units, _ := m.CodebookRaw().Dims()
+		assert.True(units > prev, "spread factor %f: %d units, previous %d units", sf, units, prev)
+		prev = units
+	}
+}
+
+func TestGSOMGrid(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(300, 1)
+	m, err := trainGSOM(data, 0.9, gsomTrainConfig(), rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	units, _ := m.CodebookRaw().Dims()
+	dims := m.Dims()
+	mask := m.Grid().Mask()
+	assert.Len(mask, dims[0]*dims[1])
+	filled := 0
+	for cell, ok := range mask {
+		row, col := cell%dims[0], cell/dims[0]
+		idx, err := m.UnitIndex(row, col)
+		if !ok {
+			assert.EqualError(err, fmt.Sprintf("invalid unit position: row %d, col %d, no unit in grid cell", row, col))
+			continue
+		}
+		assert.NoError(err)
+		assert.Equal(filled, idx)
+		r, c, err := m.UnitRC(idx)
+		assert.NoError(err)
+		assert.Equal([]int{row, col}, []int{r, c})
+		filled++
+	}
+	assert.Equal(units, filled)
+	assert.True(units < dims[0]*dims[1])
+	_, _, err = m.UnitRC(units)
+	assert.EqualError(err, fmt.Sprintf("invalid unit index: %d", units))
+	// map quality and u-matrix use the units in their grid cells
+	te, err := m.TopoError(data)
+	assert.NoError(err)
+	assert.True(te < 0.5, "topographic error: %f", te)
+	qe, err := m.QuantError(data)
+	assert.NoError(err)
+	assert.True(qe < 0.05, "quantization error: %f", qe)
+	var b bytes.Buffer
+	assert.NoError(m.UMatrix(&b, data, nil, "svg", "gsom"))
+	assert.Equal(units, bytes.Count(b.Bytes(), []byte("<polygon")))
+}
+
+func TestGSOMGrow(t *testing.T) {
+	assert := assert.New(t)
+
+	units := []gsomUnit{
+		{row: 0, col: 0, vec: []float64{0.0}, err: 3.0},
+		{row: 0, col: 1, vec: []float64{1.0}, err: 1.0},
+	}
+	m, cells := gsomMap(units)
+	assert.Nil(m.Grid().Mask())
+	grown := gsomGrow(units, cells, 1, 2.0, 10, m)
+	assert.Len(grown, 5)
+	m, _ = gsomMap(grown)
+	assert.Equal([]int{3, 3}, m.Dims())
+	assert.Equal([]bool{false, true, false, true, true, true, false, true, false}, m.Grid().Mask())
+	// the unit grown away from its neighbour extrapolates their codebook vectors
+	assert.Equal([]float64{0.0, 1.0, 1.0, 1.0, 2.0}, m.CodebookRaw().RawMatrix().Data)
+	// grown units are sorted by grid cells: the high error unit error is halved threshold
+	assert.Equal(1.0, grown[2].err)
+	// units without free cells distribute their errors
+	units = grown[:0:0]
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			units = append(units, gsomUnit{row: r, col: c, vec: []float64{0.0}, err: 1.0})
+		}
+	}
+	m, cells = gsomMap(units)
+	assert.Nil(gsomGrow(units, cells, 4, 2.0, 10, m))
+	assert.Equal(1.0, units[4].err)
+	assert.Equal(1.5, units[1].err)
+	assert.Equal(1.0, units[0].err)
+}
+
+func TestTrainGSOMErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(20, 1)
+	_, err := TrainGSOM(nil, 0.5, gsomTrainConfig())
+	assert.EqualError(err, fmt.Sprintf("invalid data supplied: %v", nil))
+	for _, sf := range []float64{0.0, 1.0, -0.5} {
+		_, err = TrainGSOM(data, sf, gsomTrainConfig())
+		assert.EqualError(err, fmt.Sprintf("invalid spread factor: %f, must be between 0 and 1", sf))
+	}
+	_, err = TrainGSOM(data, 0.5, nil)
+	assert.Error(err)
+	tc := gsomTrainConfig()
+	tc.Algorithm = NeuralGas
+	_, err = TrainGSOM(data, 0.5, tc)
+	assert.True(errors.Is(err, ErrUnsupportedMethod))
+	tc.Algorithm = Batch
+	m, err := TrainGSOM(data, 0.5, tc)
+	assert.NoError(err)
+	assert.True(m.HasTopology())
+}
+
+// growingMap returns 2x2 planar map of data with rectangle units
+func growingMap(data *mat64.Dense, gridType GridType) (*Map, error) {
+	return NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{2, 2}, Type: gridType, UShape: Rectangle},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+}
+
+func TestTrainGrowingGrid(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(300, 1)
+	prev, prevErr := 4, 1.0
+	for _, threshold := range []float64{0.2, 0.1, 0.05} {
+		m, err := growingMap(data, Planar)
+		assert.NoError(err)
+		tc := gsomTrainConfig()
+		tc.Algorithm = GSOM
+		tc.GrowThreshold = threshold
+		_, err = m.train(tc, data, 6000, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		size := m.Grid().Size()
+		units, _ := m.CodebookRaw().Dims()
+		assert.Equal(size[0]*size[1], units)
+		assert.Equal(units, m.Grid().Coords().(*mat64.Dense).RawMatrix().Rows)
+		assert.True(units > prev, "threshold %f: %d units, previous %d units", threshold, units, prev)
+		qErr, err := m.QuantError(data)
+		assert.NoError(err)
+		assert.True(qErr < prevErr, "threshold %f: quantization error %f, previous %f", threshold, qErr, prevErr)
+		prev, prevErr = units, qErr
+	}
+
+	// high threshold keeps the grid
+	m, err := growingMap(data, Planar)
+	assert.NoError(err)
+	tc := gsomTrainConfig()
+	tc.Algorithm = GSOM
+	tc.GrowThreshold = 10.0
+	_, err = m.train(tc, data, 3000, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	assert.Equal([]int{2, 2}, m.Grid().Size())
+
+	// growing grid needs a threshold and planar grid
+	tc.GrowThreshold = 0.0
+	_, err = m.train(tc, data, 3000, rand.New(rand.NewSource(1)))
+	assert.EqualError(err, "invalid grow threshold: 0.000000, must be positive")
+	tc.GrowThreshold = 0.1
+	m, err = growingMap(data, Toroidal)
+	assert.NoError(err)
+	_, err = m.train(tc, data, 3000, rand.New(rand.NewSource(1)))
+	assert.True(errors.Is(err, ErrUnsupportedGrid))
+}
+
+func TestGrowGrid(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := growingMap(mat64.NewDense(4, 2, nil), Planar)
+	assert.NoError(err)
+	m.codebook = mat64.NewDense(4, 2, []float64{
+		0, 0,
+		0, 1,
+		1, 0,
+		4, 4,
+	})
+	m.labels = []string{"a", "b", "c", "d"}
+	// unit 2 at row 0 col 1 is the farthest from unit 3 below it: a row is inserted
+	assert.NoError(m.growGrid(3))
+	assert.Equal([]int{3, 2}, m.Grid().Size())
+	assert.Equal([]float64{0, 0, 0, 0.5, 0, 1, 1, 0, 2.5, 2, 4, 4}, m.codebook.RawMatrix().Data)
+	assert.Nil(m.labels)
+	idx, err := m.UnitIndex(1, 1)
+	assert.NoError(err)
+	assert.Equal([]float64{2.5, 2}, m.codebook.RawRowView(idx))
+
+	// unit 0 is the farthest from unit 3 in the next column: a column is inserted
+	m.codebook = mat64.NewDense(6, 2, []float64{
+		0, 0,
+		0, 0,
+		0, 0,
+		2, 0,
+		0, 0,
+		0, 0,
+	})
+	assert.NoError(m.growGrid(0))
+	assert.Equal([]int{3, 3}, m.Grid().Size())
+	assert.Equal([]float64{1, 0}, m.codebook.RawRowView(3))
+	assert.Equal([]float64{2, 0}, m.codebook.RawRowView(6))
+}
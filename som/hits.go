@@ -0,0 +1,46 @@
+package som
+
+import "github.com/gonum/matrix/mat64"
+
+// HitMap returns hit histogram of data: the number of data rows mapped to every map unit.
+// Units without hits are dead units which don't represent any data, units with few hits
+// lie in sparse data regions. BMUs are found in parallel in the same way as Predict.
+// It returns error if data is nil, if any data row has no observed values or ErrDimMismatch
+// if data and codebook dimensions differ.
+func (m Map) HitMap(data mat64.Matrix) ([]int, error) {
+	bmus, _, err := m.Predict(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	units, _ := m.cbDims()
+	hits := make([]int, units)
+	for _, bmu := range bmus {
+		hits[bmu]++
+	}
+	return hits, nil
+}
+
+// QErrorMap returns quantization error map of data: the mean distance of data rows from the codebook
+// vector of every map unit which is their BMU. Units are ordered in the same way as HitMap units,
+// so the values can be rendered in the map grid like u-matrix. Units with high errors represent
+// their data poorly, which may call for a bigger map or longer training; units without hits have
+// NaN errors. BMUs are found in parallel in the same way as Predict.
+// It returns error if data is nil, if any data row has no observed values or ErrDimMismatch
+// if data and codebook dimensions differ.
+func (m Map) QErrorMap(data mat64.Matrix) ([]float64, error) {
+	bmus, dists, err := m.Predict(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	units, _ := m.cbDims()
+	errs := make([]float64, units)
+	hits := make([]int, units)
+	for i, bmu := range bmus {
+		errs[bmu] += dists[i]
+		hits[bmu]++
+	}
+	for i := range errs {
+		errs[i] /= float64(hits[i])
+	}
+	return errs, nil
+}
@@ -0,0 +1,87 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHitMap(t *testing.T) {
+	assert := assert.New(t)
+
+	data := mat64.NewDense(6, 2, []float64{
+		0, 0,
+		0.1, 0,
+		0, 0.1,
+		5, 5,
+		5.1, 5,
+		9, 9,
+	})
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{2, 2}, Type: Planar, UShape: Rectangle},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	m.codebook = mat64.NewDense(4, 2, []float64{
+		0, 0,
+		5, 5,
+		9, 9,
+		-9, -9,
+	})
+	m.norms = sqNorms(m.codebook)
+	hits, err := m.HitMap(data)
+	assert.NoError(err)
+	// unit 3 is a dead unit
+	assert.Equal([]int{3, 2, 1, 0}, hits)
+	// nil data
+	_, err = m.HitMap(nil)
+	assert.EqualError(err, fmt.Sprintf("invalid data supplied: %v", nil))
+	// dimension mismatch
+	_, err = m.HitMap(mat64.NewDense(2, 3, nil))
+	assert.Equal(ErrDimMismatch, err)
+}
+
+func TestQErrorMap(t *testing.T) {
+	assert := assert.New(t)
+
+	data := mat64.NewDense(5, 2, []float64{
+		0, 0,
+		0.2, 0,
+		0, 0.4,
+		5, 6,
+		9, 9,
+	})
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{2, 2}, Type: Planar, UShape: Rectangle},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	m.codebook = mat64.NewDense(4, 2, []float64{
+		0, 0,
+		5, 5,
+		9, 9,
+		-9, -9,
+	})
+	m.norms = sqNorms(m.codebook)
+	errs, err := m.QErrorMap(data)
+	assert.NoError(err)
+	assert.Len(errs, 4)
+	assert.InDelta(0.2, errs[0], 1e-9)
+	assert.InDelta(1.0, errs[1], 1e-9)
+	assert.Equal(0.0, errs[2])
+	// unit 3 is a dead unit
+	assert.True(math.IsNaN(errs[3]))
+	// mean of unit errors weighted by hits is the quantization error
+	qe, err := m.QuantError(data)
+	assert.NoError(err)
+	assert.InDelta(qe, (3*errs[0]+errs[1]+errs[2])/5, 1e-9)
+	// nil data
+	_, err = m.QErrorMap(nil)
+	assert.EqualError(err, fmt.Sprintf("invalid data supplied: %v", nil))
+	// dimension mismatch
+	_, err = m.QErrorMap(mat64.NewDense(2, 3, nil))
+	assert.Equal(ErrDimMismatch, err)
+}
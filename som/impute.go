@@ -0,0 +1,206 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	// DefaultImputeK is the number of best matching units of knn imputation
+	DefaultImputeK = 3
+	// MaxImputeIters is the maximum number of iterations of iterative imputation of a data row
+	MaxImputeIters = 20
+)
+
+// ImputeReport describes imputation of a single missing data cell
+type ImputeReport struct {
+	// Row is the data row of the imputed cell
+	Row int
+	// Col is the data column of the imputed cell
+	Col int
+	// Value is the imputed value
+	Value float64
+	// Units are the map units which supplied the value: the BMU or k BMUs of knn imputation
+	Units []int
+	// Dist is the distance of the observed row features to the BMU. It is a confidence proxy:
+	// the smaller the distance, the better the map represents the row.
+	Dist float64
+}
+
+// ImputeMatrix returns a copy of data with missing values, which are stored as NaN, imputed from the map
+// codebook and the reports of all imputed cells ordered by data rows and columns. BMUs of data rows are
+// found using the observed row features only. Imputation strategy is one of:
+//
+//	bmu: missing values are copied from the BMU codebook vector
+//	knn: missing values are averages of DefaultImputeK best matching unit codebook vectors weighted
+//	     by their inverse distances to the row
+//	iterative: missing values start at column means of the observed values; the BMU of the whole
+//	     row is found and missing values are copied from its codebook vector until the BMU does not
+//	     change or MaxImputeIters iterations run
+//
+// ImputeMatrix returns error if data is nil, if strategy is not supported, if any data row has
+// no observed values or ErrDimMismatch if data dimension is different from the codebook dimension.
+func (m Map) ImputeMatrix(data *mat64.Dense, strategy string) (*mat64.Dense, []ImputeReport, error) {
+	// data can't be nil
+	if data == nil {
+		return nil, nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	if !imputeStrategies.Has(strategy) {
+		return nil, nil, fmt.Errorf("unsupported imputation strategy: %s", strategy)
+	}
+	rows, cols := data.Dims()
+	units, cbCols := m.cbDims()
+	if cols != cbCols {
+		return nil, nil, ErrDimMismatch
+	}
+	out := mat64.DenseCopyOf(data)
+	observed := make([][]bool, rows)
+	for i := 0; i < rows; i++ {
+		observed[i] = make([]bool, cols)
+		count := 0
+		for j, v := range out.RawRowView(i) {
+			if !math.IsNaN(v) {
+				observed[i][j] = true
+				count++
+			}
+		}
+		if count == 0 {
+			return nil, nil, fmt.Errorf("invalid data row %d: no observed values", i)
+		}
+	}
+	cb := m.cb()
+	var means []float64
+	if strategy == "iterative" {
+		means = imputeMeans(out, cb)
+	}
+	k := DefaultImputeK
+	if k > units {
+		k = units
+	}
+	var reports []ImputeReport
+	for i := 0; i < rows; i++ {
+		row, obs := out.RawRowView(i), observed[i]
+		first := len(reports)
+		for j := range row {
+			if !obs[j] {
+				reports = append(reports, ImputeReport{Row: i, Col: j})
+			}
+		}
+		// complete rows need no imputation
+		if first == len(reports) {
+			continue
+		}
+		bmus, dists := closestObserved(cb, row, obs, k)
+		cells := reports[first:]
+		switch strategy {
+		case "bmu":
+			for c := range cells {
+				cells[c].Value = cb.At(bmus[0], cells[c].Col)
+				cells[c].Units = []int{bmus[0]}
+			}
+		case "knn":
+			for c := range cells {
+				cells[c].Value = weightedUnits(cb, bmus, dists, cells[c].Col)
+				cells[c].Units = append([]int(nil), bmus...)
+			}
+		case "iterative":
+			for c := range cells {
+				row[cells[c].Col] = means[cells[c].Col]
+			}
+			bmu := -1
+			for iter := 0; iter < MaxImputeIters; iter++ {
+				next, _ := closestObserved(cb, row, nil, 1)
+				if next[0] == bmu {
+					break
+				}
+				bmu = next[0]
+				for c := range cells {
+					row[cells[c].Col] = cb.At(bmu, cells[c].Col)
+				}
+			}
+			// distance of the observed features to the final BMU
+			d := 0.0
+			for j, v := range row {
+				if obs[j] {
+					d += (v - cb.At(bmu, j)) * (v - cb.At(bmu, j))
+				}
+			}
+			dists[0] = math.Sqrt(d)
+			for c := range cells {
+				cells[c].Value = row[cells[c].Col]
+				cells[c].Units = []int{bmu}
+			}
+		}
+		for c := range cells {
+			cells[c].Dist = dists[0]
+			row[cells[c].Col] = cells[c].Value
+		}
+	}
+	return out, reports, nil
+}
+
+// imputeMeans returns column means of observed data values.
+// Columns with no observed value use the mean of codebook column.
+func imputeMeans(data, cb *mat64.Dense) []float64 {
+	rows, cols := data.Dims()
+	means := make([]float64, cols)
+	for j := range means {
+		sum, count := 0.0, 0
+		for i := 0; i < rows; i++ {
+			if v := data.At(i, j); !math.IsNaN(v) {
+				sum += v
+				count++
+			}
+		}
+		if count == 0 {
+			units, _ := cb.Dims()
+			for i := 0; i < units; i++ {
+				sum += cb.At(i, j)
+			}
+			count = units
+		}
+		means[j] = sum / float64(count)
+	}
+	return means
+}
+
+// closestObserved returns indices of k codebook vectors closest to x and their distances to x
+// sorted by distance in ascending order. Only features marked in observed are compared;
+// if observed is nil, all features are compared.
+func closestObserved(cb *mat64.Dense, x []float64, observed []bool, k int) ([]int, []float64) {
+	units, _ := cb.Dims()
+	idx := make([]int, units)
+	dists := make([]float64, units)
+	for i := range idx {
+		d := 0.0
+		for j, v := range cb.RawRowView(i) {
+			if observed == nil || observed[j] {
+				d += (x[j] - v) * (x[j] - v)
+			}
+		}
+		idx[i], dists[i] = i, math.Sqrt(d)
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return dists[idx[a]] < dists[idx[b]] })
+	closest := make([]float64, k)
+	for i := range closest {
+		closest[i] = dists[idx[i]]
+	}
+	return idx[:k], closest
+}
+
+// weightedUnits returns the average of values of codebook column col of units weighted by inverse
+// distances dists. If any of the distances is zero, the value of the matched unit is returned.
+func weightedUnits(cb *mat64.Dense, units []int, dists []float64, col int) float64 {
+	sum, weights := 0.0, 0.0
+	for i, u := range units {
+		if dists[i] == 0.0 {
+			return cb.At(u, col)
+		}
+		sum += cb.At(u, col) / dists[i]
+		weights += 1.0 / dists[i]
+	}
+	return sum / weights
+}
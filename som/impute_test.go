@@ -0,0 +1,148 @@
+package som
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeCurve generates n noisy samples of 3D curve (t, t^2, sin(3t)) with t uniform in [-1, 1]
+func makeCurve(n int, seed int64) *mat64.Dense {
+	r := rand.New(rand.NewSource(seed))
+	data := mat64.NewDense(n, 3, nil)
+	for i := 0; i < n; i++ {
+		t := 2*r.Float64() - 1
+		data.SetRow(i, []float64{
+			t + r.NormFloat64()*0.02,
+			t*t + r.NormFloat64()*0.02,
+			math.Sin(3*t) + r.NormFloat64()*0.02,
+		})
+	}
+	return data
+}
+
+func TestImputeMatrix(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeCurve(500, 1)
+	mc := &MapConfig{
+		Grid: &GridConfig{Size: []int{1, 20}, Type: Planar, UShape: Rectangle},
+		Cb:   &CbConfig{Dim: 3, InitFunc: LinInit},
+	}
+	tc := &TrainConfig{
+		Algorithm: Seq,
+		Radius:    5.0,
+		RDecay:    ExpDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    ExpDecay,
+	}
+	m, err := NewMap(mc, data)
+	assert.NoError(err)
+	_, err = m.train(tc, data, 10000, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+
+	// mask one random feature in every other row of fresh samples
+	truth := makeCurve(200, 2)
+	masked := mat64.DenseCopyOf(truth)
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 200; i += 2 {
+		masked.Set(i, r.Intn(3), math.NaN())
+	}
+	// imputing column means gives RMSE equal to the column standard deviation
+	means := imputeMeans(masked, m.CodebookRaw())
+	meanSSE := 0.0
+	for i := 0; i < 200; i += 2 {
+		for j := 0; j < 3; j++ {
+			if math.IsNaN(masked.At(i, j)) {
+				meanSSE += (means[j] - truth.At(i, j)) * (means[j] - truth.At(i, j))
+			}
+		}
+	}
+	baseline := math.Sqrt(meanSSE / 100)
+	for _, strategy := range SupportedImputeStrategies() {
+		out, reports, err := m.ImputeMatrix(masked, strategy)
+		assert.NoError(err)
+		assert.Len(reports, 100)
+		sse := 0.0
+		for k, rep := range reports {
+			assert.Equal(2*k, rep.Row)
+			assert.True(math.IsNaN(masked.At(rep.Row, rep.Col)))
+			assert.Equal(rep.Value, out.At(rep.Row, rep.Col))
+			assert.True(rep.Dist >= 0)
+			if strategy == "knn" {
+				assert.Len(rep.Units, DefaultImputeK)
+			} else {
+				assert.Len(rep.Units, 1)
+			}
+			sse += (rep.Value - truth.At(rep.Row, rep.Col)) * (rep.Value - truth.At(rep.Row, rep.Col))
+		}
+		rmse := math.Sqrt(sse / 100)
+		assert.True(rmse < baseline/3, "%s: rmse %f, baseline %f", strategy, rmse, baseline)
+		// observed values are not changed
+		for i := 1; i < 200; i += 2 {
+			assert.Equal(truth.RawRowView(i), out.RawRowView(i))
+		}
+	}
+	// input data is not modified
+	missing := 0
+	for _, v := range masked.RawMatrix().Data {
+		if math.IsNaN(v) {
+			missing++
+		}
+	}
+	assert.Equal(100, missing)
+}
+
+func TestImputeMatrixBMU(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{1, 2}, Type: Planar, UShape: Rectangle},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, mat64.NewDense(2, 2, []float64{0.0, 0.0, 1.0, 1.0}))
+	assert.NoError(err)
+	m.codebook = mat64.NewDense(2, 2, []float64{0.0, 10.0, 4.0, 20.0})
+	data := mat64.NewDense(2, 2, []float64{3.0, math.NaN(), 1.0, 5.0})
+	out, reports, err := m.ImputeMatrix(data, "bmu")
+	assert.NoError(err)
+	assert.Equal([]float64{3.0, 20.0, 1.0, 5.0}, out.RawMatrix().Data)
+	assert.Equal([]ImputeReport{{Row: 0, Col: 1, Value: 20.0, Units: []int{1}, Dist: 1.0}}, reports)
+	// knn uses both units weighted by inverse distances 1/3 and 1
+	_, reports, err = m.ImputeMatrix(data, "knn")
+	assert.NoError(err)
+	assert.Equal([]int{1, 0}, reports[0].Units)
+	assert.InDelta(17.5, reports[0].Value, 1e-12)
+	// column mean 5 moves the row to the first unit
+	_, reports, err = m.ImputeMatrix(data, "iterative")
+	assert.NoError(err)
+	assert.Equal([]ImputeReport{{Row: 0, Col: 1, Value: 10.0, Units: []int{0}, Dist: 3.0}}, reports)
+}
+
+func TestImputeMatrixErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{2, 2}, Type: Planar, UShape: Rectangle},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, mat64.NewDense(2, 2, []float64{0.0, 0.0, 1.0, 1.0}))
+	assert.NoError(err)
+	_, _, err = m.ImputeMatrix(nil, "bmu")
+	assert.EqualError(err, fmt.Sprintf("invalid data supplied: %v", nil))
+	_, _, err = m.ImputeMatrix(mat64.NewDense(1, 2, nil), "mean")
+	assert.EqualError(err, "unsupported imputation strategy: mean")
+	_, _, err = m.ImputeMatrix(mat64.NewDense(1, 3, nil), "bmu")
+	assert.True(errors.Is(err, ErrDimMismatch))
+	_, _, err = m.ImputeMatrix(mat64.NewDense(2, 2, []float64{1.0, 2.0, math.NaN(), math.NaN()}), "knn")
+	assert.EqualError(err, "invalid data row 1: no observed values")
+	// complete data needs no imputation
+	out, reports, err := m.ImputeMatrix(mat64.NewDense(1, 2, []float64{1.0, 2.0}), "iterative")
+	assert.NoError(err)
+	assert.Nil(reports)
+	assert.Equal([]float64{1.0, 2.0}, out.RawRowView(0))
+}
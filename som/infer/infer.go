@@ -0,0 +1,389 @@
+// Package infer maps samples by trained Self-Organizing Maps without matrix dependencies.
+//
+// It holds only what inference needs: model loading and Best Match Unit search, anomaly scores
+// and predictions over plain float64 slices. It imports the standard library only, so trained maps
+// can be embedded in small edge binaries. Load reads model files written by som.Map.Save.
+// Targets which can't decode gob, such as TinyGo, create maps from a Model by New, for example
+// from a model encoded as JSON on a host by json.Marshal(m.Model()).
+//
+// Map methods return the same BMUs, distances and scores as the methods of som.Map of the same names.
+package infer
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// formatVersion is the version of the format of model files written by som.Map.Save
+const formatVersion = 1
+
+// ErrDimMismatch is returned when vector dimension does not match the codebook dimension
+var ErrDimMismatch = errors.New("dimension mismatch")
+
+// Scaler scales samples in the same way as som.Scaler: zscore and minmax scalers transform
+// feature j of sample x to (x[j] - Center[j]) / Scale[j] and unit scaler scales samples
+// to unit euclidean norm. Missing values, which are stored as NaN, stay missing.
+type Scaler struct {
+	// Method is scaling method: zscore, minmax, unit
+	Method string `json:"method"`
+	// Center holds subtracted feature values
+	Center []float64 `json:"center,omitempty"`
+	// Scale holds feature divisors
+	Scale []float64 `json:"scale,omitempty"`
+}
+
+// transform stores sample x scaled by the scaler in dst. Samples of zero norm are not scaled
+// by unit scaler.
+func (s *Scaler) transform(dst, x []float64) {
+	if s.Method != "unit" {
+		for j, v := range x {
+			dst[j] = (v - s.Center[j]) / s.Scale[j]
+		}
+		return
+	}
+	norm := 0.0
+	for _, v := range x {
+		if !math.IsNaN(v) {
+			norm += v * v
+		}
+	}
+	if norm == 0 {
+		copy(dst, x)
+		return
+	}
+	norm = math.Sqrt(norm)
+	for j, v := range x {
+		dst[j] = v / norm
+	}
+}
+
+// Model holds the state of a trained map which inference needs. Its fields have the same names
+// as the fields of model files written by som.Map.Save.
+type Model struct {
+	// Dim is codebook vector dimension
+	Dim int `json:"dim"`
+	// Codebook holds codebook vectors of map units in row major order
+	Codebook []float64 `json:"codebook"`
+	// Metric is distance metric: euclidean, manhattan, chebyshev, cosine, correlation.
+	// If it is empty, euclidean distance is used.
+	Metric string `json:"metric,omitempty"`
+	// Relevance holds feature relevances which weight squared feature differences of euclidean
+	// distance; if it is set, Metric is ignored. If it is nil, features are not weighted.
+	Relevance []float64 `json:"relevance,omitempty"`
+	// Scaler scales samples passed to Score and Predict. If it is nil, samples are not scaled.
+	Scaler *Scaler `json:"scaler,omitempty"`
+	// Labels and Clusters hold unit labels and cluster ids; they are nil if units are not labeled or clustered
+	Labels   []string `json:"labels,omitempty"`
+	Clusters []int    `json:"clusters,omitempty"`
+	// Threshold is anomaly threshold if HasThreshold is set
+	Threshold    float64 `json:"threshold,omitempty"`
+	HasThreshold bool    `json:"has_threshold,omitempty"`
+	// Features holds codebook feature names; it is nil if features are not named
+	Features []string `json:"features,omitempty"`
+}
+
+// savedModel is the part of model files written by som.Map.Save which inference reads;
+// gob skips the other fields
+type savedModel struct {
+	// Version is the format version
+	Version int
+	// the other fields hold the fields of Model of the same names
+	Dim          int
+	Codebook     []float64
+	Metric       string
+	Relevance    []float64
+	Scaler       *Scaler
+	Labels       []string
+	Clusters     []int
+	Threshold    float64
+	HasThreshold bool
+	Features     []string
+}
+
+// Map is a trained map which maps samples to its units. It is safe for concurrent use.
+type Map struct {
+	m Model
+	// units is the number of map units
+	units int
+	// dist is distance of samples from codebook vectors
+	dist func(a, b []float64) float64
+}
+
+// New creates map of model m. The map does not copy the model slices, which must not be modified.
+// It returns error if the codebook is empty or its length is not a multiple of Dim, if the metric
+// is not supported or if unit state, relevances or scaler don't match the codebook.
+func New(m Model) (*Map, error) {
+	if m.Dim <= 0 || len(m.Codebook) == 0 || len(m.Codebook)%m.Dim != 0 {
+		return nil, fmt.Errorf("invalid model: codebook of %d values, dimension %d", len(m.Codebook), m.Dim)
+	}
+	units := len(m.Codebook) / m.Dim
+	if (m.Labels != nil && len(m.Labels) != units) || (m.Clusters != nil && len(m.Clusters) != units) {
+		return nil, fmt.Errorf("invalid model: unit state of %d units", units)
+	}
+	if m.Relevance != nil && len(m.Relevance) != m.Dim {
+		return nil, fmt.Errorf("invalid model: %d feature relevances: %w", len(m.Relevance), ErrDimMismatch)
+	}
+	if m.Features != nil && len(m.Features) != m.Dim {
+		return nil, fmt.Errorf("invalid model: %d feature names: %w", len(m.Features), ErrDimMismatch)
+	}
+	if s := m.Scaler; s != nil {
+		switch s.Method {
+		case "zscore", "minmax":
+			if len(s.Center) != m.Dim || len(s.Scale) != m.Dim {
+				return nil, fmt.Errorf("invalid model: scaler: %w", ErrDimMismatch)
+			}
+		case "unit":
+		default:
+			return nil, fmt.Errorf("invalid model: unsupported scaling method: %s", s.Method)
+		}
+	}
+	dist := metric(m.Metric)
+	if dist == nil {
+		return nil, fmt.Errorf("invalid model: unsupported distance metric: %s", m.Metric)
+	}
+	if m.Relevance != nil {
+		relevance := m.Relevance
+		dist = func(a, b []float64) float64 {
+			d := 0.0
+			for j := range a {
+				d += relevance[j] * (a[j] - b[j]) * (a[j] - b[j])
+			}
+			return math.Sqrt(d)
+		}
+	}
+	return &Map{m: m, units: units, dist: dist}, nil
+}
+
+// Load reads model file written by som.Map.Save from r and creates its map.
+// It returns error if the read from r fails, if the model file is corrupted, if its format
+// version is not supported or if New fails, such as for maps with custom distance metrics.
+func Load(r io.Reader) (*Map, error) {
+	s := new(savedModel)
+	if err := gob.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("invalid model: %s", err)
+	}
+	if s.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported model version: %d", s.Version)
+	}
+	return New(Model{
+		Dim:          s.Dim,
+		Codebook:     s.Codebook,
+		Metric:       s.Metric,
+		Relevance:    s.Relevance,
+		Scaler:       s.Scaler,
+		Labels:       s.Labels,
+		Clusters:     s.Clusters,
+		Threshold:    s.Threshold,
+		HasThreshold: s.HasThreshold,
+		Features:     s.Features,
+	})
+}
+
+// Model returns the model of the map
+func (m *Map) Model() Model {
+	return m.m
+}
+
+// Units returns the number of map units
+func (m *Map) Units() int {
+	return m.units
+}
+
+// Dim returns codebook vector dimension
+func (m *Map) Dim() int {
+	return m.m.Dim
+}
+
+// Labels returns unit labels or nil if the units are not labeled
+func (m *Map) Labels() []string {
+	return m.m.Labels
+}
+
+// Clusters returns unit cluster ids or nil if the units are not clustered
+func (m *Map) Clusters() []int {
+	return m.m.Clusters
+}
+
+// AnomalyThreshold returns the map anomaly threshold or NaN if the map has none
+func (m *Map) AnomalyThreshold() float64 {
+	if !m.m.HasThreshold {
+		return math.NaN()
+	}
+	return m.m.Threshold
+}
+
+// unit returns codebook vector of unit i
+func (m *Map) unit(i int) []float64 {
+	return m.m.Codebook[i*m.m.Dim : (i+1)*m.m.Dim]
+}
+
+// BMU returns the index of Best Match Unit codebook vector for vector x and the distance between them.
+// If several codebook vectors of the same distance are found, the index of the first one is returned.
+// Like som.Map.BMU, it expects scaled samples. Missing components of x, which are stored as NaN,
+// don't contribute to the distance. It returns ErrDimMismatch if the dimension of x is different
+// from the codebook dimension or error if x has no observed components; both the index and
+// distance are set to -1 when the error is returned.
+func (m *Map) BMU(x []float64) (int, float64, error) {
+	if len(x) != m.m.Dim {
+		return -1, -1.0, ErrDimMismatch
+	}
+	missing, observed := false, false
+	for _, v := range x {
+		if math.IsNaN(v) {
+			missing = true
+		} else {
+			observed = true
+		}
+	}
+	if !observed {
+		return -1, -1.0, fmt.Errorf("invalid sample: no observed values")
+	}
+	var filled []float64
+	if missing {
+		filled = make([]float64, len(x))
+	}
+	bmu, dist := 0, math.MaxFloat64
+	for i := 0; i < m.units; i++ {
+		u := m.unit(i)
+		s := x
+		// missing components are filled from the codebook vector, so they don't contribute to the distance
+		if missing {
+			for j, v := range x {
+				if math.IsNaN(v) {
+					v = u[j]
+				}
+				filled[j] = v
+			}
+			s = filled
+		}
+		if d := m.dist(s, u); d < dist {
+			bmu, dist = i, d
+		}
+	}
+	return bmu, dist, nil
+}
+
+// scaled returns x scaled by the map scaler or x if the map has no scaler
+func (m *Map) scaled(x []float64) []float64 {
+	if m.m.Scaler == nil {
+		return x
+	}
+	scaled := make([]float64, len(x))
+	m.m.Scaler.transform(scaled, x)
+	return scaled
+}
+
+// Score returns anomaly score of sample x: the distance between x and its BMU. If the model has
+// a scaler, x is scaled by it first. It returns error in the same way as BMU; the score is NaN
+// when the error is returned.
+func (m *Map) Score(x []float64) (float64, error) {
+	if len(x) != m.m.Dim {
+		return math.NaN(), ErrDimMismatch
+	}
+	_, score, err := m.BMU(m.scaled(x))
+	if err != nil {
+		return math.NaN(), err
+	}
+	return score, nil
+}
+
+// IsAnomaly returns true if the anomaly score of x computed by Score exceeds the map anomaly threshold.
+// It also returns the anomaly score of x.
+// It returns error if the map has no anomaly threshold or if Score fails.
+func (m *Map) IsAnomaly(x []float64) (bool, float64, error) {
+	if !m.m.HasThreshold {
+		return false, math.NaN(), fmt.Errorf("anomaly threshold not fitted")
+	}
+	score, err := m.Score(x)
+	if err != nil {
+		return false, math.NaN(), err
+	}
+	return score > m.m.Threshold, score, nil
+}
+
+// Predict finds Best Match Unit of every row and returns slices of BMU indices and BMU distances
+// which preserve the order of rows. If the model has a scaler, rows are scaled by it first.
+// It returns error if any row has no observed values or ErrDimMismatch if the dimension
+// of a row is different from the codebook dimension.
+func (m *Map) Predict(rows [][]float64) ([]int, []float64, error) {
+	bmus, dists := make([]int, len(rows)), make([]float64, len(rows))
+	for i, x := range rows {
+		if len(x) != m.m.Dim {
+			return nil, nil, fmt.Errorf("invalid row %d: %w", i, ErrDimMismatch)
+		}
+		var err error
+		if bmus[i], dists[i], err = m.BMU(m.scaled(x)); err != nil {
+			return nil, nil, fmt.Errorf("invalid row %d: %s", i, err)
+		}
+	}
+	return bmus, dists, nil
+}
+
+// metric returns distance function of metric name or nil if the metric is not supported.
+// The functions compute the same distances as som distance functions of the same names.
+func metric(name string) func(a, b []float64) float64 {
+	switch name {
+	case "", "euclidean":
+		return func(a, b []float64) float64 {
+			d := 0.0
+			for i := range a {
+				d += (a[i] - b[i]) * (a[i] - b[i])
+			}
+			return math.Sqrt(d)
+		}
+	case "manhattan":
+		return func(a, b []float64) float64 {
+			d := 0.0
+			for i := range a {
+				d += math.Abs(a[i] - b[i])
+			}
+			return d
+		}
+	case "chebyshev":
+		return func(a, b []float64) float64 {
+			d := 0.0
+			for i := range a {
+				d = math.Max(d, math.Abs(a[i]-b[i]))
+			}
+			return d
+		}
+	case "cosine":
+		return func(a, b []float64) float64 {
+			dot, aa, bb := 0.0, 0.0, 0.0
+			for i := range a {
+				dot += a[i] * b[i]
+				aa += a[i] * a[i]
+				bb += b[i] * b[i]
+			}
+			if aa == 0 || bb == 0 {
+				return 1.0
+			}
+			return 1 - dot/math.Sqrt(aa*bb)
+		}
+	case "correlation":
+		return func(a, b []float64) float64 {
+			meanA, meanB := 0.0, 0.0
+			for i := range a {
+				meanA += a[i]
+				meanB += b[i]
+			}
+			meanA /= float64(len(a))
+			meanB /= float64(len(b))
+			cov, varA, varB := 0.0, 0.0, 0.0
+			for i := range a {
+				da, db := a[i]-meanA, b[i]-meanB
+				cov += da * db
+				varA += da * da
+				varB += db * db
+			}
+			if varA == 0 || varB == 0 {
+				return 1.0
+			}
+			return 1 - cov/math.Sqrt(varA*varB)
+		}
+	}
+	return nil
+}
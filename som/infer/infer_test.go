@@ -0,0 +1,173 @@
+package infer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/som"
+	"github.com/stretchr/testify/assert"
+)
+
+// trainedMap returns map of 4x3 units trained on data with the given options
+func trainedMap(t *testing.T, data *mat64.Dense, opts ...som.Option) *som.Map {
+	opts = append([]som.Option{som.WithDims(4, 3), som.WithSeed(1)}, opts...)
+	m, err := som.NewMapWithOptions(data, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := &som.TrainConfig{Algorithm: som.Batch, Radius: 2.0, RDecay: "exp", NeighbFn: som.Gaussian, LRate: 0.1, LDecay: "exp"}
+	if err := m.Train(tc, data, 10); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// loaded saves map m and loads it by Load
+func loaded(t *testing.T, m *som.Map) *Map {
+	var b bytes.Buffer
+	if err := m.Save(&b); err != nil {
+		t.Fatal(err)
+	}
+	lm, err := Load(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lm
+}
+
+// randData returns rows random samples of dimension 3 with features of different scales
+func randData(rows int, seed int64) *mat64.Dense {
+	r := rand.New(rand.NewSource(seed))
+	data := mat64.NewDense(rows, 3, nil)
+	for i := 0; i < rows; i++ {
+		data.SetRow(i, []float64{r.Float64(), 10 * r.Float64(), 100 * r.NormFloat64()})
+	}
+	return data
+}
+
+func TestLoad(t *testing.T) {
+	assert := assert.New(t)
+
+	data, test := randData(200, 1), randData(50, 2)
+	for _, metric := range []string{"euclidean", "manhattan", "cosine"} {
+		m := trainedMap(t, data, som.WithMetric(metric))
+		scaler, err := som.FitScaler(som.ZScore, data)
+		assert.NoError(err)
+		assert.NoError(m.SetScaler(scaler))
+		assert.NoError(m.FitAnomalyThreshold(data, 0.9))
+		lm := loaded(t, m)
+		assert.Equal(12, lm.Units())
+		assert.Equal(3, lm.Dim())
+		assert.Equal(m.AnomalyThreshold(), lm.AnomalyThreshold())
+
+		// the loaded map finds the same BMUs and distances
+		rows, _ := test.Dims()
+		batch := make([][]float64, rows)
+		for i := range batch {
+			x := test.RawRowView(i)
+			batch[i] = x
+			bmu, dist, err := m.BMU(x)
+			assert.NoError(err)
+			lBMU, lDist, err := lm.BMU(x)
+			assert.NoError(err)
+			assert.Equal(bmu, lBMU, metric)
+			assert.InDelta(dist, lDist, 1e-9)
+			score, err := m.Score(x)
+			assert.NoError(err)
+			lScore, err := lm.Score(x)
+			assert.NoError(err)
+			assert.InDelta(score, lScore, 1e-9)
+			anomaly, _, err := m.IsAnomaly(x)
+			assert.NoError(err)
+			lAnomaly, _, err := lm.IsAnomaly(x)
+			assert.NoError(err)
+			assert.Equal(anomaly, lAnomaly)
+		}
+		bmus, dists, err := m.Predict(test, 1)
+		assert.NoError(err)
+		lBMUs, lDists, err := lm.Predict(batch)
+		assert.NoError(err)
+		assert.Equal(bmus, lBMUs)
+		assert.InDeltaSlice(dists, lDists, 1e-9)
+	}
+
+	// missing values don't contribute to the distance
+	m := trainedMap(t, data)
+	_, err := m.ClusterKMeans(3, 1, 100)
+	assert.NoError(err)
+	lm := loaded(t, m)
+	assert.Equal(m.Clusters(), lm.Clusters())
+	x := []float64{0.5, math.NaN(), 10}
+	bmu, dist, err := m.BMU(x)
+	assert.NoError(err)
+	lBMU, lDist, err := lm.BMU(x)
+	assert.NoError(err)
+	assert.Equal(bmu, lBMU)
+	assert.InDelta(dist, lDist, 1e-9)
+	_, _, err = lm.BMU([]float64{math.NaN(), math.NaN(), math.NaN()})
+	assert.Error(err)
+	_, _, err = lm.BMU([]float64{1, 2})
+	assert.True(errors.Is(err, ErrDimMismatch))
+	_, _, err = lm.Predict([][]float64{{1, 2, 3}, {1, 2}})
+	assert.True(errors.Is(err, ErrDimMismatch))
+	_, _, err = lm.IsAnomaly(x)
+	assert.Error(err)
+
+	// maps are created from models decoded from JSON
+	enc, err := json.Marshal(lm.Model())
+	assert.NoError(err)
+	var model Model
+	assert.NoError(json.Unmarshal(enc, &model))
+	jm, err := New(model)
+	assert.NoError(err)
+	jBMU, _, err := jm.BMU(x)
+	assert.NoError(err)
+	assert.Equal(bmu, jBMU)
+
+	_, err = Load(bytes.NewReader([]byte("model")))
+	assert.Error(err)
+}
+
+func TestNew(t *testing.T) {
+	assert := assert.New(t)
+
+	cb := []float64{0, 0, 0, 1, 1, 0, 1, 1}
+	m, err := New(Model{Dim: 2, Codebook: cb, Labels: []string{"a", "b", "c", "d"}})
+	assert.NoError(err)
+	assert.Equal(4, m.Units())
+	assert.True(math.IsNaN(m.AnomalyThreshold()))
+	bmu, dist, err := m.BMU([]float64{0.9, 0.2})
+	assert.NoError(err)
+	assert.Equal(2, bmu)
+	assert.InDelta(math.Sqrt(0.05), dist, 1e-9)
+	assert.Equal("c", m.Labels()[bmu])
+
+	// feature relevances weight the distance
+	m, err = New(Model{Dim: 2, Codebook: cb, Relevance: []float64{0, 1}, Metric: "cosine"})
+	assert.NoError(err)
+	bmu, dist, err = m.BMU([]float64{0.9, 0.2})
+	assert.NoError(err)
+	assert.Equal(0, bmu)
+	assert.InDelta(0.2, dist, 1e-9)
+
+	for _, model := range []Model{
+		{Dim: 0, Codebook: cb},
+		{Dim: 3, Codebook: cb},
+		{Dim: 2},
+		{Dim: 2, Codebook: cb, Metric: "hamming"},
+		{Dim: 2, Codebook: cb, Labels: []string{"a"}},
+		{Dim: 2, Codebook: cb, Clusters: []int{0, 1}},
+		{Dim: 2, Codebook: cb, Relevance: []float64{1}},
+		{Dim: 2, Codebook: cb, Features: []string{"x"}},
+		{Dim: 2, Codebook: cb, Scaler: &Scaler{Method: "zscore", Center: []float64{0}, Scale: []float64{1}}},
+		{Dim: 2, Codebook: cb, Scaler: &Scaler{Method: "log"}},
+	} {
+		_, err := New(model)
+		assert.Error(err, "%+v", model)
+	}
+}
@@ -0,0 +1,81 @@
+package som
+
+import (
+	"encoding/json"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// jsonMap is the JSON encoding of a map produced by MarshalJSON
+type jsonMap struct {
+	Dims     []int          `json:"dims"`
+	UShape   string         `json:"ushape"`
+	Grid     string         `json:"grid"`
+	Coords   [][]float64    `json:"coords"`
+	Polygons [][][2]float64 `json:"polygons,omitempty"`
+	Features []string       `json:"features,omitempty"`
+	Codebook [][]float64    `json:"codebook"`
+	Labels   []string       `json:"labels,omitempty"`
+	Clusters []int          `json:"clusters,omitempty"`
+}
+
+// SetFeatureNames sets names of codebook vector features, such as data file column names,
+// which are encoded by MarshalJSON and saved by Save. Nil names remove the feature names.
+// It returns ErrDimMismatch if the number of names is different from the codebook dimension.
+func (m *Map) SetFeatureNames(names []string) error {
+	if _, dim := m.cbDims(); names != nil && len(names) != dim {
+		return ErrDimMismatch
+	}
+	m.features = names
+	return nil
+}
+
+// FeatureNames returns feature names set by SetFeatureNames or nil if the map has none
+func (m Map) FeatureNames() []string {
+	return m.features
+}
+
+// MarshalJSON encodes the map as a JSON object which can be rendered by web front ends.
+// The object has the following fields:
+//
+//	dims      grid dimensions: rows and columns of planar grids
+//	ushape    unit shape: hexagon or rectangle
+//	grid      grid type, such as planar or toroidal
+//	coords    array of unit grid coordinates, one array per unit
+//	polygons  array of unit polygons returned by Grid.Polygons; omitted if the grid is not two dimensional
+//	features  array of feature names set by SetFeatureNames; omitted if the map has none
+//	codebook  array of codebook vectors, one array per unit
+//	labels    array of unit labels assigned by LabelUnits; omitted if the units are not labeled
+//	clusters  array of unit cluster ids; omitted if the units are not clustered
+//
+// Units are ordered in the same way as codebook rows. Codebook vectors of maps with a scaler
+// are scaled; OrigCodebook returns them in the original feature units.
+// It returns error if the codebook has values which can't be encoded, such as NaN.
+func (m Map) MarshalJSON() ([]byte, error) {
+	// grids which are not two dimensional have no polygons
+	polygons, _ := m.grid.Polygons()
+	return json.Marshal(&jsonMap{
+		Dims:     m.grid.size,
+		UShape:   m.grid.ushape,
+		Grid:     m.grid.gtype,
+		Coords:   rowSlices(m.grid.coords),
+		Polygons: polygons,
+		Features: m.features,
+		Codebook: rowSlices(m.cb()),
+		Labels:   m.labels,
+		Clusters: m.clusters,
+	})
+}
+
+// rowSlices returns copies of the rows of matrix a
+func rowSlices(a mat64.Matrix) [][]float64 {
+	rows, cols := a.Dims()
+	out := make([][]float64, rows)
+	for i := range out {
+		out[i] = make([]float64, cols)
+		for j := range out[i] {
+			out[i][j] = a.At(i, j)
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package som
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureNames(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(makePrecisionMapCfg([]int{2, 3}, 2, ""), unitBlobs(20, 1))
+	assert.NoError(err)
+	assert.Nil(m.FeatureNames())
+	assert.NoError(m.SetFeatureNames([]string{"x", "y"}))
+	assert.Equal([]string{"x", "y"}, m.FeatureNames())
+	assert.Equal(ErrDimMismatch, m.SetFeatureNames([]string{"x"}))
+	assert.Equal([]string{"x", "y"}, m.FeatureNames())
+	assert.NoError(m.SetFeatureNames(nil))
+	assert.Nil(m.FeatureNames())
+}
+
+func TestMarshalJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, precision := range []string{"", "float32"} {
+		m, err := NewMap(makePrecisionMapCfg([]int{2, 3}, 2, precision), unitBlobs(20, 1))
+		assert.NoError(err)
+		assert.NoError(m.SetFeatureNames([]string{"x", "y"}))
+		m.clusters = []int{0, 0, 0, 1, 1, 1}
+		enc, err := json.Marshal(m)
+		assert.NoError(err)
+		var dec struct {
+			Dims     []int          `json:"dims"`
+			UShape   string         `json:"ushape"`
+			Grid     string         `json:"grid"`
+			Coords   [][]float64    `json:"coords"`
+			Polygons [][][2]float64 `json:"polygons"`
+			Features []string       `json:"features"`
+			Codebook [][]float64    `json:"codebook"`
+			Clusters []int          `json:"clusters"`
+		}
+		assert.NoError(json.Unmarshal(enc, &dec))
+		assert.Equal([]int{2, 3}, dec.Dims)
+		assert.Equal("hexagon", dec.UShape)
+		assert.Equal("planar", dec.Grid)
+		assert.Equal([]string{"x", "y"}, dec.Features)
+		assert.Equal([]int{0, 0, 0, 1, 1, 1}, dec.Clusters)
+		if assert.Len(dec.Codebook, 6) && assert.Len(dec.Coords, 6) {
+			cb, coords := m.Codebook(), m.GridCoords()
+			for i := 0; i < 6; i++ {
+				assert.Equal(mat64.Row(nil, i, cb), dec.Codebook[i])
+				assert.Equal(mat64.Row(nil, i, coords), dec.Coords[i])
+			}
+		}
+		polygons, err := m.Grid().Polygons()
+		assert.NoError(err)
+		assert.Equal(polygons, dec.Polygons)
+		// fields of unset unit state are omitted
+		var fields map[string]json.RawMessage
+		assert.NoError(json.Unmarshal(enc, &fields))
+		assert.NotContains(fields, "labels")
+	}
+
+	// NaN can't be encoded
+	m, err := NewMap(makePrecisionMapCfg([]int{2, 3}, 2, ""), unitBlobs(20, 1))
+	assert.NoError(err)
+	m.setUnit(0, []float64{math.NaN(), 0})
+	_, err = json.Marshal(m)
+	assert.Error(err)
+}
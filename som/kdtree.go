@@ -0,0 +1,161 @@
+package som
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// KDTreeMaxDim is the maximum codebook dimension for which Freeze builds KD-tree.
+// KD-tree search degrades to linear scan performance in high dimensional spaces,
+// so maps with higher dimensional codebooks always use linear scan BMU search.
+var KDTreeMaxDim = 30
+
+// kdLeafSize is the maximum number of codebook vectors stored in KD-tree leaf
+const kdLeafSize = 8
+
+// kdNode is a KD-tree node
+type kdNode struct {
+	// dim is the split dimension
+	dim int
+	// split is the split value: left subtree holds vectors with dim-th element <= split
+	split float64
+	// left and right are child nodes; both are nil in leaf nodes
+	left, right *kdNode
+	// idx holds indices of codebook vectors stored in leaf node
+	idx []int
+}
+
+// kdTree is a KD-tree over codebook vectors used for exact BMU search
+type kdTree struct {
+	// root is the tree root node
+	root *kdNode
+	// cb is the indexed codebook
+	cb *mat64.Dense
+}
+
+// Freeze builds KD-tree over the map codebook which is then used in BMU search by BMU,
+// BMUs and Predict. Frozen map returns exactly the same BMUs as linear scan, including
+// ties which are broken in favour of the lowest index unit. The tree is discarded when
+// the map is trained again. If the codebook dimension exceeds KDTreeMaxDim, if the codebook
+// is stored in float32 precision or if the map has a codebook metric no tree is built and
+// Freeze returns false.
+func (m *Map) Freeze() bool {
+	if m.cb32 != nil || m.metric != nil {
+		m.tree = nil
+		return false
+	}
+	_, dim := m.codebook.Dims()
+	if dim > KDTreeMaxDim {
+		m.tree = nil
+		return false
+	}
+	m.tree = newKDTree(m.codebook)
+	return true
+}
+
+// newKDTree builds KD-tree over codebook vectors cb. The tree searches the vectors stored in cb
+// when it is searched, so if cb is modified, the search is approximate.
+func newKDTree(cb *mat64.Dense) *kdTree {
+	units, _ := cb.Dims()
+	idx := make([]int, units)
+	for i := range idx {
+		idx[i] = i
+	}
+	return &kdTree{root: buildKDNode(cb, idx), cb: cb}
+}
+
+// trainTree rebuilds KD-tree of approximate BMU search of training by tc in iter-th iteration
+// if the search is enabled and the map codebook can be searched by the tree. Training epochs
+// have epoch iterations.
+func (m *Map) trainTree(tc *TrainConfig, iter, epoch int) {
+	if tc.KDTreeEvery <= 0 || m.cb32 != nil || m.relevance != nil || m.metric != nil {
+		return
+	}
+	if iter == tc.startIter || iter%(tc.KDTreeEvery*epoch) == 0 {
+		m.tree = newKDTree(m.codebook)
+	}
+}
+
+// Frozen returns true if the map BMU search uses KD-tree
+func (m Map) Frozen() bool {
+	return m.tree != nil
+}
+
+// buildKDNode recursively builds KD-tree node over codebook vectors with indices idx
+func buildKDNode(cb *mat64.Dense, idx []int) *kdNode {
+	if len(idx) <= kdLeafSize {
+		return &kdNode{idx: idx}
+	}
+	// split along the dimension with the widest spread
+	_, cols := cb.Dims()
+	dim, spread := 0, -1.0
+	for j := 0; j < cols; j++ {
+		min, max := math.Inf(1), math.Inf(-1)
+		for _, i := range idx {
+			min = math.Min(min, cb.At(i, j))
+			max = math.Max(max, cb.At(i, j))
+		}
+		if max-min > spread {
+			dim, spread = j, max-min
+		}
+	}
+	// all vectors are the same
+	if spread == 0.0 {
+		return &kdNode{idx: idx}
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		return cb.At(idx[a], dim) < cb.At(idx[b], dim)
+	})
+	mid := len(idx) / 2
+	// keep all vectors with the split value in the left subtree
+	split := cb.At(idx[mid-1], dim)
+	for mid < len(idx) && cb.At(idx[mid], dim) == split {
+		mid++
+	}
+	if mid == len(idx) {
+		mid = len(idx) / 2
+		for mid > 0 && cb.At(idx[mid-1], dim) == cb.At(idx[mid], dim) {
+			mid--
+		}
+		split = cb.At(idx[mid-1], dim)
+	}
+	return &kdNode{
+		dim:   dim,
+		split: split,
+		left:  buildKDNode(cb, idx[:mid]),
+		right: buildKDNode(cb, idx[mid:]),
+	}
+}
+
+// nearest returns the index of codebook vector closest to x and the distance between them
+func (t *kdTree) nearest(x []float64) (int, float64) {
+	bmu, dist := -1, math.MaxFloat64
+	t.search(t.root, x, &bmu, &dist)
+	return bmu, dist
+}
+
+// search searches node subtree for codebook vector closer to x than the current best match
+func (t *kdTree) search(n *kdNode, x []float64, bmu *int, dist *float64) {
+	if n.left == nil {
+		for _, i := range n.idx {
+			d := euclideanVec(x, t.cb.RawRowView(i))
+			if d < *dist || (d == *dist && i < *bmu) {
+				*bmu, *dist = i, d
+			}
+		}
+		return
+	}
+	diff := x[n.dim] - n.split
+	near, far := n.left, n.right
+	if diff > 0 {
+		near, far = far, near
+	}
+	t.search(near, x, bmu, dist)
+	// visit the far subtree if it can contain a closer or equally close vector;
+	// the slack guards against rounding errors in the distance computation
+	if diff*diff <= (*dist)*(*dist)*(1+1e-9) {
+		t.search(far, x, bmu, dist)
+	}
+}
@@ -0,0 +1,147 @@
+package som
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreeze(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, dim := range []int{1, 2, 8, 20} {
+		data := utils.GenerateClusters(500, dim, 5, 10.0, -10.0, 2.0, 10)
+		cb := utils.GenerateClusters(300, dim, 5, 10.0, -10.0, 2.0, 20)
+		// duplicate codebook vectors: ties must be broken by the lowest index
+		for i := 0; i < 20; i++ {
+			cb.SetRow(100+i, cb.RawRowView(i))
+		}
+		// repeated values in a single dimension
+		for i := 200; i < 250; i++ {
+			cb.Set(i, 0, 1.0)
+		}
+		linear := &Map{codebook: cb}
+		frozen := &Map{codebook: cb}
+		assert.False(frozen.Frozen())
+		assert.True(frozen.Freeze())
+		assert.True(frozen.Frozen())
+		rows, _ := data.Dims()
+		for i := 0; i < rows; i++ {
+			bmu, dist, err := linear.BMU(data.RawRowView(i))
+			assert.NoError(err)
+			fBmu, fDist, err := frozen.BMU(data.RawRowView(i))
+			assert.NoError(err)
+			assert.Equal(bmu, fBmu, "dim: %d, row: %d", dim, i)
+			assert.Equal(dist, fDist, "dim: %d, row: %d", dim, i)
+		}
+		for i := 0; i < 20; i++ {
+			bmu, dist, err := frozen.BMU(cb.RawRowView(100 + i))
+			assert.NoError(err)
+			assert.Equal(i, bmu)
+			assert.Equal(0.0, dist)
+		}
+		bmus, _, err := linear.Predict(data, 2)
+		assert.NoError(err)
+		fBmus, _, err := frozen.Predict(data, 2)
+		assert.NoError(err)
+		assert.Equal(bmus, fBmus)
+		// dimension mismatch
+		bmu, dist, err := frozen.BMU(make([]float64, dim+1))
+		assert.Equal(-1, bmu)
+		assert.Equal(-1.0, dist)
+		assert.Equal(ErrDimMismatch, err)
+	}
+	// all codebook vectors are the same
+	m := &Map{codebook: mat64.NewDense(20, 2, nil)}
+	assert.True(m.Freeze())
+	bmu, _, err := m.BMU([]float64{1.0, 1.0})
+	assert.NoError(err)
+	assert.Equal(0, bmu)
+	// high dimensional codebook
+	m = &Map{codebook: mat64.NewDense(20, KDTreeMaxDim+1, nil)}
+	assert.False(m.Freeze())
+	assert.False(m.Frozen())
+	// training discards the tree
+	m, err = NewMap(mSom, dataMx)
+	assert.NoError(err)
+	assert.True(m.Freeze())
+	assert.NoError(m.Train(tSom, dataMx, 10))
+	assert.False(m.Frozen())
+}
+
+func TestTrainKDTree(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(500, 3, 5, 10.0, -10.0, 2.0, 10)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{Size: []int{10, 10}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 3, InitFunc: LinInit},
+	}
+	// tree rebuilt every batch epoch finds exact BMUs
+	tc := &TrainConfig{Algorithm: Batch, Radius: 3.0, RDecay: ExpDecay, NeighbFn: Gaussian, LRate: 0.1, LDecay: ExpDecay}
+	exact, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	assert.NoError(exact.Train(tc, data, 10))
+	tc.KDTreeEvery = 1
+	approx, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	assert.NoError(approx.Train(tc, data, 10))
+	assert.True(mat64.EqualApprox(exact.Codebook(), approx.Codebook(), 1e-9))
+	assert.False(approx.Frozen())
+	// stale trees find BMUs which quantize the data nearly as well
+	for _, alg := range []Method{Seq, Batch} {
+		tc := gsomTrainConfig()
+		tc.Algorithm = alg
+		exact, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		_, err = exact.train(tc, data, 2000, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		tc.KDTreeEvery = 3
+		approx, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		_, err = approx.train(tc, data, 2000, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		qe, err := exact.QuantError(data)
+		assert.NoError(err)
+		aqe, err := approx.QuantError(data)
+		assert.NoError(err)
+		assert.True(aqe < 1.25*qe, "%s: quantization error %f, exact %f", alg, aqe, qe)
+	}
+
+	// invalid configurations
+	tc = gsomTrainConfig()
+	tc.KDTreeEvery = -1
+	_, err = approx.TrainWithResult(tc, data, 10)
+	assert.EqualError(err, "invalid KD-tree rebuild interval: -1, must not be negative")
+	tc.KDTreeEvery, tc.Algorithm = 1, NeuralGas
+	_, err = approx.TrainWithResult(tc, data, 10)
+	assert.True(errors.Is(err, ErrUnsupportedMethod))
+}
+
+func BenchmarkFreeze(b *testing.B) {
+	defer func(maxDim int) { KDTreeMaxDim = maxDim }(KDTreeMaxDim)
+	KDTreeMaxDim = 64
+	for _, dim := range []int{8, 64} {
+		cb := utils.GenerateClusters(10000, dim, 20, 10.0, -10.0, 2.0, 10)
+		data := utils.GenerateClusters(1000, dim, 20, 10.0, -10.0, 2.0, 20)
+		rows, _ := data.Dims()
+		linear := &Map{codebook: cb}
+		frozen := &Map{codebook: cb}
+		frozen.Freeze()
+		b.Run(fmt.Sprintf("linear/dim=%d", dim), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				linear.BMU(data.RawRowView(i % rows))
+			}
+		})
+		b.Run(fmt.Sprintf("kdtree/dim=%d", dim), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				frozen.BMU(data.RawRowView(i % rows))
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package som
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// Kernel computes inner product of two vectors in an implicit feature space.
+// TrainKernel uses RBF, Polynomial, Linear or any other type which implements Kernel.
+type Kernel interface {
+	// Eval returns kernel value of vectors x and y of the same dimension
+	Eval(x, y []float64) float64
+}
+
+// RBF is Gaussian radial basis function kernel exp(-Gamma*|x-y|^2)
+type RBF struct {
+	// Gamma is kernel width parameter. It must be a positive number.
+	Gamma float64
+}
+
+// Eval returns RBF kernel value of vectors x and y
+func (k RBF) Eval(x, y []float64) float64 {
+	d := 0.0
+	for i := range x {
+		d += (x[i] - y[i]) * (x[i] - y[i])
+	}
+	return math.Exp(-k.Gamma * d)
+}
+
+// Polynomial is polynomial kernel (Gamma*x'y + Coef0)^Degree
+type Polynomial struct {
+	// Gamma scales the inner product. It must be a positive number.
+	Gamma float64
+	// Coef0 is added to the scaled inner product
+	Coef0 float64
+	// Degree is the kernel degree. It must be a positive integer.
+	Degree int
+}
+
+// Eval returns polynomial kernel value of vectors x and y
+func (k Polynomial) Eval(x, y []float64) float64 {
+	return math.Pow(k.Gamma*floats.Dot(x, y)+k.Coef0, float64(k.Degree))
+}
+
+// Linear is linear kernel x'y: kernel SOM with linear kernel operates in the data space
+type Linear struct{}
+
+// Eval returns inner product of vectors x and y
+func (Linear) Eval(x, y []float64) float64 {
+	return floats.Dot(x, y)
+}
+
+// validateKernel checks parameters of built-in kernels
+func validateKernel(k Kernel) error {
+	switch k := k.(type) {
+	case nil:
+		return fmt.Errorf("invalid kernel: %v", k)
+	case RBF:
+		if !(k.Gamma > 0) || math.IsInf(k.Gamma, 1) {
+			return fmt.Errorf("invalid rbf kernel gamma: %f, must be positive and finite", k.Gamma)
+		}
+	case Polynomial:
+		if !(k.Gamma > 0) || math.IsInf(k.Gamma, 1) {
+			return fmt.Errorf("invalid polynomial kernel gamma: %f, must be positive and finite", k.Gamma)
+		}
+		if k.Degree < 1 {
+			return fmt.Errorf("invalid polynomial kernel degree: %d", k.Degree)
+		}
+	}
+	return nil
+}
+
+// KernelMap is a kernel SOM: its codebook vectors live in the feature space of a kernel and
+// are maintained as coefficient vectors over the training samples. Squared feature space distance
+// of vector x and unit with coefficients a is k(x,x) - 2*sum(a_j*k(x,x_j)) + a'*K*a, where K holds
+// kernel values of the training samples x_j.
+type KernelMap struct {
+	// rel is relational SOM over feature space distances of training samples
+	rel *RelMap
+	// data holds the training samples
+	data *mat64.Dense
+	// kernel is the map kernel
+	kernel Kernel
+	// diag holds kernel values of every training sample with itself
+	diag []float64
+}
+
+// TrainKernel creates new kernel SOM with grid configured by mc and trains it on data using kernel k
+// and training configuration tc. mc.Cb is ignored: every unit starts as one of randomly chosen samples.
+// Seq and batch training run the same number of iterations as TrainRelational and KernelMap.Train
+// can continue the training. The map keeps a copy of data to compute kernel values of new samples.
+// It returns error if data is nil or has fewer than two rows, if k is nil or has invalid parameters,
+// if mc or tc are invalid or if the training fails.
+func TrainKernel(data *mat64.Dense, k Kernel, mc *MapConfig, tc *TrainConfig) (*KernelMap, error) {
+	// data can't be nil
+	if data == nil {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, _ := data.Dims()
+	if rows < 2 {
+		return nil, fmt.Errorf("invalid number of data samples: %d", rows)
+	}
+	if err := validateKernel(k); err != nil {
+		return nil, err
+	}
+	diag := make([]float64, rows)
+	for i := range diag {
+		diag[i] = k.Eval(data.RawRowView(i), data.RawRowView(i))
+	}
+	// squared feature space distances of training samples
+	sq := mat64.NewDense(rows, rows, nil)
+	for i := 0; i < rows; i++ {
+		for j := i + 1; j < rows; j++ {
+			d := diag[i] + diag[j] - 2*k.Eval(data.RawRowView(i), data.RawRowView(j))
+			sq.Set(i, j, d)
+			sq.Set(j, i, d)
+		}
+	}
+	rel, err := newRelMap(sq, mc)
+	if err != nil {
+		return nil, err
+	}
+	if err := rel.Train(tc, relIters(tc, rows)); err != nil {
+		return nil, err
+	}
+	return &KernelMap{
+		rel:    rel,
+		data:   mat64.DenseCopyOf(data),
+		kernel: k,
+		diag:   diag,
+	}, nil
+}
+
+// Train continues kernel SOM training on the training samples in the same way as RelMap.Train
+func (m *KernelMap) Train(tc *TrainConfig, iters int) error {
+	return m.rel.Train(tc, iters)
+}
+
+// Grid returns SOM grid
+func (m KernelMap) Grid() *Grid {
+	return m.rel.grid
+}
+
+// Coeffs returns a copy of the matrix which contains unit coefficient vectors:
+// one row per map unit and one column per training sample.
+func (m KernelMap) Coeffs() *mat64.Dense {
+	return m.rel.Coeffs()
+}
+
+// Kernel returns the map kernel
+func (m KernelMap) Kernel() Kernel {
+	return m.kernel
+}
+
+// BMU returns the index of Best Match Unit of vector x and their feature space distance.
+// Kernel values of x are computed against all training samples.
+// It returns ErrDimMismatch if the dimension of x is different from the training data dimension.
+// When the error is returned, both the index and distance are set to -1.
+func (m KernelMap) BMU(x []float64) (int, float64, error) {
+	rows, cols := m.data.Dims()
+	if len(x) != cols {
+		return -1, -1.0, ErrDimMismatch
+	}
+	kx := m.kernel.Eval(x, x)
+	sq := make([]float64, rows)
+	for j := range sq {
+		sq[j] = kx + m.diag[j] - 2*m.kernel.Eval(x, m.data.RawRowView(j))
+	}
+	bmu, d := m.rel.bmuSq(sq)
+	return bmu, d, nil
+}
+
+// BMUs returns BMUs of all data rows.
+// It returns error if data is nil or ErrDimMismatch if data dimension is different
+// from the training data dimension.
+func (m KernelMap) BMUs(data mat64.Matrix) ([]int, error) {
+	if isNilMatrix(data) {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, _ := data.Dims()
+	rd := newRowReader(data)
+	bmus := make([]int, rows)
+	for i := range bmus {
+		bmu, _, err := m.BMU(rd.row(i))
+		if err != nil {
+			return nil, err
+		}
+		bmus[i] = bmu
+	}
+	return bmus, nil
+}
+
+// HitMap returns the number of data rows which have every map unit as their BMU.
+// It fails in the same way as BMUs.
+func (m KernelMap) HitMap(data mat64.Matrix) ([]int, error) {
+	bmus, err := m.BMUs(data)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]int, len(m.rel.self))
+	for _, bmu := range bmus {
+		hits[bmu]++
+	}
+	return hits, nil
+}
+
+// UMatrixValues returns u-matrix of the map computed from feature space distances of unit prototypes
+func (m KernelMap) UMatrixValues() ([]float64, error) {
+	return m.rel.UMatrixValues()
+}
+
+// UMatrix writes SVG u-matrix of the map with the given title to w.
+// It fails with error if the u-matrix could not be computed.
+func (m KernelMap) UMatrix(w io.Writer, title string) error {
+	return m.rel.UMatrix(w, title)
+}
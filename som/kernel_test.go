@@ -0,0 +1,175 @@
+package som
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeCircles generates two concentric rings of n samples with labels 0 for the inner
+// and 1 for the outer ring
+func makeCircles(n int, inner, outer, noise float64, seed int64) (*mat64.Dense, []int) {
+	r := rand.New(rand.NewSource(seed))
+	data := mat64.NewDense(n, 2, nil)
+	rings := make([]int, n)
+	for i := 0; i < n; i++ {
+		t := r.Float64() * 2 * math.Pi
+		radius := inner
+		if i%2 == 1 {
+			radius, rings[i] = outer, 1
+		}
+		data.Set(i, 0, radius*math.Cos(t)+r.NormFloat64()*noise)
+		data.Set(i, 1, radius*math.Sin(t)+r.NormFloat64()*noise)
+	}
+	return data, rings
+}
+
+// ringPurity returns the fraction of samples whose BMU has most of its samples from the same ring
+func ringPurity(bmus, rings []int, units int) float64 {
+	counts := make([][2]int, units)
+	for i, bmu := range bmus {
+		counts[bmu][rings[i]]++
+	}
+	pure := 0
+	for _, c := range counts {
+		if c[0] > c[1] {
+			pure += c[0]
+		} else {
+			pure += c[1]
+		}
+	}
+	return float64(pure) / float64(len(bmus))
+}
+
+func TestTrainKernelCircles(t *testing.T) {
+	assert := assert.New(t)
+
+	data, rings := makeCircles(200, 0.3, 3.0, 0.1, 7)
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{1, 2},
+			Type:   Planar,
+			UShape: Rectangle,
+		},
+		Cb: &CbConfig{
+			Dim:      2,
+			InitFunc: RandInit,
+		},
+	}
+	tc := &TrainConfig{
+		Algorithm: Batch,
+		Radius:    1.5,
+		RDecay:    LinDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    LinDecay,
+	}
+	m, err := TrainKernel(data, RBF{Gamma: 1.0}, mc, tc)
+	assert.NoError(err)
+	bmus, err := m.BMUs(data)
+	assert.NoError(err)
+	kernelPurity := ringPurity(bmus, rings, 2)
+	som, err := NewMap(mc, data)
+	assert.NoError(err)
+	assert.NoError(som.Train(tc, data, 100))
+	bmus, err = som.BMUs(data)
+	assert.NoError(err)
+	linearPurity := ringPurity(bmus, rings, 2)
+	// every unit of the kernel map holds a single ring
+	assert.Equal(1.0, kernelPurity)
+	assert.True(linearPurity < 0.9, "linear purity: %f", linearPurity)
+	// fresh samples are mapped by their kernel values against the training samples
+	test, truth := makeCircles(100, 0.3, 3.0, 0.1, 8)
+	hits, err := m.HitMap(test)
+	assert.NoError(err)
+	assert.Equal([]int{50, 50}, hits)
+	bmus, err = m.BMUs(test)
+	assert.NoError(err)
+	assert.Equal(1.0, ringPurity(bmus, truth, 2))
+	// feature space u-matrix
+	umatrix, err := m.UMatrixValues()
+	assert.NoError(err)
+	assert.Len(umatrix, 2)
+	assert.True(umatrix[0] > 0.0)
+	var b bytes.Buffer
+	assert.NoError(m.UMatrix(&b, "kernel"))
+	assert.Equal(2, bytes.Count(b.Bytes(), []byte("<polygon")))
+}
+
+func TestTrainKernelLinear(t *testing.T) {
+	assert := assert.New(t)
+
+	// linear kernel SOM is relational SOM of Euclidean distances
+	data, _ := makeCircles(40, 1.0, 2.0, 0.1, 7)
+	mc := relMapConfig(2, 2)
+	tc := &TrainConfig{
+		Algorithm: Batch,
+		Radius:    1.5,
+		RDecay:    LinDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    LinDecay,
+	}
+	km, err := TrainKernel(data, Linear{}, mc, tc)
+	assert.NoError(err)
+	rm, err := TrainRelational(euclideanDiss(data), mc, tc)
+	assert.NoError(err)
+	kc, rc := km.Coeffs(), rm.Coeffs()
+	units, items := kc.Dims()
+	for k := 0; k < units; k++ {
+		for j := 0; j < items; j++ {
+			assert.InDelta(rc.At(k, j), kc.At(k, j), 1e-9)
+		}
+	}
+	bmu, dist, err := km.BMU([]float64{0.5, 0.5})
+	assert.NoError(err)
+	protos := new(mat64.Dense)
+	protos.Mul(kc, data)
+	want, err := ClosestVec("euclidean", []float64{0.5, 0.5}, protos)
+	assert.NoError(err)
+	assert.Equal(want, bmu)
+	assert.InDelta(euclideanVec([]float64{0.5, 0.5}, protos.RawRowView(want)), dist, 1e-6)
+}
+
+func TestKernels(t *testing.T) {
+	assert := assert.New(t)
+
+	x, y := []float64{1, 2}, []float64{2, 0}
+	assert.InDelta(math.Exp(-0.5*5), RBF{Gamma: 0.5}.Eval(x, y), 1e-12)
+	assert.Equal(1.0, RBF{Gamma: 0.5}.Eval(x, x))
+	assert.Equal(9.0, Polynomial{Gamma: 1.0, Coef0: 1.0, Degree: 2}.Eval(x, y))
+	assert.Equal(2.0, Linear{}.Eval(x, y))
+}
+
+func TestTrainKernelErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := makeCircles(10, 1.0, 2.0, 0.1, 7)
+	mc := relMapConfig(2, 2)
+	testCases := []struct {
+		data   *mat64.Dense
+		k      Kernel
+		errStr string
+	}{
+		{nil, RBF{Gamma: 1.0}, "invalid data supplied: <nil>"},
+		{mat64.NewDense(1, 2, nil), RBF{Gamma: 1.0}, "invalid number of data samples: 1"},
+		{data, nil, "invalid kernel: <nil>"},
+		{data, RBF{}, "invalid rbf kernel gamma: 0.000000, must be positive and finite"},
+		{data, Polynomial{Gamma: -1.0, Degree: 2}, "invalid polynomial kernel gamma: -1.000000, must be positive and finite"},
+		{data, Polynomial{Gamma: 1.0}, "invalid polynomial kernel degree: 0"},
+	}
+	for _, tc := range testCases {
+		_, err := TrainKernel(tc.data, tc.k, mc, tSom)
+		assert.EqualError(err, tc.errStr)
+	}
+	m, err := TrainKernel(data, RBF{Gamma: 1.0}, mc, tSom)
+	assert.NoError(err)
+	_, _, err = m.BMU([]float64{1.0})
+	assert.Equal(ErrDimMismatch, err)
+	_, err = m.BMUs(nil)
+	assert.Error(err)
+}
@@ -0,0 +1,151 @@
+package som
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Layer is an input layer of multi-view maps: a group of data columns, such as spectral or demographic
+// features, which has its own normalization and weight in the distance of BMU search
+type Layer struct {
+	// Name identifies the layer in errors
+	Name string
+	// Cols holds indices of the data columns of the layer
+	Cols []int
+	// Weight is the share of the layer in the distance of data samples from codebook vectors
+	Weight float64
+}
+
+// LayerWeights returns feature weights of multi-view maps with input layers, one per data column, which
+// are used as CbConfig.FeatureWeights. Like supersom, the squared euclidean distance of a sample from
+// a codebook vector is the sum of squared layer distances, each divided by the layer variance of data,
+// i.e. the mean squared distance of the layer columns of data samples from their mean, and multiplied by
+// the layer weight. Every layer thus contributes to BMU search by its weight regardless of the number and
+// the scale of its columns. Layer weights are normalised to sum to 1. Columns of constant layers keep
+// their layer weight and columns which don't belong to any layer are passive. Missing values are ignored.
+// LayerWeights returns error if data is nil, if no layers are supplied, if any layer has no columns,
+// column out of data range or column of another layer, if any layer weight is negative or not finite
+// or if no layer weight is positive.
+func LayerWeights(data mat64.Matrix, layers []Layer) ([]float64, error) {
+	// data can't be nil
+	if isNilMatrix(data) {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	if err := checkLayers(data, layers); err != nil {
+		return nil, err
+	}
+	rows, cols := data.Dims()
+	// means and variances of data columns
+	means, vars, counts := make([]float64, cols), make([]float64, cols), make([]int, cols)
+	rd := newRowReader(data)
+	for i := 0; i < rows; i++ {
+		for j, v := range rd.row(i) {
+			if !math.IsNaN(v) {
+				means[j] += v
+				counts[j]++
+			}
+		}
+	}
+	for j := range means {
+		if counts[j] > 0 {
+			means[j] /= float64(counts[j])
+		}
+	}
+	for i := 0; i < rows; i++ {
+		for j, v := range rd.row(i) {
+			if !math.IsNaN(v) {
+				vars[j] += (v - means[j]) * (v - means[j]) / float64(counts[j])
+			}
+		}
+	}
+	total := 0.0
+	for _, l := range layers {
+		total += l.Weight
+	}
+	weights := make([]float64, cols)
+	for _, l := range layers {
+		layerVar := 0.0
+		for _, j := range l.Cols {
+			layerVar += vars[j]
+		}
+		w := l.Weight / total
+		if layerVar > 0 {
+			w /= layerVar
+		}
+		for _, j := range l.Cols {
+			weights[j] = w
+		}
+	}
+	return weights, nil
+}
+
+// checkLayers returns error if input layers of data are invalid
+func checkLayers(data mat64.Matrix, layers []Layer) error {
+	if len(layers) == 0 {
+		return fmt.Errorf("invalid layers: no layers supplied")
+	}
+	_, cols := data.Dims()
+	owner := make([]int, cols)
+	positive := false
+	for i, l := range layers {
+		if len(l.Cols) == 0 {
+			return fmt.Errorf("invalid layer %d %q: no columns", i, l.Name)
+		}
+		for _, j := range l.Cols {
+			if j < 0 || j >= cols {
+				return fmt.Errorf("invalid layer %d %q: column %d out of range [0, %d)", i, l.Name, j, cols)
+			}
+			if owner[j] != 0 {
+				return fmt.Errorf("invalid layer %d %q: column %d belongs to layer %d", i, l.Name, j, owner[j]-1)
+			}
+			owner[j] = i + 1
+		}
+		if !(l.Weight >= 0) || math.IsInf(l.Weight, 1) {
+			return fmt.Errorf("invalid layer %d %q: weight %f, must be non-negative and finite", i, l.Name, l.Weight)
+		}
+		positive = positive || l.Weight > 0
+	}
+	if !positive {
+		return fmt.Errorf("invalid layers: no positive weight")
+	}
+	return nil
+}
+
+// LayerQuantErrors returns quantization errors of input layers of data: for every layer the mean euclidean
+// distance of the layer columns of data samples from the same columns of their BMU codebook vectors.
+// Comparing the errors with those of maps trained on single layers shows how much each layer shapes the map.
+// It returns error if data is nil, if its dimension is different from the codebook dimension,
+// if the layers are invalid or if BMU search fails.
+func (m Map) LayerQuantErrors(data mat64.Matrix, layers []Layer) ([]float64, error) {
+	// data can't be nil
+	if isNilMatrix(data) {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	if err := checkLayers(data, layers); err != nil {
+		return nil, err
+	}
+	bmus, err := m.BMUs(data)
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := data.Dims()
+	errs := make([]float64, len(layers))
+	cb := m.cb()
+	rd := newRowReader(data)
+	for i, bmu := range bmus {
+		row, vec := rd.row(i), cb.RawRowView(bmu)
+		for l, layer := range layers {
+			d := 0.0
+			for _, j := range layer.Cols {
+				// missing values are ignored
+				if diff := row[j] - vec[j]; !math.IsNaN(diff) {
+					d += diff * diff
+				}
+			}
+			errs[l] += math.Sqrt(d) / float64(rows)
+		}
+	}
+	return errs, nil
+}
@@ -0,0 +1,104 @@
+package som
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// layersData returns data with spectral columns 0 and 1, demographic column 2 and constant column 3
+func layersData() *mat64.Dense {
+	return mat64.NewDense(4, 4, []float64{
+		0.0, 0.0, 0.0, 7.0,
+		200.0, 0.0, 2.0, 7.0,
+		0.0, 200.0, 0.0, 7.0,
+		200.0, 200.0, 2.0, 7.0,
+	})
+}
+
+func TestLayerWeights(t *testing.T) {
+	assert := assert.New(t)
+
+	data := layersData()
+	layers := []Layer{
+		{Name: "spectral", Cols: []int{0, 1}, Weight: 1.0},
+		{Name: "demographic", Cols: []int{2}, Weight: 3.0},
+	}
+	weights, err := LayerWeights(data, layers)
+	assert.NoError(err)
+	// layer variances are 20000 and 1; column 3 is passive
+	assert.InDeltaSlice([]float64{0.25 / 20000, 0.25 / 20000, 0.75, 0.0}, weights, 1e-12)
+	// constant layers keep their weight
+	weights, err = LayerWeights(data, append(layers, Layer{Name: "constant", Cols: []int{3}, Weight: 1.0}))
+	assert.NoError(err)
+	assert.InDeltaSlice([]float64{0.2 / 20000, 0.2 / 20000, 0.6, 0.2}, weights, 1e-12)
+	// missing values are ignored
+	data.Set(0, 2, math.NaN())
+	weights, err = LayerWeights(data, layers)
+	assert.NoError(err)
+	assert.InDelta(0.75/(8.0/9.0), weights[2], 1e-12)
+
+	_, err = LayerWeights(nil, layers)
+	assert.Error(err)
+	_, err = LayerWeights(data, nil)
+	assert.EqualError(err, "invalid layers: no layers supplied")
+	_, err = LayerWeights(data, []Layer{{Name: "a", Weight: 1.0}})
+	assert.EqualError(err, `invalid layer 0 "a": no columns`)
+	_, err = LayerWeights(data, []Layer{{Name: "a", Cols: []int{4}, Weight: 1.0}})
+	assert.EqualError(err, `invalid layer 0 "a": column 4 out of range [0, 4)`)
+	_, err = LayerWeights(data, []Layer{{Name: "a", Cols: []int{0, 1}, Weight: 1.0}, {Name: "b", Cols: []int{1}, Weight: 1.0}})
+	assert.EqualError(err, `invalid layer 1 "b": column 1 belongs to layer 0`)
+	_, err = LayerWeights(data, []Layer{{Name: "a", Cols: []int{0}, Weight: math.NaN()}})
+	assert.EqualError(err, `invalid layer 0 "a": weight NaN, must be non-negative and finite`)
+	_, err = LayerWeights(data, []Layer{{Name: "a", Cols: []int{0}}})
+	assert.EqualError(err, "invalid layers: no positive weight")
+}
+
+func TestWithLayers(t *testing.T) {
+	assert := assert.New(t)
+
+	data := layersData()
+	spectral := Layer{Name: "spectral", Cols: []int{0, 1}, Weight: 1.0}
+	demographic := Layer{Name: "demographic", Cols: []int{2}, Weight: 1.0}
+	c, err := NewMapConfig(data, WithDims(2, 1), WithLayers(spectral, demographic))
+	assert.NoError(err)
+	assert.InDeltaSlice([]float64{0.5 / 20000, 0.5 / 20000, 0.5, 0.0}, c.Cb.FeatureWeights, 1e-12)
+	_, err = NewMapConfig(data, WithDims(2, 1), WithLayers())
+	assert.Error(err)
+	_, err = NewMapConfig(data, WithDims(2, 1), WithLayers(spectral), WithFeatureWeights(1.0, 1.0, 1.0, 1.0))
+	assert.Error(err)
+	_, err = NewMapConfig(data, WithDims(2, 1), WithLayers(Layer{Name: "empty", Weight: 1.0}))
+	assert.Error(err)
+}
+
+func TestLayerQuantErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := layersData()
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{2, 1}, Type: "planar", UShape: "rectangle"},
+		Cb:   &CbConfig{Dim: 4, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	m.codebook = mat64.NewDense(2, 4, []float64{
+		0.0, 0.0, 0.0, 7.0,
+		200.0, 200.0, 2.0, 7.0,
+	})
+	layers := []Layer{
+		{Name: "spectral", Cols: []int{0, 1}, Weight: 1.0},
+		{Name: "demographic", Cols: []int{2}, Weight: 1.0},
+	}
+	// samples 1 and 2 are 200 away from their BMUs in the spectral layer only
+	errs, err := m.LayerQuantErrors(data, layers)
+	assert.NoError(err)
+	assert.InDeltaSlice([]float64{100.0, 0.0}, errs, 1e-12)
+
+	_, err = m.LayerQuantErrors(nil, layers)
+	assert.Error(err)
+	_, err = m.LayerQuantErrors(data, nil)
+	assert.Error(err)
+	_, err = m.LayerQuantErrors(mat64.NewDense(1, 2, nil), []Layer{{Cols: []int{0}, Weight: 1.0}})
+	assert.Equal(ErrDimMismatch, err)
+}
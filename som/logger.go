@@ -0,0 +1,59 @@
+package som
+
+// progressLogs is the number of progress messages logged during training
+const progressLogs = 10
+
+// Logger logs SOM training events. It is satisfied by *log.Logger from the standard library.
+// Implementations must be safe for concurrent use if the same Logger trains multiple maps at once.
+type Logger interface {
+	// Printf logs a message formatted according to a format specifier
+	Printf(format string, v ...interface{})
+}
+
+// nopLogger discards all messages
+type nopLogger struct{}
+
+// Printf discards the message
+func (nopLogger) Printf(string, ...interface{}) {}
+
+// trainLogger returns logger of training configuration tc or a logger which discards
+// all messages if tc has no logger
+func trainLogger(tc *TrainConfig) Logger {
+	if tc.Logger == nil {
+		return nopLogger{}
+	}
+	return tc.Logger
+}
+
+// progressEvery returns the number of training iterations between progress messages
+func progressEvery(iters int) int {
+	if every := iters / progressLogs; every > 0 {
+		return every
+	}
+	return 1
+}
+
+// logProgress logs radius, or range of neural gas training, and learning rate of training
+// other than batch at iter-th out of iters training iterations. Progress is logged at the first
+// and the last iteration and at every progressEvery iterations in between.
+func logProgress(l Logger, tc *TrainConfig, iter, iters int) {
+	if iter%progressEvery(iters) != 0 && iter != iters-1 {
+		return
+	}
+	if tc.Algorithm == NeuralGas {
+		l.Printf("som: %s training: iteration %d/%d: range %.4f, learning rate %.4f", tc.Algorithm, iter+1, iters,
+			tc.nRange(iter, iters), tc.lRate(iter, iters))
+		return
+	}
+	if tc.Algorithm == GrowingNeuralGas {
+		l.Printf("som: %s training: iteration %d/%d: learning rate %.4f", tc.Algorithm, iter+1, iters, tc.lRate(iter, iters))
+		return
+	}
+	radius := tc.radius(iter, iters)
+	if tc.Algorithm == "batch" {
+		l.Printf("som: %s training: iteration %d/%d: radius %.4f", tc.Algorithm, iter+1, iters, radius)
+		return
+	}
+	lRate := tc.lRate(iter, iters)
+	l.Printf("som: %s training: iteration %d/%d: radius %.4f, learning rate %.4f", tc.Algorithm, iter+1, iters, radius, lRate)
+}
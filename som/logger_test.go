@@ -0,0 +1,104 @@
+package som
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/milosgajdos83/gosom/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+// recLogger records logged messages
+type recLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *recLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, fmt.Sprintf(format, v...))
+}
+
+// count returns the number of messages which contain s
+func (l *recLogger) count(s string) int {
+	n := 0
+	for _, msg := range l.msgs {
+		if strings.Contains(msg, s) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestTrainLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := matrix.MakeRandom(20, 3, -10.0, 10.0)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{4, 4},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      3,
+			InitFunc: RandInit,
+		},
+	}
+
+	testCases := []struct {
+		algorithm Method
+		iters     int
+		progress  int
+		lrate     bool
+	}{
+		{"batch", 20, 11, false},
+		{"seq", 100, 11, true},
+		{"seq", 5, 5, true},
+	}
+
+	for _, tc := range testCases {
+		m, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		l := &recLogger{}
+		tCfg := &TrainConfig{
+			Algorithm: tc.algorithm,
+			Radius:    10.0,
+			RDecay:    "exp",
+			NeighbFn:  Gaussian,
+			LRate:     0.5,
+			LDecay:    "exp",
+			Logger:    l,
+		}
+		assert.NoError(m.Train(tCfg, data, tc.iters))
+		assert.Equal(1, l.count(fmt.Sprintf("som: %s training started: %d iterations, radius 10.0000, learning rate 0.5000", tc.algorithm, tc.iters)))
+		assert.Equal(fmt.Sprintf("som: %s training finished: %d iterations", tc.algorithm, tc.iters), l.msgs[len(l.msgs)-1])
+		assert.Equal(tc.progress, l.count("training: iteration"))
+		assert.Equal(1, l.count(fmt.Sprintf("iteration 1/%d: radius 10.0000", tc.iters)))
+		assert.Equal(1, l.count(fmt.Sprintf("iteration %d/%d: radius 1.0000", tc.iters, tc.iters)))
+		// training start message includes learning rate
+		if tc.lrate {
+			assert.Equal(tc.progress+1, l.count("learning rate"))
+		} else {
+			assert.Equal(1, l.count("learning rate"))
+		}
+		// radius exceeds grid diagonal
+		assert.Equal(1, l.count("som: warning: radius"))
+	}
+
+	// no logger
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	tCfg := &TrainConfig{
+		Algorithm: "batch",
+		Radius:    2.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+	assert.NoError(m.Train(tCfg, data, 10))
+}
@@ -1,6 +1,7 @@
 package som
 
 import (
+	"errors"
 	"math"
 	"testing"
 
@@ -15,6 +16,10 @@ func TestLinLR(t *testing.T) {
 	testLR(t, "lin")
 }
 
+func TestInvLR(t *testing.T) {
+	testLR(t, "inv")
+}
+
 func TestDefaultLR(t *testing.T) {
 	testLR(t, "some other")
 }
@@ -24,10 +29,10 @@ func TestNegativeLR(t *testing.T) {
 
 	r, err := LRate(0, 1, "exp", -1.0)
 	assert.True(math.IsNaN(r))
-	assert.NotEmpty(err)
+	assert.True(errors.Is(err, ErrInvalidLRate))
 }
 
-func testLR(t *testing.T, strategy string) {
+func testLR(t *testing.T, strategy Decay) {
 	assert := assert.New(t)
 
 	learningRate0 := 100.0
@@ -40,4 +45,8 @@ func testLR(t *testing.T, strategy string) {
 	lr, err = LRate(totalIterations-1, totalIterations, strategy, learningRate0)
 	assert.InDelta(MinLRate, lr, 0.01)
 	assert.NoError(err)
+
+	lr, err = LRate(0, 1, strategy, learningRate0)
+	assert.Equal(learningRate0, lr)
+	assert.NoError(err)
 }
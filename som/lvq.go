@@ -0,0 +1,335 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// LVQRule is learning vector quantization training rule
+type LVQRule string
+
+const (
+	// LVQ1 moves the closest prototype towards the sample if they have the same class
+	// and away from the sample otherwise
+	LVQ1 LVQRule = "lvq1"
+	// LVQ21 moves the two closest prototypes, exactly one of which has the sample class,
+	// if the sample falls into the window between them: the prototype of the sample class
+	// is moved towards the sample, the other one away from it
+	LVQ21 LVQRule = "lvq2.1"
+	// LVQ3 extends LVQ21: if both closest prototypes have the sample class, they are moved
+	// towards the sample by the learning rate scaled by Epsilon
+	LVQ3 LVQRule = "lvq3"
+)
+
+const (
+	// DefaultLVQWindow is the default relative window width of lvq2.1 and lvq3 rules
+	DefaultLVQWindow = 0.3
+	// DefaultLVQEpsilon is the default learning rate scale of lvq3 same class updates
+	DefaultLVQEpsilon = 0.2
+)
+
+// LVQConfig holds learning vector quantization training configuration.
+// Fields which are not set use the documented defaults.
+type LVQConfig struct {
+	// Rule is training rule: lvq1, lvq2.1, lvq3. The default rule is lvq1.
+	Rule LVQRule
+	// Prototypes is the number of prototypes of every class. The default is 1.
+	Prototypes int
+	// ClassPrototypes overrides the number of prototypes of listed classes
+	ClassPrototypes map[string]int
+	// LRate is initial learning rate. The default is 0.1 for lvq1 and 0.03 for lvq2.1 and lvq3.
+	LRate float64
+	// LDecay is learning rate decay strategy: lin, exp, inv. The default is lin.
+	LDecay Decay
+	// Window is relative width of the window of lvq2.1 and lvq3 rules: it must be between 0 and 1.
+	// The default is DefaultLVQWindow.
+	Window float64
+	// Epsilon scales learning rate of lvq3 updates of two prototypes of the sample class:
+	// it must be between 0 and 1. The default is DefaultLVQEpsilon.
+	Epsilon float64
+	// Relevance is initial learning rate of generalized relevance LVQ adaptation of feature relevances,
+	// which decays in the same way as LRate. Relevances start equal and are used by BMU searches of
+	// the training and of the returned map. The default zero rate disables relevance learning.
+	Relevance float64
+	// Iters is the number of training iterations; every iteration picks one random sample.
+	// The default is 10 times the number of data rows.
+	Iters int
+	// Seed is the seed of prototype initialization and sample selection
+	Seed int64
+}
+
+// withDefaults returns a copy of the configuration with defaults of unset fields for rows data samples
+func (c LVQConfig) withDefaults(rows int) LVQConfig {
+	if c.Rule == "" {
+		c.Rule = LVQ1
+	}
+	if c.Prototypes == 0 {
+		c.Prototypes = 1
+	}
+	if c.LRate == 0 {
+		c.LRate = 0.1
+		if c.Rule != LVQ1 {
+			c.LRate = 0.03
+		}
+	}
+	if c.LDecay == "" {
+		c.LDecay = LinDecay
+	}
+	if c.Window == 0 {
+		c.Window = DefaultLVQWindow
+	}
+	if c.Epsilon == 0 {
+		c.Epsilon = DefaultLVQEpsilon
+	}
+	if c.Iters == 0 {
+		c.Iters = 10 * rows
+	}
+	return c
+}
+
+// lvq validates learning vector quantization configuration with applied defaults
+// for data set with the given class sample counts
+func (v *validator) lvq(c LVQConfig, classes map[string]int) {
+	if c.Rule != LVQ1 && c.Rule != LVQ21 && c.Rule != LVQ3 {
+		if v.param("LVQConfig.Rule", c.Rule, fmt.Errorf("unsupported lvq rule: %s", c.Rule)) {
+			return
+		}
+	}
+	if c.Prototypes < 0 {
+		if v.param("LVQConfig.Prototypes", c.Prototypes, fmt.Errorf("invalid number of prototypes: %d", c.Prototypes)) {
+			return
+		}
+	}
+	for _, class := range sortedKeys(c.ClassPrototypes) {
+		if _, ok := classes[class]; !ok {
+			if v.param(fmt.Sprintf("LVQConfig.ClassPrototypes[%s]", class), c.ClassPrototypes[class], fmt.Errorf("invalid number of prototypes of class %s: class not found in labels", class)) {
+				return
+			}
+		} else if n := c.ClassPrototypes[class]; n <= 0 {
+			if v.param(fmt.Sprintf("LVQConfig.ClassPrototypes[%s]", class), c.ClassPrototypes[class], fmt.Errorf("invalid number of prototypes of class %s: %d", class, n)) {
+				return
+			}
+		}
+	}
+	if !(c.LRate > 0) || math.IsInf(c.LRate, 1) {
+		if v.param("LVQConfig.LRate", c.LRate, fmt.Errorf("%w: %f, must be positive and finite", ErrInvalidLRate, c.LRate)) {
+			return
+		}
+	}
+	if decayFunc(string(c.LDecay)) == nil {
+		if v.param("LVQConfig.LDecay", c.LDecay, fmt.Errorf("%w for learning rate: %s", ErrUnsupportedDecay, c.LDecay)) {
+			return
+		}
+	}
+	if !(c.Window > 0 && c.Window < 1) {
+		if v.param("LVQConfig.Window", c.Window, fmt.Errorf("invalid lvq window: %f, must be between 0 and 1", c.Window)) {
+			return
+		}
+	}
+	if !(c.Epsilon > 0 && c.Epsilon < 1) {
+		if v.param("LVQConfig.Epsilon", c.Epsilon, fmt.Errorf("invalid lvq epsilon: %f, must be between 0 and 1", c.Epsilon)) {
+			return
+		}
+	}
+	if !(c.Relevance >= 0) || math.IsInf(c.Relevance, 1) {
+		if v.param("LVQConfig.Relevance", c.Relevance, fmt.Errorf("invalid relevance rate: %f, must be non-negative and finite", c.Relevance)) {
+			return
+		}
+	}
+	if c.Iters < 2 {
+		v.param("LVQConfig.Iters", c.Iters, fmt.Errorf("invalid number of iterations: %d", c.Iters))
+	}
+}
+
+// sortedKeys returns sorted keys of m
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TrainLVQ trains labeled prototypes on data using learning vector quantization.
+// Prototypes of every class are initialized by randomly chosen samples of the class and
+// then trained by the configured rule. The returned map holds one unit per prototype,
+// labeled by its class, so it can be used with Classify. Unit label purity is the fraction
+// of training samples with the unit as their BMU which have the unit class.
+// The map units are laid out in a single grid row and the map has no topology.
+// If cfg Relevance rate is positive, the map also learns feature relevances, see FeatureRelevance.
+// TrainLVQ returns error if data is nil, if the number of labels is different from
+// the number of data rows, if any label is empty, if there are fewer than two classes or
+// a class has fewer samples than prototypes, or error which joins all problems of cfg.
+func TrainLVQ(data *mat64.Dense, labels []string, cfg LVQConfig) (*Map, error) {
+	// data can't be nil
+	if data == nil {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, cols := data.Dims()
+	if len(labels) != rows {
+		return nil, fmt.Errorf("invalid number of labels: %d", len(labels))
+	}
+	classes := make(map[string]int)
+	for i, label := range labels {
+		if label == "" {
+			return nil, fmt.Errorf("invalid label of data row %d: empty label", i)
+		}
+		classes[label]++
+	}
+	if len(classes) < 2 {
+		return nil, fmt.Errorf("invalid number of classes: %d, must be at least 2", len(classes))
+	}
+	c := cfg.withDefaults(rows)
+	v := &validator{}
+	v.lvq(c, classes)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	r := rand.New(rand.NewSource(c.Seed))
+	protos, protoLabels, err := initPrototypes(data, labels, c, sortedKeys(classes), r)
+	if err != nil {
+		return nil, err
+	}
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{1, len(protoLabels)},
+			Type:   Planar,
+			UShape: Rectangle,
+		},
+		Cb: &CbConfig{
+			Dim: cols,
+			InitFunc: func(*mat64.Dense, []int) (*mat64.Dense, error) {
+				return protos, nil
+			},
+		},
+	}
+	m, err := NewMap(mc, data)
+	if err != nil {
+		return nil, err
+	}
+	if c.Relevance > 0 {
+		m.relevance = uniformRelevance(cols)
+	}
+	m.lvqTrain(c, data, labels, protoLabels, r)
+	m.noTopology = true
+	m.labels = protoLabels
+	return m, m.lvqPurity(data, labels)
+}
+
+// initPrototypes returns the matrix of prototypes initialized by random samples of every class
+// and the prototype labels. Classes are processed in the order of the supplied class slice.
+func initPrototypes(data *mat64.Dense, labels []string, c LVQConfig, classes []string, r *rand.Rand) (*mat64.Dense, []string, error) {
+	samples := make(map[string][]int)
+	for i, label := range labels {
+		samples[label] = append(samples[label], i)
+	}
+	_, cols := data.Dims()
+	var vecs []float64
+	var protoLabels []string
+	for _, class := range classes {
+		n := c.Prototypes
+		if cn, ok := c.ClassPrototypes[class]; ok {
+			n = cn
+		}
+		idx := samples[class]
+		if len(idx) < n {
+			return nil, nil, fmt.Errorf("class %s has %d samples, fewer than %d prototypes", class, len(idx), n)
+		}
+		for _, j := range r.Perm(len(idx))[:n] {
+			vecs = append(vecs, data.RawRowView(idx[j])...)
+			protoLabels = append(protoLabels, class)
+		}
+	}
+	return mat64.NewDense(len(protoLabels), cols, vecs), protoLabels, nil
+}
+
+// lvqTrain trains map prototypes labeled by protoLabels on labeled data samples
+func (m *Map) lvqTrain(c LVQConfig, data *mat64.Dense, labels, protoLabels []string, r *rand.Rand) {
+	rows, _ := data.Dims()
+	// lvq2.1 and lvq3 window: ratio of the distances of the two closest prototypes
+	s := (1 - c.Window) / (1 + c.Window)
+	for i := 0; i < c.Iters; i++ {
+		k := r.Intn(rows)
+		x := data.RawRowView(k)
+		// no need to check for errors: LRate is checked by config validation
+		lRate, _ := LRate(i, c.Iters, c.LDecay, c.LRate)
+		if m.relevance != nil {
+			rate, _ := LRate(i, c.Iters, c.LDecay, c.Relevance)
+			m.grlvqStep(x, labels[k], protoLabels, rate)
+		}
+		first, second, d1, d2 := m.closestTwo(x)
+		if c.Rule == LVQ1 {
+			if protoLabels[first] == labels[k] {
+				m.moveUnit(first, x, lRate)
+			} else {
+				m.moveUnit(first, x, -lRate)
+			}
+			continue
+		}
+		match1, match2 := protoLabels[first] == labels[k], protoLabels[second] == labels[k]
+		switch {
+		case match1 != match2:
+			// the sample must fall into the window between the prototypes
+			if d2 > 0 && d1/d2 <= s {
+				continue
+			}
+			if match1 {
+				m.moveUnit(first, x, lRate)
+				m.moveUnit(second, x, -lRate)
+			} else {
+				m.moveUnit(first, x, -lRate)
+				m.moveUnit(second, x, lRate)
+			}
+		case match1 && c.Rule == LVQ3:
+			m.moveUnit(first, x, c.Epsilon*lRate)
+			m.moveUnit(second, x, c.Epsilon*lRate)
+		}
+	}
+}
+
+// closestTwo returns the two units closest to vector x and their distances to x
+func (m Map) closestTwo(x []float64) (int, int, float64, float64) {
+	units, _ := m.cbDims()
+	first, second := -1, -1
+	d1, d2 := math.Inf(1), math.Inf(1)
+	for i := 0; i < units; i++ {
+		d := m.unitDistance(x, i)
+		switch {
+		case d < d1:
+			second, d2 = first, d1
+			first, d1 = i, d
+		case d < d2:
+			second, d2 = i, d
+		}
+	}
+	return first, second, d1, d2
+}
+
+// lvqPurity sets label purity of every map unit to the fraction of samples
+// with the unit as their BMU which have the unit label
+func (m *Map) lvqPurity(data *mat64.Dense, labels []string) error {
+	bmus, err := m.BMUs(data)
+	if err != nil {
+		return err
+	}
+	hits := make([]int, len(m.labels))
+	correct := make([]int, len(m.labels))
+	for i, bmu := range bmus {
+		hits[bmu]++
+		if labels[i] == m.labels[bmu] {
+			correct[bmu]++
+		}
+	}
+	m.purity = make([]float64, len(m.labels))
+	for i := range hits {
+		if hits[i] > 0 {
+			m.purity[i] = float64(correct[i]) / float64(hits[i])
+		}
+	}
+	return nil
+}
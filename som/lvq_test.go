@@ -0,0 +1,157 @@
+package som
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrainLVQErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data, labels := makeMoons(20, 0.05, 7)
+	errString := "invalid data supplied: %v"
+	_, err := TrainLVQ(nil, labels, LVQConfig{})
+	assert.EqualError(err, fmt.Sprintf(errString, nil))
+	_, err = TrainLVQ(data, labels[:5], LVQConfig{})
+	assert.EqualError(err, "invalid number of labels: 5")
+	oneClass := make([]string, 20)
+	for i := range oneClass {
+		oneClass[i] = "a"
+	}
+	_, err = TrainLVQ(data, oneClass, LVQConfig{})
+	assert.EqualError(err, "invalid number of classes: 1, must be at least 2")
+	oneClass[3] = ""
+	_, err = TrainLVQ(data, oneClass, LVQConfig{})
+	assert.EqualError(err, "invalid label of data row 3: empty label")
+	_, err = TrainLVQ(data, labels, LVQConfig{Prototypes: 11})
+	assert.EqualError(err, "class lower has 10 samples, fewer than 11 prototypes")
+	// all configuration problems are reported
+	cfg := LVQConfig{
+		Rule:            "lvq4",
+		Prototypes:      -1,
+		ClassPrototypes: map[string]int{"upper": 0, "middle": 2},
+		LRate:           -0.5,
+		LDecay:          "foo",
+		Window:          1.5,
+		Epsilon:         -0.1,
+		Relevance:       -0.1,
+		Iters:           1,
+	}
+	_, err = TrainLVQ(data, labels, cfg)
+	assert.Error(err)
+	for _, msg := range []string{
+		"unsupported lvq rule: lvq4",
+		"invalid number of prototypes: -1",
+		"invalid number of prototypes of class middle: class not found in labels",
+		"invalid number of prototypes of class upper: 0",
+		"invalid learning rate: -0.500000, must be positive and finite",
+		"unsupported decay strategy for learning rate: foo",
+		"invalid lvq window: 1.500000, must be between 0 and 1",
+		"invalid lvq epsilon: -0.100000, must be between 0 and 1",
+		"invalid relevance rate: -0.100000, must be non-negative and finite",
+		"invalid number of iterations: 1",
+	} {
+		assert.Contains(err.Error(), msg)
+	}
+	assert.True(errors.Is(err, ErrInvalidLRate))
+	assert.True(errors.Is(err, ErrUnsupportedDecay))
+}
+
+func TestTrainLVQPrototypes(t *testing.T) {
+	assert := assert.New(t)
+
+	data, labels := makeMoons(100, 0.05, 7)
+	cfg := LVQConfig{Prototypes: 2, ClassPrototypes: map[string]int{"upper": 3}, Iters: 100}
+	m, err := TrainLVQ(data, labels, cfg)
+	assert.NoError(err)
+	assert.Equal([]string{"lower", "lower", "upper", "upper", "upper"}, m.UnitLabels())
+	units, dim := m.CodebookRaw().Dims()
+	assert.Equal(5, units)
+	assert.Equal(2, dim)
+	assert.Equal([]int{1, 5}, m.Dims())
+	assert.False(m.HasTopology())
+	_, err = m.UMatrixValues()
+	assert.True(errors.Is(err, ErrNoTopology))
+	for _, p := range m.purity {
+		assert.True(p >= 0.0 && p <= 1.0)
+	}
+	// training is reproducible
+	other, err := TrainLVQ(data, labels, cfg)
+	assert.NoError(err)
+	assert.True(mat64.Equal(m.CodebookRaw(), other.CodebookRaw()))
+	cfg.Seed = 1
+	other, err = TrainLVQ(data, labels, cfg)
+	assert.NoError(err)
+	assert.False(mat64.Equal(m.CodebookRaw(), other.CodebookRaw()))
+}
+
+// accuracy returns the fraction of test samples classified by m with their truth labels
+func accuracy(t *testing.T, m *Map, test *mat64.Dense, truth []string) float64 {
+	pred, _, err := m.ClassifyAll(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	correct := 0
+	for i := range pred {
+		if pred[i] == truth[i] {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(pred))
+}
+
+func TestTrainLVQAccuracy(t *testing.T) {
+	assert := assert.New(t)
+
+	data, labels := makeMoons(400, 0.1, 7)
+	test, truth := makeMoons(400, 0.1, 8)
+	// majority vote labeling of SOM with the same number of units as LVQ prototypes
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{2, 4},
+			Type:   Planar,
+			UShape: Hexagon,
+		},
+		Cb: &CbConfig{
+			Dim:      2,
+			InitFunc: RandInit,
+		},
+	}
+	tCfg := &TrainConfig{
+		Algorithm: Seq,
+		Radius:    2.0,
+		RDecay:    ExpDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    ExpDecay,
+	}
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	assert.NoError(m.Train(tCfg, data, 4000))
+	assert.NoError(m.LabelUnits(data, labels))
+	somAcc := accuracy(t, m, test, truth)
+
+	for _, rule := range []LVQRule{LVQ1, LVQ21, LVQ3} {
+		lvq, err := TrainLVQ(data, labels, LVQConfig{Rule: rule, Prototypes: 4, Iters: 4000})
+		assert.NoError(err)
+		acc := accuracy(t, lvq, test, truth)
+		assert.True(acc >= somAcc, "%s: lvq accuracy %f, som accuracy %f", rule, acc, somAcc)
+	}
+}
+
+func BenchmarkTrainLVQ(b *testing.B) {
+	data, labels := makeMoons(1000, 0.1, 7)
+	for _, rule := range []LVQRule{LVQ1, LVQ21, LVQ3} {
+		b.Run(string(rule), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := TrainLVQ(data, labels, LVQConfig{Rule: rule, Prototypes: 10}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
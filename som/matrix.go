@@ -0,0 +1,104 @@
+package som
+
+import (
+	"io"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// isNilMatrix returns true if m is nil or a nil *mat64.Dense
+func isNilMatrix(m mat64.Matrix) bool {
+	if m == nil {
+		return true
+	}
+	d, ok := m.(*mat64.Dense)
+	return ok && d == nil
+}
+
+// rowReader reads rows of any mat64.Matrix. Rows of *mat64.Dense are returned as views
+// without copying, rows of *SparseMatrix are expanded from their non-zero elements and rows
+// of other matrices are copied using At into a buffer, so every
+// returned row is only valid until the next call to row. rowReader must not be shared
+// between goroutines.
+type rowReader struct {
+	// m is the read matrix
+	m mat64.Matrix
+	// dense is m if it is *mat64.Dense, otherwise it is nil
+	dense *mat64.Dense
+	// sparse is m if it is *SparseMatrix, otherwise it is nil
+	sparse *SparseMatrix
+	// buf holds the last row copied from m
+	buf []float64
+}
+
+// newRowReader returns rowReader of matrix m
+func newRowReader(m mat64.Matrix) *rowReader {
+	if d, ok := m.(*mat64.Dense); ok {
+		return &rowReader{m: m, dense: d}
+	}
+	_, cols := m.Dims()
+	sparse, _ := m.(*SparseMatrix)
+	return &rowReader{m: m, sparse: sparse, buf: make([]float64, cols)}
+}
+
+// row returns i-th matrix row
+func (r *rowReader) row(i int) []float64 {
+	if r.dense != nil {
+		return r.dense.RawRowView(i)
+	}
+	if r.sparse != nil {
+		return r.sparse.denseRow(r.buf, i)
+	}
+	return mat64.Row(r.buf, i, r.m)
+}
+
+// matrixBlockBMUs works like blockBMUs, but accepts any mat64.Matrix as data.
+// Data which is not *mat64.Dense is copied into a scratch matrix one block of rows at a time.
+func matrixBlockBMUs(data mat64.Matrix, cb *mat64.Dense, norms []float64, from, to int, bmus []int, dists []float64, mul MatMulFunc) {
+	if d, ok := data.(*mat64.Dense); ok {
+		blockBMUs(d, cb, norms, from, to, bmus, dists, mul)
+		return
+	}
+	_, cols := data.Dims()
+	rd := newRowReader(data)
+	buf := make([]float64, bmuBlockRows*cols)
+	for b := from; b < to; b += bmuBlockRows {
+		n := bmuBlockRows
+		if b+n > to {
+			n = to - b
+		}
+		block := mat64.NewDense(n, cols, buf[:n*cols])
+		for i := 0; i < n; i++ {
+			copy(block.RawRowView(i), rd.row(b+i))
+		}
+		var blockDists []float64
+		if dists != nil {
+			blockDists = dists[b-from : b-from+n]
+		}
+		blockBMUs(block, cb, norms, 0, n, bmus[b-from:b-from+n], blockDists, mul)
+	}
+}
+
+// MatrixRows returns RowSource which reads the rows of matrix m, e.g. to pass it to PredictStream.
+// The rows returned by the source must not be modified: rows of *mat64.Dense are views of m.
+func MatrixRows(m mat64.Matrix) RowSource {
+	return &matrixRows{r: newRowReader(m)}
+}
+
+// matrixRows is RowSource of matrix rows
+type matrixRows struct {
+	// r reads matrix rows
+	r *rowReader
+	// next is the index of the next row
+	next int
+}
+
+// Next returns the next matrix row or io.EOF after the last row
+func (s *matrixRows) Next() ([]float64, error) {
+	rows, _ := s.r.m.Dims()
+	if s.next >= rows {
+		return nil, io.EOF
+	}
+	s.next++
+	return s.r.row(s.next - 1), nil
+}
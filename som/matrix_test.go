@@ -0,0 +1,69 @@
+package som
+
+import (
+	"io"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+// matrixOnly hides the concrete type of the embedded matrix
+type matrixOnly struct {
+	mat64.Matrix
+}
+
+func TestIsNilMatrix(t *testing.T) {
+	assert := assert.New(t)
+
+	var d *mat64.Dense
+	assert.True(isNilMatrix(nil))
+	assert.True(isNilMatrix(d))
+	assert.False(isNilMatrix(mat64.NewDense(1, 1, nil)))
+	assert.False(isNilMatrix(matrixOnly{mat64.NewDense(1, 1, nil)}))
+}
+
+func TestRowReader(t *testing.T) {
+	assert := assert.New(t)
+
+	data := mat64.NewDense(3, 2, []float64{1, 2, 3, 4, 5, 6})
+	// dense rows are views
+	rd := newRowReader(data)
+	assert.Equal([]float64{3, 4}, rd.row(1))
+	assert.True(&rd.row(1)[0] == &data.RawRowView(1)[0])
+	// other matrices are read using At
+	rd = newRowReader(data.T())
+	assert.Equal([]float64{1, 3, 5}, rd.row(0))
+	assert.Equal([]float64{2, 4, 6}, rd.row(1))
+
+	src := MatrixRows(matrixOnly{data})
+	for i := 0; i < 3; i++ {
+		row, err := src.Next()
+		assert.NoError(err)
+		assert.Equal(data.RawRowView(i), row)
+	}
+	_, err := src.Next()
+	assert.Equal(io.EOF, err)
+}
+
+func TestMatrixBlockBMUs(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := matrix.MakeRandom(2*bmuBlockRows+10, 4, -10.0, 10.0)
+	cb, _ := matrix.MakeRandom(12, 4, -10.0, 10.0)
+	norms := sqNorms(cb)
+	rows, _ := data.Dims()
+	from, to := 5, rows-3
+	bmus := make([]int, to-from)
+	dists := make([]float64, to-from)
+	blockBMUs(data, cb, norms, from, to, bmus, dists, nil)
+	mBmus := make([]int, to-from)
+	mDists := make([]float64, to-from)
+	matrixBlockBMUs(matrixOnly{data}, cb, norms, from, to, mBmus, mDists, nil)
+	assert.Equal(bmus, mBmus)
+	assert.Equal(dists, mDists)
+	// distances are optional
+	matrixBlockBMUs(matrixOnly{data}, cb, norms, from, to, mBmus, nil, nil)
+	assert.Equal(bmus, mBmus)
+}
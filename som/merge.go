@@ -0,0 +1,229 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	// mergeSamplesPerUnit is the number of merge training samples per unit of merged maps
+	mergeSamplesPerUnit = 10
+	// mergeTuneRadius is initial radius of fine-tuning of merged map
+	mergeTuneRadius = 2.0
+)
+
+// MergeMaps creates new map with grid dimensions dims from codebooks of maps a and b without
+// the data the maps were trained on. Codebook vectors of both maps form the training set of the new
+// map: every vector is weighted by the weight of its map and, if quality of the map was recorded by
+// RecordQuality, by the number of samples which had the unit as their BMU, so units which were not
+// BMU of any sample are left out. The training set replicates every vector in proportion to its
+// weight. The new map has the grid type and unit shape of map a, is initialized by LinInit and is
+// trained by tc running the same number of iterations as TrainRelational. If data is not nil,
+// the merged map is fine-tuned by one pass through data with half of the tc learning rate and
+// initial radius 2; batch training runs 10 epochs.
+// All maps use euclidean distance. MergeMaps returns error if a or b are nil, if their codebook
+// dimensions differ, if weights are negative, not finite or both zero, if dims or tc are invalid,
+// if data dimension is different from the codebook dimension or if the training fails.
+func MergeMaps(a, b *Map, weights [2]float64, dims []int, tc *TrainConfig, data *mat64.Dense) (*Map, error) {
+	return mergeMaps(a, b, weights, dims, tc, data, trainRand(tc))
+}
+
+// mergeMaps merges maps a and b using r as the source of randomness of training
+func mergeMaps(a, b *Map, weights [2]float64, dims []int, tc *TrainConfig, data *mat64.Dense, r *rand.Rand) (*Map, error) {
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("invalid maps supplied: %v, %v", a, b)
+	}
+	_, aDim := a.cbDims()
+	_, bDim := b.cbDims()
+	if aDim != bDim {
+		return nil, fmt.Errorf("invalid maps supplied: codebook dimensions %d and %d: %w", aDim, bDim, ErrDimMismatch)
+	}
+	for _, w := range weights {
+		if !(w >= 0) || math.IsInf(w, 1) {
+			return nil, fmt.Errorf("invalid merge weights: %v, must be non-negative and finite", weights)
+		}
+	}
+	if weights[0] == 0 && weights[1] == 0 {
+		return nil, fmt.Errorf("invalid merge weights: %v, must not both be zero", weights)
+	}
+	if data != nil {
+		if _, cols := data.Dims(); cols != aDim {
+			return nil, ErrDimMismatch
+		}
+	}
+	if err := validateTrainConfig(tc); err != nil {
+		return nil, err
+	}
+	vecs, vecWeights := mergeVectors(a, weights[0])
+	bVecs, bWeights := mergeVectors(b, weights[1])
+	vecs, vecWeights = append(vecs, bVecs...), append(vecWeights, bWeights...)
+	set := mergeSet(vecs, vecWeights, mergeSamplesPerUnit*len(vecs), aDim)
+	if set == nil {
+		return nil, fmt.Errorf("invalid maps supplied: no unit has positive weight")
+	}
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:         dims,
+			Type:         GridType(a.grid.Type()),
+			UShape:       UnitShape(a.grid.UShape()),
+			Connectivity: Connectivity(a.grid.connectivity),
+		},
+		Cb: &CbConfig{
+			Dim:      aDim,
+			InitFunc: LinInit,
+		},
+	}
+	m, err := NewMap(mc, set)
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := set.Dims()
+	if _, err := m.train(tc, set, relIters(tc, rows), r); err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return m, nil
+	}
+	// fine-tune the merged map on data
+	fc := *tc
+	fc.Radius, fc.AutoRadius, fc.RSchedule, fc.Phases = mergeTuneRadius, false, nil, nil
+	_, lRate := tc.initial(1)
+	fc.LRate, fc.LSchedule = lRate/2, nil
+	iters := 10
+	if fc.Algorithm != Batch {
+		iters, _ = data.Dims()
+	}
+	if _, err := m.train(&fc, data, iters, r); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// mergeVectors returns codebook vectors of map m and their merge weights: map weight w
+// scaled by unit hit counts recorded by RecordQuality if the map quality was recorded
+func mergeVectors(m *Map, w float64) ([][]float64, []float64) {
+	cb := m.cb()
+	units, _ := cb.Dims()
+	vecs := make([][]float64, units)
+	weights := make([]float64, units)
+	for i := range vecs {
+		vecs[i] = cb.RawRowView(i)
+		weights[i] = w
+		if m.quality != nil {
+			weights[i] *= float64(m.quality.hits[i])
+		}
+	}
+	return vecs, weights
+}
+
+// mergeSet returns training set of n rows which replicates vectors in proportion to their weights.
+// The copies are allocated by largest remainders. It returns nil if no vector has positive weight.
+func mergeSet(vecs [][]float64, weights []float64, n, dim int) *mat64.Dense {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		return nil
+	}
+	copies := make([]int, len(vecs))
+	rems := make([]float64, len(vecs))
+	count := 0
+	for i, w := range weights {
+		share := w / total * float64(n)
+		copies[i] = int(share)
+		rems[i] = share - float64(copies[i])
+		count += copies[i]
+	}
+	idx := make([]int, len(vecs))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return rems[idx[i]] > rems[idx[j]] })
+	for _, i := range idx[:n-count] {
+		copies[i]++
+	}
+	set := mat64.NewDense(n, dim, nil)
+	row := 0
+	for i, c := range copies {
+		for j := 0; j < c; j++ {
+			set.SetRow(row, vecs[i])
+			row++
+		}
+	}
+	return set
+}
+
+// FuseMaps fuses maps trained independently, e.g. on shards of a dataset, into new map without the
+// data they were trained on. Units of every map are aligned with units of the first map by the optimal
+// assignment, which minimises the sum of euclidean distances between codebook vectors of matched units,
+// and every codebook vector of the fused map is the weighted mean of the codebook vectors matched to
+// the unit. If weights is nil, all maps have the same weight. The fused map has the grid of the first
+// map. All maps use euclidean distance. FuseMaps returns error if maps is empty or contains nil map,
+// if the number of weights is different from the number of maps, if weights are negative, not finite
+// or all zero, if the maps have different numbers of units or ErrDimMismatch if their codebook
+// dimensions differ.
+func FuseMaps(maps []*Map, weights []float64) (*Map, error) {
+	if len(maps) == 0 {
+		return nil, fmt.Errorf("invalid maps supplied: no maps")
+	}
+	for i, m := range maps {
+		if m == nil {
+			return nil, fmt.Errorf("invalid map %d supplied: %v", i, m)
+		}
+	}
+	if weights == nil {
+		weights = make([]float64, len(maps))
+		for i := range weights {
+			weights[i] = 1.0
+		}
+	}
+	if len(weights) != len(maps) {
+		return nil, fmt.Errorf("invalid number of fuse weights: %d, expected %d", len(weights), len(maps))
+	}
+	total := 0.0
+	for _, w := range weights {
+		if !(w >= 0) || math.IsInf(w, 1) {
+			return nil, fmt.Errorf("invalid fuse weights: %v, must be non-negative and finite", weights)
+		}
+		total += w
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("invalid fuse weights: %v, must not all be zero", weights)
+	}
+	units, dim := maps[0].cbDims()
+	for i, m := range maps[1:] {
+		mUnits, mDim := m.cbDims()
+		if mDim != dim {
+			return nil, fmt.Errorf("invalid map %d supplied: codebook dimensions %d and %d: %w", i+1, dim, mDim, ErrDimMismatch)
+		}
+		if mUnits != units {
+			return nil, fmt.Errorf("invalid map %d supplied: %d units, expected %d", i+1, mUnits, units)
+		}
+	}
+	ref := maps[0].cb()
+	fused := mat64.NewDense(units, dim, nil)
+	cost := mat64.NewDense(units, units, nil)
+	for k, m := range maps {
+		cb := m.cb()
+		for i := 0; i < units; i++ {
+			for j := 0; j < units; j++ {
+				cost.Set(i, j, euclideanVec(ref.RawRowView(i), cb.RawRowView(j)))
+			}
+		}
+		w := weights[k] / total
+		for i, j := range assignment(cost) {
+			floats.AddScaled(fused.RawRowView(i), w, cb.RawRowView(j))
+		}
+	}
+	return &Map{
+		codebook: fused,
+		grid:     maps[0].grid,
+		norms:    sqNorms(fused),
+	}, nil
+}
@@ -0,0 +1,228 @@
+package som
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// mergeConfigs returns configuration of map of the given dimensions and training configuration of merge tests
+func mergeConfigs(dims []int) (*MapConfig, *TrainConfig) {
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   dims,
+			Type:   Planar,
+			UShape: Hexagon,
+		},
+		Cb: &CbConfig{
+			Dim:      2,
+			InitFunc: LinInit,
+		},
+	}
+	tc := &TrainConfig{
+		Algorithm: Seq,
+		Radius:    3.0,
+		RDecay:    ExpDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    ExpDecay,
+	}
+	return mc, tc
+}
+
+// splitRows returns data rows whose index modulo k is lower than k/2 and the remaining rows
+func splitRows(data *mat64.Dense, k int) (*mat64.Dense, *mat64.Dense) {
+	rows, cols := data.Dims()
+	var first, second []float64
+	for i := 0; i < rows; i++ {
+		if i%k < k/2 {
+			first = append(first, data.RawRowView(i)...)
+		} else {
+			second = append(second, data.RawRowView(i)...)
+		}
+	}
+	return mat64.NewDense(len(first)/cols, cols, first), mat64.NewDense(len(second)/cols, cols, second)
+}
+
+// trainMergeMap trains new map of the given dimensions on data
+func trainMergeMap(t *testing.T, data *mat64.Dense, dims []int) *Map {
+	mc, tc := mergeConfigs(dims)
+	m, err := NewMap(mc, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := data.Dims()
+	if _, err := m.train(tc, data, 10*rows, rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMergeMaps(t *testing.T) {
+	assert := assert.New(t)
+
+	// every tenant holds samples of different blobs
+	data := makeBlobs(600, 6, 1.0, 1)
+	test := makeBlobs(600, 6, 1.0, 2)
+	dataA, dataB := splitRows(data, 6)
+	_, testB := splitRows(test, 6)
+	a := trainMergeMap(t, dataA, []int{4, 4})
+	b := trainMergeMap(t, dataB, []int{4, 4})
+	assert.NoError(a.RecordQuality(dataA))
+	assert.NoError(b.RecordQuality(dataB))
+	full := trainMergeMap(t, data, []int{6, 6})
+	fullQE, err := full.QuantError(test)
+	assert.NoError(err)
+
+	_, tc := mergeConfigs(nil)
+	merged, err := mergeMaps(a, b, [2]float64{1, 1}, []int{6, 6}, tc, nil, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	assert.Equal([]int{6, 6}, merged.Dims())
+	assert.Equal("hexagon", merged.UShape())
+	mergedQE, err := merged.QuantError(test)
+	assert.NoError(err)
+	assert.True(mergedQE < 1.25*fullQE, "merged: %f, full: %f", mergedQE, fullQE)
+	aQE, err := a.QuantError(test)
+	assert.NoError(err)
+	assert.True(mergedQE < aQE/2, "merged: %f, a: %f", mergedQE, aQE)
+
+	// fine-tuning on raw data
+	tuned, err := mergeMaps(a, b, [2]float64{1, 1}, []int{6, 6}, tc, data, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	tunedQE, err := tuned.QuantError(test)
+	assert.NoError(err)
+	assert.True(tunedQE < 1.25*fullQE, "tuned: %f, full: %f", tunedQE, fullQE)
+
+	// zero weight leaves the map out
+	onlyA, err := mergeMaps(a, b, [2]float64{1, 0}, []int{6, 6}, tc, nil, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	onlyQE, err := onlyA.QuantError(testB)
+	assert.NoError(err)
+	mergedBQE, err := merged.QuantError(testB)
+	assert.NoError(err)
+	assert.True(onlyQE > 2*mergedBQE, "only a: %f, merged: %f", onlyQE, mergedBQE)
+}
+
+func TestMergeSet(t *testing.T) {
+	assert := assert.New(t)
+
+	vecs := [][]float64{{1.0}, {2.0}, {3.0}}
+	set := mergeSet(vecs, []float64{1.0, 0.0, 2.0}, 4, 1)
+	// shares 4/3 and 8/3: the larger remainder gets the last copy
+	assert.Equal([]float64{1.0, 3.0, 3.0, 3.0}, set.RawMatrix().Data)
+	assert.Nil(mergeSet(vecs, []float64{0.0, 0.0, 0.0}, 4, 1))
+
+	// recorded hits weight codebook vectors
+	m := trainMergeMap(t, makeBlobs(40, 2, 0.5, 1), []int{2, 2})
+	_, weights := mergeVectors(m, 2.0)
+	assert.Equal([]float64{2.0, 2.0, 2.0, 2.0}, weights)
+	assert.NoError(m.RecordQuality(mat64.NewDense(2, 2, []float64{10.0, 0.0, 10.0, 0.0})))
+	_, weights = mergeVectors(m, 2.0)
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	assert.Equal(4.0, total)
+}
+
+func TestMergeMapsErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(40, 2, 0.5, 1)
+	a := trainMergeMap(t, data, []int{2, 2})
+	_, tc := mergeConfigs(nil)
+	dims := []int{3, 3}
+	_, err := MergeMaps(nil, a, [2]float64{1, 1}, dims, tc, nil)
+	assert.EqualError(err, "invalid maps supplied: <nil>, "+a.String())
+	other, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{2, 2}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 3, InitFunc: RandInit},
+	}, mat64.NewDense(2, 3, nil))
+	assert.NoError(err)
+	_, err = MergeMaps(a, other, [2]float64{1, 1}, dims, tc, nil)
+	assert.EqualError(err, "invalid maps supplied: codebook dimensions 2 and 3: "+ErrDimMismatch.Error())
+	assert.True(errors.Is(err, ErrDimMismatch))
+	_, err = MergeMaps(a, a, [2]float64{-1, 1}, dims, tc, nil)
+	assert.EqualError(err, "invalid merge weights: [-1 1], must be non-negative and finite")
+	_, err = MergeMaps(a, a, [2]float64{0, 0}, dims, tc, nil)
+	assert.EqualError(err, "invalid merge weights: [0 0], must not both be zero")
+	_, err = MergeMaps(a, a, [2]float64{1, 1}, dims, tc, mat64.NewDense(2, 3, nil))
+	assert.Equal(ErrDimMismatch, err)
+	_, err = MergeMaps(a, a, [2]float64{1, 1}, []int{0, 3}, tc, nil)
+	assert.Error(err)
+	_, err = MergeMaps(a, a, [2]float64{1, 1}, dims, nil, nil)
+	assert.Error(err)
+	// units which were not BMU of any sample are left out
+	assert.NoError(a.RecordQuality(mat64.NewDense(1, 2, []float64{1e6, 1e6})))
+	assert.NoError(other.RecordQuality(mat64.NewDense(1, 3, nil)))
+	_, err = MergeMaps(a, a, [2]float64{1, 1}, dims, tc, nil)
+	assert.NoError(err)
+}
+
+func TestFuseMaps(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(400, 4, 1.0, 1)
+	a := trainMergeMap(t, data, []int{4, 4})
+	units, _ := a.cbDims()
+	// b holds codebook vectors of a shifted by 0.01 in reversed order
+	perm := mat64.NewDense(units, 2, nil)
+	for i := 0; i < units; i++ {
+		vec := a.codebook.RawRowView(units - 1 - i)
+		perm.SetRow(i, []float64{vec[0] + 0.01, vec[1] + 0.01})
+	}
+	b := &Map{codebook: perm, grid: a.grid, norms: sqNorms(perm)}
+
+	fused, err := FuseMaps([]*Map{a, a}, nil)
+	assert.NoError(err)
+	assert.InDeltaSlice(a.codebook.RawMatrix().Data, fused.codebook.RawMatrix().Data, 1e-9)
+	assert.Equal(a.Dims(), fused.Dims())
+
+	fused, err = FuseMaps([]*Map{a, b}, []float64{3, 1})
+	assert.NoError(err)
+	for i := 0; i < units; i++ {
+		vec := a.codebook.RawRowView(i)
+		assert.InDeltaSlice([]float64{vec[0] + 0.0025, vec[1] + 0.0025}, fused.codebook.RawRowView(i), 1e-9)
+	}
+
+	// maps trained on shards fuse into a map close to both
+	dataA, dataB := splitRows(data, 4)
+	shardA := trainMergeMap(t, dataA, []int{4, 4})
+	shardB := trainMergeMap(t, dataB, []int{4, 4})
+	fused, err = FuseMaps([]*Map{shardA, shardB}, nil)
+	assert.NoError(err)
+	fusedQE, err := fused.QuantError(data)
+	assert.NoError(err)
+	assert.False(math.IsNaN(fusedQE))
+}
+
+func TestFuseMapsErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(40, 2, 0.5, 1)
+	a := trainMergeMap(t, data, []int{2, 2})
+	_, err := FuseMaps(nil, nil)
+	assert.EqualError(err, "invalid maps supplied: no maps")
+	_, err = FuseMaps([]*Map{a, nil}, nil)
+	assert.EqualError(err, "invalid map 1 supplied: <nil>")
+	_, err = FuseMaps([]*Map{a, a}, []float64{1})
+	assert.EqualError(err, "invalid number of fuse weights: 1, expected 2")
+	_, err = FuseMaps([]*Map{a, a}, []float64{1, math.Inf(1)})
+	assert.EqualError(err, "invalid fuse weights: [1 +Inf], must be non-negative and finite")
+	_, err = FuseMaps([]*Map{a, a}, []float64{0, 0})
+	assert.EqualError(err, "invalid fuse weights: [0 0], must not all be zero")
+	other, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{2, 2}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 3, InitFunc: RandInit},
+	}, mat64.NewDense(2, 3, nil))
+	assert.NoError(err)
+	_, err = FuseMaps([]*Map{a, other}, nil)
+	assert.True(errors.Is(err, ErrDimMismatch))
+	larger := trainMergeMap(t, data, []int{3, 2})
+	_, err = FuseMaps([]*Map{a, larger}, nil)
+	assert.EqualError(err, "invalid map 1 supplied: 6 units, expected 4")
+}
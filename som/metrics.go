@@ -0,0 +1,346 @@
+package som
+
+import (
+	"expvar"
+	"math"
+	"sync"
+	"time"
+)
+
+// Names of training metrics updated by TrainConfig.Metrics
+const (
+	// MetricIterations counts completed training iterations; batch iterations are epochs
+	MetricIterations = "iterations"
+	// MetricRadius is the current units radius; neural gas training reports its current range
+	MetricRadius = "radius"
+	// MetricLRate is the current learning rate; batch training does not report it
+	MetricLRate = "learning_rate"
+	// MetricQuantError is the mean distance of training samples to their BMUs since the previous update
+	MetricQuantError = "quant_error"
+	// MetricDeadUnits is the number of units which were not BMU of any training sample since the previous update
+	MetricDeadUnits = "dead_units"
+	// MetricDuration is the duration of the finished training in seconds
+	MetricDuration = "duration_seconds"
+)
+
+// Metrics receives SOM training metrics. Training updates metrics at the same iterations at which
+// it logs its progress. The interface is small, so it can be adapted to metrics client libraries.
+// Implementations must be safe for concurrent use if the same Metrics trains multiple maps at once.
+type Metrics interface {
+	// Add adds delta to counter name
+	Add(name string, delta float64)
+	// Set sets gauge name to value
+	Set(name string, value float64)
+}
+
+// Epoch holds progress of training at the end of an epoch
+type Epoch struct {
+	// Epoch is the number of the finished epoch starting from 0
+	Epoch int
+	// Iters is the number of completed training iterations
+	Iters int
+	// Radius is the current units radius; neural gas training reports its current range
+	Radius float64
+	// LRate is the current learning rate; it is zero in batch training
+	LRate float64
+	// QuantError is the mean distance of training samples to their BMUs in the epoch.
+	// It is NaN in temporal training and batch training with median or trimmed update rule.
+	QuantError float64
+	// ValidationError is the quantization error of the map on TrainConfig.Validation data at the end
+	// of the epoch. It is NaN if the training has no validation data.
+	ValidationError float64
+}
+
+// ValidationReport holds quantization errors of training epochs measured on training samples and on
+// held-out validation data, so that overfitting of the codebook to the training data can be detected
+type ValidationReport struct {
+	// TrainErrors holds the mean distance of training samples to their BMUs in every epoch,
+	// measured in the same way as Epoch.QuantError
+	TrainErrors []float64
+	// Errors holds the quantization error of the map on the validation data at the end of every epoch
+	Errors []float64
+	// BestEpoch is the epoch with the lowest validation error starting from 0
+	BestEpoch int
+	// BestError is the lowest validation error
+	BestError float64
+	// Overfitting is true if the validation error of the last epoch is higher than in BestEpoch
+	// while its training error is lower than in BestEpoch: the map fits the training data
+	// more closely but generalizes worse
+	Overfitting bool
+}
+
+// newValidationReport creates validation report of epochs with training errors train and validation
+// errors errors or returns nil if there are no epochs
+func newValidationReport(train, errors []float64) *ValidationReport {
+	if len(errors) == 0 {
+		return nil
+	}
+	r := &ValidationReport{TrainErrors: train, Errors: errors, BestError: math.Inf(1)}
+	for i, e := range errors {
+		if e < r.BestError {
+			r.BestEpoch, r.BestError = i, e
+		}
+	}
+	last := len(errors) - 1
+	r.Overfitting = errors[last] > r.BestError && train[last] < train[r.BestEpoch]
+	return r
+}
+
+// EpochFunc receives progress of training at the end of every epoch.
+// It returns false if the training should stop early.
+type EpochFunc func(e Epoch) bool
+
+// ExpvarMetrics publishes training metrics as float variables of an expvar map
+type ExpvarMetrics struct {
+	mu   sync.Mutex
+	vars *expvar.Map
+}
+
+// NewExpvarMetrics creates new metrics published as expvar map name. Like expvar.NewMap,
+// it panics if name is already published.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	return &ExpvarMetrics{vars: expvar.NewMap(name)}
+}
+
+// Add adds delta to counter name
+func (e *ExpvarMetrics) Add(name string, delta float64) {
+	e.vars.AddFloat(name, delta)
+}
+
+// Set sets gauge name to value
+func (e *ExpvarMetrics) Set(name string, value float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	f, ok := e.vars.Get(name).(*expvar.Float)
+	if !ok {
+		f = new(expvar.Float)
+		e.vars.Set(name, f)
+	}
+	f.Set(value)
+}
+
+// Vars returns the published expvar map
+func (e *ExpvarMetrics) Vars() *expvar.Map {
+	return e.vars
+}
+
+// trainMetrics collects BMU statistics of training samples between metrics updates.
+// Its buffers are allocated once, so recording and updates don't allocate any memory.
+// All its methods do nothing if it is nil.
+type trainMetrics struct {
+	// m is the trained map
+	m *Map
+	// tc is SOM training configuration
+	tc *TrainConfig
+	// iters is the number of training iterations
+	iters int
+	// every is the number of training iterations between updates
+	every int
+	// done is the number of iterations counted by the previous update
+	done int
+	// hits holds the number of samples of every unit since the previous update
+	hits []int
+	// dist is the sum of BMU distances of samples since the previous update
+	dist float64
+	// samples is the number of samples since the previous update
+	samples int
+	// epoch is the number of training iterations of an epoch
+	epoch int
+	// epochDist and epochSamples are the sum of BMU distances and the number of samples of the current epoch
+	epochDist    float64
+	epochSamples int
+	// errors holds quantization errors of finished epochs if errors are tracked
+	errors []float64
+	// trainErrors and valErrors hold training and validation quantization errors of finished epochs
+	// if the training has validation data
+	trainErrors, valErrors []float64
+	// stopped is the number of completed iterations if the training stopped early
+	stopped int
+	// best is the lowest epoch quantization error and wait the number of epochs since it improved
+	best float64
+	wait int
+}
+
+// newTrainMetrics returns metrics collector of iters training iterations of map m with units units
+// whose epochs have epoch iterations or nil if training configuration tc has no metrics,
+// does not track errors, has no patience, no OnEpoch function and no validation data
+func newTrainMetrics(m *Map, tc *TrainConfig, units, iters, epoch int) *trainMetrics {
+	if tc.Metrics == nil && !tc.TrackErrors && tc.Patience == 0 && tc.OnEpoch == nil && isNilMatrix(tc.Validation) {
+		return nil
+	}
+	return &trainMetrics{
+		m:     m,
+		tc:    tc,
+		iters: iters,
+		every: progressEvery(iters),
+		hits:  make([]int, units),
+		epoch: epoch,
+		best:  math.Inf(1),
+	}
+}
+
+// resize discards unit sample counts since the previous update of training whose map has grown to units units
+func (t *trainMetrics) resize(units int) {
+	if t == nil {
+		return
+	}
+	t.hits = make([]int, units)
+}
+
+// sample records BMU of a training sample and its distance to the sample
+func (t *trainMetrics) sample(bmu int, dist float64) {
+	if t == nil {
+		return
+	}
+	t.hits[bmu]++
+	t.dist += dist
+	t.samples++
+	t.epochDist += dist
+	t.epochSamples++
+}
+
+// batch records the number of samples of every unit and the sum of BMU distances of a batch epoch
+func (t *trainMetrics) batch(counts []float64, dist float64) {
+	if t == nil {
+		return
+	}
+	for i, c := range counts {
+		t.hits[i] += int(c)
+		t.samples += int(c)
+		t.epochSamples += int(c)
+	}
+	t.dist += dist
+	t.epochDist += dist
+}
+
+// update updates training metrics after iter-th training iteration if iter is the last iteration,
+// the last iteration of a progress logging interval or the last iteration before the training stops.
+// If iter-th iteration ends an epoch, it records the epoch quantization error if errors are tracked
+// and passes the epoch progress to OnEpoch; the last epoch may be shorter.
+// It returns false if OnEpoch or the lack of improvement of quantization error stop the training.
+func (t *trainMetrics) update(iter int) bool {
+	if t == nil {
+		return true
+	}
+	stop := false
+	if (iter+1)%t.epoch == 0 || iter == t.iters-1 {
+		stop = t.endEpoch(iter)
+	}
+	if t.tc.Metrics != nil && ((iter+1)%t.every == 0 || iter == t.iters-1 || stop) {
+		t.report(iter)
+	}
+	return !stop
+}
+
+// schedule returns radius, or range of neural gas training, and learning rate of training
+// other than batch at iter-th training iteration
+func (t *trainMetrics) schedule(iter int) (float64, float64) {
+	var radius, lRate float64
+	if t.tc.Algorithm == NeuralGas {
+		radius = t.tc.nRange(iter, t.iters)
+	} else {
+		radius = t.tc.radius(iter, t.iters)
+	}
+	if t.tc.Algorithm != Batch {
+		lRate = t.tc.lRate(iter, t.iters)
+	}
+	return radius, lRate
+}
+
+// endEpoch records the epoch which ends with iter-th iteration, measures the validation error
+// if the training has validation data and passes the epoch to OnEpoch. It returns true if OnEpoch stopped the training or if the quantization error has not improved
+// for Patience epochs.
+func (t *trainMetrics) endEpoch(iter int) bool {
+	qe := math.NaN()
+	converged := false
+	if t.epochSamples > 0 {
+		qe = t.epochDist / float64(t.epochSamples)
+		if t.tc.TrackErrors {
+			t.errors = append(t.errors, qe)
+		}
+		if qe < t.best-t.tc.MinDelta {
+			t.best, t.wait = qe, 0
+		} else {
+			t.wait++
+		}
+		converged = t.tc.Patience > 0 && t.wait >= t.tc.Patience
+	}
+	t.epochDist, t.epochSamples = 0.0, 0
+	ve := math.NaN()
+	if !isNilMatrix(t.tc.Validation) {
+		// validation data dimension is checked before the training starts
+		if e, err := t.m.QuantError(t.tc.Validation); err == nil {
+			ve = e
+		}
+		t.trainErrors = append(t.trainErrors, qe)
+		t.valErrors = append(t.valErrors, ve)
+	}
+	stop := converged
+	if t.tc.OnEpoch != nil {
+		radius, lRate := t.schedule(iter)
+		e := Epoch{Epoch: iter / t.epoch, Iters: iter + 1, Radius: radius, LRate: lRate, QuantError: qe, ValidationError: ve}
+		if !t.tc.OnEpoch(e) {
+			stop = true
+		}
+	}
+	if stop && iter < t.iters-1 {
+		t.stopped = iter + 1
+	}
+	return stop
+}
+
+// report updates training metrics after iter-th training iteration
+func (t *trainMetrics) report(iter int) {
+	m := t.tc.Metrics
+	m.Add(MetricIterations, float64(iter+1-t.done))
+	t.done = iter + 1
+	radius, lRate := t.schedule(iter)
+	m.Set(MetricRadius, radius)
+	if t.tc.Algorithm != Batch {
+		m.Set(MetricLRate, lRate)
+	}
+	if t.samples == 0 {
+		return
+	}
+	m.Set(MetricQuantError, t.dist/float64(t.samples))
+	dead := 0
+	for i, h := range t.hits {
+		if h == 0 {
+			dead++
+		}
+		t.hits[i] = 0
+	}
+	m.Set(MetricDeadUnits, float64(dead))
+	t.dist, t.samples = 0.0, 0
+}
+
+// quantErrors returns quantization errors of the finished epochs or nil if errors are not tracked
+func (t *trainMetrics) quantErrors() []float64 {
+	if t == nil {
+		return nil
+	}
+	return t.errors
+}
+
+// validation returns validation report of the finished epochs or nil if the training has no validation data
+func (t *trainMetrics) validation() *ValidationReport {
+	if t == nil {
+		return nil
+	}
+	return newValidationReport(t.trainErrors, t.valErrors)
+}
+
+// stoppedAt returns the number of completed iterations if the training stopped early or 0
+func (t *trainMetrics) stoppedAt() int {
+	if t == nil {
+		return 0
+	}
+	return t.stopped
+}
+
+// setDuration sets training duration metric of training configuration tc if it has metrics
+func setDuration(tc *TrainConfig, d time.Duration) {
+	if tc.Metrics != nil {
+		tc.Metrics.Set(MetricDuration, d.Seconds())
+	}
+}
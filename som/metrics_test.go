@@ -0,0 +1,448 @@
+package som
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/milosgajdos83/gosom/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+// recMetrics records metrics updates
+type recMetrics struct {
+	mu      sync.Mutex
+	names   []string
+	values  []float64
+	counter []bool
+}
+
+func (r *recMetrics) Add(name string, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names, r.values, r.counter = append(r.names, name), append(r.values, delta), append(r.counter, true)
+}
+
+func (r *recMetrics) Set(name string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names, r.values, r.counter = append(r.names, name), append(r.values, value), append(r.counter, false)
+}
+
+// series returns all values of metric name in update order
+func (r *recMetrics) series(name string) []float64 {
+	var values []float64
+	for i, n := range r.names {
+		if n == name {
+			values = append(values, r.values[i])
+		}
+	}
+	return values
+}
+
+// nopMetrics discards all updates
+type nopMetrics struct{}
+
+func (nopMetrics) Add(string, float64) {}
+func (nopMetrics) Set(string, float64) {}
+
+func TestTrainMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := matrix.MakeRandom(20, 3, -10.0, 10.0)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{4, 4},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      3,
+			InitFunc: LinInit,
+		},
+	}
+
+	testCases := []struct {
+		algorithm Method
+		iters     int
+		updates   int
+		lrate     bool
+	}{
+		{Batch, 20, 10, false},
+		{Seq, 100, 10, true},
+		{Seq, 5, 5, true},
+		{NeuralGas, 50, 10, true},
+	}
+
+	for _, tc := range testCases {
+		m, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		rec := &recMetrics{}
+		tCfg := &TrainConfig{
+			Algorithm: tc.algorithm,
+			Radius:    10.0,
+			RDecay:    "exp",
+			NeighbFn:  Gaussian,
+			LRate:     0.5,
+			LDecay:    "exp",
+			Metrics:   rec,
+		}
+		assert.NoError(m.Train(tCfg, data, tc.iters))
+		// iterations counter adds up to all iterations
+		total := 0.0
+		for i, name := range rec.names {
+			if name == MetricIterations {
+				assert.True(rec.counter[i])
+				total += rec.values[i]
+			}
+		}
+		assert.Equal(float64(tc.iters), total)
+		assert.Len(rec.series(MetricIterations), tc.updates)
+		// radius decays
+		radius := rec.series(MetricRadius)
+		assert.Len(radius, tc.updates)
+		for i := 1; i < len(radius); i++ {
+			assert.True(radius[i] <= radius[i-1])
+		}
+		if tc.lrate {
+			assert.Len(rec.series(MetricLRate), tc.updates)
+		} else {
+			assert.Empty(rec.series(MetricLRate))
+		}
+		qe := rec.series(MetricQuantError)
+		assert.Len(qe, tc.updates)
+		for _, v := range qe {
+			assert.True(v > 0)
+		}
+		dead := rec.series(MetricDeadUnits)
+		assert.Len(dead, tc.updates)
+		for _, v := range dead {
+			assert.True(v >= 0 && v < 16)
+		}
+		// duration is the last update
+		assert.Len(rec.series(MetricDuration), 1)
+		assert.Equal(MetricDuration, rec.names[len(rec.names)-1])
+		assert.True(rec.values[len(rec.values)-1] >= 0)
+	}
+
+	// no metrics are updated if training fails
+	rec := &recMetrics{}
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	assert.Error(m.Train(&TrainConfig{Algorithm: Seq, Metrics: rec}, data, 10))
+	assert.Empty(rec.names)
+}
+
+func TestTrainMetricsAllocs(t *testing.T) {
+	assert := assert.New(t)
+
+	tc := &TrainConfig{
+		Algorithm: Seq,
+		Radius:    2.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+		Metrics:   nopMetrics{},
+	}
+	metrics := newTrainMetrics(nil, tc, 4, 10, 5)
+	iter := 0
+	allocs := testing.AllocsPerRun(100, func() {
+		metrics.sample(iter%4, 1.0)
+		metrics.update(iter % 10)
+		iter++
+	})
+	assert.Equal(0.0, allocs)
+
+	// training without metrics has no collector
+	tc.Metrics = nil
+	assert.Nil(newTrainMetrics(nil, tc, 4, 10, 5))
+}
+
+func TestTrackErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	for _, tc := range []struct {
+		algorithm Method
+		iters     int
+		epochs    int
+	}{
+		{Seq, 1050, 11},
+		{NeuralGas, 1000, 10},
+		{Batch, 20, 20},
+	} {
+		m, err := NewMap(&MapConfig{
+			Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+			Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+		}, data)
+		assert.NoError(err)
+		train := gsomTrainConfig()
+		train.Algorithm = tc.algorithm
+		train.TrackErrors = true
+		res, err := m.train(train, data, tc.iters, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		if assert.Len(res.QuantErrors, tc.epochs, string(tc.algorithm)) {
+			// the map fits the data better at the end of training
+			assert.True(res.QuantErrors[tc.epochs-1] < res.QuantErrors[0], string(tc.algorithm))
+		}
+
+		// errors are not tracked by default
+		train.TrackErrors = false
+		res, err = m.train(train, data, tc.iters, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		assert.Nil(res.QuantErrors)
+	}
+}
+
+func TestOnEpoch(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	for _, tc := range []struct {
+		algorithm Method
+		iters     int
+		epoch     int
+	}{
+		{Seq, 1050, 100},
+		{Batch, 20, 1},
+		{Temporal, 500, 100},
+	} {
+		m, err := NewMap(&MapConfig{
+			Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+			Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+		}, data)
+		assert.NoError(err)
+		train := gsomTrainConfig()
+		train.Algorithm = tc.algorithm
+		train.Leak = 0.5
+		var epochs []Epoch
+		train.OnEpoch = func(e Epoch) bool {
+			epochs = append(epochs, e)
+			return true
+		}
+		res, err := m.train(train, data, tc.iters, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		assert.False(res.Stopped)
+		assert.Equal(tc.iters, res.Iters)
+		epochCount := (tc.iters + tc.epoch - 1) / tc.epoch
+		if !assert.Len(epochs, epochCount, string(tc.algorithm)) {
+			continue
+		}
+		for i, e := range epochs {
+			assert.Equal(i, e.Epoch)
+			assert.Equal(min((i+1)*tc.epoch, tc.iters), e.Iters)
+			radius, _ := Radius(e.Iters-1, tc.iters, train.RDecay, train.Radius)
+			assert.Equal(radius, e.Radius)
+			if tc.algorithm == Batch {
+				assert.Zero(e.LRate)
+			} else {
+				lRate, _ := LRate(e.Iters-1, tc.iters, train.LDecay, train.LRate)
+				assert.Equal(lRate, e.LRate)
+			}
+			assert.Equal(tc.algorithm == Temporal, math.IsNaN(e.QuantError), string(tc.algorithm))
+		}
+
+		// the training stops when OnEpoch returns false
+		train.TrackErrors = true
+		train.OnEpoch = func(e Epoch) bool {
+			return e.Epoch < 2
+		}
+		res, err = m.train(train, data, tc.iters, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		assert.True(res.Stopped)
+		assert.Equal(3*tc.epoch, res.Iters)
+		if tc.algorithm != Temporal {
+			assert.Len(res.QuantErrors, 3)
+		}
+	}
+}
+
+func TestPatience(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	for _, algorithm := range []Method{Seq, Batch} {
+		m, err := NewMap(&MapConfig{
+			Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+			Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+		}, data)
+		assert.NoError(err)
+		epoch := 100
+		if algorithm == Batch {
+			epoch = 1
+		}
+		train := gsomTrainConfig()
+		train.Algorithm = algorithm
+		train.TrackErrors = true
+		// the first epoch improves on no error, the next two don't improve enough
+		train.Patience, train.MinDelta = 2, 1.0
+		res, err := m.train(train, data, 50*epoch, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		assert.True(res.Stopped, string(algorithm))
+		assert.Equal(3*epoch, res.Iters, string(algorithm))
+		assert.Len(res.QuantErrors, 3)
+
+		// the training converges before its end
+		train.MinDelta = 1e-3
+		res, err = m.train(train, data, 200*epoch, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		assert.True(res.Stopped, string(algorithm))
+		assert.True(res.Iters < 200*epoch, string(algorithm))
+
+		// without patience the training runs all iterations
+		train.Patience = 0
+		res, err = m.train(train, data, 50*epoch, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		assert.False(res.Stopped)
+		assert.Equal(50*epoch, res.Iters)
+	}
+
+	tc := gsomTrainConfig()
+	tc.Patience = -1
+	assert.EqualError(validateTrainConfig(tc), "invalid patience: -1, must not be negative")
+	tc.Patience, tc.MinDelta = 2, -0.1
+	assert.EqualError(validateTrainConfig(tc), "invalid minimum delta: -0.100000, must not be negative")
+}
+
+// expvarTestMetrics is published once, so tests can run repeatedly
+var expvarTestMetrics = NewExpvarMetrics("som_test_train")
+
+func TestExpvarMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := matrix.MakeRandom(20, 3, -10.0, 10.0)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{3, 3},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      3,
+			InitFunc: LinInit,
+		},
+	}
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	metrics := expvarTestMetrics
+	// the counter accumulates over repeated test runs
+	before := 0.0
+	if v := metrics.Vars().Get(MetricIterations); v != nil {
+		before, err = strconv.ParseFloat(v.String(), 64)
+		assert.NoError(err)
+	}
+	tCfg := &TrainConfig{
+		Algorithm: Seq,
+		Radius:    3.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+		Metrics:   metrics,
+	}
+	assert.NoError(m.Train(tCfg, data, 100))
+	assert.NoError(m.Train(tCfg, data, 100))
+	iters, err := strconv.ParseFloat(metrics.Vars().Get(MetricIterations).String(), 64)
+	assert.NoError(err)
+	assert.Equal(200.0, iters-before)
+	radius, err := strconv.ParseFloat(metrics.Vars().Get(MetricRadius).String(), 64)
+	assert.NoError(err)
+	assert.InDelta(1.0, radius, 1e-9)
+	for _, name := range []string{MetricLRate, MetricQuantError, MetricDeadUnits, MetricDuration} {
+		assert.NotNil(metrics.Vars().Get(name))
+	}
+}
+
+func TestValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	data, validation := unitBlobs(100, 1), unitBlobs(50, 2)
+	for _, tc := range []struct {
+		algorithm Method
+		iters     int
+		epochs    int
+	}{
+		{Seq, 1050, 11},
+		{Batch, 20, 20},
+	} {
+		m, err := NewMap(&MapConfig{
+			Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+			Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+		}, data)
+		assert.NoError(err)
+		train := gsomTrainConfig()
+		train.Algorithm = tc.algorithm
+		train.Validation = validation
+		var epochs []Epoch
+		train.OnEpoch = func(e Epoch) bool {
+			epochs = append(epochs, e)
+			return true
+		}
+		res, err := m.train(train, data, tc.iters, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		report := res.Validation
+		if !assert.NotNil(report, string(tc.algorithm)) || !assert.Len(epochs, tc.epochs) {
+			continue
+		}
+		assert.Len(report.TrainErrors, tc.epochs)
+		assert.Len(report.Errors, tc.epochs)
+		for i, e := range epochs {
+			assert.Equal(report.TrainErrors[i], e.QuantError)
+			assert.Equal(report.Errors[i], e.ValidationError)
+		}
+		// the map fits the held-out data better at the end of training
+		assert.True(report.Errors[tc.epochs-1] < report.Errors[0], string(tc.algorithm))
+		assert.Equal(report.Errors[report.BestEpoch], report.BestError)
+		qe, err := m.QuantError(validation)
+		assert.NoError(err)
+		assert.InDelta(qe, report.Errors[tc.epochs-1], 1e-9)
+
+		// no validation error is measured without validation data
+		train.Validation = nil
+		epochs = nil
+		res, err = m.train(train, data, tc.iters, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		assert.Nil(res.Validation)
+		for _, e := range epochs {
+			assert.True(math.IsNaN(e.ValidationError))
+		}
+	}
+
+	// validation data dimension must match the codebook dimension
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	validation, err = matrix.MakeConstant(10, 3, 0.5)
+	assert.NoError(err)
+	train := gsomTrainConfig()
+	train.Validation = validation
+	_, err = m.train(train, data, 100, rand.New(rand.NewSource(1)))
+	assert.True(errors.Is(err, ErrDimMismatch))
+}
+
+func TestValidationReport(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(newValidationReport(nil, nil))
+
+	// validation error rises while training error keeps falling
+	r := newValidationReport([]float64{3.0, 2.0, 1.0, 0.5}, []float64{3.0, 2.0, 2.5, 2.8})
+	assert.Equal(1, r.BestEpoch)
+	assert.Equal(2.0, r.BestError)
+	assert.True(r.Overfitting)
+
+	// validation error falls with training error
+	r = newValidationReport([]float64{3.0, 2.0, 1.0}, []float64{3.0, 2.0, 1.5})
+	assert.Equal(2, r.BestEpoch)
+	assert.False(r.Overfitting)
+
+	// both errors rise
+	r = newValidationReport([]float64{3.0, 2.0, 2.5}, []float64{3.0, 2.0, 2.5})
+	assert.False(r.Overfitting)
+}
@@ -0,0 +1,132 @@
+package som
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// hasNaN returns true if any element of x is NaN
+func hasNaN(x []float64) bool {
+	for _, v := range x {
+		if math.IsNaN(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// unobserved returns true if x has no observed values: all its elements are NaN
+func unobserved(x []float64) bool {
+	for _, v := range x {
+		if !math.IsNaN(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// missingValues returns true if data has missing values, which are stored as NaN.
+// It returns error if any data row has no observed values.
+func missingValues(data mat64.Matrix) (bool, error) {
+	rows, _ := data.Dims()
+	rd := newRowReader(data)
+	missing := false
+	for i := 0; i < rows; i++ {
+		observed := 0
+		row := rd.row(i)
+		for _, v := range row {
+			if !math.IsNaN(v) {
+				observed++
+			}
+		}
+		if observed == 0 {
+			return false, fmt.Errorf("invalid data row %d: no observed values", i)
+		}
+		missing = missing || observed < len(row)
+	}
+	return missing, nil
+}
+
+// partialBMU returns BMU of x which has missing values and its distance to x. Missing components
+// don't contribute to the distance: they are set to the codebook vector components of every compared unit.
+// It returns error if x has no observed values.
+func (m Map) partialBMU(x []float64) (int, float64, error) {
+	if unobserved(x) {
+		return -1, -1.0, fmt.Errorf("invalid sample: no observed values")
+	}
+	units, _ := m.cbDims()
+	filled := make([]float64, len(x))
+	bmu, dist := 0, math.MaxFloat64
+	for i := 0; i < units; i++ {
+		if d := m.partialDistance(filled, x, i); d < dist {
+			bmu, dist = i, d
+		}
+	}
+	return bmu, dist, nil
+}
+
+// partialDistance returns the distance between x which has missing values and codebook vector of unit i.
+// Missing components of x are set to the unit codebook vector components in filled, which has the length of x.
+func (m Map) partialDistance(filled, x []float64, i int) float64 {
+	for j, v := range x {
+		switch {
+		case !math.IsNaN(v):
+			filled[j] = v
+		case m.cb32 != nil:
+			filled[j] = float64(m.cb32.rowView(i)[j])
+		default:
+			filled[j] = m.codebook.At(i, j)
+		}
+	}
+	return m.unitDistance(filled, i)
+}
+
+// missingBMUs replaces BMUs and distances of data rows from to to-1 found by matrix block search,
+// which does not skip missing values, by BMUs of rows with missing values found by BMU. bmus and
+// dists hold the results of the rows starting at row from. Every data row must have an observed value.
+func (m Map) missingBMUs(data mat64.Matrix, from, to int, bmus []int, dists []float64) {
+	rd := newRowReader(data)
+	for i := from; i < to; i++ {
+		if row := rd.row(i); hasNaN(row) {
+			bmus[i-from], dists[i-from], _ = m.BMU(row)
+		}
+	}
+}
+
+// checkMissing returns error if any data row has no observed values or if data has missing values
+// and training configuration tc is not seq, gsom or batch training with mean update rule
+func checkMissing(tc *TrainConfig, data mat64.Matrix) error {
+	missing, err := missingValues(data)
+	if err != nil || !missing {
+		return err
+	}
+	switch {
+	case tc.Algorithm == Seq, tc.Algorithm == GSOM:
+		return nil
+	case tc.Algorithm == Batch && (tc.UpdateRule == "" || tc.UpdateRule == "mean"):
+		return nil
+	}
+	method := string(tc.Algorithm)
+	if tc.Algorithm == Batch {
+		method += " " + tc.UpdateRule
+	}
+	return fmt.Errorf("%w: %s, data with missing values supports seq, gsom and batch mean training", ErrUnsupportedMethod, method)
+}
+
+// observedRange returns the minimum and maximum observed value of data column col. If the column
+// has no observed values, both are zero.
+func observedRange(data *mat64.Dense, col int) (float64, float64) {
+	rows, _ := data.Dims()
+	min, max := math.Inf(1), math.Inf(-1)
+	for i := 0; i < rows; i++ {
+		if v := data.At(i, col); !math.IsNaN(v) {
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+	}
+	if min > max {
+		return 0.0, 0.0
+	}
+	return min, max
+}
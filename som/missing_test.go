@@ -0,0 +1,151 @@
+package som
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// withMissing returns a copy of data with fraction of values replaced by NaN.
+// Every row keeps at least one observed value.
+func withMissing(data *mat64.Dense, fraction float64, seed int64) *mat64.Dense {
+	r := rand.New(rand.NewSource(seed))
+	out := mat64.DenseCopyOf(data)
+	rows, cols := out.Dims()
+	for i := 0; i < rows; i++ {
+		keep := r.Intn(cols)
+		for j := 0; j < cols; j++ {
+			if j != keep && r.Float64() < fraction {
+				out.Set(i, j, math.NaN())
+			}
+		}
+	}
+	return out
+}
+
+func TestMissingBMU(t *testing.T) {
+	assert := assert.New(t)
+
+	cb := mat64.NewDense(3, 2, []float64{0, 0, 1, 5, 2, 1})
+	grid, err := NewGrid(&GridConfig{Size: []int{3, 1}, Type: Planar, UShape: Rectangle})
+	assert.NoError(err)
+	for _, m := range []*Map{
+		{codebook: cb, grid: grid},
+		{cb32: newCodebook32(cb), grid: grid},
+		{codebook: cb, grid: grid, metric: Manhattan},
+	} {
+		bmu, dist, err := m.BMU([]float64{math.NaN(), 0.9})
+		assert.NoError(err)
+		assert.Equal(2, bmu)
+		assert.InDelta(0.1, dist, 1e-6)
+		bmu, _, err = m.BMU([]float64{1.2, math.NaN()})
+		assert.NoError(err)
+		assert.Equal(1, bmu)
+		_, _, err = m.BMU([]float64{math.NaN(), math.NaN()})
+		assert.EqualError(err, "invalid sample: no observed values")
+		units, dists, err := m.KBMU([]float64{math.NaN(), 0.9}, 3)
+		assert.NoError(err)
+		assert.Equal([]int{2, 0, 1}, units)
+		assert.InDeltaSlice([]float64{0.1, 0.9, 4.1}, dists, 1e-6)
+		_, _, err = m.KBMU([]float64{math.NaN(), math.NaN()}, 2)
+		assert.EqualError(err, "invalid sample: no observed values")
+		// the two closest units of the first sample are not grid neighbours
+		te, err := m.TopoError(mat64.NewDense(2, 2, []float64{math.NaN(), 0.9, 1.2, math.NaN()}))
+		assert.NoError(err)
+		assert.Equal(0.5, te)
+	}
+	m := &Map{codebook: cb, grid: grid}
+	noObs := mat64.NewDense(2, 2, []float64{0, 1, math.NaN(), math.NaN()})
+	_, err = m.QuantError(noObs)
+	assert.EqualError(err, "invalid data row 1: no observed values")
+	_, err = m.TopoError(noObs)
+	assert.EqualError(err, "invalid data row 1: no observed values")
+	_, err = m.Explain(noObs)
+	assert.EqualError(err, "invalid data row 1: no observed values")
+	bmus, err := m.BMUs(mat64.NewDense(2, 2, []float64{math.NaN(), 4, 0.1, math.NaN()}))
+	assert.NoError(err)
+	assert.Equal([]int{1, 0}, bmus)
+	qe, err := m.QuantError(mat64.NewDense(2, 2, []float64{math.NaN(), 4, 0.1, math.NaN()}))
+	assert.NoError(err)
+	assert.InDelta(0.55, qe, 1e-12)
+}
+
+func TestTrainMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(200, 1)
+	missing := withMissing(data, 0.2, 2)
+	for _, tc := range []struct {
+		algorithm Method
+		iters     int
+		workers   int
+	}{
+		{Seq, 2000, 1},
+		{GSOM, 2000, 1},
+		{Batch, 20, 1},
+		{Batch, 20, 3},
+	} {
+		mc := &MapConfig{
+			Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+			Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+		}
+		m, err := NewMap(mc, missing)
+		assert.NoError(err)
+		ref, err := NewMap(mc, data)
+		assert.NoError(err)
+		train := gsomTrainConfig()
+		train.Algorithm, train.Workers, train.GrowThreshold = tc.algorithm, tc.workers, 0.5
+		_, err = m.train(train, missing, tc.iters, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		_, err = ref.train(train, data, tc.iters, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		// missing values don't corrupt the codebook
+		for _, v := range m.Codebook().RawMatrix().Data {
+			assert.False(math.IsNaN(v), string(tc.algorithm))
+		}
+		qe, err := m.QuantError(data)
+		assert.NoError(err)
+		refQe, err := ref.QuantError(data)
+		assert.NoError(err)
+		assert.True(qe < 1.5*refQe, "%s: %f, complete data %f", tc.algorithm, qe, refQe)
+	}
+
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	train := gsomTrainConfig()
+	for _, alg := range []Method{NeuralGas, Temporal} {
+		train.Algorithm, train.Leak = alg, 0.5
+		_, err = m.train(train, missing, 100, rand.New(rand.NewSource(1)))
+		assert.True(errors.Is(err, ErrUnsupportedMethod), string(alg))
+	}
+	train.Algorithm, train.UpdateRule = Batch, "median"
+	_, err = m.train(train, missing, 10, rand.New(rand.NewSource(1)))
+	assert.EqualError(err, ErrUnsupportedMethod.Error()+": batch median, data with missing values supports seq, gsom and batch mean training")
+	// rows with no observed values can't be mapped
+	empty := mat64.DenseCopyOf(data)
+	empty.Set(3, 0, math.NaN())
+	empty.Set(3, 1, math.NaN())
+	train.Algorithm, train.UpdateRule = Seq, ""
+	_, err = m.train(train, empty, 100, rand.New(rand.NewSource(1)))
+	assert.EqualError(err, "invalid data row 3: no observed values")
+}
+
+func TestRandInitMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	data := mat64.NewDense(3, 2, []float64{math.NaN(), 1, 2, math.NaN(), 4, 3})
+	cb, err := RandInit(data, []int{5, 5})
+	assert.NoError(err)
+	rows, _ := cb.Dims()
+	for i := 0; i < rows; i++ {
+		assert.True(cb.At(i, 0) >= 2 && cb.At(i, 0) <= 4)
+		assert.True(cb.At(i, 1) >= 1 && cb.At(i, 1) <= 3)
+	}
+}
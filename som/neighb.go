@@ -15,6 +15,24 @@ func Bubble(distance float64, radius float64) float64 {
 	return 0.0
 }
 
+// CutGaussian calculates gaussian neghbourhood cut off at radius: units farther than radius
+// have zero neighbourhood
+func CutGaussian(distance float64, radius float64) float64 {
+	if distance <= radius {
+		return Gaussian(distance, radius)
+	}
+	return 0.0
+}
+
+// Epanechnikov calculates epanechnikov neghbourhood which falls off quadratically
+// from 1 at zero distance to 0 at radius
+func Epanechnikov(distance float64, radius float64) float64 {
+	if distance <= radius {
+		return 1 - (distance*distance)/(radius*radius)
+	}
+	return 0.0
+}
+
 // MexicanHat calculates mexican hat neghbourhood
 func MexicanHat(distance float64, radius float64) float64 {
 	return 2 / (math.Sqrt(3*radius) * math.Pow(math.Pi, 0.25)) *
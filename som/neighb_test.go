@@ -55,3 +55,43 @@ func TestBubble(t *testing.T) {
 	assert.Equal(t, 0.0, Bubble(radius+diff, radius))
 	assert.Equal(t, 1.0, Bubble(radius, radius))
 }
+
+func TestCutGaussian(t *testing.T) {
+	radius := 2.0
+
+	assert.Equal(t, Gaussian(1.5, radius), CutGaussian(1.5, radius))
+	assert.Equal(t, Gaussian(radius, radius), CutGaussian(radius, radius))
+	assert.Equal(t, 0.0, CutGaussian(radius+0.1, radius))
+}
+
+func TestEpanechnikov(t *testing.T) {
+	radius := 2.0
+
+	assert.Equal(t, 1.0, Epanechnikov(0.0, radius))
+	assert.Equal(t, 0.75, Epanechnikov(1.0, radius))
+	assert.Equal(t, 0.0, Epanechnikov(radius, radius))
+	assert.Equal(t, 0.0, Epanechnikov(radius+0.1, radius))
+}
+
+func TestTrainCompactNeighb(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	for _, nFn := range []NeighbFunc{CutGaussian, Epanechnikov} {
+		for _, alg := range []Method{Seq, Batch} {
+			m, err := NewMap(&MapConfig{
+				Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+				Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+			}, data)
+			assert.NoError(err)
+			before, err := m.QuantError(data)
+			assert.NoError(err)
+			tc := &TrainConfig{Algorithm: alg, Radius: 2.0, RDecay: ExpDecay, NeighbFn: nFn, LRate: 0.5, LDecay: ExpDecay}
+			_, err = m.train(tc, data, 300, rand.New(rand.NewSource(1)))
+			assert.NoError(err)
+			after, err := m.QuantError(data)
+			assert.NoError(err)
+			assert.True(after < before, "%s: %f >= %f", alg, after, before)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package som
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// NeuralGas is neural gas training which ranks all units by their distance to the sample
+// instead of using grid neighbourhood. The trained map has no topology.
+const NeuralGas Method = "neuralgas"
+
+// MinNeuralGasRange is the neighbourhood range of neural gas training at the last iteration
+const MinNeuralGasRange = 0.01
+
+// neuralGasCutoff is the rank to range ratio above which neural gas update weight
+// exp(-rank/range) is smaller than 1e-10 and the unit is not updated
+const neuralGasCutoff = 23.0
+
+// ErrNoTopology is returned by methods which depend on arrangement of codebook vectors
+// on map grid, such as u-matrix and topographic error, when the map was trained by neural gas
+// or created by TrainLVQ
+var ErrNoTopology = errors.New("map has no topology: its units are not arranged on the grid")
+
+// HasTopology returns true if the codebook vectors of neighbouring grid units are expected to be
+// close to each other. It returns false if the map was last trained by neural gas or created by TrainLVQ.
+func (m Map) HasTopology() bool {
+	return !m.noTopology
+}
+
+// neuralGasRange returns neighbourhood range of neural gas training at iter-th out of iters iterations.
+// The range decays from initRange to MinNeuralGasRange using decay function fn in the same way as radius.
+func neuralGasRange(iter, iters int, fn DecayFunc, initRange float64) float64 {
+	if initRange <= MinNeuralGasRange {
+		return initRange
+	}
+	return decayed(fn, iter, iters, initRange, MinNeuralGasRange)
+}
+
+// nRange returns neighbourhood range of neural gas training at iter-th out of iters training
+// iterations: the radius schedule value if the configuration has one or the decayed radius
+func (c *TrainConfig) nRange(iter, iters int) float64 {
+	if c.RSchedule != nil {
+		return c.RSchedule.At(iter, iters)
+	}
+	return neuralGasRange(iter, iters, c.rDecay(), c.Radius)
+}
+
+// unitRanks sorts map units by their distance to a sample
+type unitRanks struct {
+	// units holds unit indices
+	units []int
+	// dists holds distances of units to the sample indexed by unit index
+	dists []float64
+}
+
+func (r *unitRanks) Len() int           { return len(r.units) }
+func (r *unitRanks) Less(i, j int) bool { return r.dists[r.units[i]] < r.dists[r.units[j]] }
+func (r *unitRanks) Swap(i, j int)      { r.units[i], r.units[j] = r.units[j], r.units[i] }
+
+// neuralGasTrain runs neural gas training on a given data set. Every iteration picks a random
+// sample and moves every unit towards it with step lrate*exp(-rank/range), where rank is the
+// position of the unit among all units sorted by their distance to the sample. Initial range
+// is the training configuration radius; both range and learning rate decay over iterations.
+func (m *Map) neuralGasTrain(ctx context.Context, tc *TrainConfig, data mat64.Matrix, iters int, r *rand.Rand) error {
+	rows, _ := data.Dims()
+	units, _ := m.cbDims()
+	ranks := &unitRanks{units: make([]int, units), dists: make([]float64, units)}
+	log := trainLogger(tc)
+	metrics := newTrainMetrics(m, tc, units, iters, rows)
+	rd := newRowReader(data)
+	for i := 0; i < iters; i++ {
+		if err := canceled(ctx, i); err != nil {
+			return err
+		}
+		logProgress(log, tc, i, iters)
+		sample := rd.row(r.Intn(rows))
+		m.profile.start()
+		for j := range ranks.units {
+			ranks.units[j] = j
+			ranks.dists[j] = m.unitDistance(sample, j)
+		}
+		sort.Stable(ranks)
+		metrics.sample(ranks.units[0], ranks.dists[ranks.units[0]])
+		m.profile.lap(profileBMU)
+		// no need to check for errors: LRate is checked by config validation
+		lRate := tc.lRate(i, iters)
+		nRange := tc.nRange(i, iters)
+		m.profile.lap(profileDecay)
+		for rank, unit := range ranks.units {
+			if float64(rank) > neuralGasCutoff*nRange {
+				break
+			}
+			m.moveUnit(unit, sample, lRate*math.Exp(-float64(rank)/nRange))
+		}
+		m.profile.lap(profileUpdate)
+		if !metrics.update(i) {
+			break
+		}
+	}
+	m.epochs = metrics
+
+	return nil
+}
+
+// moveUnit moves codebook vector of unit i towards vector x by step mul
+func (m *Map) moveUnit(i int, x []float64, mul float64) {
+	if m.cb32 != nil {
+		cbVec := m.cb32.rowView(i)
+		for k, v := range x {
+			cb := float64(cbVec[k])
+			cbVec[k] = float32(cb + mul*(v-cb))
+		}
+		return
+	}
+	cbVec := m.codebook.RawRowView(i)
+	for k, v := range x {
+		cbVec[k] += mul * (v - cbVec[k])
+	}
+}
+
+// GrowingNeuralGas is growing neural gas training, which starts with the first two map units and
+// activates the other units one by one during the first half of training iterations. Instead of grid
+// neighbourhood, units are connected by edges of a graph built by competitive Hebbian learning: every
+// iteration connects the two active units closest to the sample, moves the closest unit towards the
+// sample with the learning rate and its graph neighbours with GNGNeighbourRate of the learning rate,
+// and removes edges older than GNGMaxEdgeAge iterations. A new unit is placed halfway between the
+// unit with the highest accumulated quantization error and its graph neighbour with the highest error.
+// Radius is not used. If the training stops early, units which were not activated keep their initial
+// codebook vectors. The trained map has no topology.
+const GrowingNeuralGas Method = "gng"
+
+const (
+	// GNGNeighbourRate is the fraction of learning rate with which growing neural gas training
+	// moves graph neighbours of the BMU
+	GNGNeighbourRate = 0.03
+	// GNGMaxEdgeAge is the number of iterations after which growing neural gas training removes
+	// an edge whose units were not the two closest units of any sample
+	GNGMaxEdgeAge = 50
+	// gngErrorDecay scales accumulated errors of units at every growing neural gas iteration
+	gngErrorDecay = 0.995
+	// gngInsertDecay scales accumulated errors of the units between which a unit is inserted
+	gngInsertDecay = 0.5
+)
+
+// gngTrain runs growing neural gas training on a given data set
+func (m *Map) gngTrain(ctx context.Context, tc *TrainConfig, data mat64.Matrix, iters int, r *rand.Rand) error {
+	rows, _ := data.Dims()
+	units, _ := m.cbDims()
+	if units < 2 {
+		return fmt.Errorf("%w: %d units, growing neural gas training needs at least 2 units", ErrUnsupportedGrid, units)
+	}
+	// ages holds the age of edge between units i and j at i*units+j or -1 if the units are not connected
+	ages := make([]int, units*units)
+	for i := range ages {
+		ages[i] = -1
+	}
+	errs := make([]float64, units)
+	// units are activated evenly during the first half of training
+	every := iters / (2 * (units - 1))
+	if every < 1 {
+		every = 1
+	}
+	active := 2
+	log := trainLogger(tc)
+	metrics := newTrainMetrics(m, tc, units, iters, rows)
+	rd := newRowReader(data)
+	for i := 0; i < iters; i++ {
+		if err := canceled(ctx, i); err != nil {
+			return err
+		}
+		logProgress(log, tc, i, iters)
+		sample := rd.row(r.Intn(rows))
+		// find the two closest active units
+		s1, s2, d1, d2 := -1, -1, math.Inf(1), math.Inf(1)
+		for u := 0; u < active; u++ {
+			d := m.unitDistance(sample, u)
+			switch {
+			case d < d1:
+				s2, d2 = s1, d1
+				s1, d1 = u, d
+			case d < d2:
+				s2, d2 = u, d
+			}
+		}
+		metrics.sample(s1, d1)
+		errs[s1] += d1 * d1
+		// no need to check for errors: LRate is checked by config validation
+		lRate := tc.lRate(i, iters)
+		m.moveUnit(s1, sample, lRate)
+		for u := 0; u < active; u++ {
+			if ages[s1*units+u] < 0 {
+				continue
+			}
+			m.moveUnit(u, sample, GNGNeighbourRate*lRate)
+			age := ages[s1*units+u] + 1
+			if age > GNGMaxEdgeAge {
+				age = -1
+			}
+			ages[s1*units+u], ages[u*units+s1] = age, age
+		}
+		ages[s1*units+s2], ages[s2*units+s1] = 0, 0
+		if active < units && (i+1)%every == 0 {
+			m.gngInsert(ages, errs, active)
+			active++
+		}
+		for u := 0; u < active; u++ {
+			errs[u] *= gngErrorDecay
+		}
+		if !metrics.update(i) {
+			break
+		}
+	}
+	m.epochs = metrics
+
+	return nil
+}
+
+// gngInsert activates unit active of growing neural gas: it places the unit halfway between the active
+// unit with the highest error and its graph neighbour with the highest error, or its closest active unit
+// if it has no neighbours, and replaces the edge between them by edges to the new unit
+func (m *Map) gngInsert(ages []int, errs []float64, active int) {
+	units := len(errs)
+	q := 0
+	for u := 1; u < active; u++ {
+		if errs[u] > errs[q] {
+			q = u
+		}
+	}
+	cb := m.cb()
+	qVec := cb.RawRowView(q)
+	f := -1
+	for u := 0; u < active; u++ {
+		if ages[q*units+u] >= 0 && (f < 0 || errs[u] > errs[f]) {
+			f = u
+		}
+	}
+	if f < 0 {
+		closest := math.Inf(1)
+		for u := 0; u < active; u++ {
+			if d := m.unitDistance(qVec, u); u != q && d < closest {
+				f, closest = u, d
+			}
+		}
+	}
+	fVec := cb.RawRowView(f)
+	vec := make([]float64, len(qVec))
+	for k := range vec {
+		vec[k] = (qVec[k] + fVec[k]) / 2
+	}
+	m.setUnit(active, vec)
+	ages[q*units+f], ages[f*units+q] = -1, -1
+	ages[q*units+active], ages[active*units+q] = 0, 0
+	ages[f*units+active], ages[active*units+f] = 0, 0
+	errs[q] *= gngInsertDecay
+	errs[f] *= gngInsertDecay
+	errs[active] = errs[q]
+}
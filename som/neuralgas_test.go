@@ -0,0 +1,139 @@
+package som
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNeuralGasRange(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, strategy := range []Decay{LinDecay, ExpDecay} {
+		assert.InDelta(5.0, neuralGasRange(0, 100, decay(strategy), 5.0), 1e-12, "%s", strategy)
+		assert.InDelta(MinNeuralGasRange, neuralGasRange(99, 100, decay(strategy), 5.0), 1e-12, "%s", strategy)
+		assert.True(neuralGasRange(50, 100, decay(strategy), 5.0) < 5.0, "%s", strategy)
+	}
+	// ranges below the minimum don't decay
+	assert.Equal(0.005, neuralGasRange(10, 100, linDecay, 0.005))
+}
+
+// clusteredQE trains new map on clustered data by algorithm alg and returns its quantization error
+func clusteredQE(t *testing.T, alg Method, radius float64) (*Map, float64) {
+	data := utils.GenerateClusters(300, 2, 10, 100.0, 0.0, 1.0, 7)
+	mc := &MapConfig{
+		Grid: &GridConfig{Size: []int{3, 4}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}
+	m, err := NewMap(mc, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := &TrainConfig{
+		Algorithm: alg,
+		Radius:    radius,
+		RDecay:    ExpDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    ExpDecay,
+	}
+	if _, err := m.train(tc, data, 3000, rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+	qe, err := m.QuantError(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, qe
+}
+
+func TestNeuralGasQuantError(t *testing.T) {
+	assert := assert.New(t)
+
+	_, somQE := clusteredQE(t, Seq, 2.0)
+	m, ngQE := clusteredQE(t, NeuralGas, 6.0)
+	assert.True(ngQE < somQE, "neural gas: %f, som: %f", ngQE, somQE)
+	assert.False(math.IsNaN(ngQE))
+	assert.False(m.HasTopology())
+}
+
+func TestNoTopology(t *testing.T) {
+	assert := assert.New(t)
+
+	m, _ := clusteredQE(t, NeuralGas, 6.0)
+	data := utils.GenerateClusters(300, 2, 10, 100.0, 0.0, 1.0, 7)
+	_, err := m.UMatrixValues()
+	assert.True(errors.Is(err, ErrNoTopology))
+	_, err = m.TopoError(data)
+	assert.True(errors.Is(err, ErrNoTopology))
+	_, err = m.TopoProduct()
+	assert.True(errors.Is(err, ErrNoTopology))
+	_, err = m.Explain(data)
+	assert.True(errors.Is(err, ErrNoTopology))
+	assert.True(errors.Is(m.UMatrix(io.Discard, data, nil, "svg", ""), ErrNoTopology))
+	assert.NoError(m.RecordQuality(data))
+	assert.Contains(m.Summary(), "topographic error: n/a\n")
+	// SOM training restores the topology
+	tc := &TrainConfig{
+		Algorithm: Batch,
+		Radius:    2.0,
+		RDecay:    LinDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    LinDecay,
+	}
+	assert.NoError(m.Train(tc, data, 10))
+	assert.True(m.HasTopology())
+	_, err = m.TopoError(data)
+	assert.NoError(err)
+}
+
+func TestGrowingNeuralGas(t *testing.T) {
+	assert := assert.New(t)
+
+	_, somQE := clusteredQE(t, Seq, 2.0)
+	m, gngQE := clusteredQE(t, GrowingNeuralGas, 1.0)
+	assert.True(gngQE < somQE, "growing neural gas: %f, som: %f", gngQE, somQE)
+	assert.False(m.HasTopology())
+	// every unit is activated and has a distinct codebook vector
+	cb := m.Codebook()
+	units, _ := cb.Dims()
+	for i := 0; i < units; i++ {
+		for j := i + 1; j < units; j++ {
+			assert.True(euclideanVec(cb.RawRowView(i), cb.RawRowView(j)) > 0, "units %d and %d", i, j)
+		}
+	}
+}
+
+func TestGNGInsert(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &Map{codebook: mat64.NewDense(4, 1, []float64{0, 4, 10, 0})}
+	ages := make([]int, 16)
+	for i := range ages {
+		ages[i] = -1
+	}
+	// units 0 and 1 and units 1 and 2 are connected
+	ages[1], ages[4], ages[6], ages[9] = 3, 3, 5, 5
+	errs := []float64{1, 4, 2, 0}
+	m.gngInsert(ages, errs, 3)
+	// unit 3 is inserted between unit 1 with the highest error and its neighbour 2
+	assert.Equal(7.0, m.codebook.At(3, 0))
+	assert.Equal(-1, ages[1*4+2])
+	assert.Equal(0, ages[1*4+3])
+	assert.Equal(0, ages[3*4+2])
+	assert.Equal(3, ages[1*4+0])
+	assert.Equal([]float64{1, 2, 1, 2}, errs)
+
+	// unit without neighbours is split with its closest unit
+	m = &Map{codebook: mat64.NewDense(3, 1, []float64{0, 4, 0})}
+	ages = []int{-1, -1, -1, -1, -1, -1, -1, -1, -1}
+	m.gngInsert(ages, []float64{2, 1, 0}, 2)
+	assert.Equal(2.0, m.codebook.At(2, 0))
+}
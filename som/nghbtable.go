@@ -0,0 +1,149 @@
+package som
+
+import (
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// DefaultNghbTableMaxBytes is the default memory limit of the neighbourhood weight table
+// used by batch training
+const DefaultNghbTableMaxBytes = 256 << 20
+
+// nghbTable holds neighbourhood function values of all map unit pairs for a single radius.
+// If most of the unit pairs are within the radius, the table is stored as dense matrix,
+// otherwise only the unit pairs within the radius are stored in compressed sparse rows.
+type nghbTable struct {
+	// radius is the neighbourhood radius the table was built for
+	radius float64
+	// dense holds the neighbourhood of unit k when unit j is BMU in row k and column j;
+	// it is nil if the table is sparse
+	dense *mat64.Dense
+	// offsets[j] to offsets[j+1]-1 index the neighbours of unit j in units and weights
+	offsets []int
+	// units holds indices of neighbour units
+	units []int
+	// weights holds neighbourhood function values of neighbour units
+	weights []float64
+}
+
+// newNghbTable builds neighbourhood table for radius using map unit index ix.
+// It returns nil if the table would need more than maxBytes of memory.
+func newNghbTable(ix *unitIndex, radius float64, nFn NeighbFunc, maxBytes int) *nghbTable {
+	units, _ := ix.unitDist.Dims()
+	// count unit pairs within radius
+	pairs := 0
+	near := make([]int, 0, units)
+	for j := 0; j < units; j++ {
+		near = ix.within(near[:0], j, radius)
+		pairs += len(near)
+	}
+	t := &nghbTable{radius: radius}
+	switch {
+	case 2*pairs > units*units && units*units*8 <= maxBytes:
+		t.dense = mat64.NewDense(units, units, nil)
+		nghbMatrix(t.dense, ix.unitDist, radius, nFn)
+	case (units+1)*8+pairs*16 <= maxBytes:
+		t.offsets = make([]int, units+1)
+		t.units = make([]int, 0, pairs)
+		t.weights = make([]float64, 0, pairs)
+		for j := 0; j < units; j++ {
+			dists := ix.unitDist.RawRowView(j)
+			t.units = ix.within(t.units, j, radius)
+			for _, k := range t.units[t.offsets[j]:] {
+				t.weights = append(t.weights, nFn(dists[k], radius))
+			}
+			t.offsets[j+1] = len(t.units)
+		}
+	default:
+		return nil
+	}
+	return t
+}
+
+// stale returns true if the table can't be used for radius: the radius differs from the table
+// radius by more than tolerance times the table radius, or by any amount if tolerance is zero
+func (t *nghbTable) stale(radius, tolerance float64) bool {
+	if tolerance == 0 {
+		return radius != t.radius
+	}
+	return math.Abs(radius-t.radius) > tolerance*t.radius
+}
+
+// apply computes neighbourhood weighted sums of BMU data vector sums and of BMU data vector
+// counts for every map unit and stores them in vecs and weights, respectively.
+// Products of dense tables are computed by mul or by mat64.Dense.Mul if mul is nil.
+func (t *nghbTable) apply(sums *mat64.Dense, counts []float64, vecs *mat64.Dense, weights []float64, mul MatMulFunc) {
+	if t.dense != nil {
+		units, _ := t.dense.Dims()
+		mul = matMul(mul)
+		mul(vecs, t.dense, sums)
+		mul(mat64.NewDense(units, 1, weights), t.dense, mat64.NewDense(units, 1, counts))
+		return
+	}
+	resetNghbSums(vecs, weights)
+	for j, count := range counts {
+		// units which are not BMU of any data vector don't contribute
+		if count == 0.0 {
+			continue
+		}
+		sum := sums.RawRowView(j)
+		for n := t.offsets[j]; n < t.offsets[j+1]; n++ {
+			k, w := t.units[n], t.weights[n]
+			floats.AddScaled(vecs.RawRowView(k), w, sum)
+			weights[k] += w * count
+		}
+	}
+}
+
+// nghbApply computes the same sums as nghbTable.apply, but it evaluates the neighbourhood
+// function for every BMU unit pair found by map unit index ix instead of reading it from the table
+func nghbApply(ix *unitIndex, radius float64, nFn NeighbFunc,
+	sums *mat64.Dense, counts []float64, vecs *mat64.Dense, weights []float64) {
+	resetNghbSums(vecs, weights)
+	near := make([]int, 0, len(counts))
+	for j, count := range counts {
+		// units which are not BMU of any data vector don't contribute
+		if count == 0.0 {
+			continue
+		}
+		sum := sums.RawRowView(j)
+		dists := ix.unitDist.RawRowView(j)
+		near = ix.within(near[:0], j, radius)
+		for _, k := range near {
+			w := nFn(dists[k], radius)
+			floats.AddScaled(vecs.RawRowView(k), w, sum)
+			weights[k] += w * count
+		}
+	}
+}
+
+// resetNghbSums sets all elements of vecs and weights to zero
+func resetNghbSums(vecs *mat64.Dense, weights []float64) {
+	rows, _ := vecs.Dims()
+	for i := 0; i < rows; i++ {
+		row := vecs.RawRowView(i)
+		for j := range row {
+			row[j] = 0.0
+		}
+	}
+	for i := range weights {
+		weights[i] = 0.0
+	}
+}
+
+// nghbMatrix stores the neighbourhood of unit k when unit j is BMU in row k and column j of h.
+// Neighbourhood of units whose distance is not within radius is zero.
+func nghbMatrix(h, unitDist *mat64.Dense, radius float64, nFn NeighbFunc) {
+	units, _ := unitDist.Dims()
+	for j := 0; j < units; j++ {
+		for k, dist := range unitDist.RawRowView(j) {
+			nghb := 0.0
+			if dist < radius {
+				nghb = nFn(dist, radius)
+			}
+			h.Set(k, j, nghb)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package som
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeNghbSums returns unit index of size map and random BMU data sums and counts
+func makeNghbSums(size []int, dim int) (*unitIndex, *mat64.Dense, []float64) {
+	data, _ := matrix.MakeRandom(size[0]*size[1]*3, dim, -10.0, 10.0)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   size,
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      dim,
+			InitFunc: RandInit,
+		},
+	}
+	m, _ := NewMap(mCfg, data)
+	unitDist, _ := m.UnitDist()
+	units, _ := m.codebook.Dims()
+	sums := mat64.NewDense(units, dim, nil)
+	counts := make([]float64, units)
+	bmus, _ := m.BMUs(data)
+	for i, bmu := range bmus {
+		for j, v := range data.RawRowView(i) {
+			sums.Set(bmu, j, sums.At(bmu, j)+v)
+		}
+		counts[bmu]++
+	}
+	return newUnitIndex(m.grid.coords, unitDist), sums, counts
+}
+
+func TestNghbTable(t *testing.T) {
+	assert := assert.New(t)
+
+	ix, sums, counts := makeNghbSums([]int{10, 10}, 4)
+	// reference sums are computed by scanning all units
+	scan := newScanIndex(ix.unitDist)
+	units, dim := sums.Dims()
+	testCases := []struct {
+		radius float64
+		dense  bool
+	}{
+		{1.5, false},
+		{20.0, true},
+	}
+	for _, tc := range testCases {
+		for _, nFn := range []NeighbFunc{Gaussian, Bubble, MexicanHat} {
+			table := newNghbTable(ix, tc.radius, nFn, DefaultNghbTableMaxBytes)
+			assert.NotNil(table)
+			assert.Equal(tc.radius, table.radius)
+			assert.Equal(tc.dense, table.dense != nil)
+			vecs := mat64.NewDense(units, dim, nil)
+			weights := make([]float64, units)
+			table.apply(sums, counts, vecs, weights, nil)
+			expVecs := mat64.NewDense(units, dim, nil)
+			expWeights := make([]float64, units)
+			nghbApply(scan, tc.radius, nFn, sums, counts, expVecs, expWeights)
+			assert.InDeltaSlice(expWeights, weights, 1e-9)
+			assert.InDeltaSlice(expVecs.RawMatrix().Data, vecs.RawMatrix().Data, 1e-9)
+			// buffers are overwritten
+			table.apply(sums, counts, vecs, weights, nil)
+			assert.InDeltaSlice(expWeights, weights, 1e-9)
+		}
+	}
+	// dense table exceeds the memory limit, sparse table would be even larger
+	table := newNghbTable(ix, 20.0, Gaussian, units*units*8-1)
+	assert.Nil(table)
+	// sparse table exceeds the memory limit
+	table = newNghbTable(ix, 1.5, Gaussian, 100)
+	assert.Nil(table)
+}
+
+func TestBatchTrainNghbTable(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := matrix.MakeRandom(300, 3, -10.0, 10.0)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{6, 8},
+			Type:   "planar",
+			UShape: "rectangle",
+		},
+		Cb: &CbConfig{
+			Dim:      3,
+			InitFunc: RandInit,
+		},
+	}
+	tc := &TrainConfig{
+		Algorithm: "batch",
+		Radius:    3.0,
+		RDecay:    "lin",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "lin",
+	}
+	ref, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	tc.NghbTableMaxBytes = -1
+	assert.NoError(ref.batchTrain(context.Background(), tc, data, 10))
+	for _, maxBytes := range []int{0, 1000} {
+		m, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		tc.NghbTableMaxBytes = maxBytes
+		assert.NoError(m.batchTrain(context.Background(), tc, data, 10))
+		assert.InDeltaSlice(ref.codebook.RawMatrix().Data, m.codebook.RawMatrix().Data, 1e-9)
+	}
+}
+
+func BenchmarkNghbTable(b *testing.B) {
+	ix, sums, counts := makeNghbSums([]int{60, 60}, 16)
+	units, dim := sums.Dims()
+	vecs := mat64.NewDense(units, dim, nil)
+	weights := make([]float64, units)
+	for _, radius := range []float64{3.0, 30.0} {
+		table := newNghbTable(ix, radius, Gaussian, DefaultNghbTableMaxBytes)
+		if table == nil {
+			b.Fatalf("neighbourhood table exceeds memory limit")
+		}
+		name := "sparse"
+		if table.dense != nil {
+			name = "dense"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				table.apply(sums, counts, vecs, weights, nil)
+			}
+		})
+		b.Run(name+"-direct", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				nghbApply(ix, radius, Gaussian, sums, counts, vecs, weights)
+			}
+		})
+	}
+}
+
+func TestNghbRadiusTolerance(t *testing.T) {
+	assert := assert.New(t)
+
+	table := &nghbTable{radius: 2.0}
+	assert.False(table.stale(2.0, 0))
+	assert.True(table.stale(1.99, 0))
+	assert.False(table.stale(1.9, 0.1))
+	assert.True(table.stale(1.7, 0.1))
+
+	data := makeBlobs(300, 3, 1.0, 1)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{Size: []int{10, 10}, Type: "planar", UShape: "hexagon"},
+		Cb:   &CbConfig{Dim: 3, InitFunc: RandInit},
+	}
+	tc := &TrainConfig{
+		Algorithm: "batch",
+		Radius:    5.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+	ref, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	assert.NoError(ref.Train(tc, data, 30))
+	refQE, err := ref.QuantError(data)
+	assert.NoError(err)
+	// reused tables decay the radius in steps with similar results
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	tc.NghbRadiusTolerance = 0.2
+	assert.NoError(m.Train(tc, data, 30))
+	qe, err := m.QuantError(data)
+	assert.NoError(err)
+	assert.InDelta(refQE, qe, 0.25*refQE)
+	assert.False(mat64.Equal(ref.CodebookRaw(), m.CodebookRaw()))
+
+	for _, tol := range []float64{-0.1, 1.0, math.NaN()} {
+		tc.NghbRadiusTolerance = tol
+		err := m.Train(tc, data, 10)
+		var cerr *ConfigError
+		assert.True(errors.As(err, &cerr))
+		assert.Equal("TrainConfig.NghbRadiusTolerance", cerr.Field)
+	}
+}
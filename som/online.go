@@ -0,0 +1,213 @@
+package som
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+const (
+	// DefaultOnlineLRate is the default initial learning rate of online training
+	DefaultOnlineLRate = 0.1
+	// DefaultOnlineHalfLife is the default number of samples after which online training radius
+	// and learning rate decay halfway to their minimums
+	DefaultOnlineHalfLife = 1000
+)
+
+// OnlineConfig holds configuration of online training of a map on samples which arrive one by one.
+// Radius and learning rate decay with the number of samples seen: after t samples they are
+// min + (initial - min) * HalfLife / (HalfLife + t), so they approach but never reach their minimums.
+// Fields which are not set use the documented defaults.
+type OnlineConfig struct {
+	// Radius is initial units radius. The default is half of the largest map grid dimension.
+	Radius float64
+	// MinRadius is the radius approached by the decay: it must not exceed Radius. The default is 0.
+	MinRadius float64
+	// LRate is initial learning rate: it must not exceed 1. The default is DefaultOnlineLRate.
+	LRate float64
+	// MinLRate is the learning rate approached by the decay: it must not exceed LRate. Positive
+	// MinLRate keeps the map adapting to drifting streams. The default is 0.
+	MinLRate float64
+	// HalfLife is the number of samples after which radius and learning rate decay halfway
+	// to their minimums. The default is DefaultOnlineHalfLife.
+	HalfLife int
+	// NeighbFn is neighbourhood function. The default is Gaussian.
+	NeighbFn NeighbFunc
+	// Workers specifies the number of goroutines of BMU search of large codebooks in the same way
+	// as TrainConfig.Workers.
+	Workers int
+	// Shared receives a snapshot of the trained map after every PublishEvery updates if it is not nil,
+	// so that other goroutines can read the map while it is trained.
+	Shared *SharedMap
+	// PublishEvery is the number of updates between published snapshots. The default is DefaultPublishEvery.
+	PublishEvery int
+}
+
+// withDefaults returns a copy of the configuration with defaults of unset fields for map m
+func (c OnlineConfig) withDefaults(m *Map) OnlineConfig {
+	if c.Radius == 0 {
+		c.Radius = autoRadius(m.grid.Size())
+	}
+	if c.LRate == 0 {
+		c.LRate = DefaultOnlineLRate
+	}
+	if c.HalfLife == 0 {
+		c.HalfLife = DefaultOnlineHalfLife
+	}
+	if c.NeighbFn == nil {
+		c.NeighbFn = Gaussian
+	}
+	if c.PublishEvery == 0 {
+		c.PublishEvery = DefaultPublishEvery
+	}
+	return c
+}
+
+// online validates online training configuration with applied defaults
+func (v *validator) online(c OnlineConfig) {
+	if !(c.Radius > 0) || math.IsInf(c.Radius, 1) {
+		if v.param("OnlineConfig.Radius", c.Radius, fmt.Errorf("%w: %f, must be positive and finite", ErrInvalidRadius, c.Radius)) {
+			return
+		}
+	}
+	if !(c.MinRadius >= 0 && c.MinRadius <= c.Radius) {
+		if v.param("OnlineConfig.MinRadius", c.MinRadius, fmt.Errorf("%w: minimum %f, must be non-negative and at most %f", ErrInvalidRadius, c.MinRadius, c.Radius)) {
+			return
+		}
+	}
+	if !(c.LRate > 0 && c.LRate <= 1) {
+		if v.param("OnlineConfig.LRate", c.LRate, fmt.Errorf("%w: %f, must be positive and at most 1", ErrInvalidLRate, c.LRate)) {
+			return
+		}
+	}
+	if !(c.MinLRate >= 0 && c.MinLRate <= c.LRate) {
+		if v.param("OnlineConfig.MinLRate", c.MinLRate, fmt.Errorf("%w: minimum %f, must be non-negative and at most %f", ErrInvalidLRate, c.MinLRate, c.LRate)) {
+			return
+		}
+	}
+	if c.HalfLife < 0 {
+		if v.param("OnlineConfig.HalfLife", c.HalfLife, fmt.Errorf("invalid half life: %d, must be positive", c.HalfLife)) {
+			return
+		}
+	}
+	if c.PublishEvery < 0 {
+		v.param("OnlineConfig.PublishEvery", c.PublishEvery, fmt.Errorf("invalid publish interval: %d, must be positive", c.PublishEvery))
+	}
+}
+
+// OnlineTrainer trains a map on samples which arrive one by one, such as samples read from
+// a message queue, without collecting them into a data matrix. Every update moves codebook vectors
+// of units around the sample BMU towards the sample in the same way as seq training.
+// OnlineTrainer is not safe for concurrent use and the map must not be used while it is updated:
+// other goroutines can read snapshots of the map published to OnlineConfig.Shared instead.
+type OnlineTrainer struct {
+	// m is the trained map
+	m *Map
+	// c is online training configuration
+	c OnlineConfig
+	// t moves codebook vectors of the map
+	t *seqTrainer
+	// seen is the number of samples seen
+	seen int
+}
+
+// NewOnlineTrainer creates new online trainer of the map. If the map codebook is large enough
+// for parallel BMU search, the trainer starts search goroutines which are stopped by Close.
+// It returns error which joins all problems of cfg or error if the map grid unit distances
+// can't be computed.
+func (m *Map) NewOnlineTrainer(cfg OnlineConfig) (*OnlineTrainer, error) {
+	c := cfg.withDefaults(m)
+	v := &validator{}
+	v.online(c)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	t, err := newSeqTrainer(m, &TrainConfig{Algorithm: Seq, NeighbFn: c.NeighbFn, Workers: c.Workers})
+	if err != nil {
+		return nil, err
+	}
+	return &OnlineTrainer{m: m, c: c, t: t}, nil
+}
+
+// Update moves the map codebook towards sample using the radius and learning rate
+// of the number of samples seen so far.
+// It returns ErrDimMismatch if sample dimension is different from the codebook dimension
+// or error if sample has no observed values.
+func (o *OnlineTrainer) Update(sample []float64) error {
+	if _, dim := o.m.cbDims(); len(sample) != dim {
+		return ErrDimMismatch
+	}
+	// samples without observed values have no BMU
+	if unobserved(sample) {
+		return fmt.Errorf("invalid sample: no observed values")
+	}
+	radius, lRate := o.Schedule()
+	bmu, _ := o.t.bmu(sample)
+	o.t.move(bmu, sample, lRate, radius)
+	// keep cached codebook norms of the moved units up to date
+	if o.m.norms != nil {
+		for _, i := range o.t.near {
+			o.m.norms[i] = o.m.unitNorm(i)
+		}
+	}
+	// the codebook has changed: discard KD-tree and quality measures
+	o.m.tree, o.m.quality = nil, nil
+	o.seen++
+	if o.c.Shared != nil && o.seen%o.c.PublishEvery == 0 {
+		o.c.Shared.Publish(o.m)
+	}
+	return nil
+}
+
+// TrainStream updates the map with every sample received from samples until samples is closed
+// or ctx is done. When samples is closed, the final map is published to OnlineConfig.Shared if it is set. It returns error which wraps ctx.Err() if ctx is done
+// or error if a sample can't be used for update.
+func (o *OnlineTrainer) TrainStream(ctx context.Context, samples <-chan []float64) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("online training canceled after %d samples: %w", o.seen, ctx.Err())
+		case sample, ok := <-samples:
+			if !ok {
+				if o.c.Shared != nil {
+					o.c.Shared.Publish(o.m)
+				}
+				return nil
+			}
+			if err := o.Update(sample); err != nil {
+				return fmt.Errorf("invalid sample %d: %w", o.seen, err)
+			}
+		}
+	}
+}
+
+// Seen returns the number of samples the map was updated with
+func (o *OnlineTrainer) Seen() int {
+	return o.seen
+}
+
+// Schedule returns radius and learning rate of the next update
+func (o *OnlineTrainer) Schedule() (float64, float64) {
+	decay := float64(o.c.HalfLife) / float64(o.c.HalfLife+o.seen)
+	return o.c.MinRadius + (o.c.Radius-o.c.MinRadius)*decay, o.c.MinLRate + (o.c.LRate-o.c.MinLRate)*decay
+}
+
+// Close stops BMU search goroutines of the trainer. The trainer must not be used after Close.
+func (o *OnlineTrainer) Close() {
+	o.t.close()
+}
+
+// unitNorm returns squared norm of codebook vector of unit i
+func (m Map) unitNorm(i int) float64 {
+	norm := 0.0
+	if m.cb32 != nil {
+		for _, v := range m.cb32.rowView(i) {
+			norm += float64(v) * float64(v)
+		}
+		return norm
+	}
+	for _, v := range m.codebook.RawRowView(i) {
+		norm += v * v
+	}
+	return norm
+}
@@ -0,0 +1,123 @@
+package som
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnlineTrainer(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	rows, _ := data.Dims()
+	for _, precision := range []string{"", "float32"} {
+		m, err := NewMap(&MapConfig{
+			Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+			Cb:   &CbConfig{Dim: 2, InitFunc: RandInit, Precision: precision},
+		}, data)
+		assert.NoError(err)
+		before, err := m.QuantError(data)
+		assert.NoError(err)
+		o, err := m.NewOnlineTrainer(OnlineConfig{HalfLife: 100})
+		assert.NoError(err)
+		samples := make(chan []float64)
+		go func() {
+			for epoch := 0; epoch < 10; epoch++ {
+				for i := 0; i < rows; i++ {
+					samples <- data.RawRowView(i)
+				}
+			}
+			close(samples)
+		}()
+		assert.NoError(o.TrainStream(context.Background(), samples))
+		o.Close()
+		assert.Equal(10*rows, o.Seen())
+		after, err := m.QuantError(data)
+		assert.NoError(err)
+		assert.True(after < before/2, precision)
+		// cached codebook norms follow the updates
+		for i, norm := range m.cbNorms() {
+			assert.InDelta(norm, m.norms[i], 1e-9)
+		}
+	}
+}
+
+func TestOnlineSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{6, 3}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	o, err := m.NewOnlineTrainer(OnlineConfig{MinRadius: 1.0, LRate: 0.5, MinLRate: 0.1, HalfLife: 10})
+	assert.NoError(err)
+	defer o.Close()
+	radius, lRate := o.Schedule()
+	assert.Equal(3.0, radius)
+	assert.Equal(0.5, lRate)
+	for i := 0; i < 10; i++ {
+		assert.NoError(o.Update(data.RawRowView(i)))
+	}
+	radius, lRate = o.Schedule()
+	assert.InDelta(2.0, radius, 1e-12)
+	assert.InDelta(0.3, lRate, 1e-12)
+	for i := 0; i < 1000; i++ {
+		assert.NoError(o.Update(data.RawRowView(i % 100)))
+	}
+	radius, lRate = o.Schedule()
+	assert.True(radius > 1.0 && radius < 1.05)
+	assert.True(lRate > 0.1 && lRate < 0.11)
+
+	// invalid samples
+	assert.Equal(ErrDimMismatch, o.Update([]float64{1, 2, 3}))
+	assert.EqualError(o.Update([]float64{math.NaN(), math.NaN()}), "invalid sample: no observed values")
+	samples := make(chan []float64, 1)
+	samples <- []float64{1}
+	err = o.TrainStream(context.Background(), samples)
+	assert.True(errors.Is(err, ErrDimMismatch))
+	assert.EqualError(err, "invalid sample 1010: "+ErrDimMismatch.Error())
+	// cancelled stream training
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = o.TrainStream(ctx, make(chan []float64))
+	assert.True(errors.Is(err, context.Canceled))
+	assert.Equal(1010, o.Seen())
+	// frozen map is updated too
+	assert.True(m.Freeze())
+	assert.NoError(o.Update(data.RawRowView(0)))
+	assert.False(m.Frozen())
+}
+
+func TestOnlineConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	for _, tc := range []struct {
+		c   OnlineConfig
+		err error
+	}{
+		{OnlineConfig{Radius: -1}, ErrInvalidRadius},
+		{OnlineConfig{Radius: math.Inf(1)}, ErrInvalidRadius},
+		{OnlineConfig{Radius: 1, MinRadius: 2}, ErrInvalidRadius},
+		{OnlineConfig{LRate: 1.5}, ErrInvalidLRate},
+		{OnlineConfig{MinLRate: 0.5}, ErrInvalidLRate},
+	} {
+		_, err := m.NewOnlineTrainer(tc.c)
+		assert.True(errors.Is(err, tc.err), err)
+	}
+	_, err = m.NewOnlineTrainer(OnlineConfig{HalfLife: -1})
+	assert.EqualError(err, "invalid half life: -1, must be positive")
+	_, err = m.NewOnlineTrainer(OnlineConfig{PublishEvery: -1})
+	assert.EqualError(err, "invalid publish interval: -1, must be positive")
+}
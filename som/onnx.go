@@ -0,0 +1,218 @@
+package som
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+const (
+	// onnxIRVersion and onnxOpset are ONNX IR version and default domain opset of models
+	// written by WriteONNX
+	onnxIRVersion = 7
+	onnxOpset     = 13
+	// onnxDouble and onnxInt64 are ONNX tensor element types
+	onnxDouble = 11
+	onnxInt64  = 7
+	// onnxAttrInt and onnxAttrInts are ONNX attribute types
+	onnxAttrInt  = 2
+	onnxAttrInts = 7
+)
+
+// protoBuf encodes protocol buffer messages
+type protoBuf struct {
+	b []byte
+}
+
+func (p *protoBuf) tag(field, wire int) {
+	p.b = binary.AppendUvarint(p.b, uint64(field<<3|wire))
+}
+
+func (p *protoBuf) varint(field int, v int64) {
+	p.tag(field, 0)
+	p.b = binary.AppendUvarint(p.b, uint64(v))
+}
+
+func (p *protoBuf) bytes(field int, b []byte) {
+	p.tag(field, 2)
+	p.b = binary.AppendUvarint(p.b, uint64(len(b)))
+	p.b = append(p.b, b...)
+}
+
+func (p *protoBuf) str(field int, s string) {
+	p.bytes(field, []byte(s))
+}
+
+// msg encodes message field whose fields are encoded by fn
+func (p *protoBuf) msg(field int, fn func(m *protoBuf)) {
+	m := &protoBuf{}
+	fn(m)
+	p.bytes(field, m.b)
+}
+
+// onnxAttr is ONNX node attribute holding integer i or integers ints if ints is not nil
+type onnxAttr struct {
+	name string
+	i    int64
+	ints []int64
+}
+
+// onnxNode is ONNX graph node of op type
+type onnxNode struct {
+	op     string
+	inputs []string
+	output string
+	attrs  []onnxAttr
+}
+
+func (n onnxNode) encode(p *protoBuf) {
+	for _, in := range n.inputs {
+		p.str(1, in)
+	}
+	p.str(2, n.output)
+	p.str(3, n.output)
+	p.str(4, n.op)
+	for _, attr := range n.attrs {
+		p.msg(5, func(a *protoBuf) {
+			a.str(1, attr.name)
+			if attr.ints == nil {
+				a.varint(3, attr.i)
+				a.varint(20, onnxAttrInt)
+				return
+			}
+			for _, v := range attr.ints {
+				a.varint(8, v)
+			}
+			a.varint(20, onnxAttrInts)
+		})
+	}
+}
+
+// onnxTensor encodes double tensor initializer name of dims holding values in row major order
+func onnxTensor(p *protoBuf, name string, dims []int, values []float64) {
+	p.msg(5, func(t *protoBuf) {
+		for _, d := range dims {
+			t.varint(1, int64(d))
+		}
+		t.varint(2, onnxDouble)
+		t.str(8, name)
+		raw := make([]byte, 8*len(values))
+		for i, v := range values {
+			binary.LittleEndian.PutUint64(raw[8*i:], math.Float64bits(v))
+		}
+		t.bytes(9, raw)
+	})
+}
+
+// onnxValueInfo encodes graph input or output field name of elemType tensor with dims;
+// negative dimensions are the symbolic batch dimension N
+func onnxValueInfo(p *protoBuf, field int, name string, elemType int64, dims ...int) {
+	p.msg(field, func(v *protoBuf) {
+		v.str(1, name)
+		v.msg(2, func(t *protoBuf) {
+			t.msg(1, func(tt *protoBuf) {
+				tt.varint(1, elemType)
+				tt.msg(2, func(s *protoBuf) {
+					for _, d := range dims {
+						s.msg(1, func(dim *protoBuf) {
+							if d < 0 {
+								dim.str(2, "N")
+							} else {
+								dim.varint(1, int64(d))
+							}
+						})
+					}
+				})
+			})
+		})
+	})
+}
+
+// WriteONNX writes the map to w as ONNX model which finds BMUs of samples in the same way as Predict.
+// The model has double input tensor X of N samples x codebook dimension and two outputs: int64 tensor
+// unit of N BMU indices and double tensor distance of N BMU distances. BMUs are searched by euclidean
+// distance weighted by feature relevances, which the graph computes from squared sample and codebook
+// norms and their matrix product, so ties may be broken differently than by Predict. If the map has
+// ZScore or MinMax scaler, the graph scales the input first, so it scores samples in original units.
+// The model uses ONNX IR version 7 and opset 13 which are supported by ONNX Runtime.
+// WriteONNX returns ErrUnsupportedMetric if the map has a codebook metric, error if the map has
+// UnitLength scaler or if the write to w fails.
+func (m Map) WriteONNX(w io.Writer) error {
+	if _, err := m.exportFeatures(); err != nil {
+		return err
+	}
+	units, dim := m.cbDims()
+	graph := &protoBuf{}
+	var nodes []onnxNode
+	input := "X"
+	if m.scaler != nil {
+		onnxTensor(graph, "center", []int{dim}, m.scaler.Center)
+		onnxTensor(graph, "scale", []int{dim}, m.scaler.Scale)
+		nodes = append(nodes,
+			onnxNode{op: "Sub", inputs: []string{input, "center"}, output: "centered"},
+			onnxNode{op: "Div", inputs: []string{"centered", "scale"}, output: "scaled"})
+		input = "scaled"
+	}
+	// relevance weighted distance is euclidean distance of samples and codebook vectors
+	// whose features are multiplied by square roots of the relevances
+	sqrtRel := make([]float64, dim)
+	for j := range sqrtRel {
+		sqrtRel[j] = 1.0
+		if m.relevance != nil {
+			sqrtRel[j] = math.Sqrt(m.relevance[j])
+		}
+	}
+	if m.relevance != nil {
+		onnxTensor(graph, "relevance", []int{dim}, sqrtRel)
+		nodes = append(nodes, onnxNode{op: "Mul", inputs: []string{input, "relevance"}, output: "weighted"})
+		input = "weighted"
+	}
+	cb := m.cb()
+	cbT := make([]float64, dim*units)
+	norms := make([]float64, units)
+	for i := 0; i < units; i++ {
+		for j, v := range cb.RawRowView(i) {
+			v *= sqrtRel[j]
+			cbT[j*units+i] = v
+			norms[i] += v * v
+		}
+	}
+	onnxTensor(graph, "codebook_t", []int{dim, units}, cbT)
+	onnxTensor(graph, "codebook_norms", []int{units}, norms)
+	onnxTensor(graph, "minus_two", nil, []float64{-2.0})
+	onnxTensor(graph, "zero", nil, []float64{0.0})
+	// squared distances are |x|^2 - 2 x.c + |c|^2
+	nodes = append(nodes,
+		onnxNode{op: "ReduceSumSquare", inputs: []string{input}, output: "input_norms",
+			attrs: []onnxAttr{{name: "axes", ints: []int64{1}}, {name: "keepdims", i: 1}}},
+		onnxNode{op: "MatMul", inputs: []string{input, "codebook_t"}, output: "products"},
+		onnxNode{op: "Mul", inputs: []string{"products", "minus_two"}, output: "scaled_products"},
+		onnxNode{op: "Add", inputs: []string{"input_norms", "scaled_products"}, output: "partial"},
+		onnxNode{op: "Add", inputs: []string{"partial", "codebook_norms"}, output: "squared_distances"},
+		onnxNode{op: "ArgMin", inputs: []string{"squared_distances"}, output: "unit",
+			attrs: []onnxAttr{{name: "axis", i: 1}, {name: "keepdims", i: 0}}},
+		onnxNode{op: "ReduceMin", inputs: []string{"squared_distances"}, output: "min_squared_distance",
+			attrs: []onnxAttr{{name: "axes", ints: []int64{1}}, {name: "keepdims", i: 0}}},
+		// rounding errors can make squared distances slightly negative
+		onnxNode{op: "Max", inputs: []string{"min_squared_distance", "zero"}, output: "squared_distance"},
+		onnxNode{op: "Sqrt", inputs: []string{"squared_distance"}, output: "distance"},
+	)
+	for _, n := range nodes {
+		graph.msg(1, n.encode)
+	}
+	graph.str(2, "som")
+	onnxValueInfo(graph, 11, "X", onnxDouble, -1, dim)
+	onnxValueInfo(graph, 12, "unit", onnxInt64, -1)
+	onnxValueInfo(graph, 12, "distance", onnxDouble, -1)
+
+	model := &protoBuf{}
+	model.varint(1, onnxIRVersion)
+	model.str(2, "gosom")
+	model.bytes(7, graph.b)
+	model.msg(8, func(op *protoBuf) {
+		op.str(1, "")
+		op.varint(2, onnxOpset)
+	})
+	_, err := w.Write(model.b)
+	return err
+}
@@ -0,0 +1,187 @@
+package som
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// pbFields decodes protocol buffer message b into its varint and length delimited fields
+func pbFields(t *testing.T, b []byte) (map[int][]int64, map[int][][]byte) {
+	ints, msgs := map[int][]int64{}, map[int][][]byte{}
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("invalid protocol buffer key")
+		}
+		b = b[n:]
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("invalid protocol buffer value")
+		}
+		b = b[n:]
+		field := int(key >> 3)
+		switch key & 7 {
+		case 0:
+			ints[field] = append(ints[field], int64(v))
+		case 2:
+			msgs[field] = append(msgs[field], b[:v])
+			b = b[v:]
+		default:
+			t.Fatalf("unexpected protocol buffer wire type: %d", key&7)
+		}
+	}
+	return ints, msgs
+}
+
+// onnxValue is tensor evaluated by testGraph
+type onnxValue struct {
+	dims []int
+	v    []float64
+}
+
+// at returns element of v broadcast to element i, j of a 2D tensor or element i of a 1D tensor
+func (v onnxValue) at(rank, i, j int) float64 {
+	switch {
+	case len(v.dims) == 0:
+		return v.v[0]
+	case len(v.dims) == 1 && rank == 2:
+		return v.v[j]
+	case len(v.dims) == 1:
+		return v.v[i]
+	case v.dims[1] == 1:
+		return v.v[i]
+	}
+	return v.v[i*v.dims[1]+j]
+}
+
+// testGraph evaluates ONNX graph encoded by WriteONNX on input x
+func testGraph(t *testing.T, graph []byte, x []float64) (int, float64) {
+	_, fields := pbFields(t, graph)
+	values := map[string]onnxValue{"X": {dims: []int{1, len(x)}, v: x}}
+	for _, init := range fields[5] {
+		ints, msgs := pbFields(t, init)
+		assert.Equal(t, []int64{onnxDouble}, ints[2])
+		val := onnxValue{}
+		for _, d := range ints[1] {
+			val.dims = append(val.dims, int(d))
+		}
+		raw := msgs[9][0]
+		for i := 0; i < len(raw); i += 8 {
+			val.v = append(val.v, math.Float64frombits(binary.LittleEndian.Uint64(raw[i:])))
+		}
+		values[string(msgs[8][0])] = val
+	}
+	ops := map[string]func(a, b float64) float64{
+		"Sub": func(a, b float64) float64 { return a - b },
+		"Div": func(a, b float64) float64 { return a / b },
+		"Mul": func(a, b float64) float64 { return a * b },
+		"Add": func(a, b float64) float64 { return a + b },
+		"Max": math.Max,
+	}
+	for _, node := range fields[1] {
+		_, msgs := pbFields(t, node)
+		op, out := string(msgs[4][0]), string(msgs[2][0])
+		var in []onnxValue
+		for _, name := range msgs[1] {
+			v, ok := values[string(name)]
+			assert.True(t, ok, "undefined node input %s", name)
+			in = append(in, v)
+		}
+		a := in[0]
+		switch op {
+		case "Sub", "Div", "Mul", "Add", "Max":
+			b, res := in[1], onnxValue{dims: a.dims}
+			if len(b.dims) > len(a.dims) || (len(a.dims) == 2 && a.dims[1] == 1) {
+				res.dims = b.dims
+			}
+			if len(res.dims) == 2 && len(a.dims) == 2 && len(b.dims) == 1 {
+				res.dims = []int{a.dims[0], b.dims[0]}
+			}
+			if len(res.dims) == 2 {
+				for j := 0; j < res.dims[1]; j++ {
+					res.v = append(res.v, ops[op](a.at(2, 0, j), b.at(2, 0, j)))
+				}
+			} else {
+				res.v = []float64{ops[op](a.at(1, 0, 0), b.at(1, 0, 0))}
+			}
+			values[out] = res
+		case "MatMul":
+			b := in[1]
+			res := onnxValue{dims: []int{1, b.dims[1]}, v: make([]float64, b.dims[1])}
+			for j := range res.v {
+				for k := 0; k < b.dims[0]; k++ {
+					res.v[j] += a.v[k] * b.v[k*b.dims[1]+j]
+				}
+			}
+			values[out] = res
+		case "ReduceSumSquare":
+			sum := 0.0
+			for _, v := range a.v {
+				sum += v * v
+			}
+			values[out] = onnxValue{dims: []int{1, 1}, v: []float64{sum}}
+		case "ArgMin", "ReduceMin":
+			best := 0
+			for j, v := range a.v {
+				if v < a.v[best] {
+					best = j
+				}
+			}
+			if op == "ArgMin" {
+				values[out] = onnxValue{dims: []int{1}, v: []float64{float64(best)}}
+			} else {
+				values[out] = onnxValue{dims: []int{1}, v: []float64{a.v[best]}}
+			}
+		case "Sqrt":
+			values[out] = onnxValue{dims: a.dims, v: []float64{math.Sqrt(a.v[0])}}
+		default:
+			t.Fatalf("unexpected op: %s", op)
+		}
+	}
+	return int(values["unit"].v[0]), values["distance"].v[0]
+}
+
+func TestWriteONNX(t *testing.T) {
+	assert := assert.New(t)
+
+	m, data := makeExportMap(t)
+	var buf bytes.Buffer
+	assert.NoError(m.WriteONNX(&buf))
+	ints, msgs := pbFields(t, buf.Bytes())
+	assert.Equal([]int64{onnxIRVersion}, ints[1])
+	assert.Equal("gosom", string(msgs[2][0]))
+	opInts, _ := pbFields(t, msgs[8][0])
+	assert.Equal([]int64{onnxOpset}, opInts[2])
+	graph := msgs[7][0]
+	_, fields := pbFields(t, graph)
+	assert.Len(fields[11], 1)
+	assert.Len(fields[12], 2)
+	// the graph finds the same BMUs as Predict
+	bmus, dists, err := m.Predict(data, 0)
+	assert.NoError(err)
+	rows, _ := data.Dims()
+	for i := 0; i < rows; i++ {
+		unit, dist := testGraph(t, graph, data.RawRowView(i))
+		assert.Equal(bmus[i], unit)
+		assert.InDelta(dists[i], dist, 1e-6)
+	}
+
+	// map without scaler and feature weights
+	plain := &Map{codebook: mat64.NewDense(2, 2, []float64{0, 0, 3, 4}), grid: m.grid}
+	buf.Reset()
+	assert.NoError(plain.WriteONNX(&buf))
+	_, msgs = pbFields(t, buf.Bytes())
+	unit, dist := testGraph(t, msgs[7][0], []float64{3, 3})
+	assert.Equal(1, unit)
+	assert.InDelta(1.0, dist, 1e-9)
+
+	// unsupported maps
+	plain.metric = Cosine
+	assert.True(errors.Is(plain.WriteONNX(&buf), ErrUnsupportedMetric))
+}
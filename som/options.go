@@ -0,0 +1,363 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// mapOptions holds map configuration populated by Options
+type mapOptions struct {
+	// size holds map grid dimensions; nil if they are estimated from data
+	size []int
+	// gridType is map grid type
+	gridType GridType
+	// uShape is map unit shape
+	uShape UnitShape
+	// connectivity is connectivity of rectangle units; empty for axial units
+	connectivity Connectivity
+	// init is the name of codebook initialization function
+	init string
+	// metric is distance metric
+	metric string
+	// seed is the seed of random codebook initialization
+	seed int64
+	// hasSeed is true if the seed was set
+	hasSeed bool
+	// weights holds feature weights; nil if features are not weighted
+	weights []float64
+	// layers holds input layers whose feature weights are computed from data; nil if there are no layers
+	layers []Layer
+	// precision is codebook storage precision
+	precision string
+}
+
+// Option configures map created by NewMapWithOptions.
+// It returns error if the option value is invalid.
+type Option func(*mapOptions) error
+
+// WithDims sets map grid dimensions to x times y units.
+// If it is not used, the grid dimensions are estimated from data using GridSize.
+func WithDims(x, y int) Option {
+	return func(o *mapOptions) error {
+		if x <= 0 || y <= 0 || x*y == 1 {
+			return fmt.Errorf("%w: [%d %d]", ErrInvalidDims, x, y)
+		}
+		o.size = []int{x, y}
+		return nil
+	}
+}
+
+// WithGrid sets map grid type: planar, toroidal, cylindrical, spherical or a type registered by RegisterGridType.
+// The default grid type is planar.
+func WithGrid(gridType string) Option {
+	return func(o *mapOptions) error {
+		t, err := ParseGridType(gridType)
+		if err != nil {
+			return err
+		}
+		o.gridType = t
+		return nil
+	}
+}
+
+// WithUShape sets map unit shape: hexagon, rectangle. The default unit shape is hexagon.
+func WithUShape(uShape string) Option {
+	return func(o *mapOptions) error {
+		u, err := ParseUnitShape(uShape)
+		if err != nil {
+			return err
+		}
+		o.uShape = u
+		return nil
+	}
+}
+
+// WithConnectivity sets connectivity of rectangle units: axial, moore. The default connectivity is axial.
+// Maps of hexagon units with moore connectivity are reported by NewMap.
+func WithConnectivity(connectivity string) Option {
+	return func(o *mapOptions) error {
+		c, err := ParseConnectivity(connectivity)
+		if err != nil {
+			return err
+		}
+		o.connectivity = c
+		return nil
+	}
+}
+
+// WithInit sets codebook initialization: rand for RandInit, lin for LinInit, sample for SampleInit
+// or an initialization registered by RegisterCbInit.
+// The default initialization is rand.
+func WithInit(init string) Option {
+	return func(o *mapOptions) error {
+		if cbInitFunc(init) == nil {
+			return fmt.Errorf("unsupported codebook initialization: %s", init)
+		}
+		o.init = init
+		return nil
+	}
+}
+
+// WithMetric sets distance metric used by BMU search: euclidean, manhattan, chebyshev, cosine,
+// correlation or a metric registered by RegisterDistanceFunc. The default metric is euclidean.
+func WithMetric(metric string) Option {
+	return func(o *mapOptions) error {
+		if distanceFunc(metric) == nil {
+			return fmt.Errorf("%w: %s", ErrUnsupportedMetric, metric)
+		}
+		o.metric = metric
+		return nil
+	}
+}
+
+// WithSeed sets the seed of random codebook initialization.
+// If it is not used, rand and sample initialization use the fixed seed of RandInit.
+func WithSeed(seed int64) Option {
+	return func(o *mapOptions) error {
+		o.seed, o.hasSeed = seed, true
+		return nil
+	}
+}
+
+// WithFeatureWeights sets weights of codebook features, one per data column, which weight euclidean
+// distance of BMU search as described in CbConfig.FeatureWeights. Invalid weights are reported by NewMap.
+// It returns error if no weights are supplied.
+func WithFeatureWeights(weights ...float64) Option {
+	return func(o *mapOptions) error {
+		if len(weights) == 0 {
+			return fmt.Errorf("invalid feature weights: no weights supplied")
+		}
+		o.weights = weights
+		return nil
+	}
+}
+
+// WithLayers sets input layers of multi-view maps. Feature weights of the layers are computed from data
+// by LayerWeights when the configuration is created. It returns error if no layers are supplied.
+// Layers can't be combined with WithFeatureWeights.
+func WithLayers(layers ...Layer) Option {
+	return func(o *mapOptions) error {
+		if len(layers) == 0 {
+			return fmt.Errorf("invalid layers: no layers supplied")
+		}
+		o.layers = layers
+		return nil
+	}
+}
+
+// WithPrecision sets codebook storage precision: float64 or float32. Float32 codebooks need half
+// the memory of float64 ones and are searched by euclidean distance whose squared element differences
+// are accumulated in float64. The default precision is float64.
+func WithPrecision(precision string) Option {
+	return func(o *mapOptions) error {
+		if !precisions.Has(precision) {
+			return fmt.Errorf("unsupported codebook precision: %s", precision)
+		}
+		o.precision = precision
+		return nil
+	}
+}
+
+// optionErrors holds the errors of invalid options of configuration kind, map or training.
+// The errors can be checked using errors.Is.
+type optionErrors struct {
+	kind string
+	errs []error
+}
+
+// Error returns the messages of all option errors
+func (e *optionErrors) Error() string {
+	msgs := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		msgs[i] = err.Error()
+	}
+	return "invalid " + e.kind + " options: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the option errors
+func (e *optionErrors) Unwrap() []error {
+	return e.errs
+}
+
+// NewMapWithOptions creates new SOM for data using the provided options.
+// Options populate MapConfig, which is created by NewMapConfig, and the configuration is then passed
+// to NewMap. It returns error if NewMapConfig or NewMap fails.
+func NewMapWithOptions(data *mat64.Dense, opts ...Option) (*Map, error) {
+	c, err := NewMapConfig(data, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewMap(c, data)
+}
+
+// NewMapConfig returns map configuration for data populated by the provided options.
+// Codebook dimension is set to the number of data columns and options which are not supplied use
+// their documented defaults. It returns error if data is nil, if any of the options is invalid,
+// in which case the errors of all invalid options are reported and wrapped, or if the populated
+// configuration is invalid.
+func NewMapConfig(data *mat64.Dense, opts ...Option) (*MapConfig, error) {
+	if data == nil {
+		return nil, fmt.Errorf("invalid input data: %v", data)
+	}
+	o := &mapOptions{
+		gridType: "planar",
+		uShape:   "hexagon",
+		init:     "rand",
+		metric:   "euclidean",
+	}
+	errs := &optionErrors{kind: "map"}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			errs.errs = append(errs.errs, err)
+		}
+	}
+	if len(errs.errs) > 0 {
+		return nil, errs
+	}
+	if o.layers != nil {
+		if o.weights != nil {
+			return nil, fmt.Errorf("invalid map options: layers can't be combined with feature weights")
+		}
+		weights, err := LayerWeights(data, o.layers)
+		if err != nil {
+			return nil, err
+		}
+		o.weights = weights
+	}
+	if o.size == nil {
+		size, err := GridSize(data, string(o.uShape))
+		if err != nil {
+			return nil, err
+		}
+		o.size = size
+	}
+	initFunc := cbInitFunc(o.init)
+	switch {
+	case o.hasSeed && o.init == "rand":
+		initFunc = RandInitSeed(o.seed)
+	case o.hasSeed && o.init == "sample":
+		initFunc = SampleInitSeed(o.seed)
+	}
+	_, cols := data.Dims()
+	c := &MapConfig{
+		Grid: &GridConfig{
+			Size:         o.size,
+			Type:         o.gridType,
+			UShape:       o.uShape,
+			Connectivity: o.connectivity,
+		},
+		Cb: &CbConfig{
+			Dim:            cols,
+			InitFunc:       initFunc,
+			FeatureWeights: o.weights,
+			Precision:      o.precision,
+		},
+	}
+	// euclidean maps use the optimized BMU search
+	if o.metric != "euclidean" {
+		c.Cb.Metric = distanceFunc(o.metric)
+	}
+	if err := validateMapConfig(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// TrainOption configures training configuration created by NewTrainConfig.
+// It returns error if the option value is invalid.
+type TrainOption func(*TrainConfig) error
+
+// WithSeqTraining sets sequential training
+func WithSeqTraining() TrainOption {
+	return func(c *TrainConfig) error {
+		c.Algorithm = Seq
+		return nil
+	}
+}
+
+// WithBatchTraining sets batch training by workers goroutines, which is the default training.
+// If workers is zero, the number of CPUs is used.
+func WithBatchTraining(workers int) TrainOption {
+	return func(c *TrainConfig) error {
+		if workers < 0 {
+			return fmt.Errorf("invalid number of workers: %d", workers)
+		}
+		c.Algorithm, c.Workers = Batch, workers
+		return nil
+	}
+}
+
+// WithRadius sets initial units radius and its decay strategy.
+// If it is not used, the radius is derived from map dimensions as by AutoRadius and decays exponentially.
+func WithRadius(radius float64, decay Decay) TrainOption {
+	return func(c *TrainConfig) error {
+		if !(radius > 0) || math.IsInf(radius, 1) {
+			return fmt.Errorf("%w: %f, must be positive and finite", ErrInvalidRadius, radius)
+		}
+		if _, err := ParseDecay(string(decay)); err != nil {
+			return err
+		}
+		c.Radius, c.AutoRadius, c.RDecay = radius, false, decay
+		return nil
+	}
+}
+
+// WithLRate sets initial learning rate and its decay strategy.
+// If it is not used, the learning rate is 0.5 and decays exponentially.
+func WithLRate(lrate float64, decay Decay) TrainOption {
+	return func(c *TrainConfig) error {
+		if !(lrate > 0) || math.IsInf(lrate, 1) {
+			return fmt.Errorf("%w: %f, must be positive and finite", ErrInvalidLRate, lrate)
+		}
+		if _, err := ParseDecay(string(decay)); err != nil {
+			return err
+		}
+		c.LRate, c.LDecay = lrate, decay
+		return nil
+	}
+}
+
+// WithNeighbFn sets neighbourhood function by name: gaussian, bubble, mexican, cutgauss, epanechnikov
+// or a function registered by RegisterNeighbFunc. The default function is gaussian.
+func WithNeighbFn(name string) TrainOption {
+	return func(c *TrainConfig) error {
+		fn, err := NeighbFuncByName(name)
+		if err != nil {
+			return err
+		}
+		c.NeighbFn = fn
+		return nil
+	}
+}
+
+// NewTrainConfig returns training configuration populated by the provided options. Options which are
+// not supplied use their documented defaults: batch training with gaussian neighbourhood, radius derived
+// from map dimensions and 0.5 learning rate, both decaying exponentially. Other fields can be set
+// on the returned configuration. It returns error if any of the options is invalid, in which case
+// the errors of all invalid options are reported and wrapped, or if the populated configuration is invalid.
+func NewTrainConfig(opts ...TrainOption) (*TrainConfig, error) {
+	c := &TrainConfig{
+		Algorithm:  Batch,
+		AutoRadius: true,
+		RDecay:     ExpDecay,
+		NeighbFn:   Gaussian,
+		LRate:      0.5,
+		LDecay:     ExpDecay,
+	}
+	errs := &optionErrors{kind: "training"}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			errs.errs = append(errs.errs, err)
+		}
+	}
+	if len(errs.errs) > 0 {
+		return nil, errs
+	}
+	if err := validateTrainConfig(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,166 @@
+package som
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMapWithOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(200, 3, 3, 10.0, -10.0, 2.0, 10)
+	// options populate the same configuration as the struct based API
+	m, err := NewMapWithOptions(data, WithDims(6, 4), WithUShape("rectangle"), WithInit("lin"))
+	assert.NoError(err)
+	expMap, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{6, 4}, Type: "planar", UShape: "rectangle"},
+		Cb:   &CbConfig{Dim: 3, InitFunc: LinInit},
+	}, data)
+	assert.NoError(err)
+	assert.Equal(expMap.codebook, m.codebook)
+	assert.Equal([]int{6, 4}, m.Grid().Size())
+	assert.Equal("rectangle", m.Grid().UShape())
+	// defaults
+	m, err = NewMapWithOptions(data)
+	assert.NoError(err)
+	size, err := GridSize(data, "hexagon")
+	assert.NoError(err)
+	assert.Equal(size, m.Grid().Size())
+	assert.Equal("hexagon", m.Grid().UShape())
+	expCb, err := RandInit(data, size)
+	assert.NoError(err)
+	assert.Equal(expCb, m.codebook)
+	// seeded random initialization is reproducible
+	m1, err := NewMapWithOptions(data, WithDims(5, 5), WithSeed(42), WithGrid("planar"), WithMetric("euclidean"))
+	assert.NoError(err)
+	m2, err := NewMapWithOptions(data, WithSeed(42), WithDims(5, 5))
+	assert.NoError(err)
+	assert.Equal(m1.codebook, m2.codebook)
+	m3, err := NewMapWithOptions(data, WithDims(5, 5), WithSeed(7))
+	assert.NoError(err)
+	assert.NotEqual(m1.codebook, m3.codebook)
+	// seed 55 is the fixed seed of RandInit
+	m4, err := NewMapWithOptions(data, WithDims(5, 5), WithSeed(55))
+	assert.NoError(err)
+	expCb, err = RandInit(data, []int{5, 5})
+	assert.NoError(err)
+	assert.Equal(expCb, m4.codebook)
+	// seeded sample initialization
+	m5, err := NewMapWithOptions(data, WithDims(5, 5), WithInit("sample"), WithSeed(7))
+	assert.NoError(err)
+	expCb, err = SampleInitSeed(7)(data, []int{5, 5})
+	assert.NoError(err)
+	assert.Equal(expCb, m5.codebook)
+	// nil data
+	m, err = NewMapWithOptions(nil, WithDims(5, 5))
+	assert.Nil(m)
+	assert.EqualError(err, "invalid input data: <nil>")
+	// invalid options are reported together
+	testCases := []struct {
+		opts []Option
+		err  string
+	}{
+		{[]Option{WithDims(0, 3)}, "invalid map options: invalid grid dimensions: [0 3]"},
+		{[]Option{WithDims(1, 1)}, "invalid map options: invalid grid dimensions: [1 1]"},
+		{[]Option{WithGrid("toroid")}, "invalid map options: unsupported grid type: toroid"},
+		{[]Option{WithUShape("triangle")}, "invalid map options: unsupported unit shape: triangle"},
+		{[]Option{WithInit("zero")}, "invalid map options: unsupported codebook initialization: zero"},
+		{[]Option{WithMetric("hamming")}, "invalid map options: unsupported metric: hamming"},
+		{[]Option{WithDims(-1, 2), WithSeed(1), WithUShape("circle")},
+			"invalid map options: invalid grid dimensions: [-1 2]; unsupported unit shape: circle"},
+	}
+	for _, tc := range testCases {
+		m, err := NewMapWithOptions(data, tc.opts...)
+		assert.Nil(m)
+		assert.EqualError(err, tc.err)
+	}
+	// option errors are wrapped
+	_, err = NewMapWithOptions(data, WithDims(0, 3), WithGrid("toroid"), WithUShape("circle"))
+	assert.True(errors.Is(err, ErrInvalidDims))
+	assert.True(errors.Is(err, ErrUnsupportedGrid))
+	assert.True(errors.Is(err, ErrUnsupportedUShape))
+	assert.False(errors.Is(err, ErrDimMismatch))
+	// NewMap errors are returned
+	m, err = NewMapWithOptions(mat64.NewDense(1, 2, []float64{1.0, 2.0}), WithDims(3, 3), WithInit("lin"))
+	assert.Nil(m)
+	assert.EqualError(err, "Insufficient number of samples: 1")
+}
+
+func TestNewMapConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(100, 2, 3, 10.0, -10.0, 2.0, 10)
+	c, err := NewMapConfig(data, WithDims(4, 3), WithGrid("toroidal"), WithUShape("rectangle"))
+	assert.NoError(err)
+	assert.Equal(&GridConfig{Size: []int{4, 3}, Type: Toroidal, UShape: Rectangle}, c.Grid)
+	assert.Equal(2, c.Cb.Dim)
+	// the configuration is validated eagerly
+	_, err = NewMapConfig(data, WithDims(3, 4), WithGrid("toroidal"))
+	assert.True(errors.Is(err, ErrInvalidDims))
+	_, err = NewMapConfig(nil)
+	assert.Error(err)
+
+	// float32 codebook
+	c, err = NewMapConfig(data, WithDims(4, 3), WithPrecision("float32"))
+	assert.NoError(err)
+	m, err := NewMap(c, data)
+	assert.NoError(err)
+	assert.Equal("float32", m.Precision())
+	_, err = NewMapConfig(data, WithPrecision("float16"))
+	assert.EqualError(err, "invalid map options: unsupported codebook precision: float16")
+}
+
+func TestNewTrainConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	// defaults
+	c, err := NewTrainConfig()
+	assert.NoError(err)
+	assert.Equal(Batch, c.Algorithm)
+	assert.True(c.AutoRadius)
+	assert.Equal(0.5, c.LRate)
+	assert.Equal(ExpDecay, c.RDecay)
+	assert.Equal(ExpDecay, c.LDecay)
+	assert.Equal(Gaussian(1.0, 2.0), c.NeighbFn(1.0, 2.0))
+
+	c, err = NewTrainConfig(WithSeqTraining(), WithRadius(3.0, LinDecay), WithLRate(0.1, InvDecay), WithNeighbFn("bubble"))
+	assert.NoError(err)
+	assert.Equal(Seq, c.Algorithm)
+	assert.False(c.AutoRadius)
+	assert.Equal(3.0, c.Radius)
+	assert.Equal(LinDecay, c.RDecay)
+	assert.Equal(0.1, c.LRate)
+	assert.Equal(InvDecay, c.LDecay)
+	assert.Equal(Bubble(1.0, 2.0), c.NeighbFn(1.0, 2.0))
+	c, err = NewTrainConfig(WithBatchTraining(4))
+	assert.NoError(err)
+	assert.Equal(4, c.Workers)
+
+	// trained map is the same as the map trained by the equivalent struct configuration
+	data := utils.GenerateClusters(100, 2, 3, 10.0, -10.0, 2.0, 10)
+	mc, err := NewMapConfig(data, WithDims(4, 4))
+	assert.NoError(err)
+	m1, err := NewMap(mc, data)
+	assert.NoError(err)
+	m2, err := NewMap(mc, data)
+	assert.NoError(err)
+	c, err = NewTrainConfig(WithRadius(2.0, ExpDecay))
+	assert.NoError(err)
+	assert.NoError(m1.Train(c, data, 10))
+	assert.NoError(m2.Train(&TrainConfig{Algorithm: "batch", Radius: 2.0, RDecay: "exp", NeighbFn: Gaussian, LRate: 0.5, LDecay: "exp"}, data, 10))
+	assert.True(mat64.Equal(m1.CodebookRaw(), m2.CodebookRaw()))
+
+	// errors of all invalid options are reported
+	_, err = NewTrainConfig(WithRadius(-1.0, ExpDecay), WithLRate(0.5, "fast"), WithNeighbFn("cone"), WithBatchTraining(-1))
+	assert.True(errors.Is(err, ErrInvalidRadius))
+	assert.True(errors.Is(err, ErrUnsupportedDecay))
+	assert.True(errors.Is(err, ErrUnsupportedNeighbFn))
+	assert.EqualError(err, "invalid training options: invalid radius: -1.000000, must be positive and finite; "+
+		"unsupported decay strategy: fast; unsupported neighbourhood function: cone; invalid number of workers: -1")
+	_, err = NewTrainConfig(WithLRate(0, ExpDecay))
+	assert.True(errors.Is(err, ErrInvalidLRate))
+}
@@ -0,0 +1,53 @@
+package som
+
+import "github.com/gonum/matrix/mat64"
+
+// Path is the trajectory of ordered data samples, such as states of a system over time, on the map grid
+type Path struct {
+	// BMUs holds BMUs of the samples
+	BMUs []int
+	// Coords holds grid coordinates of the sample BMUs, one row per sample
+	Coords *mat64.Dense
+	// Steps holds grid distances between BMUs of consecutive samples: Steps[i] is the distance
+	// between BMUs of samples i-1 and i; Steps[0] is zero
+	Steps []float64
+}
+
+// BMUPath returns the path of data rows, which are samples ordered in time, on the map grid:
+// BMUs of the samples, their grid coordinates and grid distances between BMUs of consecutive samples.
+// Samples of a stable regime move between nearby units, so long steps mark regime changes.
+// BMUs are found in the same way as Predict; unlike Trajectory, every BMU depends only on its sample.
+// It returns error in the same way as Predict.
+func (m Map) BMUPath(data mat64.Matrix) (*Path, error) {
+	bmus, _, err := m.Predict(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	_, dim := m.grid.coords.Dims()
+	p := &Path{
+		BMUs:   bmus,
+		Coords: mat64.NewDense(len(bmus), dim, nil),
+		Steps:  make([]float64, len(bmus)),
+	}
+	for i, bmu := range bmus {
+		p.Coords.SetRow(i, m.grid.coords.RawRowView(bmu))
+		if i > 0 {
+			p.Steps[i] = m.grid.dist(m.grid.coords.RawRowView(bmus[i-1]), m.grid.coords.RawRowView(bmu))
+		}
+	}
+	return p, nil
+}
+
+// Jumps returns indices of samples whose BMU is at least minStep grid distance away from the BMU
+// of the previous sample, such as the first samples of new regimes
+func (p *Path) Jumps(minStep float64) []int {
+	var jumps []int
+	// allow for rounding errors of hexagon grid coordinates
+	minStep -= 1e-9
+	for i, step := range p.Steps {
+		if i > 0 && step >= minStep {
+			jumps = append(jumps, i)
+		}
+	}
+	return jumps
+}
@@ -0,0 +1,50 @@
+package som
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBMUPath(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(makePrecisionMapCfg([]int{4, 3}, 2, ""), unitBlobs(20, 1))
+	assert.NoError(err)
+	for i := 0; i < 12; i++ {
+		m.setUnit(i, []float64{float64(i), 0})
+	}
+	// two regimes around units 0 and 1 followed by a regime around unit 11
+	data := mat64.NewDense(6, 2, []float64{0, 0.1, 0.9, 0, 0.1, 0, 11, 0, 11.2, 0, 10.9, 0.1})
+	p, err := m.BMUPath(data)
+	assert.NoError(err)
+	assert.Equal([]int{0, 1, 0, 11, 11, 11}, p.BMUs)
+	for i, bmu := range p.BMUs {
+		assert.Equal(m.UnitCoords(bmu), p.Coords.RawRowView(i))
+	}
+	grid := m.GridCoords()
+	assert.Equal(0.0, p.Steps[0])
+	assert.InDelta(1.0, p.Steps[1], 1e-12)
+	assert.InDelta(Euclidean(grid.RawRowView(0), grid.RawRowView(11)), p.Steps[3], 1e-12)
+	assert.Equal(0.0, p.Steps[4])
+	assert.Equal([]int{3}, p.Jumps(2.0))
+	assert.Equal([]int{1, 2, 3}, p.Jumps(1.0))
+
+	// paths of sliding windows of a time series
+	series := mat64.NewDense(7, 1, []float64{0, 0, 0, 0, 5, 5, 5})
+	windows, err := matrix.Windows(series, 2, 1)
+	assert.NoError(err)
+	m, err = NewMap(makePrecisionMapCfg([]int{4, 3}, 2, ""), windows)
+	assert.NoError(err)
+	for i := 0; i < 12; i++ {
+		m.setUnit(i, []float64{float64(i) / 2, float64(i) / 2})
+	}
+	p, err = m.BMUPath(windows)
+	assert.NoError(err)
+	assert.Equal([]int{0, 0, 0, 5, 10, 10}, p.BMUs)
+
+	_, err = m.BMUPath(nil)
+	assert.Error(err)
+}
@@ -0,0 +1,214 @@
+package som
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// mapFormatVersion is the version of the format of saved maps
+const mapFormatVersion = 1
+
+// savedMap is map state stored by Save
+type savedMap struct {
+	// Version is the format version
+	Version int
+	// Size, UShape and Type hold grid dimensions, unit shape and grid type
+	Size   []int
+	UShape string
+	Type   string
+	// CoordDim is the dimension of grid unit coordinates stored in Coords in row major order
+	CoordDim int
+	Coords   []float64
+	// Cells, Periods and Radius hold grid mask, toroidal or cylindrical periods and sphere radius
+	Cells   []int
+	Periods []float64
+	Radius  float64
+	// Connectivity holds connectivity of rectangle units; it is empty for axial units
+	Connectivity string
+	// Dim is codebook vector dimension; Codebook holds codebook vectors in row major order
+	Dim      int
+	Codebook []float64
+	// Float32 is true if the codebook is stored in float32 precision
+	Float32 bool
+	// Metric is the name of the registered distance metric; it is empty for euclidean maps
+	Metric string
+	// Labels, Purity, Targets, Clusters and ClassDist hold unit state of trained maps;
+	// ClassDist has one row of Classes distribution per unit
+	Labels    []string
+	Purity    []float64
+	Targets   []float64
+	Clusters  []int
+	Classes   []string
+	ClassDist []float64
+	// Threshold is anomaly threshold if HasThreshold is set
+	Threshold    float64
+	HasThreshold bool
+	// Relevance holds learned feature relevances
+	Relevance []float64
+	// NoTopology is true if the map has no topology
+	NoTopology bool
+	// LastTrain holds parameters of the last map training
+	LastTrain *TrainResult
+	// Scaler holds the map scaler if the map has one
+	Scaler *Scaler
+	// Features holds codebook feature names
+	Features []string
+}
+
+// Save writes the map to w so it can be restored by LoadMap: codebook, grid, unit labels, targets,
+// clusters and class distributions, anomaly threshold, feature relevances and names, scaler and parameters of the
+// last training are saved. Quality measures recorded by RecordQuality are not saved.
+// It returns error if the map distance metric is not registered or if the write to w fails.
+func (m Map) Save(w io.Writer) error {
+	_, dim := m.cbDims()
+	_, coordDim := m.grid.coords.Dims()
+	s := &savedMap{
+		Version:      mapFormatVersion,
+		Size:         m.grid.size,
+		UShape:       m.grid.ushape,
+		Type:         m.grid.gtype,
+		CoordDim:     coordDim,
+		Coords:       mat64.DenseCopyOf(m.grid.coords).RawMatrix().Data,
+		Cells:        m.grid.cells,
+		Periods:      m.grid.periods,
+		Radius:       m.grid.radius,
+		Connectivity: m.grid.connectivity,
+		Dim:          dim,
+		Codebook:     m.Codebook().RawMatrix().Data,
+		Float32:      m.cb32 != nil,
+		Labels:       m.labels,
+		Purity:       m.purity,
+		Targets:      m.targets,
+		Clusters:     m.clusters,
+		Classes:      m.classes,
+		Threshold:    m.threshold,
+		HasThreshold: m.hasThreshold,
+		Relevance:    m.relevance,
+		NoTopology:   m.noTopology,
+		LastTrain:    m.lastTrain,
+		Scaler:       m.scaler,
+		Features:     m.features,
+	}
+	if m.metric != nil {
+		if s.Metric = distanceFuncName(m.metric); s.Metric == "" {
+			return fmt.Errorf("%w: map distance metric is not registered", ErrUnsupportedMetric)
+		}
+	}
+	if m.classDist != nil {
+		s.ClassDist = mat64.DenseCopyOf(m.classDist).RawMatrix().Data
+	}
+	return gob.NewEncoder(w).Encode(s)
+}
+
+// LoadMap reads map saved by Save from r.
+// It returns error if the read from r fails, if the saved map is corrupted, if its format
+// version is not supported or ErrUnsupportedMetric if its distance metric is not registered.
+func LoadMap(r io.Reader) (*Map, error) {
+	s := new(savedMap)
+	if err := gob.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("invalid saved map: %s", err)
+	}
+	if s.Version != mapFormatVersion {
+		return nil, fmt.Errorf("unsupported saved map version: %d", s.Version)
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	units := len(s.Codebook) / s.Dim
+	cb := mat64.NewDense(units, s.Dim, s.Codebook)
+	m := &Map{
+		codebook: cb,
+		grid: &Grid{
+			size:         s.Size,
+			ushape:       s.UShape,
+			gtype:        s.Type,
+			coords:       mat64.NewDense(units, s.CoordDim, s.Coords),
+			cells:        s.Cells,
+			periods:      s.Periods,
+			radius:       s.Radius,
+			connectivity: s.Connectivity,
+		},
+		labels:       s.Labels,
+		purity:       s.Purity,
+		targets:      s.Targets,
+		clusters:     s.Clusters,
+		classes:      s.Classes,
+		threshold:    s.Threshold,
+		hasThreshold: s.HasThreshold,
+		relevance:    s.Relevance,
+		noTopology:   s.NoTopology,
+		lastTrain:    s.LastTrain,
+		scaler:       s.Scaler,
+		features:     s.Features,
+	}
+	if s.Metric != "" {
+		if m.metric = distanceFunc(s.Metric); m.metric == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedMetric, s.Metric)
+		}
+	}
+	if s.ClassDist != nil {
+		m.classDist = mat64.NewDense(units, len(s.Classes), s.ClassDist)
+	}
+	if s.Float32 {
+		m.codebook, m.cb32 = nil, newCodebook32(cb)
+	}
+	m.norms = m.cbNorms()
+
+	return m, nil
+}
+
+// validate checks that the dimensions of saved map state are consistent
+func (s *savedMap) validate() error {
+	if s.Dim <= 0 || len(s.Codebook) == 0 || len(s.Codebook)%s.Dim != 0 {
+		return fmt.Errorf("invalid saved map: corrupted codebook")
+	}
+	units := len(s.Codebook) / s.Dim
+	if len(s.Size) == 0 || s.CoordDim <= 0 || len(s.Coords) != units*s.CoordDim {
+		return fmt.Errorf("invalid saved map: corrupted grid")
+	}
+	if s.Cells != nil && len(s.Cells) != units {
+		return fmt.Errorf("invalid saved map: corrupted grid mask")
+	}
+	for _, n := range []int{len(s.Labels), len(s.Purity), len(s.Targets), len(s.Clusters)} {
+		if n != 0 && n != units {
+			return fmt.Errorf("invalid saved map: corrupted unit state")
+		}
+	}
+	if s.ClassDist != nil && (len(s.Classes) == 0 || len(s.ClassDist) != units*len(s.Classes)) {
+		return fmt.Errorf("invalid saved map: corrupted class distributions")
+	}
+	if s.Relevance != nil && len(s.Relevance) != s.Dim {
+		return fmt.Errorf("invalid saved map: corrupted feature relevances")
+	}
+	if sc := s.Scaler; sc != nil && sc.Method != UnitLength && (len(sc.Center) != s.Dim || len(sc.Scale) != s.Dim) {
+		return fmt.Errorf("invalid saved map: corrupted scaler")
+	}
+	if s.Features != nil && len(s.Features) != s.Dim {
+		return fmt.Errorf("invalid saved map: corrupted feature names")
+	}
+	return nil
+}
+
+// MarshalBinary encodes the map in the same way as Save
+func (m Map) MarshalBinary() ([]byte, error) {
+	var b bytes.Buffer
+	if err := m.Save(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// UnmarshalBinary sets the map to the map encoded by MarshalBinary.
+// It returns error in the same way as LoadMap; the map is not changed when the error is returned.
+func (m *Map) UnmarshalBinary(data []byte) error {
+	loaded, err := LoadMap(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	*m = *loaded
+	return nil
+}
@@ -0,0 +1,156 @@
+package som
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// persistMap returns trained 3x4 map of data with codebook precision and metric
+func persistMap(data *mat64.Dense, gridType GridType, precision string, metric DistanceFunc) (*Map, error) {
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{4, 3}, Type: gridType, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit, Precision: precision, Metric: metric},
+	}, data)
+	if err != nil {
+		return nil, err
+	}
+	tc := gsomTrainConfig()
+	_, err = m.train(tc, data, 1000, rand.New(rand.NewSource(1)))
+	return m, err
+}
+
+func TestSaveLoadMap(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	for _, tc := range []struct {
+		gridType  GridType
+		precision string
+		metric    DistanceFunc
+	}{
+		{Planar, "", nil},
+		{Toroidal, "", nil},
+		{Cylindrical, "", nil},
+		{Planar, "float32", nil},
+		{Planar, "", Manhattan},
+	} {
+		m, err := persistMap(data, tc.gridType, tc.precision, tc.metric)
+		assert.NoError(err)
+		m.labels = []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}
+		m.clusters = []int{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2}
+		m.classes = []string{"x", "y"}
+		m.classDist = mat64.NewDense(12, 2, nil)
+		m.classDist.Set(3, 1, 1.0)
+		m.threshold, m.hasThreshold = 0.25, true
+		m.features = []string{"x", "y"}
+		var b bytes.Buffer
+		assert.NoError(m.Save(&b))
+		loaded, err := LoadMap(&b)
+		assert.NoError(err)
+		assert.True(mat64.Equal(m.Codebook(), loaded.Codebook()))
+		assert.True(mat64.Equal(m.GridCoords(), loaded.GridCoords()))
+		assert.Equal(m.Grid().Size(), loaded.Grid().Size())
+		assert.Equal(m.Grid().Type(), loaded.Grid().Type())
+		assert.Equal(m.Grid().UShape(), loaded.Grid().UShape())
+		assert.Equal(tc.precision == "float32", loaded.cb32 != nil)
+		assert.Equal(tc.metric != nil, loaded.metric != nil)
+		assert.Equal(m.labels, loaded.labels)
+		assert.Equal(m.clusters, loaded.clusters)
+		assert.Equal(m.classes, loaded.classes)
+		assert.True(mat64.Equal(m.classDist, loaded.classDist))
+		assert.Equal(m.features, loaded.features)
+		assert.Equal(0.25, loaded.threshold)
+		assert.True(loaded.hasThreshold)
+		assert.Equal(m.lastTrain.Algorithm, loaded.lastTrain.Algorithm)
+		assert.Equal(m.lastTrain.Iters, loaded.lastTrain.Iters)
+		// the loaded map predicts the same BMUs and has the same topology
+		bmus, err := m.BMUs(data)
+		assert.NoError(err)
+		loadedBmus, err := loaded.BMUs(data)
+		assert.NoError(err)
+		assert.Equal(bmus, loadedBmus)
+		te, err := m.TopoError(data)
+		assert.NoError(err)
+		loadedTe, err := loaded.TopoError(data)
+		assert.NoError(err)
+		assert.Equal(te, loadedTe)
+	}
+
+	// masked grids keep their mask
+	m, err := trainGSOM(data, 0.9, gsomTrainConfig(), rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	enc, err := m.MarshalBinary()
+	assert.NoError(err)
+	loaded := new(Map)
+	assert.NoError(loaded.UnmarshalBinary(enc))
+	assert.Equal(m.Grid().Mask(), loaded.Grid().Mask())
+	assert.True(mat64.Equal(m.Codebook(), loaded.Codebook()))
+
+	// moore units keep their connectivity
+	m, err = NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Rectangle, Connectivity: Moore},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	var b bytes.Buffer
+	assert.NoError(m.Save(&b))
+	loaded, err = LoadMap(&b)
+	assert.NoError(err)
+	assert.Equal(string(Moore), loaded.Grid().Connectivity())
+}
+
+func TestSaveLoadMapErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	// distance metric which is not registered
+	m, err := persistMap(data, Planar, "", func(a, b []float64) float64 {
+		return Euclidean(a, b)
+	})
+	assert.NoError(err)
+	assert.True(errors.Is(m.Save(&bytes.Buffer{}), ErrUnsupportedMetric))
+
+	_, err = LoadMap(strings.NewReader("not a map"))
+	assert.Error(err)
+	m, err = persistMap(data, Planar, "", nil)
+	assert.NoError(err)
+	var b bytes.Buffer
+	assert.NoError(m.Save(&b))
+	valid, orig := b.Bytes(), m.Codebook()
+	for _, corrupt := range []func(s *savedMap){
+		func(s *savedMap) { s.Version = 2 },
+		func(s *savedMap) { s.Dim = 0 },
+		func(s *savedMap) { s.Codebook = s.Codebook[1:] },
+		func(s *savedMap) { s.Coords = s.Coords[2:] },
+		func(s *savedMap) { s.Labels = []string{"a"} },
+		func(s *savedMap) { s.ClassDist = []float64{1.0} },
+		func(s *savedMap) { s.Relevance = []float64{1.0} },
+		func(s *savedMap) { s.Features = []string{"x"} },
+		func(s *savedMap) { s.Metric = "unknown" },
+	} {
+		s := new(savedMap)
+		assert.NoError(gob.NewDecoder(bytes.NewReader(valid)).Decode(s))
+		corrupt(s)
+		var cb bytes.Buffer
+		assert.NoError(gob.NewEncoder(&cb).Encode(s))
+		enc := cb.Bytes()
+		_, err := LoadMap(bytes.NewReader(enc))
+		assert.Error(err)
+		// the map is not changed by failed decoding
+		assert.Error(m.UnmarshalBinary(enc))
+		assert.True(mat64.Equal(orig, m.Codebook()))
+	}
+	s := &savedMap{Version: mapFormatVersion, Dim: 2, Codebook: []float64{0, 0, 1, 1}, Size: []int{1, 2},
+		CoordDim: 2, Coords: []float64{0, 0, 1, 0}, Metric: "unknown"}
+	var cb bytes.Buffer
+	assert.NoError(gob.NewEncoder(&cb).Encode(s))
+	_, err = LoadMap(&cb)
+	assert.True(errors.Is(err, ErrUnsupportedMetric))
+}
@@ -0,0 +1,93 @@
+package som
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// PhaseConfig holds configuration of a training phase of TrainConfig.Phases.
+// Radius and learning rate decay within every phase from the phase values in the same way
+// as in training without phases.
+type PhaseConfig struct {
+	// Iters is the number of training iterations of the phase. It must be positive.
+	Iters int
+	// Radius is initial units radius of the phase. If it is zero, TrainConfig radius is used.
+	Radius float64
+	// LRate is initial learning rate of the phase. If it is zero, TrainConfig learning rate is used.
+	LRate float64
+}
+
+// phase validates i-th training phase configuration p.
+// It returns true if validation should stop.
+func (v *validator) phase(i int, p PhaseConfig) bool {
+	if p.Iters <= 0 {
+		if v.param(fmt.Sprintf("TrainConfig.Phases[%d].Iters", i), p.Iters, fmt.Errorf("invalid number of phase %d iterations: %d, must be positive", i, p.Iters)) {
+			return true
+		}
+	}
+	if !(p.Radius >= 0) || math.IsInf(p.Radius, 1) {
+		if v.param(fmt.Sprintf("TrainConfig.Phases[%d].Radius", i), p.Radius, fmt.Errorf("%w: phase %d %f, must be non-negative and finite", ErrInvalidRadius, i, p.Radius)) {
+			return true
+		}
+	}
+	if !(p.LRate >= 0) || math.IsInf(p.LRate, 1) {
+		return v.param(fmt.Sprintf("TrainConfig.Phases[%d].LRate", i), p.LRate, fmt.Errorf("%w: phase %d %f, must be non-negative and finite", ErrInvalidLRate, i, p.LRate))
+	}
+	return false
+}
+
+// trainPhases trains the map in the same way as trainSeqs by every phase of c in turn.
+// The training stops after a phase which was stopped early by OnEpoch or Patience.
+// The returned result sums the iterations and revivals of all phases, joins their warnings,
+// quantization errors and validation errors and holds the initial radius and learning rate of the first phase.
+func (m *Map) trainPhases(ctx context.Context, c *TrainConfig, data mat64.Matrix, starts []int, r *rand.Rand) (*TrainResult, error) {
+	if err := validateTrainConfig(c); err != nil {
+		return nil, err
+	}
+	var res *TrainResult
+	for _, p := range c.Phases {
+		pc := *c
+		pc.Phases = nil
+		if p.Radius > 0 {
+			pc.Radius, pc.AutoRadius = p.Radius, false
+		}
+		if p.LRate > 0 {
+			pc.LRate = p.LRate
+		}
+		pr, err := m.trainSeqs(ctx, &pc, data, starts, p.Iters, r)
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			res = &TrainResult{Algorithm: c.Algorithm, Radius: pr.Radius, LRate: pr.LRate}
+		}
+		res.Iters += pr.Iters
+		res.Revived += pr.Revived
+		res.Warnings = append(res.Warnings, pr.Warnings...)
+		res.QuantErrors = append(res.QuantErrors, pr.QuantErrors...)
+		if pr.Validation != nil {
+			train, errors := pr.Validation.TrainErrors, pr.Validation.Errors
+			if res.Validation != nil {
+				train = append(res.Validation.TrainErrors, train...)
+				errors = append(res.Validation.Errors, errors...)
+			}
+			res.Validation = newValidationReport(train, errors)
+		}
+		if pr.Timings != nil {
+			if res.Timings == nil {
+				res.Timings = new(Timings)
+			}
+			res.Timings.add(pr.Timings)
+		}
+		if pr.Stopped {
+			res.Stopped = true
+			break
+		}
+	}
+	m.lastTrain = res
+	return res, nil
+}
@@ -0,0 +1,81 @@
+package som
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrainPhases(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	for _, alg := range []Method{Seq, Batch} {
+		m, err := NewMap(&MapConfig{
+			Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+			Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+		}, data)
+		assert.NoError(err)
+		train := gsomTrainConfig()
+		train.Algorithm = alg
+		train.TrackErrors = true
+		// the second phase inherits the learning rate
+		train.Phases = []PhaseConfig{{Iters: 200, Radius: 2.0, LRate: 0.5}, {Iters: 100, Radius: 1.0}}
+		var epochs []Epoch
+		train.OnEpoch = func(e Epoch) bool {
+			epochs = append(epochs, e)
+			return true
+		}
+		// phase iterations are used instead of the training method iterations
+		res, err := m.train(train, data, 1, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		assert.Equal(300, res.Iters)
+		assert.Equal(2.0, res.Radius)
+		assert.Equal(0.5, res.LRate)
+		assert.False(res.Stopped)
+		assert.Equal(res, m.lastTrain)
+		// schedules restart at the beginning of every phase
+		epoch := 100
+		if alg == Batch {
+			epoch = 1
+		}
+		if assert.Len(epochs, 300/epoch) {
+			first, second := epochs[0], epochs[200/epoch]
+			radius, _ := Radius(first.Iters-1, 200, train.RDecay, 2.0)
+			assert.Equal(radius, first.Radius, "%s", alg)
+			radius, _ = Radius(second.Iters-1, 100, train.RDecay, 1.0)
+			assert.Equal(radius, second.Radius, "%s", alg)
+			if alg == Seq {
+				lRate, _ := LRate(first.Iters-1, 200, train.LDecay, 0.5)
+				assert.Equal(lRate, first.LRate)
+				lRate, _ = LRate(second.Iters-1, 100, train.LDecay, train.LRate)
+				assert.Equal(lRate, second.LRate)
+			}
+		}
+		assert.Len(res.QuantErrors, 300/epoch)
+
+		// training stops after the phase which stopped early
+		train.OnEpoch = func(e Epoch) bool { return false }
+		res, err = m.train(train, data, 1, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		assert.True(res.Stopped)
+		assert.Equal(epoch, res.Iters)
+	}
+
+	// invalid phases
+	m, err := NewMap(mSom, data)
+	assert.NoError(err)
+	train := gsomTrainConfig()
+	for _, p := range []PhaseConfig{{Iters: 0}, {Iters: 10, Radius: -1.0}, {Iters: 10, LRate: -1.0}} {
+		train.Phases = []PhaseConfig{{Iters: 10}, p}
+		_, err = m.train(train, data, 10, rand.New(rand.NewSource(1)))
+		assert.Error(err, "%v", p)
+	}
+	train.Phases = []PhaseConfig{{Iters: 10, Radius: -1.0}}
+	_, err = m.train(train, data, 10, rand.New(rand.NewSource(1)))
+	assert.True(errors.Is(err, ErrInvalidRadius))
+	assert.EqualError(validateTrainConfig(&TrainConfig{Algorithm: Seq, Radius: 1.0, RDecay: "lin", NeighbFn: Gaussian,
+		LRate: 0.1, LDecay: "lin", Phases: []PhaseConfig{{Iters: -3}}}), "invalid number of phase 0 iterations: -3, must be positive")
+}
@@ -0,0 +1,227 @@
+package som
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// pmmlVersion is the version of PMML documents written by WritePMML
+const pmmlVersion = "4.4"
+
+type pmmlDoc struct {
+	XMLName      xml.Name           `xml:"PMML"`
+	Xmlns        string             `xml:"xmlns,attr"`
+	Version      string             `xml:"version,attr"`
+	Header       pmmlHeader         `xml:"Header"`
+	DataDict     pmmlDataDictionary `xml:"DataDictionary"`
+	ClusterModel pmmlClusteringModel
+}
+
+type pmmlHeader struct {
+	Description string          `xml:"description,attr"`
+	Application pmmlApplication `xml:"Application"`
+}
+
+type pmmlApplication struct {
+	Name string `xml:"name,attr"`
+}
+
+type pmmlDataDictionary struct {
+	NumberOfFields int             `xml:"numberOfFields,attr"`
+	Fields         []pmmlDataField `xml:"DataField"`
+}
+
+type pmmlDataField struct {
+	Name     string `xml:"name,attr"`
+	OpType   string `xml:"optype,attr"`
+	DataType string `xml:"dataType,attr"`
+}
+
+type pmmlClusteringModel struct {
+	XMLName          xml.Name              `xml:"ClusteringModel"`
+	ModelName        string                `xml:"modelName,attr"`
+	FunctionName     string                `xml:"functionName,attr"`
+	ModelClass       string                `xml:"modelClass,attr"`
+	NumberOfClusters int                   `xml:"numberOfClusters,attr"`
+	MiningFields     []pmmlMiningField     `xml:"MiningSchema>MiningField"`
+	OutputFields     []pmmlOutputField     `xml:"Output>OutputField"`
+	DerivedFields    []pmmlDerivedField    `xml:"LocalTransformations>DerivedField,omitempty"`
+	Comparison       pmmlComparison        `xml:"ComparisonMeasure"`
+	ClusteringFields []pmmlClusteringField `xml:"ClusteringField"`
+	Clusters         []pmmlCluster         `xml:"Cluster"`
+}
+
+type pmmlMiningField struct {
+	Name string `xml:"name,attr"`
+}
+
+type pmmlOutputField struct {
+	Name     string `xml:"name,attr"`
+	OpType   string `xml:"optype,attr"`
+	DataType string `xml:"dataType,attr"`
+	Feature  string `xml:"feature,attr"`
+}
+
+// pmmlDerivedField is scaled feature (field - center) / scale
+type pmmlDerivedField struct {
+	Name     string    `xml:"name,attr"`
+	OpType   string    `xml:"optype,attr"`
+	DataType string    `xml:"dataType,attr"`
+	Apply    pmmlApply `xml:"Apply"`
+}
+
+type pmmlApply struct {
+	XMLName  xml.Name `xml:"Apply"`
+	Function string   `xml:"function,attr"`
+	Args     []interface{}
+}
+
+type pmmlFieldRef struct {
+	XMLName xml.Name `xml:"FieldRef"`
+	Field   string   `xml:"field,attr"`
+}
+
+type pmmlConstant struct {
+	XMLName  xml.Name `xml:"Constant"`
+	DataType string   `xml:"dataType,attr"`
+	Value    string   `xml:",chardata"`
+}
+
+type pmmlComparison struct {
+	Kind      string   `xml:"kind,attr"`
+	Euclidean struct{} `xml:"euclidean"`
+}
+
+type pmmlClusteringField struct {
+	Field       string  `xml:"field,attr"`
+	FieldWeight float64 `xml:"fieldWeight,attr"`
+}
+
+type pmmlCluster struct {
+	ID    int       `xml:"id,attr"`
+	Name  string    `xml:"name,attr,omitempty"`
+	Array pmmlArray `xml:"Array"`
+}
+
+type pmmlArray struct {
+	N      int    `xml:"n,attr"`
+	Type   string `xml:"type,attr"`
+	Values string `xml:",chardata"`
+}
+
+// exportFeatures returns codebook feature names used by exported models: the names set by
+// SetFeatureNames or x1, x2 and so on if the map has none. It returns error if the map BMUs
+// can't be expressed by exported models: the map has a codebook metric or UnitLength scaler.
+func (m Map) exportFeatures() ([]string, error) {
+	if m.metric != nil {
+		return nil, fmt.Errorf("%w: only euclidean maps can be exported", ErrUnsupportedMetric)
+	}
+	if m.scaler != nil && m.scaler.Method == UnitLength {
+		return nil, fmt.Errorf("unsupported scaler: %s scaling can't be exported", m.scaler.Method)
+	}
+	if m.features != nil {
+		return m.features, nil
+	}
+	_, dim := m.cbDims()
+	names := make([]string, dim)
+	for j := range names {
+		names[j] = "x" + strconv.Itoa(j+1)
+	}
+	return names, nil
+}
+
+// WritePMML writes the map to w as a PMML 4.4 center-based ClusteringModel which assigns samples
+// to the clusters of the map units in the same way as Predict: every unit is a cluster whose id is
+// the unit index and whose center is the unit codebook vector, and samples are assigned to the cluster
+// of the smallest euclidean distance weighted by feature relevances. Clusters of labeled units are named
+// by the unit labels. Data fields are named by the map feature names or x1, x2 and so on if the map has
+// none. If the map has ZScore or MinMax scaler, the model scales the data fields by local transformations,
+// so it scores samples in original units. The model outputs the BMU id in field unit, the BMU distance
+// in field distance and, if the units are labeled, the BMU label in field label.
+// WritePMML returns ErrUnsupportedMetric if the map has a codebook metric, error if the map has
+// UnitLength scaler or if the write to w fails.
+func (m Map) WritePMML(w io.Writer) error {
+	names, err := m.exportFeatures()
+	if err != nil {
+		return err
+	}
+	units, dim := m.cbDims()
+	model := pmmlClusteringModel{
+		ModelName:        "som",
+		FunctionName:     "clustering",
+		ModelClass:       "centerBased",
+		NumberOfClusters: units,
+		OutputFields: []pmmlOutputField{
+			{Name: "unit", OpType: "categorical", DataType: "string", Feature: "predictedValue"},
+			{Name: "distance", OpType: "continuous", DataType: "double", Feature: "clusterAffinity"},
+		},
+		Comparison: pmmlComparison{Kind: "distance"},
+	}
+	if m.labels != nil {
+		model.OutputFields = append(model.OutputFields,
+			pmmlOutputField{Name: "label", OpType: "categorical", DataType: "string", Feature: "predictedDisplayValue"})
+	}
+	doc := &pmmlDoc{
+		Xmlns:        "http://www.dmg.org/PMML-4_4",
+		Version:      pmmlVersion,
+		Header:       pmmlHeader{Description: "self-organizing map", Application: pmmlApplication{Name: "gosom"}},
+		DataDict:     pmmlDataDictionary{NumberOfFields: dim},
+		ClusterModel: model,
+	}
+	cm := &doc.ClusterModel
+	for j, name := range names {
+		doc.DataDict.Fields = append(doc.DataDict.Fields, pmmlDataField{Name: name, OpType: "continuous", DataType: "double"})
+		cm.MiningFields = append(cm.MiningFields, pmmlMiningField{Name: name})
+		field := name
+		if m.scaler != nil {
+			field = "scaled_" + name
+			cm.DerivedFields = append(cm.DerivedFields, pmmlDerivedField{
+				Name:     field,
+				OpType:   "continuous",
+				DataType: "double",
+				Apply: pmmlApply{Function: "/", Args: []interface{}{
+					pmmlApply{Function: "-", Args: []interface{}{
+						pmmlFieldRef{Field: name},
+						pmmlConstant{DataType: "double", Value: formatFloat(m.scaler.Center[j])},
+					}},
+					pmmlConstant{DataType: "double", Value: formatFloat(m.scaler.Scale[j])},
+				}},
+			})
+		}
+		weight := 1.0
+		if m.relevance != nil {
+			weight = m.relevance[j]
+		}
+		cm.ClusteringFields = append(cm.ClusteringFields, pmmlClusteringField{Field: field, FieldWeight: weight})
+	}
+	cb := m.cb()
+	values := make([]string, dim)
+	for i := 0; i < units; i++ {
+		for j, v := range cb.RawRowView(i) {
+			values[j] = formatFloat(v)
+		}
+		cluster := pmmlCluster{ID: i, Array: pmmlArray{N: dim, Type: "real", Values: strings.Join(values, " ")}}
+		if m.labels != nil {
+			cluster.Name = m.labels[i]
+		}
+		cm.Clusters = append(cm.Clusters, cluster)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// formatFloat formats v in the shortest representation which parses back to v
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,139 @@
+package som
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeExportMap returns trained map of 3 dimensional data with feature weights and ZScore scaler
+func makeExportMap(t *testing.T) (*Map, *mat64.Dense) {
+	data := utils.GenerateClusters(100, 3, 3, 10.0, -10.0, 2.0, 10)
+	c, err := NewMapConfig(data, WithDims(4, 3), WithFeatureWeights(1.0, 0.5, 2.0), WithSeed(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := FitScaler(ZScore, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scaled, _ := s.Transform(data)
+	m, err := NewMap(c, scaled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Train(&TrainConfig{Algorithm: "batch", Radius: 2.0, RDecay: "exp", NeighbFn: Gaussian, LRate: 0.5, LDecay: "exp"}, scaled, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetScaler(s); err != nil {
+		t.Fatal(err)
+	}
+	return m, data
+}
+
+type testPMML struct {
+	Fields []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"DataDictionary>DataField"`
+	Derived []struct {
+		Name  string `xml:"name,attr"`
+		Apply struct {
+			Function string `xml:"function,attr"`
+			Inner    struct {
+				Function string `xml:"function,attr"`
+				Field    struct {
+					Name string `xml:"field,attr"`
+				} `xml:"FieldRef"`
+				Center float64 `xml:"Constant"`
+			} `xml:"Apply"`
+			Scale float64 `xml:"Constant"`
+		} `xml:"Apply"`
+	} `xml:"ClusteringModel>LocalTransformations>DerivedField"`
+	ClusteringFields []struct {
+		Field  string  `xml:"field,attr"`
+		Weight float64 `xml:"fieldWeight,attr"`
+	} `xml:"ClusteringModel>ClusteringField"`
+	Clusters []struct {
+		ID    int    `xml:"id,attr"`
+		Name  string `xml:"name,attr"`
+		Array string `xml:"Array"`
+	} `xml:"ClusteringModel>Cluster"`
+}
+
+func TestWritePMML(t *testing.T) {
+	assert := assert.New(t)
+
+	m, data := makeExportMap(t)
+	var buf bytes.Buffer
+	assert.NoError(m.WritePMML(&buf))
+	assert.True(strings.HasPrefix(buf.String(), xml.Header))
+	assert.Contains(buf.String(), `<PMML xmlns="http://www.dmg.org/PMML-4_4" version="4.4">`)
+	assert.Contains(buf.String(), `<ComparisonMeasure kind="distance">`)
+	var doc testPMML
+	assert.NoError(xml.Unmarshal(buf.Bytes(), &doc))
+	// the model scores samples in the same way as Predict
+	units, dim := m.cbDims()
+	assert.Len(doc.Clusters, units)
+	assert.Len(doc.Derived, dim)
+	assert.Equal("scaled_x1", doc.ClusteringFields[0].Field)
+	assert.Equal(2.0, doc.ClusteringFields[2].Weight)
+	centers := make([][]float64, units)
+	for i, c := range doc.Clusters {
+		assert.Equal(i, c.ID)
+		for _, v := range strings.Fields(c.Array) {
+			f, err := strconv.ParseFloat(v, 64)
+			assert.NoError(err)
+			centers[i] = append(centers[i], f)
+		}
+		assert.Equal(m.CodebookRaw().RawRowView(i), centers[i])
+	}
+	bmus, _, err := m.Predict(data, 0)
+	assert.NoError(err)
+	rows, _ := data.Dims()
+	for r := 0; r < rows; r++ {
+		best, bestDist := -1, math.Inf(1)
+		for i, c := range centers {
+			d := 0.0
+			for j, f := range doc.Derived {
+				assert.Equal(doc.Fields[j].Name, f.Apply.Inner.Field.Name)
+				x := (data.At(r, j) - f.Apply.Inner.Center) / f.Apply.Scale
+				d += doc.ClusteringFields[j].Weight * (x - c[j]) * (x - c[j])
+			}
+			if d < bestDist {
+				best, bestDist = i, d
+			}
+		}
+		assert.Equal(bmus[r], best)
+	}
+
+	// labeled units name their clusters
+	labels := make([]string, units)
+	for i := range labels {
+		labels[i] = "u" + strconv.Itoa(i)
+	}
+	m.labels = labels
+	assert.NoError(m.SetFeatureNames([]string{"a", "b", "c"}))
+	buf.Reset()
+	assert.NoError(m.WritePMML(&buf))
+	assert.Contains(buf.String(), `feature="predictedDisplayValue"`)
+	var labeled testPMML
+	assert.NoError(xml.Unmarshal(buf.Bytes(), &labeled))
+	assert.Equal("u1", labeled.Clusters[1].Name)
+	assert.Equal("c", labeled.Fields[2].Name)
+
+	// unsupported maps
+	unit, err := FitScaler(UnitLength, data)
+	assert.NoError(err)
+	assert.NoError(m.SetScaler(unit))
+	assert.EqualError(m.WritePMML(&buf), "unsupported scaler: unit scaling can't be exported")
+	m.scaler, m.metric = nil, Cosine
+	assert.True(errors.Is(m.WritePMML(&buf), ErrUnsupportedMetric))
+}
@@ -0,0 +1,290 @@
+package som
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Predict finds Best Match Unit for every data row and returns slices of BMU indices
+// and BMU distances. Both slices preserve the order of data rows.
+// The data rows are split evenly between workers goroutines; if workers is not a positive
+// integer GOMAXPROCS goroutines are used. Data which is not *mat64.Dense is read using At.
+// Rows with missing values, which are stored as NaN, are compared on their observed features.
+// If the map has a scaler set by SetScaler, data rows are scaled by it first.
+// It returns error if data is nil, if any data row has no observed values or ErrDimMismatch
+// if data and codebook dimensions differ.
+func (m Map) Predict(data mat64.Matrix, workers int) ([]int, []float64, error) {
+	// data can't be nil
+	if isNilMatrix(data) {
+		return nil, nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, cols := data.Dims()
+	// validate dimensions once so workers don't have to check for errors
+	if _, cbCols := m.cbDims(); cols != cbCols {
+		return nil, nil, ErrDimMismatch
+	}
+	data, err := m.scaled(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	missing, err := missingValues(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > rows {
+		workers = rows
+	}
+	bmus := make([]int, rows)
+	dists := make([]float64, rows)
+	// codebook norms are shared by all workers
+	// matrix multiplication search is used with euclidean float64 codebooks without feature relevances only
+	block := m.tree == nil && m.cb32 == nil && m.relevance == nil && m.metric == nil
+	var norms []float64
+	if block {
+		norms = sqNorms(m.codebook)
+	}
+	// every worker writes into its own contiguous chunk of result slices
+	chunk := (rows + workers - 1) / workers
+	wg := &sync.WaitGroup{}
+	for from := 0; from < rows; from += chunk {
+		to := from + chunk
+		if to > rows {
+			to = rows
+		}
+		wg.Add(1)
+		go func(from, to int) {
+			defer wg.Done()
+			if block {
+				matrixBlockBMUs(data, m.codebook, norms, from, to, bmus[from:to], dists[from:to], nil)
+				if missing {
+					m.missingBMUs(data, from, to, bmus[from:to], dists[from:to])
+				}
+				return
+			}
+			rd := newRowReader(data)
+			for i := from; i < to; i++ {
+				bmus[i], dists[i], _ = m.BMU(rd.row(i))
+			}
+		}(from, to)
+	}
+	wg.Wait()
+
+	return bmus, dists, nil
+}
+
+// RowSource is a source of data rows which are read one row at a time
+type RowSource interface {
+	// Next returns the next data row. It returns io.EOF when there are no more rows.
+	// The returned row may be overwritten by the following call to Next.
+	Next() ([]float64, error)
+}
+
+// predictStreamRows is the number of data rows processed by PredictStream workers at once
+const predictStreamRows = 256
+
+// predictBatch is a batch of data rows processed by PredictStream
+type predictBatch struct {
+	// seq is the batch sequence number
+	seq int
+	// first is the index of the first batch row in the data source
+	first int
+	// rows is the number of data rows stored in the batch
+	rows int
+	// data stores batch data rows
+	data *mat64.Dense
+	// bmus stores BMU indices of batch data rows
+	bmus []int
+	// dists stores BMU distances of batch data rows
+	dists []float64
+}
+
+// PredictStream finds Best Match Unit for every data row read from src and writes the row index,
+// BMU index and BMU distance of every row to w in the requested format. Supported formats are
+// "csv", which writes a header line followed by comma separated values, and "ndjson", which
+// writes one JSON object with row, unit and distance fields per line.
+// Results are written in the order of data rows as described in PredictBatches, so the memory use
+// does not depend on the number of data rows.
+// It returns error if unsupported format is requested or error returned by PredictBatches or w.
+func (m Map) PredictStream(src RowSource, w io.Writer, format string, workers int) error {
+	if format != "csv" && format != "ndjson" {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+	bw := bufio.NewWriter(w)
+	if format == "csv" {
+		if _, err := bw.WriteString("row,unit,distance\n"); err != nil {
+			return err
+		}
+	}
+	var line []byte
+	return m.PredictBatches(src, workers, func(first int, bmus []int, dists []float64) error {
+		for j := range bmus {
+			line = appendPrediction(line[:0], format, first+j, bmus[j], dists[j])
+			if _, err := bw.Write(line); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	})
+}
+
+// PredictBatches finds Best Match Unit for every data row read from src and calls fn with BMU indices
+// and BMU distances of every batch of consecutive data rows; first is the index of the first row
+// of the batch. fn is called in the order of data rows by a single goroutine and the slices are
+// reused after it returns. The rows are processed in batches by workers goroutines; if workers is
+// not a positive integer GOMAXPROCS goroutines are used. At most 2 x workers batches are held
+// in memory: reading from src blocks until fn returns for the processed batches.
+// Rows with missing values, which are stored as NaN, are compared on their observed features.
+// If the map has a scaler set by SetScaler, data rows are scaled by it first.
+// It returns error if any data row has no observed values, ErrDimMismatch if any data row dimension
+// differs from the codebook dimension or the first error returned by src or fn; reading from src
+// stops when fn returns error.
+func (m Map) PredictBatches(src RowSource, workers int, fn func(first int, bmus []int, dists []float64) error) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	_, cols := m.cbDims()
+	// matrix multiplication search is used with euclidean float64 codebooks without feature relevances only
+	block := m.tree == nil && m.cb32 == nil && m.relevance == nil && m.metric == nil
+	var norms []float64
+	if block {
+		norms = sqNorms(m.codebook)
+	}
+	// free batches limit the number of batches in memory
+	inflight := 2 * workers
+	free := make(chan *predictBatch, inflight)
+	for i := 0; i < inflight; i++ {
+		free <- &predictBatch{
+			data:  mat64.NewDense(predictStreamRows, cols, nil),
+			bmus:  make([]int, predictStreamRows),
+			dists: make([]float64, predictStreamRows),
+		}
+	}
+	jobs := make(chan *predictBatch, inflight)
+	done := make(chan *predictBatch, inflight)
+	// quit stops reading data rows when the results can't be written
+	quit := make(chan struct{})
+	var readErr error
+	go func() {
+		defer close(jobs)
+		for seq, first := 0, 0; ; seq++ {
+			// stop reading as soon as the results can't be written
+			select {
+			case <-quit:
+				return
+			default:
+			}
+			var b *predictBatch
+			select {
+			case b = <-free:
+			case <-quit:
+				return
+			}
+			b.seq, b.first, b.rows = seq, first, 0
+			for b.rows < predictStreamRows {
+				row, err := src.Next()
+				if err == io.EOF {
+					break
+				}
+				if err == nil && len(row) != cols {
+					err = ErrDimMismatch
+				}
+				if err == nil && unobserved(row) {
+					err = fmt.Errorf("invalid data row %d: no observed values", first+b.rows)
+				}
+				if err != nil {
+					readErr = err
+					return
+				}
+				if m.scaler != nil {
+					m.scaler.transform(b.data.RawRowView(b.rows), row)
+				} else {
+					b.data.SetRow(b.rows, row)
+				}
+				b.rows++
+			}
+			if b.rows == 0 {
+				return
+			}
+			first += b.rows
+			jobs <- b
+			if b.rows < predictStreamRows {
+				return
+			}
+		}
+	}()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				if block {
+					blockBMUs(b.data, m.codebook, norms, 0, b.rows, b.bmus, b.dists, nil)
+					m.missingBMUs(b.data, 0, b.rows, b.bmus, b.dists)
+				} else {
+					for j := 0; j < b.rows; j++ {
+						b.bmus[j], b.dists[j], _ = m.BMU(b.data.RawRowView(j))
+					}
+				}
+				done <- b
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	// pass the results to fn in the order of data rows
+	var writeErr error
+	stopped := false
+	pending := make(map[int]*predictBatch)
+	next := 0
+	for b := range done {
+		pending[b.seq] = b
+		for b, ok := pending[next]; ok; b, ok = pending[next] {
+			delete(pending, next)
+			next++
+			if writeErr == nil {
+				writeErr = fn(b.first, b.bmus[:b.rows], b.dists[:b.rows])
+			}
+			if writeErr != nil && !stopped {
+				close(quit)
+				stopped = true
+			}
+			free <- b
+		}
+	}
+	if readErr != nil {
+		return readErr
+	}
+
+	return writeErr
+}
+
+// appendPrediction appends a single PredictStream result line in the requested format to dst
+func appendPrediction(dst []byte, format string, row, unit int, dist float64) []byte {
+	if format == "csv" {
+		dst = strconv.AppendInt(dst, int64(row), 10)
+		dst = append(dst, ',')
+		dst = strconv.AppendInt(dst, int64(unit), 10)
+		dst = append(dst, ',')
+		dst = strconv.AppendFloat(dst, dist, 'g', -1, 64)
+		return append(dst, '\n')
+	}
+	dst = append(dst, `{"row":`...)
+	dst = strconv.AppendInt(dst, int64(row), 10)
+	dst = append(dst, `,"unit":`...)
+	dst = strconv.AppendInt(dst, int64(unit), 10)
+	dst = append(dst, `,"distance":`...)
+	dst = strconv.AppendFloat(dst, dist, 'g', -1, 64)
+	return append(dst, "}\n"...)
+}
@@ -0,0 +1,291 @@
+package som
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredict(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(503, 4, 5, 10.0, -10.0, 2.0, 10)
+	m, err := NewMap(mSom, data)
+	assert.NotNil(m)
+	assert.NoError(err)
+	// nil data
+	errString := "invalid data supplied: %v"
+	bmus, dists, err := m.Predict(nil, 1)
+	assert.Nil(bmus)
+	assert.Nil(dists)
+	assert.EqualError(err, fmt.Sprintf(errString, nil))
+	// dimension mismatch
+	bmus, dists, err = m.Predict(mat64.NewDense(2, 2, nil), 1)
+	assert.Nil(bmus)
+	assert.Nil(dists)
+	assert.Equal(ErrDimMismatch, err)
+	// serial results must match BMU
+	bmus, dists, err = m.Predict(data, 1)
+	assert.NoError(err)
+	rows, _ := data.Dims()
+	assert.Len(bmus, rows)
+	assert.Len(dists, rows)
+	for i := 0; i < rows; i++ {
+		bmu, dist, err := m.BMU(data.RawRowView(i))
+		assert.NoError(err)
+		assert.Equal(bmu, bmus[i])
+		assert.Equal(dist, dists[i])
+	}
+	// parallel results must be identical to serial results
+	for _, workers := range []int{-1, 0, 2, 7, 1000} {
+		pBmus, pDists, err := m.Predict(data, workers)
+		assert.NoError(err)
+		assert.Equal(bmus, pBmus)
+		assert.Equal(dists, pDists)
+	}
+}
+
+func BenchmarkPredict(b *testing.B) {
+	data := utils.GenerateClusters(1000000, 20, 10, 10.0, -10.0, 2.0, 10)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{20, 20},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      20,
+			InitFunc: RandInit,
+		},
+	}
+	m, err := NewMap(mCfg, data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := m.Predict(data, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// matrixSource is RowSource which reads rows of data matrix
+type matrixSource struct {
+	data *mat64.Dense
+	row  int
+}
+
+func (s *matrixSource) Next() ([]float64, error) {
+	rows, _ := s.data.Dims()
+	if s.row == rows {
+		return nil, io.EOF
+	}
+	s.row++
+	return s.data.RawRowView(s.row - 1), nil
+}
+
+// synthSource is RowSource which generates rows into a single reused buffer and records the
+// maximum number of rows read ahead of the results written to w
+type synthSource struct {
+	rows    int
+	read    int
+	row     []float64
+	w       *lineCounter
+	maxLead int
+	err     error
+}
+
+func (s *synthSource) Next() ([]float64, error) {
+	if s.read == s.rows {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	if lead := s.read - int(atomic.LoadInt64(&s.w.lines)); lead > s.maxLead {
+		s.maxLead = lead
+	}
+	for i := range s.row {
+		s.row[i] = float64((s.read*7+i*13)%23) - 11.0
+	}
+	s.read++
+	return s.row, nil
+}
+
+// lineCounter is io.Writer which counts written lines and fails after limit lines if limit is positive
+type lineCounter struct {
+	lines int64
+	limit int64
+}
+
+func (w *lineCounter) Write(p []byte) (int, error) {
+	lines := atomic.AddInt64(&w.lines, int64(bytes.Count(p, []byte("\n"))))
+	if w.limit > 0 && lines > w.limit {
+		return 0, errors.New("write failed")
+	}
+	return len(p), nil
+}
+
+func TestPredictMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(1003, 4, 5, 10.0, -10.0, 2.0, 10)
+	m, err := NewMap(mSom, data)
+	assert.NoError(err)
+	assert.NoError(m.Train(&TrainConfig{Algorithm: Batch, Radius: 2.0, RDecay: "exp", NeighbFn: Gaussian, LRate: 0.5, LDecay: "exp"}, data, 10))
+	// predictions work on maps restored by LoadMap
+	var buf bytes.Buffer
+	assert.NoError(m.Save(&buf))
+	loaded, err := LoadMap(&buf)
+	assert.NoError(err)
+	rows, cols := data.Dims()
+	missing := mat64.DenseCopyOf(data)
+	for i := 0; i < rows; i += 3 {
+		missing.Set(i, i%cols, math.NaN())
+	}
+	for _, workers := range []int{1, 3} {
+		bmus, dists, err := loaded.Predict(missing, workers)
+		assert.NoError(err)
+		for i := 0; i < rows; i++ {
+			bmu, dist, err := loaded.BMU(missing.RawRowView(i))
+			assert.NoError(err)
+			assert.Equal(bmu, bmus[i])
+			assert.InDelta(dist, dists[i], 1e-9)
+		}
+	}
+	bmus, dists, err := loaded.Predict(missing, 2)
+	assert.NoError(err)
+	out := new(bytes.Buffer)
+	assert.NoError(loaded.PredictStream(&matrixSource{data: missing}, out, "csv", 2))
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Len(lines, rows+1)
+	for i, line := range lines[1:] {
+		var row, unit int
+		var dist float64
+		_, err := fmt.Sscanf(line, "%d,%d,%g", &row, &unit, &dist)
+		assert.NoError(err)
+		assert.Equal(bmus[i], unit)
+		assert.InDelta(dists[i], dist, 1e-9)
+	}
+	// rows without observed values
+	for j := 0; j < cols; j++ {
+		missing.Set(5, j, math.NaN())
+	}
+	_, _, err = loaded.Predict(missing, 2)
+	assert.EqualError(err, "invalid data row 5: no observed values")
+	err = loaded.PredictStream(&matrixSource{data: missing}, out, "csv", 2)
+	assert.EqualError(err, "invalid data row 5: no observed values")
+}
+
+func TestPredictStream(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(1003, 4, 5, 10.0, -10.0, 2.0, 10)
+	m, err := NewMap(mSom, data)
+	assert.NoError(err)
+	bmus, dists, err := m.Predict(data, 2)
+	assert.NoError(err)
+	// csv output
+	for _, workers := range []int{0, 1, 3} {
+		buf := new(bytes.Buffer)
+		assert.NoError(m.PredictStream(&matrixSource{data: data}, buf, "csv", workers))
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		assert.Len(lines, len(bmus)+1)
+		assert.Equal("row,unit,distance", lines[0])
+		for i, line := range lines[1:] {
+			assert.Equal(fmt.Sprintf("%d,%d,%v", i, bmus[i], dists[i]), line)
+		}
+	}
+	// ndjson output
+	buf := new(bytes.Buffer)
+	assert.NoError(m.PredictStream(&matrixSource{data: data}, buf, "ndjson", 2))
+	dec := json.NewDecoder(buf)
+	for i := range bmus {
+		var res struct {
+			Row      int
+			Unit     int
+			Distance float64
+		}
+		assert.NoError(dec.Decode(&res))
+		assert.Equal(i, res.Row)
+		assert.Equal(bmus[i], res.Unit)
+		assert.Equal(dists[i], res.Distance)
+	}
+	assert.False(dec.More())
+	// empty source
+	buf.Reset()
+	assert.NoError(m.PredictStream(&matrixSource{data: data, row: 1003}, buf, "ndjson", 2))
+	assert.Equal(0, buf.Len())
+	// unsupported format
+	err = m.PredictStream(&matrixSource{data: data}, buf, "xml", 2)
+	assert.EqualError(err, "unsupported format: xml")
+	// data dimension mismatch
+	err = m.PredictStream(&matrixSource{data: mat64.NewDense(3, 2, nil)}, buf, "csv", 2)
+	assert.Equal(ErrDimMismatch, err)
+	// source error
+	w := &lineCounter{}
+	src := &synthSource{rows: 1000, row: make([]float64, 4), w: w, err: errors.New("read failed")}
+	err = m.PredictStream(src, w, "csv", 2)
+	assert.EqualError(err, "read failed")
+}
+
+func TestPredictBatches(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(1003, 4, 5, 10.0, -10.0, 2.0, 10)
+	m, err := NewMap(mSom, data)
+	assert.NoError(err)
+	bmus, dists, err := m.Predict(data, 2)
+	assert.NoError(err)
+	var gotBmus []int
+	var gotDists []float64
+	err = m.PredictBatches(&matrixSource{data: data}, 3, func(first int, b []int, d []float64) error {
+		// batches are passed in the order of data rows
+		assert.Equal(len(gotBmus), first)
+		assert.Len(d, len(b))
+		gotBmus, gotDists = append(gotBmus, b...), append(gotDists, d...)
+		return nil
+	})
+	assert.NoError(err)
+	assert.Equal(bmus, gotBmus)
+	assert.Equal(dists, gotDists)
+	// fn error stops the prediction
+	calls := 0
+	err = m.PredictBatches(&matrixSource{data: data}, 2, func(int, []int, []float64) error {
+		calls++
+		return errors.New("write failed")
+	})
+	assert.EqualError(err, "write failed")
+	assert.Equal(1, calls)
+}
+
+func TestPredictStreamMemory(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(100, 4, 5, 10.0, -10.0, 2.0, 10)
+	m, err := NewMap(mSom, data)
+	assert.NoError(err)
+	workers := 3
+	// the source reads at most the rows of all in-flight batches ahead of the written results
+	w := &lineCounter{}
+	src := &synthSource{rows: 200000, row: make([]float64, 4), w: w}
+	assert.NoError(m.PredictStream(src, w, "ndjson", workers))
+	assert.Equal(int64(src.rows), w.lines)
+	assert.True(src.maxLead <= 2*workers*predictStreamRows)
+	// failed write stops reading the source
+	w = &lineCounter{limit: 1000}
+	src = &synthSource{rows: 200000, row: make([]float64, 4), w: w}
+	assert.EqualError(m.PredictStream(src, w, "csv", workers), "write failed")
+	assert.True(src.read <= 1000+2*workers*predictStreamRows, "read %d rows", src.read)
+}
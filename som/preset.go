@@ -0,0 +1,113 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/milosgajdos83/gosom/pkg/utils"
+)
+
+// preset is a named configuration preset
+type preset struct {
+	// configs creates map and training configuration for data set of dataRows samples
+	// of dimension dataDim
+	configs func(dataRows, dataDim int) (*MapConfig, *TrainConfig)
+	// iters is the number of training iterations
+	iters int
+}
+
+// presets maps configuration presets
+var presets = map[string]preset{
+	"quick":   {configs: quickPreset, iters: 10},
+	"default": {configs: defaultPreset, iters: 100},
+	"quality": {configs: qualityPreset, iters: 500},
+	"text":    {configs: textPreset, iters: 100},
+}
+
+// quickPreset is a small map with sqrt(dataRows) units trained by 10 batch iterations with
+// bubble neighbourhood. It is meant for smoke tests rather than for analysis.
+func quickPreset(dataRows, dataDim int) (*MapConfig, *TrainConfig) {
+	mc := DefaultMapConfig(dataRows, dataDim)
+	mc.Grid.Size = gridDims(int(math.Ceil(math.Sqrt(float64(dataRows)))), dataDim)
+	tc := DefaultTrainConfig(utils.IntProduct(mc.Grid.Size))
+	tc.NeighbFn = Bubble
+	return mc, tc
+}
+
+// defaultPreset is DefaultMapConfig trained by 100 iterations of DefaultTrainConfig.
+// It uses a single batch training phase.
+func defaultPreset(dataRows, dataDim int) (*MapConfig, *TrainConfig) {
+	mc := DefaultMapConfig(dataRows, dataDim)
+	return mc, DefaultTrainConfig(utils.IntProduct(mc.Grid.Size))
+}
+
+// qualityPreset is a map with twice as many units as the default map, initialized by LinInit
+// unless data is 1D, which is trained by 500 batch iterations with linear radius decay.
+func qualityPreset(dataRows, dataDim int) (*MapConfig, *TrainConfig) {
+	mc := DefaultMapConfig(dataRows, dataDim)
+	mc.Grid.Size = gridDims(int(math.Ceil(10*math.Sqrt(float64(dataRows)))), dataDim)
+	// principal components need at least 2 samples of at least 2 dimensions
+	if dataRows > 1 && dataDim > 1 {
+		mc.Cb.InitFunc = LinInit
+	}
+	tc := DefaultTrainConfig(utils.IntProduct(mc.Grid.Size))
+	tc.RDecay = "lin"
+	return mc, tc
+}
+
+// textPreset is the default map initialized by SampleInit, so the codebook starts from actual
+// documents rather than random points of the mostly empty space of sparse text features.
+// Document vectors should be normalized to unit length: euclidean distance then ranks
+// map units the same way as cosine distance.
+func textPreset(dataRows, dataDim int) (*MapConfig, *TrainConfig) {
+	mc := DefaultMapConfig(dataRows, dataDim)
+	mc.Cb.InitFunc = SampleInit
+	return mc, DefaultTrainConfig(utils.IntProduct(mc.Grid.Size))
+}
+
+// Preset returns map and training configuration preset name for data set of dataRows samples
+// of dimension dataDim. The following presets are available:
+//
+//	quick:   small map which trains fast, meant for smoke tests
+//	default: DefaultMapConfig and DefaultTrainConfig
+//	quality: larger map initialized by LinInit which trains longer
+//	text:    default map initialized by SampleInit for unit length document vectors
+//
+// The returned configurations are validated and can be modified before they are used.
+// The number of training iterations of the preset is returned by PresetIters.
+// It returns error if the preset does not exist or data set is empty.
+func Preset(name string, dataRows, dataDim int) (*MapConfig, *TrainConfig, error) {
+	p, ok := presets[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported preset: %s", name)
+	}
+	if dataRows <= 0 || dataDim <= 0 {
+		return nil, nil, fmt.Errorf("invalid data set: %d samples of dimension %d", dataRows, dataDim)
+	}
+	mc, tc := p.configs(dataRows, dataDim)
+	if _, err := ValidateAll(mc, tc, dataRows, dataDim, p.iters); err != nil {
+		return nil, nil, fmt.Errorf("invalid preset %s: %w", name, err)
+	}
+	return mc, tc, nil
+}
+
+// PresetIters returns the number of training iterations of preset name.
+// It returns error if the preset does not exist.
+func PresetIters(name string) (int, error) {
+	p, ok := presets[name]
+	if !ok {
+		return 0, fmt.Errorf("unsupported preset: %s", name)
+	}
+	return p.iters, nil
+}
+
+// Presets returns sorted names of available configuration presets
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,68 @@
+package som
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreset(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"default", "quality", "quick", "text"}, Presets())
+
+	data2D, _ := matrix.MakeRandom(12, 3, -1.0, 1.0)
+	data1D, _ := matrix.MakeRandom(12, 1, -1.0, 1.0)
+	// train every preset end to end
+	for _, name := range Presets() {
+		for _, data := range []*mat64.Dense{data2D, data1D} {
+			rows, cols := data.Dims()
+			mc, tc, err := Preset(name, rows, cols)
+			assert.NoError(err, name)
+			iters, err := PresetIters(name)
+			assert.NoError(err)
+			assert.True(iters > 0)
+			m, err := NewMap(mc, data)
+			assert.NoError(err, name)
+			assert.NoError(m.Train(tc, data, iters), name)
+			qe, err := m.QuantError(data)
+			assert.NoError(err)
+			assert.False(math.IsNaN(qe))
+		}
+	}
+
+	// presets are ordinary configurations which can be modified
+	mc, tc, err := Preset("default", 100, 4)
+	assert.NoError(err)
+	assert.Equal([]int{8, 7}, mc.Grid.Size)
+	tc.Algorithm = "seq"
+	mc.Grid.UShape = "rectangle"
+	_, err = ValidateAll(mc, tc, 100, 4, 500)
+	assert.NoError(err)
+	// modifying a preset does not modify the next one
+	mc, tc, err = Preset("default", 100, 4)
+	assert.NoError(err)
+	assert.Equal(Hexagon, mc.Grid.UShape)
+	assert.Equal(Batch, tc.Algorithm)
+
+	// preset map sizes
+	mc, _, err = Preset("quick", 100, 4)
+	assert.NoError(err)
+	assert.Equal([]int{4, 3}, mc.Grid.Size)
+	mc, _, err = Preset("quality", 100, 4)
+	assert.NoError(err)
+	assert.Equal([]int{10, 10}, mc.Grid.Size)
+
+	// invalid presets
+	_, _, err = Preset("foo", 10, 2)
+	assert.EqualError(err, "unsupported preset: foo")
+	_, err = PresetIters("foo")
+	assert.EqualError(err, "unsupported preset: foo")
+	_, _, err = Preset("quick", 0, 2)
+	assert.Error(err)
+	_, _, err = Preset("quick", 10, 0)
+	assert.Error(err)
+}
@@ -0,0 +1,105 @@
+package som
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timings holds wall time which profiled training spent in its phases. Training is profiled if
+// TrainConfig.Profile is set. Seq, batch, neural gas, temporal and gsom training measure all phases;
+// the time of other training is reported as Other.
+type Timings struct {
+	// BMU is the time spent searching BMUs of training samples; in batch training it includes
+	// summing samples of every unit
+	BMU time.Duration
+	// Update is the time spent moving codebook vectors towards samples
+	Update time.Duration
+	// Decay is the time spent computing decayed radius and learning rate, including rebuilding
+	// neighbourhood tables of batch training when the radius changes
+	Decay time.Duration
+	// Other is the rest of the training time, such as checkpoints, dead unit revivals or KD-tree builds
+	Other time.Duration
+	// Total is the training time
+	Total time.Duration
+}
+
+// String returns the phase timings and their shares of the total training time
+func (t Timings) String() string {
+	share := func(d time.Duration) float64 {
+		if t.Total <= 0 {
+			return 0
+		}
+		return 100 * float64(d) / float64(t.Total)
+	}
+	return fmt.Sprintf("bmu %s (%.1f%%), update %s (%.1f%%), decay %s (%.1f%%), other %s (%.1f%%), total %s",
+		t.BMU, share(t.BMU), t.Update, share(t.Update), t.Decay, share(t.Decay), t.Other, share(t.Other), t.Total)
+}
+
+// add adds timings o to t
+func (t *Timings) add(o *Timings) {
+	t.BMU += o.BMU
+	t.Update += o.Update
+	t.Decay += o.Decay
+	t.Other += o.Other
+	t.Total += o.Total
+}
+
+// profiled training phases
+const (
+	profileBMU = iota
+	profileUpdate
+	profileDecay
+	profilePhases
+)
+
+// trainProfile measures time of training phases. A phase is timed from the previous start
+// or lap until its lap. All its methods do nothing if it is nil.
+type trainProfile struct {
+	// phases holds time spent in every profiled phase
+	phases [profilePhases]time.Duration
+	// mark is the time of the previous start or lap
+	mark time.Time
+}
+
+// newTrainProfile returns training profile or nil if training configuration tc is not profiled
+func newTrainProfile(tc *TrainConfig) *trainProfile {
+	if !tc.Profile {
+		return nil
+	}
+	return new(trainProfile)
+}
+
+// start starts timing of the next phase
+func (p *trainProfile) start() {
+	if p == nil {
+		return
+	}
+	p.mark = time.Now()
+}
+
+// lap adds the time since the previous start or lap to phase and starts timing of the next phase
+func (p *trainProfile) lap(phase int) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.phases[phase] += now.Sub(p.mark)
+	p.mark = now
+}
+
+// timings returns timings of training which took total time or nil if p is nil
+func (p *trainProfile) timings(total time.Duration) *Timings {
+	if p == nil {
+		return nil
+	}
+	t := &Timings{
+		BMU:    p.phases[profileBMU],
+		Update: p.phases[profileUpdate],
+		Decay:  p.phases[profileDecay],
+		Total:  total,
+	}
+	if t.Other = total - t.BMU - t.Update - t.Decay; t.Other < 0 {
+		t.Other = 0
+	}
+	return t
+}
@@ -0,0 +1,116 @@
+package som
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/milosgajdos83/gosom/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrainProfile(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(200, 1)
+	for _, algorithm := range []Method{Seq, Batch, NeuralGas, Temporal, GSOM} {
+		m, err := NewMap(makePrecisionMapCfg([]int{4, 4}, 2, ""), data)
+		assert.NoError(err)
+		tc := &TrainConfig{
+			Algorithm: algorithm,
+			Radius:    2.0,
+			RDecay:    ExpDecay,
+			NeighbFn:  Gaussian,
+			LRate:     0.5,
+			LDecay:    ExpDecay,
+		}
+		switch algorithm {
+		case Temporal:
+			tc.Leak = 0.5
+		case GSOM:
+			tc.GrowThreshold = 10.0
+		}
+		res, err := m.TrainWithResult(tc, data, 400)
+		assert.NoError(err, "%s", algorithm)
+		assert.Nil(res.Timings)
+		tc.Profile = true
+		res, err = m.TrainWithResult(tc, data, 400)
+		assert.NoError(err, "%s", algorithm)
+		timings := res.Timings
+		if assert.NotNil(timings, "%s", algorithm) {
+			assert.True(timings.BMU > 0, "%s", algorithm)
+			assert.True(timings.Update > 0, "%s", algorithm)
+			assert.True(timings.Other >= 0, "%s", algorithm)
+			assert.Equal(timings.Total, timings.BMU+timings.Update+timings.Decay+timings.Other, "%s", algorithm)
+		}
+	}
+
+	// timings of training phases are summed
+	m, err := NewMap(makePrecisionMapCfg([]int{4, 4}, 2, ""), data)
+	assert.NoError(err)
+	tc := DefaultTrainConfig(3)
+	tc.Profile = true
+	tc.Phases = []PhaseConfig{{Iters: 200, Radius: 2.0}, {Iters: 200, Radius: 0.5}}
+	res, err := m.TrainWithResult(tc, data, 0)
+	assert.NoError(err)
+	if assert.NotNil(res.Timings) {
+		assert.True(res.Timings.BMU > 0)
+		assert.True(res.Timings.Total >= res.Timings.BMU+res.Timings.Update+res.Timings.Decay)
+	}
+}
+
+func TestTimingsString(t *testing.T) {
+	assert := assert.New(t)
+
+	timings := Timings{
+		BMU:    600 * time.Millisecond,
+		Update: 300 * time.Millisecond,
+		Decay:  50 * time.Millisecond,
+		Other:  50 * time.Millisecond,
+		Total:  time.Second,
+	}
+	assert.Equal("bmu 600ms (60.0%), update 300ms (30.0%), decay 50ms (5.0%), other 50ms (5.0%), total 1s", timings.String())
+	assert.Equal("bmu 0s (0.0%), update 0s (0.0%), decay 0s (0.0%), other 0s (0.0%), total 0s", Timings{}.String())
+}
+
+func BenchmarkTrain(b *testing.B) {
+	for _, size := range []int{10, 20, 40} {
+		for _, dim := range []int{4, 16, 64} {
+			data, err := matrix.MakeRandom(2000, dim, -10.0, 10.0)
+			if err != nil {
+				b.Fatal(err)
+			}
+			mCfg := &MapConfig{
+				Grid: &GridConfig{Size: []int{size, size}, Type: "planar", UShape: "hexagon"},
+				Cb:   &CbConfig{Dim: dim, InitFunc: RandInit},
+			}
+			for _, algorithm := range []Method{Seq, Batch} {
+				tc := &TrainConfig{
+					Algorithm: algorithm,
+					Radius:    float64(size) / 2,
+					RDecay:    ExpDecay,
+					NeighbFn:  Gaussian,
+					LRate:     0.5,
+					LDecay:    ExpDecay,
+					Workers:   1,
+				}
+				// seq training runs an epoch, batch training a single iteration over all samples
+				iters := 2000
+				if algorithm == Batch {
+					iters = 1
+				}
+				m, err := NewMap(mCfg, data)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.Run(fmt.Sprintf("%s/%dx%d/dim%d", algorithm, size, size, dim), func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						if err := m.Train(tc, data, iters); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package som
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// DefaultProjectK is the default number of best matching units used by Project
+const DefaultProjectK = 3
+
+// Project returns continuous map grid coordinates of vector x computed as a weighted average
+// of grid coordinates of DefaultProjectK best matching units. See ProjectK for details.
+func (m Map) Project(x []float64) ([]float64, error) {
+	return m.ProjectK(x, DefaultProjectK)
+}
+
+// ProjectK returns continuous map grid coordinates of vector x computed as an average of grid
+// coordinates of k best matching units weighted by their inverse distances to x.
+// If x matches any of the units exactly, grid coordinates of the matched unit are returned.
+// If the map has a scaler, x is scaled by it first.
+// It returns error if k is not a positive integer or ErrDimMismatch if the dimension of x
+// is different from the map codebook dimension.
+func (m Map) ProjectK(x []float64, k int) ([]float64, error) {
+	x, err := m.ScaleSample(x)
+	if err != nil {
+		return nil, err
+	}
+	return m.projectK(x, k)
+}
+
+// projectK works like ProjectK, but expects x scaled by the map scaler
+func (m Map) projectK(x []float64, k int) ([]float64, error) {
+	units, dists, err := m.KBMU(x, k)
+	if err != nil {
+		return nil, err
+	}
+	_, dim := m.grid.coords.Dims()
+	proj := make([]float64, dim)
+	weights := 0.0
+	for i, u := range units {
+		coords := m.grid.coords.RawRowView(u)
+		if dists[i] == 0.0 {
+			copy(proj, coords)
+			return proj, nil
+		}
+		w := 1.0 / dists[i]
+		for j := range proj {
+			proj[j] += w * coords[j]
+		}
+		weights += w
+	}
+	for j := range proj {
+		proj[j] /= weights
+	}
+
+	return proj, nil
+}
+
+// ProjectAll projects every data row onto the map grid using ProjectK and returns a matrix
+// which contains continuous grid coordinates of data rows. If jitter is a positive number,
+// the data rows are placed at their BMU grid coordinates displaced by a uniformly distributed
+// random offset in [-jitter, jitter] interval in each dimension instead. The offset is seeded
+// by the row index, so the results are deterministic. If the map has a scaler, data rows are
+// scaled by it first.
+// It returns error if data is nil, k is not a positive integer, if any data row has no observed
+// values, if the scaler fails or ErrDimMismatch if data and codebook dimensions differ.
+func (m Map) ProjectAll(data *mat64.Dense, k int, jitter float64) (*mat64.Dense, error) {
+	// data can't be nil
+	if data == nil {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	if k <= 0 {
+		return nil, fmt.Errorf("invalid number of best match units requested: %d", k)
+	}
+	rows, cols := data.Dims()
+	if _, cbCols := m.cbDims(); cols != cbCols {
+		return nil, ErrDimMismatch
+	}
+	// every data row must have an observed value
+	if _, err := missingValues(data); err != nil {
+		return nil, err
+	}
+	scaled, err := m.scaled(data)
+	if err != nil {
+		return nil, err
+	}
+	rd := newRowReader(scaled)
+	_, dim := m.grid.coords.Dims()
+	proj := mat64.NewDense(rows, dim, nil)
+	for i := 0; i < rows; i++ {
+		if jitter > 0.0 {
+			bmu, _, _ := m.BMU(rd.row(i))
+			r := rand.New(rand.NewSource(int64(i)))
+			row := proj.RawRowView(i)
+			for j, c := range m.grid.coords.RawRowView(bmu) {
+				row[j] = c + (2*r.Float64()-1)*jitter
+			}
+			continue
+		}
+		// no need to check for error: parameters were validated
+		p, _ := m.projectK(rd.row(i), k)
+		proj.SetRow(i, p)
+	}
+
+	return proj, nil
+}
+
+// Transform converts data into map space for downstream models: every data row is replaced by the grid
+// coordinates of its BMU, so the returned matrix has as many columns as the grid has dimensions, two for
+// planar grids. If k is greater than 1, the coordinates are interpolated among k best matching units
+// in the same way as by ProjectK, which gives continuous coordinates of samples which fall between units.
+// Like Predict, Transform scales data rows by the map scaler if the map has one and reads rows of any
+// matrix. It returns error if data is nil, if k is not a positive integer, if any data row has no
+// observed values, if the scaler fails or ErrDimMismatch if data and codebook dimensions differ.
+func (m Map) Transform(data mat64.Matrix, k int) (*mat64.Dense, error) {
+	// data can't be nil
+	if isNilMatrix(data) {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	if k <= 0 {
+		return nil, fmt.Errorf("invalid number of best match units requested: %d", k)
+	}
+	rows, cols := data.Dims()
+	if _, cbCols := m.cbDims(); cols != cbCols {
+		return nil, ErrDimMismatch
+	}
+	// every data row must have an observed value
+	if _, err := missingValues(data); err != nil {
+		return nil, err
+	}
+	_, dim := m.grid.coords.Dims()
+	out := mat64.NewDense(rows, dim, nil)
+	if k == 1 {
+		bmus, _, err := m.Predict(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		for i, bmu := range bmus {
+			out.SetRow(i, m.grid.coords.RawRowView(bmu))
+		}
+		return out, nil
+	}
+	data, err := m.scaled(data)
+	if err != nil {
+		return nil, err
+	}
+	rd := newRowReader(data)
+	for i := 0; i < rows; i++ {
+		p, err := m.projectK(rd.row(i), k)
+		if err != nil {
+			return nil, err
+		}
+		out.SetRow(i, p)
+	}
+	return out, nil
+}
@@ -0,0 +1,182 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// inTriangle returns true if point p lies inside the triangle a, b, c
+func inTriangle(p, a, b, c []float64) bool {
+	cross := func(o, u, v []float64) float64 {
+		return (u[0]-o[0])*(v[1]-o[1]) - (u[1]-o[1])*(v[0]-o[0])
+	}
+	eps := 1e-9
+	d1, d2, d3 := cross(a, b, p), cross(b, c, p), cross(c, a, p)
+	hasNeg := d1 < -eps || d2 < -eps || d3 < -eps
+	hasPos := d1 > eps || d2 > eps || d3 > eps
+	return !(hasNeg && hasPos)
+}
+
+func TestProject(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(200, 4, 4, 10.0, -10.0, 2.0, 10)
+	m, err := NewMap(mSom, data)
+	assert.NoError(err)
+	err = m.Train(tSom, data, 500)
+	assert.NoError(err)
+	// invalid parameters
+	_, err = m.ProjectK(data.RawRowView(0), 0)
+	assert.Error(err)
+	_, err = m.Project([]float64{1.0})
+	assert.Equal(ErrDimMismatch, err)
+	// projection lies inside the convex hull of the k units' coordinates
+	rows, _ := data.Dims()
+	for i := 0; i < rows; i++ {
+		x := data.RawRowView(i)
+		p, err := m.Project(x)
+		assert.NoError(err)
+		assert.Len(p, 2)
+		units, _, err := m.KBMU(x, DefaultProjectK)
+		assert.NoError(err)
+		a, b, c := m.UnitCoords(units[0]), m.UnitCoords(units[1]), m.UnitCoords(units[2])
+		assert.True(inTriangle(p, a, b, c), "projection %v outside of %v, %v, %v", p, a, b, c)
+	}
+	// exact match projects onto unit coordinates
+	p, err := m.ProjectK(m.codebook.RawRowView(4), 3)
+	assert.NoError(err)
+	assert.InDeltaSlice(m.UnitCoords(4), p, 1e-9)
+	// single BMU gives BMU coordinates
+	bmu, _, err := m.BMU(data.RawRowView(0))
+	assert.NoError(err)
+	p, err = m.ProjectK(data.RawRowView(0), 1)
+	assert.NoError(err)
+	assert.InDeltaSlice(m.UnitCoords(bmu), p, 1e-9)
+}
+
+func TestProjectAll(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NoError(err)
+	// invalid parameters
+	errString := "invalid data supplied: %v"
+	proj, err := m.ProjectAll(nil, 3, 0.0)
+	assert.Nil(proj)
+	assert.EqualError(err, fmt.Sprintf(errString, nil))
+	errString = "invalid number of best match units requested: %d"
+	proj, err = m.ProjectAll(dataMx, -1, 0.0)
+	assert.EqualError(err, fmt.Sprintf(errString, -1))
+	proj, err = m.ProjectAll(mat64.NewDense(2, 2, nil), 3, 0.0)
+	assert.Equal(ErrDimMismatch, err)
+	_, cols := dataMx.Dims()
+	noObs := mat64.DenseCopyOf(dataMx)
+	for j := 0; j < cols; j++ {
+		noObs.Set(1, j, math.NaN())
+	}
+	for _, jitter := range []float64{0.0, 0.25} {
+		proj, err = m.ProjectAll(noObs, 3, jitter)
+		assert.EqualError(err, "invalid data row 1: no observed values")
+	}
+	// batch results match single sample results
+	proj, err = m.ProjectAll(dataMx, 3, 0.0)
+	assert.NoError(err)
+	rows, _ := dataMx.Dims()
+	for i := 0; i < rows; i++ {
+		p, err := m.Project(dataMx.RawRowView(i))
+		assert.NoError(err)
+		assert.Equal(p, proj.RawRowView(i))
+	}
+	// jitter is deterministic and bounded
+	jitter := 0.25
+	proj, err = m.ProjectAll(dataMx, 3, jitter)
+	assert.NoError(err)
+	again, err := m.ProjectAll(dataMx, 3, jitter)
+	assert.NoError(err)
+	assert.True(mat64.Equal(proj, again))
+	for i := 0; i < rows; i++ {
+		bmu, _, err := m.BMU(dataMx.RawRowView(i))
+		assert.NoError(err)
+		for j, c := range m.UnitCoords(bmu) {
+			assert.True(math.Abs(proj.At(i, j)-c) <= jitter)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(200, 4, 4, 10.0, -10.0, 2.0, 10)
+	m, err := NewMap(mSom, data)
+	assert.NoError(err)
+	assert.NoError(m.Train(tSom, data, 500))
+	rows, _ := data.Dims()
+	// single BMU gives BMU coordinates
+	coords, err := m.Transform(data, 1)
+	assert.NoError(err)
+	r, c := coords.Dims()
+	assert.Equal(rows, r)
+	assert.Equal(2, c)
+	for i := 0; i < rows; i++ {
+		bmu, _, err := m.BMU(data.RawRowView(i))
+		assert.NoError(err)
+		assert.Equal(m.UnitCoords(bmu), coords.RawRowView(i))
+	}
+	// more BMUs interpolate the coordinates
+	coords, err = m.Transform(data, 3)
+	assert.NoError(err)
+	for i := 0; i < rows; i++ {
+		p, err := m.ProjectK(data.RawRowView(i), 3)
+		assert.NoError(err)
+		assert.Equal(p, coords.RawRowView(i))
+	}
+	// data in original units is scaled by the map scaler
+	scaler, err := FitScaler(ZScore, data)
+	assert.NoError(err)
+	scaled, err := scaler.Transform(data)
+	assert.NoError(err)
+	sm, err := NewMap(mSom, mat64.DenseCopyOf(scaled))
+	assert.NoError(err)
+	assert.NoError(sm.Train(tSom, scaled, 500))
+	want := make(map[int]*mat64.Dense)
+	for _, k := range []int{1, 3} {
+		want[k], err = sm.Transform(scaled, k)
+		assert.NoError(err)
+	}
+	assert.NoError(sm.SetScaler(scaler))
+	for _, k := range []int{1, 3} {
+		got, err := sm.Transform(data, k)
+		assert.NoError(err)
+		assert.True(mat64.EqualApprox(want[k], got, 1e-9), "k %d", k)
+	}
+	// single samples and ProjectAll are scaled too
+	proj, err := sm.ProjectAll(data, 3, 0.0)
+	assert.NoError(err)
+	assert.True(mat64.EqualApprox(want[3], proj, 1e-9))
+	for i := 0; i < rows; i++ {
+		p, err := sm.Project(data.RawRowView(i))
+		assert.NoError(err)
+		assert.InDeltaSlice(want[3].RawRowView(i), p, 1e-9)
+	}
+
+	_, err = m.Transform(nil, 1)
+	assert.Error(err)
+	_, err = m.Transform(data, 0)
+	assert.EqualError(err, "invalid number of best match units requested: 0")
+	_, err = m.Transform(mat64.NewDense(2, 2, nil), 1)
+	assert.Equal(ErrDimMismatch, err)
+	_, cols := data.Dims()
+	noObs := mat64.DenseCopyOf(data)
+	for j := 0; j < cols; j++ {
+		noObs.Set(2, j, math.NaN())
+	}
+	for _, k := range []int{1, 3} {
+		_, err = m.Transform(noObs, k)
+		assert.EqualError(err, "invalid data row 2: no observed values", "k %d", k)
+	}
+}
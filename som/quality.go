@@ -6,15 +6,17 @@ import (
 	"sort"
 
 	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+	"github.com/gonum/stat/distuv"
 )
 
 // QuantError computes SOM quantization error for the supplied data set and codebook and returns it.
 // It fails with error if either data or codebook are nil or the distance between the codebook and
 // data vectors could not be calculated. This could be because the dimensions of passed in data and
 // codebook matrix are not the same. When the error is returned, quantization error is set to -1.0
-func QuantError(data, codebook *mat64.Dense) (float64, error) {
+func QuantError(data mat64.Matrix, codebook *mat64.Dense) (float64, error) {
 	// data can't be nil
-	if data == nil {
+	if isNilMatrix(data) {
 		return -1.0, fmt.Errorf("invalid data supplied: %v", data)
 	}
 	// codebook can't be nil
@@ -24,13 +26,15 @@ func QuantError(data, codebook *mat64.Dense) (float64, error) {
 	var qErr float64
 	metric := "euclidean"
 	rows, _ := data.Dims()
+	rd := newRowReader(data)
 	for i := 0; i < rows; i++ {
-		bmuIdx, err := ClosestVec(metric, data.RawRowView(i), codebook)
+		x := rd.row(i)
+		bmuIdx, err := ClosestVec(metric, x, codebook)
 		if err != nil {
 			return -1.0, err
 		}
 		// get the BMU distance -- no need to check for errors here
-		d, err := Distance(metric, data.RawRowView(i), codebook.RawRowView(bmuIdx))
+		d, err := Distance(metric, x, codebook.RawRowView(bmuIdx))
 		if err != nil {
 			return -1.0, err
 		}
@@ -57,15 +61,23 @@ func TopoProduct(codebook, grid *mat64.Dense) (float64, error) {
 	gRows, _ := grid.Dims()
 	cRows, _ := codebook.Dims()
 	if gRows != cRows {
-		return 0.0, fmt.Errorf("Grid and codebook dimension mismatch")
+		return 0.0, fmt.Errorf("grid and codebook: %w", ErrDimMismatch)
 	}
-	// unit and codebook distance matrices -- no need to check for error here
+	// unit distance matrix -- no need to check for error here
 	uDistMx, _ := DistanceMx("euclidean", grid)
-	cDistMx, _ := DistanceMx("euclidean", codebook)
+	return topoProduct(codebook, uDistMx), nil
+}
+
+// topoProduct calculates topographic product for given codebook and matrix of distances between
+// grid units whose rows match codebook rows
+func topoProduct(codebook, uDistMx *mat64.Dense) float64 {
+	cRows, _ := codebook.Dims()
+	// codebook distance matrix
+	cDistMx := euclideanMx(codebook)
 	// tp is the topographic product
 	var tp float64
 	// loop through all neurons
-	for i := 0; i < gRows; i++ {
+	for i := 0; i < cRows; i++ {
 		// retrieve unit distance slice and sort it
 		uSlice := newFloat64Slice(uDistMx.RawRowView(i)...)
 		sort.Sort(uSlice)
@@ -79,11 +91,11 @@ func TopoProduct(codebook, grid *mat64.Dense) (float64, error) {
 		for j := 0; j < cRows-1; j++ {
 			// if 2 codebooks are the same, return +Inf or -Inf
 			if cDistMx.At(i, cNeighb[j]) == 0 {
-				return math.Inf(1), nil
+				return math.Inf(1)
 			}
 
 			if cDistMx.At(i, uNeighb[j]) == 0 {
-				return math.Inf(-1), nil
+				return math.Inf(-1)
 			}
 			// lattice_space / codebook_space
 			q1 := cDistMx.At(i, uNeighb[j]) / cDistMx.At(i, cNeighb[j])
@@ -99,14 +111,14 @@ func TopoProduct(codebook, grid *mat64.Dense) (float64, error) {
 		}
 	}
 
-	return tp / float64(cRows*(cRows-1)), nil
+	return tp / float64(cRows*(cRows-1))
 }
 
 // TopoError calculate topographice error for given data set, codebook and grid and returns it
 // It returns error if either data, codebook or grid are nil or if their dimensions are mismatched.
-func TopoError(data, codebook, grid *mat64.Dense) (float64, error) {
+func TopoError(data mat64.Matrix, codebook, grid *mat64.Dense) (float64, error) {
 	// data can't be nil
-	if data == nil {
+	if isNilMatrix(data) {
 		return -1.0, fmt.Errorf("invalid data supplied: %v", data)
 	}
 	// codebook can't be nil
@@ -119,22 +131,131 @@ func TopoError(data, codebook, grid *mat64.Dense) (float64, error) {
 	}
 	// unit distance matrix -- no need to check for error
 	uDistMx, _ := DistanceMx("euclidean", grid)
+	return topoError(data, euclideanClosest(codebook), uDistMx, neighbDist(grid))
+}
+
+// euclideanClosest returns function which finds the n closest codebook vectors of x by euclidean distance
+func euclideanClosest(codebook *mat64.Dense) func(x []float64, n int) ([]int, error) {
+	return func(x []float64, n int) ([]int, error) {
+		return ClosestNVec("euclidean", n, x, codebook)
+	}
+}
+
+// topoError calculates topographic error for given data set using closest to find the two closest
+// codebook vectors of data samples and matrix of distances between grid units whose rows match
+// codebook rows. Units closer than neighb are neighbours.
+func topoError(data mat64.Matrix, closest func(x []float64, n int) ([]int, error), uDistMx *mat64.Dense, neighb float64) (float64, error) {
 	var te float64
 	// iterate through all data samples
 	rows, _ := data.Dims()
+	rd := newRowReader(data)
 	for i := 0; i < rows; i++ {
-		closest, err := ClosestNVec("euclidean", 2, data.RawRowView(i), codebook)
+		closest, err := closest(rd.row(i), 2)
 		if err != nil {
 			return -1.0, err
 		}
-		// If the 2 BMUS are next to each other on lattice increment te.
-		// 1.01*math.Sqrt(2) accounts for voronoi cell neighbourhood.
-		// This makes the diagonal lattice units to be considered neighb.
+		// If the 2 BMUS are not next to each other on lattice increment te.
 		uDistVec := uDistMx.RawRowView(closest[0])
-		if uDistVec[closest[1]] >= 1.01*math.Sqrt(2) {
+		if !adjacent(uDistVec[closest[1]], neighb) {
 			te++
 		}
 	}
 
 	return te / float64(rows), nil
 }
+
+// adjacent returns true if two grid units uDist apart are neighbours on a grid whose
+// neighbouring units are neighb apart. 1.01*neighb accounts for voronoi cell neighbourhood.
+// With neighb of math.Sqrt(2) the diagonal lattice units are considered neighb.
+func adjacent(uDist, neighb float64) bool {
+	return uDist < 1.01*neighb
+}
+
+// neighbDist returns the distance of neighbouring units of a grid with unit coordinates coords.
+// Diagonal units are neighbours, so it is math.Sqrt(2) on 2D grids and math.Sqrt(3) on 3D grids.
+func neighbDist(coords *mat64.Dense) float64 {
+	if _, dims := coords.Dims(); dims == 3 {
+		return math.Sqrt(3)
+	}
+	return math.Sqrt2
+}
+
+// Embedding computes SOM embedding accuracy for the supplied data set and codebook.
+// For every data feature it runs a two-sample t-test on the feature means and a two-sample
+// F-test on the feature variances of the data and codebook. A feature is considered to be
+// embedded when neither test rejects the null hypothesis at the given significance level alpha.
+// Embedding returns the fraction of embedded features and a slice of per-feature pass flags.
+// It fails with error if either data or codebook are nil, if their dimensions are mismatched
+// or if alpha is not in the (0, 1) interval. When the error is returned, accuracy is set to -1.0.
+func Embedding(data, codebook *mat64.Dense, alpha float64) (float64, []bool, error) {
+	// data can't be nil
+	if data == nil {
+		return -1.0, nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	// codebook can't be nil
+	if codebook == nil {
+		return -1.0, nil, fmt.Errorf("invalid codebook supplied: %v", codebook)
+	}
+	// significance level must be a probability
+	if alpha <= 0.0 || alpha >= 1.0 {
+		return -1.0, nil, fmt.Errorf("invalid significance level: %f", alpha)
+	}
+	rows, cols := data.Dims()
+	cbRows, cbCols := codebook.Dims()
+	if cols != cbCols {
+		return -1.0, nil, fmt.Errorf("data and codebook: %w", ErrDimMismatch)
+	}
+	// both tests need at least 2 samples on each side
+	if rows < 2 || cbRows < 2 {
+		return -1.0, nil, fmt.Errorf("Insufficient number of samples: %d, %d", rows, cbRows)
+	}
+	dataCol := make([]float64, rows)
+	cbCol := make([]float64, cbRows)
+	embedded := make([]bool, cols)
+	count := 0
+	for i := 0; i < cols; i++ {
+		dMean, dVar := stat.MeanVariance(mat64.Col(dataCol, i, data), nil)
+		cMean, cVar := stat.MeanVariance(mat64.Col(cbCol, i, codebook), nil)
+		pMean := meanTestPValue(dMean, dVar, float64(rows), cMean, cVar, float64(cbRows))
+		pVar := varTestPValue(dVar, float64(rows), cVar, float64(cbRows))
+		if pMean > alpha && pVar > alpha {
+			embedded[i] = true
+			count++
+		}
+	}
+
+	return float64(count) / float64(cols), embedded, nil
+}
+
+// meanTestPValue returns two-sided p-value of Welch's t-test for the means of two samples
+// given their means m1, m2, variances v1, v2 and sizes n1, n2.
+func meanTestPValue(m1, v1, n1, m2, v2, n2 float64) float64 {
+	se1, se2 := v1/n1, v2/n2
+	// both samples are constant: the means either match or they don't
+	if se1+se2 == 0 {
+		if m1 == m2 {
+			return 1.0
+		}
+		return 0.0
+	}
+	t := (m1 - m2) / math.Sqrt(se1+se2)
+	// Welch-Satterthwaite degrees of freedom
+	df := (se1 + se2) * (se1 + se2) / (se1*se1/(n1-1) + se2*se2/(n2-1))
+	tDist := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: df}
+	return 2 * tDist.Survival(math.Abs(t))
+}
+
+// varTestPValue returns two-sided p-value of F-test for the variances of two samples
+// given their variances v1, v2 and sizes n1, n2.
+func varTestPValue(v1, n1, v2, n2 float64) float64 {
+	// constant samples: the variances either match or they don't
+	if v1 == 0 || v2 == 0 {
+		if v1 == v2 {
+			return 1.0
+		}
+		return 0.0
+	}
+	fDist := distuv.F{D1: n1 - 1, D2: n2 - 1}
+	p := fDist.CDF(v1 / v2)
+	return 2 * math.Min(p, 1-p)
+}
@@ -1,6 +1,7 @@
 package som
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -64,9 +65,10 @@ func TestTopoProduct(t *testing.T) {
 	// grid and codebook dimension mismatch
 	qGridErr, err := GridCoords("rectangle", []int{3, 3})
 	assert.NoError(err)
-	errString = "Grid and codebook dimension mismatch"
+	errString = "grid and codebook: dimension mismatch"
 	tp, err = TopoProduct(qCbook, qGridErr)
 	assert.EqualError(err, errString)
+	assert.True(errors.Is(err, ErrDimMismatch))
 	assert.Equal(0.0, tp)
 	// this should go through without errors
 	tp, err = TopoProduct(qCbook, qGrid)
@@ -102,3 +104,67 @@ func TestTopoError(t *testing.T) {
 	assert.NoError(err)
 	assert.True(te > 0.0)
 }
+
+func TestTopoError3D(t *testing.T) {
+	assert := assert.New(t)
+
+	grid, err := GridCoords("rectangle", []int{2, 2, 2})
+	assert.NoError(err)
+	// the BMU of the sample is unit 0 and the second BMU is the unit in the opposite cube corner
+	units, _ := grid.Dims()
+	cb := mat64.NewDense(units, 1, nil)
+	for i := 0; i < units; i++ {
+		cb.Set(i, 0, 10.0)
+		if mat64.Norm(grid.RowView(i), 1) == 3 {
+			cb.Set(i, 0, 1.0)
+		}
+	}
+	cb.Set(0, 0, 0.0)
+	// cube diagonal units are neighbours on 3D grids
+	te, err := TopoError(mat64.NewDense(1, 1, []float64{0.4}), cb, grid)
+	assert.NoError(err)
+	assert.Equal(0.0, te)
+}
+
+func TestEmbedding(t *testing.T) {
+	assert := assert.New(t)
+
+	// nil data returns error
+	errString := "invalid data supplied: %v"
+	ea, flags, err := Embedding(nil, qCbook, 0.05)
+	assert.EqualError(err, fmt.Sprintf(errString, nil))
+	assert.Equal(-1.0, ea)
+	assert.Nil(flags)
+	// nil codebook returns error
+	errString = "invalid codebook supplied: %v"
+	ea, flags, err = Embedding(qData, nil, 0.05)
+	assert.EqualError(err, fmt.Sprintf(errString, nil))
+	assert.Equal(-1.0, ea)
+	assert.Nil(flags)
+	// invalid significance level
+	errString = "invalid significance level: %f"
+	for _, alpha := range []float64{0.0, 1.0, -0.5} {
+		ea, flags, err = Embedding(qData, qCbook, alpha)
+		assert.EqualError(err, fmt.Sprintf(errString, alpha))
+		assert.Equal(-1.0, ea)
+	}
+	// incorrect dimensions of codebook and data
+	qCbookTmp := mat64.NewDense(2, 3, []float64{5.1, 3.5, 1.4, 4.9, 3.0, 1.4})
+	ea, flags, err = Embedding(qData, qCbookTmp, 0.05)
+	assert.EqualError(err, "data and codebook: dimension mismatch")
+	assert.True(errors.Is(err, ErrDimMismatch))
+	assert.Equal(-1.0, ea)
+	// codebook which is identical to data embeds all features
+	ea, flags, err = Embedding(qData, qData, 0.05)
+	assert.NoError(err)
+	assert.Equal(1.0, ea)
+	assert.Equal([]bool{true, true, true, true}, flags)
+	// codebook far away from data embeds no features
+	farCbook := new(mat64.Dense)
+	farCbook.Clone(qData)
+	farCbook.Apply(func(i, j int, v float64) float64 { return v + 100.0 }, farCbook)
+	ea, flags, err = Embedding(qData, farCbook, 0.05)
+	assert.NoError(err)
+	assert.Equal(0.0, ea)
+	assert.Equal([]bool{false, false, false, false}, flags)
+}
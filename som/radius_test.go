@@ -1,6 +1,7 @@
 package som
 
 import (
+	"errors"
 	"math"
 	"testing"
 
@@ -15,6 +16,15 @@ func TestLinRadius(t *testing.T) {
 	testRadius(t, "lin")
 }
 
+func TestInvRadius(t *testing.T) {
+	testRadius(t, "inv")
+	// inverse decay drops faster than linear decay at the start of training
+	inv, _ := Radius(10, 100, "inv", 100.0)
+	lin, _ := Radius(10, 100, "lin", 100.0)
+	assert.True(t, inv < lin)
+	assert.InDelta(t, 100.0/(1+99.0/99*10), inv, 1e-12)
+}
+
 func TestDefaultRadius(t *testing.T) {
 	testRadius(t, "some other")
 }
@@ -24,10 +34,10 @@ func TestNegativeRadius(t *testing.T) {
 
 	r, err := Radius(0, 1, "exp", -1.0)
 	assert.True(math.IsNaN(r))
-	assert.NotEmpty(err)
+	assert.True(errors.Is(err, ErrInvalidRadius))
 }
 
-func testRadius(t *testing.T, strategy string) {
+func testRadius(t *testing.T, strategy Decay) {
 	assert := assert.New(t)
 
 	radius0 := 100.0
@@ -41,4 +51,8 @@ func testRadius(t *testing.T, strategy string) {
 	assert.InDelta(MinRadius, r, 0.01)
 	assert.NoError(err)
 
+	r, err = Radius(0, 1, strategy, radius0)
+	assert.Equal(radius0, r)
+	assert.NoError(err)
+
 }
@@ -0,0 +1,362 @@
+package som
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// registry holds supported values of a configuration parameter, such as decay strategies
+// or grid types, by their names. It is safe for concurrent use, so values can be registered
+// at runtime, e.g. by plugins, while maps are configured and trained.
+type registry[V any] struct {
+	// mu guards entries
+	mu sync.RWMutex
+	// kind names the registered values in errors
+	kind string
+	// entries maps names to registered values
+	entries map[string]V
+}
+
+// newRegistry creates new registry of values of kind which holds entries
+func newRegistry[V any](kind string, entries map[string]V) *registry[V] {
+	return &registry[V]{kind: kind, entries: entries}
+}
+
+// Register registers value v as name.
+// It returns error if name is empty or if the name is already registered.
+func (r *registry[V]) Register(name string, v V) error {
+	if name == "" {
+		return fmt.Errorf("invalid %s name: %q", r.kind, name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[name]; ok {
+		return fmt.Errorf("%s already registered: %s", r.kind, name)
+	}
+	r.entries[name] = v
+	return nil
+}
+
+// Lookup returns value registered as name and true or zero value and false if name is not registered
+func (r *registry[V]) Lookup(name string) (V, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	v, ok := r.entries[name]
+	return v, ok
+}
+
+// Has returns true if name is registered
+func (r *registry[V]) Has(name string) bool {
+	_, ok := r.Lookup(name)
+	return ok
+}
+
+// List returns sorted registered names
+func (r *registry[V]) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// supported returns comma separated sorted registered names, which errors of unsupported names list
+func (r *registry[V]) supported() string {
+	return strings.Join(r.List(), ", ")
+}
+
+// uShapes registers supported SOM unit shapes
+var uShapes = newRegistry("unit shape", map[string]bool{
+	"hexagon":   true,
+	"rectangle": true,
+})
+
+// coordsInitFns registers supported grid types
+var coordsInitFns = newRegistry("grid type", map[string]CoordsInitFunc{
+	"planar":      GridCoords,
+	"toroidal":    GridCoords,
+	"cylindrical": GridCoords,
+	"spherical":   SphereCoords,
+})
+
+// decayFuncs registers supported decay strategies
+var decayFuncs = newRegistry("decay strategy", map[string]DecayFunc{
+	"lin": linDecay,
+	"exp": expDecay,
+	"inv": invDecay,
+	"pow": powDecay,
+})
+
+// precisions registers supported codebook storage precisions
+var precisions = newRegistry("codebook precision", map[string]bool{
+	"float64": true,
+	"float32": true,
+})
+
+// metrics registers supported distance metrics
+var metrics = newRegistry("distance metric", map[string]DistanceFunc{
+	"euclidean":   Euclidean,
+	"manhattan":   Manhattan,
+	"chebyshev":   Chebyshev,
+	"cosine":      Cosine,
+	"correlation": Correlation,
+})
+
+// cbInitFuncs registers supported codebook initialization functions
+var cbInitFuncs = newRegistry("codebook initialization", map[string]CbInitFunc{
+	"rand":   RandInit,
+	"lin":    LinInit,
+	"sample": SampleInit,
+})
+
+// neighbFuncs registers supported neighbourhood functions
+var neighbFuncs = newRegistry("neighbourhood function", map[string]NeighbFunc{
+	"gaussian":     Gaussian,
+	"bubble":       Bubble,
+	"mexican":      MexicanHat,
+	"cutgauss":     CutGaussian,
+	"epanechnikov": Epanechnikov,
+})
+
+// trainingAlgs registers supported training algorithms
+var trainingAlgs = newRegistry("training algorithm", map[string]bool{
+	"seq":       true,
+	"batch":     true,
+	"neuralgas": true,
+	"gng":       true,
+	"temporal":  true,
+	"gsom":      true,
+})
+
+// updateRules registers supported batch training codebook update rules
+var updateRules = newRegistry("batch update rule", map[string]bool{
+	"mean":    true,
+	"median":  true,
+	"trimmed": true,
+})
+
+// reviveStrategies registers supported dead unit revival strategies
+var reviveStrategies = newRegistry("dead unit revival strategy", map[string]bool{
+	"split":  true,
+	"sample": true,
+})
+
+// imputeStrategies registers supported missing value imputation strategies
+var imputeStrategies = newRegistry("imputation strategy", map[string]bool{
+	"bmu":       true,
+	"knn":       true,
+	"iterative": true,
+})
+
+// ConfigConverter converts content of a config file to JSON config of the same fields
+type ConfigConverter func([]byte) ([]byte, error)
+
+// configFormats registers converters of config file extensions to JSON;
+// JSON files are not converted
+var configFormats = newRegistry("config file format", map[string]ConfigConverter{
+	".json": nil,
+})
+
+// gridTypeFunc returns coordinates initialization function of grid type or nil if it is not supported
+func gridTypeFunc(gridType string) CoordsInitFunc {
+	fn, _ := coordsInitFns.Lookup(gridType)
+	return fn
+}
+
+// distanceFunc returns distance function of metric or nil if it is not supported
+func distanceFunc(metric string) DistanceFunc {
+	fn, _ := metrics.Lookup(metric)
+	return fn
+}
+
+// distanceFuncName returns the name of metric registered with distance function fn
+// or empty string if fn is not registered
+func distanceFuncName(fn DistanceFunc) string {
+	ptr := reflect.ValueOf(fn).Pointer()
+	for _, name := range metrics.List() {
+		if f, _ := metrics.Lookup(name); reflect.ValueOf(f).Pointer() == ptr {
+			return name
+		}
+	}
+	return ""
+}
+
+// decayFunc returns decay function of strategy or nil if it is not supported
+func decayFunc(strategy string) DecayFunc {
+	fn, _ := decayFuncs.Lookup(strategy)
+	return fn
+}
+
+// cbInitFunc returns codebook initialization function registered as name or nil if it is not supported
+func cbInitFunc(name string) CbInitFunc {
+	fn, _ := cbInitFuncs.Lookup(name)
+	return fn
+}
+
+// configFormat returns converter of config files with extension ext.
+// It returns error if the format is not supported.
+func configFormat(ext string) (ConfigConverter, error) {
+	fn, ok := configFormats.Lookup(ext)
+	if !ok {
+		return nil, fmt.Errorf("unsupported config file format: %q", ext)
+	}
+	return fn, nil
+}
+
+// validateRegistration checks whether value of kind can be registered as name.
+// It returns error if name is empty or if value is nil.
+func validateRegistration(kind, name string, isNil bool) error {
+	if name == "" {
+		return fmt.Errorf("invalid %s name: %q", kind, name)
+	}
+	if isNil {
+		return fmt.Errorf("invalid %s: %s", kind, name)
+	}
+	return nil
+}
+
+// RegisterGridType registers grid type name whose unit coordinates are computed by fn.
+// Registered grid types can be used in GridConfig and WithGrid option.
+// It returns error if name is empty, fn is nil or the grid type is already registered,
+// so built-in grid types can't be replaced.
+func RegisterGridType(name string, fn CoordsInitFunc) error {
+	if err := validateRegistration("grid type", name, fn == nil); err != nil {
+		return err
+	}
+	return coordsInitFns.Register(name, fn)
+}
+
+// RegisterCbInit registers codebook initialization function fn as name.
+// Registered initializations can be used in WithInit option.
+// It returns error if name is empty, fn is nil or the name is already registered.
+func RegisterCbInit(name string, fn CbInitFunc) error {
+	if err := validateRegistration("codebook initialization", name, fn == nil); err != nil {
+		return err
+	}
+	return cbInitFuncs.Register(name, fn)
+}
+
+// RegisterNeighbFunc registers neighbourhood function fn as name.
+// Registered functions can be looked up by NeighbFuncByName.
+// It returns error if name is empty, fn is nil or the name is already registered.
+func RegisterNeighbFunc(name string, fn NeighbFunc) error {
+	if err := validateRegistration("neighbourhood function", name, fn == nil); err != nil {
+		return err
+	}
+	return neighbFuncs.Register(name, fn)
+}
+
+// RegisterConfigFormat registers converter fn of config files with extension ext, such as .yaml,
+// to JSON which is then read by LoadConfig. For example, YAML to JSON conversion of a YAML library
+// can be registered. Extensions are matched case insensitively.
+// It returns error if ext is empty, fn is nil or the extension is already registered.
+func RegisterConfigFormat(ext string, fn ConfigConverter) error {
+	if err := validateRegistration("config file format", ext, fn == nil); err != nil {
+		return err
+	}
+	return configFormats.Register(strings.ToLower(ext), fn)
+}
+
+// RegisterDecay registers decay function fn as decay strategy name. Registered strategies can be
+// used as TrainConfig RDecay and LDecay and parsed by ParseDecay.
+// It returns error if name is empty, fn is nil or the name is already registered.
+func RegisterDecay(name string, fn DecayFunc) error {
+	if err := validateRegistration("decay strategy", name, fn == nil); err != nil {
+		return err
+	}
+	return decayFuncs.Register(name, fn)
+}
+
+// RegisterDistanceFunc registers distance function fn as metric name.
+// Registered metrics can be used in WithMetric option and looked up by DistanceFuncByName.
+// It returns error if name is empty, fn is nil or the name is already registered.
+func RegisterDistanceFunc(name string, fn DistanceFunc) error {
+	if err := validateRegistration("distance metric", name, fn == nil); err != nil {
+		return err
+	}
+	return metrics.Register(name, fn)
+}
+
+// DistanceFuncByName returns distance function registered as metric name: euclidean, manhattan,
+// chebyshev, cosine, correlation or a metric registered by RegisterDistanceFunc.
+// It returns ErrUnsupportedMetric if no function is registered as name.
+func DistanceFuncByName(name string) (DistanceFunc, error) {
+	fn, ok := metrics.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMetric, name)
+	}
+	return fn, nil
+}
+
+// NeighbFuncByName returns neighbourhood function registered as name: gaussian, bubble, mexican,
+// cutgauss, epanechnikov or a function registered by RegisterNeighbFunc. It returns
+// ErrUnsupportedNeighbFn if no function is registered as name.
+func NeighbFuncByName(name string) (NeighbFunc, error) {
+	fn, ok := neighbFuncs.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedNeighbFn, name)
+	}
+	return fn, nil
+}
+
+// SupportedUShapes returns sorted names of supported unit shapes
+func SupportedUShapes() []string {
+	return uShapes.List()
+}
+
+// SupportedDecays returns sorted names of supported radius and learning rate decay strategies
+// including registered ones
+func SupportedDecays() []string {
+	return decayFuncs.List()
+}
+
+// SupportedPrecisions returns sorted names of supported codebook storage precisions
+func SupportedPrecisions() []string {
+	return precisions.List()
+}
+
+// SupportedMetrics returns sorted names of supported distance metrics including registered ones
+func SupportedMetrics() []string {
+	return metrics.List()
+}
+
+// SupportedAlgorithms returns sorted names of supported training algorithms
+func SupportedAlgorithms() []string {
+	return trainingAlgs.List()
+}
+
+// SupportedUpdateRules returns sorted names of supported batch training codebook update rules
+func SupportedUpdateRules() []string {
+	return updateRules.List()
+}
+
+// SupportedReviveStrategies returns sorted names of supported dead unit revival strategies
+func SupportedReviveStrategies() []string {
+	return reviveStrategies.List()
+}
+
+// SupportedImputeStrategies returns sorted names of supported missing value imputation strategies
+func SupportedImputeStrategies() []string {
+	return imputeStrategies.List()
+}
+
+// SupportedGridTypes returns sorted names of supported grid types including registered ones
+func SupportedGridTypes() []string {
+	return coordsInitFns.List()
+}
+
+// SupportedCbInits returns sorted names of supported codebook initializations including registered ones
+func SupportedCbInits() []string {
+	return cbInitFuncs.List()
+}
+
+// SupportedNeighbFuncs returns sorted names of supported neighbourhood functions including registered ones
+func SupportedNeighbFuncs() []string {
+	return neighbFuncs.List()
+}
@@ -0,0 +1,175 @@
+package som
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupported(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"hexagon", "rectangle"}, SupportedUShapes())
+	assert.Contains(SupportedGridTypes(), "planar")
+	for _, name := range []string{"exp", "inv", "lin", "pow"} {
+		assert.Contains(SupportedDecays(), name)
+	}
+	assert.Equal([]string{"float32", "float64"}, SupportedPrecisions())
+	for _, name := range []string{"chebyshev", "correlation", "cosine", "euclidean", "manhattan"} {
+		assert.Contains(SupportedMetrics(), name)
+	}
+	assert.Equal([]string{"batch", "gng", "gsom", "neuralgas", "seq", "temporal"}, SupportedAlgorithms())
+	assert.Equal([]string{"mean", "median", "trimmed"}, SupportedUpdateRules())
+	assert.Equal([]string{"sample", "split"}, SupportedReviveStrategies())
+	assert.Equal([]string{"bmu", "iterative", "knn"}, SupportedImputeStrategies())
+	for _, name := range []string{"lin", "rand", "sample"} {
+		assert.Contains(SupportedCbInits(), name)
+	}
+	for _, name := range []string{"bubble", "cutgauss", "epanechnikov", "gaussian", "mexican"} {
+		assert.Contains(SupportedNeighbFuncs(), name)
+	}
+
+	fn, err := NeighbFuncByName("bubble")
+	assert.NoError(err)
+	assert.Equal(Bubble(0.5, 1.0), fn(0.5, 1.0))
+	fn, err = NeighbFuncByName("foobar")
+	assert.Nil(fn)
+	assert.True(errors.Is(err, ErrUnsupportedNeighbFn))
+
+	dist, err := DistanceFuncByName("manhattan")
+	assert.NoError(err)
+	assert.Equal(3.0, dist([]float64{0, 0}, []float64{1, 2}))
+	dist, err = DistanceFuncByName("foobar")
+	assert.Nil(dist)
+	assert.True(errors.Is(err, ErrUnsupportedMetric))
+}
+
+func TestRegistry(t *testing.T) {
+	assert := assert.New(t)
+
+	r := newRegistry("shape", map[string]int{"square": 4})
+	assert.NoError(r.Register("triangle", 3))
+	assert.EqualError(r.Register("square", 5), "shape already registered: square")
+	assert.EqualError(r.Register("", 1), `invalid shape name: ""`)
+	v, ok := r.Lookup("square")
+	assert.True(ok)
+	assert.Equal(4, v)
+	v, ok = r.Lookup("circle")
+	assert.False(ok)
+	assert.Equal(0, v)
+	assert.True(r.Has("triangle"))
+	assert.False(r.Has("circle"))
+	assert.Equal([]string{"square", "triangle"}, r.List())
+	assert.Equal("square, triangle", r.supported())
+
+	// validation errors list registered values
+	assert.NoError(RegisterDecay("listed", linDecay))
+	err := validateTrainConfig(&TrainConfig{Algorithm: Seq, Radius: 2.0, RDecay: "foobar", NeighbFn: Gaussian, LRate: 0.5, LDecay: LinDecay})
+	assert.True(errors.Is(err, ErrUnsupportedDecay))
+	assert.Contains(err.Error(), "listed")
+}
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+
+	// built-in values can't be replaced
+	assert.EqualError(RegisterGridType("planar", GridCoords), "grid type already registered: planar")
+	assert.EqualError(RegisterCbInit("rand", LinInit), "codebook initialization already registered: rand")
+	assert.EqualError(RegisterNeighbFunc("gaussian", Bubble), "neighbourhood function already registered: gaussian")
+	assert.EqualError(RegisterDistanceFunc("cosine", Euclidean), "distance metric already registered: cosine")
+	assert.EqualError(RegisterDecay("exp", linDecay), "decay strategy already registered: exp")
+	// invalid registrations
+	assert.EqualError(RegisterGridType("", GridCoords), `invalid grid type name: ""`)
+	assert.EqualError(RegisterGridType("nilgrid", nil), "invalid grid type: nilgrid")
+	assert.EqualError(RegisterCbInit("nilinit", nil), "invalid codebook initialization: nilinit")
+	assert.EqualError(RegisterNeighbFunc("nilfn", nil), "invalid neighbourhood function: nilfn")
+	assert.EqualError(RegisterDistanceFunc("nilmetric", nil), "invalid distance metric: nilmetric")
+	assert.EqualError(RegisterDecay("nildecay", nil), "invalid decay strategy: nildecay")
+
+	// registered grid type is used by map grid
+	scaled := func(uShape string, size []int) (*mat64.Dense, error) {
+		coords, err := GridCoords(uShape, size)
+		if err != nil {
+			return nil, err
+		}
+		coords.Scale(2.0, coords)
+		return coords, nil
+	}
+	assert.NoError(RegisterGridType("scaled", scaled))
+	assert.Contains(SupportedGridTypes(), "scaled")
+	grid, err := NewGrid(&GridConfig{Size: []int{3, 2}, Type: "scaled", UShape: "rectangle"})
+	assert.NoError(err)
+	assert.Equal("scaled", grid.Type())
+	assert.Equal(2.0, grid.Coords().At(1, 1))
+
+	// registered decay strategy is used by radius and learning rate decays
+	step := func(iteration, totalIterations int, init, min float64) float64 {
+		if iteration < totalIterations/2 {
+			return init
+		}
+		return min
+	}
+	assert.NoError(RegisterDecay("step", step))
+	assert.Contains(SupportedDecays(), "step")
+	d, err := ParseDecay("step")
+	assert.NoError(err)
+	r, err := Radius(4, 10, d, 3.0)
+	assert.NoError(err)
+	assert.Equal(3.0, r)
+	lr, err := LRate(5, 10, d, 0.5)
+	assert.NoError(err)
+	assert.Equal(MinLRate, lr)
+	assert.NoError(validateTrainConfig(&TrainConfig{Algorithm: Seq, Radius: 2.0, RDecay: d, NeighbFn: Gaussian, LRate: 0.5, LDecay: d}))
+
+	// registered metric is used by map BMU search
+	assert.NoError(RegisterDistanceFunc("first", func(a, b []float64) float64 { return math.Abs(a[0] - b[0]) }))
+	data := mat64.NewDense(4, 2, []float64{0.0, 0.0, 0.0, 1.0, 1.0, 0.0, 1.0, 1.0})
+	m, err := NewMapWithOptions(data, WithDims(2, 2), WithMetric("first"))
+	assert.NoError(err)
+	_, dist, err := m.BMU([]float64{m.codebook.At(0, 0), 100.0})
+	assert.NoError(err)
+	assert.Equal(0.0, dist)
+}
+
+func TestRegisterConcurrent(t *testing.T) {
+	assert := assert.New(t)
+
+	data := mat64.NewDense(4, 2, []float64{0.0, 0.0, 0.0, 1.0, 1.0, 0.0, 1.0, 1.0})
+	workers := 16
+	errs := make(chan error, 4*workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			gridType := fmt.Sprintf("concurrent-grid-%d", w)
+			initName := fmt.Sprintf("concurrent-init-%d", w)
+			nFnName := fmt.Sprintf("concurrent-nfn-%d", w)
+			errs <- RegisterGridType(gridType, GridCoords)
+			errs <- RegisterCbInit(initName, RandInit)
+			errs <- RegisterNeighbFunc(nFnName, Gaussian)
+			// registrations are honoured by validation as soon as they are made
+			_, err := NewMapWithOptions(data, WithDims(3, 2), WithGrid(gridType), WithInit(initName))
+			errs <- err
+			for i := 0; i < 10; i++ {
+				_ = SupportedGridTypes()
+				_ = SupportedNeighbFuncs()
+				_, _ = NeighbFuncByName(nFnName)
+				_ = validateGridConfig(&GridConfig{Size: []int{2, 2}, Type: GridType(gridType), UShape: "hexagon"})
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(err)
+	}
+	for w := 0; w < workers; w++ {
+		assert.Contains(SupportedGridTypes(), fmt.Sprintf("concurrent-grid-%d", w))
+	}
+}
@@ -0,0 +1,128 @@
+package som
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// TrainSupervised trains the map on data using the supplied training configuration and
+// assigns a continuous target value to every map unit. The targets don't participate in
+// the BMU search: after the training each unit receives the mean target value of the data
+// samples which have the unit as their BMU. Units which are not BMU of any sample inherit
+// the mean target of the closest non-empty units on the map grid.
+// It returns error if the number of targets is different from the number of data rows
+// or if the training fails.
+func (m *Map) TrainSupervised(c *TrainConfig, data *mat64.Dense, targets []float64, iters int) error {
+	// nil data passed in
+	if data == nil {
+		return fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, _ := data.Dims()
+	if len(targets) != rows {
+		return fmt.Errorf("invalid number of targets: %d", len(targets))
+	}
+	if err := m.Train(c, data, iters); err != nil {
+		return err
+	}
+	return m.fitTargets(data, targets)
+}
+
+// fitTargets computes per-unit means of targets of samples stored in data rows
+func (m *Map) fitTargets(data *mat64.Dense, targets []float64) error {
+	bmus, err := m.BMUs(data)
+	if err != nil {
+		return err
+	}
+	units, _ := m.cbDims()
+	sums := make([]float64, units)
+	hits := make([]int, units)
+	for i, bmu := range bmus {
+		sums[bmu] += targets[i]
+		hits[bmu]++
+	}
+	m.targets = make([]float64, units)
+	for i := range sums {
+		if hits[i] > 0 {
+			m.targets[i] = sums[i] / float64(hits[i])
+		}
+	}
+	// empty units inherit the mean target of the closest non-empty grid units
+	for i := range hits {
+		if hits[i] > 0 {
+			continue
+		}
+		coords := m.grid.coords.RawRowView(i)
+		sum, count, dist := 0.0, 0, math.MaxFloat64
+		for j := range hits {
+			if hits[j] == 0 {
+				continue
+			}
+			d := euclideanVec(coords, m.grid.coords.RawRowView(j))
+			switch {
+			case d < dist:
+				sum, count, dist = sums[j]/float64(hits[j]), 1, d
+			case d == dist:
+				sum += sums[j] / float64(hits[j])
+				count++
+			}
+		}
+		m.targets[i] = sum / float64(count)
+	}
+
+	return nil
+}
+
+// UnitTargets returns target values of all map units assigned by TrainSupervised.
+// It returns nil if the map has not been trained with targets.
+func (m Map) UnitTargets() []float64 {
+	return m.targets
+}
+
+// PredictTarget returns the target value of BMU of vector x.
+// If the map has a scaler, x is scaled by it first.
+// It returns error if the map has not been trained with targets or ErrDimMismatch
+// if the dimension of x is different from the map codebook dimension.
+func (m Map) PredictTarget(x []float64) (float64, error) {
+	if m.targets == nil {
+		return math.NaN(), fmt.Errorf("map has no unit targets")
+	}
+	x, err := m.ScaleSample(x)
+	if err != nil {
+		return math.NaN(), err
+	}
+	bmu, _, err := m.BMU(x)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return m.targets[bmu], nil
+}
+
+// PredictTargetK returns the mean of target values of k best matching units of vector x
+// weighted by their inverse distances to x. If x matches any of the units exactly,
+// the target of the matched unit is returned.
+// Like PredictTarget, PredictTargetK scales x by the map scaler if the map has one.
+// PredictTargetK fails in the same way as PredictTarget or if k is not a positive integer.
+func (m Map) PredictTargetK(x []float64, k int) (float64, error) {
+	if m.targets == nil {
+		return math.NaN(), fmt.Errorf("map has no unit targets")
+	}
+	x, err := m.ScaleSample(x)
+	if err != nil {
+		return math.NaN(), err
+	}
+	units, dists, err := m.KBMU(x, k)
+	if err != nil {
+		return math.NaN(), err
+	}
+	sum, weights := 0.0, 0.0
+	for i, u := range units {
+		if dists[i] == 0.0 {
+			return m.targets[u], nil
+		}
+		sum += m.targets[u] / dists[i]
+		weights += 1.0 / dists[i]
+	}
+	return sum / weights, nil
+}
@@ -0,0 +1,122 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeRegression generates samples uniformly distributed in [-1, 1] square with target x^2 + y
+func makeRegression(n int, seed int64) (*mat64.Dense, []float64) {
+	r := rand.New(rand.NewSource(seed))
+	data := mat64.NewDense(n, 2, nil)
+	targets := make([]float64, n)
+	for i := 0; i < n; i++ {
+		x, y := 2*r.Float64()-1, 2*r.Float64()-1
+		data.SetRow(i, []float64{x, y})
+		targets[i] = x*x + y
+	}
+	return data, targets
+}
+
+func TestTrainSupervised(t *testing.T) {
+	assert := assert.New(t)
+
+	data, targets := makeRegression(500, 3)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{10, 10},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      2,
+			InitFunc: RandInit,
+		},
+	}
+	tCfg := &TrainConfig{
+		Algorithm: "seq",
+		Radius:    5.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	// map without targets can't predict
+	_, err = m.PredictTarget(data.RawRowView(0))
+	assert.EqualError(err, "map has no unit targets")
+	_, err = m.PredictTargetK(data.RawRowView(0), 3)
+	assert.EqualError(err, "map has no unit targets")
+	// invalid parameters
+	errString := "invalid data supplied: %v"
+	err = m.TrainSupervised(tCfg, nil, targets, 1000)
+	assert.EqualError(err, fmt.Sprintf(errString, nil))
+	errString = "invalid number of targets: %d"
+	err = m.TrainSupervised(tCfg, data, targets[:10], 1000)
+	assert.EqualError(err, fmt.Sprintf(errString, 10))
+	// train and predict fresh samples
+	err = m.TrainSupervised(tCfg, data, targets, 20000)
+	assert.NoError(err)
+	assert.Len(m.UnitTargets(), 100)
+	test, truth := makeRegression(200, 4)
+	rows, _ := test.Dims()
+	var sse, sseK float64
+	for i := 0; i < rows; i++ {
+		y, err := m.PredictTarget(test.RawRowView(i))
+		assert.NoError(err)
+		sse += (y - truth[i]) * (y - truth[i])
+		y, err = m.PredictTargetK(test.RawRowView(i), 3)
+		assert.NoError(err)
+		sseK += (y - truth[i]) * (y - truth[i])
+	}
+	rmse := math.Sqrt(sse / float64(rows))
+	rmseK := math.Sqrt(sseK / float64(rows))
+	// predicting the mean gives RMSE equal to the target standard deviation
+	baseline := stat.StdDev(truth, nil)
+	assert.True(rmse < baseline/3, "rmse: %f, baseline: %f", rmse, baseline)
+	assert.True(rmseK < baseline/3, "rmseK: %f, baseline: %f", rmseK, baseline)
+	// dimension mismatch
+	_, err = m.PredictTarget([]float64{1.0})
+	assert.Equal(ErrDimMismatch, err)
+	// samples in original units are scaled by the map scaler
+	x := test.RawRowView(0)
+	y, err := m.PredictTarget(x)
+	assert.NoError(err)
+	yK, err := m.PredictTargetK(x, 3)
+	assert.NoError(err)
+	assert.NoError(m.SetScaler(&Scaler{Method: ZScore, Center: []float64{1, -1}, Scale: []float64{2, 2}}))
+	orig := []float64{2*x[0] + 1, 2*x[1] - 1}
+	sy, err := m.PredictTarget(orig)
+	assert.NoError(err)
+	assert.InDelta(y, sy, 1e-9)
+	syK, err := m.PredictTargetK(orig, 3)
+	assert.NoError(err)
+	assert.InDelta(yK, syK, 1e-9)
+}
+
+func TestFitTargetsEmptyUnit(t *testing.T) {
+	assert := assert.New(t)
+
+	// 3x1 rectangle grid: units 0, 1, 2 lie on a line
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{3, 1}, Type: "planar", UShape: "rectangle"},
+		Cb:   &CbConfig{Dim: 1, InitFunc: RandInit},
+	}, mat64.NewDense(2, 1, []float64{0.0, 1.0}))
+	assert.NoError(err)
+	m.codebook = mat64.NewDense(3, 1, []float64{0.0, 10.0, 20.0})
+	data := mat64.NewDense(3, 1, []float64{0.0, 1.0, 20.0})
+	err = m.fitTargets(data, []float64{1.0, 3.0, 6.0})
+	assert.NoError(err)
+	// empty unit 1 inherits the mean of both of its neighbours
+	assert.Equal([]float64{2.0, 4.0, 6.0}, m.UnitTargets())
+	y, err := m.PredictTargetK([]float64{0.0}, 3)
+	assert.NoError(err)
+	assert.Equal(2.0, y)
+}
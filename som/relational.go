@@ -0,0 +1,384 @@
+package som
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// RelMap is a relational SOM trained on pairwise dissimilarities of data items instead of
+// data vectors. Unit prototypes are convex combinations of the training items: every unit
+// is represented by a coefficient vector which holds one coefficient per training item.
+// Squared dissimilarity of item i and unit k with coefficients a is computed by the relational
+// formula (D*a)_i - a'*D*a/2, where D holds squared dissimilarities of the training items.
+type RelMap struct {
+	// grid holds SOM unit coordinates
+	grid *Grid
+	// sq holds squared dissimilarities of training items
+	sq *mat64.Dense
+	// coeffs holds unit coefficient vectors: SOM units x training items
+	coeffs *mat64.Dense
+	// dCoeffs holds products of sq and unit coefficient vectors: SOM units x training items
+	dCoeffs *mat64.Dense
+	// self holds a'*D*a/2 of every unit coefficient vector a
+	self []float64
+}
+
+// TrainRelational creates new relational SOM with grid configured by mc and trains it on
+// dissimilarities diss of data items, such as edit distances of sequences, using training
+// configuration tc. Dissimilarities must be non-negative and finite with zero diagonal; they
+// don't need to be Euclidean distances. mc.Cb is ignored: every unit starts as one of randomly
+// chosen training items. Sequential training runs 10 iterations per item, batch training runs
+// 100 epochs, neural gas training is not supported; RelMap.Train can continue the training.
+// It returns error if diss is nil or invalid, if mc or tc are invalid or if the training fails.
+func TrainRelational(diss *mat64.SymDense, mc *MapConfig, tc *TrainConfig) (*RelMap, error) {
+	if diss == nil {
+		return nil, fmt.Errorf("invalid dissimilarities supplied: %v", diss)
+	}
+	items := diss.Symmetric()
+	if items < 2 {
+		return nil, fmt.Errorf("invalid number of items: %d", items)
+	}
+	sq := mat64.NewDense(items, items, nil)
+	for i := 0; i < items; i++ {
+		if d := diss.At(i, i); d != 0.0 {
+			return nil, fmt.Errorf("invalid dissimilarity of item %d to itself: %f", i, d)
+		}
+		for j := i + 1; j < items; j++ {
+			d := diss.At(i, j)
+			if !(d >= 0) || math.IsInf(d, 1) {
+				return nil, fmt.Errorf("invalid dissimilarity of items %d and %d: %f", i, j, d)
+			}
+			sq.Set(i, j, d*d)
+			sq.Set(j, i, d*d)
+		}
+	}
+	m, err := newRelMap(sq, mc)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Train(tc, relIters(tc, items)); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Dissimilarities returns symmetric matrix of dissimilarities of n data items computed by diss
+// for every pair of items i < j, so it can be passed to TrainRelational. Dissimilarities of items
+// to themselves are zero.
+func Dissimilarities(n int, diss func(i, j int) float64) *mat64.SymDense {
+	out := mat64.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			out.SetSym(i, j, diss(i, j))
+		}
+	}
+	return out
+}
+
+// DTW returns dynamic time warping distance of time series a and b, which may have different
+// lengths: the smallest sum of absolute differences of aligned values over all monotonic
+// alignments of the series. It returns +Inf if exactly one of the series is empty.
+func DTW(a, b []float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		if len(a) == len(b) {
+			return 0.0
+		}
+		return math.Inf(1)
+	}
+	prev, cur := make([]float64, len(b)+1), make([]float64, len(b)+1)
+	for j := 1; j <= len(b); j++ {
+		prev[j] = math.Inf(1)
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = math.Inf(1)
+		for j := 1; j <= len(b); j++ {
+			cur[j] = math.Abs(a[i-1]-b[j-1]) + math.Min(prev[j-1], math.Min(prev[j], cur[j-1]))
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// newRelMap creates new relational SOM with grid configured by mc for training items
+// with squared dissimilarities sq. Every unit starts as one of randomly chosen training items.
+func newRelMap(sq *mat64.Dense, mc *MapConfig) (*RelMap, error) {
+	items, _ := sq.Dims()
+	if mc == nil || mc.Grid == nil {
+		return nil, fmt.Errorf("invalid map configuration: %v", mc)
+	}
+	if err := validateGridConfig(mc.Grid); err != nil {
+		return nil, err
+	}
+	grid, err := NewGrid(mc.Grid)
+	if err != nil {
+		return nil, err
+	}
+	units, _ := grid.coords.Dims()
+	m := &RelMap{
+		grid:    grid,
+		sq:      sq,
+		coeffs:  mat64.NewDense(units, items, nil),
+		dCoeffs: mat64.NewDense(units, items, nil),
+		self:    make([]float64, units),
+	}
+	// units start as randomly chosen training items
+	r := rand.New(rand.NewSource(55))
+	for k := 0; k < units; k++ {
+		m.coeffs.Set(k, r.Intn(items), 1.0)
+	}
+	m.updateProducts()
+	return m, nil
+}
+
+// relIters returns the default number of iterations of relational training on items:
+// sequential training runs 10 iterations per item, batch training runs 100 epochs
+func relIters(tc *TrainConfig, items int) int {
+	if tc != nil && tc.Algorithm == Seq {
+		return 10 * items
+	}
+	return 100
+}
+
+// Train runs relational SOM training on the training items for iters iterations using
+// training configuration tc: seq iterations pick random items, batch iterations are epochs.
+// It returns error if iters is not positive, if tc is invalid or if it uses neural gas training.
+func (m *RelMap) Train(tc *TrainConfig, iters int) error {
+	return m.train(tc, iters, trainRand(tc))
+}
+
+// train runs relational SOM training using random number generator r to pick random items
+func (m *RelMap) train(tc *TrainConfig, iters int, r *rand.Rand) error {
+	if iters <= 0 {
+		return fmt.Errorf("invalid number of iterations: %d", iters)
+	}
+	if err := validateTrainConfig(tc); err != nil {
+		return err
+	}
+	if tc.Algorithm != Seq && tc.Algorithm != Batch {
+		return fmt.Errorf("%w: %s, relational training supports seq and batch", ErrUnsupportedMethod, tc.Algorithm)
+	}
+	// derive the initial radius without modifying the supplied configuration
+	if tc.AutoRadius {
+		c := *tc
+		c.Radius = autoRadius(m.grid.Size())
+		tc = &c
+	}
+	index := m.grid.unitIndex(m.grid.unitDist())
+	log := trainLogger(tc)
+	log.Printf("som: relational %s training started: %d iterations, radius %.4f, learning rate %.4f", tc.Algorithm, iters, tc.Radius, tc.LRate)
+	if tc.Algorithm == Seq {
+		m.seqTrain(tc, index, iters, r)
+	} else {
+		m.batchTrain(tc, index, iters)
+	}
+	log.Printf("som: relational %s training finished: %d iterations", tc.Algorithm, iters)
+
+	return nil
+}
+
+// seqTrain moves units within the radius of BMU of a random item towards the item
+func (m *RelMap) seqTrain(tc *TrainConfig, index *unitIndex, iters int, r *rand.Rand) {
+	units, items := m.coeffs.Dims()
+	near := make([]int, 0, units)
+	log := trainLogger(tc)
+	for i := 0; i < iters; i++ {
+		logProgress(log, tc, i, iters)
+		item := r.Intn(items)
+		bmu, _ := m.itemBMU(item)
+		lRate, radius := tc.lRate(i, iters), tc.radius(i, iters)
+		dists := index.unitDist.RawRowView(bmu)
+		near = index.within(near[:0], bmu, radius)
+		for _, k := range near {
+			mul := lRate
+			if dists[k] > 0.0 {
+				mul *= tc.NeighbFn(dists[k], radius)
+			}
+			m.moveUnit(k, item, mul)
+		}
+	}
+}
+
+// batchTrain sets unit coefficients to neighbourhood weighted hits of training items in every epoch
+func (m *RelMap) batchTrain(tc *TrainConfig, index *unitIndex, iters int) {
+	units, items := m.coeffs.Dims()
+	// every unit sums indicator vectors of the items it is BMU of
+	sums := mat64.NewDense(units, items, nil)
+	counts := make([]float64, units)
+	vecs := mat64.NewDense(units, items, nil)
+	weights := make([]float64, units)
+	log := trainLogger(tc)
+	for i := 0; i < iters; i++ {
+		logProgress(log, tc, i, iters)
+		resetNghbSums(sums, counts)
+		for item := 0; item < items; item++ {
+			bmu, _ := m.itemBMU(item)
+			sums.Set(bmu, item, 1.0)
+			counts[bmu]++
+		}
+		radius := tc.radius(i, iters)
+		nghbApply(index, radius, tc.NeighbFn, sums, counts, vecs, weights)
+		for k, weight := range weights {
+			// keep coefficients of units with no items in their neighbourhood
+			if weight == 0.0 {
+				continue
+			}
+			coeffs := m.coeffs.RawRowView(k)
+			copy(coeffs, vecs.RawRowView(k))
+			floats.Scale(1/weight, coeffs)
+		}
+		m.updateProducts()
+	}
+}
+
+// updateProducts recomputes products of squared dissimilarities and unit coefficients
+func (m *RelMap) updateProducts() {
+	m.dCoeffs.Mul(m.coeffs, m.sq)
+	for k := range m.self {
+		m.self[k] = 0.5 * floats.Dot(m.coeffs.RawRowView(k), m.dCoeffs.RawRowView(k))
+	}
+}
+
+// moveUnit moves unit k towards training item by step mul. The products of squared
+// dissimilarities and unit coefficients are updated without a full recomputation.
+func (m *RelMap) moveUnit(k, item int, mul float64) {
+	coeffs, dCoeffs := m.coeffs.RawRowView(k), m.dCoeffs.RawRowView(k)
+	// item has zero dissimilarity to itself, so a'*D*a/2 of the new coefficients
+	// (1-mul)*a + mul*e_item only depends on the old value and (D*a)_item
+	m.self[k] = (1-mul)*(1-mul)*m.self[k] + mul*(1-mul)*dCoeffs[item]
+	floats.Scale(1-mul, coeffs)
+	coeffs[item] += mul
+	floats.Scale(1-mul, dCoeffs)
+	floats.AddScaled(dCoeffs, mul, m.sq.RawRowView(item))
+}
+
+// itemBMU returns BMU of training item and their squared dissimilarity
+func (m RelMap) itemBMU(item int) (int, float64) {
+	bmu, best := -1, math.Inf(1)
+	for k, self := range m.self {
+		if d := m.dCoeffs.At(k, item) - self; d < best {
+			bmu, best = k, d
+		}
+	}
+	return bmu, best
+}
+
+// Grid returns SOM grid
+func (m RelMap) Grid() *Grid {
+	return m.grid
+}
+
+// Coeffs returns a copy of the matrix which contains unit coefficient vectors:
+// one row per map unit and one column per training item.
+func (m RelMap) Coeffs() *mat64.Dense {
+	return mat64.DenseCopyOf(m.coeffs)
+}
+
+// BMU returns the index of Best Match Unit of an item with dissimilarities diss to the training
+// items and the dissimilarity of the item and the unit. Negative squared dissimilarities, which
+// can occur for non-Euclidean data, are reported as zero dissimilarity.
+// It returns ErrDimMismatch if the length of diss is different from the number of training items.
+// When the error is returned, both the index and dissimilarity are set to -1.
+func (m RelMap) BMU(diss []float64) (int, float64, error) {
+	_, items := m.coeffs.Dims()
+	if len(diss) != items {
+		return -1, -1.0, ErrDimMismatch
+	}
+	sq := make([]float64, items)
+	for j, d := range diss {
+		sq[j] = d * d
+	}
+	bmu, d := m.bmuSq(sq)
+	return bmu, d, nil
+}
+
+// bmuSq returns BMU of an item with squared dissimilarities sq to the training items
+// and the dissimilarity of the item and the unit
+func (m RelMap) bmuSq(sq []float64) (int, float64) {
+	bmu, best := -1, math.Inf(1)
+	for k, self := range m.self {
+		if d := floats.Dot(m.coeffs.RawRowView(k), sq) - self; d < best {
+			bmu, best = k, d
+		}
+	}
+	return bmu, math.Sqrt(math.Max(best, 0.0))
+}
+
+// BMUs returns BMUs of items whose dissimilarities to the training items are stored in diss rows.
+// The training dissimilarity matrix returns BMUs of the training items.
+// It returns error if diss is nil or ErrDimMismatch if diss has wrong number of columns.
+func (m RelMap) BMUs(diss mat64.Matrix) ([]int, error) {
+	if isNilMatrix(diss) {
+		return nil, fmt.Errorf("invalid dissimilarities supplied: %v", diss)
+	}
+	rows, _ := diss.Dims()
+	rd := newRowReader(diss)
+	bmus := make([]int, rows)
+	for i := range bmus {
+		bmu, _, err := m.BMU(rd.row(i))
+		if err != nil {
+			return nil, err
+		}
+		bmus[i] = bmu
+	}
+	return bmus, nil
+}
+
+// HitMap returns the number of items which have every map unit as their BMU.
+// The items are given by their dissimilarities to the training items stored in diss rows.
+// It fails in the same way as BMUs.
+func (m RelMap) HitMap(diss mat64.Matrix) ([]int, error) {
+	bmus, err := m.BMUs(diss)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]int, len(m.self))
+	for _, bmu := range bmus {
+		hits[bmu]++
+	}
+	return hits, nil
+}
+
+// UnitDiss returns a matrix which contains dissimilarities of unit prototypes computed
+// by the relational formula. Negative squared dissimilarities are reported as zero.
+func (m RelMap) UnitDiss() *mat64.Dense {
+	units := len(m.self)
+	diss := mat64.NewDense(units, units, nil)
+	for k := 0; k < units; k++ {
+		for l := k + 1; l < units; l++ {
+			d := floats.Dot(m.coeffs.RawRowView(k), m.dCoeffs.RawRowView(l)) - m.self[k] - m.self[l]
+			d = math.Sqrt(math.Max(d, 0.0))
+			diss.Set(k, l, d)
+			diss.Set(l, k, d)
+		}
+	}
+	return diss
+}
+
+// UMatrixValues returns u-matrix of the map: the average dissimilarity of every unit prototype
+// from the prototypes of its neighbouring grid units.
+// It returns error if the grid distances could not be computed.
+func (m RelMap) UMatrixValues() ([]float64, error) {
+	return umatrixDist(m.UnitDiss(), m.grid)
+}
+
+// UMatrix writes SVG u-matrix of the map with the given title to w.
+// It fails with error if the u-matrix could not be computed, ErrInvalidDims if the grid
+// is not 2D or ErrUnsupportedGrid if the grid is spherical.
+func (m RelMap) UMatrix(w io.Writer, title string) error {
+	if len(m.grid.size) != 2 {
+		return fmt.Errorf("%w: %v, u-matrix SVG supports 2 dimensions", ErrInvalidDims, m.grid.size)
+	}
+	if m.grid.radius > 0 {
+		return fmt.Errorf("%w: %s, u-matrix SVG supports planar grids", ErrUnsupportedGrid, m.grid.gtype)
+	}
+	umatrix, err := m.UMatrixValues()
+	if err != nil {
+		return err
+	}
+	return umatrixSVG(umatrix, m.grid.coords, m.grid.size, m.grid.ushape, title, w, nil)
+}
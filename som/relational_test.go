@@ -0,0 +1,274 @@
+package som
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// euclideanDiss returns the matrix of Euclidean distances of data rows
+func euclideanDiss(data *mat64.Dense) *mat64.SymDense {
+	rows, _ := data.Dims()
+	diss := mat64.NewSymDense(rows, nil)
+	for i := 0; i < rows; i++ {
+		for j := i + 1; j < rows; j++ {
+			diss.SetSym(i, j, euclideanVec(data.RawRowView(i), data.RawRowView(j)))
+		}
+	}
+	return diss
+}
+
+// relMapConfig returns relational SOM configuration with rows x cols rectangle grid
+func relMapConfig(rows, cols int) *MapConfig {
+	return &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{rows, cols},
+			Type:   Planar,
+			UShape: Rectangle,
+		},
+	}
+}
+
+func TestTrainRelationalEuclidean(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(60, 3, 3, 10.0, 0.0, 0.5, 7)
+	diss := euclideanDiss(data)
+	for _, alg := range []Method{Seq, Batch} {
+		tc := &TrainConfig{
+			Algorithm: alg,
+			Radius:    2.0,
+			RDecay:    LinDecay,
+			NeighbFn:  Gaussian,
+			LRate:     0.5,
+			LDecay:    LinDecay,
+		}
+		m, err := TrainRelational(diss, relMapConfig(3, 3), tc)
+		assert.NoError(err)
+		coeffs := m.Coeffs()
+		units, items := coeffs.Dims()
+		assert.Equal(9, units)
+		assert.Equal(60, items)
+		// prototypes of Euclidean data are the coefficient combinations of data vectors
+		protos := new(mat64.Dense)
+		protos.Mul(coeffs, data)
+		for k := 0; k < units; k++ {
+			assert.InDelta(1.0, floats.Sum(coeffs.RawRowView(k)), 1e-9, "%s", alg)
+		}
+		for i := 0; i < items; i++ {
+			bmu, dist, err := m.BMU(mat64.Row(nil, i, diss))
+			assert.NoError(err)
+			want, err := ClosestVec("euclidean", data.RawRowView(i), protos)
+			assert.NoError(err)
+			wantDist := euclideanVec(data.RawRowView(i), protos.RawRowView(want))
+			assert.InDelta(wantDist, dist, 1e-6, "%s", alg)
+			// ties may pick a different unit at the same distance
+			assert.InDelta(wantDist, euclideanVec(data.RawRowView(i), protos.RawRowView(bmu)), 1e-6, "%s", alg)
+		}
+		unitDiss := m.UnitDiss()
+		wantDiss, err := DistanceMx("euclidean", protos)
+		assert.NoError(err)
+		for k := 0; k < units; k++ {
+			for l := 0; l < units; l++ {
+				assert.InDelta(wantDiss.At(k, l), unitDiss.At(k, l), 1e-6)
+			}
+		}
+	}
+}
+
+// levenshtein returns edit distance of strings a and b
+func levenshtein(a, b string) float64 {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return float64(prev[len(b)])
+}
+
+// mutate returns s with n random characters replaced by letters of alphabet
+func mutate(s, alphabet string, n int, r *rand.Rand) string {
+	b := []byte(s)
+	for i := 0; i < n; i++ {
+		b[r.Intn(len(b))] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+func TestTrainRelationalEditDistance(t *testing.T) {
+	assert := assert.New(t)
+
+	r := rand.New(rand.NewSource(1))
+	var seqs []string
+	for i := 0; i < 20; i++ {
+		seqs = append(seqs, mutate("aaaaaaaaaa", "abcd", 2, r), mutate("cdcdcdcdcd", "abcd", 2, r))
+	}
+	diss := mat64.NewSymDense(len(seqs), nil)
+	for i := range seqs {
+		for j := i + 1; j < len(seqs); j++ {
+			diss.SetSym(i, j, levenshtein(seqs[i], seqs[j]))
+		}
+	}
+	tc := &TrainConfig{
+		Algorithm: Batch,
+		Radius:    2.0,
+		RDecay:    LinDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    LinDecay,
+	}
+	m, err := TrainRelational(diss, relMapConfig(2, 3), tc)
+	assert.NoError(err)
+	bmus, err := m.BMUs(diss)
+	assert.NoError(err)
+	// sequences of different families have different BMUs
+	for i := 0; i < len(seqs); i += 2 {
+		for j := 1; j < len(seqs); j += 2 {
+			assert.NotEqual(bmus[i], bmus[j])
+		}
+	}
+	hits, err := m.HitMap(diss)
+	assert.NoError(err)
+	assert.Len(hits, 6)
+	total := 0
+	for _, h := range hits {
+		total += h
+	}
+	assert.Equal(len(seqs), total)
+	// new sequence is mapped by its dissimilarities to the training sequences
+	seq := mutate("aaaaaaaaaa", "abcd", 1, r)
+	newDiss := make([]float64, len(seqs))
+	for i := range seqs {
+		newDiss[i] = levenshtein(seq, seqs[i])
+	}
+	bmu, _, err := m.BMU(newDiss)
+	assert.NoError(err)
+	family := make(map[int]bool)
+	for i := 0; i < len(seqs); i += 2 {
+		family[bmus[i]] = true
+	}
+	assert.True(family[bmu])
+	// u-matrix
+	umatrix, err := m.UMatrixValues()
+	assert.NoError(err)
+	assert.Len(umatrix, 6)
+	for _, v := range umatrix {
+		assert.False(math.IsNaN(v))
+		assert.True(v >= 0.0)
+	}
+	var b bytes.Buffer
+	assert.NoError(m.UMatrix(&b, "relational"))
+	assert.Equal(6, bytes.Count(b.Bytes(), []byte("<polygon")))
+}
+
+func TestDTW(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(0.0, DTW([]float64{1, 2, 3}, []float64{1, 2, 2, 3}))
+	assert.Equal(1.0, DTW([]float64{1, 2, 3}, []float64{1, 2, 4}))
+	assert.Equal(3.0, DTW([]float64{0}, []float64{1, 1, 1}))
+	assert.Equal(0.0, DTW(nil, nil))
+	assert.True(math.IsInf(DTW(nil, []float64{1}), 1))
+}
+
+func TestTrainRelationalDTW(t *testing.T) {
+	assert := assert.New(t)
+
+	// time series of two shapes with different lengths and phases
+	r := rand.New(rand.NewSource(1))
+	var series [][]float64
+	for i := 0; i < 30; i++ {
+		n, phase := 20+r.Intn(10), r.Float64()
+		sine, ramp := make([]float64, n), make([]float64, n)
+		for k := range sine {
+			sine[k] = math.Sin(2*math.Pi*float64(k)/float64(n) + phase)
+			ramp[k] = 2*float64(k)/float64(n) - 1
+		}
+		series = append(series, sine, ramp)
+	}
+	diss := Dissimilarities(len(series), func(i, j int) float64 {
+		return DTW(series[i], series[j])
+	})
+	assert.Equal(len(series), diss.Symmetric())
+	assert.Equal(0.0, diss.At(3, 3))
+	assert.Equal(DTW(series[1], series[4]), diss.At(4, 1))
+	tc := &TrainConfig{
+		Algorithm: Batch,
+		Radius:    2.0,
+		RDecay:    LinDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    LinDecay,
+	}
+	m, err := TrainRelational(diss, relMapConfig(2, 3), tc)
+	assert.NoError(err)
+	bmus, err := m.BMUs(diss)
+	assert.NoError(err)
+	// series of different shapes have different BMUs
+	for i := 0; i < len(series); i += 2 {
+		for j := 1; j < len(series); j += 2 {
+			assert.NotEqual(bmus[i], bmus[j])
+		}
+	}
+}
+
+func TestTrainRelationalErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	tc := &TrainConfig{
+		Algorithm: Seq,
+		Radius:    1.0,
+		RDecay:    LinDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    LinDecay,
+	}
+	mc := relMapConfig(2, 2)
+	_, err := TrainRelational(nil, mc, tc)
+	assert.EqualError(err, "invalid dissimilarities supplied: <nil>")
+	_, err = TrainRelational(mat64.NewSymDense(1, nil), mc, tc)
+	assert.EqualError(err, "invalid number of items: 1")
+	diss := mat64.NewSymDense(3, []float64{0, 1, 2, 1, 0, 1, 2, 1, 0})
+	diss.SetSym(0, 2, -1.0)
+	_, err = TrainRelational(diss, mc, tc)
+	assert.EqualError(err, "invalid dissimilarity of items 0 and 2: -1.000000")
+	diss.SetSym(0, 2, 2.0)
+	diss.SetSym(1, 1, 0.5)
+	_, err = TrainRelational(diss, mc, tc)
+	assert.EqualError(err, "invalid dissimilarity of item 1 to itself: 0.500000")
+	diss.SetSym(1, 1, 0.0)
+	_, err = TrainRelational(diss, nil, tc)
+	assert.EqualError(err, "invalid map configuration: <nil>")
+	_, err = TrainRelational(diss, relMapConfig(1, 1), tc)
+	assert.Error(err)
+	ng := *tc
+	ng.Algorithm = NeuralGas
+	_, err = TrainRelational(diss, mc, &ng)
+	assert.EqualError(err, "invalid SOM training algorithm: neuralgas, relational training supports seq and batch")
+	m, err := TrainRelational(diss, mc, tc)
+	assert.NoError(err)
+	assert.EqualError(m.Train(tc, 0), "invalid number of iterations: 0")
+	bmu, dist, err := m.BMU([]float64{1, 2})
+	assert.Equal(ErrDimMismatch, err)
+	assert.Equal(-1, bmu)
+	assert.Equal(-1.0, dist)
+	_, err = m.HitMap(mat64.NewDense(2, 2, nil))
+	assert.Equal(ErrDimMismatch, err)
+}
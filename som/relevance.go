@@ -0,0 +1,169 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// FeatureRelevance returns a copy of feature relevances learned by TrainLVQ with positive Relevance
+// rate or by TrainRelevance. Learned relevances are non-negative and sum to 1. Maps created with
+// CbConfig.FeatureWeights have the weights as their relevances until they learn others. BMU searches,
+// classification and prediction of maps with relevances use euclidean distance with features weighted
+// by their relevances. It returns nil if the map has no feature relevances.
+func (m Map) FeatureRelevance() []float64 {
+	if m.relevance == nil {
+		return nil
+	}
+	return append([]float64(nil), m.relevance...)
+}
+
+// TrainRelevance trains the map on data by tc in rounds of iters iterations and adapts feature
+// relevances after every round to minimise the weighted quantization error: the relevance of every
+// feature is inversely proportional to the fraction of the feature variance which the map does not
+// explain, i.e. the mean squared difference between the feature values of data samples and their BMU
+// codebook vectors divided by the feature variance. This minimises the weighted quantization error
+// of standardised features when the product of relevances is fixed. Relevances are normalised to
+// sum to 1 and the next round searches BMUs using them, so features without cluster structure,
+// such as noise, lose their relevance to the features the map represents well.
+// The first round starts with the current map relevances or with equal relevances of all features.
+// TrainRelevance returns error if rounds is not a positive integer or if any training round fails.
+func (m *Map) TrainRelevance(tc *TrainConfig, data mat64.Matrix, iters, rounds int) error {
+	return m.trainRelevance(tc, data, iters, rounds, trainRand(tc))
+}
+
+// trainRelevance trains the map and its feature relevances using r as the source of randomness of training
+func (m *Map) trainRelevance(tc *TrainConfig, data mat64.Matrix, iters, rounds int, r *rand.Rand) error {
+	if rounds <= 0 {
+		return fmt.Errorf("invalid number of rounds: %d", rounds)
+	}
+	_, cols := m.cbDims()
+	if m.relevance == nil {
+		m.relevance = uniformRelevance(cols)
+	}
+	for round := 0; round < rounds; round++ {
+		if _, err := m.train(tc, data, iters, r); err != nil {
+			return err
+		}
+		bmus, err := m.BMUs(data)
+		if err != nil {
+			return err
+		}
+		// variance and squared quantization error of every feature
+		rows, _ := data.Dims()
+		means, vars, errs := make([]float64, cols), make([]float64, cols), make([]float64, cols)
+		rd := newRowReader(data)
+		for i := 0; i < rows; i++ {
+			for j, v := range rd.row(i) {
+				means[j] += v / float64(rows)
+			}
+		}
+		cb := m.cb()
+		for i, bmu := range bmus {
+			vec := cb.RawRowView(bmu)
+			for j, v := range rd.row(i) {
+				vars[j] += (v - means[j]) * (v - means[j])
+				errs[j] += (v - vec[j]) * (v - vec[j])
+			}
+		}
+		for j := range m.relevance {
+			m.relevance[j] = vars[j] / math.Max(errs[j], minRelevanceError)
+		}
+		normaliseRelevance(m.relevance)
+	}
+	return nil
+}
+
+// minRelevanceError is the smallest squared feature quantization error of relevance adaptation
+const minRelevanceError = 1e-12
+
+// uniformRelevance returns equal relevances of dim features
+func uniformRelevance(dim int) []float64 {
+	relevance := make([]float64, dim)
+	for j := range relevance {
+		relevance[j] = 1 / float64(dim)
+	}
+	return relevance
+}
+
+// normaliseRelevance clips negative relevances at zero and scales them to sum to 1.
+// If no relevance is positive, all features get equal relevances.
+func normaliseRelevance(relevance []float64) {
+	sum := 0.0
+	for j, v := range relevance {
+		if v < 0 {
+			relevance[j] = 0
+		}
+		sum += relevance[j]
+	}
+	if sum == 0 {
+		copy(relevance, uniformRelevance(len(relevance)))
+		return
+	}
+	for j := range relevance {
+		relevance[j] /= sum
+	}
+}
+
+// featureWeights returns a copy of feature weights of codebook configuration c
+// or nil if features are not weighted
+func featureWeights(c *CbConfig) []float64 {
+	if c.FeatureWeights == nil {
+		return nil
+	}
+	return append([]float64(nil), c.FeatureWeights...)
+}
+
+// relevanceDistance returns euclidean distance between vector x and codebook vector of unit i
+// with features weighted by map relevances
+func (m Map) relevanceDistance(x []float64, i int) float64 {
+	d := 0.0
+	if m.cb32 != nil {
+		for j, v := range m.cb32.rowView(i) {
+			diff := x[j] - float64(v)
+			d += m.relevance[j] * diff * diff
+		}
+		return math.Sqrt(d)
+	}
+	for j, v := range m.codebook.RawRowView(i) {
+		d += m.relevance[j] * (x[j] - v) * (x[j] - v)
+	}
+	return math.Sqrt(d)
+}
+
+// grlvqStep adapts map relevances by a generalized relevance LVQ gradient step of size rate for
+// sample x of class label: the relevance of every feature grows with the squared feature difference to
+// the closest prototype of another class and shrinks with the squared feature difference to the closest
+// prototype of the sample class, so features which separate the classes at the BMU gain relevance.
+// The relevances are normalised afterwards. It does nothing if either prototype does not exist.
+func (m *Map) grlvqStep(x []float64, label string, protoLabels []string, rate float64) {
+	same, other := -1, -1
+	dSame, dOther := math.Inf(1), math.Inf(1)
+	for i, l := range protoLabels {
+		d := m.unitDistance(x, i)
+		if l == label && d < dSame {
+			same, dSame = i, d
+		} else if l != label && d < dOther {
+			other, dOther = i, d
+		}
+	}
+	if same < 0 || other < 0 {
+		return
+	}
+	// the cost of the sample is (dSame-dOther)/(dSame+dOther) of squared distances
+	dSame, dOther = dSame*dSame, dOther*dOther
+	denom := (dSame + dOther) * (dSame + dOther)
+	if denom == 0 {
+		return
+	}
+	cb := m.cb()
+	sameVec, otherVec := cb.RawRowView(same), cb.RawRowView(other)
+	for j, v := range x {
+		gSame := 2 * dOther / denom * (v - sameVec[j]) * (v - sameVec[j])
+		gOther := 2 * dSame / denom * (v - otherVec[j]) * (v - otherVec[j])
+		m.relevance[j] -= rate * (gSame - gOther)
+	}
+	normaliseRelevance(m.relevance)
+}
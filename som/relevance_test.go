@@ -0,0 +1,217 @@
+package som
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeRelevant generates n samples of two classes with 10 features: the classes differ
+// in the first two features only, the remaining features are uniformly distributed noise
+func makeRelevant(n int, seed int64) (*mat64.Dense, []string) {
+	r := rand.New(rand.NewSource(seed))
+	data := mat64.NewDense(n, 10, nil)
+	labels := make([]string, n)
+	for i := 0; i < n; i++ {
+		labels[i] = "a"
+		c := -0.5
+		if i%2 == 1 {
+			labels[i], c = "b", 0.5
+		}
+		data.Set(i, 0, c+r.NormFloat64()*0.3)
+		data.Set(i, 1, c+r.NormFloat64()*0.3)
+		for j := 2; j < 10; j++ {
+			data.Set(i, j, 6*r.Float64()-3)
+		}
+	}
+	return data, labels
+}
+
+// makeRelevantBlobs generates n samples with 10 features: the first two features form four tight
+// blobs, the remaining features are uniformly distributed noise without cluster structure
+func makeRelevantBlobs(n int, seed int64) *mat64.Dense {
+	r := rand.New(rand.NewSource(seed))
+	data := mat64.NewDense(n, 10, nil)
+	for i := 0; i < n; i++ {
+		data.Set(i, 0, float64(2*(i%2)-1)+r.NormFloat64()*0.1)
+		data.Set(i, 1, float64(2*(i/2%2)-1)+r.NormFloat64()*0.1)
+		for j := 2; j < 10; j++ {
+			data.Set(i, j, 2*r.Float64()-1)
+		}
+	}
+	return data
+}
+
+func TestTrainLVQRelevance(t *testing.T) {
+	assert := assert.New(t)
+
+	data, labels := makeRelevant(400, 1)
+	test, truth := makeRelevant(400, 2)
+	cfg := LVQConfig{Prototypes: 3, Iters: 4000, Seed: 1}
+	plain, err := TrainLVQ(data, labels, cfg)
+	assert.NoError(err)
+	assert.Nil(plain.FeatureRelevance())
+	cfg.Relevance = 0.05
+	m, err := TrainLVQ(data, labels, cfg)
+	assert.NoError(err)
+	relevance := m.FeatureRelevance()
+	assert.Len(relevance, 10)
+	sum := 0.0
+	for j, v := range relevance {
+		sum += v
+		if j >= 2 {
+			assert.True(v < 0.01, "noise feature %d relevance: %f", j, v)
+		}
+	}
+	assert.InDelta(1.0, sum, 1e-9)
+	plainAcc, acc := accuracy(t, plain, test, truth), accuracy(t, m, test, truth)
+	assert.True(acc > 0.95, "relevance accuracy: %f", acc)
+	assert.True(acc > plainAcc+0.05, "relevance accuracy: %f, plain accuracy: %f", acc, plainAcc)
+	// returned relevances are copies
+	relevance[0] = 10.0
+	assert.NotEqual(10.0, m.FeatureRelevance()[0])
+}
+
+func TestTrainRelevance(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeRelevantBlobs(400, 1)
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{4, 4},
+			Type:   Planar,
+			UShape: Hexagon,
+		},
+		Cb: &CbConfig{
+			Dim:      10,
+			InitFunc: LinInit,
+		},
+	}
+	tc := &TrainConfig{
+		Algorithm: Seq,
+		Radius:    3.0,
+		RDecay:    ExpDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    ExpDecay,
+	}
+	m, err := NewMap(mc, data)
+	assert.NoError(err)
+	assert.NoError(m.trainRelevance(tc, data, 4000, 5, rand.New(rand.NewSource(1))))
+	relevance := m.FeatureRelevance()
+	for j := 2; j < 10; j++ {
+		assert.True(relevance[j] < 0.02, "noise feature %d relevance: %f", j, relevance[j])
+	}
+
+	// BMU searches use the learned relevances
+	x := data.RawRowView(0)
+	bmu, dist, err := m.BMU(x)
+	assert.NoError(err)
+	units, dists, err := m.KBMU(x, 2)
+	assert.NoError(err)
+	assert.Equal(bmu, units[0])
+	assert.Equal(dist, dists[0])
+	assert.Equal(m.relevanceDistance(x, bmu), dist)
+	bmus, _, err := m.Predict(data, 2)
+	assert.NoError(err)
+	expected, err := m.BMUs(data)
+	assert.NoError(err)
+	assert.Equal(expected, bmus)
+	sparse, sparseDist, err := m.BMUSparse([]int{0, 1}, x[:2])
+	assert.NoError(err)
+	dense := make([]float64, 10)
+	copy(dense, x[:2])
+	bmu, dist, _ = m.BMU(dense)
+	assert.Equal(bmu, sparse)
+	assert.Equal(dist, sparseDist)
+	// quality measures use the learned relevances
+	var qe, te float64
+	uDistMx, _ := m.UnitDist()
+	rows, _ := data.Dims()
+	for i := 0; i < rows; i++ {
+		units, dists, _ := m.KBMU(data.RawRowView(i), 2)
+		qe += dists[0]
+		if !adjacent(uDistMx.At(units[0], units[1]), math.Sqrt2) {
+			te++
+		}
+	}
+	mqe, err := m.QuantError(data)
+	assert.NoError(err)
+	assert.InDelta(qe/float64(rows), mqe, 1e-9)
+	plain, err := QuantError(data, m.cb())
+	assert.NoError(err)
+	assert.NotEqual(plain, mqe)
+	mte, err := m.TopoError(data)
+	assert.NoError(err)
+	assert.InDelta(te/float64(rows), mte, 1e-9)
+
+	assert.EqualError(m.TrainRelevance(tc, data, 100, 0), "invalid number of rounds: 0")
+	assert.Equal(ErrDimMismatch, m.TrainRelevance(tc, mat64.NewDense(2, 3, nil), 100, 1))
+}
+
+func TestNormaliseRelevance(t *testing.T) {
+	assert := assert.New(t)
+
+	relevance := []float64{-1.0, 1.0, 3.0}
+	normaliseRelevance(relevance)
+	assert.Equal([]float64{0.0, 0.25, 0.75}, relevance)
+	relevance = []float64{-1.0, 0.0}
+	normaliseRelevance(relevance)
+	assert.Equal([]float64{0.5, 0.5}, relevance)
+}
+
+func TestFeatureWeights(t *testing.T) {
+	assert := assert.New(t)
+
+	// the second feature is passive noise
+	r := rand.New(rand.NewSource(1))
+	data := mat64.NewDense(200, 2, nil)
+	for i := 0; i < 200; i++ {
+		data.Set(i, 0, float64(i%4))
+		data.Set(i, 1, 100*r.Float64())
+	}
+	for _, precision := range []string{"", "float32"} {
+		mCfg := makePrecisionMapCfg([]int{4, 3}, 2, precision)
+		mCfg.Cb.FeatureWeights = []float64{1.0, 0.0}
+		m, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		assert.Equal([]float64{1.0, 0.0}, m.FeatureRelevance())
+		before := m.Codebook()
+		_, err = m.train(gsomTrainConfig(), data, 2000, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		// passive features are trained but don't influence BMUs
+		assert.NotEqual(mat64.Col(nil, 1, before), mat64.Col(nil, 1, m.Codebook()))
+		for i := 0; i < 20; i++ {
+			x := data.RawRowView(i)
+			bmu, _, err := m.BMU(x)
+			assert.NoError(err)
+			shifted, _, err := m.BMU([]float64{x[0], x[1] + 1000})
+			assert.NoError(err)
+			assert.Equal(bmu, shifted)
+		}
+		bmus, _, err := m.Predict(data, 0)
+		assert.NoError(err)
+		for i := 0; i < 20; i++ {
+			bmu, _, _ := m.BMU(data.RawRowView(i))
+			assert.Equal(bmu, bmus[i])
+		}
+	}
+	// the configuration weights are copied
+	mCfg := makePrecisionMapCfg([]int{4, 3}, 2, "")
+	mCfg.Cb.FeatureWeights = []float64{1.0, 0.0}
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	mCfg.Cb.FeatureWeights[1] = 5.0
+	assert.Equal([]float64{1.0, 0.0}, m.FeatureRelevance())
+	// maps created with options
+	m, err = NewMapWithOptions(data, WithDims(4, 3), WithFeatureWeights(1.0, 0.5))
+	assert.NoError(err)
+	assert.Equal([]float64{1.0, 0.5}, m.FeatureRelevance())
+	_, err = NewMapWithOptions(data, WithDims(4, 3), WithFeatureWeights(1.0))
+	assert.Error(err)
+	_, err = NewMapWithOptions(data, WithDims(4, 3), WithFeatureWeights())
+	assert.Error(err)
+}
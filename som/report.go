@@ -0,0 +1,134 @@
+package som
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/dataset"
+)
+
+// SampleReport holds SOM diagnostics for a single data sample
+type SampleReport struct {
+	// Row is the index of the sample in data matrix
+	Row int
+	// BMU is the index of the sample Best Matching Unit
+	BMU int
+	// Coords holds BMU grid coordinates
+	Coords []float64
+	// QuantError is the distance between the sample and its BMU
+	QuantError float64
+	// SecondBMU is the index of the second Best Matching Unit
+	SecondBMU int
+	// Adjacent is true if BMU and SecondBMU are neighbours on the grid
+	Adjacent bool
+}
+
+// Explain computes per-sample diagnostic report for every data row.
+// Each report contains the sample BMU, its grid coordinates, quantization error,
+// second BMU and whether both BMUs are adjacent on the grid. Averaging reports'
+// QuantError gives map quantization error; the fraction of non-adjacent reports
+// gives map topographic error.
+// Data rows with missing values, which are stored as NaN, are compared on their observed features.
+// It returns error if data is nil, if its dimension does not match map codebook, if any data row
+// has no observed values or ErrNoTopology if the map has no topology.
+func (m Map) Explain(data *mat64.Dense) ([]SampleReport, error) {
+	if m.noTopology {
+		return nil, ErrNoTopology
+	}
+	// data can't be nil
+	if data == nil {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	// every data row must have an observed value
+	if _, err := missingValues(data); err != nil {
+		return nil, err
+	}
+	// unit distance matrix -- no need to check for error
+	uDistMx, _ := m.UnitDist()
+	rows, _ := data.Dims()
+	reports := make([]SampleReport, rows)
+	for i := 0; i < rows; i++ {
+		sample := data.RawRowView(i)
+		// map grid always has at least 2 units
+		closest, dists, err := m.KBMU(sample, 2)
+		if err != nil {
+			return nil, err
+		}
+		bmu, second := closest[0], closest[1]
+		reports[i] = SampleReport{
+			Row:        i,
+			BMU:        bmu,
+			Coords:     mat64.Row(nil, bmu, m.grid.coords),
+			QuantError: dists[0],
+			SecondBMU:  second,
+			Adjacent:   adjacent(uDistMx.At(bmu, second), m.grid.neighbRadius()),
+		}
+	}
+
+	return reports, nil
+}
+
+// WriteReportCSV writes sample reports to w in CSV format with a header row.
+// If ds is not nil and has row labels, label of each sample is written in a separate column.
+// If ds has class information, class of each sample is written in a separate column too;
+// samples which have no class information are assigned -1 class.
+// It returns error if the write to w fails.
+func WriteReportCSV(w io.Writer, reports []SampleReport, ds *dataset.DataSet) error {
+	var labels []string
+	var classes map[int]int
+	if ds != nil {
+		labels, classes = ds.Labels, ds.Classes
+	}
+	csvWriter := csv.NewWriter(w)
+	header := []string{"row"}
+	if len(labels) > 0 {
+		header = append(header, "label")
+	}
+	if len(classes) > 0 {
+		header = append(header, "class")
+	}
+	header = append(header, "bmu")
+	if len(reports) > 0 {
+		for i := range reports[0].Coords {
+			header = append(header, fmt.Sprintf("coord%d", i))
+		}
+	}
+	header = append(header, "qe", "bmu2", "adjacent")
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+	for _, r := range reports {
+		record := []string{strconv.Itoa(r.Row)}
+		if len(labels) > 0 {
+			label := ""
+			if r.Row < len(labels) {
+				label = labels[r.Row]
+			}
+			record = append(record, label)
+		}
+		if len(classes) > 0 {
+			class, ok := classes[r.Row]
+			if !ok {
+				class = -1
+			}
+			record = append(record, strconv.Itoa(class))
+		}
+		record = append(record, strconv.Itoa(r.BMU))
+		for _, c := range r.Coords {
+			record = append(record, strconv.FormatFloat(c, 'f', -1, 64))
+		}
+		record = append(record,
+			strconv.FormatFloat(r.QuantError, 'f', -1, 64),
+			strconv.Itoa(r.SecondBMU),
+			strconv.FormatBool(r.Adjacent))
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+
+	return csvWriter.Error()
+}
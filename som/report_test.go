@@ -0,0 +1,91 @@
+package som
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos83/gosom/pkg/dataset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NotNil(m)
+	assert.NoError(err)
+	// nil data returns error
+	errString := "invalid data supplied: %v"
+	reports, err := m.Explain(nil)
+	assert.Nil(reports)
+	assert.EqualError(err, fmt.Sprintf(errString, nil))
+	// train the map and compute the report
+	err = m.Train(tSom, dataMx, 100)
+	assert.NoError(err)
+	reports, err = m.Explain(dataMx)
+	assert.NoError(err)
+	rows, _ := dataMx.Dims()
+	assert.Len(reports, rows)
+	// report aggregates must match standalone quality measures
+	bmus, err := m.BMUs(dataMx)
+	assert.NoError(err)
+	var qe, te float64
+	for i, r := range reports {
+		assert.Equal(i, r.Row)
+		assert.Equal(bmus[i], r.BMU)
+		assert.NotEqual(r.BMU, r.SecondBMU)
+		assert.Len(r.Coords, len(mSom.Grid.Size))
+		qe += r.QuantError
+		if !r.Adjacent {
+			te++
+		}
+	}
+	mqe, err := m.QuantError(dataMx)
+	assert.NoError(err)
+	assert.InDelta(mqe, qe/float64(rows), 1e-9)
+	mte, err := m.TopoError(dataMx)
+	assert.NoError(err)
+	assert.InDelta(mte, te/float64(rows), 1e-9)
+}
+
+func TestWriteReportCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	reports := []SampleReport{
+		{Row: 0, BMU: 1, Coords: []float64{1.0, 0.0}, QuantError: 0.5, SecondBMU: 2, Adjacent: true},
+		{Row: 1, BMU: 3, Coords: []float64{0.5, 0.866}, QuantError: 1.25, SecondBMU: 5, Adjacent: false},
+	}
+	// no class information
+	var buf bytes.Buffer
+	err := WriteReportCSV(&buf, reports, nil)
+	assert.NoError(err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal([]string{
+		"row,bmu,coord0,coord1,qe,bmu2,adjacent",
+		"0,1,1,0,0.5,2,true",
+		"1,3,0.5,0.866,1.25,5,false",
+	}, lines)
+	// data set without class information
+	buf.Reset()
+	err = WriteReportCSV(&buf, reports, &dataset.DataSet{})
+	assert.NoError(err)
+	assert.Equal(lines, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+	// class information for one of the samples
+	buf.Reset()
+	err = WriteReportCSV(&buf, reports, &dataset.DataSet{Classes: map[int]int{1: 7}})
+	assert.NoError(err)
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal("row,class,bmu,coord0,coord1,qe,bmu2,adjacent", lines[0])
+	assert.Equal("0,-1,1,1,0,0.5,2,true", lines[1])
+	assert.Equal("1,7,3,0.5,0.866,1.25,5,false", lines[2])
+	// row labels and class information
+	buf.Reset()
+	err = WriteReportCSV(&buf, reports, &dataset.DataSet{Labels: []string{"a", "b"}, Classes: map[int]int{1: 7}})
+	assert.NoError(err)
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal("row,label,class,bmu,coord0,coord1,qe,bmu2,adjacent", lines[0])
+	assert.Equal("0,a,-1,1,1,0,0.5,2,true", lines[1])
+	assert.Equal("1,b,7,3,0.5,0.866,1.25,5,false", lines[2])
+}
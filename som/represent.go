@@ -0,0 +1,72 @@
+package som
+
+import (
+	"container/heap"
+	"fmt"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Representatives returns indices of at most n data rows closest to every map unit codebook vector.
+// For every unit only the samples which have the unit as their BMU are considered. If the unit
+// is not BMU of any sample, the globally nearest samples are returned and the unit is flagged
+// in the returned bool slice. Indices of each unit are sorted by their distance to the unit
+// codebook vector in ascending order.
+// It returns error if data is nil, n is not a positive integer, if any data row has no observed
+// values or ErrDimMismatch if data and codebook dimensions differ.
+func (m Map) Representatives(data *mat64.Dense, n int) ([][]int, []bool, error) {
+	// data can't be nil
+	if data == nil {
+		return nil, nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	if n <= 0 {
+		return nil, nil, fmt.Errorf("invalid number of representatives: %d", n)
+	}
+	rows, cols := data.Dims()
+	if _, cbCols := m.cbDims(); cols != cbCols {
+		return nil, nil, ErrDimMismatch
+	}
+	// every data row must have an observed value
+	if _, err := missingValues(data); err != nil {
+		return nil, nil, err
+	}
+	units, _ := m.cbDims()
+	// per-unit bounded heaps of the closest samples
+	heaps := make([]*float64Heap, units)
+	for i := 0; i < rows; i++ {
+		bmu, dist, _ := m.BMU(data.RawRowView(i))
+		if heaps[bmu] == nil {
+			// no need to check for error: n is positive
+			heaps[bmu], _ = newFloat64Heap(n)
+		}
+		heap.Push(heaps[bmu], &float64Item{val: dist, index: i})
+	}
+	// empty units fall back to globally nearest samples
+	empty := make([]bool, units)
+	var emptyUnits []int
+	for u, h := range heaps {
+		if h == nil {
+			empty[u] = true
+			emptyUnits = append(emptyUnits, u)
+			heaps[u], _ = newFloat64Heap(n)
+		}
+	}
+	if len(emptyUnits) > 0 {
+		for i := 0; i < rows; i++ {
+			sample := data.RawRowView(i)
+			for _, u := range emptyUnits {
+				d := m.unitDistance(sample, u)
+				heap.Push(heaps[u], &float64Item{val: d, index: i})
+			}
+		}
+	}
+	reps := make([][]int, units)
+	for u, h := range heaps {
+		reps[u] = make([]int, h.Len())
+		for j := h.Len() - 1; j >= 0; j-- {
+			reps[u][j] = heap.Pop(h).(*float64Item).index
+		}
+	}
+
+	return reps, empty, nil
+}
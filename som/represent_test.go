@@ -0,0 +1,73 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepresentatives(t *testing.T) {
+	assert := assert.New(t)
+
+	// 3x1 rectangle grid: units 0, 1, 2 lie on a line
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{3, 1}, Type: "planar", UShape: "rectangle"},
+		Cb:   &CbConfig{Dim: 1, InitFunc: RandInit},
+	}, mat64.NewDense(2, 1, []float64{0.0, 1.0}))
+	assert.NoError(err)
+	m.codebook = mat64.NewDense(3, 1, []float64{0.0, 10.0, 20.0})
+	data := mat64.NewDense(6, 1, []float64{1.0, -0.5, 0.2, 21.0, 19.0, 4.0})
+	// invalid parameters
+	errString := "invalid data supplied: %v"
+	reps, empty, err := m.Representatives(nil, 2)
+	assert.Nil(reps)
+	assert.Nil(empty)
+	assert.EqualError(err, fmt.Sprintf(errString, nil))
+	errString = "invalid number of representatives: %d"
+	reps, empty, err = m.Representatives(data, 0)
+	assert.EqualError(err, fmt.Sprintf(errString, 0))
+	reps, empty, err = m.Representatives(mat64.NewDense(2, 2, nil), 1)
+	assert.Equal(ErrDimMismatch, err)
+	// rows with no observed values can't be represented
+	reps, empty, err = m.Representatives(mat64.NewDense(2, 1, []float64{1.0, math.NaN()}), 1)
+	assert.EqualError(err, "invalid data row 1: no observed values")
+	// unit 1 is empty: it falls back to globally nearest samples
+	reps, empty, err = m.Representatives(data, 2)
+	assert.NoError(err)
+	assert.Equal([][]int{{2, 1}, {5, 0}, {3, 4}}, reps)
+	assert.Equal([]bool{false, true, false}, empty)
+	// units with fewer samples than requested
+	reps, empty, err = m.Representatives(data, 4)
+	assert.NoError(err)
+	assert.Equal([][]int{{2, 1, 0, 5}, {5, 0, 4, 2}, {3, 4}}, reps)
+	assert.Equal([]bool{false, true, false}, empty)
+}
+
+func BenchmarkRepresentatives(b *testing.B) {
+	data := utils.GenerateClusters(1000000, 20, 10, 10.0, -10.0, 2.0, 10)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{20, 20},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      20,
+			InitFunc: RandInit,
+		},
+	}
+	m, err := NewMap(mCfg, data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := m.Representatives(data, 5); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
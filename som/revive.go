@@ -0,0 +1,133 @@
+package som
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// DefaultReviveInterval is the default number of epochs between dead unit revivals
+const DefaultReviveInterval = 5
+
+// reviveEvery returns the number of training iterations between dead unit revivals of
+// training tc on rows data samples: seq iterations pick single samples, batch iterations are epochs.
+// It returns zero if dead units are not revived.
+func reviveEvery(tc *TrainConfig, rows int) int {
+	if !tc.ReviveDeadUnits {
+		return 0
+	}
+	every := tc.ReviveInterval
+	if every == 0 {
+		every = DefaultReviveInterval
+	}
+	if tc.Algorithm == Seq {
+		every *= rows
+	}
+	return every
+}
+
+// DeadUnits returns indices of dead map units, which are BMU of fewer than minHits data rows, in ascending
+// order. If minHits is zero, units which are not BMU of any data row are dead. Large maps initialized
+// randomly often have many dead units, which TrainConfig.ReviveDeadUnits revives during training.
+// It returns error if minHits is negative or if HitMap fails.
+func (m Map) DeadUnits(data mat64.Matrix, minHits int) ([]int, error) {
+	if minHits < 0 {
+		return nil, fmt.Errorf("invalid minimum hits: %d", minHits)
+	}
+	if minHits == 0 {
+		minHits = 1
+	}
+	hits, err := m.HitMap(data)
+	if err != nil {
+		return nil, err
+	}
+	dead := []int{}
+	for i, h := range hits {
+		if h < minHits {
+			dead = append(dead, i)
+		}
+	}
+	return dead, nil
+}
+
+// reviveDead re-initialises codebook vectors of dead units, which are BMU of fewer than
+// tc.ReviveMinHits data samples. Split strategy revives them in the regions of the units with the
+// highest quantization error: every dead unit splits a unit of the highest remaining error by moving
+// halfway between its codebook vector and the farthest data sample of the unit. Every unit is split
+// at most once. Sample strategy sets every dead unit to one of the data samples farthest from their
+// BMUs, the farthest sample first; every sample re-seeds at most one unit. It returns the number of
+// revived units.
+func (m *Map) reviveDead(tc *TrainConfig, data mat64.Matrix) int {
+	units, _ := m.cbDims()
+	rows, _ := data.Dims()
+	minHits := tc.ReviveMinHits
+	if minHits == 0 {
+		minHits = 1
+	}
+	hits := make([]int, units)
+	qe := make([]float64, units)
+	far, farDist := make([]int, units), make([]float64, units)
+	dists := make([]float64, rows)
+	rd := newRowReader(data)
+	for i := 0; i < rows; i++ {
+		// no need to check for error: data and codebook have the same dimension
+		bmu, d, _ := m.BMU(rd.row(i))
+		hits[bmu]++
+		qe[bmu] += d * d
+		dists[i] = d
+		if d >= farDist[bmu] {
+			far[bmu], farDist[bmu] = i, d
+		}
+	}
+	var dead, live []int
+	for i, h := range hits {
+		if h < minHits {
+			dead = append(dead, i)
+		} else {
+			live = append(live, i)
+		}
+	}
+	if tc.ReviveStrategy == "sample" {
+		return m.reseedDead(dead, dists, rd)
+	}
+	sort.SliceStable(live, func(a, b int) bool { return qe[live[a]] > qe[live[b]] })
+	if len(dead) > len(live) {
+		dead = dead[:len(live)]
+	}
+	cb := m.cb()
+	vec := make([]float64, len(cb.RawRowView(0)))
+	for i, u := range dead {
+		busy := live[i]
+		for j, v := range rd.row(far[busy]) {
+			vec[j] = (cb.At(busy, j) + v) / 2
+		}
+		m.setUnit(u, vec)
+	}
+	return len(dead)
+}
+
+// reseedDead sets codebook vectors of dead units to data samples read by rd in descending order
+// of their BMU distances dists and returns the number of re-seeded units
+func (m *Map) reseedDead(dead []int, dists []float64, rd *rowReader) int {
+	samples := make([]int, len(dists))
+	for i := range samples {
+		samples[i] = i
+	}
+	sort.SliceStable(samples, func(a, b int) bool { return dists[samples[a]] > dists[samples[b]] })
+	if len(dead) > len(samples) {
+		dead = dead[:len(samples)]
+	}
+	for i, u := range dead {
+		m.setUnit(u, rd.row(samples[i]))
+	}
+	return len(dead)
+}
+
+// logRevived counts n dead units revived at iter-th out of iters iterations of training tc and logs them
+func (m *Map) logRevived(l Logger, tc *TrainConfig, iter, iters, n int) {
+	m.revived += n
+	if n > 0 {
+		l.Printf("som: %s training: iteration %d/%d: revived %d dead units", tc.Algorithm, iter+1, iters, n)
+	}
+}
@@ -0,0 +1,162 @@
+package som
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeImbalanced generates n samples of five clusters: almost all samples belong to the central
+// cluster, the four corner clusters hold 10 samples each
+func makeImbalanced(n int, seed int64) *mat64.Dense {
+	r := rand.New(rand.NewSource(seed))
+	corners := [][]float64{{-5, -5}, {-5, 5}, {5, -5}, {5, 5}}
+	data := mat64.NewDense(n, 2, nil)
+	for i := 0; i < n; i++ {
+		c := []float64{0, 0}
+		if i < 40 {
+			c = corners[i%4]
+		}
+		data.Set(i, 0, c[0]+r.NormFloat64()*0.3)
+		data.Set(i, 1, c[1]+r.NormFloat64()*0.3)
+	}
+	return data
+}
+
+// deadUnits returns the number of map units which are not BMU of any data sample
+func deadUnits(t *testing.T, m *Map, data *mat64.Dense) int {
+	bmus, err := m.BMUs(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	units, _ := m.cbDims()
+	hits := make([]int, units)
+	for _, bmu := range bmus {
+		hits[bmu]++
+	}
+	dead := 0
+	for _, h := range hits {
+		if h == 0 {
+			dead++
+		}
+	}
+	return dead
+}
+
+func TestReviveDeadUnits(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeImbalanced(800, 1)
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{6, 6},
+			Type:   Planar,
+			UShape: Hexagon,
+		},
+		Cb: &CbConfig{
+			Dim:      2,
+			InitFunc: LinInit,
+		},
+	}
+	for _, alg := range []Method{Seq, Batch} {
+		tc := &TrainConfig{
+			Algorithm: alg,
+			Radius:    3.0,
+			RDecay:    ExpDecay,
+			NeighbFn:  Gaussian,
+			LRate:     0.5,
+			LDecay:    ExpDecay,
+		}
+		iters := relIters(tc, 800)
+		m, err := NewMap(mc, data)
+		assert.NoError(err)
+		res, err := m.train(tc, data, iters, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		assert.Equal(0, res.Revived)
+		plain := deadUnits(t, m, data)
+
+		log := &recLogger{}
+		tc.ReviveDeadUnits, tc.ReviveInterval, tc.Logger = true, 2, log
+		m, err = NewMap(mc, data)
+		assert.NoError(err)
+		res, err = m.train(tc, data, iters, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		revived := deadUnits(t, m, data)
+		assert.True(revived < plain, "%s: dead units with revival: %d, without revival: %d", alg, revived, plain)
+		assert.True(res.Revived > 0)
+		assert.True(log.count("dead units") > 0)
+	}
+}
+
+func TestReviveDead(t *testing.T) {
+	assert := assert.New(t)
+
+	data := mat64.NewDense(4, 1, []float64{0.0, 1.0, 3.0, 10.0})
+	m := &Map{codebook: mat64.NewDense(3, 1, []float64{0.0, 2.0, 20.0})}
+	tc := &TrainConfig{}
+	// unit 1 has the highest error: it is split towards its farthest sample
+	assert.Equal(1, m.reviveDead(tc, data))
+	assert.Equal([]float64{0.0, 2.0, 6.0}, m.codebook.RawMatrix().Data)
+	// units with fewer hits than the minimum are dead too
+	tc.ReviveMinHits = 2
+	m = &Map{codebook: mat64.NewDense(3, 1, []float64{0.0, 3.0, 10.0})}
+	// dead units are revived only while there are live units to split
+	assert.Equal(1, m.reviveDead(tc, data))
+	assert.Equal([]float64{0.0, 0.5, 10.0}, m.codebook.RawMatrix().Data)
+	// sample strategy re-seeds dead units with the samples farthest from their BMUs
+	tc = &TrainConfig{ReviveStrategy: "sample"}
+	m = &Map{codebook: mat64.NewDense(3, 1, []float64{0.0, 2.0, 20.0})}
+	assert.Equal(1, m.reviveDead(tc, data))
+	assert.Equal([]float64{0.0, 2.0, 10.0}, m.codebook.RawMatrix().Data)
+	// dead units are re-seeded only while there are samples
+	m = &Map{codebook: mat64.NewDense(6, 1, []float64{0.0, 20.0, 30.0, 40.0, 50.0, 60.0})}
+	assert.Equal(2, m.reviveDead(tc, mat64.NewDense(2, 1, []float64{1.0, 4.0})))
+	assert.Equal([]float64{0.0, 4.0, 1.0, 40.0, 50.0, 60.0}, m.codebook.RawMatrix().Data)
+
+	assert.Equal(0, reviveEvery(&TrainConfig{Algorithm: Seq}, 100))
+	assert.Equal(DefaultReviveInterval*100, reviveEvery(&TrainConfig{Algorithm: Seq, ReviveDeadUnits: true}, 100))
+	assert.Equal(2, reviveEvery(&TrainConfig{Algorithm: Batch, ReviveDeadUnits: true, ReviveInterval: 2}, 100))
+}
+
+func TestValidateRevive(t *testing.T) {
+	assert := assert.New(t)
+
+	tc := *tSom
+	tc.ReviveDeadUnits, tc.Algorithm, tc.Leak = true, Temporal, 0.5
+	err := validateTrainConfig(&tc)
+	assert.EqualError(err, ErrUnsupportedMethod.Error()+": temporal, dead unit revival supports seq and batch")
+	assert.True(errors.Is(err, ErrUnsupportedMethod))
+	tc.Algorithm = Batch
+	assert.NoError(validateTrainConfig(&tc))
+	tc.ReviveInterval = -1
+	assert.EqualError(validateTrainConfig(&tc), "invalid revive interval: -1")
+	tc.ReviveInterval, tc.ReviveMinHits = 1, -2
+	assert.EqualError(validateTrainConfig(&tc), "invalid revive minimum hits: -2")
+	tc.ReviveMinHits, tc.ReviveStrategy = 0, "random"
+	assert.EqualError(validateTrainConfig(&tc), "unsupported dead unit revival strategy: random, supported: sample, split")
+	tc.ReviveStrategy = "sample"
+	assert.NoError(validateTrainConfig(&tc))
+}
+
+func TestDeadUnits(t *testing.T) {
+	assert := assert.New(t)
+
+	data := mat64.NewDense(4, 1, []float64{0.0, 0.9, 3.0, 10.0})
+	m := &Map{codebook: mat64.NewDense(3, 1, []float64{0.0, 2.0, 20.0})}
+	dead, err := m.DeadUnits(data, 0)
+	assert.NoError(err)
+	assert.Equal([]int{2}, dead)
+	dead, err = m.DeadUnits(data, 3)
+	assert.NoError(err)
+	assert.Equal([]int{0, 1, 2}, dead)
+	dead, err = m.DeadUnits(data, 2)
+	assert.NoError(err)
+	assert.Equal([]int{2}, dead)
+	_, err = m.DeadUnits(data, -1)
+	assert.EqualError(err, "invalid minimum hits: -1")
+	_, err = m.DeadUnits(nil, 0)
+	assert.Error(err)
+}
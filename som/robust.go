@@ -0,0 +1,134 @@
+package som
+
+import (
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// weightedValue is a sample component with its neighbourhood weight
+type weightedValue struct {
+	v, w float64
+}
+
+// robustUpdate updates codebook vectors in batch training using median or trimmed update rule
+type robustUpdate struct {
+	// m is the trained map
+	m *Map
+	// tc is SOM training configuration
+	tc *TrainConfig
+	// data holds the training data
+	data *mat64.Dense
+	// index finds map units within radius
+	index *unitIndex
+	// near holds indices of map units within radius
+	near []int
+	// vals holds weighted components of unit neighbourhood samples
+	vals []weightedValue
+}
+
+// newRobustUpdate creates robust codebook update of map m trained on data
+func newRobustUpdate(m *Map, tc *TrainConfig, data mat64.Matrix, index *unitIndex) *robustUpdate {
+	dense, ok := data.(*mat64.Dense)
+	if !ok {
+		dense = mat64.DenseCopyOf(data)
+	}
+	units, _ := m.cbDims()
+	return &robustUpdate{
+		m:     m,
+		tc:    tc,
+		data:  dense,
+		index: index,
+		near:  make([]int, 0, units),
+	}
+}
+
+// apply sets every codebook vector component to the neighbourhood weighted median or trimmed mean
+// of the components of data samples whose BMUs are within radius of the unit. Samples are weighted
+// by the neighbourhood function of their BMU; samples with non-positive weight are ignored.
+// Codebook vectors of units with no data in their neighbourhood are not changed.
+func (u *robustUpdate) apply(radius float64) error {
+	bmus, err := u.m.BMUs(u.data)
+	if err != nil {
+		return err
+	}
+	units, dim := u.m.cbDims()
+	members := make([][]int, units)
+	for i, bmu := range bmus {
+		members[bmu] = append(members[bmu], i)
+	}
+	vec := make([]float64, dim)
+	for k := 0; k < units; k++ {
+		u.near = u.index.within(u.near[:0], k, radius)
+		dists := u.index.unitDist.RawRowView(k)
+		updated := false
+		for c := 0; c < dim; c++ {
+			u.vals = u.vals[:0]
+			for _, j := range u.near {
+				w := u.tc.NeighbFn(dists[j], radius)
+				if w <= 0.0 {
+					continue
+				}
+				for _, i := range members[j] {
+					u.vals = append(u.vals, weightedValue{v: u.data.At(i, c), w: w})
+				}
+			}
+			if len(u.vals) == 0 {
+				break
+			}
+			vec[c] = weightedTrimmedMean(u.vals, u.trim())
+			updated = true
+		}
+		if updated {
+			u.m.setUnit(k, vec)
+		}
+	}
+	return nil
+}
+
+// trim returns the trimmed weight fraction of the update rule: median trims everything but the middle
+func (u *robustUpdate) trim() float64 {
+	if u.tc.UpdateRule == "median" {
+		return 0.5
+	}
+	return u.tc.TrimFraction
+}
+
+// weightedTrimmedMean returns weighted mean of values after trim fraction of the total weight is
+// removed from both ends of the sorted values. Trim fraction 0.5 returns the weighted median:
+// the smallest value at which the cumulative weight reaches half of the total weight.
+// vals is sorted in place.
+func weightedTrimmedMean(vals []weightedValue, trim float64) float64 {
+	sort.Slice(vals, func(i, j int) bool { return vals[i].v < vals[j].v })
+	total := 0.0
+	for _, x := range vals {
+		total += x.w
+	}
+	lo, hi := trim*total, (1-trim)*total
+	if trim >= 0.5 {
+		cum := 0.0
+		for _, x := range vals {
+			if cum += x.w; cum >= lo {
+				return x.v
+			}
+		}
+		return vals[len(vals)-1].v
+	}
+	// sum the parts of value weights which lie between lo and hi
+	sum, weight, cum := 0.0, 0.0, 0.0
+	for _, x := range vals {
+		from, to := cum, cum+x.w
+		cum = to
+		if from < lo {
+			from = lo
+		}
+		if to > hi {
+			to = hi
+		}
+		if to > from {
+			sum += x.v * (to - from)
+			weight += to - from
+		}
+	}
+	return sum / weight
+}
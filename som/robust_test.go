@@ -0,0 +1,113 @@
+package som
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedTrimmedMean(t *testing.T) {
+	assert := assert.New(t)
+
+	vals := func() []weightedValue {
+		return []weightedValue{{5, 1}, {1, 1}, {3, 1}, {2, 1}, {100, 1}}
+	}
+	assert.Equal(3.0, weightedTrimmedMean(vals(), 0.5))
+	assert.Equal(22.2, weightedTrimmedMean(vals(), 0.0))
+	// 20% of the weight is one value at each end
+	assert.InDelta(10.0/3.0, weightedTrimmedMean(vals(), 0.2), 1e-12)
+	// weights shift the median
+	assert.Equal(100.0, weightedTrimmedMean([]weightedValue{{1, 1}, {100, 3}}, 0.5))
+	// partial weights are trimmed
+	assert.InDelta(2.0, weightedTrimmedMean([]weightedValue{{1, 1}, {2, 1}, {3, 1}}, 0.25), 1e-12)
+}
+
+// makeBlobs generates n samples of k 2D clusters with centres on a circle of radius 10
+func makeBlobs(n, k int, spread float64, seed int64) *mat64.Dense {
+	r := rand.New(rand.NewSource(seed))
+	data := mat64.NewDense(n, 2, nil)
+	for i := 0; i < n; i++ {
+		t := 2 * math.Pi * float64(i%k) / float64(k)
+		data.Set(i, 0, 10*math.Cos(t)+r.NormFloat64()*spread)
+		data.Set(i, 1, 10*math.Sin(t)+r.NormFloat64()*spread)
+	}
+	return data
+}
+
+func TestRobustBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	clean := makeBlobs(400, 4, 1.0, 7)
+	// replace 5% of samples by gross outliers
+	dirty := mat64.DenseCopyOf(clean)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 400; i += 20 {
+		dirty.SetRow(i, []float64{2000.0*r.Float64() - 1000.0, 2000.0*r.Float64() - 1000.0})
+	}
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{4, 4},
+			Type:   Planar,
+			UShape: Hexagon,
+		},
+		Cb: &CbConfig{
+			Dim:      2,
+			InitFunc: RandInit,
+		},
+	}
+	train := func(rule string, data *mat64.Dense) *mat64.Dense {
+		tc := &TrainConfig{
+			Algorithm:    Batch,
+			Radius:       2.0,
+			RDecay:       LinDecay,
+			NeighbFn:     Gaussian,
+			LRate:        0.5,
+			LDecay:       LinDecay,
+			UpdateRule:   rule,
+			TrimFraction: 0.1,
+		}
+		m, err := NewMap(mc, clean)
+		assert.NoError(err)
+		assert.NoError(m.Train(tc, data, 20))
+		return m.Codebook()
+	}
+	// drift is the mean distance of clean data codebook vectors from the closest dirty data codebook vectors
+	drift := func(rule string) float64 {
+		d, err := QuantError(train(rule, clean), train(rule, dirty))
+		assert.NoError(err)
+		return d
+	}
+	meanDrift, medianDrift, trimmedDrift := drift("mean"), drift("median"), drift("trimmed")
+	assert.True(medianDrift < 1.0, "median drift: %f", medianDrift)
+	assert.True(trimmedDrift < 1.5, "trimmed drift: %f", trimmedDrift)
+	assert.True(meanDrift > 3*medianDrift, "mean drift: %f, median drift: %f", meanDrift, medianDrift)
+	// robust codebooks still represent clean data well
+	for _, rule := range []string{"mean", "median", "trimmed"} {
+		qe, err := QuantError(clean, train(rule, dirty))
+		assert.NoError(err)
+		if rule == "mean" {
+			assert.True(qe > 2.0, "%s: %f", rule, qe)
+		} else {
+			assert.True(qe < 1.2, "%s: %f", rule, qe)
+		}
+	}
+}
+
+func TestValidateUpdateRule(t *testing.T) {
+	assert := assert.New(t)
+
+	tc := *tSom
+	tc.UpdateRule = "mode"
+	assert.EqualError(validateTrainConfig(&tc), "unsupported batch update rule: mode, supported: mean, median, trimmed")
+	tc.UpdateRule, tc.TrimFraction = "trimmed", 0.5
+	assert.EqualError(validateTrainConfig(&tc), "invalid trim fraction: 0.500000, must be at least 0 and less than 0.5")
+	tc.TrimFraction = 0.2
+	assert.NoError(validateTrainConfig(&tc))
+	// sequential training ignores the update rule
+	warnings, err := ValidateAll(mSom, &tc, 5, 4, 10)
+	assert.NoError(err)
+	assert.Contains(warnings, "update rule trimmed only applies to batch training")
+}
@@ -0,0 +1,148 @@
+package som
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+)
+
+const (
+	// sammonMagic is the magic factor which scales Newton steps of Sammon mapping
+	sammonMagic = 0.3
+	// sammonTol is the relative stress decrease below which Sammon mapping stops
+	sammonTol = 1e-9
+	// sammonHalvings is the maximum number of step halvings of a Sammon mapping iteration
+	sammonHalvings = 20
+)
+
+// SammonResult is 2D embedding of map codebook vectors computed by Sammon
+type SammonResult struct {
+	// Embedding holds 2D coordinates of codebook vectors, one row per map unit
+	Embedding *mat64.Dense
+	// Coords holds grid coordinates of map units in the same order
+	Coords *mat64.Dense
+	// Stress is Sammon stress of the embedding: the sum of squared differences between euclidean
+	// codebook vector distances and embedding distances divided by the codebook vector distances,
+	// normalized by the sum of codebook vector distances. It is 0 for a perfect embedding.
+	Stress float64
+	// Iters is the number of performed iterations
+	Iters int
+}
+
+// Sammon computes Sammon mapping of codebook vectors to 2D, which preserves small distances
+// between unit prototypes better than linear projections, so high-dimensional codebooks can be
+// plotted next to the map grid. The embedding is initialized by projecting codebook vectors to
+// their first two principal components and it is refined by at most iters Newton iterations.
+// Iterations stop early once the stress does not decrease. Units with the same codebook vectors
+// share their embedding coordinates.
+// It returns error if iters is not a positive integer.
+func (m Map) Sammon(iters int) (*SammonResult, error) {
+	if iters <= 0 {
+		return nil, fmt.Errorf("invalid number of iterations: %d", iters)
+	}
+	units, _ := m.cbDims()
+	cb := m.cb()
+	dist := mat64.NewDense(units, units, nil)
+	for i := 0; i < units; i++ {
+		for j := i + 1; j < units; j++ {
+			d := Euclidean(cb.RawRowView(i), cb.RawRowView(j))
+			dist.Set(i, j, d)
+			dist.Set(j, i, d)
+		}
+	}
+	y := sammonInit(cb)
+	stress := sammonStress(dist, y)
+	res := &SammonResult{Coords: mat64.DenseCopyOf(m.grid.coords)}
+	step := mat64.NewDense(units, 2, nil)
+	next := mat64.NewDense(units, 2, nil)
+	for res.Iters < iters && stress > 0 {
+		sammonStep(dist, y, step)
+		improved := false
+		for h, f := 0, sammonMagic; h < sammonHalvings; h, f = h+1, f/2 {
+			next.Scale(-f, step)
+			next.Add(next, y)
+			if s := sammonStress(dist, next); s < stress {
+				improved = stress-s > sammonTol*stress
+				y, next, stress = next, y, s
+				break
+			}
+		}
+		res.Iters++
+		if !improved {
+			break
+		}
+	}
+	res.Embedding, res.Stress = y, stress
+	return res, nil
+}
+
+// sammonInit returns codebook vectors cb projected to their first two principal components.
+// Codebooks of 1D vectors and degenerate codebooks, whose principal components can't be
+// determined, are embedded on the first axis by their first component.
+func sammonInit(cb *mat64.Dense) *mat64.Dense {
+	units, dim := cb.Dims()
+	y := mat64.NewDense(units, 2, nil)
+	var pc stat.PC
+	if dim < 2 || !pc.PrincipalComponents(cb, nil) {
+		for i := 0; i < units; i++ {
+			y.Set(i, 0, cb.At(i, 0))
+		}
+		return y
+	}
+	vecs := pc.Vectors(nil)
+	y.Mul(cb, vecs.View(0, 0, dim, 2))
+	return y
+}
+
+// sammonStress returns Sammon stress of embedding y of vectors with distances dist
+func sammonStress(dist, y *mat64.Dense) float64 {
+	units, _ := dist.Dims()
+	stress, sum := 0.0, 0.0
+	for i := 0; i < units; i++ {
+		for j := i + 1; j < units; j++ {
+			d := dist.At(i, j)
+			if d == 0 {
+				continue
+			}
+			e := d - Euclidean(y.RawRowView(i), y.RawRowView(j))
+			stress += e * e / d
+			sum += d
+		}
+	}
+	if sum == 0 {
+		return 0
+	}
+	return stress / sum
+}
+
+// sammonStep stores Newton steps of Sammon stress of embedding y of vectors with distances dist
+// in step: stress gradient divided by the absolute value of the diagonal of its Hessian.
+// Embedded points which coincide are moved apart by the stress gradient of their other distances.
+func sammonStep(dist, y, step *mat64.Dense) {
+	units, _ := dist.Dims()
+	for p := 0; p < units; p++ {
+		yp := y.RawRowView(p)
+		for q := 0; q < 2; q++ {
+			grad, hess := 0.0, 0.0
+			for j := 0; j < units; j++ {
+				d := dist.At(p, j)
+				if j == p || d == 0 {
+					continue
+				}
+				yj := y.RawRowView(j)
+				e := math.Max(Euclidean(yp, yj), 1e-12)
+				diff := yp[q] - yj[q]
+				grad += (d - e) / (d * e) * diff
+				hess += ((d - e) - diff*diff/e*(1+(d-e)/e)) / (d * e)
+			}
+			if hess == 0 {
+				step.Set(p, q, 0)
+				continue
+			}
+			// the constant factor of the gradient and the Hessian cancels out
+			step.Set(p, q, -grad/math.Abs(hess))
+		}
+	}
+}
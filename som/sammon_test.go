@@ -0,0 +1,71 @@
+package som
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSammon(t *testing.T) {
+	assert := assert.New(t)
+
+	// 2D codebook vectors are embedded without distortion
+	data := unitBlobs(100, 1)
+	m, err := NewMap(makePrecisionMapCfg([]int{4, 3}, 2, ""), data)
+	assert.NoError(err)
+	res, err := m.Sammon(50)
+	assert.NoError(err)
+	assert.InDelta(0.0, res.Stress, 1e-12)
+	assert.True(mat64.Equal(m.GridCoords(), res.Coords))
+	cb := m.Codebook()
+	for i := 0; i < 12; i++ {
+		for j := 0; j < 12; j++ {
+			assert.InDelta(Euclidean(cb.RawRowView(i), cb.RawRowView(j)),
+				Euclidean(res.Embedding.RawRowView(i), res.Embedding.RawRowView(j)), 1e-9)
+		}
+	}
+
+	// iterations decrease the stress of the principal component projection
+	r := rand.New(rand.NewSource(1))
+	data = mat64.NewDense(200, 5, nil)
+	for i := 0; i < 200; i++ {
+		for j := 0; j < 5; j++ {
+			data.Set(i, j, r.Float64())
+		}
+	}
+	m, err = NewMap(makePrecisionMapCfg([]int{5, 4}, 5, ""), data)
+	assert.NoError(err)
+	res, err = m.Sammon(100)
+	assert.NoError(err)
+	rows, cols := res.Embedding.Dims()
+	assert.Equal(20, rows)
+	assert.Equal(2, cols)
+	cb = m.CodebookRaw()
+	dist := mat64.NewDense(20, 20, nil)
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 20; j++ {
+			dist.Set(i, j, Euclidean(cb.RawRowView(i), cb.RawRowView(j)))
+		}
+	}
+	init := sammonStress(dist, sammonInit(cb))
+	assert.True(res.Stress < init, "stress %f, initial stress %f", res.Stress, init)
+	assert.InDelta(sammonStress(dist, res.Embedding), res.Stress, 1e-12)
+	assert.True(res.Iters > 0 && res.Iters <= 100)
+	// a single iteration decreases the stress less
+	one, err := m.Sammon(1)
+	assert.NoError(err)
+	assert.Equal(1, one.Iters)
+	assert.True(one.Stress < init && one.Stress >= res.Stress)
+
+	// units with the same codebook vectors share their coordinates
+	m.setUnit(1, m.CodebookRaw().RawRowView(0))
+	res, err = m.Sammon(100)
+	assert.NoError(err)
+	assert.InDelta(0.0, Euclidean(res.Embedding.RawRowView(0), res.Embedding.RawRowView(1)), 1e-6)
+
+	// invalid number of iterations
+	_, err = m.Sammon(0)
+	assert.EqualError(err, "invalid number of iterations: 0")
+}
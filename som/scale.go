@@ -0,0 +1,211 @@
+package som
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+)
+
+// ScaleMethod is feature scaling method of Scaler
+type ScaleMethod string
+
+const (
+	// ZScore scales every feature to zero mean and unit standard deviation
+	ZScore ScaleMethod = "zscore"
+	// MinMax scales every feature to the range between 0 and 1
+	MinMax ScaleMethod = "minmax"
+	// UnitLength scales every sample to unit euclidean norm. It has no fitted parameters
+	// and can't be inverted because it discards sample norms.
+	UnitLength ScaleMethod = "unit"
+)
+
+// Scaler scales data samples with feature parameters fitted by FitScaler, so new samples can be
+// scaled in the same way as training data. ZScore and MinMax scalers transform feature j of sample x
+// to (x[j] - Center[j]) / Scale[j]. Missing values, which are stored as NaN, stay missing.
+type Scaler struct {
+	// Method is scaling method
+	Method ScaleMethod
+	// Center holds subtracted feature values: feature means or minimums
+	Center []float64
+	// Scale holds feature divisors: feature standard deviations or ranges.
+	// Constant features have divisor 1.
+	Scale []float64
+}
+
+// FitScaler fits scaler of method to data: it computes feature means and standard deviations
+// of ZScore scaling or feature minimums and ranges of MinMax scaling from observed data values.
+// It returns error if data is nil or empty, if method is not supported or if a data column
+// has no observed values.
+func FitScaler(method ScaleMethod, data mat64.Matrix) (*Scaler, error) {
+	if isNilMatrix(data) {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, cols := data.Dims()
+	if rows == 0 || cols == 0 {
+		return nil, fmt.Errorf("invalid data dimensions: %d x %d", rows, cols)
+	}
+	s := &Scaler{Method: method}
+	switch method {
+	case UnitLength:
+		return s, nil
+	case ZScore, MinMax:
+	default:
+		return nil, fmt.Errorf("unsupported scale method: %s", method)
+	}
+	s.Center, s.Scale = make([]float64, cols), make([]float64, cols)
+	col := make([]float64, 0, rows)
+	for j := 0; j < cols; j++ {
+		col = col[:0]
+		for i := 0; i < rows; i++ {
+			if v := data.At(i, j); !math.IsNaN(v) {
+				col = append(col, v)
+			}
+		}
+		if len(col) == 0 {
+			return nil, fmt.Errorf("invalid data column %d: no observed values", j)
+		}
+		if method == ZScore {
+			s.Center[j], s.Scale[j] = stat.MeanStdDev(col, nil)
+		} else {
+			min, max := col[0], col[0]
+			for _, v := range col {
+				min, max = math.Min(min, v), math.Max(max, v)
+			}
+			s.Center[j], s.Scale[j] = min, max-min
+		}
+		// a single observed value has NaN standard deviation
+		if !(s.Scale[j] > 0) {
+			s.Scale[j] = 1.0
+		}
+	}
+	return s, nil
+}
+
+// Transform returns data scaled by the scaler. It does not modify data.
+// It returns error if data is nil or ErrDimMismatch if data dimension is different from
+// the dimension of fitted ZScore or MinMax scaler.
+func (s *Scaler) Transform(data mat64.Matrix) (*mat64.Dense, error) {
+	if err := s.check(data); err != nil {
+		return nil, err
+	}
+	rows, cols := data.Dims()
+	scaled := mat64.NewDense(rows, cols, nil)
+	rd := newRowReader(data)
+	for i := 0; i < rows; i++ {
+		s.transform(scaled.RawRowView(i), rd.row(i))
+	}
+	return scaled, nil
+}
+
+// Inverse returns scaled data transformed back to the original feature units, such as codebook
+// vectors of a map trained on scaled data. It does not modify data.
+// It returns error if data is nil, if the scaler is UnitLength scaler or ErrDimMismatch if data
+// dimension is different from the scaler dimension.
+func (s *Scaler) Inverse(data mat64.Matrix) (*mat64.Dense, error) {
+	if s.Method == UnitLength {
+		return nil, fmt.Errorf("unsupported inverse scaling: %s scaling can't be inverted", s.Method)
+	}
+	if err := s.check(data); err != nil {
+		return nil, err
+	}
+	rows, cols := data.Dims()
+	orig := mat64.NewDense(rows, cols, nil)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			orig.Set(i, j, data.At(i, j)*s.Scale[j]+s.Center[j])
+		}
+	}
+	return orig, nil
+}
+
+// check returns error if data can't be scaled by the scaler
+func (s *Scaler) check(data mat64.Matrix) error {
+	if isNilMatrix(data) {
+		return fmt.Errorf("invalid data supplied: %v", data)
+	}
+	if _, cols := data.Dims(); s.Method != UnitLength && cols != len(s.Center) {
+		return ErrDimMismatch
+	}
+	return nil
+}
+
+// transform stores sample x scaled by the scaler in dst. Samples of zero norm are not scaled
+// by UnitLength scaler.
+func (s *Scaler) transform(dst, x []float64) {
+	if s.Method != UnitLength {
+		for j, v := range x {
+			dst[j] = (v - s.Center[j]) / s.Scale[j]
+		}
+		return
+	}
+	norm := 0.0
+	for _, v := range x {
+		if !math.IsNaN(v) {
+			norm += v * v
+		}
+	}
+	if norm == 0 {
+		copy(dst, x)
+		return
+	}
+	norm = math.Sqrt(norm)
+	for j, v := range x {
+		dst[j] = v / norm
+	}
+}
+
+// SetScaler sets scaler which the methods that map new samples apply to them before searching their
+// BMUs, so a map trained on scaled data can map new samples in original units. These methods are
+// Predict, PredictStream, HitMap, Transform, AnomalyScores, Score, IsAnomaly, Project, ProjectK,
+// ProjectAll, Classify, ClassifyVote, ClassifyAll, PredictTarget, PredictTargetK, SoftPredict and
+// SoftPath. BMU, KBMU, BMUs, QuantError, TopoError and the training methods expect scaled samples.
+// Nil scaler removes the scaler.
+// SetScaler returns ErrDimMismatch if the dimension of ZScore or MinMax scaler is different
+// from the codebook dimension.
+func (m *Map) SetScaler(s *Scaler) error {
+	if _, dim := m.cbDims(); s != nil && s.Method != UnitLength && len(s.Center) != dim {
+		return ErrDimMismatch
+	}
+	m.scaler = s
+	return nil
+}
+
+// Scaler returns scaler set by SetScaler or nil if the map has no scaler
+func (m Map) Scaler() *Scaler {
+	return m.scaler
+}
+
+// OrigCodebook returns codebook vectors in the original feature units of data scaled by the map
+// scaler. If the map has no scaler, it returns a copy of the codebook.
+// It returns error if the map scaler can't be inverted.
+func (m Map) OrigCodebook() (*mat64.Dense, error) {
+	if m.scaler == nil {
+		return m.Codebook(), nil
+	}
+	return m.scaler.Inverse(m.CodebookRaw())
+}
+
+// ScaleSample scales sample x by the map scaler and returns the scaled copy of x.
+// If the map has no scaler, it returns x itself.
+// It returns ErrDimMismatch if the dimension of x is different from the map codebook dimension.
+func (m Map) ScaleSample(x []float64) ([]float64, error) {
+	if _, cols := m.cbDims(); len(x) != cols {
+		return nil, ErrDimMismatch
+	}
+	if m.scaler == nil {
+		return x, nil
+	}
+	scaled := make([]float64, len(x))
+	m.scaler.transform(scaled, x)
+	return scaled, nil
+}
+
+// scaled returns data scaled by the map scaler or data if the map has no scaler
+func (m Map) scaled(data mat64.Matrix) (mat64.Matrix, error) {
+	if m.scaler == nil {
+		return data, nil
+	}
+	return m.scaler.Transform(data)
+}
@@ -0,0 +1,127 @@
+package som
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFitScaler(t *testing.T) {
+	assert := assert.New(t)
+
+	data := mat64.NewDense(4, 3, []float64{
+		1, 10, 5,
+		2, 20, 5,
+		3, math.NaN(), 5,
+		6, 30, 5,
+	})
+	s, err := FitScaler(ZScore, data)
+	assert.NoError(err)
+	assert.InDeltaSlice([]float64{3, 20, 5}, s.Center, 1e-12)
+	assert.InDeltaSlice([]float64{math.Sqrt(14.0 / 3.0), 10, 1}, s.Scale, 1e-12)
+	scaled, err := s.Transform(data)
+	assert.NoError(err)
+	assert.Equal(0.0, scaled.At(1, 1))
+	assert.True(math.IsNaN(scaled.At(2, 1)))
+	// constant features are not scaled
+	assert.Equal(0.0, scaled.At(0, 2))
+	orig, err := s.Inverse(scaled)
+	assert.NoError(err)
+	assert.InDeltaSlice(data.RawRowView(3), orig.RawRowView(3), 1e-12)
+
+	s, err = FitScaler(MinMax, data)
+	assert.NoError(err)
+	assert.Equal([]float64{1, 10, 5}, s.Center)
+	assert.Equal([]float64{5, 20, 1}, s.Scale)
+	scaled, err = s.Transform(data)
+	assert.NoError(err)
+	assert.Equal([]float64{0, 0, 0}, scaled.RawRowView(0))
+	assert.Equal([]float64{1, 1, 0}, scaled.RawRowView(3))
+
+	// unit length scaling normalizes every sample
+	s, err = FitScaler(UnitLength, data)
+	assert.NoError(err)
+	scaled, err = s.Transform(mat64.NewDense(2, 2, []float64{3, 4, 0, 0}))
+	assert.NoError(err)
+	assert.Equal([]float64{0.6, 0.8, 0, 0}, scaled.RawMatrix().Data)
+	_, err = s.Inverse(scaled)
+	assert.Error(err)
+
+	// invalid input
+	_, err = FitScaler("log", data)
+	assert.EqualError(err, "unsupported scale method: log")
+	_, err = FitScaler(ZScore, nil)
+	assert.Error(err)
+	_, err = FitScaler(ZScore, mat64.NewDense(2, 1, []float64{math.NaN(), math.NaN()}))
+	assert.EqualError(err, "invalid data column 0: no observed values")
+	s, _ = FitScaler(MinMax, data)
+	_, err = s.Transform(mat64.NewDense(1, 2, nil))
+	assert.True(errors.Is(err, ErrDimMismatch))
+	_, err = s.Inverse(nil)
+	assert.Error(err)
+}
+
+func TestMapScaler(t *testing.T) {
+	assert := assert.New(t)
+
+	// features of very different ranges
+	data := unitBlobs(100, 1)
+	rows, _ := data.Dims()
+	orig := mat64.NewDense(rows, 2, nil)
+	for i := 0; i < rows; i++ {
+		orig.Set(i, 0, data.At(i, 0)*1000+500)
+		orig.Set(i, 1, data.At(i, 1))
+	}
+	s, err := FitScaler(MinMax, orig)
+	assert.NoError(err)
+	scaled, err := s.Transform(orig)
+	assert.NoError(err)
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, scaled)
+	assert.NoError(err)
+	assert.NoError(m.Train(gsomTrainConfig(), scaled, 500))
+	assert.Nil(m.Scaler())
+	cb, err := m.OrigCodebook()
+	assert.NoError(err)
+	assert.True(mat64.Equal(m.Codebook(), cb))
+	assert.True(errors.Is(m.SetScaler(&Scaler{Method: MinMax, Center: []float64{0}, Scale: []float64{1}}), ErrDimMismatch))
+	assert.NoError(m.SetScaler(s))
+	assert.Equal(s, m.Scaler())
+
+	// predictions of original samples match BMUs of scaled samples
+	expBMUs, err := m.BMUs(scaled)
+	assert.NoError(err)
+	bmus, _, err := m.Predict(orig, 2)
+	assert.NoError(err)
+	assert.Equal(expBMUs, bmus)
+	var b bytes.Buffer
+	assert.NoError(m.PredictStream(&matrixSource{data: orig}, &b, "csv", 2))
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	for i, bmu := range bmus {
+		assert.True(strings.HasPrefix(lines[i+1], strconv.Itoa(i)+","+strconv.Itoa(bmu)+","))
+	}
+
+	// codebook vectors in original units lie in the data range
+	cb, err = m.OrigCodebook()
+	assert.NoError(err)
+	for i := 0; i < 12; i++ {
+		assert.True(cb.At(i, 0) >= 400 && cb.At(i, 0) <= 1600)
+	}
+
+	// the scaler is saved with the map
+	b.Reset()
+	assert.NoError(m.Save(&b))
+	loaded, err := LoadMap(&b)
+	assert.NoError(err)
+	assert.Equal(s, loaded.Scaler())
+	assert.NoError(m.SetScaler(nil))
+	assert.Nil(m.Scaler())
+}
@@ -0,0 +1,134 @@
+package som
+
+import (
+	"fmt"
+	"math"
+)
+
+// Schedule returns value of a training parameter, such as units radius or learning rate, at every
+// training step. Unlike decay strategies, which decay the value from the configured initial value to
+// a fixed minimum, a schedule defines the whole curve, so schedules can implement warm restarts,
+// cyclic or piecewise curves. TrainConfig.RSchedule and TrainConfig.LSchedule accept any schedule.
+type Schedule interface {
+	// At returns the value at step t of T steps; t is between 0 and T-1 and T is positive
+	At(t, T int) float64
+}
+
+// ScheduleFunc is an adapter which allows the use of ordinary functions as schedules
+type ScheduleFunc func(t, T int) float64
+
+// At returns f(t, T)
+func (f ScheduleFunc) At(t, T int) float64 {
+	return f(t, T)
+}
+
+// ConstantSchedule keeps the value constant
+type ConstantSchedule struct {
+	// Value is the value at every step
+	Value float64
+}
+
+// At returns the constant value
+func (s ConstantSchedule) At(t, T int) float64 {
+	return s.Value
+}
+
+// LinearSchedule decays the value linearly from Start at the first step to End at the last step
+type LinearSchedule struct {
+	// Start and End are the values at the first and the last step
+	Start, End float64
+}
+
+// At returns the linearly decayed value at step t of T steps
+func (s LinearSchedule) At(t, T int) float64 {
+	if T < 2 {
+		return s.Start
+	}
+	return linDecay(t, T, s.Start, s.End)
+}
+
+// ExpSchedule decays the value exponentially from Start at the first step to End at the last step
+type ExpSchedule struct {
+	// Start and End are the values at the first and the last step
+	Start, End float64
+}
+
+// At returns the exponentially decayed value at step t of T steps
+func (s ExpSchedule) At(t, T int) float64 {
+	if T < 2 {
+		return s.Start
+	}
+	return powDecay(t, T, s.Start, s.End)
+}
+
+// InvSchedule decays the value inversely proportionally to the step from Start at the first step
+// to End at the last step
+type InvSchedule struct {
+	// Start and End are the values at the first and the last step
+	Start, End float64
+}
+
+// At returns the inversely decayed value at step t of T steps
+func (s InvSchedule) At(t, T int) float64 {
+	if T < 2 {
+		return s.Start
+	}
+	return invDecay(t, T, s.Start, s.End)
+}
+
+// RestartSchedule splits training into Cycles cycles of equal length and runs Schedule in every
+// cycle, so the value is restarted at the beginning of every cycle: warm restarts let training
+// escape poor local minima found while the value was small.
+type RestartSchedule struct {
+	// Schedule is the schedule of every cycle
+	Schedule Schedule
+	// Cycles is the number of cycles
+	Cycles int
+}
+
+// At returns the value of the cycle schedule at step t of T steps
+func (s RestartSchedule) At(t, T int) float64 {
+	cycles := s.Cycles
+	if cycles > T {
+		cycles = T
+	}
+	// the last cycle takes the remaining steps
+	steps := T / cycles
+	cycle := t / steps
+	if cycle >= cycles {
+		cycle = cycles - 1
+	}
+	if cycle == cycles-1 {
+		return s.Schedule.At(t-cycle*steps, T-cycle*steps)
+	}
+	return s.Schedule.At(t-cycle*steps, steps)
+}
+
+// checkSchedule returns error if built-in schedule s has values which are not positive and finite
+// or if restart schedule has no cycles. Custom schedules are not checked.
+func checkSchedule(s Schedule) error {
+	positive := func(values ...float64) error {
+		for _, v := range values {
+			if !(v > 0) || math.IsInf(v, 1) {
+				return fmt.Errorf("invalid schedule %#v: values must be positive and finite", s)
+			}
+		}
+		return nil
+	}
+	switch s := s.(type) {
+	case ConstantSchedule:
+		return positive(s.Value)
+	case LinearSchedule:
+		return positive(s.Start, s.End)
+	case ExpSchedule:
+		return positive(s.Start, s.End)
+	case InvSchedule:
+		return positive(s.Start, s.End)
+	case RestartSchedule:
+		if s.Cycles <= 0 || s.Schedule == nil {
+			return fmt.Errorf("invalid schedule %#v: needs a schedule and a positive number of cycles", s)
+		}
+		return checkSchedule(s.Schedule)
+	}
+	return nil
+}
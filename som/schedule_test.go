@@ -0,0 +1,106 @@
+package som
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedules(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, s := range []Schedule{LinearSchedule{8.0, 2.0}, ExpSchedule{8.0, 2.0}, InvSchedule{8.0, 2.0}} {
+		assert.InDelta(8.0, s.At(0, 11), 1e-12, "%#v", s)
+		assert.InDelta(2.0, s.At(10, 11), 1e-12, "%#v", s)
+		assert.True(s.At(5, 11) < s.At(4, 11), "%#v", s)
+		// single step schedules start at their first value
+		assert.Equal(8.0, s.At(0, 1), "%#v", s)
+	}
+	assert.InDelta(5.0, LinearSchedule{8.0, 2.0}.At(5, 11), 1e-12)
+	assert.InDelta(4.0, ExpSchedule{8.0, 2.0}.At(5, 11), 1e-12)
+	assert.InDelta(3.2, InvSchedule{8.0, 2.0}.At(5, 11), 1e-12)
+	assert.Equal(3.0, ConstantSchedule{3.0}.At(7, 10))
+	assert.Equal(7.0, ScheduleFunc(func(t, T int) float64 { return float64(t) }).At(7, 10))
+
+	// warm restarts: cycles of 5, 5 and 6 steps, the last cycle takes the remaining steps
+	s := RestartSchedule{Schedule: LinearSchedule{4.0, 0.5}, Cycles: 3}
+	for _, start := range []int{0, 5, 10} {
+		assert.InDelta(4.0, s.At(start, 16), 1e-12, "step %d", start)
+	}
+	assert.InDelta(0.5, s.At(4, 16), 1e-12)
+	assert.InDelta(0.5, s.At(9, 16), 1e-12)
+	assert.InDelta(0.5, s.At(15, 16), 1e-12)
+	// more cycles than steps restart at every step
+	assert.Equal(4.0, RestartSchedule{Schedule: LinearSchedule{4.0, 0.5}, Cycles: 5}.At(2, 3))
+}
+
+func TestCheckSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, s := range []Schedule{
+		ConstantSchedule{1.0},
+		LinearSchedule{2.0, 0.5},
+		RestartSchedule{Schedule: ExpSchedule{2.0, 0.5}, Cycles: 2},
+		ScheduleFunc(func(t, T int) float64 { return -1.0 }),
+	} {
+		assert.NoError(checkSchedule(s), "%#v", s)
+	}
+	for _, s := range []Schedule{
+		ConstantSchedule{0.0},
+		LinearSchedule{2.0, -0.5},
+		ExpSchedule{math.Inf(1), 0.5},
+		InvSchedule{math.NaN(), 0.5},
+		RestartSchedule{Schedule: ExpSchedule{2.0, 0.5}},
+		RestartSchedule{Cycles: 2},
+		RestartSchedule{Schedule: ConstantSchedule{-1.0}, Cycles: 2},
+	} {
+		assert.Error(checkSchedule(s), "%#v", s)
+	}
+
+	// schedules replace the initial values and their decay
+	tc := &TrainConfig{
+		Algorithm: Seq,
+		RSchedule: ExpSchedule{3.0, 1.0},
+		RDecay:    "foobar",
+		NeighbFn:  Gaussian,
+		LSchedule: ConstantSchedule{0.1},
+	}
+	assert.NoError(validateTrainConfig(tc))
+	tc.RSchedule = ConstantSchedule{0.0}
+	assert.True(errors.Is(validateTrainConfig(tc), ErrInvalidRadius))
+	tc.RSchedule, tc.LSchedule = ConstantSchedule{1.0}, LinearSchedule{0.0, 0.0}
+	assert.True(errors.Is(validateTrainConfig(tc), ErrInvalidLRate))
+}
+
+func TestTrainSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(20, 1)
+	rows, _ := data.Dims()
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	var radii, lRates []float64
+	tc := &TrainConfig{
+		Algorithm: Seq,
+		RSchedule: RestartSchedule{Schedule: LinearSchedule{3.0, 1.0}, Cycles: 2},
+		NeighbFn:  Gaussian,
+		LSchedule: ConstantSchedule{0.25},
+		OnEpoch: func(e Epoch) bool {
+			radii, lRates = append(radii, e.Radius), append(lRates, e.LRate)
+			return true
+		},
+	}
+	res, err := m.TrainWithResult(tc, mat64.DenseCopyOf(data), 4*rows)
+	assert.NoError(err)
+	assert.Equal(3.0, res.Radius)
+	assert.Equal(0.25, res.LRate)
+	// epochs end at iterations 19, 39, 59 and 79 of two cycles of 40 iterations
+	assert.InDeltaSlice([]float64{3.0 - 38.0/39.0, 1.0, 3.0 - 38.0/39.0, 1.0}, radii, 1e-12)
+	assert.Equal([]float64{0.25, 0.25, 0.25, 0.25}, lRates)
+}
@@ -0,0 +1,58 @@
+package som
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// DefaultPublishEvery is the default number of online training updates between map snapshots
+// published to OnlineConfig.Shared
+const DefaultPublishEvery = 100
+
+// SharedMap publishes immutable snapshots of a map which is being trained, so that any number of
+// goroutines can read the map, e.g. to answer Predict or Score calls, while another goroutine trains it.
+// Training goroutine publishes copies of the trained map by Publish and readers get the latest
+// published copy by Load. SharedMap is safe for concurrent use.
+type SharedMap struct {
+	// snap is the latest published snapshot
+	snap atomic.Pointer[mapSnapshot]
+}
+
+// mapSnapshot is a map snapshot published by SharedMap
+type mapSnapshot struct {
+	// m is the published map copy
+	m *Map
+	// epoch is the number of snapshots published up to and including this one
+	epoch uint64
+}
+
+// NewSharedMap creates new shared map which publishes a copy of map m.
+// It returns error if m is nil.
+func NewSharedMap(m *Map) (*SharedMap, error) {
+	if m == nil {
+		return nil, fmt.Errorf("invalid map supplied: %v", m)
+	}
+	s := &SharedMap{}
+	s.Publish(m)
+	return s, nil
+}
+
+// Publish publishes a copy of map m made by Clone and returns the epoch of the published snapshot:
+// the number of snapshots published so far. It must be called by the goroutine which trains m
+// and snapshots must be published by one goroutine at a time.
+func (s *SharedMap) Publish(m *Map) uint64 {
+	epoch := uint64(1)
+	if prev := s.snap.Load(); prev != nil {
+		epoch = prev.epoch + 1
+	}
+	s.snap.Store(&mapSnapshot{m: m.Clone(), epoch: epoch})
+	return epoch
+}
+
+// Load returns the latest published snapshot and its epoch. The snapshot is never modified by training,
+// but it is shared by all readers, so it must not be modified, e.g. by LabelUnits or Freeze, either.
+// Readers can compare epochs to find out whether a newer snapshot has been published.
+func (s *SharedMap) Load() (*Map, uint64) {
+	snap := s.snap.Load()
+	return snap.m, snap.epoch
+}
@@ -0,0 +1,94 @@
+package som
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedMap(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewSharedMap(nil)
+	assert.EqualError(err, "invalid map supplied: <nil>")
+
+	data := unitBlobs(100, 1)
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	s, err := NewSharedMap(m)
+	assert.NoError(err)
+	snap, epoch := s.Load()
+	assert.Equal(uint64(1), epoch)
+	assert.True(mat64.Equal(m.Codebook(), snap.Codebook()))
+	// training does not modify published snapshots
+	o, err := m.NewOnlineTrainer(OnlineConfig{Shared: s, PublishEvery: 10})
+	assert.NoError(err)
+	defer o.Close()
+	orig := snap.Codebook()
+	for i := 0; i < 25; i++ {
+		assert.NoError(o.Update(data.RawRowView(i)))
+	}
+	assert.True(mat64.Equal(orig, snap.Codebook()))
+	snap, epoch = s.Load()
+	assert.Equal(uint64(3), epoch)
+	assert.False(mat64.Equal(orig, snap.Codebook()))
+	assert.Equal(uint64(4), s.Publish(m))
+	snap, _ = s.Load()
+	assert.True(mat64.Equal(m.Codebook(), snap.Codebook()))
+}
+
+func TestSharedMapConcurrentReads(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	rows, _ := data.Dims()
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	s, err := NewSharedMap(m)
+	assert.NoError(err)
+	o, err := m.NewOnlineTrainer(OnlineConfig{Shared: s, PublishEvery: 5})
+	assert.NoError(err)
+	defer o.Close()
+	samples := make(chan []float64)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	// readers predict while the map is trained
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				snap, _ := s.Load()
+				if _, err := snap.BMUs(data); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		for epoch := 0; epoch < 5; epoch++ {
+			for i := 0; i < rows; i++ {
+				samples <- data.RawRowView(i)
+			}
+		}
+		close(samples)
+	}()
+	assert.NoError(o.TrainStream(context.Background(), samples))
+	cancel()
+	wg.Wait()
+	// the final map is published when the stream ends
+	snap, epoch := s.Load()
+	// the initial snapshot, one snapshot every 5 of 5*rows updates and the final one
+	assert.Equal(uint64(rows+2), epoch)
+	assert.True(mat64.Equal(m.Codebook(), snap.Codebook()))
+}
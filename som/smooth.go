@@ -0,0 +1,98 @@
+package som
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Smooth replaces every codebook vector by the average of codebook vectors of all units within radius
+// of the unit on the map grid, including the unit itself, weighted by neighbourhood function kernel
+// of their grid distances: gaussian, bubble, mexican, cutgauss, epanechnikov or a function registered
+// by RegisterNeighbFunc.
+// Grid distances respect the grid type, so toroid grids wrap around, and empty cells of masked grids
+// hold no units. Units with non-positive kernel weight are ignored. If features are supplied, only
+// the listed codebook features are smoothed. Smooth is never applied by training; it discards
+// quality measures recorded by RecordQuality. It returns error if radius is not a positive finite
+// number, if kernel is not registered or if any feature is out of codebook dimension range.
+func (m *Map) Smooth(radius float64, kernel string, features ...int) error {
+	if !(radius > 0) || math.IsInf(radius, 1) {
+		return fmt.Errorf("%w: %f, must be positive and finite", ErrInvalidRadius, radius)
+	}
+	fn, err := NeighbFuncByName(kernel)
+	if err != nil {
+		return err
+	}
+	units, dim := m.cbDims()
+	for _, f := range features {
+		if f < 0 || f >= dim {
+			return fmt.Errorf("invalid codebook feature: %d", f)
+		}
+	}
+	if features == nil {
+		features = make([]int, dim)
+		for j := range features {
+			features[j] = j
+		}
+	}
+	unitDist, err := m.UnitDist()
+	if err != nil {
+		return err
+	}
+	// all units are averaged from the original codebook
+	cb := mat64.DenseCopyOf(m.cb())
+	vec := make([]float64, dim)
+	for i := 0; i < units; i++ {
+		copy(vec, cb.RawRowView(i))
+		for _, f := range features {
+			vec[f] = 0
+		}
+		total := 0.0
+		for k, d := range unitDist.RawRowView(i) {
+			if d > radius {
+				continue
+			}
+			w := fn(d, radius)
+			if !(w > 0) {
+				continue
+			}
+			for _, f := range features {
+				vec[f] += w * cb.At(k, f)
+			}
+			total += w
+		}
+		// units without positive weight keep their codebook vectors
+		if total == 0 {
+			continue
+		}
+		for _, f := range features {
+			vec[f] /= total
+		}
+		m.setUnit(i, vec)
+	}
+	// smoothing modified the codebook: discard KD-tree, codebook norms and quality measures
+	m.tree = nil
+	m.norms = m.cbNorms()
+	m.quality = nil
+	return nil
+}
+
+// SmoothQE smooths the map codebook in the same way as Smooth and returns the change of quantization
+// error of data caused by the smoothing: positive change means the smoothed codebook quantizes data
+// worse. The codebook is not smoothed if the quantization error of data can't be computed.
+// SmoothQE fails in the same way as Smooth or QuantError.
+func (m *Map) SmoothQE(data mat64.Matrix, radius float64, kernel string, features ...int) (float64, error) {
+	before, err := m.QuantError(data)
+	if err != nil {
+		return 0.0, err
+	}
+	if err := m.Smooth(radius, kernel, features...); err != nil {
+		return 0.0, err
+	}
+	after, err := m.QuantError(data)
+	if err != nil {
+		return 0.0, err
+	}
+	return after - before, nil
+}
@@ -0,0 +1,90 @@
+package som
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmooth(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(400, 4, 0.5, 1)
+	mc, tc := mergeConfigs([]int{6, 6})
+	tc.Radius = 1.0
+	m, err := NewMap(mc, data)
+	assert.NoError(err)
+	_, err = m.train(tc, data, 4000, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+
+	// repeated smoothing converges
+	prev := mat64.DenseCopyOf(m.CodebookRaw())
+	change := -1.0
+	for i := 0; i < 5; i++ {
+		assert.NoError(m.Smooth(1.5, "gaussian"))
+		diff := mat64.NewDense(36, 2, nil)
+		diff.Sub(m.CodebookRaw(), prev)
+		next := mat64.Norm(diff, 2)
+		if change >= 0 {
+			assert.True(next < change, "smoothing %d: change %f, previous change %f", i, next, change)
+		}
+		change = next
+		prev = mat64.DenseCopyOf(m.CodebookRaw())
+	}
+
+	// only the selected features are smoothed
+	before := mat64.DenseCopyOf(m.CodebookRaw())
+	assert.NoError(m.Smooth(2.0, "bubble", 1))
+	for i := 0; i < 36; i++ {
+		assert.Equal(before.At(i, 0), m.CodebookRaw().At(i, 0))
+	}
+	assert.False(mat64.Equal(before, m.CodebookRaw()))
+
+	// quantization error change
+	m, err = NewMap(mc, data)
+	assert.NoError(err)
+	_, err = m.train(tc, data, 4000, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	qe, err := m.QuantError(data)
+	assert.NoError(err)
+	delta, err := m.SmoothQE(data, 1.5, "gaussian")
+	assert.NoError(err)
+	smoothQE, err := m.QuantError(data)
+	assert.NoError(err)
+	assert.InDelta(smoothQE-qe, delta, 1e-12)
+	assert.True(delta > 0, "quantization error change: %f", delta)
+}
+
+func TestSmoothMasked(t *testing.T) {
+	assert := assert.New(t)
+
+	// units fill 3 out of 9 cells of their bounding grid
+	m, _ := gsomMap([]gsomUnit{
+		{row: 0, col: 0, vec: []float64{0.0, 0.0}},
+		{row: 0, col: 1, vec: []float64{2.0, 4.0}},
+		{row: 2, col: 2, vec: []float64{10.0, 10.0}},
+	})
+	assert.NoError(m.Smooth(1.0, "bubble"))
+	// empty cells don't contribute and the isolated unit is untouched
+	assert.Equal([]float64{1.0, 2.0, 1.0, 2.0, 10.0, 10.0}, m.CodebookRaw().RawMatrix().Data)
+}
+
+func TestSmoothErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(40, 2, 0.5, 1)
+	mc, _ := mergeConfigs([]int{2, 2})
+	m, err := NewMap(mc, data)
+	assert.NoError(err)
+	err = m.Smooth(0.0, "gaussian")
+	assert.EqualError(err, ErrInvalidRadius.Error()+": 0.000000, must be positive and finite")
+	assert.True(errors.Is(m.Smooth(1.0, "foo"), ErrUnsupportedNeighbFn))
+	assert.EqualError(m.Smooth(1.0, "gaussian", 2), "invalid codebook feature: 2")
+	_, err = m.SmoothQE(mat64.NewDense(2, 3, nil), 1.0, "gaussian")
+	assert.Error(err)
+	_, err = m.SmoothQE(nil, 1.0, "gaussian")
+	assert.Error(err)
+}
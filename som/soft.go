@@ -0,0 +1,153 @@
+package som
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// SoftAssign returns a probability distribution of vector x over all map units.
+// The probability of each unit is computed as softmax of negative distance between x
+// and unit codebook vector scaled by temperature. Lower temperatures make the distribution
+// sharper: as the temperature approaches zero, all the probability mass moves to the BMU.
+// Missing components of x, which are stored as NaN, don't contribute to the distances in the same way as in BMU.
+// It returns error if temperature is not a positive number, if x has no observed values or ErrDimMismatch
+// if the dimension of x is different from the map codebook dimension.
+func (m Map) SoftAssign(x []float64, temperature float64) ([]float64, error) {
+	if temperature <= 0.0 {
+		return nil, fmt.Errorf("invalid temperature: %f", temperature)
+	}
+	rows, cols := m.cbDims()
+	if len(x) != cols {
+		return nil, ErrDimMismatch
+	}
+	if unobserved(x) {
+		return nil, fmt.Errorf("invalid sample: no observed values")
+	}
+	probs := make([]float64, rows)
+	m.softAssign(probs, x, temperature)
+
+	return probs, nil
+}
+
+// SoftAssignTo computes probability distribution over map units for every data row
+// and stores it in the corresponding row of dst. See SoftAssign for details.
+// dst must have as many rows as data and as many columns as there are map units.
+// SoftAssignTo does not allocate any memory for data rows without missing values. It returns error
+// if data or dst are nil, if their dimensions are invalid, if any data row has no observed values
+// or if temperature is not a positive number.
+func (m Map) SoftAssignTo(dst, data *mat64.Dense, temperature float64) error {
+	// data can't be nil
+	if data == nil {
+		return fmt.Errorf("invalid data supplied: %v", data)
+	}
+	// dst can't be nil
+	if dst == nil {
+		return fmt.Errorf("invalid destination matrix supplied: %v", dst)
+	}
+	if temperature <= 0.0 {
+		return fmt.Errorf("invalid temperature: %f", temperature)
+	}
+	rows, cols := data.Dims()
+	cbRows, cbCols := m.cbDims()
+	if cols != cbCols {
+		return ErrDimMismatch
+	}
+	if dstRows, dstCols := dst.Dims(); dstRows != rows || dstCols != cbRows {
+		return fmt.Errorf("invalid destination matrix dimensions: %d x %d", dstRows, dstCols)
+	}
+	if _, err := missingValues(data); err != nil {
+		return err
+	}
+	for i := 0; i < rows; i++ {
+		m.softAssign(dst.RawRowView(i), data.RawRowView(i), temperature)
+	}
+
+	return nil
+}
+
+// SoftPredict returns the probability distribution of sample x over all map units computed by SoftAssign,
+// such as fuzzy membership of x in map units. If the map has a scaler set by SetScaler, x is scaled by it
+// first in the same way as by Predict. It fails in the same way as SoftAssign.
+func (m Map) SoftPredict(x []float64, temperature float64) ([]float64, error) {
+	x, err := m.ScaleSample(x)
+	if err != nil {
+		return nil, err
+	}
+	return m.SoftAssign(x, temperature)
+}
+
+// SoftPath returns smoothed trajectory of data rows, which are samples ordered in time, on the map grid:
+// the grid coordinates of every sample averaged over all map units weighted by the sample probabilities
+// computed by SoftPredict, one row per sample. Unlike the BMU coordinates of BMUPath, which jump between
+// units, the averaged coordinates move continuously as the samples move between units; lower temperatures
+// bring them closer to the BMU coordinates. Coordinates are averaged in the grid coordinate space, so paths
+// on toroidal grids don't wrap around. SoftPath returns error if data is nil, if any data row has no observed
+// values or fails in the same way as SoftAssign.
+func (m Map) SoftPath(data mat64.Matrix, temperature float64) (*mat64.Dense, error) {
+	if isNilMatrix(data) {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	if temperature <= 0.0 {
+		return nil, fmt.Errorf("invalid temperature: %f", temperature)
+	}
+	rows, cols := data.Dims()
+	units, cbCols := m.cbDims()
+	if cols != cbCols {
+		return nil, ErrDimMismatch
+	}
+	if _, err := missingValues(data); err != nil {
+		return nil, err
+	}
+	data, err := m.scaled(data)
+	if err != nil {
+		return nil, err
+	}
+	coords := m.grid.coords
+	_, dim := coords.Dims()
+	path := mat64.NewDense(rows, dim, nil)
+	rd := newRowReader(data)
+	probs := make([]float64, units)
+	for i := 0; i < rows; i++ {
+		m.softAssign(probs, rd.row(i), temperature)
+		row := path.RawRowView(i)
+		for u, p := range probs {
+			for k, c := range coords.RawRowView(u) {
+				row[k] += p * c
+			}
+		}
+	}
+	return path, nil
+}
+
+// softAssign stores probabilities of x over map units in dst using log-sum-exp
+// to avoid overflow and underflow for small temperatures. x must have an observed value.
+func (m Map) softAssign(dst, x []float64, temperature float64) {
+	// missing values don't contribute to the distances
+	var filled []float64
+	if hasNaN(x) {
+		filled = make([]float64, len(x))
+	}
+	// store scaled negative distances in dst and find their maximum
+	max := math.Inf(-1)
+	for i := range dst {
+		if filled != nil {
+			dst[i] = -m.partialDistance(filled, x, i) / temperature
+		} else {
+			dst[i] = -m.unitDistance(x, i) / temperature
+		}
+		if dst[i] > max {
+			max = dst[i]
+		}
+	}
+	// log(sum(exp(d))) = max + log(sum(exp(d - max)))
+	sum := 0.0
+	for i := range dst {
+		dst[i] = math.Exp(dst[i] - max)
+		sum += dst[i]
+	}
+	for i := range dst {
+		dst[i] /= sum
+	}
+}
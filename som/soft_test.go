@@ -0,0 +1,185 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoftAssign(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NotNil(m)
+	assert.NoError(err)
+	units, _ := m.codebook.Dims()
+	x := dataMx.RawRowView(0)
+	// invalid temperature
+	errString := "invalid temperature: %f"
+	for _, temp := range []float64{0.0, -1.0} {
+		probs, err := m.SoftAssign(x, temp)
+		assert.Nil(probs)
+		assert.EqualError(err, fmt.Sprintf(errString, temp))
+	}
+	// dimension mismatch
+	probs, err := m.SoftAssign([]float64{1.0}, 1.0)
+	assert.Nil(probs)
+	assert.Equal(ErrDimMismatch, err)
+	// probabilities must sum to 1 for all temperatures
+	bmu, _, err := m.BMU(x)
+	assert.NoError(err)
+	for _, temp := range []float64{1e6, 10.0, 1.0, 1e-3, 1e-12, 1e-300} {
+		probs, err := m.SoftAssign(x, temp)
+		assert.NoError(err)
+		assert.Len(probs, units)
+		assert.InDelta(1.0, floats.Sum(probs), 1e-12)
+		for _, p := range probs {
+			assert.False(math.IsNaN(p), "NaN probability for temperature %g", temp)
+		}
+		// BMU always has the highest probability
+		assert.Equal(bmu, floats.MaxIdx(probs))
+	}
+	// as temperature approaches zero all mass moves to BMU
+	probs, err = m.SoftAssign(x, 1e-300)
+	assert.NoError(err)
+	assert.Equal(1.0, probs[bmu])
+}
+
+func TestSoftAssignTo(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NotNil(m)
+	assert.NoError(err)
+	units, _ := m.codebook.Dims()
+	rows, _ := dataMx.Dims()
+	dst := mat64.NewDense(rows, units, nil)
+	// invalid parameters
+	errString := "invalid data supplied: %v"
+	err = m.SoftAssignTo(dst, nil, 1.0)
+	assert.EqualError(err, fmt.Sprintf(errString, nil))
+	errString = "invalid destination matrix supplied: %v"
+	err = m.SoftAssignTo(nil, dataMx, 1.0)
+	assert.EqualError(err, fmt.Sprintf(errString, nil))
+	errString = "invalid temperature: %f"
+	err = m.SoftAssignTo(dst, dataMx, 0.0)
+	assert.EqualError(err, fmt.Sprintf(errString, 0.0))
+	err = m.SoftAssignTo(dst, mat64.NewDense(rows, 2, nil), 1.0)
+	assert.Equal(ErrDimMismatch, err)
+	errString = "invalid destination matrix dimensions: %d x %d"
+	err = m.SoftAssignTo(mat64.NewDense(rows, 1, nil), dataMx, 1.0)
+	assert.EqualError(err, fmt.Sprintf(errString, rows, 1))
+	// batch results must match single sample results
+	err = m.SoftAssignTo(dst, dataMx, 0.5)
+	assert.NoError(err)
+	for i := 0; i < rows; i++ {
+		probs, err := m.SoftAssign(dataMx.RawRowView(i), 0.5)
+		assert.NoError(err)
+		assert.Equal(probs, dst.RawRowView(i))
+	}
+}
+
+func TestSoftAssignMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NoError(err)
+	// missing values don't contribute to the distances
+	x := append([]float64(nil), dataMx.RawRowView(0)...)
+	x[0] = math.NaN()
+	bmu, _, err := m.BMU(x)
+	assert.NoError(err)
+	for _, temp := range []float64{1.0, 1e-3} {
+		probs, err := m.SoftAssign(x, temp)
+		assert.NoError(err)
+		assert.InDelta(1.0, floats.Sum(probs), 1e-12)
+		assert.Equal(bmu, floats.MaxIdx(probs))
+		predicted, err := m.SoftPredict(x, temp)
+		assert.NoError(err)
+		assert.Equal(probs, predicted)
+	}
+	// samples with no observed values are rejected
+	noObs := make([]float64, len(x))
+	for j := range noObs {
+		noObs[j] = math.NaN()
+	}
+	_, err = m.SoftAssign(noObs, 1.0)
+	assert.EqualError(err, "invalid sample: no observed values")
+	_, err = m.SoftPredict(noObs, 1.0)
+	assert.EqualError(err, "invalid sample: no observed values")
+	data := mat64.NewDense(2, len(x), append(append([]float64(nil), x...), noObs...))
+	units, _ := m.codebook.Dims()
+	err = m.SoftAssignTo(mat64.NewDense(2, units, nil), data, 1.0)
+	assert.EqualError(err, "invalid data row 1: no observed values")
+	_, err = m.SoftPath(data, 1.0)
+	assert.EqualError(err, "invalid data row 1: no observed values")
+}
+
+func TestSoftPredict(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NoError(err)
+	x := dataMx.RawRowView(0)
+	_, err = m.SoftPredict([]float64{1.0}, 1.0)
+	assert.Equal(ErrDimMismatch, err)
+	_, err = m.SoftPredict(x, 0.0)
+	assert.EqualError(err, "invalid temperature: 0.000000")
+	// samples are scaled by the map scaler
+	s, err := FitScaler(MinMax, dataMx)
+	assert.NoError(err)
+	assert.NoError(m.SetScaler(s))
+	probs, err := m.SoftPredict(x, 0.5)
+	assert.NoError(err)
+	scaled, err := s.Transform(mat64.NewDense(1, len(x), x))
+	assert.NoError(err)
+	exp, err := m.SoftAssign(scaled.RawRowView(0), 0.5)
+	assert.NoError(err)
+	assert.InDeltaSlice(exp, probs, 1e-12)
+}
+
+func TestSoftPath(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NoError(err)
+	_, err = m.SoftPath(nil, 1.0)
+	assert.Error(err)
+	_, err = m.SoftPath(dataMx, -1.0)
+	assert.EqualError(err, "invalid temperature: -1.000000")
+	_, err = m.SoftPath(mat64.NewDense(1, 1, nil), 1.0)
+	assert.Equal(ErrDimMismatch, err)
+	rows, _ := dataMx.Dims()
+	// low temperature follows BMUs
+	path, err := m.SoftPath(dataMx, 1e-12)
+	assert.NoError(err)
+	bmuPath, err := m.BMUPath(dataMx)
+	assert.NoError(err)
+	assert.True(mat64.EqualApprox(bmuPath.Coords, path, 1e-9))
+	// high temperature averages coordinates of all units
+	path, err = m.SoftPath(dataMx, 1e12)
+	assert.NoError(err)
+	coords := m.GridCoords()
+	units, dim := coords.Dims()
+	mean := make([]float64, dim)
+	for u := 0; u < units; u++ {
+		floats.Add(mean, coords.RawRowView(u))
+	}
+	floats.Scale(1/float64(units), mean)
+	for i := 0; i < rows; i++ {
+		assert.InDeltaSlice(mean, path.RawRowView(i), 1e-6)
+	}
+	// moderate temperature stays within the grid
+	path, err = m.SoftPath(dataMx, 1.0)
+	assert.NoError(err)
+	for i := 0; i < rows; i++ {
+		for k, c := range path.RawRowView(i) {
+			col := mat64.Col(nil, k, coords)
+			assert.True(c >= floats.Min(col)-1e-9 && c <= floats.Max(col)+1e-9)
+		}
+	}
+}
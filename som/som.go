@@ -1,8 +1,10 @@
 package som
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"math"
 	"math/rand"
 	"runtime"
 	"sort"
@@ -12,6 +14,10 @@ import (
 	"github.com/gonum/matrix/mat64"
 )
 
+// DefaultParBMUThreshold is the default number of codebook elements (units x dim) above which
+// BMU search in sequential training is split across multiple goroutines
+const DefaultParBMUThreshold = 1 << 16
+
 // Map is a Self Organizing Map (SOM)
 type Map struct {
 	// codebook is a matrix which contains SOM codebook vectors
@@ -20,10 +26,55 @@ type Map struct {
 	// grid is a matrix which contains SOM unit coordinages
 	// grid dimensions depend on chosen configuration
 	grid *Grid
+	// labels holds unit labels assigned by LabelUnits
+	labels []string
+	// purity holds the fraction of unit samples with the unit label
+	purity []float64
+	// targets holds unit target values assigned by TrainSupervised
+	targets []float64
+	// classes holds sorted classes of maps created by TrainXYF
+	classes []string
+	// classDist holds unit class distributions of maps created by TrainXYF
+	classDist *mat64.Dense
+	// threshold holds anomaly threshold computed by FitAnomalyThreshold
+	threshold float64
+	// hasThreshold is true if the anomaly threshold has been computed
+	hasThreshold bool
+	// clusters contains cluster ids of map units
+	clusters []int
+	// tree is KD-tree over codebook vectors built by Freeze
+	tree *kdTree
+	// cb32 stores codebook vectors of float32 precision maps; codebook is nil for such maps
+	cb32 *codebook32
+	// norms holds squared norms of codebook vectors used by BMUSparse
+	norms []float64
+	// lastTrain holds parameters of the last map training
+	lastTrain *TrainResult
+	// quality holds map quality measures recorded by RecordQuality
+	quality *mapQuality
+	// relevance holds feature relevances learned by TrainLVQ or TrainRelevance
+	relevance []float64
+	// metric is the distance of samples from codebook vectors; it is nil for euclidean maps
+	metric DistanceFunc
+	// adapt holds the codebook shift of the last Adapt call
+	adapt *AdaptResult
+	// revived counts dead unit revivals of the running training
+	revived int
+	// epochs holds epoch statistics of the running training if they are collected
+	epochs *trainMetrics
+	// profile holds phase timings of the running training if it is profiled
+	profile *trainProfile
+	// noTopology is true if the map was last trained by neural gas or created by TrainLVQ
+	noTopology bool
+	// scaler scales data rows of Predict and PredictStream; it is nil if the map has no scaler
+	scaler *Scaler
+	// features holds codebook feature names set by SetFeatureNames
+	features []string
 }
 
 // NewMap creates new SOM based on the provided configuration.
 // It creates a map grid and initializes codebook vectors using the provided configuration parameter.
+// If the grid size is nil, the grid dimensions are estimated from data by GridSize; c is not modified.
 // NewMap returns error if the provided configuration is not valid or if the data matrix is nil or
 // if the codebook matrix could not be initialized.
 // TODO: Avoid passing in data matrix when creating new map
@@ -32,8 +83,16 @@ func NewMap(c *MapConfig, data *mat64.Dense) (*Map, error) {
 	if data == nil {
 		return nil, fmt.Errorf("invalid input data: %v", data)
 	}
-	// validate codebook config
-	if err := validateCbConfig(c.Cb); err != nil {
+	// estimate grid dimensions from data
+	if c != nil && c.Grid != nil && c.Grid.Size == nil && c.Grid.Type != Spherical {
+		sized, err := autoSize(c, data)
+		if err != nil {
+			return nil, err
+		}
+		c = sized
+	}
+	// validate grid and codebook config
+	if err := validateMapConfig(c); err != nil {
 		return nil, err
 	}
 	// initialize codebook
@@ -46,16 +105,87 @@ func NewMap(c *MapConfig, data *mat64.Dense) (*Map, error) {
 	if err != nil {
 		return nil, err
 	}
+	// float32 codebook replaces the initialized float64 one
+	if c.Cb.Precision == "float32" {
+		m := &Map{
+			cb32:      newCodebook32(codebook),
+			grid:      grid,
+			relevance: featureWeights(c.Cb),
+		}
+		m.norms = m.cbNorms()
+		return m, nil
+	}
 	// return pointer to new map
 	return &Map{
-		codebook: codebook,
-		grid:     grid,
+		codebook:  codebook,
+		grid:      grid,
+		norms:     sqNorms(codebook),
+		metric:    c.Cb.Metric,
+		relevance: featureWeights(c.Cb),
 	}, nil
 }
 
-// Codebook returns a matrix which contains SOM codebook vectors
-func (m Map) Codebook() mat64.Matrix {
-	return m.codebook
+// autoSize returns a copy of map configuration c whose grid dimensions are estimated from data by GridSize.
+// Rows of toroidal hexagon grids are rounded up to an even number.
+func autoSize(c *MapConfig, data *mat64.Dense) (*MapConfig, error) {
+	size, err := GridSize(data, string(c.Grid.UShape))
+	if err != nil {
+		return nil, err
+	}
+	if c.Grid.Type == Toroidal && c.Grid.UShape == Hexagon && size[0]%2 != 0 {
+		size[0]++
+	}
+	grid := *c.Grid
+	grid.Size = size
+	sized := *c
+	sized.Grid = &grid
+	return &sized, nil
+}
+
+// Codebook returns a copy of the matrix which contains SOM codebook vectors: one row per map unit.
+// Modifying the returned matrix does not modify the map.
+func (m Map) Codebook() *mat64.Dense {
+	if m.cb32 != nil {
+		return m.cb32.dense()
+	}
+	return mat64.DenseCopyOf(m.codebook)
+}
+
+// Precision returns codebook storage precision: float64 or float32
+func (m Map) Precision() string {
+	if m.cb32 != nil {
+		return "float32"
+	}
+	return "float64"
+}
+
+// CodebookRaw returns the matrix which contains SOM codebook vectors without copying it.
+// The returned matrix is a view of the map codebook which is changed by training and must not
+// be modified: changing it would invalidate the KD-tree built by Freeze and cached codebook norms.
+// If the codebook is stored in float32 precision, a float64 copy of the codebook is returned.
+func (m Map) CodebookRaw() *mat64.Dense {
+	return m.cb()
+}
+
+// Clone returns a copy of the map which does not share codebook vectors, unit labels or clusters
+// with the map, so it can be read while the map is trained. The copy shares the map grid and scaler,
+// which training does not modify, and is not frozen.
+func (m Map) Clone() *Map {
+	c := m
+	if m.codebook != nil {
+		c.codebook = mat64.DenseCopyOf(m.codebook)
+	}
+	if m.cb32 != nil {
+		cb32 := *m.cb32
+		cb32.data = append([]float32(nil), m.cb32.data...)
+		c.cb32 = &cb32
+	}
+	c.norms = append([]float64(nil), m.norms...)
+	c.labels = append([]string(nil), m.labels...)
+	c.purity = append([]float64(nil), m.purity...)
+	c.clusters = append([]int(nil), m.clusters...)
+	c.tree, c.epochs, c.adapt = nil, nil, nil
+	return &c
 }
 
 // Grid returns SOM grid
@@ -63,16 +193,218 @@ func (m Map) Grid() *Grid {
 	return m.grid
 }
 
-// UnitDist returns a matrix which contains Euclidean distances between SOM units
+// GridCoords returns a copy of the matrix which contains grid coordinates of SOM units:
+// one row per map unit in the same order as the codebook rows.
+func (m Map) GridCoords() *mat64.Dense {
+	return mat64.DenseCopyOf(m.grid.coords)
+}
+
+// Dims returns a copy of SOM grid dimensions: the number of grid rows and columns and,
+// for 3D grids, layers
+func (m Map) Dims() []int {
+	return append([]int(nil), m.grid.size...)
+}
+
+// UShape returns SOM unit shape
+func (m Map) UShape() string {
+	return m.grid.ushape
+}
+
+// UnitIndex returns the index of the map unit in the given grid row and column.
+// Map units are ordered column by column: the unit index is col*rows+row where rows is
+// the number of grid rows returned by Dims. Both row and column are zero based.
+// Units of masked grids are ordered in the same way, but empty grid cells are skipped.
+// It returns error if the row or the column is outside the map grid, if the grid cell holds no unit
+// ErrInvalidDims if the grid is not 2D or ErrUnsupportedGrid if the grid is spherical.
+func (m Map) UnitIndex(row, col int) (int, error) {
+	if len(m.grid.size) != 2 {
+		return -1, fmt.Errorf("%w: %v, unit positions are 2D", ErrInvalidDims, m.grid.size)
+	}
+	if m.grid.radius > 0 {
+		return -1, fmt.Errorf("%w: %s, unit positions are planar", ErrUnsupportedGrid, m.grid.gtype)
+	}
+	rows, cols := m.grid.size[0], m.grid.size[1]
+	if row < 0 || row >= rows || col < 0 || col >= cols {
+		return -1, fmt.Errorf("invalid unit position: row %d, col %d, grid %v", row, col, m.grid.size)
+	}
+	if m.grid.cells != nil {
+		cell := col*rows + row
+		idx := sort.SearchInts(m.grid.cells, cell)
+		if idx == len(m.grid.cells) || m.grid.cells[idx] != cell {
+			return -1, fmt.Errorf("invalid unit position: row %d, col %d, no unit in grid cell", row, col)
+		}
+		return idx, nil
+	}
+	return col*rows + row, nil
+}
+
+// UnitRC returns grid row and column of the map unit with index idx. It is the inverse of UnitIndex.
+// It returns error if idx is not a valid unit index, ErrInvalidDims if the grid is not 2D
+// or ErrUnsupportedGrid if the grid is spherical.
+func (m Map) UnitRC(idx int) (int, int, error) {
+	if len(m.grid.size) != 2 {
+		return -1, -1, fmt.Errorf("%w: %v, unit positions are 2D", ErrInvalidDims, m.grid.size)
+	}
+	if m.grid.radius > 0 {
+		return -1, -1, fmt.Errorf("%w: %s, unit positions are planar", ErrUnsupportedGrid, m.grid.gtype)
+	}
+	rows, cols := m.grid.size[0], m.grid.size[1]
+	units := rows * cols
+	if m.grid.cells != nil {
+		units = len(m.grid.cells)
+	}
+	if idx < 0 || idx >= units {
+		return -1, -1, fmt.Errorf("invalid unit index: %d", idx)
+	}
+	if m.grid.cells != nil {
+		idx = m.grid.cells[idx]
+	}
+	return idx % rows, idx / rows, nil
+}
+
+// UnitDist returns a matrix which contains Euclidean distances between SOM units.
+// Distances between units of toroidal grids wrap around the grid edges.
 func (m Map) UnitDist() (*mat64.Dense, error) {
-	return DistanceMx("euclidean", m.grid.coords)
+	if m.grid.coords == nil {
+		return nil, fmt.Errorf("invalid grid coordinates: %v", m.grid.coords)
+	}
+	return m.grid.unitDist(), nil
+}
+
+// BMU returns the index of Best Match Unit codebook vector for vector x and the distance between them.
+// BMU does not apply the map scaler: if the map has one, x must already be scaled by it, for example
+// by ScaleSample. Samples in the original feature units are mapped by Predict.
+// If several codebook vectors of the same distance are found, the index of the first one is returned.
+// It returns ErrDimMismatch if the dimension of x is different from the map codebook dimension.
+// When the error is returned, both the index and distance are set to -1.
+// If the map is frozen, the search uses KD-tree built by Freeze. If the map has learned feature
+// relevances, distances are weighted by them, otherwise the map codebook metric is used.
+// Missing components of x, which are stored as NaN, don't contribute to the distance;
+// BMU returns error if x has no observed components.
+func (m Map) BMU(x []float64) (int, float64, error) {
+	rows, cols := m.cbDims()
+	if len(x) != cols {
+		return -1, -1.0, ErrDimMismatch
+	}
+	// missing values don't contribute to the distance
+	if hasNaN(x) {
+		return m.partialBMU(x)
+	}
+	// relevance weighted or metric distance
+	if m.relevance != nil || m.metric != nil {
+		bmu, dist := 0, math.MaxFloat64
+		for i := 0; i < rows; i++ {
+			if d := m.unitDistance(x, i); d < dist {
+				bmu, dist = i, d
+			}
+		}
+		return bmu, dist, nil
+	}
+	// frozen map
+	if m.tree != nil {
+		bmu, dist := m.tree.nearest(x)
+		return bmu, dist, nil
+	}
+	// float32 codebook
+	if m.cb32 != nil {
+		bmu, dist := m.cb32.closest(x)
+		return bmu, dist, nil
+	}
+	bmu := 0
+	dist := math.MaxFloat64
+	for i := 0; i < rows; i++ {
+		// no need to check for error: dimensions were checked
+		d, _ := Distance("euclidean", x, m.codebook.RawRowView(i))
+		if d < dist {
+			dist = d
+			bmu = i
+		}
+	}
+
+	return bmu, dist, nil
+}
+
+// KBMU returns indices of k Best Match Unit codebook vectors for vector x and their distances to x.
+// Like BMU, KBMU does not apply the map scaler: if the map has one, x must already be scaled by it.
+// Both slices are sorted by distance in ascending order; units of the same distance are sorted by
+// their index. If k is higher than the number of map units, all units are returned.
+// It returns error if k is not a positive integer or ErrDimMismatch if the dimension of x
+// is different from the map codebook dimension.
+// Missing components of x, which are stored as NaN, don't contribute to the distances;
+// KBMU returns error if x has no observed components.
+func (m Map) KBMU(x []float64, k int) ([]int, []float64, error) {
+	if k <= 0 {
+		return nil, nil, fmt.Errorf("invalid number of best match units requested: %d", k)
+	}
+	rows, cols := m.cbDims()
+	if len(x) != cols {
+		return nil, nil, ErrDimMismatch
+	}
+	if k > rows {
+		k = rows
+	}
+	missing := hasNaN(x)
+	if missing && unobserved(x) {
+		return nil, nil, fmt.Errorf("invalid sample: no observed values")
+	}
+	if missing || m.relevance != nil || m.metric != nil {
+		distance := m.unitDistance
+		// missing values don't contribute to the distance
+		if missing {
+			filled := make([]float64, len(x))
+			distance = func(x []float64, i int) float64 {
+				return m.partialDistance(filled, x, i)
+			}
+		}
+		units := make([]int, rows)
+		dists := make([]float64, rows)
+		for i := range units {
+			units[i], dists[i] = i, distance(x, i)
+		}
+		sort.SliceStable(units, func(a, b int) bool { return dists[units[a]] < dists[units[b]] })
+		closest := make([]float64, k)
+		for i := range closest {
+			closest[i] = dists[units[i]]
+		}
+		return units[:k], closest, nil
+	}
+	if m.cb32 != nil {
+		closest, dists := m.cb32.closestN(k, x)
+		return closest, dists, nil
+	}
+	return closestN("euclidean", k, x, m.codebook)
+}
+
+// UnitCoords returns grid coordinates of the map unit with index idx.
+// It returns nil if idx is not a valid unit index.
+func (m Map) UnitCoords(idx int) []float64 {
+	rows, _ := m.grid.coords.Dims()
+	if idx < 0 || idx >= rows {
+		return nil
+	}
+	return mat64.Row(nil, idx, m.grid.coords)
 }
 
 // BMUs returns a slice which contains indices of Best Match Unit vectors to the map
 // codebook for each vector stored in data rows.
 // It returns error if the data dimension and map codebook dimensions are not the same.
-func (m Map) BMUs(data *mat64.Dense) ([]int, error) {
-	return BMUs(data, m.codebook)
+func (m Map) BMUs(data mat64.Matrix) ([]int, error) {
+	// data can't be nil
+	if isNilMatrix(data) {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, _ := data.Dims()
+	bmus := make([]int, rows)
+	rd := newRowReader(data)
+	for i := 0; i < rows; i++ {
+		bmu, _, err := m.BMU(rd.row(i))
+		if err != nil {
+			return nil, err
+		}
+		bmus[i] = bmu
+	}
+
+	return bmus, nil
 }
 
 // MarshalTo serializes SOM codebook in a given format to writer w.
@@ -81,15 +413,19 @@ func (m Map) BMUs(data *mat64.Dense) ([]int, error) {
 func (m *Map) MarshalTo(format string, w io.Writer) (int, error) {
 	switch format {
 	case "gonum":
-		return m.codebook.MarshalBinaryTo(w)
+		return m.cb().MarshalBinaryTo(w)
 	}
 	// marshal binary to file path
 	return 0, fmt.Errorf("unsupported format: %s", format)
 }
 
 // UMatrix generates SOM u-matrix in a given format and writes the output to w.
-// At the moment only SVG format is supported. It fails with error if the write to w fails.
+// At the moment only SVG format is supported. It fails with error if the write to w fails,
+// ErrNoTopology if the map has no topology or ErrUnsupportedGrid if the grid is spherical.
 func (m Map) UMatrix(w io.Writer, data *mat64.Dense, classMap map[int]int, format, title string) error {
+	if m.noTopology {
+		return ErrNoTopology
+	}
 	switch format {
 	case "svg":
 		{
@@ -122,7 +458,18 @@ func (m Map) UMatrix(w io.Writer, data *mat64.Dense, classMap map[int]int, forma
 				}
 			}
 
-			return UMatrixSVG(m.codebook, m.grid.size, m.grid.ushape, title, w, bmuClassMap)
+			if m.grid.radius > 0 {
+				return fmt.Errorf("%w: %s, u-matrix SVG supports planar grids", ErrUnsupportedGrid, m.grid.gtype)
+			}
+			// units of masked grids don't fill the grid and units of toroidal grids have wrapped neighbours
+			if m.grid.cells != nil || m.grid.periods != nil {
+				umatrix, err := umatrixValues(m.cb(), m.grid)
+				if err != nil {
+					return err
+				}
+				return umatrixSVG(umatrix, m.grid.coords, m.grid.size, m.grid.ushape, title, w, bmuClassMap)
+			}
+			return UMatrixSVG(m.cb(), m.grid.size, m.grid.ushape, title, w, bmuClassMap)
 		}
 	}
 
@@ -137,7 +484,7 @@ func (m Map) mapBMUclasses(data *mat64.Dense, classes map[int]int) (map[int][]in
 	bmuClasses := make(map[int][]int)
 	for row := 0; row < rows; row++ {
 		// find BMU
-		cbi, err := ClosestVec("euclidean", data.RawRowView(row), m.codebook)
+		cbi, _, err := m.BMU(data.RawRowView(row))
 		if err != nil {
 			return nil, err
 		}
@@ -157,97 +504,533 @@ func (m Map) mapBMUclasses(data *mat64.Dense, classes map[int]int) (map[int][]in
 	return bmuClasses, nil
 }
 
+// TrainResult holds the parameters used by SOM training
+type TrainResult struct {
+	// Algorithm is the training algorithm
+	Algorithm Method
+	// Iters is the number of completed training iterations
+	Iters int
+	// Stopped is true if OnEpoch or Patience stopped the training early
+	Stopped bool
+	// Radius is the initial SOM units radius. If AutoRadius is set, it is derived from map dimensions;
+	// if RSchedule is set, it is the first value of the schedule.
+	Radius float64
+	// LRate is the initial SOM learning rate: the first value of LSchedule if it is set
+	LRate float64
+	// Warnings holds dubious combinations of training parameters found by ValidateAll checks
+	Warnings []string
+	// Revived is the number of dead unit revivals if ReviveDeadUnits is set
+	Revived int
+	// QuantErrors holds quantization errors of training epochs if TrackErrors is set
+	QuantErrors []float64
+	// Validation holds training and validation errors of training epochs if Validation data is set
+	Validation *ValidationReport
+	// Timings holds time spent in training phases if Profile is set
+	Timings *Timings
+}
+
 // Train runs a SOM training for a given data set and training configuration parameters.
 // It modifies the map codebook vectors based on the chosen training algorithm.
+// Data can be any matrix: rows of *mat64.Dense are read without copying, other matrices are read using At.
+// It returns error if the supplied training configuration is invalid or training fails
+func (m *Map) Train(c *TrainConfig, data mat64.Matrix, iters int) error {
+	_, err := m.TrainWithResult(c, data, iters)
+	return err
+}
+
+// TrainWithResult runs a SOM training the same way as Train and returns the training parameters
+// which were used, such as the initial radius derived from map dimensions when AutoRadius is set.
 // It returns error if the supplied training configuration is invalid or training fails
-func (m *Map) Train(c *TrainConfig, data *mat64.Dense, iters int) error {
+func (m *Map) TrainWithResult(c *TrainConfig, data mat64.Matrix, iters int) (*TrainResult, error) {
+	return m.TrainContext(context.Background(), c, data, iters)
+}
+
+// TrainContext runs a SOM training the same way as TrainWithResult until ctx is done.
+// The training checks ctx before every iteration: when ctx is cancelled or its deadline passes,
+// the training stops and returns error which wraps ctx.Err(). The codebook of the stopped training
+// holds the codebook vectors of the last completed iteration.
+func (m *Map) TrainContext(ctx context.Context, c *TrainConfig, data mat64.Matrix, iters int) (*TrainResult, error) {
+	return m.trainSeqs(ctx, c, data, nil, iters, trainRand(c))
+}
+
+// trainRand returns random number generator of training configuration c
+// or a new generator seeded by the current time if c has none
+func trainRand(c *TrainConfig) *rand.Rand {
+	if c != nil && c.Rand != nil {
+		return c.Rand
+	}
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
+// canceled returns error which wraps ctx.Err() if ctx is done before iter-th training iteration
+func canceled(ctx context.Context, iter int) error {
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("training canceled after %d iterations: %w", iter, ctx.Err())
+	default:
+		return nil
+	}
+}
+
+// autoRadius returns initial radius derived from map grid dimensions: half of the largest dimension
+func autoRadius(size []int) float64 {
+	maxDim := 0
+	for _, dim := range size {
+		if dim > maxDim {
+			maxDim = dim
+		}
+	}
+	return float64(maxDim) / 2.0
+}
+
+// train validates training parameters and runs the training algorithm.
+// Random number generator r is used to pick random samples in sequential training.
+func (m *Map) train(c *TrainConfig, data mat64.Matrix, iters int, r *rand.Rand) (*TrainResult, error) {
+	return m.trainSeqs(context.Background(), c, data, nil, iters, r)
+}
+
+// trainSeqs trains the map in the same way as train until ctx is done. Temporal training treats data
+// as sequences which start at data rows stored in starts; if starts is nil, data holds a single sequence.
+// If c has training phases, they are trained instead of iters iterations.
+func (m *Map) trainSeqs(ctx context.Context, c *TrainConfig, data mat64.Matrix, starts []int, iters int, r *rand.Rand) (*TrainResult, error) {
+	if c != nil && len(c.Phases) > 0 {
+		return m.trainPhases(ctx, c, data, starts, r)
+	}
 	// number of iterations must be a positive integer
 	if iters <= 0 {
-		return fmt.Errorf("invalid number of iterations: %d", iters)
+		return nil, fmt.Errorf("invalid number of iterations: %d", iters)
 	}
 	// nil data passed in
-	if data == nil {
-		return fmt.Errorf("invalid data supplied: %v", data)
+	if isNilMatrix(data) {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	// data and codebook dimensions must match
+	_, cols := data.Dims()
+	if _, cbCols := m.cbDims(); cols != cbCols {
+		return nil, ErrDimMismatch
 	}
 	// validate the training configuration
 	if err := validateTrainConfig(c); err != nil {
-		return err
+		return nil, err
+	}
+	if err := checkMissing(c, data); err != nil {
+		return nil, err
+	}
+	// validation data and codebook dimensions must match
+	if !isNilMatrix(c.Validation) {
+		if _, vCols := c.Validation.Dims(); vCols != cols {
+			return nil, fmt.Errorf("invalid validation data: %w", ErrDimMismatch)
+		}
+	}
+	rows, _ := data.Dims()
+	if err := checkWeights(c, rows); err != nil {
+		return nil, err
+	}
+	warnings := crossCheck(m.grid.Coords(), m.grid.Size(), UnitShape(m.grid.UShape()), c, rows, iters)
+	// derive the initial radius without modifying the supplied configuration
+	if c.AutoRadius {
+		tc := *c
+		tc.Radius = autoRadius(m.grid.Size())
+		c = &tc
 	}
+	log := trainLogger(c)
+	for _, w := range warnings {
+		log.Printf("som: warning: %s", w)
+	}
+	radius, lRate := c.initial(iters)
+	log.Printf("som: %s training started: %d iterations, radius %.4f, learning rate %.4f", c.Algorithm, iters, radius, lRate)
+	// training modifies the codebook: discard KD-tree
+	m.tree = nil
+	m.revived = 0
+	m.epochs = nil
+	m.profile = newTrainProfile(c)
 	// run the training
+	start := time.Now()
+	var err error
 	switch c.Algorithm {
 	case "seq":
-		return m.seqTrain(c, data, iters)
+		err = m.seqTrain(ctx, c, data, iters, r)
 	case "batch":
-		return m.batchTrain(c, data, iters)
+		err = m.batchTrain(ctx, c, data, iters)
+	case NeuralGas:
+		err = m.neuralGasTrain(ctx, c, data, iters, r)
+	case GrowingNeuralGas:
+		err = m.gngTrain(ctx, c, data, iters, r)
+	case Temporal:
+		err = m.temporalTrain(ctx, c, data, starts, iters, r)
+	case GSOM:
+		err = m.gsomTrain(ctx, c, data, iters, r)
+	}
+	// neural gas does not arrange codebook vectors on the grid
+	m.noTopology = c.Algorithm == NeuralGas || c.Algorithm == GrowingNeuralGas
+	// training modified the codebook: discard KD-tree of approximate BMU search,
+	// recompute codebook norms and discard quality measures
+	m.tree = nil
+	m.norms = m.cbNorms()
+	m.quality = nil
+	if err != nil {
+		log.Printf("som: %s training failed: %s", c.Algorithm, err)
+		return nil, err
+	}
+	stopped := m.epochs.stoppedAt()
+	if stopped > 0 {
+		log.Printf("som: %s training stopped early: %d/%d iterations", c.Algorithm, stopped, iters)
+		iters = stopped
+	}
+	log.Printf("som: %s training finished: %d iterations", c.Algorithm, iters)
+	elapsed := time.Since(start)
+	setDuration(c, elapsed)
+	timings := m.profile.timings(elapsed)
+	m.profile = nil
+	if timings != nil {
+		log.Printf("som: %s training timings: %s", c.Algorithm, timings)
+	}
+	m.lastTrain = &TrainResult{
+		Algorithm:   c.Algorithm,
+		Iters:       iters,
+		Stopped:     stopped > 0,
+		Radius:      radius,
+		LRate:       lRate,
+		Warnings:    warnings,
+		Revived:     m.revived,
+		QuantErrors: m.epochs.quantErrors(),
+		Validation:  m.epochs.validation(),
+		Timings:     timings,
 	}
 
-	return nil
+	return m.lastTrain, nil
 }
 
 // QuantError computes SOM quantization error for the supplied data set
-// It returns the quantization error or fails with error if the passed in data is nil
-// or the distance betweent vectors could not be calculated.
+// It returns the quantization error or fails with error if the passed in data is nil, if any data row
+// has no observed values or the distance betweent vectors could not be calculated.
+// If the map has a distance metric or learned feature relevances, distances are measured by them.
+// Data rows with missing values, which are stored as NaN, are compared on their observed features.
 // When the error is returned, quantization error is set to -1.0.
-func (m Map) QuantError(data *mat64.Dense) (float64, error) {
-	return QuantError(data, m.codebook)
+func (m Map) QuantError(data mat64.Matrix) (float64, error) {
+	if isNilMatrix(data) {
+		return -1.0, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	missing, err := missingValues(data)
+	if err != nil {
+		return -1.0, err
+	}
+	if m.metric == nil && m.relevance == nil && !missing {
+		return QuantError(data, m.cb())
+	}
+	// mean metric or relevance weighted distance of data samples from their BMUs
+	var qErr float64
+	rows, _ := data.Dims()
+	rd := newRowReader(data)
+	for i := 0; i < rows; i++ {
+		_, d, err := m.BMU(rd.row(i))
+		if err != nil {
+			return -1.0, err
+		}
+		qErr += d
+	}
+	return qErr / float64(rows), nil
 }
 
 // TopoProduct computes SOM topographic product
 // It returns a single number or fails with error if the product could not be computed
+// or ErrNoTopology if the map has no topology.
 func (m Map) TopoProduct() (float64, error) {
-	return TopoProduct(m.codebook, m.grid.coords)
+	if m.noTopology {
+		return 0.0, ErrNoTopology
+	}
+	return topoProduct(m.cb(), m.grid.unitDist()), nil
 }
 
 // TopoError computes SOM topographic error for a given data set.
-// It returns a single number or fails with error if the error could not be computed
-func (m Map) TopoError(data *mat64.Dense) (float64, error) {
-	return TopoError(data, m.codebook, m.grid.coords)
+// It returns a single number or fails with error if the error could not be computed, if any data row
+// has no observed values or ErrNoTopology if the map has no topology.
+// If the map has a distance metric or learned feature relevances, BMUs are found by them.
+// Data rows with missing values, which are stored as NaN, are compared on their observed features.
+func (m Map) TopoError(data mat64.Matrix) (float64, error) {
+	if m.noTopology {
+		return -1.0, ErrNoTopology
+	}
+	if isNilMatrix(data) {
+		return -1.0, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	missing, err := missingValues(data)
+	if err != nil {
+		return -1.0, err
+	}
+	neighb := m.grid.neighbRadius()
+	closest := euclideanClosest(m.cb())
+	if m.metric != nil || m.relevance != nil || missing {
+		closest = func(x []float64, n int) ([]int, error) {
+			units, _, err := m.KBMU(x, n)
+			return units, err
+		}
+	}
+	return topoError(data, closest, m.grid.unitDist(), neighb)
+}
+
+// Embedding computes SOM embedding accuracy for the supplied data set at significance level alpha.
+// It returns the fraction of data features whose distribution is statistically indistinguishable
+// from the distribution of the codebook and per-feature pass flags, or fails with error if
+// the accuracy could not be computed.
+func (m Map) Embedding(data *mat64.Dense, alpha float64) (float64, []bool, error) {
+	return Embedding(data, m.cb(), alpha)
 }
 
 // seqTrain runs sequential SOM training algorithm on a given data set
-func (m *Map) seqTrain(tc *TrainConfig, data *mat64.Dense, iters int) error {
+func (m *Map) seqTrain(ctx context.Context, tc *TrainConfig, data mat64.Matrix, iters int, r *rand.Rand) error {
 	rows, _ := data.Dims()
-	// create random number generator
-	rSrc := rand.NewSource(time.Now().UnixNano())
-	r := rand.New(rSrc)
-	// calculate unit distances
-	unitDist, err := m.UnitDist()
+	t, err := newSeqTrainer(m, tc)
 	if err != nil {
 		return err
 	}
-	// retrieve Neighbourhood function
-	nFn := tc.NeighbFn
+	defer t.close()
+	log := trainLogger(tc)
+	rd := newRowReader(data)
+	samples := newSampler(r, rows, tc.Weights)
+	revive := reviveEvery(tc, rows)
+	units, _ := m.cbDims()
+	t.metrics = newTrainMetrics(m, tc, units, iters, rows)
+	// BMUs of sparse samples of euclidean maps are searched on their non-zero elements
+	sparse, _ := data.(*SparseMatrix)
+	if sparse != nil && m.relevance == nil && m.metric == nil {
+		m.norms = m.cbNorms()
+	} else {
+		sparse = nil
+	}
 	// perform iters number of learning iterations
-	for i := 0; i < iters; i++ {
-		// pick a random sample from dataset
-		sample := data.RawRowView(r.Intn(rows))
-		// no need to check for error here:
-		// sample and codebook are not nil and have the same dimension
-		bmu, _ := ClosestVec("euclidean", sample, m.codebook)
-		// no need to check for errors:
-		// LRate and Radius are checked by config validation
-		lRate, _ := LRate(i, iters, tc.LDecay, tc.LRate)
-		radius, _ := Radius(i, iters, tc.RDecay, tc.Radius)
-		// pick the bmu unit distance row
-		bmuDists := unitDist.RawRowView(bmu)
-		// find units which are within the radius
-		for i := 0; i < len(bmuDists); i++ {
-			// bmu distance to i-th map unit
-			dist := bmuDists[i]
-			// we are within BMU radius
-			if dist < radius {
-				// update particular codebook vector
-				m.seqUpdateCbVec(i, sample, lRate, radius, dist, nFn)
+	for i := tc.startIter; i < iters; i++ {
+		if err := canceled(ctx, i); err != nil {
+			return err
+		}
+		if err := m.checkpoint(tc, i, iters); err != nil {
+			return err
+		}
+		logProgress(log, tc, i, iters)
+		if sparse == nil {
+			m.trainTree(tc, i, rows)
+		}
+		if revive > 0 && i > 0 && i%revive == 0 {
+			m.logRevived(log, tc, i, iters, m.reviveDead(tc, data))
+			if sparse != nil {
+				m.norms = m.cbNorms()
 			}
 		}
+		// pick a random sample from dataset
+		if sparse != nil {
+			t.sparseStep(i, iters, sparse, samples.next(), rd.buf)
+		} else {
+			t.step(i, iters, rd.row(samples.next()))
+		}
+		if !t.metrics.update(i) {
+			break
+		}
 	}
+	m.epochs = t.metrics
 
 	return nil
 }
 
+// seqTrainer holds sequential training state and scratch buffers which are reused
+// in every training step, so the training steps don't allocate any memory
+type seqTrainer struct {
+	// m is the trained map
+	m *Map
+	// tc is SOM training configuration
+	tc *TrainConfig
+	// index finds map units within BMU radius
+	index *unitIndex
+	// near holds indices of map units within BMU radius
+	near []int
+	// search is parallel BMU search; it is nil if BMU search is serial
+	search *parBMUSearch
+	// metrics collects training metrics; it is nil if training has no metrics
+	metrics *trainMetrics
+}
+
+// newSeqTrainer creates new sequential trainer of map m. If the map codebook is larger
+// than the training configuration threshold, parallel BMU search is started which
+// must be stopped by calling close.
+func newSeqTrainer(m *Map, tc *TrainConfig) (*seqTrainer, error) {
+	// calculate unit distances
+	unitDist, err := m.UnitDist()
+	if err != nil {
+		return nil, err
+	}
+	units, _ := unitDist.Dims()
+	t := &seqTrainer{
+		m:     m,
+		tc:    tc,
+		index: m.grid.unitIndex(unitDist),
+		near:  make([]int, 0, units),
+	}
+	// split BMU search across workers for large codebooks
+	workers := trainWorkers(tc)
+	threshold := tc.ParBMUThreshold
+	if threshold == 0 {
+		threshold = DefaultParBMUThreshold
+	}
+	cbRows, cbCols := m.cbDims()
+	if workers > 1 && threshold > 0 && cbRows*cbCols > threshold {
+		t.search = newParBMUSearch(m, workers)
+	}
+	return t, nil
+}
+
+// step performs iter-th out of iters training steps using sample
+func (t *seqTrainer) step(iter, iters int, sample []float64) {
+	t.m.profile.start()
+	bmu, dist := t.bmu(sample)
+	t.metrics.sample(bmu, dist)
+	t.m.profile.lap(profileBMU)
+	t.update(iter, iters, bmu, sample)
+}
+
+// sparseStep performs iter-th out of iters training steps using row of sparse data x.
+// The BMU is searched by BMUSparse and the dense form of the row is stored in buf.
+// Cached codebook norms of the moved units are kept up to date.
+func (t *seqTrainer) sparseStep(iter, iters int, x *SparseMatrix, row int, buf []float64) {
+	t.m.profile.start()
+	indices, values := x.Row(row)
+	// no need to check for error here: sparse matrix rows are valid
+	bmu, dist, _ := t.m.BMUSparse(indices, values)
+	t.metrics.sample(bmu, dist)
+	t.m.profile.lap(profileBMU)
+	t.update(iter, iters, bmu, x.denseRow(buf, row))
+	for _, i := range t.near {
+		t.m.norms[i] = t.m.unitNorm(i)
+	}
+	t.m.profile.lap(profileUpdate)
+}
+
+// bmu returns BMU of sample and its distance to the sample
+func (t *seqTrainer) bmu(sample []float64) (int, float64) {
+	if t.search != nil && t.m.tree == nil && !hasNaN(sample) {
+		return t.search.bmu(sample)
+	}
+	// no need to check for error here: sample and codebook have the same dimension
+	// and callers check that samples have observed values
+	bmu, dist, _ := t.m.BMU(sample)
+	return bmu, dist
+}
+
+// update moves codebook vectors of units within radius of unit bmu towards sample
+// in iter-th out of iters training steps. Profiled training times the update from the previous
+// lap of the map profile, which must be started by the caller.
+func (t *seqTrainer) update(iter, iters, bmu int, sample []float64) {
+	lRate, radius := t.tc.lRate(iter, iters), t.tc.radius(iter, iters)
+	t.m.profile.lap(profileDecay)
+	t.move(bmu, sample, lRate, radius)
+	t.m.profile.lap(profileUpdate)
+}
+
+// move moves codebook vectors of units within radius of unit bmu towards sample using
+// learning rate lRate. Indices of the moved units are left in t.near.
+func (t *seqTrainer) move(bmu int, sample []float64, lRate, radius float64) {
+	// pick the bmu unit distance row
+	bmuDists := t.index.unitDist.RawRowView(bmu)
+	// update codebook vectors of units which are within the radius
+	t.near = t.index.within(t.near[:0], bmu, radius)
+	for _, i := range t.near {
+		t.m.seqUpdateCbVec(i, sample, lRate, radius, bmuDists[i], t.tc.NeighbFn)
+	}
+}
+
+// close stops parallel BMU search
+func (t *seqTrainer) close() {
+	if t.search != nil {
+		t.search.close()
+	}
+}
+
+// parBMUSearch splits BMU search across multiple goroutines: each goroutine scans
+// a contiguous range of codebook vectors for every searched sample
+type parBMUSearch struct {
+	// m is the searched map
+	m *Map
+	// queries sends searched samples to search goroutines
+	queries []chan []float64
+	// done receives notifications about finished searches
+	done chan struct{}
+	// bmus and dists hold partial search results of each goroutine
+	bmus  []int
+	dists []float64
+}
+
+// newParBMUSearch starts workers goroutines which search codebook of map m.
+// The goroutines must be stopped by calling close.
+func newParBMUSearch(m *Map, workers int) *parBMUSearch {
+	rows, _ := m.cbDims()
+	if workers > rows {
+		workers = rows
+	}
+	s := &parBMUSearch{
+		m:       m,
+		queries: make([]chan []float64, workers),
+		done:    make(chan struct{}, workers),
+		bmus:    make([]int, workers),
+		dists:   make([]float64, workers),
+	}
+	chunk := (rows + workers - 1) / workers
+	for w := 0; w < workers; w++ {
+		from, to := w*chunk, (w+1)*chunk
+		if to > rows {
+			to = rows
+		}
+		s.queries[w] = make(chan []float64)
+		go s.scan(w, from, to)
+	}
+	return s
+}
+
+// scan searches codebook vectors in range from to to-1 for every sample received by w-th goroutine
+func (s *parBMUSearch) scan(w, from, to int) {
+	for x := range s.queries[w] {
+		bmu, dist := -1, math.MaxFloat64
+		for i := from; i < to; i++ {
+			if d := s.m.unitDistance(x, i); d < dist {
+				bmu, dist = i, d
+			}
+		}
+		s.bmus[w], s.dists[w] = bmu, dist
+		s.done <- struct{}{}
+	}
+}
+
+// bmu returns the same Best Match Unit of x and its distance as Map.BMU: the partial results
+// are merged in codebook range order, so ties are always broken in favour of the lowest index.
+// The dimension of x must be the same as the map codebook dimension.
+func (s *parBMUSearch) bmu(x []float64) (int, float64) {
+	for _, q := range s.queries {
+		q <- x
+	}
+	for range s.queries {
+		<-s.done
+	}
+	bmu, dist := 0, math.MaxFloat64
+	for w := range s.bmus {
+		if s.bmus[w] >= 0 && s.dists[w] < dist {
+			bmu, dist = s.bmus[w], s.dists[w]
+		}
+	}
+	return bmu, dist
+}
+
+// close stops search goroutines
+func (s *parBMUSearch) close() {
+	for _, q := range s.queries {
+		close(q)
+	}
+}
+
 // seqUpdateCbVec updates codebook vector on row cbIdx given the learning rate l,
 // radius r, distance d and neihgbourhood function nFn
 func (m *Map) seqUpdateCbVec(cbIdx int, vec []float64, l, r, d float64, nFn NeighbFunc) {
+	// float32 codebook
+	if m.cb32 != nil {
+		m.seqUpdateCbVec32(cbIdx, vec, l, r, d, nFn)
+		return
+	}
 	// pick codebook vector that should be updated
 	cbVec := m.codebook.RawRowView(cbIdx)
 	mul := l
@@ -257,51 +1040,148 @@ func (m *Map) seqUpdateCbVec(cbIdx int, vec []float64, l, r, d float64, nFn Neig
 		if d > 0.0 {
 			mul *= nFn(d, r)
 		}
+		// missing values don't move the codebook vector
+		if math.IsNaN(vec[i]) {
+			continue
+		}
 		cbVec[i] = cbVec[i] + mul*(vec[i]-cbVec[i])
 	}
 }
 
-// batchConfig holds batch training configuration
-type batchConfig struct {
-	// tc is SOM training configuration
-	tc *TrainConfig
-	// iters is a number of batch iterations
-	iters int
+// seqUpdateCbVec32 updates float32 codebook vector in the same way as seqUpdateCbVec.
+// The update is computed in float64 and only the result is stored in float32.
+func (m *Map) seqUpdateCbVec32(cbIdx int, vec []float64, l, r, d float64, nFn NeighbFunc) {
+	cbVec := m.cb32.rowView(cbIdx)
+	mul := l
+	for i := 0; i < len(cbVec); i++ {
+		if d > 0.0 {
+			mul *= nFn(d, r)
+		}
+		if math.IsNaN(vec[i]) {
+			continue
+		}
+		cb := float64(cbVec[i])
+		cbVec[i] = float32(cb + mul*(vec[i]-cb))
+	}
+}
+
+// trainWorkers returns the number of worker goroutines used by training configuration tc
+func trainWorkers(tc *TrainConfig) int {
+	if tc.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return tc.Workers
 }
 
 // batchResult holds results of batch algorithm for a particular data input batch
 type batchResult struct {
-	// vecs is a slice of nghb scaled data vectors
-	vecs [][]float64
-	// nghbs is a slice of BMU neighbourhoods
-	nghbs []float64
+	// sums holds the sum of batch data vectors of every BMU unit
+	sums *mat64.Dense
+	// counts holds the number of batch data vectors of every BMU unit;
+	// vectors of weighted samples are counted by their weights
+	counts []float64
+	// hits holds the number of batch data vectors of every BMU unit of weighted samples;
+	// it is nil if the samples are not weighted
+	hits []float64
+	// dist holds the sum of distances of batch data vectors to their BMUs
+	dist float64
+	// observed holds the number of observed values of every component of batch data vectors
+	// of every BMU unit; it is nil if the data has no missing values
+	observed *mat64.Dense
 }
 
-// batchTrain runs batch SOM training on a given data set
-func (m *Map) batchTrain(tc *TrainConfig, data *mat64.Dense, iters int) error {
-	cbRows, _ := m.codebook.Dims()
+// batchTrain runs batch SOM training on a given data set.
+// Every iteration sums data vectors S and counts data vectors c of every BMU unit and
+// updates the codebook to H*S / H*c, where H is the matrix of neighbourhood function values
+// between map units. H is built once for every distinct radius and stored either as dense
+// matrix, so the codebook update is computed by matrix multiplications, or in sparse form if
+// only a few unit pairs are within the radius. If H exceeds TrainConfig.NghbTableMaxBytes,
+// the neighbourhood function values are computed on the fly.
+// Codebook vectors of units with zero accumulated neighbourhood weight are not updated.
+func (m *Map) batchTrain(ctx context.Context, tc *TrainConfig, data mat64.Matrix, iters int) error {
+	cbRows, cbCols := m.cbDims()
 	rows, _ := data.Dims()
-	// batchConfig holds training config and number of iterations
-	bc := &batchConfig{
-		tc:    tc,
-		iters: iters,
-	}
 	// calculate unit distances
 	unitDist, err := m.UnitDist()
 	if err != nil {
 		return err
 	}
 	// number of worker goroutines
-	workers := runtime.NumCPU()
+	workers := trainWorkers(tc)
+	if workers > rows {
+		workers = rows
+	}
 	// evenly distribute batch work between workers
 	workerBatch := rows / workers
+	// map unit index finds units within BMU radius
+	index := m.grid.unitIndex(unitDist)
+	// neighbourhood table memory limit
+	maxBytes := tc.NghbTableMaxBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultNghbTableMaxBytes
+	}
+	// neighbourhood table is rebuilt only when the radius changes
+	var table *nghbTable
+	// codebook update buffers are reused in every iteration
+	sums := mat64.NewDense(cbRows, cbCols, nil)
+	counts := make([]float64, cbRows)
+	// training metrics count samples of the units of weighted training
+	var hits []float64
+	if tc.Weights != nil {
+		hits = make([]float64, cbRows)
+	}
+	vecs := mat64.NewDense(cbRows, cbCols, nil)
+	weights := make([]float64, cbRows)
+	// components of codebook vectors are averaged over observed values of data with missing values
+	missing, err := missingValues(data)
+	if err != nil {
+		return err
+	}
+	var observed, obsWeights *mat64.Dense
+	if missing {
+		observed, obsWeights = mat64.NewDense(cbRows, cbCols, nil), mat64.NewDense(cbRows, cbCols, nil)
+	}
+	log := trainLogger(tc)
+	// robust update rules need all samples of every unit neighbourhood
+	var robust *robustUpdate
+	if tc.UpdateRule == "median" || tc.UpdateRule == "trimmed" {
+		robust = newRobustUpdate(m, tc, data, index)
+	}
+	revive := reviveEvery(tc, rows)
+	metrics := newTrainMetrics(m, tc, cbRows, iters, 1)
 	// train for a number of iterations
-	for i := 0; i < iters; i++ {
+	for i := tc.startIter; i < iters; i++ {
+		if err := canceled(ctx, i); err != nil {
+			return err
+		}
+		if err := m.checkpoint(tc, i, iters); err != nil {
+			return err
+		}
+		logProgress(log, tc, i, iters)
+		m.trainTree(tc, i, 1)
+		if revive > 0 && i > 0 && i%revive == 0 {
+			m.logRevived(log, tc, i, iters, m.reviveDead(tc, data))
+		}
+		if robust != nil {
+			if err := robust.apply(tc.radius(i, iters)); err != nil {
+				return err
+			}
+			if !metrics.update(i) {
+				break
+			}
+			continue
+		}
+		m.profile.start()
 		// reset from index and input count
 		from := 0
 		count := workerBatch
-		// create batch results channel
-		results := make(chan *batchResult, workers*40)
+		// batch results are collected per worker so they are always summed in the same order
+		results := make([]*batchResult, workers)
+		// codebook norms are shared by all workers
+		var norms []float64
+		if m.cb32 == nil {
+			norms = sqNorms(m.codebook)
+		}
 		wg := &sync.WaitGroup{}
 		// start worker goroutines
 		for j := 0; j < workers; j++ {
@@ -316,84 +1196,154 @@ func (m *Map) batchTrain(tc *TrainConfig, data *mat64.Dense, iters int) error {
 				count = rows - from
 			}
 			wg.Add(1)
-			go m.processBatch(results, wg, bc, unitDist, data, from, count, i)
+			go m.processBatch(&results[j], wg, norms, data, tc.Weights, from, count, missing, tc.MatMul)
 		}
-		// wait for workers to finish and close the result channel
-		go func() {
-			wg.Wait()
-			close(results)
-		}()
+		// wait for workers to finish
+		wg.Wait()
 		// collect batch results from all workers
-		vecs := make([][]float64, cbRows)
-		nghbs := make([]float64, cbRows)
-		for result := range results {
-			for k := 0; k < len(result.vecs); k++ {
-				if result.vecs[k] != nil {
-					if vecs[k] != nil {
-						for l := 0; l < len(vecs[k]); l++ {
-							vecs[k][l] += result.vecs[k][l]
-						}
-					} else {
-						vecs[k] = result.vecs[k]
-					}
-					nghbs[k] += result.nghbs[k]
-				}
+		sums.Copy(results[0].sums)
+		copy(counts, results[0].counts)
+		if hits != nil {
+			copy(hits, results[0].hits)
+		}
+		dist := results[0].dist
+		if missing {
+			observed.Copy(results[0].observed)
+		}
+		for _, result := range results[1:] {
+			sums.Add(sums, result.sums)
+			for k, c := range result.counts {
+				counts[k] += c
 			}
+			for k, c := range result.hits {
+				hits[k] += c
+			}
+			dist += result.dist
+			if missing {
+				observed.Add(observed, result.observed)
+			}
+		}
+		if hits != nil {
+			metrics.batch(hits, dist)
+		} else {
+			metrics.batch(counts, dist)
+		}
+		m.profile.lap(profileBMU)
+		radius := tc.radius(i, iters)
+		if maxBytes > 0 && (table == nil || table.stale(radius, tc.NghbRadiusTolerance)) {
+			table = newNghbTable(index, radius, tc.NeighbFn, maxBytes)
+		}
+		m.profile.lap(profileDecay)
+		if table != nil {
+			table.apply(sums, counts, vecs, weights, tc.MatMul)
+		} else {
+			nghbApply(index, radius, tc.NeighbFn, sums, counts, vecs, weights)
+		}
+		var cb *mat64.Dense
+		if missing {
+			// neighbourhood weights of observed values of every component
+			if table != nil {
+				table.apply(observed, counts, obsWeights, weights, tc.MatMul)
+			} else {
+				nghbApply(index, radius, tc.NeighbFn, observed, counts, obsWeights, weights)
+			}
+			cb = m.cb()
 		}
 		// update codebook vectors
-		for k := 0; k < cbRows; k++ {
-			if vecs[k] != nil {
-				for l := 0; l < len(vecs[k]); l++ {
-					vecs[k][l] = vecs[k][l] / nghbs[k]
+		for k, weight := range weights {
+			// keep codebook vectors of units with no data in their neighbourhood
+			if weight == 0.0 {
+				continue
+			}
+			vec := vecs.RawRowView(k)
+			if missing {
+				// keep components with no observed values in the unit neighbourhood
+				for l, w := range obsWeights.RawRowView(k) {
+					if w > 0.0 {
+						vec[l] /= w
+					} else {
+						vec[l] = cb.At(k, l)
+					}
+				}
+			} else {
+				for l := range vec {
+					vec[l] /= weight
 				}
-				m.codebook.SetRow(k, vecs[k])
 			}
+			m.setUnit(k, vec)
+		}
+		m.profile.lap(profileUpdate)
+		if !metrics.update(i) {
+			break
 		}
 	}
+	m.epochs = metrics
 
 	return nil
 }
 
-// processRow processes data rows and sends tehm down the results channel
-func (m Map) processBatch(res chan<- *batchResult, wg *sync.WaitGroup,
-	bc *batchConfig, unitDist, data *mat64.Dense, from, count, iter int) {
-	// allocate codebook vectors and neighbourhoods
-	rows, _ := m.codebook.Dims()
-	vecs := make([][]float64, rows)
-	nghbs := make([]float64, rows)
-	// retrieve Neighbourhood function
-	nFn := bc.tc.NeighbFn
-	// iterate through the whole batch
-	for i := from; i < count+from; i++ {
-		row := data.RawRowView(i)
-		// find codebook BMU for this data row
-		bmu, _ := ClosestVec("euclidean", row, m.codebook)
-		// calculate radius for this iteration
-		radius, _ := Radius(iter, bc.iters, bc.tc.RDecay, bc.tc.Radius)
-		// pick the BMU's distance row
-		bmuDists := unitDist.RawRowView(bmu)
-		for j := 0; j < len(bmuDists); j++ {
-			// bmu distance to i-th map unit
-			dist := bmuDists[j]
-			// when in BMU radius, scale and add to all neighbourhood vecs
-			if dist < radius {
-				// calculate neighbourhood function
-				nghb := nFn(dist, radius)
-				if vecs[j] != nil {
-					for k := 0; k < len(vecs[j]); k++ {
-						vecs[j][k] += nghb * row[k]
-					}
-				} else {
-					vecs[j] = make([]float64, len(row))
-					for k := 0; k < len(vecs[j]); k++ {
-						vecs[j][k] = nghb * row[k]
-					}
-				}
-				nghbs[j] += nghb
+// processBatch finds BMUs of count data rows starting at row from and stores the sums and
+// counts of data vectors of every BMU unit in res. If missing is set, missing data values are skipped
+// and the numbers of observed values of every component are stored as well. If weights is not nil,
+// data vectors are weighted by weights of their rows in the sums and counts.
+// norms must contain squared norms of codebook vectors unless the codebook is stored in float32.
+// Matrix products of BMU search are computed by mul or by mat64.Dense.Mul if mul is nil.
+func (m Map) processBatch(res **batchResult, wg *sync.WaitGroup, norms []float64, data mat64.Matrix, weights []float64, from, count int, missing bool, mul MatMulFunc) {
+	rows, cols := m.cbDims()
+	sums := mat64.NewDense(rows, cols, nil)
+	counts := make([]float64, rows)
+	var hits []float64
+	if weights != nil {
+		hits = make([]float64, rows)
+	}
+	// find codebook BMUs for all data rows in the batch
+	bmus := make([]int, count)
+	dists := make([]float64, count)
+	rd := newRowReader(data)
+	if m.tree != nil || m.cb32 != nil || m.relevance != nil || m.metric != nil {
+		for i := range bmus {
+			bmus[i], dists[i], _ = m.BMU(rd.row(from + i))
+		}
+	} else {
+		matrixBlockBMUs(data, m.codebook, norms, from, from+count, bmus, dists, mul)
+		if missing {
+			m.missingBMUs(data, from, from+count, bmus, dists)
+		}
+	}
+	// sum data vectors of every BMU
+	var observed *mat64.Dense
+	if missing {
+		observed = mat64.NewDense(rows, cols, nil)
+	}
+	for i, bmu := range bmus {
+		row := rd.row(from + i)
+		w := 1.0
+		if weights != nil {
+			w = weights[from+i]
+			hits[bmu]++
+		}
+		if !missing {
+			sum := sums.RawRowView(bmu)
+			for k, v := range row {
+				sum[k] += w * v
+			}
+			counts[bmu] += w
+			continue
+		}
+		sum, obs := sums.RawRowView(bmu), observed.RawRowView(bmu)
+		for k, v := range row {
+			if !math.IsNaN(v) {
+				sum[k] += w * v
+				obs[k] += w
 			}
 		}
+		counts[bmu] += w
+	}
+	dist := 0.0
+	for _, d := range dists {
+		dist += d
 	}
-	// send batchResult down results channel
-	res <- &batchResult{vecs: vecs, nghbs: nghbs}
+	// store batchResult
+	*res = &batchResult{sums: sums, counts: counts, hits: hits, dist: dist, observed: observed}
 	wg.Done()
 }
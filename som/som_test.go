@@ -1,12 +1,18 @@
 package som
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"math/rand"
 	"os"
+	"runtime"
+	"sync"
 	"testing"
 
 	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/matrix"
 	"github.com/milosgajdos83/gosom/pkg/utils"
 	"github.com/stretchr/testify/assert"
 )
@@ -98,6 +104,35 @@ func TestNewMap(t *testing.T) {
 	mSom.Cb.Dim = origDim
 }
 
+func TestNewMapAutoSize(t *testing.T) {
+	assert := assert.New(t)
+
+	// elongated data gets elongated grid
+	rnd := rand.New(rand.NewSource(1))
+	data := mat64.NewDense(100, 2, nil)
+	for i := 0; i < 100; i++ {
+		data.Set(i, 0, 4.0*rnd.Float64())
+		data.Set(i, 1, rnd.Float64())
+	}
+	c := &MapConfig{
+		Grid: &GridConfig{Type: "planar", UShape: "hexagon"},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}
+	m, err := NewMap(c, data)
+	assert.NoError(err)
+	size, err := GridSize(data, "hexagon")
+	assert.NoError(err)
+	assert.Equal(size, m.Dims())
+	assert.True(size[0] > size[1])
+	// configuration is not modified
+	assert.Nil(c.Grid.Size)
+	// toroidal hexagon grids have even number of rows
+	c.Grid.Type = "toroidal"
+	m, err = NewMap(c, data)
+	assert.NoError(err)
+	assert.Equal(0, m.Dims()[0]%2)
+}
+
 func TestCodebook(t *testing.T) {
 	assert := assert.New(t)
 
@@ -114,6 +149,107 @@ func TestCodebook(t *testing.T) {
 	assert.Equal(cols, cbCols)
 }
 
+func TestCodebookCopy(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NoError(err)
+	cb := m.Codebook()
+	assert.True(mat64.Equal(cb, m.CodebookRaw()))
+	// copy does not modify the map
+	orig := cb.At(0, 0)
+	cb.Set(0, 0, orig+1.0)
+	assert.Equal(orig, m.CodebookRaw().At(0, 0))
+	// raw codebook is a view
+	assert.True(m.CodebookRaw() == m.codebook)
+}
+
+func TestClone(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NoError(err)
+	m.Freeze()
+	c := m.Clone()
+	assert.True(mat64.Equal(m.CodebookRaw(), c.CodebookRaw()))
+	assert.False(c.Frozen())
+	// training the map does not modify the clone
+	cb := c.Codebook()
+	assert.NoError(m.Train(tSom, dataMx, 100))
+	assert.True(mat64.Equal(cb, c.CodebookRaw()))
+	assert.False(mat64.Equal(m.CodebookRaw(), c.CodebookRaw()))
+}
+
+func TestGridAccessors(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, uShape := range []UnitShape{Rectangle, Hexagon} {
+		c := &MapConfig{
+			Grid: &GridConfig{
+				Size:   []int{3, 4},
+				Type:   "planar",
+				UShape: uShape,
+			},
+			Cb: &CbConfig{
+				Dim:      mSom.Cb.Dim,
+				InitFunc: RandInit,
+			},
+		}
+		m, err := NewMap(c, dataMx)
+		assert.NoError(err)
+		assert.Equal(string(uShape), m.UShape())
+		dims := m.Dims()
+		assert.Equal([]int{3, 4}, dims)
+		// dims are copied
+		dims[0] = 10
+		assert.Equal([]int{3, 4}, m.Dims())
+		coords := m.GridCoords()
+		assert.True(mat64.Equal(coords, m.Grid().Coords()))
+		coords.Set(0, 0, 10.0)
+		assert.Equal(0.0, m.Grid().Coords().At(0, 0))
+		// round trip across the whole lattice
+		seen := make(map[int]bool)
+		for row := 0; row < 3; row++ {
+			for col := 0; col < 4; col++ {
+				idx, err := m.UnitIndex(row, col)
+				assert.NoError(err)
+				seen[idx] = true
+				r, c, err := m.UnitRC(idx)
+				assert.NoError(err)
+				assert.Equal(row, r)
+				assert.Equal(col, c)
+				// index agrees with grid coordinates
+				x, y := float64(col), float64(row)
+				if uShape == "hexagon" {
+					x += 0.5 * float64(row%2)
+					y *= math.Sqrt(0.75)
+				}
+				assert.InDelta(x, m.UnitCoords(idx)[0], 1e-9)
+				assert.InDelta(y, m.UnitCoords(idx)[1], 1e-9)
+			}
+		}
+		assert.Len(seen, 12)
+		for idx := 0; idx < 12; idx++ {
+			assert.True(seen[idx])
+		}
+	}
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NoError(err)
+	rows, cols := m.Dims()[0], m.Dims()[1]
+	for _, rc := range [][]int{{-1, 0}, {0, -1}, {rows, 0}, {0, cols}} {
+		idx, err := m.UnitIndex(rc[0], rc[1])
+		assert.Error(err)
+		assert.Equal(-1, idx)
+	}
+	for _, idx := range []int{-1, rows * cols} {
+		r, c, err := m.UnitRC(idx)
+		assert.Error(err)
+		assert.Equal(-1, r)
+		assert.Equal(-1, c)
+	}
+}
+
 func TestGrid(t *testing.T) {
 	assert := assert.New(t)
 
@@ -180,8 +316,11 @@ func TestTrain(t *testing.T) {
 	origRadius := tSom.Radius
 	tSom.Radius = -10
 	err = m.Train(tSom, dataMx, iters)
-	assert.Error(err)
+	assert.True(errors.Is(err, ErrInvalidRadius))
 	tSom.Radius = origRadius
+	// data dimension must match codebook dimension
+	err = m.Train(tSom, mat64.NewDense(2, 2, []float64{1.0, 2.0, 3.0, 4.0}), iters)
+	assert.Equal(ErrDimMismatch, err)
 	// default config should not throw any errrors
 	err = m.Train(tSom, dataMx, iters)
 	assert.NoError(err)
@@ -193,6 +332,358 @@ func TestTrain(t *testing.T) {
 	tSom.Algorithm = origAlgorithm
 }
 
+func TestTrainRand(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	train := func(alg Method, seed int64) *mat64.Dense {
+		m, err := NewMap(&MapConfig{
+			Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+			Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+		}, data)
+		assert.NoError(err)
+		tc := gsomTrainConfig()
+		tc.Algorithm, tc.Leak, tc.GrowThreshold = alg, 0.5, 0.1
+		tc.Rand = rand.New(rand.NewSource(seed))
+		assert.NoError(m.Train(tc, data, 300))
+		return m.Codebook()
+	}
+	for _, alg := range []Method{Seq, NeuralGas, GrowingNeuralGas, GSOM} {
+		// trainings with generators of the same seed pick the same samples
+		assert.True(mat64.Equal(train(alg, 1), train(alg, 1)), string(alg))
+		assert.False(mat64.Equal(train(alg, 1), train(alg, 2)), string(alg))
+	}
+	// growing maps
+	tc := gsomTrainConfig()
+	tc.Rand = rand.New(rand.NewSource(1))
+	m1, err := TrainGSOM(data, 0.5, tc)
+	assert.NoError(err)
+	tc.Rand = rand.New(rand.NewSource(1))
+	m2, err := TrainGSOM(data, 0.5, tc)
+	assert.NoError(err)
+	assert.True(mat64.Equal(m1.Codebook(), m2.Codebook()))
+	// temporal training picks random sequences
+	seqs := []*mat64.Dense{unitBlobs(20, 1), unitBlobs(20, 2), unitBlobs(20, 3)}
+	trainSeqs := func(seed int64) *mat64.Dense {
+		m, err := NewMap(&MapConfig{
+			Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+			Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+		}, data)
+		assert.NoError(err)
+		tc := gsomTrainConfig()
+		tc.Algorithm, tc.Leak, tc.Rand = Temporal, 0.5, rand.New(rand.NewSource(seed))
+		assert.NoError(m.TrainSequences(tc, seqs, 300))
+		return m.Codebook()
+	}
+	assert.True(mat64.Equal(trainSeqs(1), trainSeqs(1)))
+	assert.False(mat64.Equal(trainSeqs(1), trainSeqs(2)))
+}
+
+func TestTrainContext(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	for _, alg := range []Method{Seq, Batch, NeuralGas, GrowingNeuralGas, Temporal, GSOM} {
+		m, err := NewMap(&MapConfig{
+			Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+			Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+		}, data)
+		assert.NoError(err)
+		tc := gsomTrainConfig()
+		tc.Algorithm, tc.Leak, tc.GrowThreshold = alg, 0.5, 0.1
+		orig := m.Codebook()
+		// cancelled training does not change the codebook
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		res, err := m.TrainContext(ctx, tc, data, 1000)
+		assert.Nil(res)
+		assert.True(errors.Is(err, context.Canceled), string(alg))
+		assert.True(mat64.Equal(orig, m.Codebook()))
+		// the training stops once the context is done
+		ctx, cancel = context.WithCancel(context.Background())
+		tc.OnEpoch = func(e Epoch) bool {
+			if e.Epoch == 1 {
+				cancel()
+			}
+			return true
+		}
+		_, err = m.TrainContext(ctx, tc, data, 1000)
+		epoch := 100
+		if alg == Batch {
+			epoch = 1
+		}
+		assert.EqualError(err, fmt.Sprintf("training canceled after %d iterations: context canceled", 2*epoch))
+		// expired deadline
+		ctx, cancel = context.WithTimeout(context.Background(), 0)
+		_, err = m.TrainContext(ctx, tc, data, 10)
+		cancel()
+		assert.True(errors.Is(err, context.DeadlineExceeded))
+		// training runs to its end without cancelling
+		tc.OnEpoch = nil
+		res, err = m.TrainContext(context.Background(), tc, data, 1000)
+		assert.NoError(err)
+		assert.Equal(1000, res.Iters)
+	}
+}
+
+func TestTrainAutoRadius(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := matrix.MakeRandom(200, 3, -10.0, 10.0)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{20, 10},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      3,
+			InitFunc: RandInit,
+		},
+	}
+	for _, alg := range []Method{Seq, Batch} {
+		autoTc := &TrainConfig{
+			Algorithm:  alg,
+			AutoRadius: true,
+			RDecay:     "exp",
+			NeighbFn:   Gaussian,
+			LRate:      0.5,
+			LDecay:     "exp",
+		}
+		tc := *autoTc
+		tc.AutoRadius, tc.Radius = false, 10.0
+		m, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		ref, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		res, err := m.train(autoTc, data, 50, rand.New(rand.NewSource(3)))
+		assert.NoError(err)
+		refRes, err := ref.train(&tc, data, 50, rand.New(rand.NewSource(3)))
+		assert.NoError(err)
+		assert.Equal(ref.codebook.RawMatrix().Data, m.codebook.RawMatrix().Data)
+		assert.Equal(refRes, res)
+		assert.Equal(alg, res.Algorithm)
+		assert.Equal(50, res.Iters)
+		assert.Equal(10.0, res.Radius)
+		assert.Equal(0.5, res.LRate)
+		// the supplied configuration is not modified
+		assert.Equal(0.0, autoTc.Radius)
+		if alg == "seq" {
+			assert.Equal([]string{"50 sequential training iterations don't visit all 200 data samples"}, res.Warnings)
+		} else {
+			assert.Empty(res.Warnings)
+		}
+		// public entry point reports the derived radius
+		res, err = m.TrainWithResult(autoTc, data, 5)
+		assert.NoError(err)
+		assert.Equal(10.0, res.Radius)
+	}
+	// radius can't be set together with AutoRadius
+	tc := &TrainConfig{
+		Algorithm:  "batch",
+		Radius:     2.0,
+		AutoRadius: true,
+		RDecay:     "exp",
+		NeighbFn:   Gaussian,
+		LRate:      0.5,
+		LDecay:     "exp",
+	}
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	res, err := m.TrainWithResult(tc, data, 5)
+	assert.Nil(res)
+	assert.True(errors.Is(err, ErrInvalidRadius))
+	assert.Equal(1.0, autoRadius([]int{2, 1}))
+	assert.Equal(7.5, autoRadius([]int{4, 15}))
+}
+
+func TestMapMetric(t *testing.T) {
+	assert := assert.New(t)
+
+	// samples in four directions with very different magnitudes
+	r := rand.New(rand.NewSource(1))
+	dirs := [][]float64{{1, 0.1}, {0.1, 1}, {-1, 0.1}, {0.1, -1}}
+	data := mat64.NewDense(200, 2, nil)
+	for i := 0; i < 200; i++ {
+		scale := 0.1 + 10*r.Float64()
+		dir := dirs[i%4]
+		data.SetRow(i, []float64{scale * (dir[0] + 0.05*r.NormFloat64()), scale * (dir[1] + 0.05*r.NormFloat64())})
+	}
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{2, 2},
+			Type:   Planar,
+			UShape: Rectangle,
+		},
+		Cb: &CbConfig{
+			Dim:      2,
+			InitFunc: RandInit,
+			Metric:   Cosine,
+		},
+	}
+	for _, alg := range []Method{Seq, Batch} {
+		m, err := NewMap(mc, data)
+		assert.NoError(err)
+		tc := &TrainConfig{
+			Algorithm: alg,
+			Radius:    1.0,
+			RDecay:    ExpDecay,
+			NeighbFn:  Gaussian,
+			LRate:     0.5,
+			LDecay:    ExpDecay,
+		}
+		_, err = m.train(tc, data, relIters(tc, 200), rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		// samples of the same direction share their BMU regardless of their magnitude
+		bmus, err := m.BMUs(data)
+		assert.NoError(err)
+		units := make(map[int]bool)
+		for i, bmu := range bmus {
+			assert.Equal(bmus[i%4], bmu, "%s sample %d", alg, i)
+			units[bmu] = true
+		}
+		assert.Len(units, 4, "%s", alg)
+		// BMU searches use the metric
+		x := data.RawRowView(0)
+		bmu, dist, err := m.BMU(x)
+		assert.NoError(err)
+		exp, _ := ClosestVec("cosine", x, m.codebook)
+		assert.Equal(exp, bmu)
+		assert.Equal(Cosine(x, m.codebook.RawRowView(bmu)), dist)
+		predicted, dists, err := m.Predict(data, 2)
+		assert.NoError(err)
+		assert.Equal(bmus, predicted)
+		assert.Equal(dist, dists[0])
+		closest, _, err := m.KBMU(x, 2)
+		assert.NoError(err)
+		assert.Equal(bmu, closest[0])
+		qe, err := m.QuantError(data)
+		assert.NoError(err)
+		assert.True(qe < 0.05, "%s: %f", alg, qe)
+		_, err = m.TopoError(data)
+		assert.NoError(err)
+		// KD-tree search is euclidean
+		assert.False(m.Freeze())
+	}
+
+	// float32 codebooks don't support metrics
+	mc.Cb.Precision = "float32"
+	_, err := NewMap(mc, data)
+	assert.True(errors.Is(err, ErrUnsupportedMetric))
+	mc.Cb.Precision = ""
+	m, err := NewMapWithOptions(data, WithDims(2, 2), WithMetric("cosine"))
+	assert.NoError(err)
+	assert.NotNil(m.metric)
+	m, err = NewMapWithOptions(data, WithDims(2, 2), WithMetric("euclidean"))
+	assert.NoError(err)
+	assert.Nil(m.metric)
+}
+
+func TestTrainToroidal(t *testing.T) {
+	assert := assert.New(t)
+
+	data := mat64.NewDense(1, 2, []float64{1.0, 1.0})
+	for _, alg := range []Method{Seq, Batch} {
+		for _, gridType := range []GridType{Planar, Toroidal, Cylindrical} {
+			mc := &MapConfig{
+				Grid: &GridConfig{
+					Size:   []int{5, 5},
+					Type:   gridType,
+					UShape: Rectangle,
+				},
+				Cb: &CbConfig{
+					Dim:      2,
+					InitFunc: RandInit,
+				},
+			}
+			m, err := NewMap(mc, data)
+			assert.NoError(err)
+			// only the corner unit matches the sample
+			m.codebook.Scale(0, m.codebook)
+			m.codebook.SetRow(0, []float64{1.0, 1.0})
+			tc := &TrainConfig{
+				Algorithm: alg,
+				Radius:    1.5,
+				RDecay:    LinDecay,
+				NeighbFn:  Bubble,
+				LRate:     0.5,
+				LDecay:    LinDecay,
+			}
+			_, err = m.train(tc, data, 1, rand.New(rand.NewSource(1)))
+			assert.NoError(err)
+			// the neighbourhood of the corner unit wraps around the edges of toroidal grids
+			// and around the left and right edges of cylindrical grids
+			for unit, wraps := range map[int]bool{4: gridType == Toroidal, 20: gridType != Planar, 24: gridType == Toroidal} {
+				assert.Equal(wraps, m.codebook.At(unit, 0) > 0, "%s %s unit %d", alg, gridType, unit)
+			}
+			assert.True(m.codebook.At(6, 0) > 0)
+			assert.Equal(0.0, m.codebook.At(12, 0))
+		}
+	}
+}
+
+func TestTrain3D(t *testing.T) {
+	assert := assert.New(t)
+
+	// samples around corners of a cube
+	r := rand.New(rand.NewSource(1))
+	data := mat64.NewDense(400, 3, nil)
+	for i := 0; i < 400; i++ {
+		corner := i % 8
+		for j := 0; j < 3; j++ {
+			data.Set(i, j, 10*float64(corner>>uint(j)&1)+r.NormFloat64()*0.5)
+		}
+	}
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{2, 2, 2},
+			Type:   Planar,
+			UShape: Rectangle,
+		},
+		Cb: &CbConfig{
+			Dim:      3,
+			InitFunc: LinInit,
+		},
+	}
+	for _, alg := range []Method{Seq, Batch} {
+		m, err := NewMap(mc, data)
+		assert.NoError(err)
+		tc := &TrainConfig{
+			Algorithm: alg,
+			Radius:    1.0,
+			RDecay:    ExpDecay,
+			NeighbFn:  Gaussian,
+			LRate:     0.5,
+			LDecay:    ExpDecay,
+		}
+		_, err = m.train(tc, data, relIters(tc, 400), rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		// every corner has its own unit and neighbouring corners have neighbouring units
+		bmus, err := m.BMUs(data)
+		assert.NoError(err)
+		seen := make(map[int]bool)
+		for _, bmu := range bmus[:8] {
+			seen[bmu] = true
+		}
+		assert.Len(seen, 8, "%s", alg)
+		qe, err := m.QuantError(data)
+		assert.NoError(err)
+		assert.True(qe < 1.5, "%s: %f", alg, qe)
+		te, err := m.TopoError(data)
+		assert.NoError(err)
+		assert.True(te < 0.05, "%s: %f", alg, te)
+	}
+
+	m, err := NewMap(mc, data)
+	assert.NoError(err)
+	assert.Equal([]int{2, 2, 2}, m.Dims())
+	_, err = m.UnitIndex(0, 0)
+	assert.True(errors.Is(err, ErrInvalidDims))
+	_, _, err = m.UnitRC(0)
+	assert.True(errors.Is(err, ErrInvalidDims))
+	assert.Contains(m.Summary(), "dims: 2x2x2\n")
+}
+
 func TestMapQuantError(t *testing.T) {
 	assert := assert.New(t)
 
@@ -205,3 +696,626 @@ func TestMapQuantError(t *testing.T) {
 	assert.NoError(err)
 	assert.True(qe > 0.0)
 }
+
+func TestMapEmbedding(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(300, 3, 3, 10.0, -10.0, 2.0, 33)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{10, 10},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim: 3,
+			// initialize codebook away from the data
+			InitFunc: func(d *mat64.Dense, dims []int) (*mat64.Dense, error) {
+				cb, err := RandInit(d, dims)
+				if err != nil {
+					return nil, err
+				}
+				cb.Apply(func(i, j int, v float64) float64 { return v/10.0 + 30.0 }, cb)
+				return cb, nil
+			},
+		},
+	}
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	// untrained map does not embed the data
+	untrained, flags, err := m.Embedding(data, 0.05)
+	assert.NoError(err)
+	assert.Len(flags, 3)
+	// converged map embeds the data
+	tCfg := &TrainConfig{
+		Algorithm: "seq",
+		Radius:    5.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+	err = m.Train(tCfg, data, 20000)
+	assert.NoError(err)
+	trained, flags, err := m.Embedding(data, 0.05)
+	assert.NoError(err)
+	assert.Len(flags, 3)
+	assert.True(trained-untrained >= 0.5, "untrained: %f, trained: %f", untrained, trained)
+}
+
+func TestMapBMU(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NotNil(m)
+	assert.NoError(err)
+	// dimension mismatch
+	idx, dist, err := m.BMU([]float64{1.0, 2.0})
+	assert.Equal(ErrDimMismatch, err)
+	assert.Equal(-1, idx)
+	assert.Equal(-1.0, dist)
+	idx, dist, err = m.BMU(nil)
+	assert.Equal(ErrDimMismatch, err)
+	// BMU must be the same as the closest codebook vector
+	rows, _ := dataMx.Dims()
+	for i := 0; i < rows; i++ {
+		sample := dataMx.RawRowView(i)
+		idx, dist, err = m.BMU(sample)
+		assert.NoError(err)
+		closest, err := ClosestVec("euclidean", sample, m.codebook)
+		assert.NoError(err)
+		assert.Equal(closest, idx)
+		d, err := Distance("euclidean", sample, m.codebook.RawRowView(closest))
+		assert.NoError(err)
+		assert.Equal(d, dist)
+	}
+	// codebook vector is its own BMU
+	idx, dist, err = m.BMU(m.codebook.RawRowView(3))
+	assert.NoError(err)
+	assert.Equal(3, idx)
+	assert.Equal(0.0, dist)
+}
+
+func TestMapUnitCoords(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NotNil(m)
+	assert.NoError(err)
+	units := utils.IntProduct(mSom.Grid.Size)
+	for i := 0; i < units; i++ {
+		coords := m.UnitCoords(i)
+		assert.Equal(mat64.Row(nil, i, m.grid.coords), coords)
+	}
+	// invalid unit index
+	assert.Nil(m.UnitCoords(-1))
+	assert.Nil(m.UnitCoords(units))
+}
+
+func BenchmarkMapBMU(b *testing.B) {
+	data := utils.GenerateClusters(1000, 10, 5, 10.0, -10.0, 2.0, 10)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{20, 20},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      10,
+			InitFunc: RandInit,
+		},
+	}
+	m, err := NewMap(mCfg, data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	rows, _ := data.Dims()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.BMU(data.RawRowView(i % rows))
+	}
+}
+
+func TestMapKBMU(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NotNil(m)
+	assert.NoError(err)
+	// codebook with deliberate distance ties
+	m.codebook = mat64.NewDense(6, 2, []float64{
+		0.0, 2.0,
+		1.0, 0.0,
+		0.0, 1.0,
+		-1.0, 0.0,
+		0.0, 1.0,
+		3.0, 3.0,
+	})
+	x := []float64{0.0, 0.0}
+	// invalid k
+	errString := "invalid number of best match units requested: %d"
+	for _, k := range []int{0, -2} {
+		idx, dists, err := m.KBMU(x, k)
+		assert.Nil(idx)
+		assert.Nil(dists)
+		assert.EqualError(err, fmt.Sprintf(errString, k))
+	}
+	// dimension mismatch
+	idx, dists, err := m.KBMU([]float64{0.0}, 2)
+	assert.Nil(idx)
+	assert.Nil(dists)
+	assert.Equal(ErrDimMismatch, err)
+	// ties are broken by unit index
+	testCases := []struct {
+		k     int
+		idx   []int
+		dists []float64
+	}{
+		{1, []int{1}, []float64{1.0}},
+		{2, []int{1, 2}, []float64{1.0, 1.0}},
+		{4, []int{1, 2, 3, 4}, []float64{1.0, 1.0, 1.0, 1.0}},
+		{5, []int{1, 2, 3, 4, 0}, []float64{1.0, 1.0, 1.0, 1.0, 2.0}},
+		{100, []int{1, 2, 3, 4, 0, 5}, []float64{1.0, 1.0, 1.0, 1.0, 2.0, 3 * math.Sqrt2}},
+	}
+	for _, tc := range testCases {
+		idx, dists, err := m.KBMU(x, tc.k)
+		assert.NoError(err)
+		assert.Equal(tc.idx, idx)
+		assert.InDeltaSlice(tc.dists, dists, 1e-12)
+	}
+	// first KBMU is the BMU
+	bmu, dist, err := m.BMU(x)
+	assert.NoError(err)
+	idx, dists, err = m.KBMU(x, 3)
+	assert.NoError(err)
+	assert.Equal(bmu, idx[0])
+	assert.Equal(dist, dists[0])
+}
+
+func TestMapParBMU(t *testing.T) {
+	assert := assert.New(t)
+
+	data := utils.GenerateClusters(200, 8, 4, 10.0, -10.0, 2.0, 10)
+	cb := utils.GenerateClusters(101, 8, 4, 10.0, -10.0, 2.0, 20)
+	// duplicate codebook vectors: ties must be broken by the lowest index
+	cb.SetRow(70, cb.RawRowView(3))
+	cb.SetRow(99, cb.RawRowView(50))
+	m := &Map{codebook: cb}
+	rows, _ := data.Dims()
+	for _, workers := range []int{1, 2, 3, 7, 200} {
+		s := newParBMUSearch(m, workers)
+		for i := 0; i < rows; i++ {
+			bmu, dist, err := m.BMU(data.RawRowView(i))
+			assert.NoError(err)
+			parBmu, parDist := s.bmu(data.RawRowView(i))
+			assert.Equal(bmu, parBmu)
+			assert.Equal(dist, parDist)
+		}
+		bmu, _ := s.bmu(cb.RawRowView(70))
+		assert.Equal(3, bmu)
+		bmu, _ = s.bmu(cb.RawRowView(99))
+		assert.Equal(50, bmu)
+		s.close()
+	}
+	// parallel BMU search does not change training results
+	tc := &TrainConfig{
+		Algorithm: "seq",
+		Radius:    5.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+	codebooks := make([]*mat64.Dense, 2)
+	for i, threshold := range []int{-1, 1} {
+		m, err := NewMap(mSom, data)
+		assert.NoError(err)
+		tc.Workers, tc.ParBMUThreshold = 3, threshold
+		_, err = m.train(tc, data, 500, rand.New(rand.NewSource(10)))
+		assert.NoError(err)
+		codebooks[i] = m.codebook
+	}
+	assert.True(mat64.Equal(codebooks[0], codebooks[1]))
+}
+
+func BenchmarkMapParBMU(b *testing.B) {
+	dim := 64
+	for _, units := range []int{1000, 10000, 100000} {
+		cb := utils.GenerateClusters(units, dim, 10, 10.0, -10.0, 2.0, 10)
+		data := utils.GenerateClusters(100, dim, 10, 10.0, -10.0, 2.0, 20)
+		m := &Map{codebook: cb}
+		b.Run(fmt.Sprintf("serial/units=%d", units), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m.BMU(data.RawRowView(i % 100))
+			}
+		})
+		b.Run(fmt.Sprintf("parallel/units=%d", units), func(b *testing.B) {
+			s := newParBMUSearch(m, runtime.NumCPU())
+			defer s.close()
+			for i := 0; i < b.N; i++ {
+				s.bmu(data.RawRowView(i % 100))
+			}
+		})
+	}
+}
+
+func TestSeqTrainer(t *testing.T) {
+	assert := assert.New(t)
+
+	// codebook trained by sequential training before the trainer was introduced
+	expCb := []float64{
+		2.7268773364818926, -0.9010099336078189, 4.594210715787185, -4.696952372274239,
+		2.6532809010505773, -2.4177424097733318, 4.27944343124893, 0.1888397289820707,
+		0.2791598272026202, 0.3732837855359351, 3.9343275266659394, 0.9241367191848586,
+		-1.1635215632816318, -0.6749741188978996, -0.23778135054338193, 3.0816013227828294,
+		-3.039636648713732, 1.8912856725863876, -0.8789816840594152, 3.7469840898728863,
+		-2.616503023445782, 0.26057003245550464, -5.437793944639136, 0.6469407005181257,
+	}
+	// matrix.MakeRandom uses fixed seed, so the training data is the same in every run
+	data, _ := matrix.MakeRandom(100, 4, -10.0, 10.0)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{2, 3},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      4,
+			InitFunc: RandInit,
+		},
+	}
+	tc := &TrainConfig{
+		Algorithm: "seq",
+		Radius:    3.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+		Workers:   2,
+	}
+	// serial and parallel BMU search
+	for _, threshold := range []int{-1, 1} {
+		m, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		tc.ParBMUThreshold = threshold
+		_, err = m.train(tc, data, 1000, rand.New(rand.NewSource(7)))
+		assert.NoError(err)
+		assert.Equal(expCb, m.codebook.RawMatrix().Data)
+	}
+	// training steps don't allocate
+	for _, threshold := range []int{-1, 1} {
+		m, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		tc.ParBMUThreshold = threshold
+		st, err := newSeqTrainer(m, tc)
+		assert.NoError(err)
+		sample := data.RawRowView(0)
+		allocs := testing.AllocsPerRun(100, func() {
+			st.step(10, 1000, sample)
+		})
+		assert.Equal(0.0, allocs)
+		st.close()
+	}
+}
+
+func BenchmarkSeqTrainerStep(b *testing.B) {
+	data := utils.GenerateClusters(1000, 64, 10, 10.0, -10.0, 2.0, 10)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{30, 30},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      64,
+			InitFunc: RandInit,
+		},
+	}
+	rows, _ := data.Dims()
+	for _, threshold := range []int{-1, 1} {
+		m, err := NewMap(mCfg, data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		tc := &TrainConfig{
+			Algorithm:       "seq",
+			Radius:          5.0,
+			RDecay:          "exp",
+			NeighbFn:        Gaussian,
+			LRate:           0.5,
+			LDecay:          "exp",
+			Workers:         2,
+			ParBMUThreshold: threshold,
+		}
+		st, err := newSeqTrainer(m, tc)
+		if err != nil {
+			b.Fatal(err)
+		}
+		name := "serial"
+		if st.search != nil {
+			name = "parallel"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				st.step(i%1000, 1000, data.RawRowView(i%rows))
+			}
+		})
+		st.close()
+	}
+}
+
+// loopBatchTrain is reference element-wise loop implementation of batch training
+func loopBatchTrain(m *Map, tc *TrainConfig, data *mat64.Dense, iters int) {
+	cbRows, cbCols := m.cbDims()
+	rows, _ := data.Dims()
+	unitDist, _ := m.UnitDist()
+	bmus := make([]int, rows)
+	for i := 0; i < iters; i++ {
+		blockBMUs(data, m.codebook, sqNorms(m.codebook), 0, rows, bmus, nil, nil)
+		radius, _ := Radius(i, iters, tc.RDecay, tc.Radius)
+		vecs := mat64.NewDense(cbRows, cbCols, nil)
+		nghbs := make([]float64, cbRows)
+		for r, bmu := range bmus {
+			row := data.RawRowView(r)
+			for j, dist := range unitDist.RawRowView(bmu) {
+				if dist < radius {
+					nghb := tc.NeighbFn(dist, radius)
+					vec := vecs.RawRowView(j)
+					for k := range vec {
+						vec[k] += nghb * row[k]
+					}
+					nghbs[j] += nghb
+				}
+			}
+		}
+		for j := 0; j < cbRows; j++ {
+			if nghbs[j] == 0.0 {
+				continue
+			}
+			vec := vecs.RawRowView(j)
+			for k := range vec {
+				vec[k] /= nghbs[j]
+			}
+			m.codebook.SetRow(j, vec)
+		}
+	}
+}
+
+func TestBatchTrainMatMul(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := matrix.MakeRandom(300, 5, -10.0, 10.0)
+	mCfg := makePrecisionMapCfg([]int{6, 5}, 5, "")
+	tc := &TrainConfig{
+		Algorithm: "batch",
+		Radius:    4.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+		Workers:   2,
+	}
+	ref, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	assert.NoError(ref.Train(tc, data, 5))
+	// custom matrix products replace gonum products of BMU search and neighbourhood table
+	var mu sync.Mutex
+	calls := 0
+	tc.MatMul = func(dst *mat64.Dense, a, b mat64.Matrix) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		dst.Mul(a, b)
+	}
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	assert.NoError(m.Train(tc, data, 5))
+	assert.True(calls > 10)
+	assert.True(mat64.EqualApprox(ref.codebook, m.codebook, 1e-9))
+}
+
+func TestBatchTrain(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := matrix.MakeRandom(500, 5, -10.0, 10.0)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{8, 6},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      5,
+			InitFunc: RandInit,
+		},
+	}
+	for _, nFn := range []NeighbFunc{Gaussian, Bubble, MexicanHat} {
+		tc := &TrainConfig{
+			Algorithm: "batch",
+			Radius:    4.0,
+			RDecay:    "exp",
+			NeighbFn:  nFn,
+			LRate:     0.5,
+			LDecay:    "exp",
+			Workers:   3,
+		}
+		m, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		ref, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		assert.NoError(m.batchTrain(context.Background(), tc, data, 5))
+		loopBatchTrain(ref, tc, data, 5)
+		exp := ref.codebook.RawMatrix().Data
+		for i, v := range m.codebook.RawMatrix().Data {
+			assert.InDelta(exp[i], v, 1e-9)
+		}
+	}
+	// the codebook doesn't depend on how the data is sharded across workers
+	var codebooks []*mat64.Dense
+	for _, workers := range []int{1, 4, 7, 600} {
+		tc := &TrainConfig{
+			Algorithm: "batch",
+			Radius:    4.0,
+			RDecay:    "exp",
+			NeighbFn:  Gaussian,
+			LRate:     0.5,
+			LDecay:    "exp",
+			Workers:   workers,
+		}
+		m, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		assert.NoError(m.batchTrain(context.Background(), tc, data, 5))
+		codebooks = append(codebooks, m.codebook)
+	}
+	for _, cb := range codebooks[1:] {
+		assert.True(mat64.EqualApprox(codebooks[0], cb, 1e-9))
+	}
+	// units with zero neighbourhood weight keep their codebook vectors
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	origCb := mat64.DenseCopyOf(m.codebook)
+	sample := mat64.NewDense(1, 5, []float64{1.0, 2.0, 3.0, 4.0, 5.0})
+	bmu, _, err := m.BMU(sample.RawRowView(0))
+	assert.NoError(err)
+	tc := &TrainConfig{
+		Algorithm: "batch",
+		Radius:    3.0,
+		RDecay:    "exp",
+		// units within radius other than BMU have zero neighbourhood weight
+		NeighbFn: func(distance, radius float64) float64 {
+			if distance == 0.0 {
+				return 1.0
+			}
+			return 0.0
+		},
+		LRate:  0.5,
+		LDecay: "exp",
+	}
+	assert.NoError(m.batchTrain(context.Background(), tc, sample, 2))
+	units, _ := m.codebook.Dims()
+	for i := 0; i < units; i++ {
+		if i == bmu {
+			assert.Equal(sample.RawRowView(0), m.codebook.RawRowView(i))
+			continue
+		}
+		assert.Equal(origCb.RawRowView(i), m.codebook.RawRowView(i))
+	}
+}
+
+func TestTrainMatrix(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := matrix.MakeRandom(40, 3, -10.0, 10.0)
+	// transposed view of the transposed data
+	dataT := mat64.DenseCopyOf(data.T())
+	view := dataT.T()
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{4, 3},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      3,
+			InitFunc: RandInit,
+		},
+	}
+	for _, alg := range []Method{Batch, Seq} {
+		tc := &TrainConfig{
+			Algorithm: alg,
+			Radius:    2.0,
+			RDecay:    "exp",
+			NeighbFn:  Gaussian,
+			LRate:     0.5,
+			LDecay:    "exp",
+			Workers:   2,
+		}
+		m, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		_, err = m.train(tc, data, 50, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		mView, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		_, err = mView.train(tc, view, 50, rand.New(rand.NewSource(1)))
+		assert.NoError(err)
+		assert.True(mat64.Equal(m.codebook, mView.codebook), alg)
+		// prediction and quality measures accept any matrix
+		bmus, dists, err := m.Predict(data, 2)
+		assert.NoError(err)
+		vBmus, vDists, err := m.Predict(view, 2)
+		assert.NoError(err)
+		assert.Equal(bmus, vBmus)
+		assert.Equal(dists, vDists)
+		vBmus, err = m.BMUs(view)
+		assert.NoError(err)
+		assert.Equal(bmus, vBmus)
+		qe, err := m.QuantError(data)
+		assert.NoError(err)
+		vQE, err := m.QuantError(view)
+		assert.NoError(err)
+		assert.Equal(qe, vQE)
+		te, err := m.TopoError(data)
+		assert.NoError(err)
+		vTE, err := m.TopoError(view)
+		assert.NoError(err)
+		assert.Equal(te, vTE)
+		assert.NoError(m.RecordQuality(view))
+	}
+
+	// nil dense matrix
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	var nilData *mat64.Dense
+	assert.Error(m.Train(DefaultTrainConfig(12), nilData, 10))
+	_, _, err = m.Predict(nilData, 1)
+	assert.Error(err)
+}
+
+func BenchmarkBatchTrain(b *testing.B) {
+	data, _ := matrix.MakeRandom(10000, 32, -10.0, 10.0)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{60, 60},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      32,
+			InitFunc: RandInit,
+		},
+	}
+	tc := &TrainConfig{
+		Algorithm: "batch",
+		Radius:    30.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+		Workers:   1,
+	}
+	m, err := NewMap(mCfg, data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			loopBatchTrain(m, tc, data, 2)
+		}
+	})
+	b.Run("blas", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := m.batchTrain(context.Background(), tc, data, 2); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	// data which is not *mat64.Dense is read using At
+	b.Run("matrix", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := m.batchTrain(context.Background(), tc, matrixOnly{data}, 2); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
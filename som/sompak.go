@@ -0,0 +1,152 @@
+package som
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// somPakTopologies maps unit shapes to SOM_PAK topology names
+var somPakTopologies = map[UnitShape]string{
+	Hexagon:   "hexa",
+	Rectangle: "rect",
+}
+
+// WriteCod writes the map codebook to w in SOM_PAK .cod format which is read by SOM_PAK,
+// somoclu or R kohonen. The header line holds codebook dimension, topology, the number of grid
+// columns and rows and gaussian neighbourhood; every following line holds codebook vector of a unit
+// followed by the unit label if the map units are labeled. Units are written row by row.
+// It returns ErrUnsupportedGrid if the map grid is not a 2D planar grid without mask or error
+// if the write to w fails.
+func (m Map) WriteCod(w io.Writer) error {
+	if m.grid.gtype != string(Planar) || len(m.grid.size) != 2 || m.grid.cells != nil {
+		return fmt.Errorf("%w: %s %v, SOM_PAK maps are 2D planar grids", ErrUnsupportedGrid, m.grid.gtype, m.grid.size)
+	}
+	rows, cols := m.grid.size[0], m.grid.size[1]
+	_, dim := m.cbDims()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d %s %d %d gaussian\n", dim, somPakTopologies[UnitShape(m.grid.ushape)], cols, rows)
+	cb := m.cb()
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			idx := col*rows + row
+			for j, v := range cb.RawRowView(idx) {
+				if j > 0 {
+					bw.WriteByte(' ')
+				}
+				bw.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+			}
+			if m.labels != nil && m.labels[idx] != "" {
+				bw.WriteByte(' ')
+				bw.WriteString(m.labels[idx])
+			}
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadCod reads map from r in SOM_PAK .cod format written by WriteCod, SOM_PAK, somoclu or R kohonen.
+// Lines which start with # are comments. Text which follows codebook vector values of a unit is its label.
+// The returned map has planar grid of hexa or rect topology units.
+// It returns error if the read from r fails or if r does not hold a valid SOM_PAK codebook.
+func ReadCod(r io.Reader) (*Map, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	var dim, rows, cols int
+	var uShape UnitShape
+	var cb *mat64.Dense
+	var labels []string
+	line, unit := 0, 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if cb == nil {
+			var err error
+			if dim, uShape, cols, rows, err = parseCodHeader(fields); err != nil {
+				return nil, fmt.Errorf("invalid SOM_PAK header on line %d: %w", line, err)
+			}
+			cb = mat64.NewDense(rows*cols, dim, nil)
+			continue
+		}
+		if unit == rows*cols {
+			return nil, fmt.Errorf("invalid SOM_PAK codebook: more than %d units on line %d", rows*cols, line)
+		}
+		if len(fields) < dim {
+			return nil, fmt.Errorf("invalid SOM_PAK codebook vector on line %d: %d values, expected %d", line, len(fields), dim)
+		}
+		// units are stored row by row
+		idx := (unit%cols)*rows + unit/cols
+		vec := cb.RawRowView(idx)
+		for j := range vec {
+			v, err := strconv.ParseFloat(fields[j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SOM_PAK codebook vector on line %d: %s", line, err)
+			}
+			vec[j] = v
+		}
+		if len(fields) > dim {
+			if labels == nil {
+				labels = make([]string, rows*cols)
+			}
+			labels[idx] = strings.Join(fields[dim:], " ")
+		}
+		unit++
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if cb == nil {
+		return nil, fmt.Errorf("invalid SOM_PAK codebook: missing header")
+	}
+	if unit != rows*cols {
+		return nil, fmt.Errorf("invalid SOM_PAK codebook: %d units, expected %d", unit, rows*cols)
+	}
+	grid, err := NewGrid(&GridConfig{Size: []int{rows, cols}, Type: Planar, UShape: uShape})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Map{codebook: cb, grid: grid, labels: labels, norms: sqNorms(cb)}, nil
+}
+
+// parseCodHeader parses SOM_PAK header fields: codebook dimension, topology, the number of grid
+// columns and rows and optional neighbourhood which is ignored
+func parseCodHeader(fields []string) (int, UnitShape, int, int, error) {
+	if len(fields) < 4 {
+		return 0, "", 0, 0, fmt.Errorf("%d fields, expected dimension, topology, xdim and ydim", len(fields))
+	}
+	var uShape UnitShape
+	for shape, topology := range somPakTopologies {
+		if fields[1] == topology {
+			uShape = shape
+		}
+	}
+	if uShape == "" {
+		return 0, "", 0, 0, fmt.Errorf("%w: %s", ErrUnsupportedUShape, fields[1])
+	}
+	dim, err := strconv.Atoi(fields[0])
+	if err != nil || dim <= 0 {
+		return 0, "", 0, 0, fmt.Errorf("invalid codebook dimension: %s, must be a positive integer", fields[0])
+	}
+	var dims [2]int
+	for i, f := range fields[2:4] {
+		n, err := strconv.Atoi(f)
+		if err != nil || n <= 0 {
+			return 0, "", 0, 0, fmt.Errorf("%w: %s, must be a positive integer", ErrInvalidDims, f)
+		}
+		dims[i] = n
+	}
+	if dims[0]*dims[1] == 1 {
+		return 0, "", 0, 0, fmt.Errorf("%w: %d x %d units", ErrInvalidDims, dims[0], dims[1])
+	}
+	return dim, uShape, dims[0], dims[1], nil
+}
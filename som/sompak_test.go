@@ -0,0 +1,112 @@
+package som
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCod(t *testing.T) {
+	assert := assert.New(t)
+
+	cod := `# map trained by SOM_PAK
+2 hexa 3 2 gaussian
+0 0 A
+1 0.5
+2 1
+0 1 B C
+1 1.5
+2 2
+`
+	m, err := ReadCod(strings.NewReader(cod))
+	assert.NoError(err)
+	assert.Equal([]int{2, 3}, m.Grid().Size())
+	assert.Equal("hexagon", m.Grid().UShape())
+	assert.Equal("planar", m.Grid().Type())
+	// units are stored row by row
+	for row := 0; row < 2; row++ {
+		for col := 0; col < 3; col++ {
+			idx, err := m.UnitIndex(row, col)
+			assert.NoError(err)
+			assert.Equal(float64(col), m.codebook.At(idx, 0))
+		}
+	}
+	idx, _ := m.UnitIndex(1, 0)
+	assert.Equal([]float64{0, 1}, m.codebook.RawRowView(idx))
+	assert.Equal("B C", m.labels[idx])
+	assert.Equal("A", m.labels[0])
+	assert.Equal("", m.labels[2])
+	bmus, err := m.BMUs(mat64.NewDense(1, 2, []float64{1.9, 1.1}))
+	assert.NoError(err)
+	idx, _ = m.UnitIndex(0, 2)
+	assert.Equal([]int{idx}, bmus)
+
+	// unlabeled maps
+	m, err = ReadCod(strings.NewReader("1 rect 2 1 bubble\n3\n4\n"))
+	assert.NoError(err)
+	assert.Equal("rectangle", m.Grid().UShape())
+	assert.Nil(m.labels)
+
+	for _, tc := range []struct {
+		cod string
+		err string
+	}{
+		{"", "missing header"},
+		{"2 hexa 3\n", "3 fields"},
+		{"2 torus 3 2\n", "unsupported unit shape"},
+		{"2 rect 0 2\n", "invalid grid dimensions"},
+		{"x rect 2 2\n", "invalid codebook dimension"},
+		{"1 rect 1 1\n", "invalid grid dimensions"},
+		{"2 rect 2 1\n1 2\n3\n", "1 values, expected 2"},
+		{"1 rect 2 1\n1\nx\n", "invalid syntax"},
+		{"1 rect 2 1\n1\n2\n3\n", "more than 2 units"},
+		{"1 rect 2 1\n1\n", "1 units, expected 2"},
+	} {
+		_, err = ReadCod(strings.NewReader(tc.cod))
+		if assert.Error(err, tc.cod) {
+			assert.Contains(err.Error(), tc.err)
+		}
+	}
+}
+
+func TestWriteCod(t *testing.T) {
+	assert := assert.New(t)
+
+	data := unitBlobs(100, 1)
+	for _, tc := range []struct {
+		uShape    UnitShape
+		precision string
+	}{
+		{Hexagon, ""},
+		{Rectangle, "float32"},
+	} {
+		m, err := NewMap(&MapConfig{
+			Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: tc.uShape},
+			Cb:   &CbConfig{Dim: 2, InitFunc: RandInit, Precision: tc.precision},
+		}, data)
+		assert.NoError(err)
+		m.labels = make([]string, 12)
+		m.labels[5] = "five"
+		var b bytes.Buffer
+		assert.NoError(m.WriteCod(&b))
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		assert.Len(lines, 13)
+		assert.Equal("2 "+somPakTopologies[tc.uShape]+" 3 4 gaussian", lines[0])
+		read, err := ReadCod(&b)
+		assert.NoError(err)
+		assert.True(mat64.Equal(m.Codebook(), read.Codebook()))
+		assert.True(mat64.Equal(m.GridCoords(), read.GridCoords()))
+		assert.Equal(m.labels, read.labels)
+	}
+
+	m, err := NewMap(&MapConfig{
+		Grid: &GridConfig{Size: []int{4, 4}, Type: Toroidal, UShape: Rectangle},
+		Cb:   &CbConfig{Dim: 2, InitFunc: RandInit},
+	}, data)
+	assert.NoError(err)
+	assert.True(errors.Is(m.WriteCod(&bytes.Buffer{}), ErrUnsupportedGrid))
+}
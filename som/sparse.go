@@ -0,0 +1,240 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+)
+
+// BMUSparse returns the index of Best Match Unit codebook vector for sparse vector x and the
+// distance between them. x is specified by the indices of its non-zero elements and their values.
+// Squared euclidean distance between x and codebook vector c is |c|^2 - 2*x.c + |x|^2, so the
+// search uses cached codebook norms and only touches non-zero elements of x. Units which score
+// within rounding tolerance of the best one are rescored exactly, so BMUSparse returns the same
+// unit and distance as BMU called with the dense form of x.
+// It returns error if indices and values have different lengths or if indices are not sorted
+// in ascending order, contain duplicates or are out of codebook dimension range.
+// When the error is returned, both the index and distance are set to -1.
+func (m *Map) BMUSparse(indices []int, values []float64) (int, float64, error) {
+	units, dim := m.cbDims()
+	if len(indices) != len(values) {
+		return -1, -1.0, fmt.Errorf("invalid sparse vector: %d indices, %d values", len(indices), len(values))
+	}
+	for i, idx := range indices {
+		if idx < 0 || idx >= dim {
+			return -1, -1.0, fmt.Errorf("invalid sparse vector index: %d", idx)
+		}
+		if i > 0 && idx <= indices[i-1] {
+			return -1, -1.0, fmt.Errorf("unsorted or duplicate sparse vector index: %d", idx)
+		}
+	}
+	// relevance weighted and metric searches run on the dense form of x
+	if m.relevance != nil || m.metric != nil {
+		x := make([]float64, dim)
+		for n, idx := range indices {
+			x[idx] = values[n]
+		}
+		return m.BMU(x)
+	}
+	xx, maxNorm := 0.0, 0.0
+	for _, v := range values {
+		xx += v * v
+	}
+	for _, n := range m.norms {
+		maxNorm = math.Max(maxNorm, n)
+	}
+	// BMU minimizes |c|^2 - 2*x.c
+	scores := make([]float64, units)
+	best := math.MaxFloat64
+	for j := range scores {
+		dot := 0.0
+		if m.cb32 != nil {
+			row := m.cb32.rowView(j)
+			for n, idx := range indices {
+				dot += values[n] * float64(row[idx])
+			}
+		} else {
+			row := m.codebook.RawRowView(j)
+			for n, idx := range indices {
+				dot += values[n] * row[idx]
+			}
+		}
+		scores[j] = m.norms[j] - 2*dot
+		best = math.Min(best, scores[j])
+	}
+	limit := best + bmuTolerance*(xx+maxNorm)
+	bmu, dist := -1, math.MaxFloat64
+	for j, s := range scores {
+		if s > limit {
+			continue
+		}
+		var d float64
+		if m.cb32 != nil {
+			d = sparseEuclidean32(indices, values, m.cb32.rowView(j))
+		} else {
+			d = sparseEuclidean(indices, values, m.codebook.RawRowView(j))
+		}
+		if d < dist {
+			bmu, dist = j, d
+		}
+	}
+
+	return bmu, dist, nil
+}
+
+// sparseEuclidean computes euclidean distance between sparse vector a and vector b
+// with the same arithmetic as euclideanVec applied to the dense form of a
+func sparseEuclidean(indices []int, values, b []float64) float64 {
+	d, n := 0.0, 0
+	for i, v := range b {
+		a := 0.0
+		if n < len(indices) && indices[n] == i {
+			a = values[n]
+			n++
+		}
+		d += (a - v) * (a - v)
+	}
+
+	return math.Sqrt(d)
+}
+
+// sparseEuclidean32 computes euclidean distance between sparse vector a and float32 vector b
+// with the same arithmetic as euclidean32 applied to the dense form of a
+func sparseEuclidean32(indices []int, values []float64, b []float32) float64 {
+	d, n := 0.0, 0
+	for i, v := range b {
+		var a float32
+		if n < len(indices) && indices[n] == i {
+			a = float32(values[n])
+			n++
+		}
+		diff := a - v
+		d += float64(diff * diff)
+	}
+
+	return math.Sqrt(d)
+}
+
+// SparseMatrix is read-only data matrix stored in compressed sparse row format, such as
+// bag-of-words or one-hot encoded data with many more columns than non-zero elements per row.
+// It implements mat64.Matrix, so it can be passed to training and prediction methods which
+// copy one row at a time into a dense buffer instead of converting the whole matrix to a dense one.
+type SparseMatrix struct {
+	// rows and cols are matrix dimensions
+	rows, cols int
+	// indptr holds offsets of rows in indices and values: row i is stored in [indptr[i], indptr[i+1])
+	indptr []int
+	// indices hold ascending column indices of non-zero elements of every row
+	indices []int
+	// values hold values of non-zero elements
+	values []float64
+}
+
+// NewSparseMatrix creates sparse matrix with cols columns from compressed sparse row data:
+// the elements of row i have column indices indices[indptr[i]:indptr[i+1]] and values
+// values[indptr[i]:indptr[i+1]]; the matrix has len(indptr)-1 rows. The slices are not copied.
+// It returns error if cols is not positive, if the matrix has no rows, if indptr is not
+// non-decreasing from 0 to len(indices), if indices and values have different lengths or if
+// column indices of a row are not sorted in ascending order, contain duplicates or are out of range.
+func NewSparseMatrix(cols int, indptr, indices []int, values []float64) (*SparseMatrix, error) {
+	if cols <= 0 {
+		return nil, fmt.Errorf("invalid sparse matrix columns: %d", cols)
+	}
+	if len(indptr) < 2 || indptr[0] != 0 || indptr[len(indptr)-1] != len(indices) {
+		return nil, fmt.Errorf("invalid sparse matrix row offsets: %d offsets of %d elements", len(indptr), len(indices))
+	}
+	if len(indices) != len(values) {
+		return nil, fmt.Errorf("invalid sparse matrix: %d indices, %d values", len(indices), len(values))
+	}
+	for i := 1; i < len(indptr); i++ {
+		if indptr[i] < indptr[i-1] {
+			return nil, fmt.Errorf("invalid sparse matrix row offsets: row %d ends before it starts", i-1)
+		}
+	}
+	for i := 1; i < len(indptr); i++ {
+		for k := indptr[i-1]; k < indptr[i]; k++ {
+			if indices[k] < 0 || indices[k] >= cols {
+				return nil, fmt.Errorf("invalid sparse matrix row %d index: %d", i-1, indices[k])
+			}
+			if k > indptr[i-1] && indices[k] <= indices[k-1] {
+				return nil, fmt.Errorf("unsorted or duplicate sparse matrix row %d index: %d", i-1, indices[k])
+			}
+		}
+	}
+	return &SparseMatrix{rows: len(indptr) - 1, cols: cols, indptr: indptr, indices: indices, values: values}, nil
+}
+
+// Dims returns matrix dimensions
+func (s *SparseMatrix) Dims() (int, int) {
+	return s.rows, s.cols
+}
+
+// At returns matrix element in row i and column j. It panics if i or j are out of range.
+func (s *SparseMatrix) At(i, j int) float64 {
+	if i < 0 || i >= s.rows || j < 0 || j >= s.cols {
+		panic(fmt.Sprintf("sparse matrix index out of range: %d, %d", i, j))
+	}
+	indices, values := s.Row(i)
+	if k := sort.SearchInts(indices, j); k < len(indices) && indices[k] == j {
+		return values[k]
+	}
+	return 0.0
+}
+
+// T returns transpose of the matrix
+func (s *SparseMatrix) T() mat64.Matrix {
+	return mat64.Transpose{Matrix: s}
+}
+
+// Row returns column indices and values of non-zero elements of row i without copying them.
+// The returned slices must not be modified.
+func (s *SparseMatrix) Row(i int) ([]int, []float64) {
+	from, to := s.indptr[i], s.indptr[i+1]
+	return s.indices[from:to], s.values[from:to]
+}
+
+// NNZ returns the number of stored matrix elements
+func (s *SparseMatrix) NNZ() int {
+	return len(s.values)
+}
+
+// denseRow stores row i in dst and returns it
+func (s *SparseMatrix) denseRow(dst []float64, i int) []float64 {
+	for j := range dst {
+		dst[j] = 0.0
+	}
+	indices, values := s.Row(i)
+	for k, j := range indices {
+		dst[j] = values[k]
+	}
+	return dst
+}
+
+// denseRows returns dense matrix of at most n rows spread evenly over the matrix rows
+func (s *SparseMatrix) denseRows(n int) *mat64.Dense {
+	if n > s.rows {
+		n = s.rows
+	}
+	dense := mat64.NewDense(n, s.cols, nil)
+	for i := 0; i < n; i++ {
+		s.denseRow(dense.RawRowView(i), i*s.rows/n)
+	}
+	return dense
+}
+
+// NewSparseMap creates new SOM for sparse data in the same way as NewMap. The codebook is
+// initialized by the configured initialization function from a dense matrix of at most as many
+// data rows as the map has units, spread evenly over data, so that data don't have to be converted
+// to a dense matrix. It returns error if data is nil or in the same way as NewMap.
+func NewSparseMap(c *MapConfig, data *SparseMatrix) (*Map, error) {
+	if data == nil {
+		return nil, fmt.Errorf("invalid input data: %v", data)
+	}
+	if err := validateMapConfig(c); err != nil {
+		return nil, err
+	}
+	return NewMap(c, data.denseRows(utils.IntProduct(c.Grid.Size)))
+}
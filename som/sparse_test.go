@@ -0,0 +1,227 @@
+package som
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeSparse returns indices and values of random sparse vector of dimension dim
+// with the given density of non-zero elements and its dense form
+func makeSparse(r *rand.Rand, dim int, density float64) ([]int, []float64, []float64) {
+	var indices []int
+	var values []float64
+	dense := make([]float64, dim)
+	for i := range dense {
+		if r.Float64() < density {
+			v := r.Float64()*20.0 - 10.0
+			indices = append(indices, i)
+			values = append(values, v)
+			dense[i] = v
+		}
+	}
+	return indices, values, dense
+}
+
+func TestBMUSparse(t *testing.T) {
+	assert := assert.New(t)
+
+	dim := 50
+	data, _ := matrix.MakeRandom(100, dim, -10.0, 10.0)
+	tc := &TrainConfig{
+		Algorithm: "seq",
+		Radius:    3.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+	r := rand.New(rand.NewSource(11))
+	for _, precision := range []string{"float64", "float32"} {
+		m, err := NewMap(makePrecisionMapCfg([]int{15, 12}, dim, precision), data)
+		assert.NoError(err)
+		for _, train := range []bool{false, true} {
+			// training refreshes cached codebook norms
+			if train {
+				_, err = m.train(tc, data, 500, r)
+				assert.NoError(err)
+			}
+			for _, density := range []float64{0.0, 0.1, 0.5, 1.0} {
+				for i := 0; i < 20; i++ {
+					indices, values, dense := makeSparse(r, dim, density)
+					bmu, dist, err := m.BMUSparse(indices, values)
+					assert.NoError(err)
+					expBmu, expDist, err := m.BMU(dense)
+					assert.NoError(err)
+					assert.Equal(expBmu, bmu)
+					assert.Equal(expDist, dist)
+				}
+			}
+			// codebook vector
+			row := m.cb().RawRowView(7)
+			indices := make([]int, dim)
+			for i := range indices {
+				indices[i] = i
+			}
+			bmu, dist, err := m.BMUSparse(indices, row)
+			assert.NoError(err)
+			expBmu, expDist, err := m.BMU(row)
+			assert.NoError(err)
+			assert.Equal(expBmu, bmu)
+			assert.Equal(expDist, dist)
+		}
+	}
+	// invalid sparse vectors
+	m, err := NewMap(makePrecisionMapCfg([]int{4, 3}, dim, ""), data)
+	assert.NoError(err)
+	testCases := []struct {
+		indices []int
+		values  []float64
+		err     string
+	}{
+		{[]int{1, 2}, []float64{1.0}, "invalid sparse vector: 2 indices, 1 values"},
+		{[]int{-1}, []float64{1.0}, "invalid sparse vector index: -1"},
+		{[]int{3, dim}, []float64{1.0, 2.0}, "invalid sparse vector index: 50"},
+		{[]int{3, 2}, []float64{1.0, 2.0}, "unsorted or duplicate sparse vector index: 2"},
+		{[]int{2, 5, 5}, []float64{1.0, 2.0, 3.0}, "unsorted or duplicate sparse vector index: 5"},
+	}
+	for _, tc := range testCases {
+		bmu, dist, err := m.BMUSparse(tc.indices, tc.values)
+		assert.EqualError(err, tc.err)
+		assert.Equal(-1, bmu)
+		assert.Equal(-1.0, dist)
+	}
+}
+
+// makeSparseMatrix returns random sparse matrix with the given density of non-zero elements
+// and its dense form
+func makeSparseMatrix(r *rand.Rand, rows, cols int, density float64) (*SparseMatrix, *mat64.Dense) {
+	indptr := []int{0}
+	var indices []int
+	var values []float64
+	dense := mat64.NewDense(rows, cols, nil)
+	for i := 0; i < rows; i++ {
+		idx, vals, row := makeSparse(r, cols, density)
+		indices, values = append(indices, idx...), append(values, vals...)
+		indptr = append(indptr, len(indices))
+		dense.SetRow(i, row)
+	}
+	s, err := NewSparseMatrix(cols, indptr, indices, values)
+	if err != nil {
+		panic(err)
+	}
+	return s, dense
+}
+
+func TestSparseMatrix(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewSparseMatrix(4, []int{0, 2, 2, 3}, []int{0, 3, 1}, []float64{1, 2, 3})
+	assert.NoError(err)
+	rows, cols := s.Dims()
+	assert.Equal(3, rows)
+	assert.Equal(4, cols)
+	assert.Equal(3, s.NNZ())
+	assert.True(mat64.Equal(mat64.NewDense(3, 4, []float64{1, 0, 0, 2, 0, 0, 0, 0, 0, 3, 0, 0}), s))
+	assert.True(mat64.Equal(mat64.NewDense(4, 3, []float64{1, 0, 0, 0, 0, 3, 0, 0, 0, 2, 0, 0}), s.T()))
+	indices, values := s.Row(1)
+	assert.Empty(indices)
+	assert.Empty(values)
+	assert.Panics(func() { s.At(3, 0) })
+
+	for _, tc := range []struct {
+		cols    int
+		indptr  []int
+		indices []int
+		values  []float64
+		err     string
+	}{
+		{0, []int{0, 0}, nil, nil, "invalid sparse matrix columns: 0"},
+		{2, []int{0}, nil, nil, "invalid sparse matrix row offsets"},
+		{2, []int{0, 2}, []int{0}, []float64{1}, "invalid sparse matrix row offsets"},
+		{2, []int{0, 1}, []int{0}, []float64{1, 2}, "1 indices, 2 values"},
+		{2, []int{0, 2, 1}, []int{0}, []float64{1}, "invalid sparse matrix row offsets"},
+		{2, []int{0, 1}, []int{2}, []float64{1}, "invalid sparse matrix row 0 index: 2"},
+		{2, []int{0, 2}, []int{1, 1}, []float64{1, 2}, "duplicate sparse matrix row 0 index: 1"},
+	} {
+		_, err := NewSparseMatrix(tc.cols, tc.indptr, tc.indices, tc.values)
+		if assert.Error(err, tc.err) {
+			assert.Contains(err.Error(), tc.err)
+		}
+	}
+}
+
+func TestTrainSparse(t *testing.T) {
+	assert := assert.New(t)
+
+	sparse, dense := makeSparseMatrix(rand.New(rand.NewSource(3)), 200, 300, 0.05)
+	for _, alg := range []Method{Seq, Batch} {
+		cbs := make([]*mat64.Dense, 2)
+		for i, data := range []mat64.Matrix{dense, sparse} {
+			m, err := NewMap(makePrecisionMapCfg([]int{4, 3}, 300, ""), dense)
+			assert.NoError(err)
+			tc := gsomTrainConfig()
+			tc.Algorithm, tc.Rand = alg, rand.New(rand.NewSource(1))
+			assert.NoError(m.Train(tc, data, 400))
+			cbs[i] = m.Codebook()
+			// sparse data are predicted in the same way as dense data
+			bmus, dists, err := m.Predict(sparse, 2)
+			assert.NoError(err)
+			expBMUs, expDists, err := m.Predict(dense, 2)
+			assert.NoError(err)
+			assert.Equal(expBMUs, bmus)
+			assert.InDeltaSlice(expDists, dists, 1e-9)
+		}
+		// sparse training matches dense training
+		assert.True(mat64.EqualApprox(cbs[0], cbs[1], 1e-9), string(alg))
+	}
+
+	// codebook of sparse maps is initialized from data rows
+	cfg := makePrecisionMapCfg([]int{4, 3}, 300, "")
+	cfg.Cb.InitFunc = SampleInit
+	m, err := NewSparseMap(cfg, sparse)
+	assert.NoError(err)
+	for i := 0; i < 12; i++ {
+		found := false
+		for j := 0; j < 200 && !found; j++ {
+			found = mat64.Equal(m.codebook.RowView(i), dense.RowView(j))
+		}
+		assert.True(found)
+	}
+	_, err = NewSparseMap(cfg, nil)
+	assert.Error(err)
+	cfg.Grid.Size = nil
+	_, err = NewSparseMap(cfg, sparse)
+	assert.Error(err)
+}
+
+func BenchmarkBMUSparse(b *testing.B) {
+	dim := 10000
+	data, _ := matrix.MakeRandom(50, dim, -10.0, 10.0)
+	m, err := NewMap(makePrecisionMapCfg([]int{20, 20}, dim, ""), data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	indices, values, _ := makeSparse(rand.New(rand.NewSource(5)), dim, 0.01)
+	b.Run("sparse", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, _, err := m.BMUSparse(indices, values); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("densify", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			dense := make([]float64, dim)
+			for n, idx := range indices {
+				dense[idx] = values[n]
+			}
+			if _, _, err := m.BMU(dense); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package som
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// SphericalSize returns size of spherical grid whose units are vertices of an icosahedron
+// with every edge subdivided into freq segments. The grid has 10*freq^2+2 units.
+// It returns nil if freq is not a positive integer.
+func SphericalSize(freq int) []int {
+	if freq <= 0 {
+		return nil
+	}
+	return []int{2, 5*freq*freq + 1}
+}
+
+// sphereFreq returns the subdivision frequency of spherical grid of the given size
+// or error if the number of units of size is not a geodesic number of units
+func sphereFreq(size []int) (int, error) {
+	if len(size) != 2 {
+		return 0, fmt.Errorf("%w: %v, spherical grids have 2 dimensions", ErrInvalidDims, size)
+	}
+	units := size[0] * size[1]
+	freq := int(math.Round(math.Sqrt(float64(units-2) / 10.0)))
+	if freq <= 0 || 10*freq*freq+2 != units {
+		return 0, fmt.Errorf("%w: %v, spherical grids must have 10*f^2+2 units, see SphericalSize", ErrInvalidDims, size)
+	}
+	return freq, nil
+}
+
+// SphereCoords returns a matrix which contains coordinates of units of spherical grid stored row
+// by row. The units are vertices of geodesic subdivision of an icosahedron projected on a sphere
+// centered at the origin whose radius is chosen so that the mean distance of every unit from its
+// nearest unit along the sphere surface is 1. Units of spherical grids are hexagons except for 12
+// pentagons, so uShape must be hexagon. dims are the grid size, see SphericalSize.
+// SphereCoords fails with error if uShape is not hexagon or if dims are not a spherical grid size.
+func SphereCoords(uShape string, dims []int) (*mat64.Dense, error) {
+	if uShape != string(Hexagon) {
+		return nil, fmt.Errorf("%w: %s, spherical grids have hexagon units", ErrUnsupportedUShape, uShape)
+	}
+	freq, err := sphereFreq(dims)
+	if err != nil {
+		return nil, err
+	}
+	points := geodesicPoints(freq)
+	coords := mat64.NewDense(len(points), 3, nil)
+	for i, p := range points {
+		coords.SetRow(i, p[:])
+	}
+	// scale the unit sphere to the mean nearest unit angle
+	mean := 0.0
+	for i, a := range points {
+		nearest := math.Inf(1)
+		for j, b := range points {
+			if i != j {
+				nearest = math.Min(nearest, sphereAngle(a[:], b[:]))
+			}
+		}
+		mean += nearest
+	}
+	mean /= float64(len(points))
+	coords.Scale(1/mean, coords)
+	return coords, nil
+}
+
+// geodesicPoints returns unit vectors of vertices of icosahedron whose edges are subdivided
+// into freq segments. Vertices shared by faces are returned once in the order of their first
+// occurrence, so the order is the same in every call.
+func geodesicPoints(freq int) [][3]float64 {
+	phi := (1 + math.Sqrt(5)) / 2
+	verts := [][3]float64{
+		{-1, phi, 0}, {1, phi, 0}, {-1, -phi, 0}, {1, -phi, 0},
+		{0, -1, phi}, {0, 1, phi}, {0, -1, -phi}, {0, 1, -phi},
+		{phi, 0, -1}, {phi, 0, 1}, {-phi, 0, -1}, {-phi, 0, 1},
+	}
+	faces := [][3]int{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+	// shared vertices are identified by their rounded coordinates
+	type key [3]int64
+	seen := make(map[key]bool)
+	points := make([][3]float64, 0, 10*freq*freq+2)
+	for _, f := range faces {
+		a, b, c := verts[f[0]], verts[f[1]], verts[f[2]]
+		for i := 0; i <= freq; i++ {
+			for j := 0; i+j <= freq; j++ {
+				k := freq - i - j
+				var p [3]float64
+				norm := 0.0
+				for d := range p {
+					p[d] = (float64(i)*a[d] + float64(j)*b[d] + float64(k)*c[d]) / float64(freq)
+					norm += p[d] * p[d]
+				}
+				norm = math.Sqrt(norm)
+				var id key
+				for d := range p {
+					p[d] /= norm
+					id[d] = int64(math.Round(p[d] * 1e9))
+				}
+				if !seen[id] {
+					seen[id] = true
+					points = append(points, p)
+				}
+			}
+		}
+	}
+	return points
+}
+
+// sphereAngle returns the angle between vectors a and b of the same length
+func sphereAngle(a, b []float64) float64 {
+	dot, norm := 0.0, 0.0
+	for i := range a {
+		dot += a[i] * b[i]
+		norm += a[i] * a[i]
+	}
+	// rounding errors may push the cosine out of its range
+	return math.Acos(math.Max(-1, math.Min(1, dot/norm)))
+}
@@ -0,0 +1,140 @@
+package som
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSphericalSize(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]int{2, 6}, SphericalSize(1))
+	assert.Equal([]int{2, 21}, SphericalSize(2))
+	assert.Nil(SphericalSize(0))
+	for _, size := range [][]int{{2, 6}, {6, 2}, {3, 14}, {1, 42}} {
+		_, err := sphereFreq(size)
+		assert.NoError(err)
+	}
+	for _, size := range [][]int{{2, 5}, {3, 3}, {2, 3, 7}} {
+		_, err := sphereFreq(size)
+		assert.True(errors.Is(err, ErrInvalidDims))
+	}
+}
+
+func TestSphereCoords(t *testing.T) {
+	assert := assert.New(t)
+
+	for freq := 1; freq <= 4; freq++ {
+		g, err := NewGrid(&GridConfig{Size: SphericalSize(freq), Type: Spherical, UShape: Hexagon})
+		assert.NoError(err)
+		units, dims := g.coords.Dims()
+		assert.Equal(10*freq*freq+2, units)
+		assert.Equal(3, dims)
+		// all units lie on the sphere
+		for i := 0; i < units; i++ {
+			assert.InDelta(g.radius, floats.Norm(g.coords.RawRowView(i), 2), 1e-9)
+		}
+		// units have 5 or 6 neighbours about a unit apart, 12 units are pentagons
+		dist := g.unitDist()
+		pentagons, nearest := 0, 0.0
+		for i := 0; i < units; i++ {
+			neighb, min := 0, math.Inf(1)
+			for j := 0; j < units; j++ {
+				if i == j {
+					continue
+				}
+				d := dist.At(i, j)
+				assert.True(d > 0.8)
+				if d < 1.3 {
+					neighb++
+				}
+				min = math.Min(min, d)
+			}
+			assert.True(neighb == 5 || neighb == 6, "freq %d unit %d: %d", freq, i, neighb)
+			if neighb == 5 {
+				pentagons++
+			}
+			nearest += min
+		}
+		assert.Equal(12, pentagons)
+		assert.InDelta(1.0, nearest/float64(units), 1e-9)
+		// the unit index finds the same neighbours as the distances
+		near := g.unitIndex(dist).within(nil, 0, 1.3)
+		assert.True(len(near) == 6 || len(near) == 7)
+	}
+
+	_, err := SphereCoords("rectangle", SphericalSize(2))
+	assert.True(errors.Is(err, ErrUnsupportedUShape))
+	_, err = SphereCoords("hexagon", []int{3, 3})
+	assert.True(errors.Is(err, ErrInvalidDims))
+	err = validateGridConfig(&GridConfig{Size: SphericalSize(2), Type: Spherical, UShape: Rectangle})
+	assert.True(errors.Is(err, ErrUnsupportedUShape))
+	err = validateGridConfig(&GridConfig{Size: []int{4, 4}, Type: Spherical, UShape: Hexagon})
+	assert.True(errors.Is(err, ErrInvalidDims))
+}
+
+func TestTrainSpherical(t *testing.T) {
+	assert := assert.New(t)
+
+	// directions clustered around the axes
+	r := rand.New(rand.NewSource(1))
+	data := mat64.NewDense(600, 3, nil)
+	for i := 0; i < 600; i++ {
+		v := make([]float64, 3)
+		for j := range v {
+			v[j] = r.NormFloat64() * 0.2
+		}
+		v[i%3] += float64(1 - 2*(i/3%2))
+		floats.Scale(1/floats.Norm(v, 2), v)
+		data.SetRow(i, v)
+	}
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   SphericalSize(3),
+			Type:   Spherical,
+			UShape: Hexagon,
+		},
+		Cb: &CbConfig{
+			Dim:      3,
+			InitFunc: RandInit,
+		},
+	}
+	m, err := NewMap(mc, data)
+	assert.NoError(err)
+	before, err := m.QuantError(data)
+	assert.NoError(err)
+	tc := &TrainConfig{
+		Algorithm: Seq,
+		Radius:    3.0,
+		RDecay:    ExpDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    ExpDecay,
+	}
+	_, err = m.train(tc, data, 6000, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	after, err := m.QuantError(data)
+	assert.NoError(err)
+	assert.True(after < before/2, "%f %f", before, after)
+	te, err := m.TopoError(data)
+	assert.NoError(err)
+	assert.True(te < 0.1, "%f", te)
+	umatrix, err := m.UMatrixValues()
+	assert.NoError(err)
+	assert.Len(umatrix, 92)
+
+	// spherical units have no planar positions
+	_, err = m.UnitIndex(0, 0)
+	assert.True(errors.Is(err, ErrUnsupportedGrid))
+	_, _, err = m.UnitRC(0)
+	assert.True(errors.Is(err, ErrUnsupportedGrid))
+	err = m.UMatrix(&bytes.Buffer{}, data, nil, "svg", "sphere")
+	assert.True(errors.Is(err, ErrUnsupportedGrid))
+}
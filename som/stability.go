@@ -0,0 +1,172 @@
+package som
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// StabilityPairs is the largest number of sample pairs evaluated by AssignmentStability:
+// if data has more pairs, StabilityPairs random pairs are evaluated instead
+const StabilityPairs = 1 << 20
+
+// StabilityReport describes how stable BMU assignments of data samples are across maps
+// trained on bootstrap resamples of the data
+type StabilityReport struct {
+	// Samples holds the stability score of every data sample: the mean agreement of runs on whether
+	// the sample shares its BMU with the other samples of evaluated pairs. Agreement on a pair is
+	// the fraction of runs which agree with the majority of runs, so it ranges from 0.5 to 1.
+	// Samples which are not part of any evaluated pair have score 1.
+	Samples []float64
+	// Mean is the mean agreement of runs on all evaluated pairs
+	Mean float64
+	// AdjustedRand is the mean adjusted Rand index of BMU assignments of data samples of all pairs
+	// of runs. It is 1 for identical assignments and close to 0 for independent assignments.
+	AdjustedRand float64
+	// Pairs is the number of evaluated sample pairs
+	Pairs int
+}
+
+// AssignmentStability trains b maps configured by mc and tc on bootstrap resamples of data, which are
+// drawn with replacement and have the same number of rows as data, and measures how consistently
+// the maps assign data samples to the same BMUs. The maps run the same number of iterations as
+// TrainRelational. Since units of different maps are not aligned, two runs agree on a pair of samples
+// if either both runs or neither of them assign the samples to a shared BMU; see StabilityReport for
+// the measures. Map size sets the granularity of the measured assignments: maps with as many units as
+// expected clusters measure cluster stability. Maps are trained concurrently by at most workers goroutines;
+// if workers is not a positive integer, the number of CPUs is used. The results are deterministic
+// for a fixed seed. AssignmentStability returns error if data is nil or has fewer than two rows,
+// if b is lower than 2 or if training of any map fails.
+func AssignmentStability(data *mat64.Dense, mc *MapConfig, tc *TrainConfig, b int, seed int64, workers int) (StabilityReport, error) {
+	// data can't be nil
+	if data == nil {
+		return StabilityReport{}, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, _ := data.Dims()
+	if rows < 2 {
+		return StabilityReport{}, fmt.Errorf("invalid number of data samples: %d", rows)
+	}
+	if b < 2 {
+		return StabilityReport{}, fmt.Errorf("invalid number of bootstrap runs: %d", b)
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	bmus := make([][]int, b)
+	units := make([]int, b)
+	errs := make([]error, b)
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// every run gets its own seed derived from the stability seed
+				m, err := trainBootstrap(data, mc, tc, seed+int64(i)+1)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				units[i], _ = m.cbDims()
+				bmus[i], errs[i] = m.BMUs(data)
+			}
+		}()
+	}
+	for i := 0; i < b; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return StabilityReport{}, fmt.Errorf("run %d: %w", i, err)
+		}
+	}
+	// evaluate all sample pairs or a random sample of them
+	report := StabilityReport{Samples: make([]float64, rows)}
+	scores, counts := make([]float64, rows), make([]int, rows)
+	agreement := func(i, j int) {
+		shared := 0
+		for _, run := range bmus {
+			if run[i] == run[j] {
+				shared++
+			}
+		}
+		f := float64(shared) / float64(b)
+		if f < 0.5 {
+			f = 1 - f
+		}
+		scores[i], scores[j] = scores[i]+f, scores[j]+f
+		counts[i]++
+		counts[j]++
+		report.Mean += f
+		report.Pairs++
+	}
+	if rows*(rows-1)/2 <= StabilityPairs {
+		for i := 0; i < rows; i++ {
+			for j := i + 1; j < rows; j++ {
+				agreement(i, j)
+			}
+		}
+	} else {
+		r := rand.New(rand.NewSource(seed))
+		for p := 0; p < StabilityPairs; p++ {
+			i, j := r.Intn(rows), r.Intn(rows-1)
+			// skip the pair of a sample with itself
+			if j >= i {
+				j++
+			}
+			agreement(i, j)
+		}
+	}
+	report.Mean /= float64(report.Pairs)
+	for i := range report.Samples {
+		report.Samples[i] = 1.0
+		if counts[i] > 0 {
+			report.Samples[i] = scores[i] / float64(counts[i])
+		}
+	}
+	runPairs := 0
+	for i := 0; i < b; i++ {
+		for j := i + 1; j < b; j++ {
+			report.AdjustedRand += adjustedRand(bmus[i], bmus[j], units[i], units[j])
+			runPairs++
+		}
+	}
+	report.AdjustedRand /= float64(runPairs)
+	return report, nil
+}
+
+// adjustedRand returns the adjusted Rand index of labelings x and y of the same samples
+// with labels in the range from 0 to kx-1 and to ky-1 respectively.
+// It returns 1 if the index is undefined because both labelings have a single label.
+func adjustedRand(x, y []int, kx, ky int) float64 {
+	table := mat64.NewDense(kx, ky, nil)
+	xSums, ySums := make([]float64, kx), make([]float64, ky)
+	for i := range x {
+		table.Set(x[i], y[i], table.At(x[i], y[i])+1)
+		xSums[x[i]]++
+		ySums[y[i]]++
+	}
+	pairs := func(n float64) float64 { return n * (n - 1) / 2 }
+	index, xPairs, yPairs := 0.0, 0.0, 0.0
+	for i := 0; i < kx; i++ {
+		for _, n := range table.RawRowView(i) {
+			index += pairs(n)
+		}
+		xPairs += pairs(xSums[i])
+	}
+	for _, n := range ySums {
+		yPairs += pairs(n)
+	}
+	expected := xPairs * yPairs / pairs(float64(len(x)))
+	max := (xPairs + yPairs) / 2
+	if max == expected {
+		return 1.0
+	}
+	return (index - expected) / (max - expected)
+}
@@ -0,0 +1,87 @@
+package som
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdjustedRand(t *testing.T) {
+	assert := assert.New(t)
+
+	x := []int{0, 0, 0, 1, 1, 1}
+	// identical partitions with permuted labels
+	assert.InDelta(1.0, adjustedRand(x, []int{2, 2, 2, 0, 0, 0}, 2, 3), 1e-12)
+	// known value: contingency table [[2 1] [1 2]]
+	assert.InDelta(-1.0/9.0, adjustedRand(x, []int{0, 0, 1, 0, 1, 1}, 2, 2), 1e-12)
+	// single label partitions
+	assert.Equal(1.0, adjustedRand([]int{0, 0, 0}, []int{0, 0, 0}, 1, 1))
+}
+
+func TestAssignmentStability(t *testing.T) {
+	assert := assert.New(t)
+
+	// a unit for every well separated blob
+	blobs := makeBlobs(200, 4, 0.5, 1)
+	mc, tc := mergeConfigs([]int{2, 2})
+	tc.Radius = 1.0
+	report, err := AssignmentStability(blobs, mc, tc, 6, 1, 2)
+	assert.NoError(err)
+	assert.Equal(200*199/2, report.Pairs)
+	assert.Len(report.Samples, 200)
+	assert.True(report.Mean > 0.95)
+	assert.True(report.AdjustedRand > 0.95)
+	for _, s := range report.Samples {
+		assert.True(s > 0.9)
+	}
+	// the results are deterministic regardless of the number of workers
+	other, err := AssignmentStability(blobs, mc, tc, 6, 1, 1)
+	assert.NoError(err)
+	assert.Equal(report, other)
+
+	// uniform noise has no stable partition
+	r := rand.New(rand.NewSource(1))
+	noise := mat64.NewDense(200, 10, nil)
+	for i := 0; i < 200; i++ {
+		for j := 0; j < 10; j++ {
+			noise.Set(i, j, r.Float64())
+		}
+	}
+	mc.Cb.Dim = 10
+	mc.Cb.InitFunc = RandInit
+	report, err = AssignmentStability(noise, mc, tc, 6, 1, 0)
+	assert.NoError(err)
+	assert.True(report.AdjustedRand < 0.4)
+	assert.True(report.Mean < 0.9)
+}
+
+func TestAssignmentStabilitySampledPairs(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(1500, 4, 0.5, 1)
+	mc, tc := mergeConfigs([]int{2, 2})
+	tc.Algorithm = Batch
+	tc.Radius = 1.0
+	report, err := AssignmentStability(data, mc, tc, 2, 1, 0)
+	assert.NoError(err)
+	assert.Equal(StabilityPairs, report.Pairs)
+	assert.True(report.Mean > 0.95)
+	assert.True(report.AdjustedRand > 0.95)
+}
+
+func TestAssignmentStabilityErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data := makeBlobs(20, 2, 0.5, 1)
+	mc, tc := mergeConfigs([]int{2, 2})
+	_, err := AssignmentStability(nil, mc, tc, 3, 1, 0)
+	assert.Error(err)
+	_, err = AssignmentStability(mat64.NewDense(1, 2, nil), mc, tc, 3, 1, 0)
+	assert.Error(err)
+	_, err = AssignmentStability(data, mc, tc, 1, 1, 0)
+	assert.Error(err)
+	_, err = AssignmentStability(data, mc, &TrainConfig{Algorithm: Seq}, 3, 1, 0)
+	assert.Error(err)
+}
@@ -0,0 +1,111 @@
+package som
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// mapQuality holds map quality measures computed on data
+type mapQuality struct {
+	// quantErr is quantization error
+	quantErr float64
+	// topoErr is topographic error; it is NaN if the map has no topology
+	topoErr float64
+	// dead is the number of units which are not BMU of any data sample
+	dead int
+	// hits holds the number of data samples which have every unit as their BMU
+	hits []int
+}
+
+// RecordQuality computes quantization error, topographic error and the number of dead units,
+// which are not BMU of any data sample, and records them for Summary together with the number of data
+// samples which have every unit as their BMU used by MergeMaps.
+// The recorded measures are discarded when the map is trained again. Topographic error
+// is not computed if the map has no topology.
+// It returns error if data is nil or if any of the measures could not be computed.
+func (m *Map) RecordQuality(data mat64.Matrix) error {
+	// nil data passed in
+	if isNilMatrix(data) {
+		return fmt.Errorf("invalid data supplied: %v", data)
+	}
+	qe, err := m.QuantError(data)
+	if err != nil {
+		return err
+	}
+	te := math.NaN()
+	if !m.noTopology {
+		if te, err = m.TopoError(data); err != nil {
+			return err
+		}
+	}
+	bmus, err := m.BMUs(data)
+	if err != nil {
+		return err
+	}
+	units, _ := m.cbDims()
+	hits := make([]int, units)
+	for _, bmu := range bmus {
+		hits[bmu]++
+	}
+	dead := 0
+	for _, hit := range hits {
+		if hit == 0 {
+			dead++
+		}
+	}
+	m.quality = &mapQuality{quantErr: qe, topoErr: te, dead: dead, hits: hits}
+
+	return nil
+}
+
+// Summary returns multi-line human readable description of the map: grid dimensions, unit shape,
+// grid type, distance metric, codebook size and precision, the last training and the quality
+// measures recorded by RecordQuality. Missing information is reported as n/a.
+// Floating point numbers are formatted with 4 decimal places, so the format is stable.
+func (m Map) Summary() string {
+	units, dim := m.cbDims()
+	size := m.grid.Size()
+	var b bytes.Buffer
+	dims := make([]string, len(size))
+	for i, dim := range size {
+		dims[i] = strconv.Itoa(dim)
+	}
+	fmt.Fprintf(&b, "dims: %s\n", strings.Join(dims, "x"))
+	fmt.Fprintf(&b, "unit shape: %s\n", m.grid.UShape())
+	fmt.Fprintf(&b, "grid: %s\n", m.grid.Type())
+	// BMU search uses euclidean distance
+	fmt.Fprintf(&b, "metric: euclidean\n")
+	fmt.Fprintf(&b, "codebook: %d units x %d dims, %s\n", units, dim, m.Precision())
+	if m.lastTrain != nil {
+		fmt.Fprintf(&b, "trained: yes\n")
+		fmt.Fprintf(&b, "training: %s, %d iterations\n", m.lastTrain.Algorithm, m.lastTrain.Iters)
+	} else {
+		fmt.Fprintf(&b, "trained: no\n")
+		fmt.Fprintf(&b, "training: n/a\n")
+	}
+	if m.quality != nil {
+		fmt.Fprintf(&b, "quantization error: %.4f\n", m.quality.quantErr)
+		if math.IsNaN(m.quality.topoErr) {
+			fmt.Fprintf(&b, "topographic error: n/a\n")
+		} else {
+			fmt.Fprintf(&b, "topographic error: %.4f\n", m.quality.topoErr)
+		}
+		fmt.Fprintf(&b, "dead units: %d\n", m.quality.dead)
+	} else {
+		fmt.Fprintf(&b, "quantization error: n/a\n")
+		fmt.Fprintf(&b, "topographic error: n/a\n")
+		fmt.Fprintf(&b, "dead units: n/a\n")
+	}
+
+	return b.String()
+}
+
+// String returns map Summary
+func (m Map) String() string {
+	return m.Summary()
+}
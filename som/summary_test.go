@@ -0,0 +1,72 @@
+package som
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/milosgajdos83/gosom/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := matrix.MakeRandom(30, 4, -10.0, 10.0)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{4, 3},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      4,
+			InitFunc: RandInit,
+		},
+	}
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	exp := "dims: 4x3\n" +
+		"unit shape: hexagon\n" +
+		"grid: planar\n" +
+		"metric: euclidean\n" +
+		"codebook: 12 units x 4 dims, float64\n" +
+		"trained: no\n" +
+		"training: n/a\n" +
+		"quantization error: n/a\n" +
+		"topographic error: n/a\n" +
+		"dead units: n/a\n"
+	assert.Equal(exp, m.Summary())
+	assert.Equal(exp, m.String())
+	assert.Equal(exp, fmt.Sprint(m))
+
+	tc := &TrainConfig{
+		Algorithm: "batch",
+		Radius:    2.0,
+		RDecay:    "exp",
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    "exp",
+	}
+	assert.NoError(m.Train(tc, data, 20))
+	assert.NoError(m.RecordQuality(data))
+	exp = "dims: 4x3\n" +
+		"unit shape: hexagon\n" +
+		"grid: planar\n" +
+		"metric: euclidean\n" +
+		"codebook: 12 units x 4 dims, float64\n" +
+		"trained: yes\n" +
+		"training: batch, 20 iterations\n" +
+		"quantization error: 6.0139\n" +
+		"topographic error: 0.0333\n" +
+		"dead units: 1\n"
+	assert.Equal(exp, m.Summary())
+	// training discards recorded quality measures
+	assert.NoError(m.Train(tc, data, 5))
+	assert.Contains(m.Summary(), "training: batch, 5 iterations\nquantization error: n/a\n")
+	// float32 precision maps
+	mCfg.Cb.Precision = "float32"
+	m, err = NewMap(mCfg, data)
+	assert.NoError(err)
+	assert.Contains(m.Summary(), "codebook: 12 units x 4 dims, float32\n")
+	assert.Error(m.RecordQuality(nil))
+}
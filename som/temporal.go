@@ -0,0 +1,181 @@
+package som
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Temporal is Temporal Kohonen Map training of sequences. Every unit keeps a leaky activation
+// a(t) = Leak*a(t-1) - d(t), where d(t) is the squared distance of the unit codebook vector
+// from sample x(t), and the unit with maximal activation is the BMU which is updated
+// in the same way as in sequential training. Data rows are samples ordered in time.
+const Temporal Method = "temporal"
+
+// Activations holds leaky unit activations of a temporal map over a sequence of samples
+type Activations struct {
+	// m is the map whose units are activated
+	m *Map
+	// leak is activation leak coefficient
+	leak float64
+	// act holds unit activations
+	act []float64
+}
+
+// NewActivations returns zero activations of map m units with leak coefficient leak.
+// It returns error if m is nil or if leak is not between 0 and 1.
+func NewActivations(m *Map, leak float64) (*Activations, error) {
+	if m == nil {
+		return nil, fmt.Errorf("invalid map supplied: %v", m)
+	}
+	if !(leak > 0 && leak < 1) {
+		return nil, fmt.Errorf("invalid leak coefficient: %f, must be between 0 and 1", leak)
+	}
+	units, _ := m.cbDims()
+	return &Activations{m: m, leak: leak, act: make([]float64, units)}, nil
+}
+
+// Reset sets all activations to zero. Activations should be reset at the start of every sequence.
+func (a *Activations) Reset() {
+	for i := range a.act {
+		a.act[i] = 0.0
+	}
+}
+
+// Step activates map units by the next sample x of the sequence and returns the index of
+// the unit with maximal activation. It returns ErrDimMismatch if the dimension of x is different
+// from the map codebook dimension. When the error is returned, the activations are not changed.
+func (a *Activations) Step(x []float64) (int, error) {
+	if _, cols := a.m.cbDims(); len(x) != cols {
+		return -1, ErrDimMismatch
+	}
+	return a.step(x), nil
+}
+
+// step updates activations by sample x and returns the unit with maximal activation
+func (a *Activations) step(x []float64) int {
+	bmu, best := -1, math.Inf(-1)
+	for i := range a.act {
+		d := a.m.unitDistance(x, i)
+		a.act[i] = a.leak*a.act[i] - d*d
+		if a.act[i] > best {
+			bmu, best = i, a.act[i]
+		}
+	}
+	return bmu
+}
+
+// Values returns a copy of unit activations
+func (a *Activations) Values() []float64 {
+	return append([]float64(nil), a.act...)
+}
+
+// Trajectory returns BMUs of all samples of sequence seq found by unit activations with
+// leak coefficient leak which start at zero. Sequence rows are samples ordered in time.
+// It returns error if seq is nil, if leak is not between 0 and 1 or ErrDimMismatch if
+// the dimension of seq is different from the map codebook dimension.
+func (m *Map) Trajectory(seq mat64.Matrix, leak float64) ([]int, error) {
+	if isNilMatrix(seq) {
+		return nil, fmt.Errorf("invalid sequence supplied: %v", seq)
+	}
+	a, err := NewActivations(m, leak)
+	if err != nil {
+		return nil, err
+	}
+	rows, cols := seq.Dims()
+	if _, cbCols := m.cbDims(); cols != cbCols {
+		return nil, ErrDimMismatch
+	}
+	rd := newRowReader(seq)
+	bmus := make([]int, rows)
+	for i := range bmus {
+		bmus[i] = a.step(rd.row(i))
+	}
+	return bmus, nil
+}
+
+// TrainSequences trains the map on sequences whose rows are samples ordered in time.
+// Temporal training picks a random sequence, resets unit activations and trains on all
+// its samples in order before picking the next sequence; other training algorithms
+// train on samples of all sequences as if they were a single data set.
+// It returns error if seqs is empty, if any sequence is nil, if the sequences
+// have different dimensions or if the training fails in the same way as Train.
+func (m *Map) TrainSequences(c *TrainConfig, seqs []*mat64.Dense, iters int) error {
+	if len(seqs) == 0 {
+		return fmt.Errorf("invalid number of sequences: %d", len(seqs))
+	}
+	starts := make([]int, len(seqs))
+	rows, cols := 0, 0
+	for i, seq := range seqs {
+		if seq == nil {
+			return fmt.Errorf("invalid sequence %d: %v", i, seq)
+		}
+		r, c := seq.Dims()
+		if i == 0 {
+			cols = c
+		}
+		if c != cols {
+			return fmt.Errorf("invalid sequence %d: %w", i, ErrDimMismatch)
+		}
+		starts[i] = rows
+		rows += r
+	}
+	data := mat64.NewDense(rows, cols, nil)
+	for i, seq := range seqs {
+		r, _ := seq.Dims()
+		data.Slice(starts[i], starts[i]+r, 0, cols).(*mat64.Dense).Copy(seq)
+	}
+	_, err := m.trainSeqs(context.Background(), c, data, starts, iters, trainRand(c))
+	return err
+}
+
+// temporalTrain runs temporal training on sequences stored in data rows which start at rows in starts.
+// If starts is nil, data holds a single sequence.
+func (m *Map) temporalTrain(ctx context.Context, tc *TrainConfig, data mat64.Matrix, starts []int, iters int, r *rand.Rand) error {
+	rows, _ := data.Dims()
+	if starts == nil {
+		starts = []int{0}
+	}
+	t, err := newSeqTrainer(m, tc)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+	// no need to check for error: Leak is checked by config validation
+	a, _ := NewActivations(m, tc.Leak)
+	log := trainLogger(tc)
+	units, _ := m.cbDims()
+	metrics := newTrainMetrics(m, tc, units, iters, rows)
+	rd := newRowReader(data)
+	pos, end := 0, 0
+	for i := 0; i < iters; i++ {
+		if err := canceled(ctx, i); err != nil {
+			return err
+		}
+		logProgress(log, tc, i, iters)
+		// pick the next sequence when the current one is finished
+		for pos == end {
+			s := r.Intn(len(starts))
+			pos, end = starts[s], rows
+			if s+1 < len(starts) {
+				end = starts[s+1]
+			}
+			a.Reset()
+		}
+		sample := rd.row(pos)
+		pos++
+		m.profile.start()
+		bmu := a.step(sample)
+		m.profile.lap(profileBMU)
+		t.update(i, iters, bmu, sample)
+		if !metrics.update(i) {
+			break
+		}
+	}
+	m.epochs = metrics
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package som
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeSequence generates n noisy samples of values 0 and 1 which repeat in blocks of the given length
+func makeSequence(n, block int, seed int64) *mat64.Dense {
+	r := rand.New(rand.NewSource(seed))
+	seq := mat64.NewDense(n, 1, nil)
+	for i := 0; i < n; i++ {
+		seq.Set(i, 0, float64((i/block)%2)+r.NormFloat64()*0.02)
+	}
+	return seq
+}
+
+// hitOverlap returns the overlap of normalized BMU histograms of two sequences
+func hitOverlap(a, b []int, units int) float64 {
+	ha, hb := make([]float64, units), make([]float64, units)
+	for _, bmu := range a {
+		ha[bmu] += 1.0 / float64(len(a))
+	}
+	for _, bmu := range b {
+		hb[bmu] += 1.0 / float64(len(b))
+	}
+	overlap := 0.0
+	for i := range ha {
+		if ha[i] < hb[i] {
+			overlap += ha[i]
+		} else {
+			overlap += hb[i]
+		}
+	}
+	return overlap
+}
+
+func TestTemporalSeparation(t *testing.T) {
+	assert := assert.New(t)
+
+	// both sequences hold the same number of zeros and ones
+	alternating := makeSequence(200, 1, 1)
+	blocks := makeSequence(200, 4, 2)
+	data := mat64.NewDense(400, 1, nil)
+	data.Slice(0, 200, 0, 1).(*mat64.Dense).Copy(alternating)
+	data.Slice(200, 400, 0, 1).(*mat64.Dense).Copy(blocks)
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{1, 10},
+			Type:   Planar,
+			UShape: Rectangle,
+		},
+		Cb: &CbConfig{
+			Dim:      1,
+			InitFunc: RandInit,
+		},
+	}
+	tc := &TrainConfig{
+		Algorithm: Seq,
+		Radius:    3.0,
+		RDecay:    ExpDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    ExpDecay,
+		Leak:      0.3,
+	}
+	overlap := func(m *Map) float64 {
+		a, err := m.Trajectory(alternating, tc.Leak)
+		assert.NoError(err)
+		b, err := m.Trajectory(blocks, tc.Leak)
+		assert.NoError(err)
+		return hitOverlap(a, b, 10)
+	}
+
+	// plain SOM BMUs only depend on sample values
+	m, err := NewMap(mc, data)
+	assert.NoError(err)
+	_, err = m.trainSeqs(context.Background(), tc, data, []int{0, 200}, 4000, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	bmusA, err := m.BMUs(alternating)
+	assert.NoError(err)
+	bmusB, err := m.BMUs(blocks)
+	assert.NoError(err)
+	somOverlap := hitOverlap(bmusA, bmusB, 10)
+	assert.True(somOverlap > 0.8, "som overlap: %f", somOverlap)
+
+	tc.Algorithm = Temporal
+	m, err = NewMap(mc, data)
+	assert.NoError(err)
+	_, err = m.trainSeqs(context.Background(), tc, data, []int{0, 200}, 4000, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	tkmOverlap := overlap(m)
+	assert.True(tkmOverlap < 0.5, "temporal overlap: %f", tkmOverlap)
+	assert.NoError(m.TrainSequences(tc, []*mat64.Dense{alternating, blocks}, 400))
+}
+
+func TestActivations(t *testing.T) {
+	assert := assert.New(t)
+
+	mc := &MapConfig{
+		Grid: &GridConfig{Size: []int{1, 2}, Type: Planar, UShape: Rectangle},
+		Cb: &CbConfig{
+			Dim: 1,
+			InitFunc: func(*mat64.Dense, []int) (*mat64.Dense, error) {
+				return mat64.NewDense(2, 1, []float64{0.0, 1.0}), nil
+			},
+		},
+	}
+	m, err := NewMap(mc, mat64.NewDense(2, 1, []float64{0.0, 1.0}))
+	assert.NoError(err)
+
+	_, err = NewActivations(nil, 0.5)
+	assert.EqualError(err, "invalid map supplied: <nil>")
+	for _, leak := range []float64{0.0, 1.0, -0.5} {
+		_, err = NewActivations(m, leak)
+		assert.EqualError(err, fmt.Sprintf("invalid leak coefficient: %f, must be between 0 and 1", leak))
+	}
+	a, err := NewActivations(m, 0.5)
+	assert.NoError(err)
+	_, err = a.Step([]float64{1.0, 2.0})
+	assert.True(errors.Is(err, ErrDimMismatch))
+	assert.Equal([]float64{0.0, 0.0}, a.Values())
+
+	bmu, err := a.Step([]float64{0.0})
+	assert.NoError(err)
+	assert.Equal(0, bmu)
+	assert.Equal([]float64{0.0, -1.0}, a.Values())
+	// history keeps the first unit active
+	bmu, err = a.Step([]float64{0.6})
+	assert.NoError(err)
+	assert.Equal(0, bmu)
+	assert.InDeltaSlice([]float64{-0.36, -0.66}, a.Values(), 1e-12)
+	a.Reset()
+	bmu, err = a.Step([]float64{0.6})
+	assert.NoError(err)
+	assert.Equal(1, bmu)
+
+	bmus, err := m.Trajectory(mat64.NewDense(2, 1, []float64{0.0, 0.6}), 0.5)
+	assert.NoError(err)
+	assert.Equal([]int{0, 0}, bmus)
+	_, err = m.Trajectory(nil, 0.5)
+	assert.EqualError(err, "invalid sequence supplied: <nil>")
+	_, err = m.Trajectory(mat64.NewDense(2, 2, nil), 0.5)
+	assert.True(errors.Is(err, ErrDimMismatch))
+	_, err = m.Trajectory(mat64.NewDense(2, 1, nil), 1.5)
+	assert.Error(err)
+}
+
+func TestTrainSequencesErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	seq := makeSequence(10, 2, 1)
+	mc := &MapConfig{
+		Grid: &GridConfig{Size: []int{1, 3}, Type: Planar, UShape: Rectangle},
+		Cb:   &CbConfig{Dim: 1, InitFunc: RandInit},
+	}
+	m, err := NewMap(mc, seq)
+	assert.NoError(err)
+	tc := &TrainConfig{
+		Algorithm: Temporal,
+		Radius:    1.0,
+		RDecay:    LinDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    LinDecay,
+	}
+	assert.EqualError(m.TrainSequences(tc, nil, 10), "invalid number of sequences: 0")
+	assert.EqualError(m.TrainSequences(tc, []*mat64.Dense{seq, nil}, 10), "invalid sequence 1: <nil>")
+	err = m.TrainSequences(tc, []*mat64.Dense{seq, mat64.NewDense(2, 2, nil)}, 10)
+	assert.True(errors.Is(err, ErrDimMismatch))
+	err = m.TrainSequences(tc, []*mat64.Dense{seq}, 10)
+	assert.Error(err)
+	assert.Contains(err.Error(), "invalid leak coefficient: 0.000000, must be between 0 and 1")
+	tc.Leak = 0.3
+	assert.NoError(m.TrainSequences(tc, []*mat64.Dense{seq, seq}, 10))
+}
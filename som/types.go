@@ -84,8 +84,16 @@ func newFloat64Heap(cap int, items ...*float64Item) (*float64Heap, error) {
 	return h, nil
 }
 
+// itemLess orders heap items by value; items with the same value are ordered by index
+func itemLess(a, b *float64Item) bool {
+	if a.val == b.val {
+		return a.index < b.index
+	}
+	return a.val < b.val
+}
+
 func (h float64Heap) Len() int           { return h.size }
-func (h float64Heap) Less(i, j int) bool { return h.items[i].val > h.items[j].val }
+func (h float64Heap) Less(i, j int) bool { return itemLess(h.items[j], h.items[i]) }
 func (h float64Heap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
 
 func (h *float64Heap) Push(x interface{}) {
@@ -93,7 +101,7 @@ func (h *float64Heap) Push(x interface{}) {
 	// if we are at full cap, just replace the peak
 	switch h.size {
 	case len(h.items):
-		if item.val < (*h).items[0].val {
+		if itemLess(item, (*h).items[0]) {
 			(*h).items[0] = item
 			heap.Fix(h, 0)
 		}
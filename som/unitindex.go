@@ -0,0 +1,127 @@
+package som
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// unitIndex is a spatial index of map grid units which finds units within a radius of
+// a given unit without scanning all map units. The grid space is split into unit sized
+// square or cube cells, so the units within radius r are found in O(r^2) cells around
+// the unit of 2D grids and in O(r^3) cells of 3D grids.
+type unitIndex struct {
+	// coords holds grid unit coordinates
+	coords *mat64.Dense
+	// unitDist holds distances between grid units
+	unitDist *mat64.Dense
+	// minX, minY and minZ are the lowest grid unit coordinates; minZ is zero for 2D grids
+	minX, minY, minZ float64
+	// cols, rows and layers are the number of cells in x, y and z direction
+	cols, rows, layers int
+	// cells holds indices of grid units in every cell stored in row major order layer by layer
+	cells [][]int
+}
+
+// newUnitIndex builds unit index from grid unit coordinates and their distance matrix.
+// If the grid units don't have 2D or 3D coordinates, the index scans all units.
+func newUnitIndex(coords, unitDist *mat64.Dense) *unitIndex {
+	units, dims := coords.Dims()
+	if dims != 2 && dims != 3 {
+		return newScanIndex(unitDist)
+	}
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	minZ, maxZ := 0.0, 0.0
+	if dims == 3 {
+		minZ, maxZ = math.Inf(1), math.Inf(-1)
+	}
+	for i := 0; i < units; i++ {
+		minX, maxX = math.Min(minX, coords.At(i, 0)), math.Max(maxX, coords.At(i, 0))
+		minY, maxY = math.Min(minY, coords.At(i, 1)), math.Max(maxY, coords.At(i, 1))
+		if dims == 3 {
+			minZ, maxZ = math.Min(minZ, coords.At(i, 2)), math.Max(maxZ, coords.At(i, 2))
+		}
+	}
+	ix := &unitIndex{
+		coords:   coords,
+		unitDist: unitDist,
+		minX:     minX,
+		minY:     minY,
+		minZ:     minZ,
+		cols:     int(maxX-minX) + 1,
+		rows:     int(maxY-minY) + 1,
+		layers:   int(maxZ-minZ) + 1,
+	}
+	ix.cells = make([][]int, ix.cols*ix.rows*ix.layers)
+	for i := 0; i < units; i++ {
+		x, y, z := ix.point(i)
+		cx, cy, cz := ix.cell(x, y, z)
+		c := (cz*ix.rows+cy)*ix.cols + cx
+		ix.cells[c] = append(ix.cells[c], i)
+	}
+	return ix
+}
+
+// newScanIndex builds unit index which stores all units in a single cell,
+// so every search scans all units
+func newScanIndex(unitDist *mat64.Dense) *unitIndex {
+	units, _ := unitDist.Dims()
+	all := make([]int, units)
+	for i := range all {
+		all[i] = i
+	}
+	return &unitIndex{unitDist: unitDist, cols: 1, rows: 1, layers: 1, cells: [][]int{all}}
+}
+
+// point returns coordinates of unit i; z coordinate of units of 2D grids is zero
+func (ix *unitIndex) point(i int) (float64, float64, float64) {
+	z := 0.0
+	if _, dims := ix.coords.Dims(); dims == 3 {
+		z = ix.coords.At(i, 2)
+	}
+	return ix.coords.At(i, 0), ix.coords.At(i, 1), z
+}
+
+// cell returns cell coordinates of point x, y, z clamped to the indexed grid space
+func (ix *unitIndex) cell(x, y, z float64) (int, int, int) {
+	return clampCell(x-ix.minX, ix.cols), clampCell(y-ix.minY, ix.rows), clampCell(z-ix.minZ, ix.layers)
+}
+
+// clampCell returns the index of cell which contains offset v clamped to range 0 to n-1
+func clampCell(v float64, n int) int {
+	v = math.Floor(v)
+	// NaN offsets are clamped to the first cell
+	if !(v >= 0) {
+		return 0
+	}
+	if v > float64(n-1) {
+		return n - 1
+	}
+	return int(v)
+}
+
+// within appends indices of units whose distance from unit is less than radius to dst
+// and returns the extended slice.
+func (ix *unitIndex) within(dst []int, unit int, radius float64) []int {
+	fromX, fromY, fromZ, toX, toY, toZ := 0, 0, 0, 0, 0, 0
+	if ix.coords != nil {
+		x, y, z := ix.point(unit)
+		fromX, fromY, fromZ = ix.cell(x-radius, y-radius, z-radius)
+		toX, toY, toZ = ix.cell(x+radius, y+radius, z+radius)
+	}
+	dists := ix.unitDist.RawRowView(unit)
+	for cz := fromZ; cz <= toZ; cz++ {
+		for cy := fromY; cy <= toY; cy++ {
+			row := (cz*ix.rows + cy) * ix.cols
+			for _, cell := range ix.cells[row+fromX : row+toX+1] {
+				for _, i := range cell {
+					if dists[i] < radius {
+						dst = append(dst, i)
+					}
+				}
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,138 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/milosgajdos83/gosom/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitIndex(t *testing.T) {
+	assert := assert.New(t)
+
+	radii := []float64{0.5, 1.0, 1.5, 2.7, 10.0, math.Inf(1), math.NaN()}
+	sizes := map[string][][]int{
+		"hexagon":   {{7, 5}, {1, 10}, {12, 9}},
+		"rectangle": {{7, 5}, {1, 10}, {12, 9}, {3, 4, 5}, {1, 2, 6}},
+	}
+	for uShape, shapeSizes := range sizes {
+		for _, size := range shapeSizes {
+			coords, err := GridCoords(uShape, size)
+			assert.NoError(err)
+			unitDist, err := DistanceMx("euclidean", coords)
+			assert.NoError(err)
+			ix := newUnitIndex(coords, unitDist)
+			scan := newScanIndex(unitDist)
+			units, _ := unitDist.Dims()
+			for _, radius := range radii {
+				for unit := 0; unit < units; unit++ {
+					var exp []int
+					for i, dist := range unitDist.RawRowView(unit) {
+						if dist < radius {
+							exp = append(exp, i)
+						}
+					}
+					near := ix.within(nil, unit, radius)
+					sort.Ints(near)
+					assert.Equal(exp, near)
+					assert.Equal(exp, scan.within(nil, unit, radius))
+				}
+			}
+		}
+	}
+}
+
+func TestSeqTrainerUnitIndex(t *testing.T) {
+	assert := assert.New(t)
+
+	data, _ := matrix.MakeRandom(200, 3, -10.0, 10.0)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{9, 7},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      3,
+			InitFunc: RandInit,
+		},
+	}
+	rows, _ := data.Dims()
+	iters := 2000
+	for _, nFn := range []NeighbFunc{Gaussian, Bubble, MexicanHat} {
+		tc := &TrainConfig{
+			Algorithm: "seq",
+			Radius:    5.0,
+			RDecay:    "exp",
+			NeighbFn:  nFn,
+			LRate:     0.5,
+			LDecay:    "exp",
+		}
+		m, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		ref, err := NewMap(mCfg, data)
+		assert.NoError(err)
+		st, err := newSeqTrainer(m, tc)
+		assert.NoError(err)
+		refSt, err := newSeqTrainer(ref, tc)
+		assert.NoError(err)
+		// reference trainer scans all map units
+		refSt.index = newScanIndex(refSt.index.unitDist)
+		for i := 0; i < iters; i++ {
+			st.step(i, iters, data.RawRowView(i%rows))
+			refSt.step(i, iters, data.RawRowView(i%rows))
+		}
+		assert.Equal(ref.codebook.RawMatrix().Data, m.codebook.RawMatrix().Data)
+	}
+}
+
+func BenchmarkSeqTrainerRadius(b *testing.B) {
+	data, _ := matrix.MakeRandom(1000, 16, -10.0, 10.0)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{60, 60},
+			Type:   "planar",
+			UShape: "hexagon",
+		},
+		Cb: &CbConfig{
+			Dim:      16,
+			InitFunc: RandInit,
+		},
+	}
+	m, err := NewMap(mCfg, data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	rows, _ := data.Dims()
+	for _, radius := range []float64{1.5, 5.0, 15.0, 60.0} {
+		tc := &TrainConfig{
+			Algorithm: "seq",
+			Radius:    radius,
+			RDecay:    "exp",
+			NeighbFn:  Bubble,
+			LRate:     0.1,
+			LDecay:    "exp",
+		}
+		st, err := newSeqTrainer(m, tc)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, scan := range []bool{false, true} {
+			name := fmt.Sprintf("index-%.1f", radius)
+			if scan {
+				st.index = newScanIndex(st.index.unitDist)
+				name = fmt.Sprintf("scan-%.1f", radius)
+			}
+			b.Run(name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					// the first training step uses the initial radius
+					st.step(0, 1000, data.RawRowView(i%rows))
+				}
+			})
+		}
+		st.close()
+	}
+}
@@ -0,0 +1,145 @@
+package som
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Encode quantizes data using the map codebook: each data row is encoded as the index
+// of its Best Match Unit. It returns error if data is nil or ErrDimMismatch if data
+// and codebook dimensions differ.
+func (m Map) Encode(data *mat64.Dense) ([]int, error) {
+	return m.BMUs(data)
+}
+
+// Decode reconstructs data rows from the supplied codebook indices: i-th row of the
+// returned matrix is the codebook vector with index indices[i].
+// It returns error if indices is empty or if any of the indices is out of codebook range.
+func (m Map) Decode(indices []int) (*mat64.Dense, error) {
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("invalid number of indices: %d", len(indices))
+	}
+	units, dim := m.cbDims()
+	cb := m.cb()
+	data := mat64.NewDense(len(indices), dim, nil)
+	for i, idx := range indices {
+		if idx < 0 || idx >= units {
+			return nil, fmt.Errorf("invalid codebook index: %d", idx)
+		}
+		data.SetRow(i, cb.RawRowView(idx))
+	}
+
+	return data, nil
+}
+
+// ReconstructionError encodes and decodes data and returns the mean euclidean distance
+// between the original and reconstructed data rows. This equals map quantization error.
+// It fails with the same errors as Encode.
+func (m Map) ReconstructionError(data *mat64.Dense) (float64, error) {
+	indices, err := m.Encode(data)
+	if err != nil {
+		return -1.0, err
+	}
+	// no need to check for error: indices are valid
+	decoded, _ := m.Decode(indices)
+	var rErr float64
+	for i := range indices {
+		rErr += euclideanVec(data.RawRowView(i), decoded.RawRowView(i))
+	}
+
+	return rErr / float64(len(indices)), nil
+}
+
+// indexBits returns the number of bits required to store index of any of units
+func indexBits(units int) uint {
+	bits := uint(1)
+	for (1 << bits) < units {
+		bits++
+	}
+	return bits
+}
+
+// WriteIndices writes codebook indices to w packed into ceil(log2(units)) bits per index.
+// The packed stream is preceded by a header which contains units and the number of
+// indices, both stored as big endian uint32. The last byte is padded with zero bits.
+// It returns error if units is not a positive integer, if any of the indices is out of
+// range or if the write to w fails.
+func WriteIndices(w io.Writer, indices []int, units int) error {
+	if units <= 0 {
+		return fmt.Errorf("invalid number of units: %d", units)
+	}
+	header := []uint32{uint32(units), uint32(len(indices))}
+	if err := binary.Write(w, binary.BigEndian, header); err != nil {
+		return err
+	}
+	bits := indexBits(units)
+	bw := bufio.NewWriter(w)
+	var acc uint64
+	var n uint
+	for _, idx := range indices {
+		if idx < 0 || idx >= units {
+			return fmt.Errorf("invalid codebook index: %d", idx)
+		}
+		acc = acc<<bits | uint64(idx)
+		n += bits
+		for n >= 8 {
+			n -= 8
+			if err := bw.WriteByte(byte(acc >> n)); err != nil {
+				return err
+			}
+		}
+		acc &= (1 << n) - 1
+	}
+	if n > 0 {
+		if err := bw.WriteByte(byte(acc << (8 - n))); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// ReadIndices reads codebook indices written by WriteIndices from r.
+// It returns the indices and the number of codebook units stored in the stream header.
+// It returns error if the stream is truncated or corrupted.
+func ReadIndices(r io.Reader) ([]int, int, error) {
+	header := make([]uint32, 2)
+	if err := binary.Read(r, binary.BigEndian, header); err != nil {
+		return nil, 0, err
+	}
+	units, count := int(header[0]), int(header[1])
+	if units <= 0 {
+		return nil, 0, fmt.Errorf("invalid number of units: %d", units)
+	}
+	bits := indexBits(units)
+	br := bufio.NewReader(r)
+	indices := make([]int, count)
+	var acc uint64
+	var n uint
+	for i := range indices {
+		for n < bits {
+			b, err := br.ReadByte()
+			if err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return nil, 0, err
+			}
+			acc = acc<<8 | uint64(b)
+			n += 8
+		}
+		n -= bits
+		idx := int(acc >> n)
+		if idx >= units {
+			return nil, 0, fmt.Errorf("invalid codebook index: %d", idx)
+		}
+		indices[i] = idx
+		acc &= (1 << n) - 1
+	}
+
+	return indices, units, nil
+}
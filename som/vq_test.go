@@ -0,0 +1,117 @@
+package som
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMap(mSom, dataMx)
+	assert.NoError(err)
+	// invalid input
+	indices, err := m.Encode(nil)
+	assert.Nil(indices)
+	assert.EqualError(err, fmt.Sprintf("invalid data supplied: %v", nil))
+	indices, err = m.Encode(mat64.NewDense(2, 2, nil))
+	assert.Nil(indices)
+	assert.Equal(ErrDimMismatch, err)
+	decoded, err := m.Decode(nil)
+	assert.Nil(decoded)
+	assert.EqualError(err, fmt.Sprintf("invalid number of indices: %d", 0))
+	decoded, err = m.Decode([]int{0, 6})
+	assert.Nil(decoded)
+	assert.EqualError(err, fmt.Sprintf("invalid codebook index: %d", 6))
+	// round trip
+	indices, err = m.Encode(dataMx)
+	assert.NoError(err)
+	bmus, err := m.BMUs(dataMx)
+	assert.NoError(err)
+	assert.Equal(bmus, indices)
+	decoded, err = m.Decode(indices)
+	assert.NoError(err)
+	for i, idx := range indices {
+		assert.Equal(m.codebook.RawRowView(idx), decoded.RawRowView(i))
+	}
+	// distortion equals quantization error
+	data := utils.GenerateClusters(200, 4, 3, 10.0, -10.0, 2.0, 10)
+	rErr, err := m.ReconstructionError(data)
+	assert.NoError(err)
+	qErr, err := m.QuantError(data)
+	assert.NoError(err)
+	assert.InDelta(qErr, rErr, 1e-9)
+	rErr, err = m.ReconstructionError(nil)
+	assert.Equal(-1.0, rErr)
+	assert.Error(err)
+}
+
+func TestWriteReadIndices(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		units   int
+		indices []int
+		size    int
+	}{
+		{1, []int{0, 0, 0}, 8 + 1},
+		{2, []int{0, 1, 1, 0, 1, 0, 0, 1, 1}, 8 + 2},
+		{6, []int{5, 0, 3, 2, 1, 4}, 8 + 3},
+		{256, []int{255, 0, 128}, 8 + 3},
+		{400, []int{399, 0, 17, 256, 1}, 8 + 6},
+		{10, []int{}, 8},
+	}
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		assert.NoError(WriteIndices(&buf, tc.indices, tc.units))
+		assert.Equal(tc.size, buf.Len())
+		indices, units, err := ReadIndices(&buf)
+		assert.NoError(err)
+		assert.Equal(tc.units, units)
+		assert.Equal(tc.indices, indices)
+	}
+	// invalid input
+	var buf bytes.Buffer
+	assert.EqualError(WriteIndices(&buf, []int{1}, 0), fmt.Sprintf("invalid number of units: %d", 0))
+	assert.EqualError(WriteIndices(&buf, []int{6}, 6), fmt.Sprintf("invalid codebook index: %d", 6))
+	// truncated stream
+	buf.Reset()
+	assert.NoError(WriteIndices(&buf, []int{1, 2, 3, 4}, 6))
+	truncated := bytes.NewReader(buf.Bytes()[:buf.Len()-1])
+	indices, _, err := ReadIndices(truncated)
+	assert.Nil(indices)
+	assert.Equal(io.ErrUnexpectedEOF, err)
+	// corrupted stream: index out of range
+	buf.Reset()
+	assert.NoError(WriteIndices(&buf, []int{7}, 8))
+	corrupted := buf.Bytes()
+	corrupted[3] = 6
+	indices, _, err = ReadIndices(bytes.NewReader(corrupted))
+	assert.Nil(indices)
+	assert.EqualError(err, fmt.Sprintf("invalid codebook index: %d", 7))
+	// round trip through map codebook
+	m, err := NewMap(mSom, dataMx)
+	assert.NoError(err)
+	indices, err = m.Encode(dataMx)
+	assert.NoError(err)
+	buf.Reset()
+	units, _ := m.codebook.Dims()
+	assert.NoError(WriteIndices(&buf, indices, units))
+	read, _, err := ReadIndices(&buf)
+	assert.NoError(err)
+	decoded, err := m.Decode(read)
+	assert.NoError(err)
+	qErr, err := m.QuantError(dataMx)
+	assert.NoError(err)
+	rErr := 0.0
+	for i := 0; i < len(read); i++ {
+		rErr += euclideanVec(dataMx.RawRowView(i), decoded.RawRowView(i))
+	}
+	assert.InDelta(qErr, rErr/float64(len(read)), 1e-9)
+}
@@ -0,0 +1,52 @@
+package som
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// sampler picks random training samples of seq training. Weighted samples are picked with
+// probability proportional to their weights.
+type sampler struct {
+	// r generates random numbers
+	r *rand.Rand
+	// rows is the number of samples
+	rows int
+	// cum holds cumulative sample weights; it is nil if the samples are not weighted
+	cum []float64
+}
+
+// newSampler returns sampler of rows samples with weights using random number generator r.
+// If weights is nil, every sample is picked with the same probability.
+func newSampler(r *rand.Rand, rows int, weights []float64) *sampler {
+	s := &sampler{r: r, rows: rows}
+	if weights != nil {
+		s.cum = make([]float64, rows)
+		total := 0.0
+		for i, w := range weights {
+			total += w
+			s.cum[i] = total
+		}
+	}
+	return s
+}
+
+// next returns the index of the next random sample
+func (s *sampler) next() int {
+	if s.cum == nil {
+		return s.r.Intn(s.rows)
+	}
+	u := s.r.Float64() * s.cum[s.rows-1]
+	// samples of zero weight are never picked
+	return sort.Search(s.rows, func(i int) bool { return s.cum[i] > u })
+}
+
+// checkWeights returns error if training configuration tc has sample weights and their
+// number is different from the number of data rows
+func checkWeights(tc *TrainConfig, rows int) error {
+	if tc.Weights != nil && len(tc.Weights) != rows {
+		return fmt.Errorf("invalid number of sample weights: %d, data has %d rows", len(tc.Weights), rows)
+	}
+	return nil
+}
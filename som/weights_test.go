@@ -0,0 +1,104 @@
+package som
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampler(t *testing.T) {
+	assert := assert.New(t)
+
+	s := newSampler(rand.New(rand.NewSource(1)), 4, []float64{0.0, 1.0, 0.0, 3.0})
+	counts := make([]int, 4)
+	for i := 0; i < 4000; i++ {
+		counts[s.next()]++
+	}
+	assert.Equal(0, counts[0])
+	assert.Equal(0, counts[2])
+	assert.InDelta(3.0, float64(counts[3])/float64(counts[1]), 0.3)
+	// unweighted samples are picked in the same way as by rand.Intn
+	s = newSampler(rand.New(rand.NewSource(1)), 4, nil)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		assert.Equal(r.Intn(4), s.next())
+	}
+}
+
+func TestTrainWeights(t *testing.T) {
+	assert := assert.New(t)
+
+	// batch training with counts is equivalent to training with repeated samples
+	data := unitBlobs(100, 1)
+	rows, cols := data.Dims()
+	weights := make([]float64, rows)
+	var repeated []float64
+	for i := range weights {
+		weights[i] = float64(1 + i%3)
+		for k := 0; k < 1+i%3; k++ {
+			repeated = append(repeated, data.RawRowView(i)...)
+		}
+	}
+	expanded := mat64.NewDense(len(repeated)/cols, cols, repeated)
+	mCfg := &MapConfig{
+		Grid: &GridConfig{Size: []int{4, 3}, Type: Planar, UShape: Hexagon},
+		Cb:   &CbConfig{Dim: 2, InitFunc: LinInit},
+	}
+	tc := &TrainConfig{Algorithm: Batch, Radius: 2.0, RDecay: ExpDecay, NeighbFn: Gaussian, LRate: 0.1, LDecay: ExpDecay, Workers: 1}
+	ref, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	assert.NoError(ref.Train(tc, expanded, 10))
+	m, err := NewMap(mCfg, data)
+	assert.NoError(err)
+	tc.Weights = weights
+	assert.NoError(m.Train(tc, data, 10))
+	assert.True(mat64.EqualApprox(ref.Codebook(), m.Codebook(), 1e-9))
+
+	// seq training never picks samples of zero weight
+	outliers := mat64.NewDense(2*rows, cols, nil)
+	weights = make([]float64, 2*rows)
+	for i := 0; i < rows; i++ {
+		outliers.SetRow(i, data.RawRowView(i))
+		outliers.SetRow(rows+i, []float64{100.0, 100.0})
+		weights[i] = 1.0
+	}
+	seq := gsomTrainConfig()
+	seq.Weights = weights
+	m, err = NewMap(mCfg, data)
+	assert.NoError(err)
+	_, err = m.train(seq, outliers, 1000, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	assert.True(mat64.Max(m.CodebookRaw()) < 10.0)
+
+	// invalid weights
+	for _, c := range []struct {
+		alg     Method
+		rule    string
+		weights []float64
+		err     string
+	}{
+		{Seq, "", []float64{1.0}, "invalid number of sample weights: 1, data has 100 rows"},
+		{Seq, "", append(make([]float64, 99), -1.0), "invalid sample weight 99: -1.000000"},
+		{Batch, "", make([]float64, 100), "invalid sample weights: no positive weight"},
+	} {
+		tc := gsomTrainConfig()
+		tc.Algorithm, tc.UpdateRule, tc.Weights = c.alg, c.rule, c.weights
+		_, err := m.TrainWithResult(tc, data, 10)
+		assert.EqualError(err, c.err)
+	}
+	for _, c := range []struct {
+		alg  Method
+		rule string
+	}{
+		{NeuralGas, ""},
+		{Batch, "median"},
+	} {
+		tc := gsomTrainConfig()
+		tc.Algorithm, tc.UpdateRule, tc.Weights = c.alg, c.rule, make([]float64, 100)
+		_, err := m.TrainWithResult(tc, data, 10)
+		assert.True(errors.Is(err, ErrUnsupportedMethod))
+	}
+}
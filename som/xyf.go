@@ -0,0 +1,156 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// TrainXYF creates new supervised XYF map with grid and codebook configured by mc and trains it
+// on labeled data using training configuration tc. Every unit has X codebook vector of data features
+// and Y codebook vector of class probabilities. Training BMU of a sample minimizes weighted sum
+// (1-xyWeight)*|x-X|^2 + xyWeight*|y-Y|^2 of squared distances in both spaces, where y is one-hot
+// encoded sample label, and both codebook vectors of the BMU and its neighbours are updated.
+// Features should be scaled to comparable range because class distances are at most 2.
+// The returned map codebook holds the X codebook vectors: BMU search of new samples uses features only.
+// Every unit is labeled by its most probable class and its label purity is the class probability,
+// so the map can be used with Classify. ClassDistributions returns the Y codebook and ClassProbs
+// the Y codebook vector of the BMU of a new sample.
+// TrainXYF returns error if data is nil, if the number of labels is different from the number
+// of data rows, if any label is empty, if xyWeight is not at least 0 and less than 1, if mc codebook
+// dimension is different from data dimension, or if mc or tc are invalid or the training fails.
+func TrainXYF(data *mat64.Dense, labels []string, xyWeight float64, mc *MapConfig, tc *TrainConfig, iters int) (*Map, error) {
+	return trainXYF(data, labels, xyWeight, mc, tc, iters, trainRand(tc))
+}
+
+// trainXYF trains new XYF map using r as the source of randomness of training
+func trainXYF(data *mat64.Dense, labels []string, xyWeight float64, mc *MapConfig, tc *TrainConfig, iters int, r *rand.Rand) (*Map, error) {
+	// data can't be nil
+	if data == nil {
+		return nil, fmt.Errorf("invalid data supplied: %v", data)
+	}
+	rows, cols := data.Dims()
+	if len(labels) != rows {
+		return nil, fmt.Errorf("invalid number of labels: %d", len(labels))
+	}
+	classIdx := make(map[string]int)
+	for i, label := range labels {
+		if label == "" {
+			return nil, fmt.Errorf("invalid label of data row %d: empty label", i)
+		}
+		classIdx[label] = 0
+	}
+	if !(xyWeight >= 0 && xyWeight < 1) {
+		return nil, fmt.Errorf("invalid xy weight: %f, must be at least 0 and less than 1", xyWeight)
+	}
+	if err := validateMapConfig(mc); err != nil {
+		return nil, err
+	}
+	if mc.Cb.Dim != cols {
+		return nil, ErrDimMismatch
+	}
+	classes := sortedKeys(classIdx)
+	for i, class := range classes {
+		classIdx[class] = i
+	}
+	// training data join scaled features and scaled one-hot encoded labels
+	xs, ys := math.Sqrt(1-xyWeight), math.Sqrt(xyWeight)
+	joined := mat64.NewDense(rows, cols+len(classes), nil)
+	for i := 0; i < rows; i++ {
+		row := joined.RawRowView(i)
+		for j, v := range data.RawRowView(i) {
+			row[j] = xs * v
+		}
+		row[cols+classIdx[labels[i]]] = ys
+	}
+	cb := *mc.Cb
+	cb.Dim = cols + len(classes)
+	xyf, err := NewMap(&MapConfig{Grid: mc.Grid, Cb: &cb}, joined)
+	if err != nil {
+		return nil, err
+	}
+	res, err := xyf.train(tc, joined, iters, r)
+	if err != nil {
+		return nil, err
+	}
+	// split the trained codebook into X and Y codebooks
+	units, _ := xyf.cbDims()
+	trained := xyf.cb()
+	x := mat64.NewDense(units, cols, nil)
+	y := mat64.NewDense(units, len(classes), nil)
+	m := &Map{
+		grid:       xyf.grid,
+		labels:     make([]string, units),
+		purity:     make([]float64, units),
+		lastTrain:  res,
+		noTopology: xyf.noTopology,
+		classes:    classes,
+	}
+	for i := 0; i < units; i++ {
+		row := trained.RawRowView(i)
+		for j := 0; j < cols; j++ {
+			x.Set(i, j, row[j]/xs)
+		}
+		m.labels[i], m.purity[i] = classes[0], 0.0
+		probs := y.RawRowView(i)
+		classProbs(probs, row[cols:])
+		for k, p := range probs {
+			if p > m.purity[i] {
+				m.labels[i], m.purity[i] = classes[k], p
+			}
+		}
+	}
+	m.classDist = y
+	if cb.Precision == "float32" {
+		m.cb32 = newCodebook32(x)
+	} else {
+		m.codebook = x
+	}
+	m.norms = m.cbNorms()
+	return m, nil
+}
+
+// classProbs stores class probabilities of Y codebook vector y in dst: negative values are
+// clipped to zero and the values are normalized to sum to one. If all values are zero,
+// every class receives the same probability.
+func classProbs(dst, y []float64) {
+	sum := 0.0
+	for i, v := range y {
+		dst[i] = math.Max(v, 0.0)
+		sum += dst[i]
+	}
+	for i := range dst {
+		if sum > 0 {
+			dst[i] /= sum
+		} else {
+			dst[i] = 1.0 / float64(len(dst))
+		}
+	}
+}
+
+// ClassDistributions returns the classes of XYF map sorted lexicographically and a copy of
+// the matrix of unit class distributions: one row per map unit and one column per class.
+// It returns nil slice and matrix if the map was not created by TrainXYF.
+func (m Map) ClassDistributions() ([]string, *mat64.Dense) {
+	if m.classDist == nil {
+		return nil, nil
+	}
+	return append([]string(nil), m.classes...), mat64.DenseCopyOf(m.classDist)
+}
+
+// ClassProbs returns class probabilities of vector x, which are the class distribution of its BMU,
+// in the order of classes returned by ClassDistributions.
+// It returns error if the map was not created by TrainXYF or ErrDimMismatch if the dimension
+// of x is different from the map codebook dimension.
+func (m Map) ClassProbs(x []float64) ([]float64, error) {
+	if m.classDist == nil {
+		return nil, fmt.Errorf("invalid map: no class distributions")
+	}
+	bmu, _, err := m.BMU(x)
+	if err != nil {
+		return nil, err
+	}
+	return mat64.Row(nil, bmu, m.classDist), nil
+}
@@ -0,0 +1,188 @@
+package som
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+// xyfConfigs returns map and training configuration of XYF tests
+func xyfConfigs() (*MapConfig, *TrainConfig) {
+	mc := &MapConfig{
+		Grid: &GridConfig{
+			Size:   []int{4, 4},
+			Type:   Planar,
+			UShape: Hexagon,
+		},
+		Cb: &CbConfig{
+			Dim:      2,
+			InitFunc: LinInit,
+		},
+	}
+	tc := &TrainConfig{
+		Algorithm: Seq,
+		Radius:    2.0,
+		RDecay:    ExpDecay,
+		NeighbFn:  Gaussian,
+		LRate:     0.5,
+		LDecay:    ExpDecay,
+	}
+	return mc, tc
+}
+
+// makeStripes generates n samples of two classes which differ in the second feature only:
+// the first feature is uniformly distributed noise with much larger variance
+func makeStripes(n int, seed int64) (*mat64.Dense, []string) {
+	r := rand.New(rand.NewSource(seed))
+	data := mat64.NewDense(n, 2, nil)
+	labels := make([]string, n)
+	for i := 0; i < n; i++ {
+		labels[i] = "a"
+		y := -0.5
+		if i%2 == 1 {
+			labels[i], y = "b", 0.5
+		}
+		data.Set(i, 0, 6*r.Float64()-3)
+		data.Set(i, 1, y+r.NormFloat64()*0.1)
+	}
+	return data, labels
+}
+
+func TestTrainXYFSeparation(t *testing.T) {
+	assert := assert.New(t)
+
+	mc, tc := xyfConfigs()
+	somSum, xyfSum := 0.0, 0.0
+	for seed := int64(1); seed < 6; seed++ {
+		data, labels := makeStripes(400, seed)
+		test, truth := makeStripes(400, seed+100)
+		// unsupervised map labeled after training
+		m, err := NewMap(mc, data)
+		assert.NoError(err)
+		_, err = m.train(tc, data, 4000, rand.New(rand.NewSource(seed)))
+		assert.NoError(err)
+		assert.NoError(m.LabelUnits(data, labels))
+		somAcc := accuracy(t, m, test, truth)
+		xyf, err := trainXYF(data, labels, 0.5, mc, tc, 4000, rand.New(rand.NewSource(seed)))
+		assert.NoError(err)
+		xyfAcc := accuracy(t, xyf, test, truth)
+		assert.True(xyfAcc >= somAcc, "seed %d: xyf accuracy %f, som accuracy %f", seed, xyfAcc, somAcc)
+		somSum += somAcc
+		xyfSum += xyfAcc
+	}
+	assert.True(xyfSum/5 > 0.98, "xyf accuracy: %f", xyfSum/5)
+	assert.True(xyfSum > somSum+0.2, "xyf accuracy: %f, som accuracy: %f", xyfSum/5, somSum/5)
+}
+
+func TestClassDistributions(t *testing.T) {
+	assert := assert.New(t)
+
+	data, labels := makeStripes(200, 1)
+	mc, tc := xyfConfigs()
+	m, err := NewMap(mc, data)
+	assert.NoError(err)
+	classes, dist := m.ClassDistributions()
+	assert.Nil(classes)
+	assert.Nil(dist)
+
+	m, err = TrainXYF(data, labels, 0.5, mc, tc, 2000)
+	assert.NoError(err)
+	units, dim := m.CodebookRaw().Dims()
+	assert.Equal(16, units)
+	assert.Equal(2, dim)
+	classes, dist = m.ClassDistributions()
+	assert.Equal([]string{"a", "b"}, classes)
+	rows, cols := dist.Dims()
+	assert.Equal(16, rows)
+	assert.Equal(2, cols)
+	for i := 0; i < rows; i++ {
+		a, b := dist.At(i, 0), dist.At(i, 1)
+		assert.InDelta(1.0, a+b, 1e-12)
+		assert.True(a >= 0 && b >= 0)
+		if a >= b {
+			assert.Equal("a", m.UnitLabels()[i])
+			assert.Equal(a, m.purity[i])
+		} else {
+			assert.Equal("b", m.UnitLabels()[i])
+			assert.Equal(b, m.purity[i])
+		}
+	}
+	// returned distributions are copies
+	dist.Set(0, 0, 10.0)
+	_, dist = m.ClassDistributions()
+	assert.NotEqual(10.0, dist.At(0, 0))
+	label, _, err := m.Classify([]float64{0.0, 0.5})
+	assert.NoError(err)
+	assert.Equal("b", label)
+
+	probs := make([]float64, 3)
+	classProbs(probs, []float64{-0.5, 1.0, 3.0})
+	assert.Equal([]float64{0.0, 0.25, 0.75}, probs)
+	classProbs(probs, []float64{-0.5, 0.0, 0.0})
+	assert.InDeltaSlice([]float64{1.0 / 3, 1.0 / 3, 1.0 / 3}, probs, 1e-12)
+}
+
+func TestClassProbs(t *testing.T) {
+	assert := assert.New(t)
+
+	data, labels := makeStripes(200, 1)
+	mc, tc := xyfConfigs()
+	m, err := NewMap(mc, data)
+	assert.NoError(err)
+	_, err = m.ClassProbs([]float64{0.0, 0.5})
+	assert.EqualError(err, "invalid map: no class distributions")
+
+	m, err = trainXYF(data, labels, 0.5, mc, tc, 2000, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	_, dist := m.ClassDistributions()
+	for _, x := range [][]float64{{0.0, 0.5}, {-2.0, -0.5}} {
+		probs, err := m.ClassProbs(x)
+		assert.NoError(err)
+		bmu, _, err := m.BMU(x)
+		assert.NoError(err)
+		assert.Equal(mat64.Row(nil, bmu, dist), probs)
+		// the most probable class is the classified label
+		label, conf, err := m.Classify(x)
+		assert.NoError(err)
+		if probs[0] >= probs[1] {
+			assert.Equal("a", label)
+		} else {
+			assert.Equal("b", label)
+		}
+		assert.Equal(math.Max(probs[0], probs[1]), conf)
+	}
+	probs, err := m.ClassProbs([]float64{0.0, 0.5})
+	assert.NoError(err)
+	assert.True(probs[1] > 0.9)
+	_, err = m.ClassProbs([]float64{1.0})
+	assert.Equal(ErrDimMismatch, err)
+}
+
+func TestTrainXYFErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	data, labels := makeStripes(20, 1)
+	mc, tc := xyfConfigs()
+	_, err := TrainXYF(nil, labels, 0.5, mc, tc, 100)
+	assert.EqualError(err, fmt.Sprintf("invalid data supplied: %v", nil))
+	_, err = TrainXYF(data, labels[:3], 0.5, mc, tc, 100)
+	assert.EqualError(err, "invalid number of labels: 3")
+	empty := append([]string(nil), labels...)
+	empty[4] = ""
+	_, err = TrainXYF(data, empty, 0.5, mc, tc, 100)
+	assert.EqualError(err, "invalid label of data row 4: empty label")
+	for _, w := range []float64{-0.1, 1.0} {
+		_, err = TrainXYF(data, labels, w, mc, tc, 100)
+		assert.EqualError(err, fmt.Sprintf("invalid xy weight: %f, must be at least 0 and less than 1", w))
+	}
+	_, err = TrainXYF(data, labels, 0.5, nil, tc, 100)
+	assert.Error(err)
+	_, err = TrainXYF(mat64.NewDense(20, 3, nil), labels, 0.5, mc, tc, 100)
+	assert.Equal(ErrDimMismatch, err)
+	_, err = TrainXYF(data, labels, 0.5, mc, tc, 0)
+	assert.EqualError(err, "invalid number of iterations: 0")
+}
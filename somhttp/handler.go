@@ -0,0 +1,426 @@
+// Package somhttp serves predictions of a trained Self-Organizing Map over HTTP.
+//
+// The handler returned by NewHandler provides the following endpoints:
+//
+//	POST /bmu         Best Match Units of JSON array or NDJSON stream of vectors
+//	GET  /umatrix     u-matrix as JSON or PNG image depending on Accept header
+//	GET  /model/meta  map grid and codebook description
+//	GET  /codebook    map grid and codebook vectors encoded by Map.MarshalJSON
+//	GET  /hits        hit counts of data set by WithHitData
+//
+// Monitor serves the same endpoints and a web page which draws them for a map which is being trained.
+package somhttp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/som"
+)
+
+const (
+	// DefaultMaxBodyBytes is the default limit of JSON request body size
+	DefaultMaxBodyBytes = 32 << 20
+	// DefaultPixelSize is the default size of map unit in u-matrix PNG images
+	DefaultPixelSize = 10
+	// ndjsonFlushRows is the number of NDJSON results written between flushes
+	ndjsonFlushRows = 256
+)
+
+// handler serves a trained map
+type handler struct {
+	// mu guards the served map and training progress replaced by Monitor
+	mu sync.RWMutex
+	// m is the served map
+	m *som.Map
+	// progress holds the training progress of the served map; it is nil if the map is not trained
+	progress *Progress
+	// data holds data rows whose hits are served by /hits endpoint
+	data mat64.Matrix
+	// maxBodyBytes limits JSON request body size
+	maxBodyBytes int64
+	// pixelSize is the size of map unit in u-matrix PNG images
+	pixelSize int
+	// mux routes requests to endpoints
+	mux *http.ServeMux
+}
+
+// Option configures handler created by NewHandler
+type Option func(*handler)
+
+// WithMaxBodyBytes limits the size of JSON array request body to n bytes.
+// NDJSON streams are not limited. If n is not positive, DefaultMaxBodyBytes is used.
+func WithMaxBodyBytes(n int64) Option {
+	return func(h *handler) {
+		if n > 0 {
+			h.maxBodyBytes = n
+		}
+	}
+}
+
+// WithPixelSize sets the size of map unit in u-matrix PNG images to px pixels.
+// If px is not positive, DefaultPixelSize is used.
+func WithPixelSize(px int) Option {
+	return func(h *handler) {
+		if px > 0 {
+			h.pixelSize = px
+		}
+	}
+}
+
+// WithHitData sets data whose hit counts are served by /hits endpoint.
+// Data rows are scaled by the map scaler in the same way as by Map.HitMap.
+func WithHitData(data mat64.Matrix) Option {
+	return func(h *handler) {
+		h.data = data
+	}
+}
+
+// BMUResult is Best Match Unit of a vector returned by /bmu endpoint
+type BMUResult struct {
+	// Unit is BMU index
+	Unit int `json:"unit"`
+	// Coords holds BMU grid coordinates
+	Coords []float64 `json:"coords"`
+	// Distance is the distance between the vector scaled by the map scaler and BMU codebook vector
+	Distance float64 `json:"distance"`
+}
+
+// UMatrix is u-matrix returned by /umatrix endpoint
+type UMatrix struct {
+	// Dims holds map grid dimensions
+	Dims []int `json:"dims"`
+	// UShape is map unit shape
+	UShape string `json:"ushape"`
+	// Values holds u-matrix value of every map unit
+	Values []float64 `json:"values"`
+}
+
+// Meta describes the served map and is returned by /model/meta endpoint
+type Meta struct {
+	// Dims holds map grid dimensions
+	Dims []int `json:"dims"`
+	// Grid is map grid type
+	Grid string `json:"grid"`
+	// UShape is map unit shape
+	UShape string `json:"ushape"`
+	// Units is the number of map units
+	Units int `json:"units"`
+	// Dim is codebook vector dimension
+	Dim int `json:"dim"`
+	// Frozen is true if BMU search uses KD-tree
+	Frozen bool `json:"frozen"`
+	// Training holds the progress of the map training served by Monitor
+	Training *Progress `json:"training,omitempty"`
+}
+
+// Hits holds hit counts returned by /hits endpoint
+type Hits struct {
+	// Dims holds map grid dimensions
+	Dims []int `json:"dims"`
+	// UShape is map unit shape
+	UShape string `json:"ushape"`
+	// Counts holds the number of data rows mapped to every map unit
+	Counts []int `json:"counts"`
+}
+
+// errorResponse is JSON body of error responses
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewHandler returns http.Handler which serves predictions of map m.
+// The handler serves a frozen copy of m to speed up BMU search; m itself is not frozen.
+// The copy shares codebook with m and the handler only reads it, so it is safe for
+// concurrent use as long as m is not modified, e.g. trained, while it is served.
+func NewHandler(m *som.Map, opts ...Option) http.Handler {
+	frozen := *m
+	frozen.Freeze()
+	return newHandler(&frozen, opts...)
+}
+
+// newHandler returns handler which serves map m
+func newHandler(m *som.Map, opts ...Option) *handler {
+	h := &handler{
+		m:            m,
+		maxBodyBytes: DefaultMaxBodyBytes,
+		pixelSize:    DefaultPixelSize,
+		mux:          http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.mux.HandleFunc("/bmu", h.bmu)
+	h.mux.HandleFunc("/umatrix", h.umatrix)
+	h.mux.HandleFunc("/model/meta", h.meta)
+	h.mux.HandleFunc("/codebook", h.codebook)
+	h.mux.HandleFunc("/hits", h.hits)
+	return h
+}
+
+// current returns the served map and its training progress
+func (h *handler) current() (*som.Map, *Progress) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.m, h.progress
+}
+
+// set replaces the served map and its training progress
+func (h *handler) set(m *som.Map, p *Progress) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.m, h.progress = m, p
+}
+
+// ServeHTTP routes requests to endpoints
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// writeJSON writes v encoded as JSON with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// allow writes 405 response and returns false if request method is not method
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed: %s", r.Method))
+		return false
+	}
+	return true
+}
+
+// result returns BMU result in map m of vector x stored in row-th request row.
+// Vectors are in the original feature units, so x is scaled by the map scaler first.
+func result(m *som.Map, row int, x []float64) (*BMUResult, error) {
+	x, err := m.ScaleSample(x)
+	if err != nil {
+		return nil, fmt.Errorf("row %d: %w", row, err)
+	}
+	bmu, dist, err := m.BMU(x)
+	if err != nil {
+		return nil, fmt.Errorf("row %d: %w", row, err)
+	}
+	return &BMUResult{Unit: bmu, Coords: m.UnitCoords(bmu), Distance: dist}, nil
+}
+
+// bmu serves BMUs of vectors in request body. JSON array requests receive JSON array of results.
+// NDJSON requests, which have application/x-ndjson content type, are processed as a stream and
+// receive one result per line. Invalid vectors fail the request with 400 status; if the NDJSON
+// response has already started, an error object is written as the last line instead.
+func (h *handler) bmu(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-ndjson" {
+		h.bmuStream(w, r)
+		return
+	}
+	var vecs [][]float64
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxBodyBytes)).Decode(&vecs); err != nil {
+		status := http.StatusBadRequest
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, fmt.Errorf("invalid request body: %s", err))
+		return
+	}
+	m, _ := h.current()
+	results := make([]*BMUResult, len(vecs))
+	for i, x := range vecs {
+		res, err := result(m, i, x)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		results[i] = res
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// bmuStream serves BMUs of NDJSON stream of vectors
+func (h *handler) bmuStream(w http.ResponseWriter, r *http.Request) {
+	m, _ := h.current()
+	dec := json.NewDecoder(r.Body)
+	var bw *bufio.Writer
+	var enc *json.Encoder
+	flusher, _ := w.(http.Flusher)
+	fail := func(err error) {
+		// nothing has been written yet: report the error in status code
+		if bw == nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		enc.Encode(errorResponse{Error: err.Error()})
+		bw.Flush()
+	}
+	for row := 0; ; row++ {
+		var x []float64
+		if err := dec.Decode(&x); err != nil {
+			if err == io.EOF {
+				break
+			}
+			fail(fmt.Errorf("row %d: invalid vector: %s", row, err))
+			return
+		}
+		res, err := result(m, row, x)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if bw == nil {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			bw = bufio.NewWriter(w)
+			enc = json.NewEncoder(bw)
+		}
+		if err := enc.Encode(res); err != nil {
+			return
+		}
+		if (row+1)%ndjsonFlushRows == 0 {
+			if bw.Flush() != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+	if bw == nil {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	bw.Flush()
+}
+
+// umatrix serves map u-matrix as PNG image if the request accepts image/png or as JSON otherwise
+func (h *handler) umatrix(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	m, _ := h.current()
+	values, err := m.UMatrixValues()
+	if err != nil {
+		status := http.StatusInternalServerError
+		// the map can't have u-matrix
+		if errors.Is(err, som.ErrNoTopology) {
+			status = http.StatusConflict
+		}
+		writeError(w, status, err)
+		return
+	}
+	if strings.Contains(r.Header.Get("Accept"), "image/png") {
+		w.Header().Set("Content-Type", "image/png")
+		png.Encode(w, h.umatrixImage(m, values))
+		return
+	}
+	grid := m.Grid()
+	writeJSON(w, http.StatusOK, UMatrix{Dims: grid.Size(), UShape: grid.UShape(), Values: values})
+}
+
+// umatrixImage renders u-matrix values of map m as grayscale image in which every unit is a square
+// placed at the unit grid coordinates. Units which are far from their neighbours are dark.
+func (h *handler) umatrixImage(m *som.Map, values []float64) *image.Gray {
+	coords := m.Grid().Coords()
+	px := float64(h.pixelSize)
+	min, max := math.Inf(1), math.Inf(-1)
+	width, height := 0, 0
+	for i, v := range values {
+		min, max = math.Min(min, v), math.Max(max, v)
+		width = int(math.Max(float64(width), coords.At(i, 0)*px+px))
+		height = int(math.Max(float64(height), coords.At(i, 1)*px+px))
+	}
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i, v := range values {
+		shade := 1.0
+		if max > min {
+			shade = 1.0 - (v-min)/(max-min)
+		}
+		c := color.Gray{Y: uint8(255 * shade)}
+		x0, y0 := int(coords.At(i, 0)*px), int(coords.At(i, 1)*px)
+		for y := y0; y < y0+h.pixelSize; y++ {
+			for x := x0; x < x0+h.pixelSize; x++ {
+				img.SetGray(x, y, c)
+			}
+		}
+	}
+	return img
+}
+
+// meta serves map description
+func (h *handler) meta(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	m, p := h.current()
+	grid := m.Grid()
+	units, dim := m.CodebookRaw().Dims()
+	writeJSON(w, http.StatusOK, Meta{
+		Dims:     grid.Size(),
+		Grid:     grid.Type(),
+		UShape:   grid.UShape(),
+		Units:    units,
+		Dim:      dim,
+		Frozen:   m.Frozen(),
+		Training: p,
+	})
+}
+
+// codebook serves map grid and codebook vectors
+func (h *handler) codebook(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	m, _ := h.current()
+	body, err := m.MarshalJSON()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// hits serves hit counts of data set by WithHitData
+func (h *handler) hits(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	if h.data == nil {
+		writeError(w, http.StatusNotFound, errors.New("no hit data: set it by WithHitData"))
+		return
+	}
+	m, _ := h.current()
+	counts, err := m.HitMap(h.data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	grid := m.Grid()
+	writeJSON(w, http.StatusOK, Hits{Dims: grid.Size(), UShape: grid.UShape(), Counts: counts})
+}
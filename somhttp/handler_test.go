@@ -0,0 +1,301 @@
+package somhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/som"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestMap returns 2x2 rectangle map with 2D codebook holding the unit coordinates
+func newTestMap(t *testing.T) *som.Map {
+	cb := mat64.NewDense(4, 2, []float64{0, 0, 0, 1, 1, 0, 1, 1})
+	c := &som.MapConfig{
+		Grid: &som.GridConfig{
+			Size:   []int{2, 2},
+			Type:   "planar",
+			UShape: "rectangle",
+		},
+		Cb: &som.CbConfig{
+			Dim: 2,
+			InitFunc: func(*mat64.Dense, []int) (*mat64.Dense, error) {
+				return mat64.DenseCopyOf(cb), nil
+			},
+		},
+	}
+	m, err := som.NewMap(c, cb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestBMU(t *testing.T) {
+	assert := assert.New(t)
+
+	h := NewHandler(newTestMap(t))
+	req := httptest.NewRequest(http.MethodPost, "/bmu", strings.NewReader("[[0.1,0.9],[1,0]]"))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal("application/json", rec.Header().Get("Content-Type"))
+	var res []BMUResult
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Len(res, 2)
+	assert.Equal(1, res[0].Unit)
+	assert.Equal([]float64{0, 1}, res[0].Coords)
+	assert.InDelta(0.1414, res[0].Distance, 0.0001)
+	assert.Equal(2, res[1].Unit)
+	assert.Equal(0.0, res[1].Distance)
+
+	testCases := []struct {
+		method string
+		body   string
+		status int
+		msg    string
+	}{
+		{http.MethodPost, "[[0,0],[1,2,3]]", http.StatusBadRequest, "row 1: " + som.ErrDimMismatch.Error()},
+		{http.MethodPost, "[[0,0]", http.StatusBadRequest, "invalid request body"},
+		{http.MethodPost, "[[" + strings.Repeat("0,", 100) + "0]]", http.StatusRequestEntityTooLarge, "invalid request body"},
+		{http.MethodGet, "", http.StatusMethodNotAllowed, "method not allowed: GET"},
+	}
+
+	h = NewHandler(newTestMap(t), WithMaxBodyBytes(64))
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, "/bmu", strings.NewReader(tc.body))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(tc.status, rec.Code, tc.body)
+		var e errorResponse
+		assert.NoError(json.Unmarshal(rec.Body.Bytes(), &e))
+		assert.Contains(e.Error, tc.msg)
+	}
+}
+
+func TestBMUScaled(t *testing.T) {
+	assert := assert.New(t)
+
+	// vectors in original units are scaled before BMU search as by Predict
+	m := newTestMap(t)
+	assert.NoError(m.SetScaler(&som.Scaler{Method: som.ZScore, Center: []float64{10, 100}, Scale: []float64{2, 50}}))
+	data := mat64.NewDense(3, 2, []float64{10.2, 148, 12, 100, 11.5, 140})
+	bmus, dists, err := m.Predict(data, 1)
+	assert.NoError(err)
+	h := NewHandler(m)
+	req := httptest.NewRequest(http.MethodPost, "/bmu", strings.NewReader("[[10.2,148],[12,100],[11.5,140]]"))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	var res []BMUResult
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Len(res, 3)
+	for i := range res {
+		assert.Equal(bmus[i], res[i].Unit)
+		assert.InDelta(dists[i], res[i].Distance, 1e-9)
+	}
+	assert.Equal([]int{1, 2, 3}, bmus)
+}
+
+func TestBMUStream(t *testing.T) {
+	assert := assert.New(t)
+
+	h := NewHandler(newTestMap(t))
+	testCases := []struct {
+		body   string
+		status int
+		lines  []string
+	}{
+		{"[0,0]\n[1,1]\n", http.StatusOK, []string{
+			`{"unit":0,"coords":[0,0],"distance":0}`,
+			`{"unit":3,"coords":[1,1],"distance":0}`,
+		}},
+		{"", http.StatusOK, nil},
+		{"[0,0,0]\n[1,1]\n", http.StatusBadRequest, []string{
+			`{"error":"row 0: ` + som.ErrDimMismatch.Error() + `"}`,
+		}},
+		{"[0,0]\n[1]\n[1,1]\n", http.StatusOK, []string{
+			`{"unit":0,"coords":[0,0],"distance":0}`,
+			`{"error":"row 1: ` + som.ErrDimMismatch.Error() + `"}`,
+		}},
+		{"[0,0]\nfoo\n", http.StatusOK, nil},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodPost, "/bmu", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(tc.status, rec.Code, tc.body)
+		if tc.lines == nil && tc.body != "" {
+			// malformed line: the last line reports the error
+			lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+			assert.Len(lines, 2)
+			assert.Contains(lines[1], "row 1: invalid vector")
+			continue
+		}
+		assert.Equal(strings.Join(tc.lines, "\n"), strings.TrimSpace(rec.Body.String()), tc.body)
+	}
+
+	// stream longer than a flush batch
+	var body bytes.Buffer
+	for i := 0; i < 2*ndjsonFlushRows+1; i++ {
+		body.WriteString("[1,0]\n")
+	}
+	req := httptest.NewRequest(http.MethodPost, "/bmu", &body)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal(2*ndjsonFlushRows+1, strings.Count(rec.Body.String(), `{"unit":2,`))
+	assert.True(rec.Flushed)
+}
+
+func TestUMatrix(t *testing.T) {
+	assert := assert.New(t)
+
+	h := NewHandler(newTestMap(t), WithPixelSize(5))
+	req := httptest.NewRequest(http.MethodGet, "/umatrix", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	var u UMatrix
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &u))
+	assert.Equal([]int{2, 2}, u.Dims)
+	assert.Equal("rectangle", u.UShape)
+	assert.Len(u.Values, 4)
+
+	req = httptest.NewRequest(http.MethodGet, "/umatrix", nil)
+	req.Header.Set("Accept", "image/png")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal("image/png", rec.Header().Get("Content-Type"))
+	img, err := png.Decode(rec.Body)
+	assert.NoError(err)
+	assert.Equal(10, img.Bounds().Dx())
+	assert.Equal(10, img.Bounds().Dy())
+
+	req = httptest.NewRequest(http.MethodPost, "/umatrix", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(http.StatusMethodNotAllowed, rec.Code)
+
+	// neural gas map has no u-matrix
+	m := newTestMap(t)
+	tc := &som.TrainConfig{
+		Algorithm: som.NeuralGas,
+		Radius:    1.0,
+		RDecay:    som.ExpDecay,
+		NeighbFn:  som.Gaussian,
+		LRate:     0.5,
+		LDecay:    som.ExpDecay,
+	}
+	assert.NoError(m.Train(tc, mat64.NewDense(2, 2, []float64{0, 0, 1, 1}), 10))
+	req = httptest.NewRequest(http.MethodGet, "/umatrix", nil)
+	rec = httptest.NewRecorder()
+	NewHandler(m).ServeHTTP(rec, req)
+	assert.Equal(http.StatusConflict, rec.Code)
+	assert.Contains(rec.Body.String(), "map has no topology")
+}
+
+func TestMeta(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newTestMap(t)
+	h := NewHandler(m)
+	// served map is frozen, but the supplied map is not
+	assert.False(m.Frozen())
+	req := httptest.NewRequest(http.MethodGet, "/model/meta", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	var meta Meta
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &meta))
+	assert.Equal(Meta{
+		Dims:   []int{2, 2},
+		Grid:   "planar",
+		UShape: "rectangle",
+		Units:  4,
+		Dim:    2,
+		Frozen: true,
+	}, meta)
+}
+
+func TestConcurrentBMU(t *testing.T) {
+	assert := assert.New(t)
+
+	h := NewHandler(newTestMap(t))
+	var wg sync.WaitGroup
+	codes := make([]int, 16)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/bmu", strings.NewReader("[[0.9,0.9],[0,0.2]]"))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			codes[i] = rec.Code
+		}(i)
+	}
+	wg.Wait()
+	for _, code := range codes {
+		assert.Equal(http.StatusOK, code)
+	}
+}
+
+func TestCodebook(t *testing.T) {
+	assert := assert.New(t)
+
+	h := NewHandler(newTestMap(t))
+	req := httptest.NewRequest(http.MethodGet, "/codebook", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal("application/json", rec.Header().Get("Content-Type"))
+	var cb struct {
+		Dims     []int       `json:"dims"`
+		Codebook [][]float64 `json:"codebook"`
+	}
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &cb))
+	assert.Equal([]int{2, 2}, cb.Dims)
+	assert.Equal([][]float64{{0, 0}, {0, 1}, {1, 0}, {1, 1}}, cb.Codebook)
+
+	req = httptest.NewRequest(http.MethodPost, "/codebook", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestHits(t *testing.T) {
+	assert := assert.New(t)
+
+	data := mat64.NewDense(3, 2, []float64{0.1, 0.1, 0, 0.2, 0.9, 0.9})
+	h := NewHandler(newTestMap(t), WithHitData(data))
+	req := httptest.NewRequest(http.MethodGet, "/hits", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	var hits Hits
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &hits))
+	assert.Equal(Hits{Dims: []int{2, 2}, UShape: "rectangle", Counts: []int{2, 0, 0, 1}}, hits)
+
+	// handler has no hit data
+	req = httptest.NewRequest(http.MethodGet, "/hits", nil)
+	rec = httptest.NewRecorder()
+	NewHandler(newTestMap(t)).ServeHTTP(rec, req)
+	assert.Equal(http.StatusNotFound, rec.Code)
+
+	// hit data dimension is different from the codebook dimension
+	req = httptest.NewRequest(http.MethodGet, "/hits", nil)
+	rec = httptest.NewRecorder()
+	NewHandler(newTestMap(t), WithHitData(mat64.NewDense(1, 3, nil))).ServeHTTP(rec, req)
+	assert.Equal(http.StatusInternalServerError, rec.Code)
+}
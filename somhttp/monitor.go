@@ -0,0 +1,93 @@
+package somhttp
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/milosgajdos83/gosom/som"
+)
+
+// indexPage is the web page served by Monitor which draws the map u-matrix and hit counts
+//
+//go:embed index.html
+var indexPage []byte
+
+// Progress is the training progress of the map served by Monitor
+type Progress struct {
+	// Epoch is the number of the last finished epoch starting from 0
+	Epoch int `json:"epoch"`
+	// Iters is the number of completed training iterations
+	Iters int `json:"iters"`
+	// Radius is the current units radius
+	Radius float64 `json:"radius"`
+	// LRate is the current learning rate
+	LRate float64 `json:"lrate"`
+	// QuantError is the epoch quantization error; it is nil if the training does not measure it
+	QuantError *float64 `json:"quant_error,omitempty"`
+}
+
+// Monitor serves a map which is being trained. Its OnEpoch function, which is set as TrainConfig.OnEpoch,
+// stores a copy of the map at the end of every training epoch and Monitor serves the latest copy on the same
+// endpoints as the handler returned by NewHandler, so the served map never changes while a request is served.
+// GET / serves a web page which periodically redraws the u-matrix and hit counts of the served map.
+type Monitor struct {
+	// h serves map copies
+	h *handler
+	// m is the trained map
+	m *som.Map
+}
+
+// NewMonitor returns Monitor of map m which serves a copy of m until the first training epoch ends
+func NewMonitor(m *som.Map, opts ...Option) *Monitor {
+	mon := &Monitor{h: newHandler(snapshot(m), opts...), m: m}
+	mon.h.mux.HandleFunc("/", mon.index)
+	return mon
+}
+
+// OnEpoch replaces the served map by a copy of the trained map and records the epoch progress.
+// It must be called by the training, which is not allowed to modify the map meanwhile, and it never stops
+// the training. Call Update when the training finishes to serve the trained map.
+func (mon *Monitor) OnEpoch(e som.Epoch) bool {
+	p := &Progress{Epoch: e.Epoch, Iters: e.Iters, Radius: e.Radius, LRate: e.LRate}
+	if !math.IsNaN(e.QuantError) {
+		qe := e.QuantError
+		p.QuantError = &qe
+	}
+	mon.h.set(snapshot(mon.m), p)
+	return true
+}
+
+// Update replaces the served map by a copy of the trained map and keeps the recorded progress.
+// It must not be called while the map is trained.
+func (mon *Monitor) Update() {
+	_, p := mon.h.current()
+	mon.h.set(snapshot(mon.m), p)
+}
+
+// ServeHTTP routes requests to endpoints
+func (mon *Monitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	mon.h.ServeHTTP(w, r)
+}
+
+// index serves the monitor web page
+func (mon *Monitor) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("not found: %s", r.URL.Path))
+		return
+	}
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(indexPage)
+}
+
+// snapshot returns frozen copy of map m
+func snapshot(m *som.Map) *som.Map {
+	c := m.Clone()
+	c.Freeze()
+	return c
+}
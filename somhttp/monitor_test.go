@@ -0,0 +1,109 @@
+package somhttp
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/gosom/som"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitor(t *testing.T) {
+	assert := assert.New(t)
+
+	rnd := rand.New(rand.NewSource(1))
+	data := mat64.NewDense(50, 2, nil)
+	for i := 0; i < 50; i++ {
+		data.Set(i, 0, rnd.Float64())
+		data.Set(i, 1, rnd.Float64())
+	}
+	m := newTestMap(t)
+	mon := NewMonitor(m, WithHitData(data))
+	// the map is served before the training starts
+	var meta Meta
+	rec := httptest.NewRecorder()
+	mon.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/model/meta", nil))
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &meta))
+	assert.Nil(meta.Training)
+
+	// requests are served while the map is trained
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			for _, path := range []string{"/codebook", "/umatrix", "/hits", "/model/meta"} {
+				rec := httptest.NewRecorder()
+				mon.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				if rec.Code != http.StatusOK {
+					t.Errorf("%s: unexpected status %d", path, rec.Code)
+				}
+			}
+		}
+	}()
+	tc := &som.TrainConfig{
+		Algorithm: som.Seq,
+		Radius:    1.0,
+		RDecay:    som.LinDecay,
+		NeighbFn:  som.Gaussian,
+		LRate:     0.5,
+		LDecay:    som.LinDecay,
+		OnEpoch:   mon.OnEpoch,
+	}
+	assert.NoError(m.Train(tc, data, 500))
+	close(done)
+	wg.Wait()
+
+	rec = httptest.NewRecorder()
+	mon.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/model/meta", nil))
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &meta))
+	assert.NotNil(meta.Training)
+	assert.Equal(500, meta.Training.Iters)
+	assert.NotNil(meta.Training.QuantError)
+	assert.True(meta.Frozen)
+
+	// the last epoch copy holds the trained codebook
+	mon.Update()
+	rec = httptest.NewRecorder()
+	mon.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/codebook", nil))
+	var cb struct {
+		Codebook [][]float64 `json:"codebook"`
+	}
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &cb))
+	assert.Equal(m.CodebookRaw().RawRowView(0), cb.Codebook[0])
+	rec = httptest.NewRecorder()
+	mon.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/model/meta", nil))
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &meta))
+	assert.Equal(500, meta.Training.Iters)
+}
+
+func TestMonitorIndex(t *testing.T) {
+	assert := assert.New(t)
+
+	mon := NewMonitor(newTestMap(t))
+	rec := httptest.NewRecorder()
+	mon.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal("text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(rec.Body.String(), "<canvas")
+
+	rec = httptest.NewRecorder()
+	mon.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	assert.Equal(http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	mon.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(http.StatusMethodNotAllowed, rec.Code)
+}